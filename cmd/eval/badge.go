@@ -0,0 +1,29 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/report"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/spf13/cobra"
+)
+
+// badgeRun implements 'eval badge': it writes a pass-rate badge for a run
+// result and prints the markdown that embeds it.
+func badgeRun(cmd *cobra.Command, args []string) error {
+	summary, err := result.LoadSummary(args[0])
+	if err != nil {
+		return fmt.Errorf("loading run results: %w", err)
+	}
+	outPath, _ := cmd.Flags().GetString("out")
+	label, _ := cmd.Flags().GetString("label")
+	link, _ := cmd.Flags().GetString("link")
+
+	if err := os.WriteFile(outPath, []byte(report.Badge(label, summary.Stats)), 0o644); err != nil {
+		return fmt.Errorf("writing badge: %w", err)
+	}
+	fmt.Fprintf(os.Stderr, "Wrote %s badge (%s) to %s\n", label, report.BadgeValue(summary.Stats), outPath)
+	fmt.Println(report.BadgeMarkdown(label, outPath, link))
+	return nil
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/bundle"
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// bundleRun implements 'eval bundle': it zips a run result with the suite,
+// prompts, and redacted config it was produced from, scrubbing API keys
+// from every file.
+func bundleRun(cmd *cobra.Command, args []string) error {
+	runPath := args[0]
+	runData, err := os.ReadFile(runPath)
+	if err != nil {
+		return fmt.Errorf("reading run results: %w", err)
+	}
+	summary, err := result.LoadSummary(runPath)
+	if err != nil {
+		return fmt.Errorf("loading run results: %w", err)
+	}
+	files := []bundle.File{{Name: "run.json", Data: runData}}
+
+	suitePath, _ := cmd.Flags().GetString("suite")
+	if suitePath == "" {
+		suiteDir, _ := cmd.Flags().GetString("suite-dir")
+		suitePath, err = findYAML(suiteDir, summary.SuiteName, func(path string) (string, error) {
+			s, err := suite.Load(path)
+			if err != nil {
+				return "", err
+			}
+			return s.Name, nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: suite not bundled: %v\n", err)
+		}
+	}
+	if suitePath != "" {
+		data, err := os.ReadFile(suitePath)
+		if err != nil {
+			return fmt.Errorf("reading suite: %w", err)
+		}
+		files = append(files, bundle.File{Name: "suites/" + filepath.Base(suitePath), Data: data})
+	}
+
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	seen := make(map[string]bool)
+	for _, cr := range summary.Results {
+		if cr.Prompt == "" || seen[cr.Prompt] {
+			continue
+		}
+		seen[cr.Prompt] = true
+		path, err := findYAML(promptDir, cr.Prompt, func(path string) (string, error) {
+			p, err := prompt.Load(path)
+			if err != nil {
+				return "", err
+			}
+			return p.Name, nil
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: prompt not bundled: %v\n", err)
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("reading prompt: %w", err)
+		}
+		files = append(files, bundle.File{Name: "prompts/" + filepath.Base(path), Data: data})
+	}
+
+	var secrets []string
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.Load(cfgPath)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		fmt.Fprintf(os.Stderr, "warning: config not bundled: %s does not exist\n", cfgPath)
+	case err != nil:
+		return fmt.Errorf("loading config: %w", err)
+	default:
+		data, err := yaml.Marshal(cfg.Redacted())
+		if err != nil {
+			return fmt.Errorf("encoding redacted config: %w", err)
+		}
+		files = append(files, bundle.File{Name: "eval.yaml", Data: data})
+		secrets = cfg.Secrets()
+	}
+
+	outPath, _ := cmd.Flags().GetString("output")
+	if outPath == "" {
+		outPath = strings.TrimSuffix(filepath.Base(runPath), filepath.Ext(runPath)) + ".bundle.zip"
+	}
+	f, err := os.Create(outPath)
+	if err != nil {
+		return fmt.Errorf("creating bundle: %w", err)
+	}
+	err = bundle.Write(f, files, secrets, time.Now())
+	if cerr := f.Close(); err == nil && cerr != nil {
+		err = fmt.Errorf("writing bundle: %w", cerr)
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		fmt.Printf("  %s\n", file.Name)
+	}
+	fmt.Printf("Bundle written to %s (%d files, API keys redacted)\n", outPath, len(files))
+	return nil
+}
+
+// findYAML returns the YAML file in dir whose name, as reported by nameOf,
+// is name. Files that fail to load are skipped.
+func findYAML(dir, name string, nameOf func(path string) (string, error)) (string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", fmt.Errorf("reading %s: %w", dir, err)
+	}
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		if n, err := nameOf(path); err == nil && n == name {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("%q not found in %s", name, dir)
+}
@@ -0,0 +1,250 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/evalkit"
+	"github.com/jdgilhuly/go_eval_agent/internal/agentloop"
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+	"github.com/spf13/cobra"
+)
+
+// errDebugQuit is returned by the debugger's tool resolver when the
+// operator quits the session.
+var errDebugQuit = errors.New("debug session ended by operator")
+
+// debugCase implements 'eval debug': it runs one case, printing each turn
+// of the transcript as it completes and pausing at each tool call so the
+// operator can change the response, then judges the final output. The
+// case's first request and mocks are built as the runner builds them.
+func debugCase(cmd *cobra.Command, args []string) error {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	suitePath, _ := cmd.Flags().GetString("suite")
+	caseName, _ := cmd.Flags().GetString("case")
+	if suitePath == "" || caseName == "" {
+		return fmt.Errorf("--suite and --case are required")
+	}
+	s, err := evalkit.LoadSuite(suitePath)
+	if err != nil {
+		return err
+	}
+	c, ok := findCase(s, caseName)
+	if !ok {
+		return fmt.Errorf("case %q not found in suite %q", caseName, s.Name)
+	}
+
+	promptName, _ := cmd.Flags().GetString("prompt")
+	if promptName == "" {
+		promptName = s.Prompt
+	}
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	pv, err := findPrompt(promptDir, promptName)
+	if err != nil {
+		return fmt.Errorf("suite %q: %w", s.Name, err)
+	}
+
+	providerName, _ := cmd.Flags().GetString("provider")
+	if providerName == "" {
+		providerName = c.Provider
+	}
+	p, model, err := newProvider(cfg, providerName)
+	if err != nil {
+		return err
+	}
+	if c.Model != "" {
+		model = c.Model
+	}
+	if m, _ := cmd.Flags().GetString("model"); m != "" {
+		model = m
+	}
+
+	ctx, cancel := context.WithCancel(cmd.Context())
+	defer cancel()
+	judges, err := judge.FromConfigs(c.Judges, judge.Options{Provider: p, Model: model, Ctx: ctx})
+	if err != nil {
+		return fmt.Errorf("case %q: %w", c.Name, err)
+	}
+
+	req, err := runner.CaseRequest(c, pv, model, cfg.TemplateEnv)
+	if err != nil {
+		return fmt.Errorf("case %q: %w", c.Name, err)
+	}
+	req.Metadata = runner.RequestMetadata(s.Name, runner.RunID(time.Now(), s.Name))
+	req.Seed = cfg.Seed
+	registry, err := runner.CaseMocks(s, c, nil)
+	if err != nil {
+		return fmt.Errorf("case %q: %w", c.Name, err)
+	}
+
+	noPause, _ := cmd.Flags().GetBool("no-pause")
+	d := &debugger{
+		in:     bufio.NewScanner(os.Stdin),
+		out:    os.Stdout,
+		tools:  registry,
+		pause:  !noPause,
+		cancel: cancel,
+	}
+
+	fmt.Printf("Debugging %s/%s with %s/%s\n", s.Name, c.Name, p.Name(), model)
+	maxIter, _ := cmd.Flags().GetInt("max-iterations")
+	tr := trace.New()
+	loop, err := d.run(ctx, p, req, tr, maxIter)
+	switch {
+	case d.quit:
+		fmt.Println("\nStopped before the agent finished; not judging.")
+		return nil
+	case err != nil:
+		return fmt.Errorf("provider error: %w", err)
+	case !loop.Done:
+		fmt.Printf("\nThe agent was still calling tools after %d iterations.\n", loop.Iterations)
+	}
+
+	usage := tr.GetUsage()
+	fmt.Printf("\n--- Judges ---\n")
+	fmt.Printf("Tokens: %d in / %d out\n", usage.InputTokens, usage.OutputTokens)
+	if len(judges) == 0 {
+		fmt.Println("Case has no judges.")
+		return nil
+	}
+	res := judge.NewCompositeScorer(c.Threshold).Score(evalkit.JudgeInput(c, loop.Final, tr), judges)
+	for _, js := range res.Scores {
+		fmt.Printf("  %-14s %-7s %.2f  %s\n", js.JudgeName, js.Status, js.Score, js.Reason)
+	}
+	fmt.Printf("Result: %s (score %.2f)\n", res.Status, res.CompositeScore)
+	return nil
+}
+
+// run runs the agent loop from req, recording it in tr and printing each
+// turn as it completes.
+func (d *debugger) run(ctx context.Context, p provider.Provider, req provider.Request, tr *trace.AgentTrace, maxIter int) (agentloop.Result, error) {
+	if req.System != "" {
+		d.printTurn("system", req.System)
+	}
+	user := req.Messages[0].Content
+	d.printTurn("user", user)
+	tr.AddMessage("user", user)
+	loop, err := agentloop.Run(ctx, &echoProvider{Provider: p, d: d}, req, d, tr, maxIter, nil)
+	tr.Finish()
+	return loop, err
+}
+
+// debugger resolves tool calls through the case's mocks, showing each call
+// and its mocked response and, when pausing, letting the operator keep,
+// edit, or replace the response with an error.
+type debugger struct {
+	in     *bufio.Scanner
+	out    io.Writer
+	tools  agentloop.ToolResolver
+	pause  bool
+	cancel context.CancelFunc
+	quit   bool
+}
+
+// Resolve implements agentloop.ToolResolver.
+func (d *debugger) Resolve(toolName string, params map[string]interface{}) (string, error) {
+	if d.quit {
+		return "", errDebugQuit
+	}
+	args, _ := json.Marshal(params)
+	fmt.Fprintf(d.out, "\n[tool call] %s %s\n", toolName, args)
+	content, err := d.tools.Resolve(toolName, params)
+	if err != nil {
+		fmt.Fprintf(d.out, "  mock error: %v\n", err)
+	} else {
+		fmt.Fprintf(d.out, "  mock response:\n%s\n", indent(content))
+	}
+	if !d.pause {
+		return content, err
+	}
+
+	for {
+		fmt.Fprintf(d.out, "  [enter] continue, (e)dit response, e(r)ror, (c)ontinue without pausing, (q)uit: ")
+		if !d.in.Scan() {
+			fmt.Fprintln(d.out)
+			return d.stop()
+		}
+		switch strings.ToLower(strings.TrimSpace(d.in.Text())) {
+		case "":
+			return content, err
+		case "c":
+			d.pause = false
+			return content, err
+		case "e":
+			fmt.Fprintf(d.out, "  New response, ended by a line with a single '.':\n")
+			var lines []string
+			for d.in.Scan() && d.in.Text() != "." {
+				lines = append(lines, d.in.Text())
+			}
+			return strings.Join(lines, "\n"), nil
+		case "r":
+			fmt.Fprintf(d.out, "  Error message: ")
+			if !d.in.Scan() {
+				return d.stop()
+			}
+			return "", errors.New(strings.TrimSpace(d.in.Text()))
+		case "q":
+			return d.stop()
+		}
+	}
+}
+
+// stop ends the session: the loop's next provider call fails on the
+// canceled context.
+func (d *debugger) stop() (string, error) {
+	d.quit = true
+	d.cancel()
+	return "", errDebugQuit
+}
+
+func (d *debugger) printTurn(role, content string) {
+	fmt.Fprintf(d.out, "\n[%s]\n%s\n", role, indent(content))
+}
+
+// echoProvider prints each response of the wrapped provider as it
+// arrives.
+type echoProvider struct {
+	provider.Provider
+	d *debugger
+}
+
+func (e *echoProvider) Complete(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	resp, err := e.Provider.Complete(ctx, req)
+	if err != nil || e.d.quit {
+		return resp, err
+	}
+	if resp.Content != "" || len(resp.ToolCalls) == 0 {
+		e.d.printTurn("assistant", resp.Content)
+	}
+	for _, tc := range resp.ToolCalls {
+		if len(req.Tools) > 0 && !slices.ContainsFunc(req.Tools, func(t provider.Tool) bool { return t.Name == tc.Name }) {
+			fmt.Fprintf(e.d.out, "\n[tool call] %s: not in the tool list, answered with an error\n", tc.Name)
+		}
+	}
+	return resp, nil
+}
+
+// indent prefixes every line of s with four spaces.
+func indent(s string) string {
+	return "    " + strings.ReplaceAll(s, "\n", "\n    ")
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+// scriptedProvider returns its responses in order and records the
+// requests it gets.
+type scriptedProvider struct {
+	responses []provider.Response
+	requests  []provider.Request
+}
+
+func (s *scriptedProvider) Name() string { return "scripted" }
+
+func (s *scriptedProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	s.requests = append(s.requests, *req)
+	if len(s.requests) > len(s.responses) {
+		return nil, fmt.Errorf("no more responses")
+	}
+	resp := s.responses[len(s.requests)-1]
+	return &resp, nil
+}
+
+func newTestDebugger(input string, out *strings.Builder, cancel context.CancelFunc) *debugger {
+	return &debugger{
+		in:     bufio.NewScanner(strings.NewReader(input)),
+		out:    out,
+		tools:  mock.NewRegistry([]mock.MockConfig{{ToolName: "lookup", DefaultResponse: &mock.MockResponse{Content: "mocked"}}}),
+		pause:  true,
+		cancel: cancel,
+	}
+}
+
+func TestDebugger_Resolve(t *testing.T) {
+	var out strings.Builder
+	d := newTestDebugger("\ne\nfirst\nsecond\n.\nr\nboom\nc\n", &out, func() {})
+
+	if got, err := d.Resolve("lookup", nil); got != "mocked" || err != nil {
+		t.Errorf("Resolve() kept = %q, %v, want the mocked response", got, err)
+	}
+	if got, err := d.Resolve("lookup", nil); got != "first\nsecond" || err != nil {
+		t.Errorf("Resolve() edited = %q, %v, want the typed response", got, err)
+	}
+	if _, err := d.Resolve("lookup", nil); err == nil || err.Error() != "boom" {
+		t.Errorf("Resolve() error = %v, want the typed error", err)
+	}
+	if got, _ := d.Resolve("lookup", nil); got != "mocked" || d.pause {
+		t.Errorf("Resolve() after c = %q, pause %v, want the mock and no more pausing", got, d.pause)
+	}
+	if got, _ := d.Resolve("lookup", nil); got != "mocked" {
+		t.Errorf("Resolve() without pausing = %q, want the mock", got)
+	}
+	if n := strings.Count(out.String(), "[tool call] lookup"); n != 5 {
+		t.Errorf("printed %d tool calls, want 5:\n%s", n, out.String())
+	}
+}
+
+func TestDebugger_Quit(t *testing.T) {
+	var out strings.Builder
+	canceled := false
+	d := newTestDebugger("q\n", &out, func() { canceled = true })
+	if _, err := d.Resolve("lookup", nil); err != errDebugQuit || !d.quit || !canceled {
+		t.Errorf("Resolve() = %v, quit %v, canceled %v, want the session ended", err, d.quit, canceled)
+	}
+	if _, err := d.Resolve("lookup", nil); err != errDebugQuit {
+		t.Errorf("Resolve() after quitting = %v, want errDebugQuit", err)
+	}
+}
+
+func TestDebugger_Run(t *testing.T) {
+	p := &scriptedProvider{responses: []provider.Response{
+		{Content: "Let me look.", ToolCalls: []provider.ToolCall{{ID: "1", Name: "lookup"}}},
+		{Content: "The answer is 42."},
+	}}
+	var out strings.Builder
+	d := newTestDebugger("e\n42\n.\n", &out, func() {})
+	req := provider.Request{
+		System:   "Be brief.",
+		Messages: []provider.Message{{Role: "user", Content: "What is it?"}},
+		Tools:    []provider.Tool{{Name: "lookup"}},
+		Metadata: map[string]string{"suite": "s"},
+	}
+	tr := trace.New()
+	loop, err := d.run(context.Background(), p, req, tr, 0)
+	if err != nil || !loop.Done || loop.Final != "The answer is 42." {
+		t.Fatalf("run() = %+v, %v", loop, err)
+	}
+	if len(p.requests) != 2 || p.requests[1].Metadata["suite"] != "s" {
+		t.Fatalf("requests = %+v, want two carrying the request's metadata", p.requests)
+	}
+	if last := p.requests[1].Messages; !strings.Contains(fmt.Sprint(last), "42") {
+		t.Errorf("second request messages = %+v, want the edited tool response", last)
+	}
+	transcript := out.String()
+	for _, want := range []string{"[system]", "[user]", "    What is it?", "[assistant]", "    Let me look.", "[tool call] lookup", "    The answer is 42."} {
+		if !strings.Contains(transcript, want) {
+			t.Errorf("transcript missing %q:\n%s", want, transcript)
+		}
+	}
+	if len(tr.GetMessages()) == 0 || tr.EndTime.IsZero() {
+		t.Error("run() should record the session in a finished trace")
+	}
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+)
+
+// estimateRun implements 'eval estimate': it counts the input tokens of
+// each case's first request and flags those too large for their model.
+func estimateRun(cmd *cobra.Command, args []string) error {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	suitePath, _ := cmd.Flags().GetString("suite")
+	if suitePath == "" {
+		return fmt.Errorf("--suite is required")
+	}
+	s, err := suite.Load(suitePath)
+	if err != nil {
+		return fmt.Errorf("loading suite: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("invalid suite: %w", err)
+	}
+	promptName, _ := cmd.Flags().GetString("prompt")
+	if promptName == "" {
+		promptName = s.Prompt
+	}
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	pv, err := findPrompt(promptDir, promptName)
+	if err != nil {
+		return err
+	}
+
+	// Offline, the provider stays nil and every count is an estimate.
+	offline, _ := cmd.Flags().GetBool("offline")
+	providerName, _ := cmd.Flags().GetString("provider")
+	var p provider.Provider
+	var model string
+	if offline {
+		if err := loadPricing(cfg); err != nil {
+			return err
+		}
+		model = configuredModel(cfg, providerName)
+	} else if p, model, err = newProvider(cfg, providerName); err != nil {
+		return err
+	}
+	modelFlag, _ := cmd.Flags().GetString("model")
+	if modelFlag != "" {
+		model = modelFlag
+	}
+	providers := providerCache(cfg)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "CASE\tMODEL\tINPUT TOKENS\tWINDOW\tINPUT COST")
+	var total int
+	var cost float64
+	var over int
+	var failed []string
+	for _, c := range s.Cases {
+		cp, cm := p, model
+		if c.Provider != "" {
+			if offline {
+				cm = cfg.Providers[c.Provider].Model
+			} else if cp, cm, err = providers(c.Provider); err != nil {
+				fmt.Fprintf(w, "%s\t-\terror\t-\t-\n", c.Name)
+				failed = append(failed, fmt.Sprintf("%s: %v", c.Name, err))
+				continue
+			}
+		}
+		if modelFlag != "" {
+			cm = modelFlag
+		} else if c.Model != "" {
+			cm = c.Model
+		}
+		req, err := runner.CaseRequest(c, pv, cm, cfg.TemplateEnv)
+		if err != nil {
+			fmt.Fprintf(w, "%s\t%s\terror\t-\t-\n", c.Name, orDash(cm))
+			failed = append(failed, fmt.Sprintf("%s: %v", c.Name, err))
+			continue
+		}
+		n, err := provider.CheckContextWindow(cmd.Context(), cp, &req)
+		window := "-"
+		if limit, ok := provider.ContextWindow(cm); ok {
+			window = strconv.Itoa(limit)
+		}
+		switch {
+		case errors.Is(err, provider.ErrContextWindow):
+			window += " (over)"
+			over++
+		case err != nil:
+			fmt.Fprintf(w, "%s\t%s\terror\t%s\t-\n", c.Name, orDash(cm), window)
+			failed = append(failed, fmt.Sprintf("%s: %v", c.Name, err))
+			continue
+		}
+		caseCost := provider.EstimateCost(cm, provider.Usage{InputTokens: n})
+		total += n
+		cost += caseCost
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t$%.4f\n", c.Name, orDash(cm), n, window, caseCost)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d cases, %d input tokens, $%.4f for first requests\n", len(s.Cases), total, cost)
+	if len(failed) > 0 {
+		fmt.Println("\nCases that could not be counted:")
+		for _, f := range failed {
+			fmt.Printf("  %s\n", f)
+		}
+	}
+
+	switch {
+	case over > 0:
+		return fmt.Errorf("%d of %d cases exceed their model's context window", over, len(s.Cases))
+	case len(failed) > 0:
+		return fmt.Errorf("%d of %d cases could not be counted", len(failed), len(s.Cases))
+	}
+	return nil
+}
+
+// configuredModel returns the model configured for the named provider, or
+// for the only configured provider when name is empty.
+func configuredModel(cfg *config.Config, name string) string {
+	if name == "" && len(cfg.Providers) == 1 {
+		for _, pc := range cfg.Providers {
+			return pc.Model
+		}
+	}
+	return cfg.Providers[name].Model
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/benchmark"
+	"github.com/spf13/cobra"
+)
+
+// fetchTimeout bounds a dataset download.
+const fetchTimeout = 5 * time.Minute
+
+// fetchRun implements 'eval fetch': it downloads a registered benchmark and
+// writes it as a suite file and a prompt file.
+func fetchRun(cmd *cobra.Command, args []string) error {
+	d, ok := benchmark.Lookup(args[0])
+	if !ok {
+		return fmt.Errorf("unknown dataset %q (available: %s)", args[0], strings.Join(benchmark.Names(), ", "))
+	}
+	outDir, _ := cmd.Flags().GetString("out")
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	limit, _ := cmd.Flags().GetInt("limit")
+
+	fmt.Printf("Downloading %s from %s\n", d.Name, d.URL)
+	data, err := d.Fetch(cmd.Context(), &http.Client{Timeout: fetchTimeout}, limit)
+	if err != nil {
+		return err
+	}
+
+	for _, dir := range []string{outDir, promptDir} {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return fmt.Errorf("creating directory %s: %w", dir, err)
+		}
+	}
+	suitePath := filepath.Join(outDir, d.Name+".yaml")
+	if err := os.WriteFile(suitePath, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", suitePath, err)
+	}
+	fmt.Printf("  created %s\n", suitePath)
+
+	promptPath := filepath.Join(promptDir, d.Name+".yaml")
+	if _, err := os.Stat(promptPath); err == nil {
+		fmt.Printf("  skipped %s (already exists)\n", promptPath)
+		return nil
+	}
+	if err := os.WriteFile(promptPath, []byte(d.Prompt), 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", promptPath, err)
+	}
+	fmt.Printf("  created %s\n", promptPath)
+	return nil
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/lint"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+)
+
+// runLint implements 'eval lint': it prints the suspicious judge
+// configurations in the given suites and fails when there are any.
+func runLint(cmd *cobra.Command, args []string) error {
+	paths, _ := cmd.Flags().GetStringSlice("suite")
+	suites, err := suite.LoadPaths(paths)
+	if err != nil {
+		return err
+	}
+
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	promptName, _ := cmd.Flags().GetString("prompt")
+
+	var findings int
+	for _, s := range suites {
+		name := promptName
+		if name == "" {
+			name = s.Prompt
+		}
+		// Without its prompt a suite is still linted; only the check for
+		// contains values found in the prompt is skipped.
+		var pv *prompt.PromptVariant
+		if name != "" {
+			if pv, err = findPrompt(promptDir, name); err != nil {
+				fmt.Printf("warning: suite %q: %v; not checking contains judges against the prompt\n", s.Name, err)
+			}
+		}
+		for _, f := range lint.Suite(s, pv, prompt.WithEnv(cfg.TemplateEnv)) {
+			fmt.Println(f)
+			findings++
+		}
+	}
+
+	if findings == 0 {
+		fmt.Printf("No lint findings in %d suite(s).\n", len(suites))
+		return nil
+	}
+	cmd.SilenceUsage = true
+	return fmt.Errorf("%d lint finding(s)", findings)
+}
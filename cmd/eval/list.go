@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+)
+
+// listCases implements 'eval list cases'.
+func listCases(cmd *cobra.Command, args []string) error {
+	dir, _ := cmd.Flags().GetString("dir")
+	name, _ := cmd.Flags().GetString("suite")
+	tags, _ := cmd.Flags().GetStringSlice("tags")
+	pattern, _ := cmd.Flags().GetString("grep")
+
+	var re *regexp.Regexp
+	if pattern != "" {
+		var err error
+		if re, err = regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid --grep pattern: %w", err)
+		}
+	}
+
+	suiteDir := filepath.Join(dir, "suites")
+	suites, err := suite.LoadDir(suiteDir)
+	if err != nil {
+		return fmt.Errorf("loading suites from %s: %w", suiteDir, err)
+	}
+	if name != "" {
+		var found []*suite.EvalSuite
+		for _, s := range suites {
+			if s.Name == name {
+				found = append(found, s)
+			}
+		}
+		if len(found) == 0 {
+			return fmt.Errorf("suite %q not found in %s", name, suiteDir)
+		}
+		suites = found
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SUITE\tCASE\tTAGS\tJUDGES\tEXPECTED TOOLS")
+	var n int
+	for _, s := range suites {
+		s = s.FilterByTag(tags)
+		if re != nil {
+			s = s.Search(re)
+		}
+		for _, c := range s.Cases {
+			judges := make([]string, len(c.Judges))
+			for i, j := range c.Judges {
+				judges[i] = j.Type
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", s.Name, c.Name,
+				orDash(strings.Join(c.Tags, ",")),
+				orDash(strings.Join(judges, ",")),
+				orDash(strings.Join(c.ExpectedTools, ",")))
+			n++
+		}
+	}
+	if n == 0 {
+		fmt.Println("No matching cases found.")
+		return nil
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("\n%d cases\n", n)
+	return nil
+}
+
+// orDash returns s, or "-" for an empty table cell.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
@@ -1,17 +1,25 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/jdgilhuly/go_eval_agent/evalkit"
+	"github.com/jdgilhuly/go_eval_agent/pkg/benchmark"
 	"github.com/jdgilhuly/go_eval_agent/pkg/config"
 	"github.com/jdgilhuly/go_eval_agent/pkg/diff"
 	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/report"
 	"github.com/jdgilhuly/go_eval_agent/pkg/result"
 	"github.com/jdgilhuly/go_eval_agent/pkg/review"
 	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"gopkg.in/yaml.v3"
 )
 
@@ -39,26 +47,101 @@ var runCmd = &cobra.Command{
 	Long: `Execute an eval suite against a configured LLM provider.
 
 Runs all cases in the suite, applies judges, and outputs results.
-Results are saved to a JSON file for later comparison with 'eval diff'.`,
-	RunE: func(cmd *cobra.Command, args []string) error {
-		cfgPath, _ := cmd.Flags().GetString("config")
-		cfg, err := config.LoadOrDefault(cfgPath)
-		if err != nil {
-			return fmt.Errorf("loading config: %w", err)
-		}
-		if err := cfg.Validate(); err != nil {
-			return fmt.Errorf("invalid config: %w", err)
-		}
+Results are saved to a JSON file for later comparison with 'eval diff'.
 
-		verbose, _ := cmd.Flags().GetBool("verbose")
-		if verbose {
-			fmt.Printf("Config loaded: concurrency=%d timeout=%s output=%s\n",
-				cfg.Concurrency, cfg.Timeout, cfg.OutputDir)
-		}
+Pass --suite several times, or a directory of suites, to run multiple
+suites concurrently. They share the concurrency limit and each is saved to
+its own result file. A combined result, with a section per suite and a
+roll-up total, is printed at the end and saved next to them.
 
-		fmt.Println("eval run: not yet implemented")
-		return nil
-	},
+Use --deterministic to normalize run IDs, timestamps, and durations so the
+saved file can be committed as a golden result and diffed textually. Retry
+counts, provider fallbacks, and system fingerprints, which vary with the
+state of the APIs, are cleared; model versions are kept.
+
+Use --fail-fast to stop starting cases once one fails. Cases may also list
+depends_on: cases that must pass first; when one does not, the dependent
+case is reported as blocked instead of being run.
+
+Use --preflight to send one trivial request to the provider and run one
+case through its model-backed judges before starting, so auth or model
+misconfiguration fails fast with a single clear error.
+
+Use --sample for quick smoke runs on part of each suite: "20%" or "15"
+picks cases at random, and "stratified:20%" samples every suite's first-tag
+categories in proportion, so no category is dropped by chance. Pass
+--sample-seed to repeat the same sample.
+
+Use --batch to send Anthropic and OpenAI requests through their batch
+APIs at half price. Each agent turn may then take minutes to hours; add
+--detach to submit the requests and exit instead of waiting, and run
+'eval collect' later to pick the run up. The run is saved with the seeds
+it resolved, so collect samples the same cases.
+
+Use --check-tokens to count each case's first request before sending it
+(see 'eval estimate') and fail cases that would not fit in their model's
+context window without calling the model.
+
+A suite with pass_criteria has them checked after its run; the command
+exits non-zero when a suite's minimum pass rate or maximum regressions
+against its baseline is not met.`,
+	RunE: runEval,
+}
+
+// --- collect command ---
+
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Continue a run detached with 'eval run --batch --detach'",
+	Long: `Repeat a detached batch run with the arguments it was started with.
+
+Requests whose batches have ended get their results, and the requests that
+follow from them, such as the next turn of an agent loop, are submitted in
+new batches. Run it again until no request is pending; the run then
+finishes like any other: cases are judged and results saved.
+
+Tool mocks replay the same responses on every pass, but passthrough tools
+are called again, so their side effects repeat.`,
+	Args: cobra.NoArgs,
+	RunE: collectRun,
+}
+
+var rerunCmd = &cobra.Command{
+	Use:   "rerun <run.json>",
+	Short: "Re-execute a run from its reproducibility manifest",
+	Long: `Repeat the 'eval run' invocation recorded in a run's manifest, with the
+same sampling, spot-check, and provider seeds, for the suites of that run.
+
+Every run records a manifest: its arguments, the framework version,
+fingerprints of the config, suites, prompts, and each case's tool mocks,
+the provider and model, and its seeds. Before running, rerun lists the
+definitions that changed since, so a different outcome can be traced to
+its cause. Results are saved to a new file; the original is kept, and a
+rerun whose results would replace it, as those of a --deterministic run
+do by default, stops before running unless given another --output.
+
+Model outputs are not deterministic, so a rerun reproduces the conditions
+of a run, not necessarily its results. A run with --seed comes closest;
+'eval diff' then reports a changed system fingerprint, the provider's
+backend, as the likely cause of different outputs.`,
+	Args: cobra.ExactArgs(1),
+	RunE: rerunRun,
+}
+
+// --- debug command ---
+
+var debugCmd = &cobra.Command{
+	Use:   "debug",
+	Short: "Step through a single case interactively",
+	Long: `Run one case of a suite and print its transcript turn by turn, each
+model response once it completes.
+
+The run pauses at every tool call and shows the mocked response. Press
+enter to send it as is, e to type a replacement response, r to answer
+with an error instead, c to stop pausing, or q to quit. Once the agent
+answers, the case's judges score the output and each judge's verdict is
+printed.`,
+	RunE: debugCase,
 }
 
 // --- diff command ---
@@ -69,9 +152,37 @@ var diffCmd = &cobra.Command{
 	Long: `Compare results from two eval runs side-by-side.
 
 Shows score regressions, improvements, and unchanged cases.
-Useful for evaluating prompt changes or model upgrades.`,
+Useful for evaluating prompt changes or model upgrades.
+
+With --explain, asks the configured model for a one-line explanation of
+each regressed case, shown in the table and markdown output.
+
+With --traces, aligns each case's transcript (messages and tool calls)
+between the two runs and shows where the behavior diverged. Use --case
+to look at a single case.
+
+Runs of several suites in one invocation also save a combined result.
+Combined results are compared case by case within each suite; use
+--suite to compare one suite, including against a single-suite run.
+
+For CI gates, --only limits the listed cases to some categories (e.g.
+--only regressed,new), --summary prints just the counts, and --fail-on
+exits non-zero when any case falls in the given categories (e.g.
+--fail-on regressed). Categories are improved, regressed, unchanged, new,
+and removed; --fail-on counts every case, whatever --only shows.`,
 	Args: cobra.ExactArgs(2),
 	RunE: func(cmd *cobra.Command, args []string) error {
+		onlyNames, _ := cmd.Flags().GetStringSlice("only")
+		only, err := diff.ParseCategories(onlyNames)
+		if err != nil {
+			return fmt.Errorf("--only: %w", err)
+		}
+		failOnNames, _ := cmd.Flags().GetStringSlice("fail-on")
+		failOn, err := diff.ParseCategories(failOnNames)
+		if err != nil {
+			return fmt.Errorf("--fail-on: %w", err)
+		}
+
 		a, err := result.LoadSummary(args[0])
 		if err != nil {
 			return fmt.Errorf("loading run A: %w", err)
@@ -81,23 +192,105 @@ Useful for evaluating prompt changes or model upgrades.`,
 			return fmt.Errorf("loading run B: %w", err)
 		}
 
-		threshold, _ := cmd.Flags().GetFloat64("threshold")
-		dr := diff.Compare(a, b, threshold)
+		if suiteName, _ := cmd.Flags().GetString("suite"); suiteName != "" {
+			if a, err = a.SuiteRun(suiteName); err != nil {
+				return err
+			}
+			if b, err = b.SuiteRun(suiteName); err != nil {
+				return err
+			}
+		}
 
 		format, _ := cmd.Flags().GetString("format")
-		if format == "json" {
-			data, err := dr.JSON()
+		caseName, _ := cmd.Flags().GetString("case")
+		if traces, _ := cmd.Flags().GetBool("traces"); traces {
+			diffs, err := diff.CompareTraces(a, b, caseName)
+			if err != nil {
+				return err
+			}
+			if format == "json" {
+				data, err := json.MarshalIndent(diffs, "", "  ")
+				if err != nil {
+					return fmt.Errorf("serializing trace diff: %w", err)
+				}
+				fmt.Println(string(data))
+			} else {
+				diff.PrintTraceDiffs(os.Stdout, diffs)
+			}
+			return nil
+		}
+		if caseName != "" {
+			return fmt.Errorf("--case requires --traces")
+		}
+
+		threshold, _ := cmd.Flags().GetFloat64("threshold")
+		dr := diff.Compare(a, b, threshold).Filter(only)
+
+		if explain, _ := cmd.Flags().GetBool("explain"); explain && dr.Summary.Regressed > 0 {
+			cfgPath, _ := cmd.Flags().GetString("config")
+			cfg, err := config.LoadOrDefault(cfgPath)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			providerName, _ := cmd.Flags().GetString("provider")
+			p, model, err := newProvider(cfg, providerName)
+			if err != nil {
+				return err
+			}
+			if m, _ := cmd.Flags().GetString("model"); m != "" {
+				model = m
+			}
+			if err := diff.Explain(cmd.Context(), p, model, dr, a, b); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: %v\n", err)
+			}
+		}
+
+		out := dr
+		if summaryOnly, _ := cmd.Flags().GetBool("summary"); summaryOnly {
+			out = dr.SummaryOnly()
+		}
+		switch format {
+		case "json":
+			data, err := out.JSON()
 			if err != nil {
 				return fmt.Errorf("serializing diff: %w", err)
 			}
 			fmt.Println(string(data))
-		} else {
-			dr.PrintTable(os.Stdout)
+		case "markdown":
+			out.PrintMarkdown(os.Stdout)
+		default:
+			out.PrintTable(os.Stdout)
+		}
+
+		var failed []string
+		for _, c := range failOn {
+			if n := dr.Summary.Count(c); n > 0 {
+				failed = append(failed, fmt.Sprintf("%d %s", n, c))
+			}
+		}
+		if len(failed) > 0 {
+			cmd.SilenceUsage = true
+			return fmt.Errorf("diff failed: %s", strings.Join(failed, ", "))
 		}
 		return nil
 	},
 }
 
+// --- rejudge command ---
+
+var rejudgeCmd = &cobra.Command{
+	Use:   "rejudge <run.json>",
+	Short: "Re-score a saved run with the suite's judges",
+	Long: `Apply the suite's current judges to the outputs and traces saved in a
+run result, without calling the agent again.
+
+Use this to score runs made with 'eval run --no-judge', or to re-score an
+old run after changing judge definitions. The result file is updated in
+place unless --output is given.`,
+	Args: cobra.ExactArgs(1),
+	RunE: rejudgeRun,
+}
+
 // --- review command ---
 
 var reviewCmd = &cobra.Command{
@@ -113,6 +306,11 @@ cases marked for human review.`,
 		if err != nil {
 			return fmt.Errorf("loading run results: %w", err)
 		}
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := config.LoadOrDefault(cfgPath)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
 
 		filterStr, _ := cmd.Flags().GetString("filter")
 		filter := review.ParseFilter(filterStr)
@@ -131,6 +329,9 @@ cases marked for human review.`,
 			if err := summary.Save(args[0]); err != nil {
 				return fmt.Errorf("saving updated results: %w", err)
 			}
+			if err := signResult(cfg, args[0]); err != nil {
+				return err
+			}
 			fmt.Printf("\nReviewed %d cases. Results saved to %s\n", reviewed, args[0])
 		}
 
@@ -138,6 +339,439 @@ cases marked for human review.`,
 	},
 }
 
+// --- results command ---
+
+var resultsCmd = &cobra.Command{
+	Use:   "results",
+	Short: "Manage stored run results",
+}
+
+var resultsPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old run results",
+	Long: `Delete run result files that fall outside the retention policy.
+
+A result is kept if it is among the --keep-last most recent runs, started
+within the last --keep-days days, is listed under retention.pinned in the
+config, or was saved with --deterministic. The combined summary of a
+multi-suite run is not counted as a run; it is kept while any of its suite
+runs is. Flags override the retention settings in the config file.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfgPath, _ := cmd.Flags().GetString("config")
+		cfg, err := config.LoadOrDefault(cfgPath)
+		if err != nil {
+			return fmt.Errorf("loading config: %w", err)
+		}
+
+		policy := result.RetentionPolicy{
+			KeepLast: cfg.Retention.KeepLast,
+			KeepDays: cfg.Retention.KeepDays,
+			Pinned:   cfg.Retention.Pinned,
+		}
+		if cmd.Flags().Changed("keep-last") {
+			policy.KeepLast, _ = cmd.Flags().GetInt("keep-last")
+		}
+		if cmd.Flags().Changed("keep-days") {
+			policy.KeepDays, _ = cmd.Flags().GetInt("keep-days")
+		}
+
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			dir = cfg.OutputDir
+		}
+		dryRun, _ := cmd.Flags().GetBool("dry-run")
+
+		pr, err := result.Prune(dir, policy, time.Now(), dryRun)
+		if err != nil {
+			return fmt.Errorf("pruning results: %w", err)
+		}
+
+		verb := "removed"
+		if dryRun {
+			verb = "would remove"
+		}
+		for _, path := range pr.Removed {
+			fmt.Printf("  %s %s\n", verb, path)
+		}
+		for _, path := range pr.Signatures {
+			fmt.Printf("  %s %s\n", verb, path)
+		}
+		fmt.Printf("%d kept, %d %s\n", len(pr.Kept), len(pr.Removed), verb)
+		return nil
+	},
+}
+
+// --- history command ---
+
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List stored runs with their tags and notes",
+	Long: `List stored run results, newest first, with the tags, labels, and note
+each run was started with. Use --tag, --label, and --suite to narrow the
+list to one experiment.`,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			cfgPath, _ := cmd.Flags().GetString("config")
+			cfg, err := config.LoadOrDefault(cfgPath)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			dir = cfg.OutputDir
+		}
+
+		runs, err := result.LoadDir(dir)
+		if err != nil {
+			return err
+		}
+
+		tags, _ := cmd.Flags().GetStringSlice("tag")
+		labels, _ := cmd.Flags().GetStringToString("label")
+		suiteName, _ := cmd.Flags().GetString("suite")
+
+		var filtered []*result.RunSummary
+		for _, rf := range runs {
+			s := rf.Summary
+			if suiteName != "" && s.SuiteName != suiteName {
+				continue
+			}
+			if !s.HasTags(tags) || !s.HasLabels(labels) {
+				continue
+			}
+			filtered = append(filtered, s)
+		}
+
+		if len(filtered) == 0 {
+			fmt.Println("No matching runs found.")
+			return nil
+		}
+		report.PrintHistory(os.Stdout, filtered)
+		return nil
+	},
+}
+
+// --- pin command ---
+
+var pinCmd = &cobra.Command{
+	Use:   "pin <run.json> <case>",
+	Short: "Pin a case transcript as an exemplar for later runs",
+	Long: `Pin a case from a stored run as an exemplar. After every later run of the
+suite, 'eval run' prints each pinned exemplar's response next to the new
+response for the same case, for tracking qualitative changes that scores
+do not capture.
+
+Pins are kept in pins.json in the results directory and hold a copy of the
+case's transcript, so they survive 'eval results prune'. Pinning the same
+case from another run adds a second exemplar; --remove drops every pin of
+the case.`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, _ := cmd.Flags().GetString("dir")
+		if dir == "" {
+			cfgPath, _ := cmd.Flags().GetString("config")
+			cfg, err := config.LoadOrDefault(cfgPath)
+			if err != nil {
+				return fmt.Errorf("loading config: %w", err)
+			}
+			dir = cfg.OutputDir
+		}
+		path := result.PinsPath(dir)
+		pins, err := result.LoadPins(path)
+		if err != nil {
+			return err
+		}
+
+		summary, err := result.LoadSummary(args[0])
+		if err != nil {
+			return fmt.Errorf("loading run results: %w", err)
+		}
+
+		if remove, _ := cmd.Flags().GetBool("remove"); remove {
+			var n int
+			pins, n = result.RemovePins(pins, summary.SuiteName, args[1])
+			if n == 0 {
+				return fmt.Errorf("case %q of suite %q is not pinned", args[1], summary.SuiteName)
+			}
+			if err := result.SavePins(path, pins); err != nil {
+				return err
+			}
+			fmt.Printf("Unpinned %s/%s\n", summary.SuiteName, args[1])
+			return nil
+		}
+
+		note, _ := cmd.Flags().GetString("note")
+		pin, err := summary.PinCase(args[1], note, time.Now())
+		if err != nil {
+			return err
+		}
+		if err := result.SavePins(path, result.AddPin(pins, pin)); err != nil {
+			return err
+		}
+		fmt.Printf("Pinned %s/%s from run %s\n", pin.SuiteName, pin.CaseName, pin.RunID)
+		return nil
+	},
+}
+
+// --- badge command ---
+
+var badgeCmd = &cobra.Command{
+	Use:   "badge <run.json>",
+	Short: "Render a pass-rate badge for a run result",
+	Long: `Render a shields-style SVG badge of a run's pass rate for embedding in
+READMEs and dashboards, and print a markdown snippet that shows it:
+
+  eval badge results/latest.json --out docs/eval-badge.svg --link https://ci.example.com/evals
+
+The badge shows the pass rate rounded down to a whole percent, so a run
+with any failure never reads 100%, colored from green at 95% and above
+to red below 40%. The rate is the one in the run's stats: errored cases
+do not count against it. Use --label to name the suite on the badge.`,
+	Args: cobra.ExactArgs(1),
+	RunE: badgeRun,
+}
+
+// --- matrix command ---
+
+var matrixCmd = &cobra.Command{
+	Use:   "matrix <run.json>...",
+	Short: "Compare models across suites in one grid",
+	Long: `Render a model-by-suite grid of pass rate, average score, and cost per
+case from the results of runs that put several models through the same
+suites, so choosing a model comes down to one table:
+
+  eval run -s suites/qa.yaml -m claude-sonnet-4-5 -o results/sonnet.json
+  eval run -s suites/qa.yaml -m gpt-4o -o results/gpt-4o.json
+  eval matrix results/sonnet.json results/gpt-4o.json --format html -o models.html
+
+Cases are grouped by the model they ran on, so a single run whose cases
+override the model is compared too. With several suites, a final column
+covers all of them. The best pass rate, score, and cost in each column are
+highlighted.
+
+--format table prints to the terminal; markdown and html produce a report
+to share. 'eval run' prints the table itself when its cases span several
+models.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "table" && format != "markdown" && format != "html" {
+			return fmt.Errorf("unsupported matrix format %q (supported: table, markdown, html)", format)
+		}
+		var summaries []*result.RunSummary
+		for _, path := range args {
+			summary, err := result.LoadSummary(path)
+			if err != nil {
+				return fmt.Errorf("loading run results: %w", err)
+			}
+			summaries = append(summaries, summary)
+		}
+		m := report.BuildMatrix(summaries)
+		write := func(w io.Writer, color bool) {
+			switch format {
+			case "markdown":
+				report.PrintMatrixMarkdown(w, m)
+			case "html":
+				report.PrintMatrixHTML(w, m)
+			default:
+				report.PrintMatrix(w, m, color)
+			}
+		}
+
+		outPath, _ := cmd.Flags().GetString("output")
+		if outPath == "" {
+			write(os.Stdout, isTerminal(os.Stdout))
+			return nil
+		}
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("creating matrix file: %w", err)
+		}
+		write(f, false)
+		if err := f.Close(); err != nil {
+			return fmt.Errorf("writing matrix file: %w", err)
+		}
+		fmt.Printf("Wrote %d models x %d suites to %s\n", len(m.Models), len(m.Suites), outPath)
+		return nil
+	},
+}
+
+// --- export command ---
+
+var exportCmd = &cobra.Command{
+	Use:   "export <run.json>",
+	Short: "Export run transcripts for use outside eval",
+	Long: `Export the case transcripts saved in a run result.
+
+--format conversations writes OpenAI-style chat JSONL, one conversation per
+case with its tool calls, for fine-tuning or external analysis. Use
+--status to export only some cases (e.g. --status fail,error) and
+--metadata to tag each line with the run and case it came from.
+
+--format finetune writes the same chat JSONL prepared as training data:
+email addresses, phone, card, and social security numbers, and IP
+addresses are replaced by placeholders, and duplicate transcripts are
+dropped. Combine it with --passing-only to export only the cases the
+judges or a human reviewer approved.`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		format, _ := cmd.Flags().GetString("format")
+		if format != "conversations" && format != "finetune" {
+			return fmt.Errorf("unsupported export format %q (supported: conversations, finetune)", format)
+		}
+		statuses, _ := cmd.Flags().GetStringSlice("status")
+		withMetadata, _ := cmd.Flags().GetBool("metadata")
+		if passingOnly, _ := cmd.Flags().GetBool("passing-only"); passingOnly {
+			if len(statuses) > 0 {
+				return fmt.Errorf("--passing-only and --status are mutually exclusive")
+			}
+			statuses = []string{"pass"}
+		}
+		if format == "finetune" && withMetadata {
+			return fmt.Errorf("--metadata is not supported with --format finetune: fine-tuning APIs reject unknown fields")
+		}
+
+		summary, err := result.LoadSummary(args[0])
+		if err != nil {
+			return fmt.Errorf("loading run results: %w", err)
+		}
+		write := func(w io.Writer) (string, error) {
+			if format == "finetune" {
+				n, dups, err := summary.WriteFinetune(w, statuses)
+				return fmt.Sprintf("%d conversations (%d duplicates dropped)", n, dups), err
+			}
+			n, err := summary.WriteConversations(w, statuses, withMetadata)
+			return fmt.Sprintf("%d conversations", n), err
+		}
+
+		outPath, _ := cmd.Flags().GetString("output")
+		if outPath == "" {
+			_, err := write(os.Stdout)
+			return err
+		}
+
+		f, err := os.Create(outPath)
+		if err != nil {
+			return fmt.Errorf("creating export file: %w", err)
+		}
+		written, err := write(f)
+		if cerr := f.Close(); err == nil && cerr != nil {
+			err = fmt.Errorf("writing export file: %w", cerr)
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Exported %s to %s\n", written, outPath)
+		return nil
+	},
+}
+
+// --- bundle command ---
+
+var bundleCmd = &cobra.Command{
+	Use:   "bundle <run.json>",
+	Short: "Package a run for sharing",
+	Long: `Package a run result with the suite, prompts, and config it was produced
+from into a single zip, for attaching to bug reports to model vendors or
+sharing across teams.
+
+The suite and prompts are found by name in --suite-dir and --prompt-dir.
+Provider headers in the config are redacted, and the API keys currently
+set for the configured providers are scrubbed from every bundled file.`,
+	Args: cobra.ExactArgs(1),
+	RunE: bundleRun,
+}
+
+// --- fetch command ---
+
+var fetchCmd = &cobra.Command{
+	Use:   "fetch <dataset>",
+	Short: "Download a public benchmark as a suite",
+	Long: `Download a well-known benchmark dataset and convert it into a suite file
+in --out and a prompt in --prompt-dir, so that public baselines can be run
+alongside private suites.
+
+Datasets:
+  gsm8k          grade school math word problems, scored by a numeric judge
+  humaneval-go   Go function completion, scored by running the tests with
+                 a go_test judge (needs the go command when judging)
+  mt-bench       open-ended questions (first turn), scored by an LLM judge
+
+The suite file is replaced on every fetch. An existing prompt file is
+kept, so a prompt tuned for a benchmark survives refetching it.`,
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: benchmark.Names(),
+	RunE:      fetchRun,
+}
+
+// --- optimize command ---
+
+var optimizeCmd = &cobra.Command{
+	Use:   "optimize",
+	Short: "Search for a better prompt with a meta-model",
+	Long: `Improve a prompt automatically. The suite's cases are split into a dev
+split and a held-out test split. Each iteration shows a meta-model the best
+prompt so far with the dev cases it failed and the judges' reasons, asks it
+for an edited prompt, and runs the candidate on the dev split; a candidate
+that scores higher becomes the new best.
+
+When the search ends, the best variant is reported with its score deltas
+over the original on both splits, the test split showing whether the gain
+holds on cases the search did not see. The best prompt is printed, or
+written to --output as a prompt file.
+
+The meta-model defaults to the agent's provider and model; a stronger
+model can be chosen with --meta-provider and --meta-model.`,
+	Args: cobra.NoArgs,
+	RunE: optimizeRun,
+}
+
+// --- estimate command ---
+
+var estimateCmd = &cobra.Command{
+	Use:   "estimate",
+	Short: "Count each case's prompt tokens before running",
+	Long: `Render each case's first request, as a run would send it, and count its
+input tokens, so oversized prompts are caught before any money is spent.
+Each case is shown with its model's context window and the input cost of
+its first request; later tool-loop turns add to both.
+
+Anthropic providers count exactly with the API's free count_tokens
+endpoint; other providers, and every provider with --offline, use a local
+tiktoken-style estimate that needs no API key.
+
+The command exits non-zero when any case's request would not fit in its
+model's context window.`,
+	Args: cobra.NoArgs,
+	RunE: estimateRun,
+}
+
+// --- verify command ---
+
+var verifyCmd = &cobra.Command{
+	Use:   "verify <run.json>",
+	Short: "Check that a run result is unmodified since it was signed",
+	Long: `Check a run result against the signature written next to it, so a
+baseline used for sign-off can be shown to be byte-for-byte what the run
+produced.
+
+Results are signed when the config sets signing.key_env to the
+environment variable holding an HMAC key:
+
+  signing:
+    key_env: EVAL_SIGNING_KEY
+    key_id: ci-2026
+
+'eval run', 'eval rejudge', and 'eval review' then write <run.json>.sig
+beside each result they save. Verification needs the same key:
+
+  eval verify results/baseline.json
+
+The command exits non-zero when the result is unsigned, was modified, or
+was signed with a different key.`,
+	Args: cobra.ExactArgs(1),
+	RunE: verifyRun,
+}
+
 // --- list command ---
 
 var listCmd = &cobra.Command{
@@ -202,6 +836,38 @@ var listSuitesCmd = &cobra.Command{
 	},
 }
 
+var listCasesCmd = &cobra.Command{
+	Use:   "cases",
+	Short: "List the cases in eval suites",
+	Long: `List each case with its tags, judges, and expected tools.
+
+Cases come from every suite under <dir>/suites, or only the suite named
+by --suite. --tags keeps cases with any of the given tags, and --grep
+keeps cases whose ID, name, tags, or input values match a regular
+expression.`,
+	RunE: listCases,
+}
+
+// --- show command ---
+
+var showCmd = &cobra.Command{
+	Use:   "show",
+	Short: "Show how a resource is defined",
+}
+
+var showCaseCmd = &cobra.Command{
+	Use:   "case <suite>/<case>",
+	Short: "Show everything that defines a single case",
+	Long: `Print a case's prompt, tool schemas, mocks, and judges without running it.
+
+The case is named by its suite name and its case name or ID. With
+--interpolate, the prompt is rendered with the case's input exactly as a
+run would send it; otherwise the raw templates are shown. The model shown
+is the one a run would use, unless --model overrides it.`,
+	Args: cobra.ExactArgs(1),
+	RunE: showCase,
+}
+
 // --- validate command ---
 
 var validateCmd = &cobra.Command{
@@ -238,6 +904,27 @@ prompt template variables.`,
 	},
 }
 
+// --- lint command ---
+
+var lintCmd = &cobra.Command{
+	Use:   "lint",
+	Short: "Flag judge configurations that cannot measure much",
+	Long: `Check suites for judges and cases that run fine but say little
+about the output:
+
+  no-judges         a case has no judges at all
+  trivial-contains  a contains judge's value appears verbatim in the
+                    rendered prompt, or is empty
+  inert-judge       a judge's weight is so small next to the others that
+                    its result can never change pass/fail (judges are
+                    treated as passing or failing outright)
+  short-rubric      an llm or agent rubric has fewer than 10 words
+
+Each finding is printed with the case's file and line. The command exits
+non-zero when there are findings.`,
+	RunE: runLint,
+}
+
 // --- init command ---
 
 var initCmd = &cobra.Command{
@@ -351,38 +1038,211 @@ func writeExampleSuite(path string) error {
 	return writeYAML(path, data)
 }
 
+// addRunFlags registers the 'eval run' flags on fs. Commands that run on
+// behalf of 'eval run', like 'eval rerun' and 'eval collect', parse its
+// recorded arguments into a fresh set.
+func addRunFlags(fs *pflag.FlagSet) {
+	fs.StringSliceP("suite", "s", nil, "Eval suite YAML file or directory of suites (repeatable)")
+	fs.StringP("prompt", "p", "", "Override prompt template")
+	fs.StringP("model", "m", "", "Override model name")
+	fs.StringP("config", "c", "eval.yaml", "Path to config file")
+	fs.IntP("concurrency", "j", 0, "Max concurrent eval cases (0 = use config default)")
+	fs.StringSliceP("tag", "t", nil, "Tag this run for identification (repeatable)")
+	fs.String("note", "", "Free-form note describing this run")
+	fs.StringToString("label", nil, "Experiment label as key=value (repeatable)")
+	fs.StringP("output", "o", "", "Output file path, or directory when running several suites (default: results/<timestamp>-<suite>.json)")
+	fs.BoolP("verbose", "v", false, "Enable verbose output")
+	fs.String("provider", "", "Provider name from config (default: the only configured provider)")
+	fs.String("prompt-dir", "prompts", "Directory containing prompt templates")
+	fs.Bool("no-judge", false, "Save outputs and traces without scoring (score later with 'eval rejudge')")
+	fs.Bool("deterministic", false, "Normalize IDs and timestamps so results can be stored as golden files")
+	fs.Bool("preflight", false, "Check the provider and judge model with one request and one case before the full run")
+	fs.Bool("check-tokens", false, "Fail cases whose first request would not fit in the model's context window, before sending it")
+	fs.Float64("cost-warn", 0, "Warn once the run's estimated agent cost in USD passes this amount (0 = never)")
+	fs.Bool("fail-fast", false, "Stop starting cases after the first failure; the rest are reported as skipped")
+	fs.Bool("batch", false, "Send Anthropic and OpenAI requests through their batch APIs (half price, results can take hours)")
+	fs.Bool("detach", false, "With --batch, submit the requests and exit; continue later with 'eval collect'")
+	fs.String("batch-state", "", "File recording a detached run's batches (default: <output_dir>/batch-pending.json)")
+	fs.String("sample", "", "Run a sample of each suite: N, N%, random:N%, or stratified:N% (by first tag)")
+	fs.Int64("sample-seed", 0, "Seed for --sample (0 = random, or fixed with --deterministic; the seed used is printed)")
+	fs.Float64("spot-check", 0, "Fraction of judge-passed cases to route to human review (overrides spot_check.rate)")
+	fs.Int64("spot-check-seed", 0, "Seed for spot-check sampling (overrides spot_check.seed; 0 = random, or fixed with --deterministic)")
+	fs.Int64("seed", 0, "Seed sent with every agent request for reproducible sampling (overrides the config's seed)")
+
+}
+
 func init() {
 	// run command flags
-	runCmd.Flags().StringP("suite", "s", "", "Path to eval suite YAML file")
-	runCmd.Flags().StringP("prompt", "p", "", "Override prompt template")
-	runCmd.Flags().StringP("model", "m", "", "Override model name")
-	runCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
-	runCmd.Flags().IntP("concurrency", "j", 0, "Max concurrent eval cases (0 = use config default)")
-	runCmd.Flags().StringP("tag", "t", "", "Tag this run for identification")
-	runCmd.Flags().StringP("output", "o", "", "Output file path (default: results/<timestamp>.json)")
-	runCmd.Flags().BoolP("verbose", "v", false, "Enable verbose output")
+	addRunFlags(runCmd.Flags())
+
+	// collect command flags
+	collectCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file (locates the default batch state file)")
+	collectCmd.Flags().String("batch-state", "", "File recording the detached run (default: <output_dir>/batch-pending.json)")
+
+	// rerun command flags
+	rerunCmd.Flags().StringP("output", "o", "", "Output file path (default: a new file in the output directory)")
 
 	// diff command flags
 	diffCmd.Flags().Float64("threshold", 0.0, "Minimum score change to highlight")
 	diffCmd.Flags().String("format", "table", "Output format: table, json, markdown")
+	diffCmd.Flags().Bool("traces", false, "Align case transcripts and show where they diverged")
+	diffCmd.Flags().String("case", "", "With --traces, compare only this case")
+	diffCmd.Flags().StringP("suite", "s", "", "Compare only this suite's cases of combined multi-suite runs")
+	diffCmd.Flags().StringSlice("only", nil, "Only list cases in these categories (e.g. regressed,new)")
+	diffCmd.Flags().StringSlice("fail-on", nil, "Exit non-zero if any case is in these categories (e.g. regressed)")
+	diffCmd.Flags().Bool("summary", false, "Print only the summary counts and warnings, not each case")
+	diffCmd.Flags().Bool("explain", false, "Explain each regressed case with the configured model")
+	diffCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file (needed for --explain)")
+	diffCmd.Flags().String("provider", "", "Provider name for --explain (default: the only configured provider)")
+	diffCmd.Flags().StringP("model", "m", "", "Override the model used by --explain")
+
+	// debug command flags
+	debugCmd.Flags().StringP("suite", "s", "", "Eval suite YAML file")
+	debugCmd.Flags().String("case", "", "Name or ID of the case to run")
+	debugCmd.Flags().StringP("prompt", "p", "", "Override prompt template")
+	debugCmd.Flags().StringP("model", "m", "", "Override model name")
+	debugCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	debugCmd.Flags().String("provider", "", "Provider name from config (default: the case's, or the only configured provider)")
+	debugCmd.Flags().String("prompt-dir", "prompts", "Directory containing prompt templates")
+	debugCmd.Flags().Int("max-iterations", 0, "Maximum tool-call round-trips (0 = the runner's default)")
+	debugCmd.Flags().Bool("no-pause", false, "Print the transcript without pausing at tool calls")
+
+	// rejudge command flags
+	rejudgeCmd.Flags().StringP("suite", "s", "", "Path to the eval suite the run was made from")
+	rejudgeCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file (needed for llm judges)")
+	rejudgeCmd.Flags().String("provider", "", "Provider name for llm judges (default: the only configured provider)")
+	rejudgeCmd.Flags().StringP("model", "m", "", "Override judge model name")
+	rejudgeCmd.Flags().StringP("output", "o", "", "Write the re-scored run here instead of updating it in place")
 
 	// review command flags
 	reviewCmd.Flags().String("filter", "review", "Filter cases: review, fail, all")
+	reviewCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file (for signing the updated results)")
+
+	// results command flags
+	resultsPruneCmd.Flags().Int("keep-last", 0, "Keep the N most recent runs (overrides retention.keep_last)")
+	resultsPruneCmd.Flags().Int("keep-days", 0, "Keep runs from the last N days (overrides retention.keep_days)")
+	resultsPruneCmd.Flags().String("dir", "", "Results directory (default: output_dir from config)")
+	resultsPruneCmd.Flags().String("config", "eval.yaml", "Path to config file")
+	resultsPruneCmd.Flags().Bool("dry-run", false, "Show what would be removed without deleting anything")
+	resultsCmd.AddCommand(resultsPruneCmd)
+
+	// history command flags
+	historyCmd.Flags().String("dir", "", "Results directory (default: output_dir from config)")
+	historyCmd.Flags().String("config", "eval.yaml", "Path to config file")
+	historyCmd.Flags().StringSliceP("tag", "t", nil, "Only show runs carrying this tag (repeatable)")
+	historyCmd.Flags().StringToString("label", nil, "Only show runs with this key=value label (repeatable)")
+	historyCmd.Flags().StringP("suite", "s", "", "Only show runs of this suite")
+
+	// pin command flags
+	pinCmd.Flags().String("dir", "", "Results directory holding pins.json (default: output_dir from config)")
+	pinCmd.Flags().String("config", "eval.yaml", "Path to config file")
+	pinCmd.Flags().String("note", "", "Why this transcript is an exemplar")
+	pinCmd.Flags().Bool("remove", false, "Unpin the case instead")
+
+	// export command flags
+	exportCmd.Flags().String("format", "conversations", "Export format: conversations or finetune")
+	exportCmd.Flags().StringSlice("status", nil, "Only export cases with this status (repeatable, e.g. fail,error)")
+	exportCmd.Flags().Bool("passing-only", false, "Only export cases that passed, by judge or human review")
+	exportCmd.Flags().Bool("metadata", false, "Add run and case metadata to each line")
+	exportCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+
+	// fetch command flags
+	fetchCmd.Flags().String("out", "suites", "Directory to write the suite file to")
+	fetchCmd.Flags().String("prompt-dir", "prompts", "Directory to write the prompt file to")
+	fetchCmd.Flags().Int("limit", 0, "Only convert the first N records (0 for all)")
+
+	// optimize command flags
+	optimizeCmd.Flags().StringP("suite", "s", "", "Eval suite YAML file (required)")
+	optimizeCmd.Flags().StringP("prompt", "p", "", "Prompt to optimize (default: the suite's)")
+	optimizeCmd.Flags().Int("iterations", evalkit.DefaultOptimizeIterations, "Number of candidate prompts to try")
+	optimizeCmd.Flags().Float64("dev-fraction", 0.5, "Fraction of cases in the dev split; the rest are held out for testing")
+	optimizeCmd.Flags().String("provider", "", "Provider name (default: the config's default provider)")
+	optimizeCmd.Flags().StringP("model", "m", "", "Model to run the agent with")
+	optimizeCmd.Flags().String("meta-provider", "", "Provider that proposes prompt edits (default: --provider)")
+	optimizeCmd.Flags().String("meta-model", "", "Model that proposes prompt edits (default: --model)")
+	optimizeCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	optimizeCmd.Flags().String("prompt-dir", "prompts", "Directory containing prompt templates")
+	optimizeCmd.Flags().StringP("output", "o", "", "Write the best prompt to this file instead of stdout")
+
+	// estimate command flags
+	estimateCmd.Flags().StringP("suite", "s", "", "Eval suite YAML file (required)")
+	estimateCmd.Flags().StringP("prompt", "p", "", "Override prompt template")
+	estimateCmd.Flags().StringP("model", "m", "", "Override model name")
+	estimateCmd.Flags().String("provider", "", "Provider name from config (default: the only configured provider)")
+	estimateCmd.Flags().Bool("offline", false, "Estimate locally instead of asking the provider's token counting API")
+	estimateCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	estimateCmd.Flags().String("prompt-dir", "prompts", "Directory containing prompt templates")
+
+	// verify command flags
+	verifyCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	verifyCmd.Flags().String("key-env", "", "Environment variable holding the signing key (default: the config's signing.key_env)")
+
+	// matrix command flags
+	matrixCmd.Flags().String("format", "table", "Output format: table, markdown, html")
+	matrixCmd.Flags().StringP("output", "o", "", "Write to this file instead of stdout")
+
+	// badge command flags
+	badgeCmd.Flags().StringP("out", "o", "badge.svg", "Path to write the SVG badge to")
+	badgeCmd.Flags().String("label", "evals", "Text on the left of the badge")
+	badgeCmd.Flags().String("link", "", "URL the markdown snippet links the badge to")
+
+	// bundle command flags
+	bundleCmd.Flags().StringP("output", "o", "", "Bundle path (default: <run>.bundle.zip)")
+	bundleCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	bundleCmd.Flags().StringP("suite", "s", "", "Suite file (default: found by name in --suite-dir)")
+	bundleCmd.Flags().String("suite-dir", "suites", "Directory to search for the run's suite")
+	bundleCmd.Flags().String("prompt-dir", "prompts", "Directory to search for the run's prompts")
 
 	// list command flags
 	listCmd.PersistentFlags().String("dir", ".", "Base directory to search")
 	listCmd.AddCommand(listPromptsCmd)
 	listCmd.AddCommand(listSuitesCmd)
+	listCasesCmd.Flags().StringP("suite", "s", "", "Only list cases of the suite with this name")
+	listCasesCmd.Flags().StringSliceP("tags", "t", nil, "Only list cases with any of these tags")
+	listCasesCmd.Flags().StringP("grep", "g", "", "Only list cases matching this regular expression")
+	listCmd.AddCommand(listCasesCmd)
+
+	// show command flags
+	showCaseCmd.Flags().Bool("interpolate", false, "Render the prompt with the case input")
+	showCaseCmd.Flags().StringP("model", "m", "", "Model to show instead of the configured one")
+	showCaseCmd.Flags().StringP("prompt", "p", "", "Prompt to use instead of the suite's")
+	showCaseCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	showCaseCmd.Flags().String("suite-dir", "suites", "Directory containing eval suites")
+	showCaseCmd.Flags().String("prompt-dir", "prompts", "Directory containing prompt templates")
+	showCmd.AddCommand(showCaseCmd)
 
 	// validate command flags
 	validateCmd.Flags().String("suite", "", "Path to suite file to validate")
 	validateCmd.Flags().String("config", "eval.yaml", "Path to config file to validate")
 
 	// register all subcommands
+	// lint command flags
+	lintCmd.Flags().StringSliceP("suite", "s", []string{"suites"}, "Eval suite YAML file or directory of suites (repeatable)")
+	lintCmd.Flags().StringP("prompt", "p", "", "Prompt to check instead of each suite's")
+	lintCmd.Flags().StringP("config", "c", "eval.yaml", "Path to config file")
+	lintCmd.Flags().String("prompt-dir", "prompts", "Directory containing prompt templates")
+
 	rootCmd.AddCommand(runCmd)
+	rootCmd.AddCommand(collectCmd)
+	rootCmd.AddCommand(rerunCmd)
+	rootCmd.AddCommand(fetchCmd)
+	rootCmd.AddCommand(optimizeCmd)
+	rootCmd.AddCommand(estimateCmd)
+	rootCmd.AddCommand(verifyCmd)
+	rootCmd.AddCommand(debugCmd)
 	rootCmd.AddCommand(diffCmd)
+	rootCmd.AddCommand(rejudgeCmd)
 	rootCmd.AddCommand(reviewCmd)
+	rootCmd.AddCommand(resultsCmd)
+	rootCmd.AddCommand(historyCmd)
+	rootCmd.AddCommand(pinCmd)
+	rootCmd.AddCommand(exportCmd)
+	rootCmd.AddCommand(badgeCmd)
+	rootCmd.AddCommand(matrixCmd)
+	rootCmd.AddCommand(bundleCmd)
 	rootCmd.AddCommand(listCmd)
+	rootCmd.AddCommand(showCmd)
 	rootCmd.AddCommand(validateCmd)
+	rootCmd.AddCommand(lintCmd)
 	rootCmd.AddCommand(initCmd)
 }
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/jdgilhuly/go_eval_agent/evalkit"
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// optimizeRun implements 'eval optimize': it searches for a better prompt
+// on the suite's dev split and compares the best one with the original on
+// the test split.
+func optimizeRun(cmd *cobra.Command, args []string) error {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	iterations, _ := cmd.Flags().GetInt("iterations")
+	if iterations < 1 {
+		return fmt.Errorf("--iterations must be at least 1, got %d", iterations)
+	}
+	fraction, _ := cmd.Flags().GetFloat64("dev-fraction")
+	if fraction <= 0 || fraction > 1 {
+		return fmt.Errorf("--dev-fraction must be greater than 0 and at most 1, got %g", fraction)
+	}
+
+	suitePath, _ := cmd.Flags().GetString("suite")
+	if suitePath == "" {
+		return fmt.Errorf("--suite is required")
+	}
+	s, err := suite.Load(suitePath)
+	if err != nil {
+		return fmt.Errorf("loading suite: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return fmt.Errorf("invalid suite: %w", err)
+	}
+	promptName, _ := cmd.Flags().GetString("prompt")
+	if promptName == "" {
+		promptName = s.Prompt
+	}
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	pv, err := findPrompt(promptDir, promptName)
+	if err != nil {
+		return err
+	}
+
+	providerName, _ := cmd.Flags().GetString("provider")
+	p, model, err := newProvider(cfg, providerName)
+	if err != nil {
+		return err
+	}
+	if m, _ := cmd.Flags().GetString("model"); m != "" {
+		model = m
+	}
+	opts := evalkit.OptimizeOptions{Meta: p, MetaModel: model, Iterations: iterations}
+	if name, _ := cmd.Flags().GetString("meta-provider"); name != "" {
+		if opts.Meta, opts.MetaModel, err = newProvider(cfg, name); err != nil {
+			return err
+		}
+	}
+	if m, _ := cmd.Flags().GetString("meta-model"); m != "" {
+		opts.MetaModel = m
+	}
+
+	dev, test := s.Split(fraction)
+	opts.Test = test
+	testCases := 0
+	if test != nil {
+		testCases = len(test.Cases)
+	}
+	fmt.Printf("Optimizing prompt %q on suite %q: %d dev cases, %d test cases, %d iterations\n\n",
+		pv.Name, s.Name, len(dev.Cases), testCases, iterations)
+
+	var baseline result.Stats
+	fmt.Printf("%-4s %-24s %9s %9s %9s\n", "ITER", "PROMPT", "PASS", "SCORE", "DELTA")
+	opts.OnCandidate = func(c evalkit.Candidate) {
+		if c.Err != nil {
+			fmt.Printf("%-4d %-24s %s\n", c.Iteration, "-", c.Err)
+			return
+		}
+		st := c.Summary.Stats
+		if c.Iteration == 0 {
+			baseline = st
+		}
+		fmt.Printf("%-4d %-24s %8.1f%% %9.3f %+8.1f%%\n",
+			c.Iteration, c.Prompt.Name, st.PassRate*100, st.AvgScore, (st.PassRate-baseline.PassRate)*100)
+	}
+
+	kit := evalkit.New(p, model,
+		evalkit.WithConcurrency(cfg.Concurrency),
+		evalkit.WithTimeout(cfg.Timeout),
+		evalkit.WithProviders(providerCache(cfg)),
+		evalkit.WithRetryBudget(sharedRetryBudget(cfg)),
+		evalkit.WithTemplateEnv(cfg.TemplateEnv...),
+	)
+	res, err := kit.Optimize(cmd.Context(), dev, pv, opts)
+	if err != nil {
+		return err
+	}
+
+	best := res.BestCandidate()
+	fmt.Println()
+	if res.Best == 0 {
+		fmt.Println("No candidate beat the original prompt on the dev split.")
+	} else {
+		st := best.Summary.Stats
+		fmt.Printf("Best variant: %s (iteration %d)\n", best.Prompt.Name, best.Iteration)
+		fmt.Printf("  dev:  pass rate %.1f%% -> %.1f%% (%+.1f%%), avg score %.3f -> %.3f (%+.3f)\n",
+			baseline.PassRate*100, st.PassRate*100, (st.PassRate-baseline.PassRate)*100,
+			baseline.AvgScore, st.AvgScore, st.AvgScore-baseline.AvgScore)
+	}
+	if res.BaselineTest != nil && res.Best != 0 {
+		b, t := res.BaselineTest.Stats, res.BestTest.Stats
+		fmt.Printf("  test: pass rate %.1f%% -> %.1f%% (%+.1f%%), avg score %.3f -> %.3f (%+.3f)\n",
+			b.PassRate*100, t.PassRate*100, (t.PassRate-b.PassRate)*100,
+			b.AvgScore, t.AvgScore, t.AvgScore-b.AvgScore)
+	}
+	if res.Best == 0 {
+		return nil
+	}
+	if best.Rationale != "" {
+		fmt.Printf("  rationale: %s\n", best.Rationale)
+	}
+
+	data, err := yaml.Marshal(best.Prompt)
+	if err != nil {
+		return fmt.Errorf("encoding prompt: %w", err)
+	}
+	outPath, _ := cmd.Flags().GetString("output")
+	if outPath == "" {
+		fmt.Printf("\n%s", data)
+		return nil
+	}
+	if err := os.WriteFile(outPath, data, 0o644); err != nil {
+		return fmt.Errorf("writing %s: %w", outPath, err)
+	}
+	fmt.Printf("Best prompt written to %s\n", outPath)
+	return nil
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"runtime/debug"
+	"strconv"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// seedFlags maps Manifest.Seeds keys to the run flags that set them.
+var seedFlags = map[string]string{
+	"sample":     "sample-seed",
+	"spot_check": "spot-check-seed",
+	"provider":   "seed",
+}
+
+// rerunRun implements 'eval rerun': it repeats the 'eval run' invocation
+// recorded in a run's manifest with the same seeds, limited to the suites
+// of that run, and reports what changed since. It refuses to overwrite
+// the run's own results.
+func rerunRun(cmd *cobra.Command, args []string) error {
+	summary, err := result.LoadSummary(args[0])
+	if err != nil {
+		return fmt.Errorf("loading run results: %w", err)
+	}
+	m := summary.Manifest
+	if m == nil || len(m.Args) == 0 {
+		return fmt.Errorf("run %s has no manifest to rerun from", summary.RunID)
+	}
+	fmt.Printf("Rerunning %s: eval run %s\n", summary.RunID, strings.Join(m.Args, " "))
+	fs, err := parseRunArgs(m.Args)
+	if err != nil {
+		return err
+	}
+	// The recorded output path is the original run's results.
+	out, _ := cmd.Flags().GetString("output")
+	if err := fs.Set("output", out); err != nil {
+		return err
+	}
+	for key, seed := range m.Seeds {
+		if err := fs.Set(seedFlags[key], strconv.FormatInt(seed, 10)); err != nil {
+			return fmt.Errorf("setting %s seed: %w", key, err)
+		}
+	}
+	return executeRun(cmd.Context(), runParams{flags: fs, rerun: m, keep: args[0]})
+}
+
+// rerunSuites returns the suites of the run being repeated.
+func rerunSuites(suites []*suite.EvalSuite, m *result.Manifest) ([]*suite.EvalSuite, error) {
+	if len(m.SuiteHashes) == 0 {
+		return suites, nil
+	}
+	var out []*suite.EvalSuite
+	for _, s := range suites {
+		if _, ok := m.SuiteHashes[s.Name]; ok {
+			out = append(out, s)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("none of the run's suites were found")
+	}
+	return out, nil
+}
+
+// printChanges reports how the definitions about to run differ from those
+// recorded in m.
+func printChanges(m *result.Manifest, runs []*suiteRun) {
+	var current *result.Manifest
+	for _, sr := range runs {
+		current = current.Merge(sr.manifest)
+	}
+	changes := m.Changes(current)
+	if len(changes) == 0 {
+		fmt.Println("No definitions changed since the original run")
+		return
+	}
+	fmt.Println("Changed since the original run:")
+	for _, c := range changes {
+		fmt.Printf("  %s\n", c)
+	}
+}
+
+// newManifest records what a suite run is produced from.
+func newManifest(args []string, configHash string, sr *suiteRun, providerName, model string, seeds map[string]int64) *result.Manifest {
+	m := &result.Manifest{
+		Args:             args,
+		FrameworkVersion: frameworkVersion(),
+		ConfigHash:       configHash,
+		SuiteHashes:      map[string]string{sr.suite.Name: sr.hash},
+		PromptHashes:     map[string]string{sr.prompt.Name: fingerprint(sr.prompt)},
+		Provider:         providerName,
+		Model:            model,
+		Seeds:            seeds,
+		MockFingerprints: make(map[string]string),
+	}
+	for _, c := range sr.suite.Cases {
+		id := c.ID
+		if id == "" {
+			id = c.Name
+		}
+		m.MockFingerprints[sr.suite.Name+"/"+id] = fingerprint(struct {
+			Default []mock.MockConfig
+			Case    []mock.MockConfig
+		}{sr.suite.DefaultMocks, c.Mocks})
+	}
+	return m
+}
+
+// fingerprint returns a short hash of v's YAML encoding, which lists map
+// keys in sorted order, so equal definitions hash alike.
+func fingerprint(v interface{}) string {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// frameworkVersion returns the module version of this build or, for a
+// development build, its VCS revision.
+func frameworkVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	if v := info.Main.Version; v != "" && v != "(devel)" {
+		return v
+	}
+	var rev, modified string
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			rev = s.Value
+		case "vcs.modified":
+			if s.Value == "true" {
+				modified = "-dirty"
+			}
+		}
+	}
+	if rev == "" {
+		return "devel"
+	}
+	if len(rev) > 12 {
+		rev = rev[:12]
+	}
+	return "devel+" + rev + modified
+}
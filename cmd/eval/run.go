@@ -0,0 +1,1054 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/evalkit"
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/diff"
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/report"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/review"
+	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// suiteRun holds everything needed to execute and save one suite.
+type suiteRun struct {
+	suite   *suite.EvalSuite
+	prompt  *prompt.PromptVariant
+	judges  [][]judge.JudgeConfig
+	result  *runner.RunResult
+	summary *result.RunSummary
+	err     error
+
+	// verdicts holds cases judged as soon as they finished, because
+	// --fail-fast or a dependent case needed the outcome; evalkit.Score
+	// reuses them rather than judging again.
+	mu       sync.Mutex
+	verdicts map[int]judge.CompositeResult
+
+	// hash fingerprints the suite as loaded, before any sampling, and
+	// manifest records what the run is produced from.
+	hash     string
+	manifest *result.Manifest
+}
+
+// judgeNow scores case idx of the suite from its run result and records
+// the verdict for evalkit.Score.
+func (sr *suiteRun) judgeNow(idx int, cr runner.CaseResult) judge.CompositeResult {
+	c := sr.suite.Cases[idx]
+	res := judge.NewCompositeScorer(c.Threshold).Score(evalkit.JudgeInput(c, cr.FinalResponse, cr.Trace), sr.judges[idx])
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+	if sr.verdicts == nil {
+		sr.verdicts = make(map[int]judge.CompositeResult)
+	}
+	sr.verdicts[idx] = res
+	return res
+}
+
+// runEval implements 'eval run'.
+func runEval(cmd *cobra.Command, args []string) error {
+	return executeRun(cmd.Context(), runParams{flags: cmd.Flags()})
+}
+
+// runParams is what executeRun runs from: the parsed 'eval run' flags,
+// and what a command running on behalf of 'eval run' adds to them.
+type runParams struct {
+	flags *pflag.FlagSet
+
+	// rerun is the manifest of the run 'eval rerun' repeats: only its
+	// suites run, and the definitions changed since are listed first.
+	rerun *result.Manifest
+	// keep is a result file the run must not overwrite, such as the
+	// original of a rerun.
+	keep string
+}
+
+// executeRun loads the suites and prompts, executes every case against
+// the configured provider, scores the results with each suite's judges,
+// and saves one summary per suite. Multiple suites run concurrently and
+// share the configured concurrency limit.
+func executeRun(ctx context.Context, params runParams) error {
+	fs := params.flags
+	cfgPath, _ := fs.GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	configHash := fingerprint(cfg.Redacted())
+	if fs.Changed("spot-check") {
+		rate, _ := fs.GetFloat64("spot-check")
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("--spot-check must be between 0 and 1, got %g", rate)
+		}
+		cfg.SpotCheck.Rate = rate
+	}
+	if seed, _ := fs.GetInt64("spot-check-seed"); seed != 0 {
+		cfg.SpotCheck.Seed = seed
+	}
+
+	if b, _ := fs.GetBool("batch"); b {
+		for name, pc := range cfg.Providers {
+			if t := pc.ResolvedType(name); t == config.ProviderAnthropic || t == config.ProviderOpenAI {
+				pc.Batch = true
+				cfg.Providers[name] = pc
+			}
+		}
+	}
+	batch := usesBatch(cfg)
+	if detach, _ := fs.GetBool("detach"); detach {
+		if !batch {
+			return fmt.Errorf("--detach requires --batch or a provider with batch: true")
+		}
+		store, err := provider.OpenBatchStore(batchStatePath(fs, cfg))
+		if err != nil {
+			return err
+		}
+		detachedBatches = store
+	}
+
+	verbose, _ := fs.GetBool("verbose")
+	if verbose {
+		fmt.Printf("Config loaded: concurrency=%d timeout=%s output=%s\n",
+			cfg.Concurrency, cfg.Timeout, cfg.OutputDir)
+	}
+
+	suitePaths, _ := fs.GetStringSlice("suite")
+	if len(suitePaths) == 0 {
+		return fmt.Errorf("--suite is required")
+	}
+	suites, err := suite.LoadPaths(suitePaths)
+	if err != nil {
+		return fmt.Errorf("loading suites: %w", err)
+	}
+	if len(suites) == 0 {
+		return fmt.Errorf("no suites found in %v", suitePaths)
+	}
+	if params.rerun != nil {
+		if suites, err = rerunSuites(suites, params.rerun); err != nil {
+			return err
+		}
+	}
+
+	providerName, _ := fs.GetString("provider")
+	p, model, err := newProvider(cfg, providerName)
+	if err != nil {
+		return err
+	}
+	if m, _ := fs.GetString("model"); m != "" {
+		model = m
+	}
+
+	judgeOpts := judge.Options{Provider: p, Model: model, Ctx: ctx}
+	promptOverride, _ := fs.GetString("prompt")
+	promptDir, _ := fs.GetString("prompt-dir")
+
+	deterministic, _ := fs.GetBool("deterministic")
+	seeds := make(map[string]int64)
+	var sample *suite.SampleSpec
+	var rng *rand.Rand
+	if spec, _ := fs.GetString("sample"); spec != "" {
+		sp, err := suite.ParseSampleSpec(spec)
+		if err != nil {
+			return fmt.Errorf("invalid --sample: %w", err)
+		}
+		seed, _ := fs.GetInt64("sample-seed")
+		seed = resolveSeed(seed, deterministic)
+		sample, rng = &sp, rand.New(rand.NewSource(seed))
+		seeds["sample"] = seed
+		fmt.Printf("Sampling cases (%s, seed %d)\n", sp, seed)
+	}
+	noJudge, _ := fs.GetBool("no-judge")
+	var spotRNG *rand.Rand
+	if cfg.SpotCheck.Enabled() && !noJudge {
+		seed := resolveSeed(cfg.SpotCheck.Seed, deterministic)
+		spotRNG = rand.New(rand.NewSource(seed))
+		seeds["spot_check"] = seed
+	}
+	providerSeed := cfg.Seed
+	if fs.Changed("seed") {
+		seed, _ := fs.GetInt64("seed")
+		providerSeed = &seed
+	}
+	if providerSeed != nil {
+		seeds["provider"] = *providerSeed
+	}
+
+	// Resolve prompts and build every case's judges up front so a bad
+	// definition fails before any provider calls are made.
+	runs := make([]*suiteRun, len(suites))
+	for i, s := range suites {
+		if err := s.Validate(); err != nil {
+			return fmt.Errorf("invalid suite: %w", err)
+		}
+		hash := fingerprint(s)
+		if sample != nil {
+			n := len(s.Cases)
+			s = s.Sample(*sample, rng)
+			fmt.Printf("Suite %q: sampled %d of %d cases\n", s.Name, len(s.Cases), n)
+		}
+		promptName := promptOverride
+		if promptName == "" {
+			promptName = s.Prompt
+		}
+		pv, err := findPrompt(promptDir, promptName)
+		if err != nil {
+			return fmt.Errorf("suite %q: %w", s.Name, err)
+		}
+		judges, err := evalkit.BuildJudges(s, judgeOpts)
+		if err != nil {
+			return err
+		}
+		runs[i] = &suiteRun{suite: s, prompt: pv, judges: judges, hash: hash}
+	}
+	// The recorded arguments carry the seeds resolved above, so a rerun or
+	// collect samples the same cases and sends the same requests.
+	invocation := recordedArgs(fs, seeds)
+	for _, sr := range runs {
+		sr.manifest = newManifest(invocation, configHash, sr, p.Name(), model, seeds)
+	}
+	if params.rerun != nil {
+		printChanges(params.rerun, runs)
+	}
+	multi := len(runs) > 1
+	outFlag, _ := fs.GetString("output")
+	if params.keep != "" {
+		for _, name := range outputSuites(runs) {
+			if path := outputPath(outFlag, multi, cfg.OutputDir, name, time.Now(), deterministic); samePath(path, params.keep) {
+				return fmt.Errorf("results would overwrite %s; choose another path with --output", params.keep)
+			}
+		}
+	}
+	if store := detachedBatches; store != nil && len(store.Args()) == 0 {
+		if err := store.SetArgs(invocation); err != nil {
+			return err
+		}
+	}
+
+	concurrency, _ := fs.GetInt("concurrency")
+	timeout := cfg.Timeout
+	if batch {
+		// Every case must be in flight at once for its requests to share a
+		// batch, and a batch may take hours to end.
+		fmt.Println("Batch mode: requests go through the provider's batch API at half price; each agent turn may take minutes to hours.")
+		if concurrency == 0 {
+			for _, sr := range runs {
+				concurrency += len(sr.suite.Cases)
+			}
+		}
+		timeout = max(timeout, batchCaseTimeout)
+	}
+	if concurrency == 0 {
+		concurrency = cfg.Concurrency
+	}
+	failFast, _ := fs.GetBool("fail-fast")
+	rcfg := runner.Config{
+		Concurrency: concurrency,
+		Timeout:     timeout,
+		Model:       model,
+		FailFast:    failFast,
+		Providers:   providerCache(cfg),
+		Meter:       &runner.Meter{},
+		RetryBudget: sharedRetryBudget(cfg),
+		Seed:        providerSeed,
+
+		ValidateOutput: judge.ValidateOutput,
+		TemplateEnv:    cfg.TemplateEnv,
+		TraceLimits: trace.Limits{
+			MaxMessages: cfg.TraceLimits.MaxMessages,
+			MaxBytes:    cfg.TraceLimits.MaxBytes,
+		},
+	}
+	rcfg.CheckContextWindow, _ = fs.GetBool("check-tokens")
+	if warn, _ := fs.GetFloat64("cost-warn"); warn > 0 {
+		rcfg.Meter.WarnCost = warn
+		rcfg.Meter.OnWarn = func(cost float64) {
+			fmt.Fprintf(os.Stderr, "warning: estimated agent cost $%.4f has passed --cost-warn $%.4f\n", cost, warn)
+		}
+	}
+	// Without judging, only errors count as failures for --fail-fast and
+	// depends_on.
+	if !noJudge {
+		bySuite := make(map[*suite.EvalSuite]*suiteRun, len(runs))
+		for _, sr := range runs {
+			bySuite[sr.suite] = sr
+		}
+		rcfg.Passed = func(s *suite.EvalSuite, idx int, cr runner.CaseResult) bool {
+			return bySuite[s].judgeNow(idx, cr).Pass
+		}
+	}
+	r := runner.New(rcfg)
+
+	if pre, _ := fs.GetBool("preflight"); pre && batch {
+		fmt.Println("Preflight: skipped in batch mode, where one request can take hours")
+	} else if pre {
+		fmt.Printf("Preflight: checking %s/%s\n", p.Name(), model)
+		if err := preflight(ctx, r, rcfg, runs, p, judgeOpts, noJudge); err != nil {
+			return fmt.Errorf("preflight failed, not starting the run: %w", err)
+		}
+	}
+
+	var wg sync.WaitGroup
+	for _, sr := range runs {
+		fmt.Printf("Running suite %q (%d cases) with %s/%s\n", sr.suite.Name, len(sr.suite.Cases), p.Name(), model)
+		prefix := ""
+		if multi {
+			prefix = sr.suite.Name + "/"
+		}
+		progress := progressPrinter(prefix, rcfg.Meter)
+		wg.Add(1)
+		go func(sr *suiteRun, progress runner.ProgressFunc) {
+			defer wg.Done()
+			sr.result, sr.err = r.Run(ctx, sr.suite, sr.prompt, p, progress)
+		}(sr, progress)
+	}
+	wg.Wait()
+
+	if store := detachedBatches; store != nil {
+		if n, batches := store.Pending(); n > 0 {
+			fmt.Printf("\n%d requests pending in batches %s.\nRun 'eval collect' once they end to continue the run; its state is saved in %s.\n",
+				n, strings.Join(batches, ", "), store.Path())
+			return nil
+		}
+	}
+
+	tags, _ := fs.GetStringSlice("tag")
+	note, _ := fs.GetString("note")
+	labels, _ := fs.GetStringToString("label")
+	color := isTerminal(os.Stdout)
+	pins, err := result.LoadPins(result.PinsPath(cfg.OutputDir))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "warning: %v; not showing pinned cases\n", err)
+	}
+
+	var summaries []*result.RunSummary
+	var unmet []string
+	for _, sr := range runs {
+		if sr.err != nil {
+			return fmt.Errorf("running suite %q: %w", sr.suite.Name, sr.err)
+		}
+		sr.summary = result.FromRunResult(sr.result)
+		sr.summary.Manifest = sr.manifest
+		sr.summary.Tags = tags
+		sr.summary.Note = note
+		if len(labels) > 0 {
+			sr.summary.Labels = labels
+		}
+		if noJudge {
+			sr.summary.MarkUnjudged()
+		} else {
+			evalkit.Score(sr.summary, sr.suite, sr.judges, sr.verdicts)
+		}
+		if spotRNG != nil {
+			spotChecker(cfg.SpotCheck).Sample(sr.summary, spotRNG)
+		}
+		if deterministic {
+			sr.summary.Normalize()
+		}
+
+		outPath := outputPath(outFlag, multi, cfg.OutputDir, sr.suite.Name, sr.result.StartTime, deterministic)
+		if err := sr.summary.Save(outPath); err != nil {
+			return err
+		}
+		if err := signResult(cfg, outPath); err != nil {
+			return err
+		}
+
+		fmt.Println()
+		if multi {
+			fmt.Printf("Suite %s\n", sr.suite.Name)
+		}
+		if verbose {
+			report.PrintVerbose(os.Stdout, sr.summary, color)
+		} else if !multi {
+			report.PrintSummaryTable(os.Stdout, sr.summary, color)
+		}
+		report.PrintPins(os.Stdout, sr.summary, pins, color)
+		if pc := sr.suite.PassCriteria; pc != nil {
+			baseline, err := findBaseline(*pc, cfg.OutputDir, sr.suite.Name, sr.result.StartTime, outPath)
+			if err != nil {
+				return fmt.Errorf("suite %q: %w", sr.suite.Name, err)
+			}
+			criteria := diff.CheckCriteria(sr.suite, sr.summary, baseline)
+			criteria.Print(os.Stdout)
+			if !criteria.Pass() {
+				unmet = append(unmet, sr.suite.Name)
+			}
+		}
+		fmt.Printf("Results saved to %s\n", outPath)
+		summaries = append(summaries, sr.summary)
+	}
+
+	if multi {
+		// The combined summary keeps each case's suite, so it can be
+		// diffed as a whole or one suite at a time with 'eval diff --suite'.
+		combined := result.Combine(summaries)
+		outPath := outputPath(outFlag, multi, cfg.OutputDir, result.CombinedSuiteName, combined.StartTime, deterministic)
+		if err := combined.Save(outPath); err != nil {
+			return err
+		}
+		if err := signResult(cfg, outPath); err != nil {
+			return err
+		}
+		fmt.Println()
+		report.PrintSummaryTable(os.Stdout, combined, color)
+		fmt.Printf("Combined results saved to %s\n", outPath)
+	}
+	if m := report.BuildMatrix(summaries); len(m.Models) > 1 {
+		fmt.Println()
+		report.PrintMatrix(os.Stdout, m, color)
+	}
+	if store := detachedBatches; store != nil {
+		if err := store.Remove(); err != nil {
+			fmt.Fprintf(os.Stderr, "warning: removing batch state: %v\n", err)
+		}
+	}
+	if b := rcfg.RetryBudget; b.Exhausted() {
+		used := b.Used()
+		return fmt.Errorf("retry budget exhausted after %d retries (%s backing off); cases not yet started were skipped, the provider may be unavailable",
+			used.Retries, report.FormatDuration(used.Backoff))
+	}
+	if len(unmet) > 0 {
+		return fmt.Errorf("pass criteria not met for %s", strings.Join(unmet, ", "))
+	}
+	return nil
+}
+
+// findBaseline returns the run that pass criteria count regressions
+// against: pc.Baseline when set, otherwise the newest earlier run of the
+// same suite in outputDir that started before start, other than the one
+// just saved to outPath. It returns nil when there is no earlier run.
+func findBaseline(pc suite.PassCriteria, outputDir, suiteName string, start time.Time, outPath string) (*result.RunSummary, error) {
+	if pc.Baseline != "" {
+		s, err := result.LoadSummary(pc.Baseline)
+		if err != nil {
+			return nil, fmt.Errorf("loading pass_criteria baseline: %w", err)
+		}
+		return s, nil
+	}
+	runs, err := result.LoadDir(outputDir)
+	if err != nil {
+		return nil, nil
+	}
+	for _, rf := range runs {
+		if rf.Summary.SuiteName == suiteName && filepath.Clean(rf.Path) != filepath.Clean(outPath) &&
+			rf.Summary.StartTime.Before(start) {
+			return rf.Summary, nil
+		}
+	}
+	return nil, nil
+}
+
+// rejudgeRun implements 'eval rejudge': it re-scores the saved outputs of
+// a run against the judges currently defined in its suite and saves the
+// updated summary.
+func rejudgeRun(cmd *cobra.Command, args []string) error {
+	summary, err := result.LoadSummary(args[0])
+	if err != nil {
+		return fmt.Errorf("loading run results: %w", err)
+	}
+
+	suitePath, _ := cmd.Flags().GetString("suite")
+	if suitePath == "" {
+		return fmt.Errorf("--suite is required")
+	}
+	s, err := suite.Load(suitePath)
+	if err != nil {
+		return fmt.Errorf("loading suite: %w", err)
+	}
+
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+
+	// Only model-backed judges need a provider; deterministic rejudging
+	// works without any provider configuration.
+	var judgeOpts judge.Options
+	if suiteUsesJudge(s, "llm", "agent") {
+		providerName, _ := cmd.Flags().GetString("provider")
+		p, model, err := newProvider(cfg, providerName)
+		if err != nil {
+			return err
+		}
+		if m, _ := cmd.Flags().GetString("model"); m != "" {
+			model = m
+		}
+		judgeOpts = judge.Options{Provider: p, Model: model, Ctx: cmd.Context()}
+	}
+
+	judges, err := evalkit.BuildJudges(s, judgeOpts)
+	if err != nil {
+		return err
+	}
+	evalkit.Score(summary, s, judges, nil)
+
+	outPath, _ := cmd.Flags().GetString("output")
+	if outPath == "" {
+		outPath = args[0]
+	}
+	if err := summary.Save(outPath); err != nil {
+		return err
+	}
+	if err := signResult(cfg, outPath); err != nil {
+		return err
+	}
+
+	report.PrintSummaryTable(os.Stdout, summary, isTerminal(os.Stdout))
+	fmt.Printf("Results saved to %s\n", outPath)
+	return nil
+}
+
+// providerCache returns a factory for cases that name their own provider.
+// Each provider is constructed once and shared by every case using it.
+func providerCache(cfg *config.Config) runner.ProviderFactory {
+	type entry struct {
+		p     provider.Provider
+		model string
+		err   error
+	}
+	var mu sync.Mutex
+	cache := make(map[string]entry)
+	return func(name string) (provider.Provider, string, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		e, ok := cache[name]
+		if !ok {
+			e.p, e.model, e.err = newProvider(cfg, name)
+			cache[name] = e
+		}
+		return e.p, e.model, e.err
+	}
+}
+
+// preflight checks the provider with one trivial request, repeated for
+// each other provider and model that cases select, and then, unless
+// judging is disabled, runs and judges the first case that uses a
+// model-backed judge, so auth and model problems in either the agent or
+// the judge configuration surface once before the full run starts.
+func preflight(ctx context.Context, r *runner.Runner, rcfg runner.Config, runs []*suiteRun, p provider.Provider, judgeOpts judge.Options, noJudge bool) error {
+	model, timeout := rcfg.Model, rcfg.Timeout
+	if err := runner.Preflight(ctx, p, model, timeout); err != nil {
+		return err
+	}
+	checked := map[suite.ProviderOverride]bool{{Model: model}: true}
+	for _, sr := range runs {
+		for _, c := range sr.suite.Cases {
+			o := suite.ProviderOverride{Provider: c.Provider, Model: c.Model}
+			if o.Model == "" && o.Provider == "" {
+				o.Model = model
+			}
+			if checked[o] {
+				continue
+			}
+			checked[o] = true
+			cp, cm := p, model
+			if o.Provider != "" {
+				var err error
+				if cp, cm, err = rcfg.Providers(o.Provider); err != nil {
+					return fmt.Errorf("case %q: %w", c.Name, err)
+				}
+			}
+			if o.Model != "" {
+				cm = o.Model
+			}
+			if err := runner.Preflight(ctx, cp, cm, timeout); err != nil {
+				return fmt.Errorf("case %q: %w", c.Name, err)
+			}
+		}
+	}
+	if noJudge {
+		return nil
+	}
+
+	for _, sr := range runs {
+		for _, c := range sr.suite.Cases {
+			if !caseUsesJudge(c, "llm", "agent") {
+				continue
+			}
+			one := *sr.suite
+			one.Cases = []suite.EvalCase{c}
+			rr, err := r.Run(ctx, &one, sr.prompt, p, nil)
+			if err != nil {
+				return err
+			}
+			if cr := rr.Cases[0]; cr.Error != "" {
+				return fmt.Errorf("preflight case %q: %s", c.Name, cr.Error)
+			}
+			// Fresh judges, so the check's usage is not charged to the run.
+			judges, err := judge.FromConfigs(c.Judges, judgeOpts)
+			if err != nil {
+				return err
+			}
+			res := judge.NewCompositeScorer(c.Threshold).Score(evalkit.JudgeInput(c, rr.Cases[0].FinalResponse, rr.Cases[0].Trace), judges)
+			for _, js := range res.Scores {
+				if js.Status == judge.StatusError {
+					return fmt.Errorf("preflight case %q: %s judge: %s", c.Name, js.JudgeName, js.Reason)
+				}
+			}
+			return nil
+		}
+	}
+	return nil
+}
+
+// suiteUsesJudge reports whether any case in s uses a judge of one of the
+// given types.
+func suiteUsesJudge(s *suite.EvalSuite, judgeTypes ...string) bool {
+	for _, c := range s.Cases {
+		if caseUsesJudge(c, judgeTypes...) {
+			return true
+		}
+	}
+	return false
+}
+
+// caseUsesJudge reports whether c uses a judge of one of the given types.
+func caseUsesJudge(c suite.EvalCase, judgeTypes ...string) bool {
+	for _, j := range c.Judges {
+		for _, t := range judgeTypes {
+			if j.Type == t {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// outputPath picks where a suite's summary is written. With several suites
+// an explicit --output is treated as a directory.
+func outputPath(outFlag string, multi bool, outputDir, suiteName string, start time.Time, deterministic bool) string {
+	if outFlag != "" && !multi {
+		return outFlag
+	}
+	dir := outputDir
+	if outFlag != "" {
+		dir = outFlag
+	}
+	if deterministic {
+		return filepath.Join(dir, suiteName+".json")
+	}
+	return result.DefaultPath(dir, suiteName, start)
+}
+
+// deterministicSeed seeds the sampling a --deterministic run is not given
+// a seed for, so that its golden files cover the same cases every time.
+const deterministicSeed = 1
+
+// resolveSeed returns seed or, when it is 0 for unset, deterministicSeed
+// in a deterministic run and a time-derived seed otherwise.
+func resolveSeed(seed int64, deterministic bool) int64 {
+	switch {
+	case seed != 0:
+		return seed
+	case deterministic:
+		return deterministicSeed
+	default:
+		return time.Now().UnixNano()
+	}
+}
+
+// outputSuites returns the names under which a run's summaries are saved:
+// each suite's and, with several suites, the combined summary's.
+func outputSuites(runs []*suiteRun) []string {
+	names := make([]string, 0, len(runs)+1)
+	for _, sr := range runs {
+		names = append(names, sr.suite.Name)
+	}
+	if len(runs) > 1 {
+		names = append(names, result.CombinedSuiteName)
+	}
+	return names
+}
+
+// samePath reports whether a and b name the same file.
+func samePath(a, b string) bool {
+	ai, errA := os.Stat(a)
+	bi, errB := os.Stat(b)
+	if errA == nil && errB == nil {
+		return os.SameFile(ai, bi)
+	}
+	absA, errA := filepath.Abs(a)
+	absB, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && absA == absB
+}
+
+// signResult signs the result file at path when the config has a signing
+// key, so it can later be checked with 'eval verify'.
+func signResult(cfg *config.Config, path string) error {
+	if cfg.Signing.KeyEnv == "" {
+		return nil
+	}
+	key, err := cfg.Signing.Key()
+	if err != nil {
+		return err
+	}
+	if _, err := result.SignFile(path, key, cfg.Signing.KeyID, time.Now()); err != nil {
+		return fmt.Errorf("signing results: %w", err)
+	}
+	return nil
+}
+
+// progressPrinter returns a progress callback that reports each completed
+// case on stdout with the run-wide token and cost tally so far. Case names
+// are prefixed with prefix, the suite name for runs covering several
+// suites.
+func progressPrinter(prefix string, meter *runner.Meter) runner.ProgressFunc {
+	return func(index, total int, caseName string, elapsed time.Duration, err error) {
+		status := "done"
+		if err != nil {
+			switch {
+			case errors.Is(err, runner.ErrSkipped):
+				status = err.Error()
+			case errors.Is(err, provider.ErrBatchPending):
+				status = "pending"
+			default:
+				status = "error: " + err.Error()
+			}
+		}
+		fmt.Printf("  [%d/%d] %s%s (%s) %s | %s\n", index+1, total, prefix, caseName, report.FormatDuration(elapsed), status, meter)
+	}
+}
+
+// findPrompt loads the prompt variant with the given name from dir.
+func findPrompt(dir, name string) (*prompt.PromptVariant, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no prompt specified: set 'prompt' in the suite or pass --prompt")
+	}
+	return evalkit.LoadPrompt(dir, name)
+}
+
+var (
+	httpClientOnce sync.Once
+	httpClient     *http.Client
+)
+
+// sharedHTTPClient returns the HTTP client every provider in this process
+// uses, built once from the config's http settings so all providers share
+// one connection pool.
+func sharedHTTPClient(cfg *config.Config) *http.Client {
+	httpClientOnce.Do(func() {
+		httpClient = provider.NewHTTPClient(provider.NewTransport(provider.TransportConfig{
+			MaxIdleConnsPerHost: cfg.HTTP.MaxIdleConnsPerHost,
+			MaxConnsPerHost:     cfg.HTTP.MaxConnsPerHost,
+			IdleConnTimeout:     cfg.HTTP.IdleConnTimeout,
+			KeepAlive:           cfg.HTTP.KeepAlive,
+			DisableHTTP2:        cfg.HTTP.DisableHTTP2,
+		}))
+	})
+	return httpClient
+}
+
+var (
+	retryBudgetOnce sync.Once
+	retryBudget     *provider.RetryBudget
+)
+
+// sharedRetryBudget returns the retry budget every provider in this
+// process draws from, or nil when the config sets no run-level limit.
+func sharedRetryBudget(cfg *config.Config) *provider.RetryBudget {
+	retryBudgetOnce.Do(func() {
+		rc := cfg.RetryConfig
+		if rc.MaxTotalRetries > 0 || rc.MaxTotalBackoff > 0 {
+			retryBudget = &provider.RetryBudget{MaxRetries: rc.MaxTotalRetries, MaxBackoff: rc.MaxTotalBackoff}
+		}
+	})
+	return retryBudget
+}
+
+var (
+	pricingOnce sync.Once
+	pricingErr  error
+)
+
+// loadPricing merges the config's prices file into the pricing table and
+// sets its fallback rate, once per process, before any provider's costs
+// are estimated.
+func loadPricing(cfg *config.Config) error {
+	pricingOnce.Do(func() {
+		if cfg.Prices != "" {
+			pricingErr = provider.LoadPricing(cfg.Prices)
+		}
+		if pf := cfg.PriceFallback; pf != nil {
+			provider.SetFallbackPricing(&provider.Pricing{InputPerMillion: pf.Input, OutputPerMillion: pf.Output})
+		}
+	})
+	return pricingErr
+}
+
+// secretPatterns returns the patterns outbound requests are scanned for,
+// or nil when the config does not enable secret scanning.
+func secretPatterns(cfg *config.Config) ([]provider.SecretPattern, error) {
+	if !cfg.SecretScan.Enabled {
+		return nil, nil
+	}
+	return provider.SecretPatterns(cfg.SecretScan.Patterns)
+}
+
+var (
+	quotasMu sync.Mutex
+	quotas   = make(map[string]*provider.Quota)
+)
+
+// sharedQuota returns the usage quota every instance of the named provider
+// in this process draws from, or nil when the config sets none.
+func sharedQuota(cfg *config.Config, name string) *provider.Quota {
+	qc, ok := cfg.Quotas[name]
+	if !ok || (qc.MaxRequests == 0 && qc.MaxTokens == 0) {
+		return nil
+	}
+	quotasMu.Lock()
+	defer quotasMu.Unlock()
+	if q, ok := quotas[name]; ok {
+		return q
+	}
+	q := &provider.Quota{Name: name, MaxRequests: qc.MaxRequests, MaxTokens: qc.MaxTokens}
+	quotas[name] = q
+	return q
+}
+
+var (
+	rateLimitersMu sync.Mutex
+	rateLimiters   = make(map[string]*provider.RateLimiter)
+)
+
+// sharedRateLimiter returns the rate limiter every instance of the named
+// provider in this process waits on, or nil when the config sets none.
+func sharedRateLimiter(cfg *config.Config, name string) *provider.RateLimiter {
+	rl := cfg.Providers[name].RateLimit
+	if rl == nil || (rl.RequestsPerMinute == 0 && rl.TokensPerMinute == 0) {
+		return nil
+	}
+	rateLimitersMu.Lock()
+	defer rateLimitersMu.Unlock()
+	if l, ok := rateLimiters[name]; ok {
+		return l
+	}
+	l := &provider.RateLimiter{RequestsPerMinute: rl.RequestsPerMinute, TokensPerMinute: rl.TokensPerMinute}
+	rateLimiters[name] = l
+	return l
+}
+
+// batchCaseTimeout is the case timeout in batch mode: the time the batch
+// APIs allow a batch before it expires.
+const batchCaseTimeout = 24 * time.Hour
+
+// detachedBatches records the batches of a run started with --detach. Its
+// batch providers return as soon as their requests are submitted.
+var detachedBatches *provider.BatchStore
+
+// batchStatePath returns where a detached run records its batches.
+func batchStatePath(fs *pflag.FlagSet, cfg *config.Config) string {
+	if path, _ := fs.GetString("batch-state"); path != "" {
+		return path
+	}
+	return filepath.Join(cfg.OutputDir, "batch-pending.json")
+}
+
+// collectRun implements 'eval collect': it repeats the detached run whose
+// batches are recorded in the batch state file. Requests whose batches
+// have ended get their results; the next turns of agent loops are
+// submitted in new batches. Once no request is pending the run finishes
+// like any other and the state file is removed.
+func collectRun(cmd *cobra.Command, args []string) error {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	path := batchStatePath(cmd.Flags(), cfg)
+	store, err := provider.OpenBatchStore(path)
+	if err != nil {
+		return err
+	}
+	saved := store.Args()
+	if len(saved) == 0 {
+		return fmt.Errorf("no detached run recorded in %s; start one with 'eval run --batch --detach'", path)
+	}
+	fmt.Printf("Collecting: eval run %s\n", strings.Join(saved, " "))
+	fs, err := parseRunArgs(saved)
+	if err != nil {
+		return err
+	}
+	return executeRun(cmd.Context(), runParams{flags: fs})
+}
+
+// parseRunArgs parses recorded 'eval run' arguments into a fresh set of
+// its flags.
+func parseRunArgs(args []string) (*pflag.FlagSet, error) {
+	fs := pflag.NewFlagSet("run", pflag.ContinueOnError)
+	addRunFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return nil, fmt.Errorf("parsing recorded arguments: %w", err)
+	}
+	return fs, nil
+}
+
+// recordedArgs returns the 'eval run' arguments that repeat the run whose
+// flags fs holds: each flag not at its default, with seeds, keyed like
+// Manifest.Seeds, in place of the flags that set them.
+func recordedArgs(fs *pflag.FlagSet, seeds map[string]int64) []string {
+	resolved := make(map[string]string, len(seeds))
+	for key, seed := range seeds {
+		resolved[seedFlags[key]] = strconv.FormatInt(seed, 10)
+	}
+	var args []string
+	fs.VisitAll(func(f *pflag.Flag) {
+		if v, ok := resolved[f.Name]; ok {
+			args = append(args, "--"+f.Name+"="+v)
+			return
+		}
+		if !f.Changed {
+			return
+		}
+		switch v := f.Value.(type) {
+		case pflag.SliceValue:
+			for _, elem := range v.GetSlice() {
+				args = append(args, "--"+f.Name+"="+csvQuote(elem))
+			}
+		default:
+			if f.Value.Type() == "stringToString" {
+				m, _ := fs.GetStringToString(f.Name)
+				keys := make([]string, 0, len(m))
+				for k := range m {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					args = append(args, "--"+f.Name+"="+csvQuote(k+"="+m[k]))
+				}
+			} else if s := f.Value.String(); s != f.DefValue {
+				args = append(args, "--"+f.Name+"="+s)
+			}
+		}
+	})
+	return append(args, fs.Args()...)
+}
+
+// csvQuote quotes s the way list flags read their comma-separated values,
+// so that it is parsed back as a single element.
+func csvQuote(s string) string {
+	if !strings.ContainsAny(s, ",\"\n") {
+		return s
+	}
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
+
+// usesBatch reports whether any configured provider sends its requests in
+// batches.
+func usesBatch(cfg *config.Config) bool {
+	for _, pc := range cfg.Providers {
+		if pc.Batch {
+			return true
+		}
+	}
+	return false
+}
+
+// newProvider constructs the named provider from config, sharing this
+// process's retry budget, quotas, rate limiters, and HTTP client, and
+// returns it with its configured model. A provider with fallbacks is
+// wrapped in a CompositeProvider that tries them in turn. If name is empty
+// and exactly one provider is configured, that provider is used.
+func newProvider(cfg *config.Config, name string) (provider.Provider, string, error) {
+	if err := loadPricing(cfg); err != nil {
+		return nil, "", err
+	}
+	if name == "" {
+		if len(cfg.Providers) != 1 {
+			names := make([]string, 0, len(cfg.Providers))
+			for n := range cfg.Providers {
+				names = append(names, n)
+			}
+			sort.Strings(names)
+			return nil, "", fmt.Errorf("--provider is required when config defines %d providers %v", len(names), names)
+		}
+		for n := range cfg.Providers {
+			name = n
+		}
+	}
+
+	pc, ok := cfg.Providers[name]
+	if !ok {
+		return nil, "", fmt.Errorf("provider %q not found in config", name)
+	}
+	p, err := buildProvider(cfg, name, pc)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(pc.Fallbacks) > 0 {
+		fallbacks := make([]provider.Fallback, len(pc.Fallbacks))
+		for i, fb := range pc.Fallbacks {
+			fpc := cfg.Providers[fb]
+			fp, err := buildProvider(cfg, fb, fpc)
+			if err != nil {
+				return nil, "", fmt.Errorf("fallback %q: %w", fb, err)
+			}
+			fallbacks[i] = provider.Fallback{Provider: fp, Model: fpc.Model}
+		}
+		p = provider.NewCompositeProvider(p, fallbacks...)
+	}
+	return p, pc.Model, nil
+}
+
+// buildProvider constructs the named provider from its config entry,
+// sharing this process's retry budget, quotas, rate limiters, and HTTP
+// client.
+func buildProvider(cfg *config.Config, name string, pc config.ProviderConfig) (provider.Provider, error) {
+	patterns, err := secretPatterns(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return provider.NewFromConfig(name, pc, provider.Deps{
+		MaxRetries:  cfg.RetryConfig.MaxRetries,
+		RetryBudget: sharedRetryBudget(cfg),
+		Quota:       sharedQuota(cfg, name),
+		RateLimiter: sharedRateLimiter(cfg, name),
+		HTTPClient:  sharedHTTPClient(cfg),
+		BatchStore:  detachedBatches,
+
+		SecretPatterns: patterns,
+	})
+}
+
+// isTerminal reports whether f is attached to a terminal, used to decide
+// whether to emit ANSI colors.
+func isTerminal(f *os.File) bool {
+	fi, err := f.Stat()
+	if err != nil {
+		return false
+	}
+	return fi.Mode()&os.ModeCharDevice != 0
+}
+
+// spotChecker converts the spot_check config section.
+func spotChecker(c config.SpotCheckConfig) review.SpotCheck {
+	sc := review.SpotCheck{Rate: c.Rate}
+	for _, b := range c.Bands {
+		sc.Bands = append(sc.Bands, review.ScoreBand{Min: b.Min, Max: b.Max, Rate: b.Rate})
+	}
+	return sc
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// showCase implements 'eval show case': it prints everything that defines
+// one case's behavior without running it.
+func showCase(cmd *cobra.Command, args []string) error {
+	suiteName, caseName, ok := strings.Cut(args[0], "/")
+	if !ok || suiteName == "" || caseName == "" {
+		return fmt.Errorf("expected <suite>/<case>, got %q", args[0])
+	}
+
+	suiteDir, _ := cmd.Flags().GetString("suite-dir")
+	promptDir, _ := cmd.Flags().GetString("prompt-dir")
+	suites, err := suite.LoadDir(suiteDir)
+	if err != nil {
+		return fmt.Errorf("loading suites from %s: %w", suiteDir, err)
+	}
+	var es *suite.EvalSuite
+	for _, s := range suites {
+		if s.Name == suiteName {
+			es = s
+			break
+		}
+	}
+	if es == nil {
+		return fmt.Errorf("suite %q not found in %s", suiteName, suiteDir)
+	}
+	c, ok := findCase(es, caseName)
+	if !ok {
+		return fmt.Errorf("case %q not found in suite %q", caseName, suiteName)
+	}
+
+	promptName, _ := cmd.Flags().GetString("prompt")
+	if promptName == "" {
+		promptName = es.Prompt
+	}
+	pv, err := findPrompt(promptDir, promptName)
+	if err != nil {
+		return fmt.Errorf("suite %q: %w", es.Name, err)
+	}
+
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	model, _ := cmd.Flags().GetString("model")
+	if model == "" {
+		model = caseModel(cfg, c)
+	}
+
+	if interp, _ := cmd.Flags().GetBool("interpolate"); interp {
+		env := prompt.WithEnv(cfg.TemplateEnv)
+		vars, err := prompt.ExpandEnv(c.Input, env)
+		if err != nil {
+			return fmt.Errorf("interpolating inputs: %w", err)
+		}
+		if pv, err = pv.Interpolate(vars, env); err != nil {
+			return err
+		}
+	}
+
+	printCase(os.Stdout, es, c, pv, model)
+	return nil
+}
+
+// findCase returns the case in s whose name or ID is name.
+func findCase(s *suite.EvalSuite, name string) (suite.EvalCase, bool) {
+	for _, c := range s.Cases {
+		if c.Name == name || (c.ID != "" && c.ID == name) {
+			return c, true
+		}
+	}
+	return suite.EvalCase{}, false
+}
+
+// caseModel returns the model a run would use for c: its own model, or
+// the model of its provider, or of the only configured provider.
+func caseModel(cfg *config.Config, c suite.EvalCase) string {
+	if c.Model != "" {
+		return c.Model
+	}
+	if pc, ok := cfg.Providers[c.Provider]; ok {
+		return pc.Model
+	}
+	if len(cfg.Providers) == 1 {
+		for _, pc := range cfg.Providers {
+			return pc.Model
+		}
+	}
+	return "(set by --model or --provider at run time)"
+}
+
+func printCase(w io.Writer, s *suite.EvalSuite, c suite.EvalCase, pv *prompt.PromptVariant, model string) {
+	fmt.Fprintf(w, "Case:   %s\n", c.Name)
+	if c.ID != "" {
+		fmt.Fprintf(w, "ID:     %s\n", c.ID)
+	}
+	fmt.Fprintf(w, "Suite:  %s\n", s.Name)
+	if c.Source != "" {
+		fmt.Fprintf(w, "Source: %s\n", c.Source)
+	}
+	fmt.Fprintf(w, "Prompt: %s\n", pv.Name)
+	fmt.Fprintf(w, "Model:  %s\n", model)
+	if c.Provider != "" {
+		fmt.Fprintf(w, "Provider: %s\n", c.Provider)
+	}
+	if len(c.Tags) > 0 {
+		fmt.Fprintf(w, "Tags:   %s\n", strings.Join(c.Tags, ", "))
+	}
+	if len(c.DependsOn) > 0 {
+		fmt.Fprintf(w, "Depends on: %s\n", strings.Join(c.DependsOn, ", "))
+	}
+	if c.Timeout > 0 {
+		fmt.Fprintf(w, "Timeout: %s\n", c.Timeout)
+	}
+
+	printSection(w, "Input", yamlString(c.Input))
+	printSection(w, "System prompt", pv.System)
+	printSection(w, "User prompt", pv.User)
+
+	if len(pv.Tools) > 0 {
+		var b strings.Builder
+		for _, t := range pv.Tools {
+			fmt.Fprintf(&b, "%s: %s\n", t.Name, t.Description)
+			schema, _ := json.MarshalIndent(t.Parameters, "  ", "  ")
+			fmt.Fprintf(&b, "  %s\n", schema)
+		}
+		if pv.ToolChoice != "" {
+			fmt.Fprintf(&b, "tool_choice: %s\n", pv.ToolChoice)
+		}
+		printSection(w, "Tools", b.String())
+	}
+	if len(c.Mocks) > 0 {
+		printSection(w, "Mocks", yamlString(c.Mocks))
+	}
+	if len(c.Judges) > 0 {
+		printSection(w, "Judges", yamlString(c.Judges))
+	}
+	if c.ExpectedOutput != "" {
+		printSection(w, "Expected output", c.ExpectedOutput)
+	}
+	if len(c.ExpectedTools) > 0 {
+		printSection(w, "Expected tools", strings.Join(c.ExpectedTools, ", "))
+	}
+}
+
+func printSection(w io.Writer, title, body string) {
+	fmt.Fprintf(w, "\n--- %s ---\n", title)
+	if body = strings.TrimRight(body, "\n"); body == "" {
+		body = "(empty)"
+	}
+	fmt.Fprintln(w, body)
+}
+
+// yamlString renders v as YAML without the unset fields, which would
+// otherwise bury a mock or judge under every option it does not use.
+func yamlString(v interface{}) string {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	var generic interface{}
+	if err := yaml.Unmarshal(data, &generic); err != nil {
+		return string(data)
+	}
+	if data, err = yaml.Marshal(pruneZero(generic)); err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(data)
+}
+
+// pruneZero drops map entries holding zero values, recursively.
+func pruneZero(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, e := range t {
+			e = pruneZero(e)
+			if isZeroValue(e) {
+				delete(t, k)
+			} else {
+				t[k] = e
+			}
+		}
+	case []interface{}:
+		for i := range t {
+			t[i] = pruneZero(t[i])
+		}
+	}
+	return v
+}
+
+func isZeroValue(v interface{}) bool {
+	switch t := v.(type) {
+	case nil:
+		return true
+	case string:
+		return t == "" || t == "0s"
+	case int:
+		return t == 0
+	case float64:
+		return t == 0
+	case bool:
+		return !t
+	case map[string]interface{}:
+		return len(t) == 0
+	case []interface{}:
+		return len(t) == 0
+	}
+	return false
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/spf13/cobra"
+)
+
+// verifyRun implements 'eval verify': it checks a result file against its
+// detached signature.
+func verifyRun(cmd *cobra.Command, args []string) error {
+	cfgPath, _ := cmd.Flags().GetString("config")
+	cfg, err := config.LoadOrDefault(cfgPath)
+	if err != nil {
+		return fmt.Errorf("loading config: %w", err)
+	}
+	signing := cfg.Signing
+	if env, _ := cmd.Flags().GetString("key-env"); env != "" {
+		signing.KeyEnv = env
+	}
+	key, err := signing.Key()
+	if err != nil {
+		return err
+	}
+
+	sig, err := result.VerifyFile(args[0], key)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("OK %s\n", args[0])
+	if sig.KeyID != "" {
+		fmt.Printf("  key:       %s\n", sig.KeyID)
+	}
+	fmt.Printf("  signed at: %s\n", sig.SignedAt.Format("2006-01-02 15:04:05 MST"))
+	fmt.Printf("  sha256:    %s\n", sig.Digest)
+	return nil
+}
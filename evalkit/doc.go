@@ -0,0 +1,28 @@
+// Package evalkit is the Go entry point for embedding eval runs in other
+// programs. It combines the runner, judges, results, and diff packages
+// behind a small API, so a service can execute a suite, score it, and
+// compare it with a baseline without shelling out to the eval CLI.
+//
+// A Kit holds the provider and model under test and the run settings.
+// Suites and prompts are loaded from the same YAML files the CLI uses, and
+// runs produce the same result.RunSummary the CLI saves, so summaries from
+// either can be compared with each other. Suites can also be generated in
+// Go with suite.New and run directly.
+//
+// Example usage:
+//
+//	kit := evalkit.New(myProvider, "claude-sonnet-4-20250514",
+//	    evalkit.WithConcurrency(4))
+//	s, err := evalkit.LoadSuite("suites/support.yaml")
+//	...
+//	pv, err := evalkit.LoadPrompt("prompts", s.Prompt)
+//	...
+//	summary, err := kit.Run(ctx, s, pv)
+//	...
+//	baseline, err := result.LoadSummary("results/baseline.json")
+//	...
+//	d := evalkit.Compare(baseline, summary, 0.05)
+//	if d.Regressed > 0 {
+//	    ...
+//	}
+package evalkit
@@ -0,0 +1,284 @@
+package evalkit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/diff"
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+// Kit runs suites against one provider and model and scores the results.
+// A Kit is safe for concurrent use; each Run gets its own judges.
+type Kit struct {
+	provider provider.Provider
+	model    string
+
+	// judgeProvider and judgeModel back llm and agent judges; they default
+	// to the provider and model under test.
+	judgeProvider provider.Provider
+	judgeModel    string
+
+	cfg      runner.Config
+	progress runner.ProgressFunc
+}
+
+// Option configures a Kit.
+type Option func(*Kit)
+
+// WithConcurrency sets how many cases run at once. Defaults to 1; a
+// suite's max_concurrency still caps it.
+func WithConcurrency(n int) Option {
+	return func(k *Kit) {
+		k.cfg.Concurrency = n
+	}
+}
+
+// WithTimeout sets the per-case timeout. Defaults to 60 seconds; a case's
+// own timeout takes precedence.
+func WithTimeout(d time.Duration) Option {
+	return func(k *Kit) {
+		k.cfg.Timeout = d
+	}
+}
+
+// WithMaxIterations limits each case's tool-call round-trips. Defaults to
+// runner.MaxToolLoopIterations.
+func WithMaxIterations(n int) Option {
+	return func(k *Kit) {
+		k.cfg.MaxIterations = n
+	}
+}
+
+// WithJudge sets the provider and model used by llm and agent judges, so
+// grading can use a different model from the agent under test.
+func WithJudge(p provider.Provider, model string) Option {
+	return func(k *Kit) {
+		k.judgeProvider = p
+		k.judgeModel = model
+	}
+}
+
+// WithFailFast stops starting cases once one fails. Cases not yet started
+// are recorded as skipped.
+func WithFailFast() Option {
+	return func(k *Kit) {
+		k.cfg.FailFast = true
+	}
+}
+
+// WithProviders resolves the providers named by cases' provider fields.
+// Without it, cases that name a provider error.
+func WithProviders(f runner.ProviderFactory) Option {
+	return func(k *Kit) {
+		k.cfg.Providers = f
+	}
+}
+
+// WithToolExecutor runs real tool calls for suites with on_unmocked_tool:
+// passthrough.
+func WithToolExecutor(t runner.ToolResolver) Option {
+	return func(k *Kit) {
+		k.cfg.ToolExecutor = t
+	}
+}
+
+// WithTemplateEnv allow-lists the environment variables prompts and case
+// inputs may read with {{env "NAME"}}.
+func WithTemplateEnv(names ...string) Option {
+	return func(k *Kit) {
+		k.cfg.TemplateEnv = append(k.cfg.TemplateEnv, names...)
+	}
+}
+
+// WithRetryBudget caps provider retries across the run; cases not yet
+// started once it is exhausted are skipped. The budget only limits
+// providers constructed with it as well.
+func WithRetryBudget(b *provider.RetryBudget) Option {
+	return func(k *Kit) {
+		k.cfg.RetryBudget = b
+	}
+}
+
+// WithProgress sets a callback invoked after each case completes.
+func WithProgress(fn runner.ProgressFunc) Option {
+	return func(k *Kit) {
+		k.progress = fn
+	}
+}
+
+// New returns a Kit that runs the agent on p with model.
+func New(p provider.Provider, model string, opts ...Option) *Kit {
+	k := &Kit{provider: p, model: model}
+	for _, opt := range opts {
+		opt(k)
+	}
+	if k.judgeProvider == nil {
+		k.judgeProvider, k.judgeModel = p, model
+	}
+	return k
+}
+
+// Run executes every case of s with the prompt variant pv, scores each
+// case with its judges, and returns the scored summary. Invalid suites,
+// prompts, and judge definitions are reported before any provider call is
+// made. Errors of individual cases are recorded in the summary rather than
+// returned.
+func (k *Kit) Run(ctx context.Context, s *suite.EvalSuite, pv *prompt.PromptVariant) (*result.RunSummary, error) {
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid suite: %w", err)
+	}
+	if err := pv.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid prompt: %w", err)
+	}
+	judges, err := BuildJudges(s, k.judgeOptions(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	// Cases judged early for fail-fast or depends_on keep their verdict.
+	var mu sync.Mutex
+	verdicts := make(map[int]judge.CompositeResult)
+	cfg := k.cfg
+	cfg.Model = k.model
+	cfg.ValidateOutput = judge.ValidateOutput
+	cfg.Passed = func(_ *suite.EvalSuite, idx int, cr runner.CaseResult) bool {
+		res := judge.NewCompositeScorer(s.Cases[idx].Threshold).Score(JudgeInput(s.Cases[idx], cr.FinalResponse, cr.Trace), judges[idx])
+		mu.Lock()
+		defer mu.Unlock()
+		verdicts[idx] = res
+		return res.Pass
+	}
+
+	rr, err := runner.New(cfg).Run(ctx, s, pv, k.provider, k.progress)
+	if err != nil {
+		return nil, fmt.Errorf("running suite %q: %w", s.Name, err)
+	}
+	summary := result.FromRunResult(rr)
+	Score(summary, s, judges, verdicts)
+	return summary, nil
+}
+
+// Rejudge re-scores the saved outputs in summary against the judges now
+// defined in s, without calling the agent again.
+func (k *Kit) Rejudge(ctx context.Context, summary *result.RunSummary, s *suite.EvalSuite) error {
+	judges, err := BuildJudges(s, k.judgeOptions(ctx))
+	if err != nil {
+		return err
+	}
+	Score(summary, s, judges, nil)
+	return nil
+}
+
+func (k *Kit) judgeOptions(ctx context.Context) judge.Options {
+	return judge.Options{Provider: k.judgeProvider, Model: k.judgeModel, Ctx: ctx}
+}
+
+// Compare diffs current against baseline. Cases are matched by name, and
+// score changes of at most threshold count as unchanged.
+func Compare(baseline, current *result.RunSummary, threshold float64) *diff.DiffResult {
+	return diff.Compare(baseline, current, threshold)
+}
+
+// CheckCriteria evaluates the pass_criteria of s for the current run,
+// counting regressions against baseline, which may be nil. A suite
+// without pass_criteria always passes.
+func CheckCriteria(s *suite.EvalSuite, current, baseline *result.RunSummary) *diff.CriteriaResult {
+	return diff.CheckCriteria(s, current, baseline)
+}
+
+// LoadSuite reads and validates the suite at path.
+func LoadSuite(path string) (*suite.EvalSuite, error) {
+	s, err := suite.Load(path)
+	if err != nil {
+		return nil, fmt.Errorf("loading suite: %w", err)
+	}
+	if err := s.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid suite: %w", err)
+	}
+	return s, nil
+}
+
+// LoadPrompt loads and validates the prompt variant with the given name
+// from dir.
+func LoadPrompt(dir, name string) (*prompt.PromptVariant, error) {
+	if name == "" {
+		return nil, fmt.Errorf("no prompt name given")
+	}
+	prompts, err := prompt.LoadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("loading prompts: %w", err)
+	}
+	for _, p := range prompts {
+		if p.Name == name {
+			if err := p.Validate(); err != nil {
+				return nil, fmt.Errorf("invalid prompt: %w", err)
+			}
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("prompt %q not found in %s", name, dir)
+}
+
+// BuildJudges constructs the judges for every case in s, indexed like
+// s.Cases.
+func BuildJudges(s *suite.EvalSuite, opts judge.Options) ([][]judge.JudgeConfig, error) {
+	judges := make([][]judge.JudgeConfig, len(s.Cases))
+	for i, c := range s.Cases {
+		var err error
+		judges[i], err = judge.FromConfigs(c.Judges, opts)
+		if err != nil {
+			return nil, fmt.Errorf("suite %q case %q: %w", s.Name, c.Name, err)
+		}
+	}
+	return judges, nil
+}
+
+// Score applies each case's judges to its result and recomputes the
+// summary statistics. Results are matched to suite cases by name; cases
+// that errored or no longer exist in the suite are left unscored. Cases
+// with a verdict, keyed by suite case index, are not judged again; pass
+// nil to judge every case.
+func Score(summary *result.RunSummary, s *suite.EvalSuite, caseJudges [][]judge.JudgeConfig, verdicts map[int]judge.CompositeResult) {
+	caseIdx := make(map[string]int, len(s.Cases))
+	for i, c := range s.Cases {
+		caseIdx[c.Name] = i
+	}
+
+	for i := range summary.Results {
+		cr := &summary.Results[i]
+		idx, ok := caseIdx[cr.CaseName]
+		if cr.Error != "" || !ok {
+			continue
+		}
+		if v, ok := verdicts[idx]; ok {
+			cr.ApplyJudgement(v)
+			continue
+		}
+		c := s.Cases[idx]
+		cr.ApplyJudgement(judge.NewCompositeScorer(c.Threshold).Score(JudgeInput(c, cr.FinalResponse, cr.Trace), caseJudges[idx]))
+	}
+	summary.Stats = result.ComputeStats(summary.Results)
+}
+
+// JudgeInput builds what the judges see for one case's output.
+func JudgeInput(c suite.EvalCase, output string, tr *trace.AgentTrace) judge.Input {
+	input := judge.Input{
+		Output:         output,
+		ExpectedOutput: c.ExpectedOutput,
+		Vars:           c.Input,
+	}
+	if tr != nil {
+		input.ToolCalls = tr.GetToolCalls()
+		input.Messages = tr.GetMessages()
+	}
+	return input
+}
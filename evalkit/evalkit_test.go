@@ -0,0 +1,170 @@
+package evalkit
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// answerProvider answers each question with the mapped reply, so results
+// do not depend on the order cases run in.
+type answerProvider struct {
+	answers map[string]string
+
+	mu    sync.Mutex
+	calls int
+}
+
+func (p *answerProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.mu.Lock()
+	p.calls++
+	p.mu.Unlock()
+	q := req.Messages[len(req.Messages)-1].Content
+	return &provider.Response{Content: p.answers[q], StopReason: "end_turn"}, nil
+}
+
+func (p *answerProvider) Name() string { return "answer" }
+
+func testPrompt() *prompt.PromptVariant {
+	return &prompt.PromptVariant{Name: "qa", System: "Answer briefly.", User: "{{.question}}"}
+}
+
+func testSuite() *suite.EvalSuite {
+	return &suite.EvalSuite{
+		Name: "qa-suite",
+		Cases: []suite.EvalCase{
+			{
+				Name:   "capital",
+				Input:  map[string]interface{}{"question": "Capital of France?"},
+				Judges: []suite.JudgeConfig{{Type: "contains", Value: "Paris"}},
+			},
+			{
+				Name:   "sum",
+				Input:  map[string]interface{}{"question": "2+2?"},
+				Judges: []suite.JudgeConfig{{Type: "contains", Value: "4"}},
+			},
+		},
+	}
+}
+
+func TestKit_Run(t *testing.T) {
+	p := &answerProvider{answers: map[string]string{
+		"Capital of France?": "Paris.",
+		"2+2?":               "5",
+	}}
+	kit := New(p, "test-model", WithConcurrency(2))
+
+	summary, err := kit.Run(context.Background(), testSuite(), testPrompt())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if summary.SuiteName != "qa-suite" || len(summary.Results) != 2 {
+		t.Fatalf("summary = %s with %d results, want qa-suite with 2", summary.SuiteName, len(summary.Results))
+	}
+	if summary.Stats.PassedCases != 1 || summary.Stats.FailedCases != 1 {
+		t.Errorf("passed/failed = %d/%d, want 1/1", summary.Stats.PassedCases, summary.Stats.FailedCases)
+	}
+	for _, cr := range summary.Results {
+		if cr.Model != "test-model" {
+			t.Errorf("%s: Model = %q, want test-model", cr.CaseName, cr.Model)
+		}
+		if want := cr.CaseName == "capital"; cr.Pass != want {
+			t.Errorf("%s: Pass = %v, want %v", cr.CaseName, cr.Pass, want)
+		}
+	}
+}
+
+func TestKit_RunInvalidJudge(t *testing.T) {
+	s := testSuite()
+	s.Cases[0].Judges = []suite.JudgeConfig{{Type: "no-such-judge"}}
+	p := &answerProvider{}
+
+	_, err := New(p, "test-model").Run(context.Background(), s, testPrompt())
+	if err == nil || !strings.Contains(err.Error(), "no-such-judge") {
+		t.Fatalf("Run() error = %v, want unknown judge error", err)
+	}
+	if p.calls != 0 {
+		t.Errorf("provider called %d times before the judge error, want 0", p.calls)
+	}
+}
+
+func TestKit_RejudgeAndCompare(t *testing.T) {
+	p := &answerProvider{answers: map[string]string{
+		"Capital of France?": "Paris.",
+		"2+2?":               "4",
+	}}
+	kit := New(p, "test-model")
+	s := testSuite()
+	baseline, err := kit.Run(context.Background(), s, testPrompt())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	p.answers["2+2?"] = "five"
+	current, err := kit.Run(context.Background(), s, testPrompt())
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	d := Compare(baseline, current, 0)
+	if d.Regressed != 1 || d.Unchanged != 1 {
+		t.Errorf("regressed/unchanged = %d/%d, want 1/1", d.Regressed, d.Unchanged)
+	}
+
+	none := 0
+	s.PassCriteria = &suite.PassCriteria{MinPassRate: 0.5, MaxRegressionsVsBaseline: &none}
+	if cr := CheckCriteria(s, current, baseline); cr.Pass() {
+		t.Error("CheckCriteria passed despite a regression")
+	}
+
+	// Loosening the judge makes the saved output pass without a new run.
+	s.Cases[1].Judges = []suite.JudgeConfig{{Type: "regex", Value: `(?i)4|four|five`}}
+	if err := kit.Rejudge(context.Background(), current, s); err != nil {
+		t.Fatalf("Rejudge() error: %v", err)
+	}
+	if current.Stats.PassedCases != 2 {
+		t.Errorf("PassedCases after rejudge = %d, want 2", current.Stats.PassedCases)
+	}
+	if cr := CheckCriteria(s, current, baseline); !cr.Pass() {
+		t.Error("CheckCriteria failed after rejudge")
+	}
+
+	// A case's own threshold replaces the default of 0.5.
+	s.Cases[1].Judges = []suite.JudgeConfig{
+		{Type: "contains", Value: "five"},
+		{Type: "contains", Value: "4"},
+	}
+	s.Cases[1].Threshold = 0.9
+	if err := kit.Rejudge(context.Background(), current, s); err != nil {
+		t.Fatalf("Rejudge() error: %v", err)
+	}
+	if cr := current.Results[1]; cr.Pass || cr.Score != 0.5 {
+		t.Errorf("case with threshold 0.9 scored %v, pass %v; want 0.5 failing", cr.Score, cr.Pass)
+	}
+}
+
+func TestLoadPrompt(t *testing.T) {
+	dir := t.TempDir()
+	data := "name: qa\nsystem: Answer briefly.\nuser: \"{{.question}}\"\n"
+	if err := os.WriteFile(filepath.Join(dir, "qa.yaml"), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	pv, err := LoadPrompt(dir, "qa")
+	if err != nil {
+		t.Fatalf("LoadPrompt() error: %v", err)
+	}
+	if pv.System != "Answer briefly." {
+		t.Errorf("System = %q", pv.System)
+	}
+	if _, err := LoadPrompt(dir, "missing"); err == nil {
+		t.Error("expected error for a missing prompt")
+	}
+}
@@ -0,0 +1,232 @@
+package evalkit
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// DefaultOptimizeIterations is the number of candidate prompts Optimize
+// tries when OptimizeOptions.Iterations is unset.
+const DefaultOptimizeIterations = 5
+
+// maxOptimizeExamples caps the failed cases shown to the meta-model.
+const maxOptimizeExamples = 5
+
+// OptimizeOptions configures Kit.Optimize.
+type OptimizeOptions struct {
+	// Meta and MetaModel propose the prompt edits. They default to the
+	// Kit's judge provider and model.
+	Meta      provider.Provider
+	MetaModel string
+
+	// Iterations is the number of candidates to propose and evaluate.
+	Iterations int
+
+	// Test is the held-out split the original and best prompts are
+	// compared on once the search ends. It may be nil.
+	Test *suite.EvalSuite
+
+	// OnCandidate, if set, is called as each candidate is evaluated.
+	OnCandidate func(Candidate)
+}
+
+// Candidate is a prompt tried by Optimize and its results on the dev split.
+type Candidate struct {
+	// Iteration is 0 for the original prompt.
+	Iteration int
+	Prompt    *prompt.PromptVariant
+
+	// Rationale is the meta-model's explanation of its edit.
+	Rationale string
+
+	// Summary holds the dev results. It is nil when Err is set: the
+	// proposal could not be used or the run failed.
+	Summary *result.RunSummary
+	Err     error
+}
+
+// OptimizeResult is the outcome of Kit.Optimize.
+type OptimizeResult struct {
+	// Candidates holds the original prompt followed by every proposal,
+	// and Best indexes the one that scored highest on the dev split.
+	Candidates []Candidate
+	Best       int
+
+	// BaselineTest and BestTest are the original and best prompts'
+	// results on the test split, when one was given.
+	BaselineTest *result.RunSummary
+	BestTest     *result.RunSummary
+}
+
+// BestCandidate returns the candidate that scored highest on the dev split.
+func (r *OptimizeResult) BestCandidate() Candidate { return r.Candidates[r.Best] }
+
+// Optimize searches for a better version of pv by hill climbing on dev:
+// each iteration shows the meta-model the best prompt so far with the dev
+// cases it failed and asks for an edited prompt, which replaces the best
+// if it scores higher. Candidates are ranked by pass rate, then average
+// score. A proposal that cannot be parsed or run is recorded and the
+// search goes on.
+func (k *Kit) Optimize(ctx context.Context, dev *suite.EvalSuite, pv *prompt.PromptVariant, opts OptimizeOptions) (*OptimizeResult, error) {
+	if opts.Meta == nil {
+		opts.Meta, opts.MetaModel = k.judgeProvider, k.judgeModel
+	}
+	if opts.Iterations <= 0 {
+		opts.Iterations = DefaultOptimizeIterations
+	}
+	record := func(res *OptimizeResult, c Candidate) {
+		res.Candidates = append(res.Candidates, c)
+		if opts.OnCandidate != nil {
+			opts.OnCandidate(c)
+		}
+	}
+
+	baseline, err := k.Run(ctx, dev, pv)
+	if err != nil {
+		return nil, err
+	}
+	res := &OptimizeResult{}
+	record(res, Candidate{Prompt: pv, Summary: baseline})
+
+	for i := 1; i <= opts.Iterations; i++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		best := res.BestCandidate()
+		c := Candidate{Iteration: i}
+		c.Prompt, c.Rationale, c.Err = proposePrompt(ctx, opts.Meta, opts.MetaModel, best.Prompt, best.Summary, i)
+		if c.Err == nil {
+			c.Summary, c.Err = k.Run(ctx, dev, c.Prompt)
+		}
+		record(res, c)
+		if c.Err == nil && better(c.Summary.Stats, best.Summary.Stats) {
+			res.Best = len(res.Candidates) - 1
+		}
+	}
+
+	if opts.Test != nil {
+		if res.BaselineTest, err = k.Run(ctx, opts.Test, pv); err != nil {
+			return nil, err
+		}
+		res.BestTest = res.BaselineTest
+		if res.Best != 0 {
+			if res.BestTest, err = k.Run(ctx, opts.Test, res.BestCandidate().Prompt); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return res, nil
+}
+
+// better reports whether a ranks above b.
+func better(a, b result.Stats) bool {
+	if a.PassRate != b.PassRate {
+		return a.PassRate > b.PassRate
+	}
+	return a.AvgScore > b.AvgScore
+}
+
+const optimizeInstructions = `You improve prompts for an LLM agent. Below are the agent's current system prompt and user prompt template, its results on an evaluation suite, and cases it failed with the judges' reasons.
+
+Propose one revised prompt that fixes the failures without breaking the cases that pass. Keep every {{...}} template reference the user template uses, since cases fill them in. Make targeted edits rather than rewriting everything.
+
+Respond with ONLY a JSON object, no other text:
+{"system": "<revised system prompt>", "user": "<revised user template>", "rationale": "<what you changed and why>"}`
+
+// proposePrompt asks the meta-model for a revision of pv given its dev
+// results.
+func proposePrompt(ctx context.Context, meta provider.Provider, model string, pv *prompt.PromptVariant, summary *result.RunSummary, iteration int) (*prompt.PromptVariant, string, error) {
+	var b strings.Builder
+	b.WriteString(optimizeInstructions)
+	fmt.Fprintf(&b, "\n\n## System prompt\n%s\n\n## User template\n%s\n", pv.System, pv.User)
+	st := summary.Stats
+	fmt.Fprintf(&b, "\n## Results\n%d of %d cases passed (pass rate %.0f%%, average score %.2f).\n",
+		st.PassedCases, st.TotalCases, st.PassRate*100, st.AvgScore)
+
+	shown := 0
+	for _, cr := range summary.Results {
+		if cr.Pass || shown == maxOptimizeExamples {
+			continue
+		}
+		shown++
+		fmt.Fprintf(&b, "\n### Failed case %q (score %.2f)\n", cr.CaseName, cr.Score)
+		if input := firstUserMessage(cr); input != "" {
+			fmt.Fprintf(&b, "Input: %s\n", truncate(input, 1000))
+		}
+		if cr.Error != "" {
+			fmt.Fprintf(&b, "Error: %s\n", truncate(cr.Error, 500))
+		} else {
+			fmt.Fprintf(&b, "Output: %s\n", truncate(cr.FinalResponse, 1000))
+		}
+		for _, js := range cr.Judges {
+			if !js.Pass {
+				fmt.Fprintf(&b, "Judge %s: %s\n", js.JudgeName, truncate(js.Reason, 500))
+			}
+		}
+	}
+
+	resp, err := meta.Complete(ctx, &provider.Request{
+		Model:    model,
+		Messages: []provider.Message{{Role: "user", Content: b.String()}},
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("proposing prompt: %w", err)
+	}
+	text, _ := judge.Preprocess(resp.Content, []string{judge.StepExtractJSON})
+	var proposal struct {
+		System    string `json:"system"`
+		User      string `json:"user"`
+		Rationale string `json:"rationale"`
+	}
+	if err := json.Unmarshal([]byte(text), &proposal); err != nil {
+		return nil, "", fmt.Errorf("parsing proposed prompt: %w", err)
+	}
+	if proposal.User == "" {
+		return nil, "", errors.New("proposed prompt has no user template")
+	}
+
+	next := *pv
+	next.Name = fmt.Sprintf("%s-opt%d", baseName(pv.Name), iteration)
+	next.System, next.User = proposal.System, proposal.User
+	if err := next.Validate(); err != nil {
+		return nil, "", fmt.Errorf("proposed prompt: %w", err)
+	}
+	return &next, proposal.Rationale, nil
+}
+
+// firstUserMessage returns the rendered user prompt of a case.
+func firstUserMessage(cr result.CaseResult) string {
+	if cr.Trace == nil {
+		return ""
+	}
+	for _, m := range cr.Trace.GetMessages() {
+		if m.Role == "user" {
+			return m.Content
+		}
+	}
+	return ""
+}
+
+// baseName strips the suffix proposePrompt adds to a prompt's name.
+func baseName(name string) string {
+	if i := strings.LastIndex(name, "-opt"); i > 0 {
+		return name[:i]
+	}
+	return name
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	return s[:n] + "..."
+}
@@ -0,0 +1,87 @@
+package evalkit
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// scriptedProvider returns its replies in order, repeating the last one.
+type scriptedProvider struct {
+	replies []string
+
+	mu       sync.Mutex
+	requests []*provider.Request
+}
+
+func (p *scriptedProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.requests = append(p.requests, req)
+	reply := p.replies[min(len(p.requests), len(p.replies))-1]
+	return &provider.Response{Content: reply, StopReason: "end_turn"}, nil
+}
+
+func (p *scriptedProvider) Name() string { return "scripted" }
+
+// arithmeticProvider gets sums right only when told to show its work.
+type arithmeticProvider struct{}
+
+func (arithmeticProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	answer := "Paris."
+	if req.Messages[len(req.Messages)-1].Content == "2+2?" {
+		answer = "5"
+		if strings.Contains(req.System, "Show your arithmetic") {
+			answer = "2+2 = 4"
+		}
+	}
+	return &provider.Response{Content: answer, StopReason: "end_turn"}, nil
+}
+
+func (arithmeticProvider) Name() string { return "arithmetic" }
+
+func TestKit_Optimize(t *testing.T) {
+	meta := &scriptedProvider{replies: []string{
+		"I would rewrite the whole thing.",
+		"```json\n" + `{"system": "Answer briefly. Show your arithmetic.", "user": "{{.question}}", "rationale": "sums need work shown"}` + "\n```",
+	}}
+	kit := New(arithmeticProvider{}, "m")
+
+	var seen []int
+	res, err := kit.Optimize(context.Background(), testSuite(), testPrompt(), OptimizeOptions{
+		Meta:        meta,
+		Iterations:  2,
+		Test:        testSuite(),
+		OnCandidate: func(c Candidate) { seen = append(seen, c.Iteration) },
+	})
+	if err != nil {
+		t.Fatalf("Optimize() error: %v", err)
+	}
+	if len(res.Candidates) != 3 || len(seen) != 3 {
+		t.Fatalf("got %d candidates (%d reported), want baseline plus 2", len(res.Candidates), len(seen))
+	}
+	if res.Candidates[1].Err == nil {
+		t.Error("unparsable proposal recorded without an error")
+	}
+	if res.Best != 2 {
+		t.Fatalf("Best = %d, want 2", res.Best)
+	}
+	best := res.BestCandidate()
+	if best.Prompt.Name != "qa-opt2" || best.Rationale != "sums need work shown" || best.Summary.Stats.PassRate != 1 {
+		t.Errorf("best = %s %q pass rate %v", best.Prompt.Name, best.Rationale, best.Summary.Stats.PassRate)
+	}
+	if res.BaselineTest.Stats.PassRate != 0.5 || res.BestTest.Stats.PassRate != 1 {
+		t.Errorf("test pass rates = %v -> %v, want 0.5 -> 1", res.BaselineTest.Stats.PassRate, res.BestTest.Stats.PassRate)
+	}
+
+	// The meta-model saw the failed case and its judge's reason.
+	shown := meta.requests[0].Messages[0].Content
+	for _, want := range []string{`Failed case "sum"`, "Output: 5", `does not contain "4"`} {
+		if !strings.Contains(shown, want) {
+			t.Errorf("meta prompt missing %q:\n%s", want, shown)
+		}
+	}
+}
@@ -6,42 +6,92 @@ import (
 	"strings"
 
 	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 )
 
+// Check is the soft assertion for any condition: when ok is false it fails
+// the case with the message given by msgAndArgs, and the case continues.
+// It returns ok, so later steps that depend on the condition can be
+// skipped.
+//
+//	tc.Check(len(tc.MockCalls("refund")) == 1, "refund issued exactly once")
+func (tc *TestCase) Check(ok bool, msgAndArgs ...interface{}) bool {
+	tc.t.Helper()
+	if !ok {
+		msg := messageFromMsgAndArgs(msgAndArgs)
+		if msg == "" {
+			msg = "check failed"
+		}
+		tc.fail(nil, "%s", msg)
+	}
+	return ok
+}
+
 // AssertOutputContains asserts that the output contains the given substring.
-func (tc *TestCase) AssertOutputContains(substr string) {
+func (tc *TestCase) AssertOutputContains(substr string, msgAndArgs ...interface{}) {
 	tc.t.Helper()
 	if !tc.executed {
-		tc.t.Error("AssertOutputContains called before Input()")
+		tc.fail(msgAndArgs, "AssertOutputContains called before Input()")
 		return
 	}
 	if !strings.Contains(tc.output, substr) {
-		tc.t.Errorf("output does not contain %q\n  output: %s", substr, truncate(tc.output, 200))
+		tc.fail(msgAndArgs, "output does not contain %q\n  output: %s", substr, truncate(tc.output, 200))
 	}
 }
 
 // AssertOutputMatches asserts that the output matches the given regex pattern.
-func (tc *TestCase) AssertOutputMatches(pattern string) {
+func (tc *TestCase) AssertOutputMatches(pattern string, msgAndArgs ...interface{}) {
 	tc.t.Helper()
 	if !tc.executed {
-		tc.t.Error("AssertOutputMatches called before Input()")
+		tc.fail(msgAndArgs, "AssertOutputMatches called before Input()")
 		return
 	}
 	re, err := regexp.Compile(pattern)
 	if err != nil {
-		tc.t.Errorf("invalid regex pattern %q: %v", pattern, err)
+		tc.fail(msgAndArgs, "invalid regex pattern %q: %v", pattern, err)
 		return
 	}
 	if !re.MatchString(tc.output) {
-		tc.t.Errorf("output does not match pattern %q\n  output: %s", pattern, truncate(tc.output, 200))
+		tc.fail(msgAndArgs, "output does not match pattern %q\n  output: %s", pattern, truncate(tc.output, 200))
+	}
+}
+
+// AssertOutputSchema asserts that the output is JSON matching a schema.
+// schema is either a JSON Schema string or a Go value whose type the
+// schema is derived from with judge.SchemaFromStruct.
+func (tc *TestCase) AssertOutputSchema(schema any, msgAndArgs ...interface{}) {
+	tc.t.Helper()
+	if !tc.executed {
+		tc.fail(msgAndArgs, "AssertOutputSchema called before Input()")
+		return
+	}
+
+	doc, ok := schema.(string)
+	if !ok {
+		var err error
+		doc, err = judge.SchemaFromStruct(schema)
+		if err != nil {
+			tc.fail(msgAndArgs, "deriving schema: %v", err)
+			return
+		}
+	}
+
+	j := &judge.SchemaJudge{Schema: doc}
+	result, err := j.Evaluate(judge.Input{Output: tc.output})
+	if err != nil {
+		tc.fail(msgAndArgs, "schema judge evaluation failed: %v", err)
+		return
+	}
+	if !result.Pass {
+		tc.fail(msgAndArgs, "%s\n  output: %s", result.Reason, truncate(tc.output, 200))
 	}
 }
 
 // AssertToolCalled asserts that the named tool was called at least once.
-func (tc *TestCase) AssertToolCalled(toolName string) {
+func (tc *TestCase) AssertToolCalled(toolName string, msgAndArgs ...interface{}) {
 	tc.t.Helper()
 	if tc.trace == nil {
-		tc.t.Error("AssertToolCalled called before Input()")
+		tc.fail(msgAndArgs, "AssertToolCalled called before Input()")
 		return
 	}
 	for _, call := range tc.trace.GetToolCalls() {
@@ -49,53 +99,123 @@ func (tc *TestCase) AssertToolCalled(toolName string) {
 			return
 		}
 	}
-	tc.t.Errorf("tool %q was not called", toolName)
+	tc.fail(msgAndArgs, "tool %q was not called", toolName)
 }
 
 // AssertToolNotCalled asserts that the named tool was never called.
-func (tc *TestCase) AssertToolNotCalled(toolName string) {
+func (tc *TestCase) AssertToolNotCalled(toolName string, msgAndArgs ...interface{}) {
 	tc.t.Helper()
 	if tc.trace == nil {
-		tc.t.Error("AssertToolNotCalled called before Input()")
+		tc.fail(msgAndArgs, "AssertToolNotCalled called before Input()")
 		return
 	}
 	for _, call := range tc.trace.GetToolCalls() {
 		if call.ToolName == toolName {
-			tc.t.Errorf("tool %q was called but should not have been", toolName)
+			tc.fail(msgAndArgs, "tool %q was called but should not have been", toolName)
 			return
 		}
 	}
 }
 
 // AssertToolCalledWith asserts the named tool was called with parameters
-// that are a superset of the given params (subset match).
-func (tc *TestCase) AssertToolCalledWith(toolName string, params map[string]interface{}) {
+// that are a superset of the given params (subset match). Values are
+// compared by type as in judge.ParamsMatch, so keys may be dotted paths
+// into nested arguments and values may be judge.AnyValue or "regex:"
+// patterns. A judge.ParamOptions given first in optsAndMsg enables exact
+// matching, numeric tolerance, or case-insensitive strings; the rest are
+// msgAndArgs.
+func (tc *TestCase) AssertToolCalledWith(toolName string, params map[string]interface{}, optsAndMsg ...interface{}) {
 	tc.t.Helper()
+	var opt judge.ParamOptions
+	msgAndArgs := optsAndMsg
+	if len(optsAndMsg) > 0 {
+		if o, ok := optsAndMsg[0].(judge.ParamOptions); ok {
+			opt, msgAndArgs = o, optsAndMsg[1:]
+		}
+	}
 	if tc.trace == nil {
-		tc.t.Error("AssertToolCalledWith called before Input()")
+		tc.fail(msgAndArgs, "AssertToolCalledWith called before Input()")
 		return
 	}
 	for _, call := range tc.trace.GetToolCalls() {
-		if call.ToolName == toolName && isSubset(params, call.Parameters) {
+		if call.ToolName == toolName && judge.ParamsMatch(params, call.Parameters, opt) {
 			return
 		}
 	}
-	tc.t.Errorf("tool %q was not called with params %v", toolName, params)
+	tc.fail(msgAndArgs, "tool %q was not called with params %v", toolName, params)
+}
+
+// AssertNoMessageMatches asserts that no recorded message matches the
+// given regex pattern, for example that the agent never echoed its system
+// prompt. Only assistant messages are checked unless rolesAndMsg starts
+// with the roles to check ("user", "assistant", "tool"); what follows the
+// roles is msgAndArgs.
+func (tc *TestCase) AssertNoMessageMatches(pattern string, rolesAndMsg ...interface{}) {
+	tc.t.Helper()
+	var roles []string
+	msgAndArgs := rolesAndMsg
+	for len(msgAndArgs) > 0 {
+		role, ok := msgAndArgs[0].(string)
+		if !ok || !containsString(messageRoles, role) {
+			break
+		}
+		roles = append(roles, role)
+		msgAndArgs = msgAndArgs[1:]
+	}
+	if tc.trace == nil {
+		tc.fail(msgAndArgs, "AssertNoMessageMatches called before Input()")
+		return
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		tc.fail(msgAndArgs, "invalid regex pattern %q: %v", pattern, err)
+		return
+	}
+	if len(roles) == 0 {
+		roles = []string{"assistant"}
+	}
+	for i, msg := range tc.trace.GetMessages() {
+		if !containsString(roles, msg.Role) {
+			continue
+		}
+		if loc := re.FindStringIndex(msg.Content); loc != nil {
+			tc.fail(msgAndArgs, "message %d (%s) matches pattern %q: %q",
+				i, msg.Role, pattern, truncate(msg.Content[loc[0]:], 100))
+		}
+	}
+}
+
+// JudgeOptions overrides the harness's judge provider and model for one
+// AssertLLMJudge call. Zero fields keep the harness's settings.
+type JudgeOptions struct {
+	Provider provider.Provider
+	Model    string
 }
 
-// AssertLLMJudge runs the given LLM judge with the specified rubric and
-// checks that the resulting score matches the provided ScoreMatcher. This
-// requires that a real LLM provider is configured on the harness or that
-// a mock provider is set up to return judge-formatted responses.
-func (tc *TestCase) AssertLLMJudge(rubric string, matcher ScoreMatcher) {
+// AssertLLMJudge runs an LLM judge with the specified rubric and checks
+// that the resulting score matches the provided ScoreMatcher. The judge
+// uses the provider and model set by WithJudgeProvider and WithJudgeModel,
+// falling back to the agent's; a JudgeOptions given first in optsAndMsg
+// overrides them for this call, and the rest are msgAndArgs. This requires
+// a real LLM provider or a mock provider set up to return judge-formatted
+// responses.
+func (tc *TestCase) AssertLLMJudge(rubric string, matcher ScoreMatcher, optsAndMsg ...interface{}) {
 	tc.t.Helper()
+	var opt JudgeOptions
+	msgAndArgs := optsAndMsg
+	if len(optsAndMsg) > 0 {
+		if o, ok := optsAndMsg[0].(JudgeOptions); ok {
+			opt, msgAndArgs = o, optsAndMsg[1:]
+		}
+	}
 	if !tc.executed {
-		tc.t.Error("AssertLLMJudge called before Input()")
+		tc.fail(msgAndArgs, "AssertLLMJudge called before Input()")
 		return
 	}
 
 	j := &judge.LLMJudge{
-		Provider: tc.harness.provider,
+		Provider: tc.harness.judgeProviderFor(opt),
+		Model:    tc.harness.judgeModelFor(opt),
 		Rubric:   rubric,
 	}
 
@@ -108,25 +228,77 @@ func (tc *TestCase) AssertLLMJudge(rubric string, matcher ScoreMatcher) {
 
 	result, err := j.Evaluate(input)
 	if err != nil {
-		tc.t.Errorf("LLM judge evaluation failed: %v", err)
+		tc.fail(msgAndArgs, "LLM judge evaluation failed: %v", err)
 		return
 	}
 
 	if !matcher.Match(result.Score) {
-		tc.t.Errorf("LLM judge score %.2f does not satisfy %s (reason: %s)", result.Score, matcher, result.Reason)
+		tc.fail(msgAndArgs, "LLM judge score %.2f does not satisfy %s (reason: %s)", result.Score, matcher, result.Reason)
+	}
+}
+
+// judgeProviderFor returns the provider an LLM judge call uses.
+func (h *Harness) judgeProviderFor(opt JudgeOptions) provider.Provider {
+	switch {
+	case opt.Provider != nil:
+		return opt.Provider
+	case h.judgeProvider != nil:
+		return h.judgeProvider
+	}
+	return h.provider
+}
+
+// judgeModelFor returns the model an LLM judge call uses.
+func (h *Harness) judgeModelFor(opt JudgeOptions) string {
+	switch {
+	case opt.Model != "":
+		return opt.Model
+	case h.judgeModel != "":
+		return h.judgeModel
+	}
+	return h.model
+}
+
+// messageRoles are the roles of recorded messages.
+var messageRoles = []string{"user", "assistant", "tool"}
+
+// fail marks the case failed with the formatted message, prefixed by the
+// caller's msgAndArgs, and records it in the case result.
+func (tc *TestCase) fail(msgAndArgs []interface{}, format string, args ...interface{}) {
+	tc.t.Helper()
+	msg := fmt.Sprintf(format, args...)
+	if intent := messageFromMsgAndArgs(msgAndArgs); intent != "" {
+		msg = intent + ": " + msg
+	}
+	tc.failures = append(tc.failures, msg)
+	tc.t.Error(msg)
+}
+
+// messageFromMsgAndArgs renders optional assertion msgAndArgs: a single
+// value as is, or a format string followed by its arguments.
+func messageFromMsgAndArgs(msgAndArgs []interface{}) string {
+	switch len(msgAndArgs) {
+	case 0:
+		return ""
+	case 1:
+		if s, ok := msgAndArgs[0].(string); ok {
+			return s
+		}
+		return fmt.Sprintf("%+v", msgAndArgs[0])
+	}
+	if format, ok := msgAndArgs[0].(string); ok {
+		return fmt.Sprintf(format, msgAndArgs[1:]...)
 	}
+	return fmt.Sprint(msgAndArgs...)
 }
 
-// isSubset checks whether every key/value in subset exists in superset
-// with the same value (compared via fmt.Sprintf).
-func isSubset(subset, superset map[string]interface{}) bool {
-	for k, v := range subset {
-		sv, ok := superset[k]
-		if !ok || fmt.Sprintf("%v", v) != fmt.Sprintf("%v", sv) {
-			return false
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
 		}
 	}
-	return true
+	return false
 }
 
 func truncate(s string, maxLen int) string {
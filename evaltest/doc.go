@@ -6,6 +6,18 @@
 // a subtest via Harness.Run, receiving a TestCase with helpers for tool
 // mocking, input execution, and assertion methods.
 //
+// Assertions are soft: a failed assertion marks the case failed and is
+// recorded in its CaseResult, and the case goes on, so a missing substring
+// does not hide a later tool-call failure. TestCase.Check does the same
+// for any condition. Every Assert helper takes optional msgAndArgs, a
+// single value or a format string and its arguments, that states the
+// intent of the check and prefixes its failure message.
+//
+// Cases can run concurrently with TestCase.Parallel. A MockProvider shared
+// by parallel cases should script each case's responses with
+// MockProvider.Script, keyed by case name, rather than in one shared
+// queue whose order the cases would race for.
+//
 // Example usage:
 //
 //	func TestMyAgent(t *testing.T) {
@@ -14,7 +26,7 @@
 //	        tc.MockTool("lookup", "John Doe")
 //	        output := tc.Input("Greet the user")
 //	        tc.AssertOutputContains("John")
-//	        tc.AssertToolCalled("lookup")
+//	        tc.AssertToolCalled("lookup", "the name must come from the directory")
 //	    })
 //	}
 package evaltest
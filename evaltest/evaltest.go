@@ -5,19 +5,19 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/jdgilhuly/go_eval_agent/internal/agentloop"
 	"github.com/jdgilhuly/go_eval_agent/pkg/config"
 	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
 	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
 	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
-// maxToolIterations is the maximum number of tool-call round-trips per case
-// to prevent infinite loops.
-const maxToolIterations = 20
-
 // Option configures a Harness.
 type Option func(*Harness)
 
@@ -43,13 +43,42 @@ func WithSystem(system string) Option {
 	}
 }
 
-// WithTools sets the tools available to the agent for all cases.
+// WithTools sets the tools available to the agent for all cases. A case
+// can replace or extend them with TestCase.SetTools and TestCase.AddTools.
 func WithTools(tools []provider.Tool) Option {
 	return func(h *Harness) {
 		h.tools = tools
 	}
 }
 
+// WithSharedMocks registers tool mocks that every case starts with, like a
+// suite's default_mocks. Each case gets its own copy, so response
+// sequences are not shared between cases, and MockTool or MockToolError in
+// a case replaces the shared mock for that tool.
+func WithSharedMocks(mocks ...mock.MockConfig) Option {
+	return func(h *Harness) {
+		h.sharedMocks = append(h.sharedMocks, mocks...)
+	}
+}
+
+// ToolMock builds a mock config with the same semantics as
+// TestCase.MockTool, for use with WithSharedMocks.
+func ToolMock(name string, responses ...string) mock.MockConfig {
+	mockResponses := make([]mock.MockResponse, len(responses))
+	for i, r := range responses {
+		mockResponses[i] = mock.MockResponse{Content: r}
+	}
+	cfg := mock.MockConfig{
+		ToolName:  name,
+		Responses: mockResponses,
+	}
+	if len(responses) > 0 {
+		last := mock.MockResponse{Content: responses[len(responses)-1]}
+		cfg.DefaultResponse = &last
+	}
+	return cfg
+}
+
 // WithTimeout sets the per-case timeout. Defaults to 30 seconds.
 func WithTimeout(d time.Duration) Option {
 	return func(h *Harness) {
@@ -57,6 +86,48 @@ func WithTimeout(d time.Duration) Option {
 	}
 }
 
+// WithModel sets the model sent with every request. It is also used to
+// estimate cost in the harness summary.
+func WithModel(model string) Option {
+	return func(h *Harness) {
+		h.model = model
+	}
+}
+
+// WithJudgeProvider sets the provider AssertLLMJudge grades with, so the
+// judge can run on a different API than the agent. Defaults to the
+// harness's provider.
+func WithJudgeProvider(p provider.Provider) Option {
+	return func(h *Harness) {
+		h.judgeProvider = p
+	}
+}
+
+// WithJudgeModel sets the model AssertLLMJudge grades with, such as a
+// cheaper model than the agent's. Defaults to the model set by WithModel.
+func WithJudgeModel(model string) Option {
+	return func(h *Harness) {
+		h.judgeModel = model
+	}
+}
+
+// WithMaxIterations sets the maximum number of tool-call round-trips per
+// case. Defaults to the runner's limit of 20.
+func WithMaxIterations(n int) Option {
+	return func(h *Harness) {
+		h.maxIterations = n
+	}
+}
+
+// WithToolExecutor passes calls to tools that a case has not mocked to e,
+// like a suite with on_unmocked_tool: passthrough. Without one, unmocked
+// calls fail.
+func WithToolExecutor(e runner.ToolResolver) Option {
+	return func(h *Harness) {
+		h.toolExecutor = e
+	}
+}
+
 // WithResultFile configures the harness to write test results to a JSON file
 // when all cases are complete.
 func WithResultFile(path string) Option {
@@ -67,11 +138,18 @@ func WithResultFile(path string) Option {
 
 // CaseResult captures the outcome of a single eval test case.
 type CaseResult struct {
-	Name      string                `json:"name"`
-	Output    string                `json:"output"`
-	ToolCalls []trace.ToolCallTrace `json:"tool_calls"`
-	Duration  time.Duration         `json:"duration"`
-	Error     string                `json:"error,omitempty"`
+	Name         string                `json:"name"`
+	Output       string                `json:"output"`
+	ToolCalls    []trace.ToolCallTrace `json:"tool_calls"`
+	Duration     time.Duration         `json:"duration"`
+	Error        string                `json:"error,omitempty"`
+	Passed       bool                  `json:"passed"`
+	InputTokens  int                   `json:"input_tokens"`
+	OutputTokens int                   `json:"output_tokens"`
+
+	// Failures lists every failed assertion and check of the case, in
+	// order.
+	Failures []string `json:"failures,omitempty"`
 }
 
 // Harness provides the scaffolding for running eval cases as standard Go
@@ -86,6 +164,20 @@ type Harness struct {
 	timeout    time.Duration
 	resultFile string
 	results    []CaseResult
+
+	sharedMocks []mock.MockConfig
+	model       string
+
+	maxIterations int
+	toolExecutor  runner.ToolResolver
+
+	// judgeProvider and judgeModel, when set, replace provider and model
+	// for AssertLLMJudge.
+	judgeProvider provider.Provider
+	judgeModel    string
+
+	summaryFile string
+	mu          sync.Mutex
 }
 
 // New creates a Harness bound to the given *testing.T. Options can be used
@@ -102,11 +194,12 @@ func New(t *testing.T, opts ...Option) *Harness {
 	for _, opt := range opts {
 		opt(h)
 	}
-	if h.resultFile != "" {
-		t.Cleanup(func() {
+	t.Cleanup(func() {
+		if h.resultFile != "" {
 			h.writeResults()
-		})
-	}
+		}
+		h.reportSummary()
+	})
 	return h
 }
 
@@ -121,15 +214,23 @@ func (h *Harness) Run(name string, fn func(tc *TestCase)) {
 			t:        t,
 			harness:  h,
 			name:     name,
-			registry: mock.NewRegistry(nil),
+			registry: mock.NewRegistry(h.sharedMocks),
+			tools:    h.tools,
 		}
+		if h.toolExecutor != nil {
+			tc.registry.SetUnmockedPolicy(mock.UnmockedPassthrough, h.toolExecutor.Resolve)
+		}
+		// Deferred so the outcome is recorded even when fn calls t.Fatal.
+		defer tc.finish()
 		fn(tc)
 	})
 }
 
 // writeResults saves all recorded results to the configured JSON file.
 func (h *Harness) writeResults() {
+	h.mu.Lock()
 	data, err := json.MarshalIndent(h.results, "", "  ")
+	h.mu.Unlock()
 	if err != nil {
 		h.t.Errorf("evaltest: failed to marshal results: %v", err)
 		return
@@ -145,10 +246,24 @@ type TestCase struct {
 	harness   *Harness
 	name      string
 	registry  *mock.MockRegistry
+	tools     []provider.Tool
 	output    string
 	trace     *trace.AgentTrace
 	toolCalls []provider.ToolCall
 	executed  bool
+	resultIdx int
+	recorded  bool
+	failures  []string
+
+	// onToolCall holds the hooks registered with OnToolCall.
+	onToolCall []func(call provider.ToolCall, result trace.ToolCallTrace)
+}
+
+// Parallel runs the case in parallel with the harness's other parallel
+// cases, like testing.T.Parallel. Cases sharing a MockProvider should
+// script their responses with MockProvider.Script.
+func (tc *TestCase) Parallel() {
+	tc.t.Parallel()
 }
 
 // MockTool registers mock responses for a tool. Responses are returned in
@@ -156,19 +271,7 @@ type TestCase struct {
 // consumed.
 func (tc *TestCase) MockTool(name string, responses ...string) {
 	tc.t.Helper()
-	mockResponses := make([]mock.MockResponse, len(responses))
-	for i, r := range responses {
-		mockResponses[i] = mock.MockResponse{Content: r}
-	}
-	cfg := mock.MockConfig{
-		ToolName:  name,
-		Responses: mockResponses,
-	}
-	if len(responses) > 0 {
-		last := mock.MockResponse{Content: responses[len(responses)-1]}
-		cfg.DefaultResponse = &last
-	}
-	tc.registry.Register(cfg)
+	tc.registry.Register(ToolMock(name, responses...))
 }
 
 // MockToolError registers a mock for a tool that always returns an error.
@@ -180,93 +283,103 @@ func (tc *TestCase) MockToolError(name string, errMsg string) {
 	})
 }
 
+// SetTools replaces the tools inherited from the harness for this case.
+func (tc *TestCase) SetTools(tools []provider.Tool) {
+	tc.tools = tools
+}
+
+// AddTools makes additional tools available in this case on top of those
+// inherited from the harness.
+func (tc *TestCase) AddTools(tools ...provider.Tool) {
+	tc.tools = append(append([]provider.Tool(nil), tc.tools...), tools...)
+}
+
+// OnToolCall registers fn to be called for every tool call the agent makes
+// during Input, once the call's result is known, so that invariants can be
+// checked as they happen rather than after the fact, such as that each file
+// the agent writes parses. Assertions made in fn, such as with Check, are
+// attributed to the case. Hooks must be registered before Input and run in
+// registration order.
+func (tc *TestCase) OnToolCall(fn func(call provider.ToolCall, result trace.ToolCallTrace)) {
+	tc.onToolCall = append(tc.onToolCall, fn)
+}
+
 // Input sends the user message to the agent via the configured provider and
-// executes the agent loop (processing tool calls via mocks). It returns the
-// final agent output text.
+// executes the agent loop (processing tool calls via mocks). It uses the
+// same loop as suite runs, so iteration limits and hallucinated-tool
+// detection behave identically. It returns the final agent output text.
 func (tc *TestCase) Input(text string) string {
 	tc.t.Helper()
+	return tc.run(text, provider.Message{Role: "user", Content: text})
+}
+
+// InputParts is Input for a multimodal user message, such as text with
+// images for a vision agent. The trace records the message's text, with
+// a placeholder for each image or document.
+func (tc *TestCase) InputParts(parts ...provider.ContentPart) string {
+	tc.t.Helper()
+	var text strings.Builder
+	for _, p := range parts {
+		switch p.Type {
+		case provider.PartText:
+			text.WriteString(p.Text)
+		case provider.PartDocument:
+			fmt.Fprintf(&text, "[document %s]", p.Name)
+		default:
+			fmt.Fprintf(&text, "[%s]", p.Type)
+		}
+	}
+	return tc.run(text.String(), provider.Message{Role: "user", Parts: parts})
+}
+
+// run sends msg, recorded in the trace as text, and executes the agent
+// loop.
+func (tc *TestCase) run(text string, msg provider.Message) string {
+	tc.t.Helper()
 
 	h := tc.harness
-	ctx, cancel := context.WithTimeout(context.Background(), h.timeout)
+	ctx, cancel := context.WithTimeout(WithConversation(context.Background(), tc.name), h.timeout)
 	defer cancel()
 
 	tr := trace.New()
 	tc.trace = tr
 
-	messages := []provider.Message{
-		{Role: "user", Content: text},
-	}
-	tr.AddMessage("user", text)
-
-	for i := 0; i < maxToolIterations; i++ {
-		req := &provider.Request{
-			System:   h.system,
-			Messages: messages,
-			Tools:    h.tools,
-		}
-
-		resp, err := h.provider.Complete(ctx, req)
-		if err != nil {
-			tc.t.Errorf("provider error: %v", err)
-			tr.Finish()
-			tc.recordResult(err.Error())
-			return ""
-		}
-
-		tr.AddUsage(resp.Usage.InputTokens, resp.Usage.OutputTokens)
-
-		if len(resp.ToolCalls) == 0 {
-			tr.AddMessage("assistant", resp.Content)
-			tc.output = resp.Content
-			tc.executed = true
-			tr.Finish()
-			tc.recordResult("")
-			return tc.output
-		}
-
-		tc.toolCalls = append(tc.toolCalls, resp.ToolCalls...)
-		tr.AddMessage("assistant", resp.Content)
-		messages = append(messages, provider.Message{
-			Role:      "assistant",
-			Content:   resp.Content,
-			ToolCalls: resp.ToolCalls,
-		})
-
-		for _, call := range resp.ToolCalls {
-			tcStart := time.Now()
-			content, mockErr := tc.registry.Resolve(call.Name, call.Parameters)
-			tcDuration := time.Since(tcStart)
-
-			tcTrace := trace.ToolCallTrace{
-				ToolName:   call.Name,
-				Parameters: call.Parameters,
-				Response:   content,
-				StartTime:  tcStart,
-				EndTime:    time.Now(),
-				Duration:   tcDuration,
-			}
-			if mockErr != nil {
-				tcTrace.Error = mockErr.Error()
-			}
-			tr.AddToolCall(tcTrace)
-
-			toolContent := content
-			if mockErr != nil {
-				toolContent = fmt.Sprintf("Error: %v", mockErr)
+	var onToolCall agentloop.ToolHook
+	if len(tc.onToolCall) > 0 {
+		onToolCall = func(call provider.ToolCall, result trace.ToolCallTrace) error {
+			for _, fn := range tc.onToolCall {
+				fn(call, result)
 			}
-			messages = append(messages, provider.Message{
-				Role:       "tool",
-				Content:    toolContent,
-				ToolCallID: call.ID,
-			})
-			tr.AddMessage("tool", toolContent)
+			return nil
 		}
 	}
 
-	tc.t.Error("agent loop exceeded maximum iterations")
+	tr.AddMessage("user", text)
+	res, err := agentloop.Run(ctx, h.provider, provider.Request{
+		Model:    h.model,
+		System:   h.system,
+		Messages: []provider.Message{msg},
+		Tools:    tc.tools,
+	}, tc.registry, tr, h.maxIterations, onToolCall)
+	for _, m := range res.Messages {
+		tc.toolCalls = append(tc.toolCalls, m.ToolCalls...)
+	}
 	tr.Finish()
-	tc.recordResult("max iterations exceeded")
-	return ""
+
+	switch {
+	case err != nil:
+		tc.t.Errorf("provider error: %v", err)
+		tc.recordResult(err.Error())
+		return ""
+	case !res.Done:
+		tc.t.Error("agent loop exceeded maximum iterations")
+		tc.recordResult("max iterations exceeded")
+		return ""
+	}
+	tc.output = res.Final
+	tc.executed = true
+	tc.recordResult("")
+	return tc.output
 }
 
 func (tc *TestCase) recordResult(errMsg string) {
@@ -282,8 +395,34 @@ func (tc *TestCase) recordResult(errMsg string) {
 	}
 	if tc.trace != nil {
 		result.Duration = tc.trace.Duration
+		usage := tc.trace.GetUsage()
+		result.InputTokens = usage.InputTokens
+		result.OutputTokens = usage.OutputTokens
 	}
-	tc.harness.results = append(tc.harness.results, result)
+
+	h := tc.harness
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if tc.recorded {
+		h.results[tc.resultIdx] = result
+		return
+	}
+	tc.resultIdx = len(h.results)
+	tc.recorded = true
+	h.results = append(h.results, result)
+}
+
+// finish records the case outcome once its subtest body has returned,
+// including cases that never reached Input.
+func (tc *TestCase) finish() {
+	if !tc.recorded {
+		tc.recordResult("")
+	}
+	h := tc.harness
+	h.mu.Lock()
+	h.results[tc.resultIdx].Passed = !tc.t.Failed()
+	h.results[tc.resultIdx].Failures = tc.failures
+	h.mu.Unlock()
 }
 
 // Output returns the agent's final output text.
@@ -300,8 +439,31 @@ func (tc *TestCase) Trace() *trace.AgentTrace {
 	return tc.trace
 }
 
+// Messages returns the recorded conversation: the user input, each
+// assistant turn, and each tool result, in order. The system prompt is not
+// included.
+func (tc *TestCase) Messages() []trace.Message {
+	tc.t.Helper()
+	if tc.trace == nil {
+		tc.t.Error("Messages() called before Input()")
+		return nil
+	}
+	return tc.trace.GetMessages()
+}
+
 // ToolCallRecords returns all tool calls made by the provider during
 // the agent loop.
 func (tc *TestCase) ToolCallRecords() []provider.ToolCall {
 	return tc.toolCalls
 }
+
+// MockCalls returns the calls the mock registry resolved for tool, in the
+// order they were made, with their parameters, responses, and timing. An
+// empty tool name returns the calls to every tool, including unmocked
+// calls handled by a tool executor.
+func (tc *TestCase) MockCalls(tool string) []mock.ToolCallRecord {
+	if tool == "" {
+		return tc.registry.GetCalls()
+	}
+	return tc.registry.GetCallsForTool(tool)
+}
@@ -1,12 +1,17 @@
 package evaltest
 
 import (
+	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
 	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
 func TestHarness_SimpleOutput(t *testing.T) {
@@ -235,6 +240,36 @@ func TestScoreMatchers(t *testing.T) {
 	}
 }
 
+func TestScoreMatchers_RangesAndComposition(t *testing.T) {
+	tests := []struct {
+		matcher ScoreMatcher
+		score   float64
+		want    bool
+	}{
+		{ScoreBelow(0.5), 0.4, true},
+		{ScoreBelow(0.5), 0.5, false},
+		{ScoreBetween(0.6, 0.9), 0.6, true},
+		{ScoreBetween(0.6, 0.9), 0.9, true},
+		{ScoreBetween(0.6, 0.9), 0.95, false},
+		{AllOf(ScoreAtLeast(0.7), ScoreBelow(1)), 0.8, true},
+		{AllOf(ScoreAtLeast(0.7), ScoreBelow(1)), 1, false},
+		{AnyOf(ScoreBelow(0.2), ScoreExact(1)), 1, true},
+		{AnyOf(ScoreBelow(0.2), ScoreExact(1)), 0.5, false},
+		{AllOf(), 0.3, true},
+		{AnyOf(), 0.3, false},
+	}
+	for _, tt := range tests {
+		if got := tt.matcher.Match(tt.score); got != tt.want {
+			t.Errorf("%s: Match(%v) = %v, want %v", tt.matcher, tt.score, got, tt.want)
+		}
+	}
+
+	m := AllOf(ScoreAtLeast(0.7), AnyOf(ScoreBelow(0.9), ScoreExact(1)))
+	if got, want := m.String(), "(score >= 0.70 and (score < 0.90 or score == 1.00))"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
 func TestAssertToolNotCalled_Negative(t *testing.T) {
 	fp := NewMockProvider(
 		provider.Response{Content: "no tools used", StopReason: "end_turn"},
@@ -246,3 +281,507 @@ func TestAssertToolNotCalled_Negative(t *testing.T) {
 		tc.AssertToolNotCalled("any_tool")
 	})
 }
+
+func TestAssertOutputSchema(t *testing.T) {
+	type answer struct {
+		Answer     string  `json:"answer"`
+		Confidence float64 `json:"confidence"`
+	}
+
+	fp := NewMockProvider(provider.Response{
+		Content:    `{"answer": "42", "confidence": 0.9}`,
+		StopReason: "end_turn",
+	})
+
+	h := New(t, WithProvider(fp))
+	h.Run("structured", func(tc *TestCase) {
+		tc.Input("Answer as JSON")
+		tc.AssertOutputSchema(answer{})
+		tc.AssertOutputSchema(`{"type": "object", "required": ["answer"]}`)
+	})
+}
+
+func TestHarness_SharedMocks(t *testing.T) {
+	searchThenAnswer := func() []provider.Response {
+		return []provider.Response{
+			{ToolCalls: []provider.ToolCall{{ID: "tc1", Name: "search"}}, StopReason: "tool_use"},
+			{Content: "done", StopReason: "end_turn"},
+		}
+	}
+	fp := NewMockProvider(append(searchThenAnswer(), searchThenAnswer()...)...)
+
+	h := New(t, WithProvider(fp), WithSharedMocks(ToolMock("search", "shared result")))
+	h.Run("inherits", func(tc *TestCase) {
+		tc.Input("search")
+		calls := tc.Trace().GetToolCalls()
+		if len(calls) != 1 || calls[0].Response != "shared result" {
+			t.Errorf("tool calls = %+v, want shared result", calls)
+		}
+	})
+	h.Run("overrides", func(tc *TestCase) {
+		tc.MockTool("search", "case result")
+		tc.Input("search")
+		calls := tc.Trace().GetToolCalls()
+		if len(calls) != 1 || calls[0].Response != "case result" {
+			t.Errorf("tool calls = %+v, want case result", calls)
+		}
+	})
+}
+
+// toolRecordingProvider records the tools offered in each request.
+type toolRecordingProvider struct {
+	tools [][]provider.Tool
+}
+
+func (p *toolRecordingProvider) Name() string { return "tool-recorder" }
+func (p *toolRecordingProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.tools = append(p.tools, req.Tools)
+	return &provider.Response{Content: "ok", StopReason: "end_turn"}, nil
+}
+
+func TestHarness_ToolOverrides(t *testing.T) {
+	rp := &toolRecordingProvider{}
+	search := provider.Tool{Name: "search"}
+	calc := provider.Tool{Name: "calc"}
+
+	h := New(t, WithProvider(rp), WithTools([]provider.Tool{search}))
+	h.Run("inherited", func(tc *TestCase) { tc.Input("x") })
+	h.Run("added", func(tc *TestCase) {
+		tc.AddTools(calc)
+		tc.Input("x")
+	})
+	h.Run("replaced", func(tc *TestCase) {
+		tc.SetTools([]provider.Tool{calc})
+		tc.Input("x")
+	})
+
+	want := [][]string{{"search"}, {"search", "calc"}, {"calc"}}
+	if len(rp.tools) != len(want) {
+		t.Fatalf("requests = %d, want %d", len(rp.tools), len(want))
+	}
+	for i, tools := range rp.tools {
+		var names []string
+		for _, tool := range tools {
+			names = append(names, tool.Name)
+		}
+		if strings.Join(names, ",") != strings.Join(want[i], ",") {
+			t.Errorf("request %d tools = %v, want %v", i, names, want[i])
+		}
+	}
+}
+
+func TestHarness_Messages(t *testing.T) {
+	fp := NewMockProvider(
+		provider.Response{
+			Content:    "Let me look.",
+			ToolCalls:  []provider.ToolCall{{ID: "tc1", Name: "search"}},
+			StopReason: "tool_use",
+		},
+		provider.Response{Content: "The capital is Paris.", StopReason: "end_turn"},
+	)
+
+	h := New(t, WithProvider(fp), WithSystem("SECRET-INSTRUCTIONS: be brief."))
+	h.Run("dialogue", func(tc *TestCase) {
+		tc.MockTool("search", "Paris is the capital of France")
+		tc.Input("What is the capital of France?")
+
+		var roles []string
+		for _, m := range tc.Messages() {
+			roles = append(roles, m.Role)
+		}
+		if got := strings.Join(roles, ","); got != "user,assistant,tool,assistant" {
+			t.Errorf("roles = %s", got)
+		}
+
+		tc.AssertNoMessageMatches(`SECRET-INSTRUCTIONS`)
+		// The tool result mentions France, but only assistant messages
+		// are checked by default.
+		tc.AssertNoMessageMatches(`France`)
+	})
+}
+
+func TestHarness_Summary(t *testing.T) {
+	summaryPath := filepath.Join(t.TempDir(), "summary.json")
+	fp := NewMockProvider(
+		provider.Response{Content: "one", StopReason: "end_turn", Usage: provider.Usage{InputTokens: 100, OutputTokens: 10}},
+		provider.Response{Content: "two", StopReason: "end_turn", Usage: provider.Usage{InputTokens: 200, OutputTokens: 20}},
+	)
+
+	h := New(t, WithProvider(fp), WithModel("gpt-4o"), WithSummaryFile(summaryPath))
+	h.Run("first", func(tc *TestCase) {
+		tc.Input("a")
+	})
+	h.Run("second", func(tc *TestCase) {
+		tc.Input("b")
+	})
+	h.Run("no-input", func(tc *TestCase) {})
+
+	s := h.Summary()
+	if s.Cases != 3 || s.Passed != 3 || s.PassRate != 1 {
+		t.Errorf("summary = %+v, want 3 cases all passed", s)
+	}
+	if s.InputTokens != 300 || s.OutputTokens != 30 {
+		t.Errorf("tokens = %d/%d, want 300/30", s.InputTokens, s.OutputTokens)
+	}
+	if s.EstimatedCost <= 0 {
+		t.Errorf("EstimatedCost = %v, want > 0", s.EstimatedCost)
+	}
+
+	h.reportSummary()
+	data, err := os.ReadFile(summaryPath)
+	if err != nil {
+		t.Fatalf("failed to read summary file: %v", err)
+	}
+	var got Summary
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("summary file is not valid JSON: %v", err)
+	}
+	if got != s {
+		t.Errorf("summary file = %+v, want %+v", got, s)
+	}
+}
+
+func TestHarness_RunPerturbed(t *testing.T) {
+	paraphraser := NewMockProvider(provider.Response{Content: "  Please say hello.  "})
+	perturbations := []Perturbation{
+		Typos(2, 1),
+		Lowercase(),
+		Uppercase(),
+		ExtraWhitespace(),
+		Paraphrase(paraphraser, ""),
+	}
+
+	var inputs []string
+	h := New(t)
+	h.RunPerturbed("greet", "Say Hello", perturbations, func(tc *TestCase, input string) {
+		inputs = append(inputs, input)
+		tc.Input(input)
+		tc.AssertOutputContains(strings.TrimSpace(input))
+	})
+
+	want := []string{"Say Hello", "say hello", "SAY HELLO", "\n  Say   Hello  \n\n", "Please say hello."}
+	if len(inputs) != len(perturbations)+1 {
+		t.Fatalf("got %d runs, want %d", len(inputs), len(perturbations)+1)
+	}
+	if inputs[0] != want[0] {
+		t.Errorf("original input = %q, want %q", inputs[0], want[0])
+	}
+	if inputs[1] == want[0] {
+		t.Error("typos perturbation left the input unchanged")
+	}
+	for i, w := range want[1:] {
+		if got := inputs[i+2]; got != w {
+			t.Errorf("input %d = %q, want %q", i+2, got, w)
+		}
+	}
+}
+
+func TestTypos_Deterministic(t *testing.T) {
+	ctx := context.Background()
+	a, _ := Typos(3, 42).Apply(ctx, "the quick brown fox")
+	b, _ := Typos(3, 42).Apply(ctx, "the quick brown fox")
+	if a != b {
+		t.Errorf("same seed gave %q and %q", a, b)
+	}
+}
+
+func TestAssertToolCalledWith_TypeAware(t *testing.T) {
+	fp := NewMockProvider(
+		provider.Response{
+			ToolCalls: []provider.ToolCall{
+				{ID: "tc1", Name: "search", Parameters: map[string]interface{}{
+					"query":  "Golang",
+					"limit":  10.0,
+					"filter": map[string]interface{}{"lang": "en", "safe": true},
+				}},
+			},
+		},
+		provider.Response{Content: "done", StopReason: "end_turn"},
+	)
+
+	h := New(t, WithProvider(fp))
+	h.Run("search", func(tc *TestCase) {
+		tc.MockTool("search", "[]")
+		tc.Input("Search")
+		tc.AssertToolCalledWith("search", map[string]interface{}{
+			"limit":  10,
+			"filter": map[string]interface{}{"safe": "true"},
+		})
+		tc.AssertToolCalledWith("search", map[string]interface{}{"query": "golang"}, judge.ParamOptions{IgnoreCase: true})
+	})
+}
+
+// toolExecutorFunc adapts a function to runner.ToolResolver.
+type toolExecutorFunc func(name string, params map[string]interface{}) (string, error)
+
+func (f toolExecutorFunc) Resolve(name string, params map[string]interface{}) (string, error) {
+	return f(name, params)
+}
+
+func TestHarness_ToolExecutor(t *testing.T) {
+	fp := NewMockProvider(
+		provider.Response{
+			ToolCalls: []provider.ToolCall{
+				{ID: "tc1", Name: "clock"},
+				{ID: "tc2", Name: "read_file", Parameters: map[string]interface{}{"path": "a.go"}},
+			},
+			StopReason: "tool_use",
+		},
+		provider.Response{Content: "done", StopReason: "end_turn"},
+	)
+	var executed []string
+	exec := toolExecutorFunc(func(name string, _ map[string]interface{}) (string, error) {
+		executed = append(executed, name)
+		return "12:00", nil
+	})
+
+	h := New(t, WithProvider(fp), WithToolExecutor(exec), WithMaxIterations(2))
+	h.Run("passthrough", func(tc *TestCase) {
+		tc.MockTool("read_file", "package a")
+		tc.Input("What time is it?")
+		calls := tc.Trace().GetToolCalls()
+		if len(calls) != 2 || calls[0].Response != "12:00" || calls[1].Response != "package a" {
+			t.Errorf("tool calls = %+v, want clock executed and read_file mocked", calls)
+		}
+	})
+	if len(executed) != 1 || executed[0] != "clock" {
+		t.Errorf("executed = %v, want [clock]", executed)
+	}
+}
+
+func TestHarness_MockCalls(t *testing.T) {
+	fp := NewMockProvider(
+		provider.Response{
+			ToolCalls: []provider.ToolCall{
+				{ID: "tc1", Name: "search", Parameters: map[string]interface{}{"q": "go"}},
+				{ID: "tc2", Name: "fetch", Parameters: map[string]interface{}{"url": "https://go.dev"}},
+			},
+			StopReason: "tool_use",
+		},
+		provider.Response{
+			ToolCalls:  []provider.ToolCall{{ID: "tc3", Name: "search", Parameters: map[string]interface{}{"q": "rust"}}},
+			StopReason: "tool_use",
+		},
+		provider.Response{Content: "done", StopReason: "end_turn"},
+	)
+
+	h := New(t, WithProvider(fp))
+	h.Run("records", func(tc *TestCase) {
+		tc.MockTool("search", "r1", "r2")
+		tc.MockTool("fetch", "<html>")
+		tc.Input("Compare languages")
+
+		searches := tc.MockCalls("search")
+		if len(searches) != 2 {
+			t.Fatalf("len(MockCalls(search)) = %d, want 2", len(searches))
+		}
+		if searches[0].Parameters["q"] != "go" || searches[1].Parameters["q"] != "rust" {
+			t.Errorf("search params = %v, %v", searches[0].Parameters, searches[1].Parameters)
+		}
+		if searches[1].Response != "r2" {
+			t.Errorf("second search response = %q, want r2", searches[1].Response)
+		}
+
+		all := tc.MockCalls("")
+		var order []string
+		for _, c := range all {
+			order = append(order, c.ToolName)
+		}
+		if strings.Join(order, ",") != "search,fetch,search" {
+			t.Errorf("call order = %v", order)
+		}
+		if all[1].Timestamp.Before(all[0].Timestamp) {
+			t.Error("timestamps out of order")
+		}
+		if len(tc.MockCalls("delete")) != 0 {
+			t.Error("expected no calls to an unused tool")
+		}
+	})
+}
+
+func TestMessageFromMsgAndArgs(t *testing.T) {
+	tests := []struct {
+		args []interface{}
+		want string
+	}{
+		{nil, ""},
+		{[]interface{}{"refund must be issued"}, "refund must be issued"},
+		{[]interface{}{"order %s for %d items", "A1", 3}, "order A1 for 3 items"},
+		{[]interface{}{42}, "42"},
+	}
+	for _, tt := range tests {
+		if got := messageFromMsgAndArgs(tt.args); got != tt.want {
+			t.Errorf("messageFromMsgAndArgs(%v) = %q, want %q", tt.args, got, tt.want)
+		}
+	}
+}
+
+func TestTestCase_CheckAndMessages(t *testing.T) {
+	fp := NewMockProvider(
+		provider.Response{
+			ToolCalls:  []provider.ToolCall{{ID: "tc1", Name: "search", Parameters: map[string]interface{}{"query": "Go"}}},
+			StopReason: "tool_use",
+		},
+		provider.Response{Content: "Found it.", StopReason: "end_turn"},
+	)
+	resultPath := filepath.Join(t.TempDir(), "results.json")
+
+	h := New(t, WithProvider(fp), WithResultFile(resultPath))
+	h.Run("search", func(tc *TestCase) {
+		tc.MockTool("search", "Go is a language")
+		tc.Input("Search for Go")
+
+		if !tc.Check(len(tc.MockCalls("search")) == 1, "searched exactly once") {
+			t.Error("Check returned false for a true condition")
+		}
+		tc.AssertOutputContains("Found", "the agent reports the result")
+		tc.AssertToolCalledWith("search", map[string]interface{}{"query": "go"}, judge.ParamOptions{IgnoreCase: true}, "query is %s", "go")
+		// Leading role names select the roles; the rest is the message.
+		tc.AssertNoMessageMatches(`secret`, "assistant", "tool", "no leaks in %s", "replies")
+	})
+
+	results := h.Summary()
+	if results.Passed != 1 {
+		t.Errorf("Passed = %d, want 1", results.Passed)
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if f := h.results[0].Failures; len(f) != 0 {
+		t.Errorf("Failures = %v, want none", f)
+	}
+}
+
+// judgeRecordingProvider records the model of each request and answers
+// with a passing judge verdict.
+type judgeRecordingProvider struct {
+	name   string
+	models []string
+}
+
+func (p *judgeRecordingProvider) Name() string { return p.name }
+func (p *judgeRecordingProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.models = append(p.models, req.Model)
+	return &provider.Response{Content: `{"score": 5, "pass": true, "reasoning": "fine"}`, StopReason: "end_turn"}, nil
+}
+
+func TestAssertLLMJudge_ProviderAndModel(t *testing.T) {
+	agent := &judgeRecordingProvider{name: "agent"}
+	judgeP := &judgeRecordingProvider{name: "judge"}
+	override := &judgeRecordingProvider{name: "override"}
+
+	h := New(t, WithProvider(agent), WithModel("big-model"),
+		WithJudgeProvider(judgeP), WithJudgeModel("small-model"))
+	h.Run("judged", func(tc *TestCase) {
+		tc.Input("Say hello")
+		tc.AssertLLMJudge("The reply greets the user.", ScoreAtLeast(0.8))
+		tc.AssertLLMJudge("The reply greets the user.", ScoreAtLeast(0.8),
+			JudgeOptions{Provider: override, Model: "other-model"}, "greeting")
+	})
+
+	if want := []string{"big-model"}; !reflect.DeepEqual(agent.models, want) {
+		t.Errorf("agent models = %v, want %v", agent.models, want)
+	}
+	if want := []string{"small-model"}; !reflect.DeepEqual(judgeP.models, want) {
+		t.Errorf("judge models = %v, want %v", judgeP.models, want)
+	}
+	if want := []string{"other-model"}; !reflect.DeepEqual(override.models, want) {
+		t.Errorf("override models = %v, want %v", override.models, want)
+	}
+
+	// Without judge options the judge falls back to the agent's settings.
+	h2 := New(t, WithProvider(agent), WithModel("big-model"))
+	h2.Run("fallback", func(tc *TestCase) {
+		tc.Input("Say hello")
+		tc.AssertLLMJudge("The reply greets the user.", ScoreAtLeast(0.8))
+	})
+	if want := []string{"big-model", "big-model", "big-model"}; !reflect.DeepEqual(agent.models, want) {
+		t.Errorf("agent models = %v, want %v", agent.models, want)
+	}
+}
+
+func TestTestCase_OnToolCall(t *testing.T) {
+	fp := NewMockProvider(
+		provider.Response{
+			ToolCalls: []provider.ToolCall{
+				{ID: "tc1", Name: "write_file", Parameters: map[string]interface{}{"path": "a.go"}},
+				{ID: "tc2", Name: "search", Parameters: map[string]interface{}{"query": "go"}},
+			},
+			StopReason: "tool_use",
+		},
+		provider.Response{Content: "Done.", StopReason: "end_turn"},
+	)
+
+	h := New(t, WithProvider(fp))
+	h.Run("hooks", func(tc *TestCase) {
+		tc.MockTool("write_file", "ok")
+		var seen []string
+		tc.OnToolCall(func(call provider.ToolCall, result trace.ToolCallTrace) {
+			seen = append(seen, call.Name+"="+result.Response)
+		})
+		tc.Input("Write a file")
+
+		want := []string{"write_file=ok", "search="}
+		if !reflect.DeepEqual(seen, want) {
+			t.Errorf("hook saw %v, want %v", seen, want)
+		}
+	})
+}
+
+// requestRecordingProvider records each request and answers "ok".
+type requestRecordingProvider struct {
+	reqs []provider.Request
+}
+
+func (p *requestRecordingProvider) Name() string { return "recording" }
+func (p *requestRecordingProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.reqs = append(p.reqs, *req)
+	return &provider.Response{Content: "ok", StopReason: "end_turn"}, nil
+}
+
+func TestTestCase_InputParts(t *testing.T) {
+	rp := &requestRecordingProvider{}
+	h := New(t, WithProvider(rp))
+	h.Run("vision", func(tc *TestCase) {
+		img := provider.ImagePart("image/png", []byte("png"))
+		if out := tc.InputParts(provider.TextPart("Describe "), img); out != "ok" {
+			t.Errorf("InputParts() = %q, want ok", out)
+		}
+		if len(rp.reqs) != 1 || !reflect.DeepEqual(rp.reqs[0].Messages[0].Parts, []provider.ContentPart{provider.TextPart("Describe "), img}) {
+			t.Errorf("requests = %+v, want the parts sent", rp.reqs)
+		}
+		if msgs := tc.Messages(); len(msgs) == 0 || msgs[0].Content != "Describe [image]" {
+			t.Errorf("Messages() = %+v, want the user message recorded as text", msgs)
+		}
+	})
+}
+
+func TestMockProvider_ScriptedConversations(t *testing.T) {
+	mp := NewMockProvider(provider.Response{Content: "shared", StopReason: "end_turn"})
+	for _, name := range []string{"a", "b", "c"} {
+		mp.Script(name,
+			provider.Response{StopReason: "tool_use", ToolCalls: []provider.ToolCall{{ID: "t1", Name: "lookup"}}},
+			provider.Response{Content: "answer " + name, StopReason: "end_turn"},
+		)
+	}
+
+	t.Run("group", func(t *testing.T) {
+		h := New(t, WithProvider(mp))
+		for _, name := range []string{"a", "b", "c"} {
+			h.Run(name, func(tc *TestCase) {
+				tc.Parallel()
+				tc.MockTool("lookup", "found")
+				out := tc.Input("question")
+				tc.Check(out == "answer "+name, "output %q, want %q", out, "answer "+name)
+			})
+		}
+	})
+
+	// Requests from other conversations still use the shared queue.
+	resp, err := mp.Complete(WithConversation(context.Background(), "other"), &provider.Request{})
+	if err != nil || resp.Content != "shared" {
+		t.Errorf("Complete() for unscripted conversation = %v, %v; want the shared response", resp, err)
+	}
+	if _, err := mp.Complete(WithConversation(context.Background(), "a"), &provider.Request{}); err == nil {
+		t.Error("Complete() after a conversation's queue is used up should fail")
+	}
+}
@@ -1,6 +1,9 @@
 package evaltest
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 // ScoreMatcher defines an interface for matching judge scores.
 type ScoreMatcher interface {
@@ -69,3 +72,96 @@ func (m scoreAtLeast) Match(score float64) bool {
 func (m scoreAtLeast) String() string {
 	return fmt.Sprintf("score >= %.2f", m.min)
 }
+
+// scoreBelow matches scores strictly below a limit.
+type scoreBelow struct {
+	limit float64
+}
+
+// ScoreBelow returns a matcher that passes when the score is strictly less
+// than the given limit.
+func ScoreBelow(limit float64) ScoreMatcher {
+	return scoreBelow{limit: limit}
+}
+
+func (m scoreBelow) Match(score float64) bool {
+	return score < m.limit
+}
+
+func (m scoreBelow) String() string {
+	return fmt.Sprintf("score < %.2f", m.limit)
+}
+
+// scoreBetween matches scores within an inclusive range.
+type scoreBetween struct {
+	lo, hi float64
+}
+
+// ScoreBetween returns a matcher that passes when the score is at least lo
+// and at most hi.
+func ScoreBetween(lo, hi float64) ScoreMatcher {
+	return scoreBetween{lo: lo, hi: hi}
+}
+
+func (m scoreBetween) Match(score float64) bool {
+	return score >= m.lo && score <= m.hi
+}
+
+func (m scoreBetween) String() string {
+	return fmt.Sprintf("%.2f <= score <= %.2f", m.lo, m.hi)
+}
+
+// allOf matches scores that every one of its matchers matches.
+type allOf []ScoreMatcher
+
+// AllOf returns a matcher that passes when every given matcher passes, such
+// as AllOf(ScoreAtLeast(0.7), ScoreBelow(1)) for a score that is good but not
+// suspiciously perfect. With no matchers it passes any score.
+func AllOf(matchers ...ScoreMatcher) ScoreMatcher {
+	return allOf(matchers)
+}
+
+func (m allOf) Match(score float64) bool {
+	for _, sm := range m {
+		if !sm.Match(score) {
+			return false
+		}
+	}
+	return true
+}
+
+func (m allOf) String() string {
+	return joinMatchers(m, " and ")
+}
+
+// anyOf matches scores that at least one of its matchers matches.
+type anyOf []ScoreMatcher
+
+// AnyOf returns a matcher that passes when at least one given matcher
+// passes. With no matchers it passes no score.
+func AnyOf(matchers ...ScoreMatcher) ScoreMatcher {
+	return anyOf(matchers)
+}
+
+func (m anyOf) Match(score float64) bool {
+	for _, sm := range m {
+		if sm.Match(score) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m anyOf) String() string {
+	return joinMatchers(m, " or ")
+}
+
+// joinMatchers describes matchers joined by sep, parenthesized so nested
+// combinations read unambiguously.
+func joinMatchers(matchers []ScoreMatcher, sep string) string {
+	parts := make([]string, len(matchers))
+	for i, m := range matchers {
+		parts[i] = m.String()
+	}
+	return "(" + strings.Join(parts, sep) + ")"
+}
@@ -10,10 +10,19 @@ import (
 
 // MockProvider is a simple provider that returns pre-configured responses
 // in sequence. It is safe for concurrent use.
+//
+// Responses given to NewMockProvider form one queue shared by every
+// caller. Cases that run in parallel should each script their own queue
+// with Script, keyed by case name, so they cannot consume each other's
+// responses.
 type MockProvider struct {
 	responses []provider.Response
 	mu        sync.Mutex
 	idx       int
+
+	// queues holds the responses scripted per conversation with Script,
+	// consumed from the front.
+	queues map[string][]provider.Response
 }
 
 // NewMockProvider creates a MockProvider that returns the given responses in
@@ -24,11 +33,38 @@ func NewMockProvider(responses ...provider.Response) *MockProvider {
 	}
 }
 
-// Complete returns the next pre-configured response. It ignores the request
-// contents entirely; responses are returned in the order they were provided.
-func (m *MockProvider) Complete(_ context.Context, _ *provider.Request) (*provider.Response, error) {
+// Script queues responses for one conversation: requests whose context
+// carries conversation as its ConversationID are answered from this queue
+// only, in order. Harness cases use their name as the conversation, so
+// Script(name, ...) scripts the case h.Run(name, ...). It returns m for
+// chaining.
+func (m *MockProvider) Script(conversation string, responses ...provider.Response) *MockProvider {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	if m.queues == nil {
+		m.queues = make(map[string][]provider.Response)
+	}
+	m.queues[conversation] = append(m.queues[conversation], responses...)
+	return m
+}
+
+// Complete returns the next pre-configured response: from the queue
+// scripted for the request's conversation if there is one, and from the
+// shared queue otherwise. It ignores the request contents entirely;
+// responses are returned in the order they were provided.
+func (m *MockProvider) Complete(ctx context.Context, _ *provider.Request) (*provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := ConversationID(ctx)
+	if q, ok := m.queues[id]; ok {
+		if len(q) == 0 {
+			return nil, fmt.Errorf("mock provider: no more responses for conversation %q", id)
+		}
+		resp := q[0]
+		m.queues[id] = q[1:]
+		return &resp, nil
+	}
 
 	if m.idx >= len(m.responses) {
 		return nil, fmt.Errorf("mock provider: no more responses (consumed %d/%d)", m.idx, len(m.responses))
@@ -42,6 +78,23 @@ func (m *MockProvider) Complete(_ context.Context, _ *provider.Request) (*provid
 // Name returns "mock".
 func (m *MockProvider) Name() string { return "mock" }
 
+type conversationKey struct{}
+
+// WithConversation returns a copy of ctx that identifies the conversation
+// its requests belong to, so that providers such as MockProvider can keep
+// concurrent conversations apart. The harness sets it to the case name for
+// every request a case makes.
+func WithConversation(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, conversationKey{}, id)
+}
+
+// ConversationID returns the conversation set on ctx by WithConversation,
+// or "" if there is none.
+func ConversationID(ctx context.Context) string {
+	id, _ := ctx.Value(conversationKey{}).(string)
+	return id
+}
+
 // echoProvider is a trivial provider that echoes the last user message.
 type echoProvider struct{}
 
@@ -0,0 +1,135 @@
+package evaltest
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+	"unicode"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// Perturbation rewrites a case input to simulate the noise real users
+// introduce. Name labels the generated subtest.
+type Perturbation struct {
+	Name  string
+	Apply func(ctx context.Context, input string) (string, error)
+}
+
+// RunPerturbed runs fn once against the unmodified input and once per
+// perturbation, each as its own subtest ("name/original",
+// "name/typos", ...). fn receives the case and the input to send, so the
+// same assertions check that the agent is robust to every variant.
+func (h *Harness) RunPerturbed(name, input string, perturbations []Perturbation, fn func(tc *TestCase, input string)) {
+	h.t.Helper()
+	h.Run(name+"/original", func(tc *TestCase) {
+		fn(tc, input)
+	})
+	for _, p := range perturbations {
+		h.Run(name+"/"+p.Name, func(tc *TestCase) {
+			ctx, cancel := context.WithTimeout(WithConversation(context.Background(), tc.name), h.timeout)
+			defer cancel()
+			perturbed, err := p.Apply(ctx, input)
+			if err != nil {
+				tc.t.Fatalf("perturbation %q failed: %v", p.Name, err)
+			}
+			tc.t.Logf("perturbed input: %q", perturbed)
+			fn(tc, perturbed)
+		})
+	}
+}
+
+// Typos introduces n single-character typos (adjacent swaps, drops, and
+// doubled letters) at letter positions chosen from seed, so runs are
+// reproducible.
+func Typos(n int, seed int64) Perturbation {
+	return Perturbation{
+		Name: "typos",
+		Apply: func(_ context.Context, input string) (string, error) {
+			rng := rand.New(rand.NewSource(seed))
+			runes := []rune(input)
+			for i := 0; i < n; i++ {
+				var letters []int
+				for j, r := range runes {
+					if unicode.IsLetter(r) {
+						letters = append(letters, j)
+					}
+				}
+				if len(letters) == 0 {
+					break
+				}
+				pos := letters[rng.Intn(len(letters))]
+				switch rng.Intn(3) {
+				case 0:
+					if pos+1 < len(runes) {
+						runes[pos], runes[pos+1] = runes[pos+1], runes[pos]
+					}
+				case 1:
+					runes = append(runes[:pos], runes[pos+1:]...)
+				case 2:
+					runes = append(runes[:pos+1], runes[pos:]...)
+				}
+			}
+			return string(runes), nil
+		},
+	}
+}
+
+// Lowercase lowercases the entire input.
+func Lowercase() Perturbation {
+	return Perturbation{
+		Name: "lowercase",
+		Apply: func(_ context.Context, input string) (string, error) {
+			return strings.ToLower(input), nil
+		},
+	}
+}
+
+// Uppercase uppercases the entire input.
+func Uppercase() Perturbation {
+	return Perturbation{
+		Name: "uppercase",
+		Apply: func(_ context.Context, input string) (string, error) {
+			return strings.ToUpper(input), nil
+		},
+	}
+}
+
+// ExtraWhitespace doubles the spacing between words and pads the input
+// with surrounding blank lines.
+func ExtraWhitespace() Perturbation {
+	return Perturbation{
+		Name: "whitespace",
+		Apply: func(_ context.Context, input string) (string, error) {
+			return "\n  " + strings.Join(strings.Fields(input), "   ") + "  \n\n", nil
+		},
+	}
+}
+
+const paraphraseSystemPrompt = `Rewrite the user's message so it asks for exactly the same thing using different wording. Do not answer it. Respond with only the rewritten message.`
+
+// Paraphrase asks p to reword the input while preserving its meaning.
+// Use a different provider from the agent under test so the paraphrase
+// does not consume the agent's scripted responses.
+func Paraphrase(p provider.Provider, model string) Perturbation {
+	return Perturbation{
+		Name: "paraphrase",
+		Apply: func(ctx context.Context, input string) (string, error) {
+			resp, err := p.Complete(ctx, &provider.Request{
+				Model:     model,
+				System:    paraphraseSystemPrompt,
+				Messages:  []provider.Message{{Role: "user", Content: input}},
+				MaxTokens: 1024,
+			})
+			if err != nil {
+				return "", fmt.Errorf("paraphrase call failed: %w", err)
+			}
+			out := strings.TrimSpace(resp.Content)
+			if out == "" {
+				return "", fmt.Errorf("paraphrase returned empty output")
+			}
+			return out, nil
+		},
+	}
+}
@@ -0,0 +1,83 @@
+package evaltest
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// Summary aggregates the cases run through a Harness. It is logged when the
+// harness's test finishes and, with WithSummaryFile, written as JSON so
+// `go test -json` pipelines can pick up eval metrics without parsing
+// assertion output.
+type Summary struct {
+	Test          string  `json:"test"`
+	Model         string  `json:"model,omitempty"`
+	Cases         int     `json:"cases"`
+	Passed        int     `json:"passed"`
+	Failed        int     `json:"failed"`
+	PassRate      float64 `json:"pass_rate"`
+	InputTokens   int     `json:"input_tokens"`
+	OutputTokens  int     `json:"output_tokens"`
+	EstimatedCost float64 `json:"estimated_cost_usd"`
+}
+
+// WithSummaryFile writes the harness Summary as JSON to path when the test
+// finishes.
+func WithSummaryFile(path string) Option {
+	return func(h *Harness) {
+		h.summaryFile = path
+	}
+}
+
+// Summary returns aggregate results for the cases run so far.
+func (h *Harness) Summary() Summary {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	s := Summary{Test: h.t.Name(), Model: h.model, Cases: len(h.results)}
+	for _, r := range h.results {
+		if r.Passed {
+			s.Passed++
+		} else {
+			s.Failed++
+		}
+		s.InputTokens += r.InputTokens
+		s.OutputTokens += r.OutputTokens
+	}
+	if s.Cases > 0 {
+		s.PassRate = float64(s.Passed) / float64(s.Cases)
+	}
+	s.EstimatedCost = provider.EstimateCost(h.model, provider.Usage{
+		InputTokens:  s.InputTokens,
+		OutputTokens: s.OutputTokens,
+	})
+	return s
+}
+
+// reportSummary logs the summary on the harness's test and writes the
+// summary file if one is configured. The JSON line is prefixed with
+// "eval summary json:" so it can be extracted from `go test -json` output.
+func (h *Harness) reportSummary() {
+	s := h.Summary()
+	if s.Cases == 0 {
+		return
+	}
+
+	h.t.Logf("eval summary: %d cases, %d passed (%.1f%%), tokens %d in / %d out, est. cost $%.4f",
+		s.Cases, s.Passed, s.PassRate*100, s.InputTokens, s.OutputTokens, s.EstimatedCost)
+	data, err := json.Marshal(s)
+	if err != nil {
+		h.t.Errorf("evaltest: failed to marshal summary: %v", err)
+		return
+	}
+	h.t.Logf("eval summary json: %s", data)
+
+	if h.summaryFile == "" {
+		return
+	}
+	if err := os.WriteFile(h.summaryFile, append(data, '\n'), 0o644); err != nil {
+		h.t.Errorf("evaltest: failed to write summary to %s: %v", h.summaryFile, err)
+	}
+}
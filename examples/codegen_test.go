@@ -224,4 +224,13 @@ func TestScoreMatchers(t *testing.T) {
 	if atLeast.Match(0.4) {
 		t.Error("ScoreAtLeast(0.5) should not match 0.4")
 	}
+
+	// Matchers compose: good but not suspiciously perfect
+	plausible := evaltest.AllOf(evaltest.ScoreAtLeast(0.7), evaltest.ScoreBelow(1.0))
+	if !plausible.Match(0.85) {
+		t.Error("AllOf(ScoreAtLeast(0.7), ScoreBelow(1.0)) should match 0.85")
+	}
+	if plausible.Match(1.0) {
+		t.Error("AllOf(ScoreAtLeast(0.7), ScoreBelow(1.0)) should not match 1.0")
+	}
 }
@@ -0,0 +1,174 @@
+// Package agentloop implements the agent tool-use loop shared by the
+// runner and the evaltest harness, so suites and Go tests drive agents
+// the same way.
+package agentloop
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+// DefaultMaxIterations is the number of tool-call round-trips allowed
+// when a caller sets no limit.
+const DefaultMaxIterations = 20
+
+// ToolResolver produces the result of a tool call. *mock.MockRegistry
+// implements it.
+type ToolResolver interface {
+	Resolve(toolName string, params map[string]interface{}) (string, error)
+}
+
+// ToolHook is called for every tool call once its result is known, with
+// the call and its trace entry. An error records a violation on the call's
+// trace entry; the loop continues.
+type ToolHook func(call provider.ToolCall, result trace.ToolCallTrace) error
+
+// Result is the outcome of a loop.
+type Result struct {
+	// Final is the model's answer, empty if it never stopped calling
+	// tools.
+	Final string
+
+	// Messages is the conversation, starting with the request's messages
+	// and ending with the final assistant message, so it can be
+	// continued.
+	Messages []provider.Message
+
+	// Iterations is the 1-based iteration that was last started. On a
+	// provider error it identifies the request that failed.
+	Iterations int
+
+	// Done is set when the model answered without calling tools, and
+	// unset when the loop ran out of iterations.
+	Done bool
+}
+
+// Run sends req to p, resolves any tool calls through tools, feeds the
+// results back, and repeats until the model answers without tool calls or
+// maxIterations round-trips have been made (DefaultMaxIterations when
+// maxIterations <= 0). req.ToolChoice applies to the first request only,
+// so a forced tool call does not stop the model from answering afterwards.
+// When req.Tools is set, calls to tools not in it are recorded as
+// hallucinated and answered with an error instead of being resolved.
+// Assistant and tool messages, tool calls, usage, provider retries, and the
+// model versions the provider reports are recorded in tr; the caller records the initial messages.
+// onToolCall, when not nil, is called for every tool call, hallucinated
+// ones included. The loop stops with tr's LimitErr once tr has exceeded
+// its limits.
+func Run(ctx context.Context, p provider.Provider, req provider.Request, tools ToolResolver, tr *trace.AgentTrace, maxIterations int, onToolCall ToolHook) (Result, error) {
+	if maxIterations <= 0 {
+		maxIterations = DefaultMaxIterations
+	}
+	res := Result{Messages: append([]provider.Message(nil), req.Messages...)}
+
+	for res.Iterations = 1; res.Iterations <= maxIterations; res.Iterations++ {
+		req.Messages = res.Messages
+		if res.Iterations > 1 {
+			req.ToolChoice = nil
+		}
+		resp, err := p.Complete(ctx, &req)
+		if err != nil {
+			var re *provider.RetryError
+			if errors.As(err, &re) {
+				tr.AddRetries(re.Retry.Retries, re.Retry.Backoff)
+				tr.AddOverloaded(re.Retry.Overloaded)
+			}
+			return res, err
+		}
+		tr.AddUsage(resp.Usage.InputTokens, resp.Usage.OutputTokens)
+		tr.AddCacheUsage(resp.Usage.CacheReadTokens, resp.Usage.CacheWriteTokens)
+		tr.AddRetries(resp.Retry.Retries, resp.Retry.Backoff)
+		tr.AddOverloaded(resp.Retry.Overloaded)
+		tr.AddModelVersion(resp.Model)
+		tr.AddSystemFingerprint(resp.SystemFingerprint)
+		tr.AddProvider(resp.Provider, resp.Fallbacks)
+
+		// If no tool calls, we have the final response.
+		if len(resp.ToolCalls) == 0 {
+			tr.AddMessage("assistant", resp.Content)
+			res.Messages = append(res.Messages, provider.Message{Role: "assistant", Content: resp.Content})
+			res.Final = resp.Content
+			res.Done = true
+			return res, tr.LimitErr()
+		}
+
+		// Record assistant message with tool calls.
+		tr.AddMessage("assistant", resp.Content)
+
+		// Append the assistant message (with tool calls) to the conversation.
+		res.Messages = append(res.Messages, provider.Message{
+			Role:      "assistant",
+			Content:   resp.Content,
+			ToolCalls: resp.ToolCalls,
+			Reasoning: resp.Reasoning,
+		})
+
+		// Resolve each tool call. Calls to tools the request did not
+		// offer are hallucinated: they are flagged and answered with an
+		// error rather than resolved.
+		for _, tc := range resp.ToolCalls {
+			tcStart := time.Now()
+			hallucinated := len(req.Tools) > 0 && !offersTool(req.Tools, tc.Name)
+			var content string
+			var toolErr error
+			if hallucinated {
+				toolErr = fmt.Errorf("tool %q does not exist", tc.Name)
+			} else {
+				content, toolErr = tools.Resolve(tc.Name, tc.Parameters)
+			}
+			tcDuration := time.Since(tcStart)
+
+			tcTrace := trace.ToolCallTrace{
+				ToolName:   tc.Name,
+				Parameters: tc.Parameters,
+				Response:   content,
+				StartTime:  tcStart,
+				EndTime:    time.Now(),
+				Duration:   tcDuration,
+
+				Hallucinated: hallucinated,
+			}
+			if toolErr != nil {
+				tcTrace.Error = toolErr.Error()
+			}
+			if onToolCall != nil {
+				if err := onToolCall(tc, tcTrace); err != nil {
+					tcTrace.Violation = err.Error()
+				}
+			}
+			tr.AddToolCall(tcTrace)
+
+			// Add the tool result as a message for the next turn.
+			toolContent := content
+			if toolErr != nil {
+				toolContent = fmt.Sprintf("Error: %v", toolErr)
+			}
+			res.Messages = append(res.Messages, provider.Message{
+				Role:       "tool",
+				Content:    toolContent,
+				ToolCallID: tc.ID,
+			})
+			tr.AddMessage("tool", toolContent)
+		}
+		if err := tr.LimitErr(); err != nil {
+			return res, err
+		}
+	}
+	res.Iterations = maxIterations
+	return res, nil
+}
+
+// offersTool reports whether tools includes one named name.
+func offersTool(tools []provider.Tool, name string) bool {
+	for _, t := range tools {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
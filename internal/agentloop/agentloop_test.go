@@ -0,0 +1,85 @@
+package agentloop
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+// loopingProvider calls the lookup tool on every turn.
+type loopingProvider struct{ calls int }
+
+func (p *loopingProvider) Complete(_ context.Context, _ *provider.Request) (*provider.Response, error) {
+	p.calls++
+	return &provider.Response{
+		ToolCalls:  []provider.ToolCall{{ID: "tc", Name: "lookup"}},
+		StopReason: "tool_use",
+	}, nil
+}
+
+func (p *loopingProvider) Name() string { return "looping" }
+
+func TestRun_MaxIterations(t *testing.T) {
+	p := &loopingProvider{}
+	tools := mock.NewRegistry([]mock.MockConfig{{ToolName: "lookup", DefaultResponse: &mock.MockResponse{Content: "x"}}})
+	req := provider.Request{Messages: []provider.Message{{Role: "user", Content: "go"}}}
+
+	res, err := Run(context.Background(), p, req, tools, trace.New(), 3, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if res.Done || res.Final != "" {
+		t.Errorf("Done = %v, Final = %q, want an unfinished loop", res.Done, res.Final)
+	}
+	if res.Iterations != 3 || p.calls != 3 {
+		t.Errorf("Iterations = %d, provider calls = %d, want 3 and 3", res.Iterations, p.calls)
+	}
+	// The user message plus an assistant and a tool message per iteration.
+	if len(res.Messages) != 7 {
+		t.Errorf("len(Messages) = %d, want 7", len(res.Messages))
+	}
+}
+
+func TestRun_DefaultMaxIterations(t *testing.T) {
+	p := &loopingProvider{}
+	req := provider.Request{Messages: []provider.Message{{Role: "user", Content: "go"}}}
+
+	res, _ := Run(context.Background(), p, req, mock.NewRegistry(nil), trace.New(), 0, nil)
+	if res.Iterations != DefaultMaxIterations || p.calls != DefaultMaxIterations {
+		t.Errorf("Iterations = %d, provider calls = %d, want %d", res.Iterations, p.calls, DefaultMaxIterations)
+	}
+}
+
+func TestRun_ToolHook(t *testing.T) {
+	p := &loopingProvider{}
+	tools := mock.NewRegistry([]mock.MockConfig{{ToolName: "lookup", DefaultResponse: &mock.MockResponse{Content: "x"}}})
+	req := provider.Request{
+		Messages: []provider.Message{{Role: "user", Content: "go"}},
+		Tools:    []provider.Tool{{Name: "search"}},
+	}
+	tr := trace.New()
+	var seen []trace.ToolCallTrace
+	hook := func(call provider.ToolCall, result trace.ToolCallTrace) error {
+		seen = append(seen, result)
+		if len(seen) == 2 {
+			return fmt.Errorf("second call to %s", call.Name)
+		}
+		return nil
+	}
+	if _, err := Run(context.Background(), p, req, tools, tr, 2, hook); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	// lookup is not offered, so the hook sees hallucinated calls too.
+	if len(seen) != 2 || !seen[0].Hallucinated {
+		t.Fatalf("hook saw %+v, want 2 hallucinated calls", seen)
+	}
+	if got, want := tr.ToolViolations(), []string{"lookup: second call to lookup"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ToolViolations() = %v, want %v", got, want)
+	}
+}
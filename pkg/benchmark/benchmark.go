@@ -0,0 +1,124 @@
+package benchmark
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// Dataset is a public benchmark that can be converted to a suite.
+type Dataset struct {
+	// Name is the dataset's name on the command line, and the name of the
+	// suite and prompt it is converted to.
+	Name        string
+	Description string
+
+	// URL is where the dataset is downloaded from. A URL ending in ".gz"
+	// is decompressed.
+	URL string
+
+	// SHA256 is the hex SHA-256 digest of the file at URL as downloaded,
+	// before any decompression. Fetch rejects a download that does not
+	// match it; an empty SHA256 skips the check.
+	SHA256 string
+
+	// Prompt is the YAML of the prompt the suite's cases run with.
+	Prompt string
+
+	// convert adds a case to b for each record of the dataset.
+	convert func(b *suite.Builder, records [][]byte) error
+}
+
+// Datasets lists the registered benchmarks.
+func Datasets() []Dataset { return registry }
+
+// Lookup returns the registered benchmark with the given name.
+func Lookup(name string) (Dataset, bool) {
+	for _, d := range registry {
+		if d.Name == name {
+			return d, true
+		}
+	}
+	return Dataset{}, false
+}
+
+// Names returns the names of the registered benchmarks.
+func Names() []string {
+	names := make([]string, len(registry))
+	for i, d := range registry {
+		names[i] = d.Name
+	}
+	return names
+}
+
+// Fetch downloads the dataset with client and converts it; see Convert.
+func (d Dataset) Fetch(ctx context.Context, client *http.Client, limit int) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, d.URL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", d.Name, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("downloading %s: %s returned %s", d.Name, d.URL, resp.Status)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("downloading %s: %w", d.Name, err)
+	}
+	if d.SHA256 != "" {
+		sum := sha256.Sum256(data)
+		if got := hex.EncodeToString(sum[:]); !strings.EqualFold(got, d.SHA256) {
+			return nil, fmt.Errorf("downloading %s: %s has SHA-256 %s, want %s", d.Name, d.URL, got, d.SHA256)
+		}
+	}
+	if strings.HasSuffix(d.URL, ".gz") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("decompressing %s: %w", d.Name, err)
+		}
+		defer zr.Close()
+		if data, err = io.ReadAll(zr); err != nil {
+			return nil, fmt.Errorf("decompressing %s: %w", d.Name, err)
+		}
+	}
+	return d.Convert(data, limit)
+}
+
+// Convert converts the dataset's JSON Lines data to the YAML of a suite.
+// A positive limit keeps only the first limit records.
+func (d Dataset) Convert(data []byte, limit int) ([]byte, error) {
+	var records [][]byte
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for sc.Scan() {
+		if line := bytes.TrimSpace(sc.Bytes()); len(line) > 0 {
+			records = append(records, append([]byte(nil), line...))
+		}
+		if limit > 0 && len(records) == limit {
+			break
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", d.Name, err)
+	}
+
+	b := suite.New(d.Name).Description(d.Description).Prompt(d.Name)
+	if err := d.convert(b, records); err != nil {
+		return nil, fmt.Errorf("converting %s: %w", d.Name, err)
+	}
+	return b.YAML()
+}
@@ -0,0 +1,162 @@
+package benchmark
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// load converts data with the named dataset and loads the resulting suite
+// and prompt files.
+func load(t *testing.T, name, data string, limit int) (*suite.EvalSuite, *prompt.PromptVariant) {
+	t.Helper()
+	d, ok := Lookup(name)
+	if !ok {
+		t.Fatalf("dataset %q not registered", name)
+	}
+	out, err := d.Convert([]byte(data), limit)
+	if err != nil {
+		t.Fatalf("Convert() error: %v", err)
+	}
+	dir := t.TempDir()
+	suitePath := filepath.Join(dir, "suite.yaml")
+	promptPath := filepath.Join(dir, "prompt.yaml")
+	if err := os.WriteFile(suitePath, out, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(promptPath, []byte(d.Prompt), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := suite.Load(suitePath)
+	if err != nil {
+		t.Fatalf("loading converted suite: %v\n%s", err, out)
+	}
+	pv, err := prompt.Load(promptPath)
+	if err != nil {
+		t.Fatalf("loading prompt: %v", err)
+	}
+	if pv.Name != s.Prompt {
+		t.Errorf("prompt name = %q, suite prompt = %q", pv.Name, s.Prompt)
+	}
+	return s, pv
+}
+
+func TestConvert_GSM8K(t *testing.T) {
+	data := `{"question": "Tom has 3 apples and buys 1,200 more. How many?", "answer": "3 + 1200 = 1203\n#### 1,203"}
+{"question": "What is 2 + 2?", "answer": "2 + 2 = 4\n#### 4"}
+{"question": "Unused", "answer": "#### 1"}
+`
+	s, _ := load(t, "gsm8k", data, 2)
+	if len(s.Cases) != 2 {
+		t.Fatalf("got %d cases, want 2 (limit)", len(s.Cases))
+	}
+	c := s.Cases[0]
+	if c.Name != "gsm8k-0001" || c.ExpectedOutput != "1203" || c.Input["question"] == nil {
+		t.Errorf("case = %+v", c)
+	}
+	if len(c.Judges) != 1 || c.Judges[0].Type != "numeric" || c.Judges[0].Value != "1203" {
+		t.Errorf("judges = %+v, want one numeric judge for 1203", c.Judges)
+	}
+
+	d, _ := Lookup("gsm8k")
+	if _, err := d.Convert([]byte(`{"question": "q", "answer": "no marker"}`), 0); err == nil {
+		t.Error("Convert accepted an answer without a final line")
+	}
+}
+
+func TestConvert_HumanEvalGo(t *testing.T) {
+	data := `{"task_id": "Go/7", "prompt": "package main\n\n// Add adds.\nfunc Add(a, b int) int {\n", "test": "func TestAdd(t *testing.T) {\n    assert := assert.New(t)\n    assert.Equal(5, Add(2, 3))\n}\n"}
+`
+	s, _ := load(t, "humaneval-go", data, 0)
+	c := s.Cases[0]
+	if c.Name != "humaneval-go-7" || c.ID != "Go/7" {
+		t.Errorf("case = %q (%q), want humaneval-go-7 (Go/7)", c.Name, c.ID)
+	}
+	if len(c.Judges) != 1 || c.Judges[0].Type != "go_test" {
+		t.Fatalf("judges = %+v, want one go_test judge", c.Judges)
+	}
+	test := c.Judges[0].Value
+	for _, want := range []string{"package main", `"testing"`, `"github.com/stretchr/testify/assert"`, "func TestAdd"} {
+		if !strings.Contains(test, want) {
+			t.Errorf("test file missing %s:\n%s", want, test)
+		}
+	}
+}
+
+func TestConvert_MTBench(t *testing.T) {
+	data := `{"question_id": 81, "category": "writing", "turns": ["Write a travel blog post.", "Rewrite it."]}
+{"question_id": 111, "category": "math", "turns": ["What is the area?"], "reference": ["The area is 3."]}
+`
+	s, _ := load(t, "mt-bench", data, 0)
+	if len(s.Cases) != 2 {
+		t.Fatalf("got %d cases, want 2", len(s.Cases))
+	}
+	if c := s.Cases[0]; c.Input["question"] != "Write a travel blog post." || len(c.Tags) != 1 || c.Tags[0] != "writing" {
+		t.Errorf("case = %+v, want the first turn tagged writing", c)
+	}
+	if c := s.Cases[1]; c.ExpectedOutput != "The area is 3." || c.Judges[0].Type != "llm" {
+		t.Errorf("case = %+v, want the reference answer and an llm judge", c)
+	}
+}
+
+func TestFetch_Gzip(t *testing.T) {
+	var gz bytes.Buffer
+	zw := gzip.NewWriter(&gz)
+	zw.Write([]byte(`{"task_id": "Go/0", "prompt": "p", "test": "func TestX(t *testing.T) {}"}` + "\n"))
+	zw.Close()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/data.jsonl.gz" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(gz.Bytes())
+	}))
+	defer server.Close()
+
+	d, _ := Lookup("humaneval-go")
+	d.URL = server.URL + "/data.jsonl.gz"
+	out, err := d.Fetch(context.Background(), server.Client(), 0)
+	if err != nil {
+		t.Fatalf("Fetch() error: %v", err)
+	}
+	if !strings.Contains(string(out), "humaneval-go-0") {
+		t.Errorf("suite missing case:\n%s", out)
+	}
+
+	d.URL = server.URL + "/missing.jsonl"
+	if _, err := d.Fetch(context.Background(), server.Client(), 0); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("Fetch() of a missing file error = %v, want 404", err)
+	}
+}
+
+func TestFetch_Checksum(t *testing.T) {
+	data := `{"question": "What is 1 + 1?", "answer": "It is 2.\n#### 2"}` + "\n"
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(data))
+	}))
+	defer server.Close()
+
+	d, _ := Lookup("gsm8k")
+	d.URL = server.URL + "/test.jsonl"
+	sum := sha256.Sum256([]byte(data))
+	d.SHA256 = hex.EncodeToString(sum[:])
+	if _, err := d.Fetch(context.Background(), server.Client(), 0); err != nil {
+		t.Fatalf("Fetch() with a matching checksum error: %v", err)
+	}
+
+	d.SHA256 = strings.Repeat("0", 64)
+	if _, err := d.Fetch(context.Background(), server.Client(), 0); err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Errorf("Fetch() with a mismatched checksum error = %v, want a SHA-256 mismatch", err)
+	}
+}
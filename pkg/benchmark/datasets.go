@@ -0,0 +1,146 @@
+package benchmark
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+var registry = []Dataset{
+	{
+		Name:        "gsm8k",
+		Description: "GSM8K grade school math word problems (test split), scored by final numeric answer. Source: github.com/openai/grade-school-math (MIT).",
+		URL:         "https://raw.githubusercontent.com/openai/grade-school-math/master/grade_school_math/data/test.jsonl",
+		Prompt: `name: gsm8k
+description: Grade school math word problems, answered with a final number.
+system: |
+  Solve the math word problem. Reason step by step, then give the final
+  answer as a plain number on the last line.
+user: "{{.question}}"
+`,
+		convert: convertGSM8K,
+	},
+	{
+		Name:        "humaneval-go",
+		Description: "HumanEval-X Go function completion problems, scored by running each problem's tests. Source: huggingface.co/datasets/THUDM/humaneval-x (Apache-2.0).",
+		URL:         "https://huggingface.co/datasets/THUDM/humaneval-x/resolve/main/data/go/data/humaneval.jsonl.gz",
+		Prompt: `name: humaneval-go
+description: Go function completion, checked by running unit tests.
+system: |
+  You are an expert Go programmer. Complete the function so that it is
+  correct for every input its documentation allows.
+user: |
+  Complete this Go code. Reply with the complete Go file, including the
+  package clause and imports, in a single go code block.
+
+  {{.prompt}}
+`,
+		convert: convertHumanEvalGo,
+	},
+	{
+		Name:        "mt-bench",
+		Description: "MT-Bench open-ended questions (first turn) across eight categories, scored by an LLM judge. Source: github.com/lm-sys/FastChat (Apache-2.0).",
+		URL:         "https://raw.githubusercontent.com/lm-sys/FastChat/main/fastchat/llm_judge/data/mt_bench/question.jsonl",
+		Prompt: `name: mt-bench
+description: Open-ended questions in writing, roleplay, reasoning, math, coding, extraction, STEM, and humanities.
+user: "{{.question}}"
+`,
+		convert: convertMTBench,
+	},
+}
+
+// convertGSM8K converts GSM8K records, whose answers are a worked
+// solution ending in "#### <number>".
+func convertGSM8K(b *suite.Builder, records [][]byte) error {
+	for i, line := range records {
+		var r struct {
+			Question string `json:"question"`
+			Answer   string `json:"answer"`
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+		_, final, ok := strings.Cut(r.Answer, "####")
+		if !ok {
+			return fmt.Errorf("record %d: answer has no final \"####\" line", i+1)
+		}
+		final = strings.ReplaceAll(strings.TrimSpace(final), ",", "")
+		name := fmt.Sprintf("gsm8k-%04d", i+1)
+		b.Case(name).ID(name).
+			Input("question", r.Question).
+			ExpectedOutput(final).
+			Judge(suite.JudgeConfig{Type: "numeric", Value: final})
+	}
+	return nil
+}
+
+// convertHumanEvalGo converts HumanEval-X Go records. Their tests assert
+// with testify, which the go_test judge fetches as a module dependency.
+func convertHumanEvalGo(b *suite.Builder, records [][]byte) error {
+	for i, line := range records {
+		var r struct {
+			TaskID    string `json:"task_id"`
+			Prompt    string `json:"prompt"`
+			Test      string `json:"test"`
+			TestSetup string `json:"test_setup"`
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+		_, num, _ := strings.Cut(r.TaskID, "/")
+		name := "humaneval-go-" + num
+		b.Case(name).ID(r.TaskID).
+			Input("prompt", r.Prompt).
+			Judge(suite.JudgeConfig{Type: "go_test", Value: humanEvalTestFile(r.TestSetup, r.Test)})
+	}
+	return nil
+}
+
+// humanEvalTestFile assembles a HumanEval-X test into a complete test
+// file. Records that carry no test_setup get the imports their test uses.
+func humanEvalTestFile(setup, test string) string {
+	if strings.TrimSpace(setup) == "" {
+		imports := []string{`"testing"`}
+		if strings.Contains(test, "assert.") {
+			imports = append(imports, `"github.com/stretchr/testify/assert"`)
+		}
+		setup = "package main\n\nimport (\n\t" + strings.Join(imports, "\n\t") + "\n)\n"
+	}
+	return strings.TrimRight(setup, "\n") + "\n\n" + test
+}
+
+// mtBenchRubric adapts MT-Bench's single-answer grading instructions to
+// the llm judge's scale.
+const mtBenchRubric = `Act as an impartial judge of the quality of the response to the user's question. ` +
+	`Consider its helpfulness, relevance, accuracy, depth, creativity, and level of detail. ` +
+	`When a reference answer is given, correctness against it matters most.`
+
+// convertMTBench converts MT-Bench questions. Suites hold single-turn
+// cases, so only each question's first turn is used.
+func convertMTBench(b *suite.Builder, records [][]byte) error {
+	for i, line := range records {
+		var r struct {
+			QuestionID int      `json:"question_id"`
+			Category   string   `json:"category"`
+			Turns      []string `json:"turns"`
+			Reference  []string `json:"reference"`
+		}
+		if err := json.Unmarshal(line, &r); err != nil {
+			return fmt.Errorf("record %d: %w", i+1, err)
+		}
+		if len(r.Turns) == 0 {
+			return fmt.Errorf("record %d: question %d has no turns", i+1, r.QuestionID)
+		}
+		name := fmt.Sprintf("mt-bench-%d", r.QuestionID)
+		c := b.Case(name).ID(name).
+			Input("question", r.Turns[0]).
+			Tags(r.Category).
+			Judge(suite.JudgeConfig{Type: "llm", Value: mtBenchRubric})
+		if len(r.Reference) > 0 {
+			c.ExpectedOutput(r.Reference[0])
+		}
+	}
+	return nil
+}
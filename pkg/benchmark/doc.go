@@ -0,0 +1,7 @@
+// Package benchmark converts well-known public benchmark datasets into
+// eval suites, so that public baselines can be run alongside private
+// suites. Each registered Dataset names where its data is downloaded from,
+// the prompt its cases run with, and the judges that score them: a numeric
+// judge for GSM8K answers, a go_test judge for HumanEval solutions, and an
+// LLM judge for MT-Bench's open-ended questions.
+package benchmark
@@ -0,0 +1,54 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Redacted replaces every secret found in bundled files.
+const Redacted = "[REDACTED]"
+
+// File is one entry in a bundle.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Write writes files to w as a zip archive. Every occurrence of each
+// secret in a file's contents is replaced with Redacted; empty secrets are
+// ignored. Entries are timestamped with modified.
+func Write(w io.Writer, files []File, secrets []string, modified time.Time) error {
+	zw := zip.NewWriter(w)
+	for _, f := range files {
+		fw, err := zw.CreateHeader(&zip.FileHeader{
+			Name:     f.Name,
+			Method:   zip.Deflate,
+			Modified: modified,
+		})
+		if err != nil {
+			return fmt.Errorf("adding %s to bundle: %w", f.Name, err)
+		}
+		if _, err := fw.Write(Redact(f.Data, secrets)); err != nil {
+			return fmt.Errorf("writing %s to bundle: %w", f.Name, err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finishing bundle: %w", err)
+	}
+	return nil
+}
+
+// Redact returns data with every occurrence of each secret replaced with
+// Redacted.
+func Redact(data []byte, secrets []string) []byte {
+	for _, s := range secrets {
+		if s == "" {
+			continue
+		}
+		data = bytes.ReplaceAll(data, []byte(s), []byte(Redacted))
+	}
+	return data
+}
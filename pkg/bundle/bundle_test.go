@@ -0,0 +1,38 @@
+package bundle
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestWrite(t *testing.T) {
+	files := []File{
+		{Name: "run.json", Data: []byte(`{"error":"401: invalid key sk-secret-123"}`)},
+		{Name: "suite.yaml", Data: []byte("name: s\n")},
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, files, []string{"sk-secret-123", ""}, time.Time{}); err != nil {
+		t.Fatalf("Write() error: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("reading zip: %v", err)
+	}
+	if len(zr.File) != 2 {
+		t.Fatalf("zip has %d files, want 2", len(zr.File))
+	}
+	rc, err := zr.File[0].Open()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	got, _ := io.ReadAll(rc)
+	if want := `{"error":"401: invalid key [REDACTED]"}`; string(got) != want {
+		t.Errorf("run.json = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,4 @@
+// Package bundle packages a run result with the suite, prompt, and config
+// behind it into a single zip archive, with secrets redacted, for sharing
+// in bug reports or across teams.
+package bundle
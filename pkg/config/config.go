@@ -4,6 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"gopkg.in/yaml.v3"
@@ -16,19 +20,310 @@ type Config struct {
 	Timeout     time.Duration             `yaml:"timeout"`
 	OutputDir   string                    `yaml:"output_dir"`
 	RetryConfig RetryConfig               `yaml:"retry"`
+	Retention   RetentionConfig           `yaml:"retention"`
+	HTTP        HTTPConfig                `yaml:"http"`
+
+	// Quotas caps each named provider's usage over a single run, keyed
+	// by the provider's name in Providers.
+	Quotas map[string]QuotaConfig `yaml:"quotas"`
+
+	// Prices is the path of a prices file whose per-model rates are merged
+	// over the built-in pricing used for cost estimates; see
+	// provider.LoadPricing.
+	Prices string `yaml:"prices"`
+
+	// PriceFallback prices models that neither the built-in table nor the
+	// prices file covers, so their cost is estimated instead of $0.
+	PriceFallback *PriceFallbackConfig `yaml:"price_fallback"`
+
+	// TemplateEnv allow-lists the environment variables that prompts and
+	// case inputs may read with {{env "NAME"}}, such as account IDs that
+	// differ between staging and production.
+	TemplateEnv []string `yaml:"template_env"`
+
+	// SpotCheck routes a sample of the cases the judges pass to human
+	// review, to measure how often a judge pass is right.
+	SpotCheck SpotCheckConfig `yaml:"spot_check"`
+
+	// SecretScan guards against sending credentials from fixtures to
+	// provider APIs.
+	SecretScan SecretScanConfig `yaml:"secret_scan"`
+
+	// Signing signs the result files a run writes, so they can be shown
+	// unmodified with 'eval verify'.
+	Signing SigningConfig `yaml:"signing"`
+
+	// TraceLimits caps how large each case's trace may grow before the
+	// case fails; zero fields use the runner's defaults.
+	TraceLimits TraceLimitsConfig `yaml:"trace_limits"`
+
+	// Seed, when set, is sent with every agent request so repeated runs
+	// are as reproducible as the provider allows.
+	Seed *int64 `yaml:"seed"`
 }
 
+// Built-in provider types accepted in ProviderConfig.Type.
+const (
+	ProviderAnthropic   = "anthropic"
+	ProviderOpenAI      = "openai"
+	ProviderAzureOpenAI = "azure-openai"
+	ProviderOpenRouter  = "openrouter"
+)
+
+var (
+	providerTypesMu sync.RWMutex
+	providerTypes   = map[string]bool{
+		ProviderAnthropic:   true,
+		ProviderOpenAI:      true,
+		ProviderAzureOpenAI: true,
+		ProviderOpenRouter:  true,
+	}
+)
+
+// RegisterProviderType makes Validate accept typ as a provider type.
+// provider.Register calls it for each registered provider.
+func RegisterProviderType(typ string) {
+	providerTypesMu.Lock()
+	defer providerTypesMu.Unlock()
+	providerTypes[typ] = true
+}
+
+// ProviderTypes returns the accepted provider types, sorted.
+func ProviderTypes() []string {
+	providerTypesMu.RLock()
+	defer providerTypesMu.RUnlock()
+	types := make([]string, 0, len(providerTypes))
+	for t := range providerTypes {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+func knownProviderType(typ string) bool {
+	providerTypesMu.RLock()
+	defer providerTypesMu.RUnlock()
+	return providerTypes[typ]
+}
+
+// Key rotation modes, selected with ProviderConfig.KeyRotation.
+const (
+	KeyRotationRoundRobin  = "round_robin"   // each request uses the next key (the default)
+	KeyRotationOnRateLimit = "on_rate_limit" // keep using a key until it is rate limited
+)
+
+// OpenAI API surfaces, selected with ProviderConfig.API.
+const (
+	APIChatCompletions = "chat_completions"
+	APIResponses       = "responses"
+)
+
 // ProviderConfig holds configuration for a single LLM provider.
 type ProviderConfig struct {
+	// Type selects the provider implementation. It defaults to the
+	// provider's name in the providers map, so "anthropic" and "openai"
+	// entries need not set it.
+	Type string `yaml:"type"`
+
 	Model     string `yaml:"model"`
 	BaseURL   string `yaml:"base_url"`
 	APIKeyEnv string `yaml:"api_key_env"`
+
+	// APIKeyEnvs names several environment variables holding API keys,
+	// in place of APIKeyEnv, to spread a large run's requests over a pool
+	// of keys. KeyRotation selects how requests move between them.
+	APIKeyEnvs  []string `yaml:"api_key_envs"`
+	KeyRotation string   `yaml:"key_rotation"`
+
+	// Headers are added to every request sent to this provider, e.g.
+	// organization or routing headers required by an enterprise gateway.
+	Headers map[string]string `yaml:"headers"`
+
+	// Deployment and APIVersion configure an azure-openai provider, whose
+	// base_url is the resource endpoint. Deployment defaults to Model.
+	Deployment string `yaml:"deployment"`
+	APIVersion string `yaml:"api_version"`
+
+	// Routing holds an openrouter provider's routing preferences.
+	Routing *RoutingConfig `yaml:"routing"`
+
+	// API selects the API an openai provider calls: "chat_completions"
+	// (the default) or "responses", which newer models expect.
+	API string `yaml:"api"`
+
+	// Batch sends an anthropic or openai provider's requests through the
+	// vendor's batch API: half the price, but results can take hours.
+	Batch bool `yaml:"batch"`
+
+	// RateLimit paces the provider's requests, across every concurrent
+	// case and judge of a run, below the vendor's rate limits.
+	RateLimit *RateLimitConfig `yaml:"rate_limit"`
+
+	// Fallbacks names other configured providers to try, in order, when
+	// this one fails with exhausted retries, an overload, or an
+	// unavailable model. Each serves the request with its own model. The
+	// fallbacks' own fallbacks are not followed.
+	Fallbacks []string `yaml:"fallbacks"`
+}
+
+// RateLimitConfig sets a provider's client-side rate limits. Zero values
+// mean no limit.
+type RateLimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	TokensPerMinute   int `yaml:"tokens_per_minute"` // input and output combined
+}
+
+// RoutingConfig holds OpenRouter provider routing preferences: which
+// upstream providers may serve a model slug, and in what order. See
+// OpenRouter's provider routing documentation for each field's meaning.
+type RoutingConfig struct {
+	Order             []string `yaml:"order"`
+	Only              []string `yaml:"only"`
+	Ignore            []string `yaml:"ignore"`
+	AllowFallbacks    *bool    `yaml:"allow_fallbacks"`
+	RequireParameters bool     `yaml:"require_parameters"`
+	DataCollection    string   `yaml:"data_collection"` // allow or deny
+	Sort              string   `yaml:"sort"`            // price, throughput, or latency
+}
+
+// ResolvedType returns the provider's type, falling back to name, its key
+// in the providers map.
+func (p ProviderConfig) ResolvedType(name string) string {
+	if p.Type != "" {
+		return p.Type
+	}
+	return name
+}
+
+// ResolvedDeployment returns the Azure deployment name, which defaults to
+// the model name.
+func (p ProviderConfig) ResolvedDeployment() string {
+	if p.Deployment != "" {
+		return p.Deployment
+	}
+	return p.Model
 }
 
 // RetryConfig holds retry behavior settings.
 type RetryConfig struct {
 	MaxRetries int           `yaml:"max_retries"`
 	BaseDelay  time.Duration `yaml:"base_delay"`
+
+	// MaxTotalRetries and MaxTotalBackoff bound the retrying done across
+	// a whole run. Once either is spent, calls stop retrying and cases not
+	// yet started are skipped. Zero means no limit.
+	MaxTotalRetries int           `yaml:"max_total_retries"`
+	MaxTotalBackoff time.Duration `yaml:"max_total_backoff"`
+}
+
+// QuotaConfig limits one provider's usage over a run, protecting shared
+// organization API quotas from an accidentally unbounded suite. Zero
+// values mean no limit.
+type QuotaConfig struct {
+	MaxRequests int `yaml:"max_requests"`
+	MaxTokens   int `yaml:"max_tokens"` // input and output combined
+}
+
+// PriceFallbackConfig is a token rate in USD per million tokens.
+type PriceFallbackConfig struct {
+	Input  float64 `yaml:"input"`
+	Output float64 `yaml:"output"`
+}
+
+// TraceLimitsConfig caps the messages and bytes a single case's trace may
+// hold, so an agent stuck in a verbose loop fails its case with "trace
+// limit exceeded" instead of exhausting memory at high concurrency.
+type TraceLimitsConfig struct {
+	MaxMessages int `yaml:"max_messages"`
+	MaxBytes    int `yaml:"max_bytes"`
+}
+
+// SigningConfig names the HMAC key result files are signed with. Signing
+// is off unless KeyEnv is set.
+type SigningConfig struct {
+	// KeyEnv is the environment variable holding the signing key.
+	KeyEnv string `yaml:"key_env"`
+
+	// KeyID is recorded in each signature to say which key made it; it is
+	// not secret.
+	KeyID string `yaml:"key_id"`
+}
+
+// Key reads the signing key from the environment variable named by
+// KeyEnv.
+func (sc SigningConfig) Key() ([]byte, error) {
+	if sc.KeyEnv == "" {
+		return nil, errors.New("signing.key_env is not configured")
+	}
+	key := os.Getenv(sc.KeyEnv)
+	if key == "" {
+		return nil, fmt.Errorf("environment variable %s for signing.key_env is not set", sc.KeyEnv)
+	}
+	return []byte(key), nil
+}
+
+// SecretScanConfig enables scanning every outbound provider request,
+// rendered prompts and tool results alike, for secrets. A case whose
+// request matches fails instead of sending it.
+type SecretScanConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Patterns adds named regular expressions to the built-in patterns
+	// for common API keys, tokens, and private keys.
+	Patterns map[string]string `yaml:"patterns"`
+}
+
+// SpotCheckConfig sets how many judge-passed cases are sampled for human
+// review. Rates are fractions in [0, 1]; the first band containing a
+// case's score overrides Rate. Zero values sample nothing.
+type SpotCheckConfig struct {
+	Rate  float64         `yaml:"rate"`
+	Bands []SpotCheckBand `yaml:"bands"`
+
+	// Seed makes the sample reproducible; 0 picks a random seed, or a
+	// fixed one for 'eval run --deterministic'.
+	Seed int64 `yaml:"seed"`
+}
+
+// SpotCheckBand is a spot-check rate for the scores from Min to Max
+// inclusive.
+type SpotCheckBand struct {
+	Min  float64 `yaml:"min"`
+	Max  float64 `yaml:"max"`
+	Rate float64 `yaml:"rate"`
+}
+
+// Enabled reports whether any case can be sampled.
+func (sc SpotCheckConfig) Enabled() bool {
+	if sc.Rate > 0 {
+		return true
+	}
+	for _, b := range sc.Bands {
+		if b.Rate > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HTTPConfig tunes the connection pool shared by all providers. Zero
+// values use the provider package defaults.
+type HTTPConfig struct {
+	MaxIdleConnsPerHost int           `yaml:"max_idle_conns_per_host"`
+	MaxConnsPerHost     int           `yaml:"max_conns_per_host"`
+	IdleConnTimeout     time.Duration `yaml:"idle_conn_timeout"`
+	KeepAlive           time.Duration `yaml:"keep_alive"`
+	DisableHTTP2        bool          `yaml:"disable_http2"`
+}
+
+// RetentionConfig controls which result files 'eval results prune' keeps.
+// A file is kept if it satisfies any rule; zero values disable a rule.
+type RetentionConfig struct {
+	KeepLast int `yaml:"keep_last"`
+	KeepDays int `yaml:"keep_days"`
+	// Pinned lists result files (relative to output_dir) that are never
+	// pruned, such as baselines used for comparison.
+	Pinned []string `yaml:"pinned"`
 }
 
 // Default returns a Config populated with sensible defaults.
@@ -76,20 +371,102 @@ func LoadOrDefault(path string) (*Config, error) {
 }
 
 // ResolveAPIKey reads the API key for the named provider from the environment
-// variable specified in that provider's APIKeyEnv field.
+// variable specified in that provider's APIKeyEnv field, or the first of
+// its APIKeyEnvs.
 func (c *Config) ResolveAPIKey(providerName string) (string, error) {
 	p, ok := c.Providers[providerName]
 	if !ok {
 		return "", fmt.Errorf("provider %q not found in config", providerName)
 	}
-	if p.APIKeyEnv == "" {
-		return "", fmt.Errorf("provider %q has no api_key_env configured", providerName)
+	return p.APIKey(providerName)
+}
+
+// APIKey reads the provider's API key from the environment variable named
+// by APIKeyEnv, or the first of APIKeyEnvs. name is the provider's key in
+// the providers map, used in errors.
+func (p ProviderConfig) APIKey(name string) (string, error) {
+	keys, err := p.APIKeys(name)
+	if err != nil {
+		return "", err
 	}
-	key := os.Getenv(p.APIKeyEnv)
-	if key == "" {
-		return "", fmt.Errorf("environment variable %s for provider %q is not set", p.APIKeyEnv, providerName)
+	return keys[0], nil
+}
+
+// APIKeys reads the provider's API keys from the environment variables
+// named by APIKeyEnvs, or the single one named by APIKeyEnv. Every
+// variable must be set.
+func (p ProviderConfig) APIKeys(name string) ([]string, error) {
+	envs := p.keyEnvs()
+	if len(envs) == 0 {
+		return nil, fmt.Errorf("provider %q has no api_key_env configured", name)
 	}
-	return key, nil
+	keys := make([]string, len(envs))
+	for i, env := range envs {
+		keys[i] = os.Getenv(env)
+		if keys[i] == "" {
+			return nil, fmt.Errorf("environment variable %s for provider %q is not set", env, name)
+		}
+	}
+	return keys, nil
+}
+
+// keyEnvs returns the environment variables holding the provider's keys.
+func (p ProviderConfig) keyEnvs() []string {
+	if len(p.APIKeyEnvs) > 0 {
+		return p.APIKeyEnvs
+	}
+	if p.APIKeyEnv != "" {
+		return []string{p.APIKeyEnv}
+	}
+	return nil
+}
+
+// RedactedHeader replaces header values in a Redacted config.
+const RedactedHeader = "[REDACTED]"
+
+// Redacted returns a copy of the config that is safe to share: provider
+// header values, which often carry credentials, are replaced with
+// RedactedHeader. API keys are never stored in the config itself.
+func (c *Config) Redacted() *Config {
+	out := *c
+	out.Providers = make(map[string]ProviderConfig, len(c.Providers))
+	for name, p := range c.Providers {
+		if len(p.Headers) > 0 {
+			headers := make(map[string]string, len(p.Headers))
+			for k := range p.Headers {
+				headers[k] = RedactedHeader
+			}
+			p.Headers = headers
+		}
+		out.Providers[name] = p
+	}
+	return &out
+}
+
+// Secrets returns the API keys currently set in the environment for the
+// configured providers, the values of the template_env variables that
+// may have been injected into prompts, and the signing key, so they can
+// be scrubbed from shared output.
+func (c *Config) Secrets() []string {
+	var secrets []string
+	for _, p := range c.Providers {
+		for _, env := range p.keyEnvs() {
+			if key := os.Getenv(env); key != "" {
+				secrets = append(secrets, key)
+			}
+		}
+	}
+	for _, name := range c.TemplateEnv {
+		if v := os.Getenv(name); v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	if c.Signing.KeyEnv != "" {
+		if v := os.Getenv(c.Signing.KeyEnv); v != "" {
+			secrets = append(secrets, v)
+		}
+	}
+	return secrets
 }
 
 // Validate checks the config for required fields and returns a descriptive
@@ -112,13 +489,130 @@ func (c *Config) Validate() error {
 	if c.RetryConfig.BaseDelay < 0 {
 		errs = append(errs, fmt.Errorf("retry.base_delay must be >= 0, got %s", c.RetryConfig.BaseDelay))
 	}
+	if c.RetryConfig.MaxTotalRetries < 0 {
+		errs = append(errs, fmt.Errorf("retry.max_total_retries must be >= 0, got %d", c.RetryConfig.MaxTotalRetries))
+	}
+	if c.RetryConfig.MaxTotalBackoff < 0 {
+		errs = append(errs, fmt.Errorf("retry.max_total_backoff must be >= 0, got %s", c.RetryConfig.MaxTotalBackoff))
+	}
+	if c.HTTP.MaxIdleConnsPerHost < 0 {
+		errs = append(errs, fmt.Errorf("http.max_idle_conns_per_host must be >= 0, got %d", c.HTTP.MaxIdleConnsPerHost))
+	}
+	if c.HTTP.MaxConnsPerHost < 0 {
+		errs = append(errs, fmt.Errorf("http.max_conns_per_host must be >= 0, got %d", c.HTTP.MaxConnsPerHost))
+	}
+	if c.Retention.KeepLast < 0 {
+		errs = append(errs, fmt.Errorf("retention.keep_last must be >= 0, got %d", c.Retention.KeepLast))
+	}
+	if c.Retention.KeepDays < 0 {
+		errs = append(errs, fmt.Errorf("retention.keep_days must be >= 0, got %d", c.Retention.KeepDays))
+	}
+	if pf := c.PriceFallback; pf != nil && (pf.Input < 0 || pf.Output < 0) {
+		errs = append(errs, errors.New("price_fallback: prices must be >= 0"))
+	}
+	if c.TraceLimits.MaxMessages < 0 {
+		errs = append(errs, fmt.Errorf("trace_limits.max_messages must be >= 0, got %d", c.TraceLimits.MaxMessages))
+	}
+	if c.TraceLimits.MaxBytes < 0 {
+		errs = append(errs, fmt.Errorf("trace_limits.max_bytes must be >= 0, got %d", c.TraceLimits.MaxBytes))
+	}
+	if r := c.SpotCheck.Rate; r < 0 || r > 1 {
+		errs = append(errs, fmt.Errorf("spot_check.rate must be between 0 and 1, got %g", r))
+	}
+	for i, b := range c.SpotCheck.Bands {
+		if b.Rate < 0 || b.Rate > 1 {
+			errs = append(errs, fmt.Errorf("spot_check.bands[%d].rate must be between 0 and 1, got %g", i, b.Rate))
+		}
+		if b.Min > b.Max {
+			errs = append(errs, fmt.Errorf("spot_check.bands[%d]: min %g is above max %g", i, b.Min, b.Max))
+		}
+	}
+
+	for name, pattern := range c.SecretScan.Patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			errs = append(errs, fmt.Errorf("secret_scan.patterns.%s: %w", name, err))
+		}
+	}
+
+	for name, q := range c.Quotas {
+		if _, ok := c.Providers[name]; !ok {
+			errs = append(errs, fmt.Errorf("quotas.%s: no provider named %q", name, name))
+		}
+		if q.MaxRequests < 0 {
+			errs = append(errs, fmt.Errorf("quotas.%s.max_requests must be >= 0, got %d", name, q.MaxRequests))
+		}
+		if q.MaxTokens < 0 {
+			errs = append(errs, fmt.Errorf("quotas.%s.max_tokens must be >= 0, got %d", name, q.MaxTokens))
+		}
+	}
 
 	for name, p := range c.Providers {
 		if p.Model == "" {
 			errs = append(errs, fmt.Errorf("provider %q: model is required", name))
 		}
-		if p.APIKeyEnv == "" {
+		switch {
+		case p.APIKeyEnv == "" && len(p.APIKeyEnvs) == 0:
 			errs = append(errs, fmt.Errorf("provider %q: api_key_env is required", name))
+		case p.APIKeyEnv != "" && len(p.APIKeyEnvs) > 0:
+			errs = append(errs, fmt.Errorf("provider %q: set api_key_env or api_key_envs, not both", name))
+		}
+		for i, env := range p.APIKeyEnvs {
+			if env == "" {
+				errs = append(errs, fmt.Errorf("provider %q: api_key_envs[%d] is empty", name, i))
+			}
+		}
+		switch p.KeyRotation {
+		case "", KeyRotationRoundRobin, KeyRotationOnRateLimit:
+		default:
+			errs = append(errs, fmt.Errorf("provider %q: key_rotation must be %s or %s, got %q", name, KeyRotationRoundRobin, KeyRotationOnRateLimit, p.KeyRotation))
+		}
+		if p.Routing != nil {
+			if p.ResolvedType(name) != ProviderOpenRouter {
+				errs = append(errs, fmt.Errorf("provider %q: routing is only supported for type %s", name, ProviderOpenRouter))
+			}
+			switch p.Routing.Sort {
+			case "", "price", "throughput", "latency":
+			default:
+				errs = append(errs, fmt.Errorf("provider %q: routing.sort must be price, throughput, or latency, got %q", name, p.Routing.Sort))
+			}
+			switch p.Routing.DataCollection {
+			case "", "allow", "deny":
+			default:
+				errs = append(errs, fmt.Errorf("provider %q: routing.data_collection must be allow or deny, got %q", name, p.Routing.DataCollection))
+			}
+		}
+		switch p.API {
+		case "", APIChatCompletions:
+		case APIResponses:
+			if p.ResolvedType(name) != ProviderOpenAI {
+				errs = append(errs, fmt.Errorf("provider %q: api %s is only supported for type %s", name, APIResponses, ProviderOpenAI))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("provider %q: api must be %s or %s, got %q", name, APIChatCompletions, APIResponses, p.API))
+		}
+		for _, fb := range p.Fallbacks {
+			if _, ok := c.Providers[fb]; !ok || fb == name {
+				errs = append(errs, fmt.Errorf("provider %q: fallback %q must be another configured provider", name, fb))
+			}
+		}
+		if rl := p.RateLimit; rl != nil {
+			if rl.RequestsPerMinute < 0 {
+				errs = append(errs, fmt.Errorf("provider %q: rate_limit.requests_per_minute must be >= 0, got %d", name, rl.RequestsPerMinute))
+			}
+			if rl.TokensPerMinute < 0 {
+				errs = append(errs, fmt.Errorf("provider %q: rate_limit.tokens_per_minute must be >= 0, got %d", name, rl.TokensPerMinute))
+			}
+		}
+		if t := p.ResolvedType(name); p.Batch && t != ProviderAnthropic && t != ProviderOpenAI {
+			errs = append(errs, fmt.Errorf("provider %q: batch is only supported for types %s and %s", name, ProviderAnthropic, ProviderOpenAI))
+		}
+		switch t := p.ResolvedType(name); {
+		case t == ProviderAzureOpenAI:
+			if p.BaseURL == "" {
+				errs = append(errs, fmt.Errorf("provider %q: base_url is required for type %s (the resource endpoint, e.g. https://my-resource.openai.azure.com)", name, ProviderAzureOpenAI))
+			}
+		case p.Type != "" && !knownProviderType(t):
+			errs = append(errs, fmt.Errorf("provider %q: unknown type %q (want one of %s)", name, p.Type, strings.Join(ProviderTypes(), ", ")))
 		}
 	}
 
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
@@ -233,6 +234,344 @@ func TestValidate_MultipleErrors(t *testing.T) {
 	}
 }
 
+func TestLoad_ProviderHeaders(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  gateway:
+    model: gpt-4o
+    api_key_env: GATEWAY_KEY
+    headers:
+      X-Org-Id: org-42
+      X-Route: eu-west
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	h := cfg.Providers["gateway"].Headers
+	if h["X-Org-Id"] != "org-42" || h["X-Route"] != "eu-west" {
+		t.Errorf("Headers = %v, want X-Org-Id and X-Route", h)
+	}
+}
+
+func TestLoad_AzureOpenAI(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  azure:
+    type: azure-openai
+    model: gpt-4o
+    base_url: https://my-resource.openai.azure.com
+    deployment: prod-4o
+    api_version: "2024-06-01"
+    api_key_env: AZURE_OPENAI_KEY
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	p := cfg.Providers["azure"]
+	if p.ResolvedType("azure") != ProviderAzureOpenAI || p.ResolvedDeployment() != "prod-4o" || p.APIVersion != "2024-06-01" {
+		t.Errorf("provider = %+v", p)
+	}
+
+	// The type defaults to the provider's name, and the deployment to the model.
+	if got := (ProviderConfig{}).ResolvedType("openai"); got != ProviderOpenAI {
+		t.Errorf("ResolvedType(openai) = %q, want %q", got, ProviderOpenAI)
+	}
+	if got := (ProviderConfig{Model: "gpt-4o"}).ResolvedDeployment(); got != "gpt-4o" {
+		t.Errorf("ResolvedDeployment() = %q, want gpt-4o", got)
+	}
+}
+
+func TestValidate_ProviderType(t *testing.T) {
+	cfg := Default()
+	cfg.Providers["azure"] = ProviderConfig{Type: ProviderAzureOpenAI, Model: "gpt-4o", APIKeyEnv: "KEY"}
+	cfg.Providers["other"] = ProviderConfig{Type: "bedrock", Model: "m", APIKeyEnv: "KEY"}
+	cfg.Providers["openai"] = ProviderConfig{Model: "gpt-4o", APIKeyEnv: "KEY", Batch: true}
+	cfg.Providers["openrouter"] = ProviderConfig{Model: "openai/gpt-4o", APIKeyEnv: "KEY", Batch: true}
+
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{`provider "azure": base_url is required`, `provider "other": unknown type "bedrock"`, `provider "openrouter": batch is only supported for types anthropic and openai`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), `provider "openai"`) {
+		t.Errorf("error %q rejects batch for openai", err)
+	}
+}
+
+func TestLoad_Quotas(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  anthropic:
+    model: claude-sonnet-4-20250514
+    api_key_env: ANTHROPIC_API_KEY
+quotas:
+  anthropic:
+    max_requests: 500
+    max_tokens: 2000000
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if q := cfg.Quotas["anthropic"]; q.MaxRequests != 500 || q.MaxTokens != 2000000 {
+		t.Errorf("Quotas[anthropic] = %+v, want max_requests=500 max_tokens=2000000", q)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.Quotas["anthropic"] = QuotaConfig{MaxTokens: -1}
+	cfg.Quotas["bedrock"] = QuotaConfig{MaxRequests: 10}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"quotas.anthropic.max_tokens must be >= 0", `quotas.bedrock: no provider named "bedrock"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestLoad_SpotCheck(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  anthropic:
+    model: claude-sonnet-4-20250514
+    api_key_env: ANTHROPIC_API_KEY
+spot_check:
+  rate: 0.05
+  bands:
+    - {min: 0.5, max: 0.7, rate: 0.25}
+  seed: 7
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := SpotCheckConfig{Rate: 0.05, Bands: []SpotCheckBand{{Min: 0.5, Max: 0.7, Rate: 0.25}}, Seed: 7}
+	if !reflect.DeepEqual(cfg.SpotCheck, want) {
+		t.Errorf("SpotCheck = %+v, want %+v", cfg.SpotCheck, want)
+	}
+	if !cfg.SpotCheck.Enabled() || (SpotCheckConfig{}).Enabled() {
+		t.Error("Enabled() should be true only when some rate is set")
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.SpotCheck = SpotCheckConfig{Rate: 1.5, Bands: []SpotCheckBand{{Min: 0.9, Max: 0.1, Rate: 0.5}}}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{"spot_check.rate must be between 0 and 1", "spot_check.bands[0]: min 0.9 is above max 0.1"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestLoad_SecretScan(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  anthropic:
+    model: claude-sonnet-4-20250514
+    api_key_env: ANTHROPIC_API_KEY
+secret_scan:
+  enabled: true
+  patterns:
+    internal_token: "itk_[a-f0-9]{32}"
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	want := SecretScanConfig{Enabled: true, Patterns: map[string]string{"internal_token": "itk_[a-f0-9]{32}"}}
+	if !reflect.DeepEqual(cfg.SecretScan, want) {
+		t.Errorf("SecretScan = %+v, want %+v", cfg.SecretScan, want)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.SecretScan.Patterns["broken"] = "itk_["
+	err = cfg.Validate()
+	if err == nil || !strings.Contains(err.Error(), "secret_scan.patterns.broken") {
+		t.Errorf("Validate() = %v, want an error naming the broken pattern", err)
+	}
+}
+
+func TestAPIKeys_Pool(t *testing.T) {
+	t.Setenv("KEY_A", "a")
+	t.Setenv("KEY_B", "b")
+	p := ProviderConfig{Model: "m", APIKeyEnvs: []string{"KEY_A", "KEY_B"}, KeyRotation: KeyRotationOnRateLimit}
+	keys, err := p.APIKeys("anthropic")
+	if err != nil {
+		t.Fatalf("APIKeys() error: %v", err)
+	}
+	if !reflect.DeepEqual(keys, []string{"a", "b"}) {
+		t.Errorf("APIKeys() = %v, want [a b]", keys)
+	}
+	if key, _ := p.APIKey("anthropic"); key != "a" {
+		t.Errorf("APIKey() = %q, want the first key", key)
+	}
+	cfg := Default()
+	cfg.Providers = map[string]ProviderConfig{"anthropic": p}
+	if err := cfg.Validate(); err != nil {
+		t.Errorf("Validate() error: %v", err)
+	}
+	if secrets := cfg.Secrets(); len(secrets) != 2 {
+		t.Errorf("Secrets() = %v, want both keys", secrets)
+	}
+
+	t.Setenv("KEY_B", "")
+	if _, err := p.APIKeys("anthropic"); err == nil || !strings.Contains(err.Error(), "KEY_B") {
+		t.Errorf("APIKeys() = %v, want an error naming KEY_B", err)
+	}
+
+	p.APIKeyEnv = "KEY_A"
+	p.KeyRotation = "random"
+	cfg.Providers["anthropic"] = p
+	err = cfg.Validate()
+	for _, want := range []string{"not both", "key_rotation must be"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestLoad_OpenRouterRouting(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  openrouter:
+    model: anthropic/claude-sonnet-4
+    api_key_env: OPENROUTER_API_KEY
+    routing:
+      order: [Anthropic, Bedrock]
+      allow_fallbacks: false
+      sort: latency
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+	r := cfg.Providers["openrouter"].Routing
+	if r == nil || len(r.Order) != 2 || r.AllowFallbacks == nil || *r.AllowFallbacks || r.Sort != "latency" {
+		t.Errorf("Routing = %+v", r)
+	}
+
+	cfg.Providers["openai"] = ProviderConfig{Model: "gpt-4o", APIKeyEnv: "KEY", Routing: &RoutingConfig{Sort: "cheapest"}}
+	err = cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{`provider "openai": routing is only supported for type openrouter`, `routing.sort must be price, throughput, or latency, got "cheapest"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestValidate_API(t *testing.T) {
+	cfg := Default()
+	cfg.Providers["openai"] = ProviderConfig{Model: "o4-mini", APIKeyEnv: "KEY", API: APIResponses}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.Providers["anthropic"] = ProviderConfig{Model: "m", APIKeyEnv: "KEY", API: APIResponses}
+	cfg.Providers["other"] = ProviderConfig{Type: ProviderOpenAI, Model: "m", APIKeyEnv: "KEY", API: "assistants"}
+	err := cfg.Validate()
+	if err == nil {
+		t.Fatal("expected validation errors")
+	}
+	for _, want := range []string{`provider "anthropic": api responses is only supported for type openai`, `provider "other": api must be chat_completions or responses, got "assistants"`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestValidate_Fallbacks(t *testing.T) {
+	cfg := Default()
+	cfg.Providers["anthropic"] = ProviderConfig{Model: "m", APIKeyEnv: "KEY", Fallbacks: []string{"openai"}}
+	cfg.Providers["openai"] = ProviderConfig{Model: "gpt-4o", APIKeyEnv: "KEY"}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() error: %v", err)
+	}
+
+	cfg.Providers["openai"] = ProviderConfig{Model: "gpt-4o", APIKeyEnv: "KEY", Fallbacks: []string{"openai", "bedrock"}}
+	err := cfg.Validate()
+	for _, want := range []string{`fallback "openai" must be another`, `fallback "bedrock" must be another`} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Validate() = %v, want it to mention %q", err, want)
+		}
+	}
+}
+
+func TestLoad_RateLimit(t *testing.T) {
+	path := writeTemp(t, `
+providers:
+  openai:
+    model: gpt-4o
+    api_key_env: KEY
+    rate_limit:
+      requests_per_minute: 500
+      tokens_per_minute: 200000
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	rl := cfg.Providers["openai"].RateLimit
+	if rl == nil || rl.RequestsPerMinute != 500 || rl.TokensPerMinute != 200000 {
+		t.Errorf("RateLimit = %+v, want 500 requests and 200000 tokens per minute", rl)
+	}
+
+	rl.TokensPerMinute = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "rate_limit.tokens_per_minute") {
+		t.Errorf("Validate() error = %v, want rate_limit.tokens_per_minute error", err)
+	}
+}
+
+func TestLoad_Retention(t *testing.T) {
+	path := writeTemp(t, `
+retention:
+  keep_last: 20
+  keep_days: 30
+  pinned:
+    - baseline.json
+`)
+	cfg, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	if cfg.Retention.KeepLast != 20 || cfg.Retention.KeepDays != 30 {
+		t.Errorf("Retention = %+v, want keep_last=20 keep_days=30", cfg.Retention)
+	}
+	if len(cfg.Retention.Pinned) != 1 || cfg.Retention.Pinned[0] != "baseline.json" {
+		t.Errorf("Retention.Pinned = %v, want [baseline.json]", cfg.Retention.Pinned)
+	}
+
+	cfg.Retention.KeepDays = -1
+	if err := cfg.Validate(); err == nil || !strings.Contains(err.Error(), "retention.keep_days") {
+		t.Errorf("Validate() error = %v, want retention.keep_days error", err)
+	}
+}
+
 func TestResolveAPIKey(t *testing.T) {
 	cfg := Default()
 	cfg.Providers["anthropic"] = ProviderConfig{
@@ -291,6 +630,31 @@ func TestResolveAPIKey_NoAPIKeyEnv(t *testing.T) {
 	}
 }
 
+func TestRedactedAndSecrets(t *testing.T) {
+	cfg := Default()
+	cfg.Providers["openai"] = ProviderConfig{
+		Model:     "gpt-4o",
+		APIKeyEnv: "TEST_EVAL_OPENAI_KEY",
+		Headers:   map[string]string{"Authorization": "Bearer gateway-token"},
+	}
+	t.Setenv("TEST_EVAL_OPENAI_KEY", "sk-test-67890")
+	cfg.TemplateEnv = []string{"TEST_EVAL_ACCOUNT_ID"}
+	t.Setenv("TEST_EVAL_ACCOUNT_ID", "acct-staging-1")
+
+	red := cfg.Redacted()
+	if got := red.Providers["openai"].Headers["Authorization"]; got != RedactedHeader {
+		t.Errorf("redacted header = %q, want %q", got, RedactedHeader)
+	}
+	if got := cfg.Providers["openai"].Headers["Authorization"]; got != "Bearer gateway-token" {
+		t.Errorf("Redacted modified the original config: header = %q", got)
+	}
+
+	secrets := cfg.Secrets()
+	if len(secrets) != 2 || secrets[0] != "sk-test-67890" || secrets[1] != "acct-staging-1" {
+		t.Errorf("Secrets() = %v, want [sk-test-67890 acct-staging-1]", secrets)
+	}
+}
+
 func TestDefault(t *testing.T) {
 	cfg := Default()
 	if cfg.Concurrency != 5 {
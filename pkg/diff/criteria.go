@@ -0,0 +1,139 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// CriterionCheck is the outcome of one pass criterion.
+type CriterionCheck struct {
+	Name   string `json:"name"`
+	Pass   bool   `json:"pass"`
+	Detail string `json:"detail"`
+}
+
+// CriteriaResult is the outcome of checking a run against its suite's
+// pass criteria.
+type CriteriaResult struct {
+	Checks []CriterionCheck `json:"checks"`
+}
+
+// Pass reports whether every checked criterion held.
+func (cr *CriteriaResult) Pass() bool {
+	for _, c := range cr.Checks {
+		if !c.Pass {
+			return false
+		}
+	}
+	return true
+}
+
+// CheckCriteria checks run against the pass criteria of s, which holds
+// no checks when s has none. Regressions are counted as in Compare with no
+// threshold; when baseline is nil the regression criteria pass with a note
+// that there was nothing to compare against. Tag criteria are checked on
+// the results of the cases s gives that tag.
+func CheckCriteria(s *suite.EvalSuite, run, baseline *result.RunSummary) *CriteriaResult {
+	cr := &CriteriaResult{}
+	pc := s.PassCriteria
+	if pc == nil {
+		return cr
+	}
+	if pc.MinPassRate > 0 {
+		cr.Checks = append(cr.Checks, passRateCheck("min_pass_rate", "", run.Stats.PassRate, pc.MinPassRate))
+	}
+	if pc.MaxRegressionsVsBaseline != nil {
+		cr.Checks = append(cr.Checks, regressionCheck("max_regressions_vs_baseline", "", run, baseline, *pc.MaxRegressionsVsBaseline))
+	}
+
+	tags := make([]string, 0, len(pc.Tags))
+	for tag := range pc.Tags {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	for _, tag := range tags {
+		tc := pc.Tags[tag]
+		name := "tags." + tag
+		tagged := make(map[string]bool)
+		for _, c := range s.Cases {
+			if slices.Contains(c.Tags, tag) {
+				tagged[c.Name] = true
+			}
+		}
+		tagRun := withCases(run, tagged)
+		if len(tagRun.Results) == 0 {
+			cr.Checks = append(cr.Checks, CriterionCheck{Name: name, Pass: true, Detail: fmt.Sprintf("%s: no cases ran", tag)})
+			continue
+		}
+		if tc.MinPassRate > 0 {
+			rate := result.ComputeStats(tagRun.Results).PassRate
+			cr.Checks = append(cr.Checks, passRateCheck(name+".min_pass_rate", tag, rate, tc.MinPassRate))
+		}
+		if tc.MaxRegressions != nil {
+			var tagBaseline *result.RunSummary
+			if baseline != nil {
+				tagBaseline = withCases(baseline, tagged)
+			}
+			cr.Checks = append(cr.Checks, regressionCheck(name+".max_regressions", tag, tagRun, tagBaseline, *tc.MaxRegressions))
+		}
+	}
+	return cr
+}
+
+func passRateCheck(name, tag string, rate, min float64) CriterionCheck {
+	return CriterionCheck{
+		Name:   name,
+		Pass:   rate >= min,
+		Detail: tagPrefix(tag) + fmt.Sprintf("pass rate %.1f%% (min %.1f%%)", rate*100, min*100),
+	}
+}
+
+func regressionCheck(name, tag string, run, baseline *result.RunSummary, max int) CriterionCheck {
+	check := CriterionCheck{Name: name, Pass: true}
+	if baseline == nil {
+		check.Detail = tagPrefix(tag) + "no baseline run to compare against"
+		return check
+	}
+	n := Compare(baseline, run, 0).Summary.Regressed
+	check.Pass = n <= max
+	check.Detail = tagPrefix(tag) + fmt.Sprintf("%d regressions vs %s (max %d)", n, baseline.RunID, max)
+	return check
+}
+
+func tagPrefix(tag string) string {
+	if tag == "" {
+		return ""
+	}
+	return tag + ": "
+}
+
+// withCases returns a copy of s holding only the results of the named
+// cases, with its stats recomputed.
+func withCases(s *result.RunSummary, names map[string]bool) *result.RunSummary {
+	out := *s
+	out.Results = nil
+	for _, r := range s.Results {
+		if names[r.CaseName] {
+			out.Results = append(out.Results, r)
+		}
+	}
+	out.Stats = result.ComputeStats(out.Results)
+	return &out
+}
+
+// Print writes one line per criterion.
+func (cr *CriteriaResult) Print(w io.Writer) {
+	fmt.Fprintln(w, "Pass criteria:")
+	for _, c := range cr.Checks {
+		mark := "ok  "
+		if !c.Pass {
+			mark = "FAIL"
+		}
+		fmt.Fprintf(w, "  %s %s\n", mark, c.Detail)
+	}
+}
@@ -5,7 +5,10 @@ import (
 	"fmt"
 	"io"
 	"math"
+	"slices"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/jdgilhuly/go_eval_agent/pkg/result"
 )
@@ -21,8 +24,30 @@ const (
 	Removed   Category = "removed"
 )
 
+// Categories lists every category in the order reports show them.
+var Categories = []Category{Improved, Regressed, Unchanged, New, Removed}
+
+// ParseCategories parses category names, as given to 'eval diff --only'
+// and --fail-on, rejecting unknown ones.
+func ParseCategories(names []string) ([]Category, error) {
+	var out []Category
+	for _, name := range names {
+		c := Category(strings.ToLower(strings.TrimSpace(name)))
+		if !slices.Contains(Categories, c) {
+			valid := make([]string, len(Categories))
+			for i, v := range Categories {
+				valid[i] = string(v)
+			}
+			return nil, fmt.Errorf("unknown diff category %q (want %s)", name, strings.Join(valid, ", "))
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
 // CaseDiff represents the comparison of a single case between two runs.
 type CaseDiff struct {
+	Suite      string   `json:"suite,omitempty"` // set when diffing combined runs
 	CaseName   string   `json:"case_name"`
 	Category   Category `json:"category"`
 	ScoreA     float64  `json:"score_a"`
@@ -30,14 +55,123 @@ type CaseDiff struct {
 	ScoreDelta float64  `json:"score_delta"`
 	StatusA    string   `json:"status_a"`
 	StatusB    string   `json:"status_b"`
+
+	// Explanation is a model-written summary of what changed, filled in
+	// for regressed cases by Explain.
+	Explanation string `json:"explanation,omitempty"`
 }
 
 // DiffResult holds the full comparison between two runs.
 type DiffResult struct {
 	RunA  string     `json:"run_a"`
 	RunB  string     `json:"run_b"`
+	InfoA RunInfo    `json:"info_a"`
+	InfoB RunInfo    `json:"info_b"`
 	Cases []CaseDiff `json:"cases"`
 	Summary
+
+	// ModelDrift lists models both runs nominally used whose API
+	// reported different versions or system fingerprints, so a regression
+	// can be attributed to a changed model snapshot or backend rather than
+	// to the prompt or suite.
+	ModelDrift []ModelDrift `json:"model_drift,omitempty"`
+}
+
+// ModelDrift records a model alias that resolved to different versions,
+// or ran on different system fingerprints, in the two runs. Only the
+// fields that drifted are set.
+type ModelDrift struct {
+	Model     string   `json:"model"`
+	VersionsA []string `json:"versions_a,omitempty"`
+	VersionsB []string `json:"versions_b,omitempty"`
+
+	// FingerprintsA and FingerprintsB are the system fingerprints the API
+	// reported in each run, which change when the vendor changes the
+	// backend serving a model version.
+	FingerprintsA []string `json:"fingerprints_a,omitempty"`
+	FingerprintsB []string `json:"fingerprints_b,omitempty"`
+}
+
+// String describes the drift on a single line.
+func (md ModelDrift) String() string {
+	var parts []string
+	if len(md.VersionsA) > 0 {
+		parts = append(parts, fmt.Sprintf("served by %s in A, %s in B",
+			strings.Join(md.VersionsA, ", "), strings.Join(md.VersionsB, ", ")))
+	}
+	if len(md.FingerprintsA) > 0 {
+		parts = append(parts, fmt.Sprintf("system fingerprint %s in A, %s in B",
+			strings.Join(md.FingerprintsA, ", "), strings.Join(md.FingerprintsB, ", ")))
+	}
+	return md.Model + " " + strings.Join(parts, "; ")
+}
+
+// modelDrift compares the model versions and system fingerprints reported
+// in each run. Models without reported values in either run are not
+// compared.
+func modelDrift(a, b *result.RunSummary) []ModelDrift {
+	va, vb := a.ModelVersions(), b.ModelVersions()
+	fa, fb := a.SystemFingerprints(), b.SystemFingerprints()
+	models := make(map[string]bool)
+	for model := range va {
+		models[model] = true
+	}
+	for model := range fa {
+		models[model] = true
+	}
+	var drift []ModelDrift
+	for model := range models {
+		md := ModelDrift{Model: model}
+		if versionsB, ok := vb[model]; ok && len(va[model]) > 0 && !slices.Equal(va[model], versionsB) {
+			md.VersionsA, md.VersionsB = va[model], versionsB
+		}
+		if fpsB, ok := fb[model]; ok && len(fa[model]) > 0 && !slices.Equal(fa[model], fpsB) {
+			md.FingerprintsA, md.FingerprintsB = fa[model], fpsB
+		}
+		if md.VersionsA != nil || md.FingerprintsA != nil {
+			drift = append(drift, md)
+		}
+	}
+	sort.Slice(drift, func(i, j int) bool { return drift[i].Model < drift[j].Model })
+	return drift
+}
+
+// RunInfo carries the experiment metadata of one side of a diff.
+type RunInfo struct {
+	Tags   []string          `json:"tags,omitempty"`
+	Note   string            `json:"note,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+func runInfo(s *result.RunSummary) RunInfo {
+	return RunInfo{Tags: s.Tags, Note: s.Note, Labels: s.Labels}
+}
+
+// empty reports whether the run carries no metadata.
+func (ri RunInfo) empty() bool {
+	return len(ri.Tags) == 0 && ri.Note == "" && len(ri.Labels) == 0
+}
+
+// String renders the metadata on a single line.
+func (ri RunInfo) String() string {
+	var parts []string
+	if len(ri.Tags) > 0 {
+		parts = append(parts, "tags="+strings.Join(ri.Tags, ","))
+	}
+	if len(ri.Labels) > 0 {
+		keys := make([]string, 0, len(ri.Labels))
+		for k := range ri.Labels {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			parts = append(parts, k+"="+ri.Labels[k])
+		}
+	}
+	if ri.Note != "" {
+		parts = append(parts, fmt.Sprintf("note=%q", ri.Note))
+	}
+	return strings.Join(parts, " ")
 }
 
 // Summary holds counts by category.
@@ -47,36 +181,112 @@ type Summary struct {
 	Unchanged int `json:"unchanged"`
 	New       int `json:"new"`
 	Removed   int `json:"removed"`
+
+	// TimedOutA and TimedOutB count cases that hit their per-case timeout
+	// in each run, independent of the score-based category.
+	TimedOutA int `json:"timed_out_a"`
+	TimedOutB int `json:"timed_out_b"`
+
+	// RetriesA and RetriesB total the provider retries in each run, and
+	// BackoffA and BackoffB the time spent backing off, so API flakiness
+	// can be told apart from a real regression.
+	RetriesA int           `json:"retries_a,omitempty"`
+	RetriesB int           `json:"retries_b,omitempty"`
+	BackoffA time.Duration `json:"backoff_a,omitempty"`
+	BackoffB time.Duration `json:"backoff_b,omitempty"`
+
+	// HallucinatedA and HallucinatedB count cases whose agent called a
+	// tool that was not in its tool list.
+	HallucinatedA int `json:"hallucinated_a,omitempty"`
+	HallucinatedB int `json:"hallucinated_b,omitempty"`
+
+	// PassRateA and PassRateB are each run's pass rate with its 95%
+	// confidence interval.
+	PassRateA PassRate `json:"pass_rate_a"`
+	PassRateB PassRate `json:"pass_rate_b"`
+}
+
+// PassRate is a run's pass rate over Cases judged cases, with the bounds
+// of its 95% Wilson confidence interval.
+type PassRate struct {
+	Rate  float64 `json:"rate"`
+	Low   float64 `json:"low"`
+	High  float64 `json:"high"`
+	Cases int     `json:"cases"`
+}
+
+func passRate(s result.Stats) PassRate {
+	low, high := s.PassRateInterval()
+	return PassRate{Rate: s.PassRate, Low: low, High: high, Cases: s.JudgedCases()}
+}
+
+// String formats the rate and its interval.
+func (pr PassRate) String() string {
+	return fmt.Sprintf("%.1f%% [%.1f%%-%.1f%%, n=%d]", pr.Rate*100, pr.Low*100, pr.High*100, pr.Cases)
+}
+
+// PassRateNoise reports whether the two runs' pass rates differ while
+// their confidence intervals overlap, so the change should not be read as
+// an improvement or regression.
+func (s Summary) PassRateNoise() bool {
+	a, b := s.PassRateA, s.PassRateB
+	if a.Cases == 0 || b.Cases == 0 || a.Rate == b.Rate {
+		return false
+	}
+	return a.Low <= b.High && b.Low <= a.High
 }
 
 // Compare produces a diff between two run summaries. Cases are matched by
-// case_name. A threshold controls the minimum absolute score delta to
+// case_name, and by suite too in combined summaries of several suites. A threshold controls the minimum absolute score delta to
 // classify a case as improved or regressed (below threshold = unchanged).
 func Compare(a, b *result.RunSummary, threshold float64) *DiffResult {
 	dr := &DiffResult{
-		RunA: a.RunID,
-		RunB: b.RunID,
+		RunA:  a.RunID,
+		RunB:  b.RunID,
+		InfoA: runInfo(a),
+		InfoB: runInfo(b),
+
+		ModelDrift: modelDrift(a, b),
 	}
+	dr.Summary.PassRateA = passRate(a.Stats)
+	dr.Summary.PassRateB = passRate(b.Stats)
 
 	// Index cases from run A by name.
 	aMap := make(map[string]result.CaseResult, len(a.Results))
 	for _, cr := range a.Results {
-		aMap[cr.CaseName] = cr
+		aMap[caseKey(cr)] = cr
+		if cr.Status == "timeout" {
+			dr.Summary.TimedOutA++
+		}
+		dr.Summary.RetriesA += cr.Retries
+		dr.Summary.BackoffA += cr.BackoffTime
+		if len(cr.HallucinatedTools) > 0 {
+			dr.Summary.HallucinatedA++
+		}
 	}
 
 	// Index cases from run B by name.
 	bMap := make(map[string]result.CaseResult, len(b.Results))
 	for _, cr := range b.Results {
-		bMap[cr.CaseName] = cr
+		bMap[caseKey(cr)] = cr
+		if cr.Status == "timeout" {
+			dr.Summary.TimedOutB++
+		}
+		dr.Summary.RetriesB += cr.Retries
+		dr.Summary.BackoffB += cr.BackoffTime
+		if len(cr.HallucinatedTools) > 0 {
+			dr.Summary.HallucinatedB++
+		}
 	}
 
 	// Process all cases in B (may be matched from A, or new).
 	seen := make(map[string]bool, len(b.Results))
 	for _, crB := range b.Results {
-		seen[crB.CaseName] = true
+		seen[caseKey(crB)] = true
 
-		crA, inA := aMap[crB.CaseName]
+		crA, inA := aMap[caseKey(crB)]
 		cd := CaseDiff{
+			Suite:    crB.Suite,
 			CaseName: crB.CaseName,
 			ScoreB:   crB.Score,
 			StatusB:  statusStr(crB),
@@ -107,8 +317,9 @@ func Compare(a, b *result.RunSummary, threshold float64) *DiffResult {
 
 	// Cases in A but not in B are removed.
 	for _, crA := range a.Results {
-		if !seen[crA.CaseName] {
+		if !seen[caseKey(crA)] {
 			dr.Cases = append(dr.Cases, CaseDiff{
+				Suite:    crA.Suite,
 				CaseName: crA.CaseName,
 				Category: Removed,
 				ScoreA:   crA.Score,
@@ -134,8 +345,12 @@ func (dr *DiffResult) Filter(categories []Category) *DiffResult {
 	}
 
 	filtered := &DiffResult{
-		RunA: dr.RunA,
-		RunB: dr.RunB,
+		RunA:  dr.RunA,
+		RunB:  dr.RunB,
+		InfoA: dr.InfoA,
+		InfoB: dr.InfoB,
+
+		ModelDrift: dr.ModelDrift,
 	}
 	for _, cd := range dr.Cases {
 		if catSet[cd.Category] {
@@ -146,6 +361,32 @@ func (dr *DiffResult) Filter(categories []Category) *DiffResult {
 	return filtered
 }
 
+// Count returns the number of cases in category c across the whole
+// comparison, including cases a Filter left out.
+func (s Summary) Count(c Category) int {
+	switch c {
+	case Improved:
+		return s.Improved
+	case Regressed:
+		return s.Regressed
+	case Unchanged:
+		return s.Unchanged
+	case New:
+		return s.New
+	case Removed:
+		return s.Removed
+	}
+	return 0
+}
+
+// SummaryOnly returns a copy of the diff without its per-case rows, for
+// logs that only need the counts and warnings.
+func (dr *DiffResult) SummaryOnly() *DiffResult {
+	out := *dr
+	out.Cases = nil
+	return &out
+}
+
 // JSON serializes the diff result.
 func (dr *DiffResult) JSON() ([]byte, error) {
 	return json.MarshalIndent(dr, "", "  ")
@@ -154,43 +395,147 @@ func (dr *DiffResult) JSON() ([]byte, error) {
 // PrintTable writes a formatted diff table.
 func (dr *DiffResult) PrintTable(w io.Writer) {
 	sep := strings.Repeat("-", 82)
+	if !dr.InfoA.empty() || !dr.InfoB.empty() {
+		fmt.Fprintf(w, "  A: %s\n", strings.TrimSpace(dr.RunA+"  "+dr.InfoA.String()))
+		fmt.Fprintf(w, "  B: %s\n", strings.TrimSpace(dr.RunB+"  "+dr.InfoB.String()))
+	}
 	fmt.Fprintf(w, "%s\n", sep)
-	fmt.Fprintf(w, "  %-25s  %-10s  %8s  %8s  %8s\n", "CASE", "CHANGE", "SCORE A", "SCORE B", "DELTA")
-	fmt.Fprintf(w, "%s\n", sep)
+	if len(dr.Cases) > 0 {
+		fmt.Fprintf(w, "  %-25s  %-10s  %8s  %8s  %8s\n", "CASE", "CHANGE", "SCORE A", "SCORE B", "DELTA")
+		fmt.Fprintf(w, "%s\n", sep)
+	}
 
 	for _, cd := range dr.Cases {
-		name := cd.CaseName
+		name := cd.key()
 		if len(name) > 25 {
 			name = name[:22] + "..."
 		}
 
-		var delta string
-		switch cd.Category {
-		case New:
-			delta = "new"
-		case Removed:
-			delta = "removed"
-		default:
-			delta = fmt.Sprintf("%+.2f", cd.ScoreDelta)
-		}
-
 		fmt.Fprintf(w, "  %-25s  %-10s  %8.2f  %8.2f  %8s\n",
-			name, string(cd.Category), cd.ScoreA, cd.ScoreB, delta)
+			name, string(cd.Category), cd.ScoreA, cd.ScoreB, cd.delta())
+		if cd.Explanation != "" {
+			fmt.Fprintf(w, "    -> %s\n", cd.Explanation)
+		}
 	}
 
-	fmt.Fprintf(w, "%s\n", sep)
+	if len(dr.Cases) > 0 {
+		fmt.Fprintf(w, "%s\n", sep)
+	}
 	fmt.Fprintf(w, "  %d improved  %d regressed  %d unchanged  %d new  %d removed\n",
 		dr.Summary.Improved, dr.Summary.Regressed, dr.Summary.Unchanged,
 		dr.Summary.New, dr.Summary.Removed)
+	if dr.Summary.TimedOutA > 0 || dr.Summary.TimedOutB > 0 {
+		fmt.Fprintf(w, "  timeouts: %d in A, %d in B\n", dr.Summary.TimedOutA, dr.Summary.TimedOutB)
+	}
+	if dr.Summary.HallucinatedA > 0 || dr.Summary.HallucinatedB > 0 {
+		fmt.Fprintf(w, "  hallucinated tool calls: %d cases in A, %d in B\n", dr.Summary.HallucinatedA, dr.Summary.HallucinatedB)
+	}
+	if dr.Summary.RetriesA > 0 || dr.Summary.RetriesB > 0 {
+		fmt.Fprintf(w, "  API retries: %d in A (%s backoff), %d in B (%s backoff)\n",
+			dr.Summary.RetriesA, dr.Summary.BackoffA.Round(time.Millisecond),
+			dr.Summary.RetriesB, dr.Summary.BackoffB.Round(time.Millisecond))
+	}
+	if dr.Summary.PassRateA.Cases > 0 && dr.Summary.PassRateB.Cases > 0 {
+		fmt.Fprintf(w, "  pass rate: %s -> %s\n", dr.Summary.PassRateA, dr.Summary.PassRateB)
+	}
+	if dr.Summary.PassRateNoise() {
+		fmt.Fprintf(w, "  warning: pass rate change is within the 95%% confidence intervals and may be noise\n")
+	}
+	for _, md := range dr.ModelDrift {
+		fmt.Fprintf(w, "  warning: model version changed: %s\n", md)
+	}
 	fmt.Fprintf(w, "%s\n", sep)
 }
 
+// PrintMarkdown writes the diff as a markdown report, including any
+// explanations of regressed cases.
+func (dr *DiffResult) PrintMarkdown(w io.Writer) {
+	fmt.Fprintf(w, "# Eval diff\n\n")
+	fmt.Fprintf(w, "- **A:** %s\n", strings.TrimSpace("`"+dr.RunA+"` "+dr.InfoA.String()))
+	fmt.Fprintf(w, "- **B:** %s\n\n", strings.TrimSpace("`"+dr.RunB+"` "+dr.InfoB.String()))
+	fmt.Fprintf(w, "%d improved, %d regressed, %d unchanged, %d new, %d removed\n\n",
+		dr.Summary.Improved, dr.Summary.Regressed, dr.Summary.Unchanged,
+		dr.Summary.New, dr.Summary.Removed)
+	if dr.Summary.PassRateA.Cases > 0 && dr.Summary.PassRateB.Cases > 0 {
+		fmt.Fprintf(w, "Pass rate: %s -> %s\n\n", dr.Summary.PassRateA, dr.Summary.PassRateB)
+	}
+	if dr.Summary.PassRateNoise() {
+		fmt.Fprintf(w, "> **Warning:** the pass rate change is within the 95%% confidence intervals and may be noise.\n\n")
+	}
+	for _, md := range dr.ModelDrift {
+		fmt.Fprintf(w, "> **Warning:** model version changed: %s\n\n", md)
+	}
+
+	if len(dr.Cases) > 0 {
+		fmt.Fprintf(w, "| Case | Change | Score A | Score B | Delta |\n")
+		fmt.Fprintf(w, "|---|---|---:|---:|---:|\n")
+	}
+	for _, cd := range dr.Cases {
+		fmt.Fprintf(w, "| %s | %s | %.2f | %.2f | %s |\n",
+			mdEscape(cd.key()), cd.Category, cd.ScoreA, cd.ScoreB, cd.delta())
+	}
+
+	var explained []CaseDiff
+	for _, cd := range dr.Cases {
+		if cd.Explanation != "" {
+			explained = append(explained, cd)
+		}
+	}
+	if len(explained) > 0 {
+		fmt.Fprintf(w, "\n## Regressions explained\n\n")
+		for _, cd := range explained {
+			fmt.Fprintf(w, "- **%s** (%+.2f): %s\n", mdEscape(cd.key()), cd.ScoreDelta, cd.Explanation)
+		}
+	}
+}
+
+// caseKey identifies a case within a run: its name, qualified by its
+// suite in a combined summary.
+func caseKey(cr result.CaseResult) string {
+	if cr.Suite != "" {
+		return cr.Suite + "/" + cr.CaseName
+	}
+	return cr.CaseName
+}
+
+// key is the caseKey of the compared case.
+func (cd CaseDiff) key() string {
+	if cd.Suite != "" {
+		return cd.Suite + "/" + cd.CaseName
+	}
+	return cd.CaseName
+}
+
+// delta formats the score change, or the category for cases present in
+// only one run.
+func (cd CaseDiff) delta() string {
+	switch cd.Category {
+	case New:
+		return "new"
+	case Removed:
+		return "removed"
+	}
+	return fmt.Sprintf("%+.2f", cd.ScoreDelta)
+}
+
+// mdEscape escapes characters that would break a markdown table cell.
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", "\\|")
+}
+
 func statusStr(cr result.CaseResult) string {
+	switch cr.Status {
+	case "timeout", "skipped", "blocked":
+		return cr.Status
+	}
 	if cr.Error != "" {
 		return "error"
 	}
 	if cr.Pass {
 		return "pass"
 	}
+	if cr.FailureCategory != "" {
+		return cr.FailureCategory
+	}
 	return "fail"
 }
@@ -2,12 +2,90 @@ package diff
 
 import (
 	"bytes"
+	"context"
+	"slices"
 	"strings"
 	"testing"
+	"time"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
+func TestCheckCriteria(t *testing.T) {
+	zero := 0
+	s := &suite.EvalSuite{PassCriteria: &suite.PassCriteria{MinPassRate: 0.5, MaxRegressionsVsBaseline: &zero}}
+	b := runB()
+	b.Stats.PassRate = 0.75
+
+	cr := CheckCriteria(s, b, runA())
+	if len(cr.Checks) != 2 || !cr.Checks[0].Pass || cr.Checks[1].Pass || cr.Pass() {
+		t.Errorf("checks = %+v, want pass rate ok and regressions failing", cr.Checks)
+	}
+	if !strings.Contains(cr.Checks[1].Detail, "1 regressions vs run-a") {
+		t.Errorf("detail = %q", cr.Checks[1].Detail)
+	}
+
+	// Without a baseline only the pass rate is enforced.
+	if cr := CheckCriteria(s, b, nil); !cr.Pass() {
+		t.Errorf("checks without baseline = %+v, want pass", cr.Checks)
+	}
+
+	var buf bytes.Buffer
+	CheckCriteria(&suite.EvalSuite{PassCriteria: &suite.PassCriteria{MinPassRate: 0.9}}, b, nil).Print(&buf)
+	if !strings.Contains(buf.String(), "FAIL pass rate 75.0% (min 90.0%)") {
+		t.Errorf("Print() = %q", buf.String())
+	}
+
+	if cr := CheckCriteria(&suite.EvalSuite{}, b, runA()); len(cr.Checks) != 0 {
+		t.Errorf("checks without pass_criteria = %+v, want none", cr.Checks)
+	}
+}
+
+func TestCheckCriteria_Tags(t *testing.T) {
+	zero, two := 0, 2
+	s := &suite.EvalSuite{
+		Cases: []suite.EvalCase{
+			{Name: "stable", Tags: []string{"style"}},
+			{Name: "improved", Tags: []string{"style"}},
+			{Name: "regressed", Tags: []string{"safety"}},
+			{Name: "new-case", Tags: []string{"style"}},
+		},
+		PassCriteria: &suite.PassCriteria{
+			MaxRegressionsVsBaseline: &two,
+			Tags: map[string]suite.TagCriteria{
+				"safety": {MaxRegressions: &zero},
+				"style":  {MinPassRate: 0.7},
+				"rare":   {MinPassRate: 1},
+			},
+		},
+	}
+
+	cr := CheckCriteria(s, runB(), runA())
+	got := make(map[string]CriterionCheck)
+	for _, c := range cr.Checks {
+		got[c.Name] = c
+	}
+	if c := got["max_regressions_vs_baseline"]; !c.Pass {
+		t.Errorf("suite-wide regressions failed: %s", c.Detail)
+	}
+	if c := got["tags.safety.max_regressions"]; c.Pass || c.Detail != "safety: 1 regressions vs run-a (max 0)" {
+		t.Errorf("safety check = %+v, want its one regression to fail", c)
+	}
+	if c := got["tags.style.min_pass_rate"]; !c.Pass || c.Detail != "style: pass rate 100.0% (min 70.0%)" {
+		t.Errorf("style check = %+v, want a pass rate of its own cases", c)
+	}
+	if c := got["tags.rare"]; !c.Pass {
+		t.Errorf("check of a tag no case carries = %+v, want pass", c)
+	}
+	if cr.Pass() {
+		t.Error("criteria passed despite a safety regression")
+	}
+}
+
 func runA() *result.RunSummary {
 	return &result.RunSummary{
 		RunID:     "run-a",
@@ -110,6 +188,162 @@ func TestCompare_Empty(t *testing.T) {
 	}
 }
 
+func TestCompare_CountsTimeouts(t *testing.T) {
+	a := runA()
+	b := runB()
+	b.Results[2].Status = "timeout"
+	b.Results[2].Error = "timeout after 1s during tool loop iteration 1"
+
+	dr := Compare(a, b, 0.0)
+	if dr.Summary.TimedOutA != 0 || dr.Summary.TimedOutB != 1 {
+		t.Errorf("timeouts = %d/%d, want 0/1", dr.Summary.TimedOutA, dr.Summary.TimedOutB)
+	}
+	for _, cd := range dr.Cases {
+		if cd.CaseName == "regressed" && cd.StatusB != "timeout" {
+			t.Errorf("StatusB = %q, want %q", cd.StatusB, "timeout")
+		}
+	}
+
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	if !strings.Contains(buf.String(), "timeouts: 0 in A, 1 in B") {
+		t.Errorf("table missing timeout summary:\n%s", buf.String())
+	}
+}
+
+func TestCompare_HallucinatedTools(t *testing.T) {
+	a := runA()
+	b := runB()
+	b.Results[2].HallucinatedTools = []string{"web_search"}
+	b.Results[2].FailureCategory = result.FailureHallucinatedTool
+
+	dr := Compare(a, b, 0.0)
+	if dr.Summary.HallucinatedA != 0 || dr.Summary.HallucinatedB != 1 {
+		t.Errorf("hallucinated = %d/%d, want 0/1", dr.Summary.HallucinatedA, dr.Summary.HallucinatedB)
+	}
+	for _, cd := range dr.Cases {
+		if cd.CaseName == "regressed" && cd.StatusB != "hallucinated_tool" {
+			t.Errorf("StatusB = %q, want %q", cd.StatusB, "hallucinated_tool")
+		}
+	}
+
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	if !strings.Contains(buf.String(), "hallucinated tool calls: 0 cases in A, 1 in B") {
+		t.Errorf("table missing hallucination summary:\n%s", buf.String())
+	}
+}
+
+func TestCompare_PassRateNoise(t *testing.T) {
+	withCounts := func(passed, failed int) *result.RunSummary {
+		s := runA()
+		s.Stats = result.Stats{PassedCases: passed, FailedCases: failed, PassRate: float64(passed) / float64(passed+failed)}
+		return s
+	}
+
+	dr := Compare(withCounts(6, 4), withCounts(8, 2), 0.0)
+	if !dr.Summary.PassRateNoise() {
+		t.Errorf("60%% -> 80%% over 10 cases should be noise: %s -> %s", dr.Summary.PassRateA, dr.Summary.PassRateB)
+	}
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	for _, want := range []string{"pass rate: 60.0% [31.3%-83.2%, n=10] -> 80.0%", "may be noise"} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	dr = Compare(withCounts(60, 40), withCounts(80, 20), 0.0)
+	if dr.Summary.PassRateNoise() {
+		t.Errorf("60%% -> 80%% over 100 cases should not be noise: %s -> %s", dr.Summary.PassRateA, dr.Summary.PassRateB)
+	}
+}
+
+func TestCompare_CombinedRuns(t *testing.T) {
+	inSuite := func(s *result.RunSummary, name string) *result.RunSummary {
+		s.SuiteName = name
+		return s
+	}
+	a := result.Combine([]*result.RunSummary{inSuite(runA(), "s1"), inSuite(runA(), "s2")})
+	b := result.Combine([]*result.RunSummary{inSuite(runA(), "s1"), inSuite(runB(), "s2")})
+
+	// Same-named cases in different suites are compared separately, so
+	// only s2 changed.
+	dr := Compare(a, b, 0.0)
+	if got := dr.Summary; got.Improved != 1 || got.Regressed != 1 || got.Unchanged != 5 || got.New != 1 || got.Removed != 1 {
+		t.Errorf("Summary = %+v, want 1 improved, 1 regressed, 5 unchanged, 1 new, 1 removed", got)
+	}
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	if !strings.Contains(buf.String(), "s2/regressed") {
+		t.Errorf("table should name cases by suite:\n%s", buf.String())
+	}
+
+	// One suite of a combined run compares against a single-suite run.
+	s2, err := a.SuiteRun("s2")
+	if err != nil {
+		t.Fatalf("SuiteRun() error: %v", err)
+	}
+	dr = Compare(s2, runB(), 0.0)
+	if got := dr.Summary; got.Improved != 1 || got.Regressed != 1 || got.Unchanged != 1 {
+		t.Errorf("per-suite Summary = %+v, want 1 improved, 1 regressed, 1 unchanged", got)
+	}
+}
+
+func TestCompare_ModelDrift(t *testing.T) {
+	a := runA()
+	b := runB()
+	for i := range a.Results {
+		a.Results[i].Model = "gpt-4o"
+		a.Results[i].ModelVersions = []string{"gpt-4o-2024-05-13"}
+	}
+	for i := range b.Results {
+		b.Results[i].Model = "gpt-4o"
+		b.Results[i].ModelVersions = []string{"gpt-4o-2024-08-06"}
+	}
+
+	dr := Compare(a, b, 0.0)
+	if len(dr.ModelDrift) != 1 {
+		t.Fatalf("ModelDrift = %+v, want one entry", dr.ModelDrift)
+	}
+	md := dr.ModelDrift[0]
+	if md.Model != "gpt-4o" || md.VersionsA[0] != "gpt-4o-2024-05-13" || md.VersionsB[0] != "gpt-4o-2024-08-06" {
+		t.Errorf("ModelDrift[0] = %+v", md)
+	}
+
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	if !strings.Contains(buf.String(), "warning: model version changed: gpt-4o served by gpt-4o-2024-05-13 in A, gpt-4o-2024-08-06 in B") {
+		t.Errorf("table missing model drift warning:\n%s", buf.String())
+	}
+
+	// The same snapshot, or a run without reported versions, is no drift.
+	for i := range b.Results {
+		b.Results[i].ModelVersions = a.Results[0].ModelVersions
+	}
+	if dr := Compare(a, b, 0.0); len(dr.ModelDrift) != 0 {
+		t.Errorf("ModelDrift = %+v for identical snapshots, want none", dr.ModelDrift)
+	}
+	if dr := Compare(a, runB(), 0.0); len(dr.ModelDrift) != 0 {
+		t.Errorf("ModelDrift = %+v without versions in B, want none", dr.ModelDrift)
+	}
+
+	// The same snapshot on a changed backend is drift too.
+	for i := range a.Results {
+		a.Results[i].SystemFingerprints = []string{"fp_a"}
+	}
+	for i := range b.Results {
+		b.Results[i].SystemFingerprints = []string{"fp_b"}
+	}
+	dr = Compare(a, b, 0.0)
+	if len(dr.ModelDrift) != 1 || dr.ModelDrift[0].VersionsA != nil {
+		t.Fatalf("ModelDrift = %+v, want one fingerprint drift", dr.ModelDrift)
+	}
+	if got, want := dr.ModelDrift[0].String(), "gpt-4o system fingerprint fp_a in A, fp_b in B"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
 func TestFilter(t *testing.T) {
 	dr := Compare(runA(), runB(), 0.0)
 
@@ -125,6 +359,36 @@ func TestFilter(t *testing.T) {
 	}
 }
 
+func TestParseCategories(t *testing.T) {
+	got, err := ParseCategories([]string{"regressed", " New"})
+	if err != nil || !slices.Equal(got, []Category{Regressed, New}) {
+		t.Errorf("ParseCategories() = %v, %v; want [regressed new]", got, err)
+	}
+	if _, err := ParseCategories([]string{"worse"}); err == nil {
+		t.Error("ParseCategories() should reject unknown categories")
+	}
+}
+
+func TestSummaryOnlyAndCount(t *testing.T) {
+	dr := Compare(runA(), runB(), 0.0).Filter([]Category{New})
+	if dr.Summary.Count(Regressed) != 1 || dr.Summary.Count(Unchanged) != 1 {
+		t.Errorf("Count() should cover cases the filter left out: %+v", dr.Summary)
+	}
+
+	var buf bytes.Buffer
+	dr.SummaryOnly().PrintTable(&buf)
+	out := buf.String()
+	if strings.Contains(out, "CASE") || strings.Contains(out, "new-case") {
+		t.Errorf("summary should not list cases:\n%s", out)
+	}
+	if !strings.Contains(out, "1 improved  1 regressed  1 unchanged  1 new  1 removed") {
+		t.Errorf("summary missing counts:\n%s", out)
+	}
+	if len(dr.Cases) != 1 {
+		t.Errorf("SummaryOnly() should not change the diff, which has %d cases", len(dr.Cases))
+	}
+}
+
 func TestFilter_Nil(t *testing.T) {
 	dr := Compare(runA(), runB(), 0.0)
 	filtered := dr.Filter(nil)
@@ -147,6 +411,52 @@ func TestJSON(t *testing.T) {
 	}
 }
 
+func TestCompare_RetryTelemetry(t *testing.T) {
+	a := runA()
+	b := runB()
+	b.Results[0].Retries = 4
+	b.Results[0].BackoffTime = 7500 * time.Millisecond
+
+	dr := Compare(a, b, 0.0)
+	if dr.Summary.RetriesA != 0 || dr.Summary.RetriesB != 4 {
+		t.Errorf("retries = %d/%d, want 0/4", dr.Summary.RetriesA, dr.Summary.RetriesB)
+	}
+
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	if !strings.Contains(buf.String(), "API retries: 0 in A (0s backoff), 4 in B (7.5s backoff)") {
+		t.Errorf("table missing retry summary:\n%s", buf.String())
+	}
+}
+
+func TestCompare_RunInfo(t *testing.T) {
+	a := runA()
+	a.Tags = []string{"baseline"}
+	b := runB()
+	b.Tags = []string{"exp", "v2"}
+	b.Labels = map[string]string{"model": "m2"}
+	b.Note = "shorter prompt"
+
+	dr := Compare(a, b, 0.0)
+	if len(dr.InfoB.Tags) != 2 || dr.InfoB.Labels["model"] != "m2" {
+		t.Errorf("InfoB = %+v", dr.InfoB)
+	}
+	if got := dr.Filter([]Category{Regressed}).InfoA.Tags; len(got) != 1 {
+		t.Errorf("Filter dropped InfoA tags: %v", got)
+	}
+
+	var buf bytes.Buffer
+	dr.PrintTable(&buf)
+	for _, want := range []string{
+		"A: run-a  tags=baseline",
+		`B: run-b  tags=exp,v2 model=m2 note="shorter prompt"`,
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("table output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
+
 func TestPrintTable(t *testing.T) {
 	dr := Compare(runA(), runB(), 0.0)
 
@@ -183,3 +493,95 @@ func TestScoreDelta(t *testing.T) {
 		t.Errorf("stable delta = %f, want 0.0", d)
 	}
 }
+
+func TestCompareTraces(t *testing.T) {
+	mk := func(tool string) *trace.AgentTrace {
+		tr := trace.New()
+		tr.AddMessage("user", "go")
+		tr.AddToolCall(trace.ToolCallTrace{ToolName: tool})
+		tr.AddMessage("tool", "ok")
+		tr.AddMessage("assistant", "done")
+		return tr
+	}
+	a := &result.RunSummary{Results: []result.CaseResult{
+		{CaseName: "same", Trace: mk("read")},
+		{CaseName: "changed", Trace: mk("read")},
+		{CaseName: "only-a", Trace: mk("read")},
+	}}
+	b := &result.RunSummary{Results: []result.CaseResult{
+		{CaseName: "same", Trace: mk("read")},
+		{CaseName: "changed", Trace: mk("write")},
+	}}
+
+	diffs, err := CompareTraces(a, b, "")
+	if err != nil {
+		t.Fatalf("CompareTraces() error: %v", err)
+	}
+	if len(diffs) != 2 {
+		t.Fatalf("got %d diffs, want 2", len(diffs))
+	}
+	if !diffs[0].Diff.Identical() || diffs[1].Diff.Identical() {
+		t.Errorf("identical = %v/%v, want true/false", diffs[0].Diff.Identical(), diffs[1].Diff.Identical())
+	}
+
+	diffs, err = CompareTraces(a, b, "changed")
+	if err != nil || len(diffs) != 1 || diffs[0].CaseName != "changed" {
+		t.Errorf("CompareTraces(changed) = %+v, %v", diffs, err)
+	}
+
+	if _, err := CompareTraces(a, b, "only-a"); err == nil {
+		t.Error("expected error for case missing from run B")
+	}
+}
+
+// explainProvider records prompts and answers with a fixed explanation.
+type explainProvider struct {
+	prompts []string
+}
+
+func (p *explainProvider) Name() string { return "explain" }
+func (p *explainProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.prompts = append(p.prompts, req.Messages[0].Content)
+	return &provider.Response{Content: "The new output dropped the summary.\nExtra detail."}, nil
+}
+
+func TestExplain(t *testing.T) {
+	a, b := runA(), runB()
+	a.Results[2].FinalResponse = "old answer"
+	b.Results[2].FinalResponse = "new answer"
+	b.Results[2].Judges = []judge.JudgeScore{{JudgeName: "contains", Score: 0.4, Reason: "missing summary"}}
+
+	dr := Compare(a, b, 0)
+	p := &explainProvider{}
+	if err := Explain(context.Background(), p, "m", dr, a, b); err != nil {
+		t.Fatalf("Explain() error: %v", err)
+	}
+
+	if len(p.prompts) != 1 {
+		t.Fatalf("made %d calls, want 1 (regressed cases only)", len(p.prompts))
+	}
+	for _, want := range []string{"old answer", "new answer", "missing summary"} {
+		if !strings.Contains(p.prompts[0], want) {
+			t.Errorf("prompt missing %q", want)
+		}
+	}
+
+	for _, cd := range dr.Cases {
+		want := ""
+		if cd.Category == Regressed {
+			want = "The new output dropped the summary."
+		}
+		if cd.Explanation != want {
+			t.Errorf("%s explanation = %q, want %q", cd.CaseName, cd.Explanation, want)
+		}
+	}
+
+	var buf bytes.Buffer
+	dr.PrintMarkdown(&buf)
+	out := buf.String()
+	for _, want := range []string{"| regressed | regressed | 0.90 | 0.40 | -0.50 |", "## Regressions explained", "- **regressed** (-0.50): The new output dropped the summary."} {
+		if !strings.Contains(out, want) {
+			t.Errorf("markdown missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -0,0 +1,72 @@
+package diff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+)
+
+const explainSystemPrompt = `You compare two outputs from an AI agent for the same eval case. The score went down from the old run to the new one. In one short sentence, explain what changed in the new output that most likely caused the regression. Respond with only that sentence.`
+
+// Explain asks the model for a one-line explanation of each regressed
+// case, using both runs' outputs and judge reasons, and stores it in the
+// case's Explanation. A failed call leaves that case unexplained; the
+// errors are joined and returned once every case has been tried.
+func Explain(ctx context.Context, p provider.Provider, model string, dr *DiffResult, a, b *result.RunSummary) error {
+	aMap := make(map[string]result.CaseResult, len(a.Results))
+	for _, cr := range a.Results {
+		aMap[caseKey(cr)] = cr
+	}
+	bMap := make(map[string]result.CaseResult, len(b.Results))
+	for _, cr := range b.Results {
+		bMap[caseKey(cr)] = cr
+	}
+
+	var errs []error
+	for i := range dr.Cases {
+		cd := &dr.Cases[i]
+		if cd.Category != Regressed {
+			continue
+		}
+		resp, err := p.Complete(ctx, &provider.Request{
+			Model:     model,
+			System:    explainSystemPrompt,
+			Messages:  []provider.Message{{Role: "user", Content: explainPrompt(aMap[cd.key()], bMap[cd.key()])}},
+			MaxTokens: 200,
+		})
+		if err != nil {
+			errs = append(errs, fmt.Errorf("explaining %q: %w", cd.key(), err))
+			continue
+		}
+		line, _, _ := strings.Cut(strings.TrimSpace(resp.Content), "\n")
+		cd.Explanation = line
+	}
+	return errors.Join(errs...)
+}
+
+func explainPrompt(a, b result.CaseResult) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "## Case\n%s\n\n", b.CaseName)
+	for _, side := range []struct {
+		label string
+		cr    result.CaseResult
+	}{{"Old", a}, {"New", b}} {
+		fmt.Fprintf(&sb, "## %s run (score %.2f, %s)\n", side.label, side.cr.Score, statusStr(side.cr))
+		if side.cr.Error != "" {
+			fmt.Fprintf(&sb, "Error: %s\n", side.cr.Error)
+		}
+		fmt.Fprintf(&sb, "Output:\n%s\n", side.cr.FinalResponse)
+		if len(side.cr.Judges) > 0 {
+			sb.WriteString("Judges:\n")
+			for _, js := range side.cr.Judges {
+				fmt.Fprintf(&sb, "- %s: %.2f %s\n", js.JudgeName, js.Score, js.Reason)
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,57 @@
+package diff
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+// CaseTraceDiff is the transcript alignment for one case present in both
+// runs.
+type CaseTraceDiff struct {
+	CaseName string           `json:"case_name"`
+	Diff     *trace.TraceDiff `json:"diff"`
+}
+
+// CompareTraces aligns the traces of cases present in both runs, matched
+// as in Compare; cases of combined runs are named suite/case. When
+// caseName is set only that case is compared, and it is an error for it
+// to be missing from either run.
+func CompareTraces(a, b *result.RunSummary, caseName string) ([]CaseTraceDiff, error) {
+	aMap := make(map[string]result.CaseResult, len(a.Results))
+	for _, cr := range a.Results {
+		aMap[caseKey(cr)] = cr
+	}
+
+	var diffs []CaseTraceDiff
+	found := false
+	for _, crB := range b.Results {
+		if caseName != "" && crB.CaseName != caseName && caseKey(crB) != caseName {
+			continue
+		}
+		crA, ok := aMap[caseKey(crB)]
+		if !ok {
+			continue
+		}
+		found = true
+		diffs = append(diffs, CaseTraceDiff{
+			CaseName: caseKey(crB),
+			Diff:     trace.Diff(crA.Trace, crB.Trace),
+		})
+	}
+	if caseName != "" && !found {
+		return nil, fmt.Errorf("case %q not found in both runs", caseName)
+	}
+	return diffs, nil
+}
+
+// PrintTraceDiffs writes each case's aligned transcripts.
+func PrintTraceDiffs(w io.Writer, diffs []CaseTraceDiff) {
+	for _, cd := range diffs {
+		fmt.Fprintf(w, "%s\n", cd.CaseName)
+		cd.Diff.Print(w, 100)
+		fmt.Fprintln(w)
+	}
+}
@@ -0,0 +1,93 @@
+package judge
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+const agentJudgeSystemPrompt = judgeSystemPrompt + `
+
+You have tools available. Use them to verify the agent's output (for
+example, run code or look up facts) before you grade it. When you are
+done verifying, respond with the JSON verdict and nothing else.`
+
+// AgentJudge is an LLM judge that may call verification tools before
+// returning a verdict. It drives the same tool loop as the runner, with
+// tool calls resolved by Mocks, and grades against Rubric on the same 1-5
+// scale as LLMJudge.
+type AgentJudge struct {
+	Provider provider.Provider
+	Model    string
+	Rubric   string
+	Tools    []provider.Tool
+	Mocks    []mock.MockConfig
+	Ctx      context.Context
+
+	// Usage tracks token consumption from judge calls separately.
+	Usage provider.Usage
+
+	// Trace records the judge's own conversation and tool calls from the
+	// most recent evaluation.
+	Trace *trace.AgentTrace
+}
+
+// Name returns "agent".
+func (j *AgentJudge) Name() string { return "agent" }
+
+// Evaluate lets the judge model verify the output with its tools, then
+// parses its verdict.
+func (j *AgentJudge) Evaluate(input Input) (Result, error) {
+	ctx := j.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	userMsg := buildJudgePrompt(j.Rubric, input)
+	tr := trace.New()
+	tr.AddMessage("user", userMsg)
+	j.Trace = tr
+
+	final, _, err := runner.RunToolLoop(ctx, j.Provider, provider.Request{
+		Model:     j.Model,
+		System:    agentJudgeSystemPrompt,
+		Messages:  []provider.Message{{Role: "user", Content: userMsg}},
+		Tools:     j.Tools,
+		MaxTokens: 1024,
+	}, mock.NewRegistry(j.Mocks), tr)
+	tr.Finish()
+
+	usage := tr.GetUsage()
+	j.Usage.InputTokens += usage.InputTokens
+	j.Usage.OutputTokens += usage.OutputTokens
+	j.Usage.CacheReadTokens += usage.CacheReadTokens
+	j.Usage.CacheWriteTokens += usage.CacheWriteTokens
+
+	if err != nil {
+		return Result{}, fmt.Errorf("agent judge call failed: %w", err)
+	}
+	if final == "" {
+		return Result{}, fmt.Errorf("agent judge gave no verdict within %d tool loop iterations", runner.MaxToolLoopIterations)
+	}
+
+	result, err := parseJudgeResponse(final)
+	if err != nil {
+		return Result{}, fmt.Errorf("parsing judge response: %w", err)
+	}
+	if n := len(tr.GetToolCalls()); n > 0 {
+		result.Reason = fmt.Sprintf("%s (verified with %d tool calls)", result.Reason, n)
+	}
+	return result, nil
+}
+
+// GetUsage returns the accumulated token usage from judge calls.
+func (j *AgentJudge) GetUsage() provider.Usage {
+	return j.Usage
+}
+
+// ModelName returns the judge model.
+func (j *AgentJudge) ModelName() string { return j.Model }
@@ -0,0 +1,89 @@
+package judge
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// scriptedProvider returns its responses in order and records requests.
+type scriptedProvider struct {
+	responses []provider.Response
+	requests  []provider.Request
+}
+
+func (s *scriptedProvider) Name() string { return "scripted" }
+
+func (s *scriptedProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	s.requests = append(s.requests, *req)
+	if len(s.requests) > len(s.responses) {
+		return nil, errors.New("no more responses")
+	}
+	resp := s.responses[len(s.requests)-1]
+	return &resp, nil
+}
+
+func TestAgentJudge_VerifiesWithTools(t *testing.T) {
+	sp := &scriptedProvider{responses: []provider.Response{
+		{
+			ToolCalls: []provider.ToolCall{{ID: "t1", Name: "run_code", Parameters: map[string]interface{}{"code": "print(6*7)"}}},
+			Usage:     provider.Usage{InputTokens: 10, OutputTokens: 5},
+		},
+		{
+			Content: `{"score": 5, "pass": true, "reasoning": "code prints 42"}`,
+			Usage:   provider.Usage{InputTokens: 20, OutputTokens: 5},
+		},
+	}}
+
+	j := &AgentJudge{
+		Provider: sp,
+		Model:    "judge-model",
+		Rubric:   "The program must print 42.",
+		Tools:    []provider.Tool{{Name: "run_code", Description: "Run Python code"}},
+		Mocks: []mock.MockConfig{{
+			ToolName:        "run_code",
+			DefaultResponse: &mock.MockResponse{Content: "42"},
+		}},
+	}
+
+	r, err := j.Evaluate(Input{Output: "print(6*7)"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass || r.Score != 1.0 {
+		t.Errorf("got %+v, want pass with score 1.0", r)
+	}
+	if !strings.Contains(r.Reason, "verified with 1 tool calls") {
+		t.Errorf("reason = %q", r.Reason)
+	}
+
+	if len(sp.requests) != 2 {
+		t.Fatalf("requests = %d, want 2", len(sp.requests))
+	}
+	if len(sp.requests[0].Tools) != 1 || sp.requests[0].Model != "judge-model" {
+		t.Errorf("first request = %+v", sp.requests[0])
+	}
+	// The tool result is fed back to the judge model.
+	last := sp.requests[1].Messages
+	if got := last[len(last)-1]; got.Role != "tool" || got.Content != "42" {
+		t.Errorf("last message = %+v, want tool result 42", got)
+	}
+
+	if j.Usage.InputTokens != 30 || j.Usage.OutputTokens != 10 {
+		t.Errorf("Usage = %+v", j.Usage)
+	}
+	if calls := j.Trace.GetToolCalls(); len(calls) != 1 || calls[0].ToolName != "run_code" {
+		t.Errorf("trace tool calls = %+v", calls)
+	}
+}
+
+func TestAgentJudge_ProviderError(t *testing.T) {
+	j := &AgentJudge{Provider: &scriptedProvider{}, Rubric: "x"}
+	if _, err := j.Evaluate(Input{Output: "x"}); err == nil {
+		t.Error("expected error")
+	}
+}
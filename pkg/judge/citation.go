@@ -0,0 +1,197 @@
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+const (
+	defaultCitationPattern = `\[([^\[\]]+)\]`
+	defaultCitationIDField = "id"
+	defaultMinClaimWords   = 4
+)
+
+// quotedSpan matches text in straight or curly double quotes.
+var quotedSpan = regexp.MustCompile(`"([^"]+)"|“([^”]+)”`)
+
+// CitationJudge checks that an agent grounds its answer in what it
+// retrieved. Each sentence of the output with at least MinClaimWords words
+// is a claim, unless it is a lead-in ending with a colon. Each claim must either cite the ID of a retrieved
+// document or quote a span that appears verbatim in a retrieval response.
+// Citations of IDs that were never retrieved do not count. The score is
+// the fraction of claims that were cited.
+type CitationJudge struct {
+	Rules suite.CitationRules
+}
+
+// Name returns the judge type identifier.
+func (j *CitationJudge) Name() string { return "citation" }
+
+// Evaluate collects the retrieved sources from the tool calls and checks
+// every claim in the output against them.
+func (j *CitationJudge) Evaluate(input Input) (Result, error) {
+	pattern := j.Rules.Pattern
+	if pattern == "" {
+		pattern = defaultCitationPattern
+	}
+	marker, err := regexp.Compile(pattern)
+	if err != nil {
+		return Result{}, fmt.Errorf("invalid citation pattern %q: %w", pattern, err)
+	}
+	if marker.NumSubexp() < 1 {
+		return Result{}, fmt.Errorf("citation pattern %q has no group for the cited ID", pattern)
+	}
+	minWords := j.Rules.MinClaimWords
+	if minWords <= 0 {
+		minWords = defaultMinClaimWords
+	}
+
+	ids, texts := j.sources(input)
+	claims := citationClaims(input.Output, marker)
+
+	var uncited []string
+	total := 0
+	for _, claim := range claims {
+		if strings.HasSuffix(claim, ":") || len(strings.Fields(marker.ReplaceAllString(claim, ""))) < minWords {
+			continue
+		}
+		total++
+		if !citesID(claim, marker, ids) && !quotesSource(claim, texts) {
+			uncited = append(uncited, claim)
+		}
+	}
+
+	if total == 0 {
+		return Result{Pass: true, Score: 1.0, Reason: "output makes no claims"}, nil
+	}
+	if len(uncited) == 0 {
+		return Result{
+			Pass:   true,
+			Score:  1.0,
+			Reason: fmt.Sprintf("all %d claims cite a retrieved source", total),
+		}, nil
+	}
+
+	reasons := make([]string, len(uncited))
+	for i, c := range uncited {
+		reasons[i] = fmt.Sprintf("%q", clipClaim(c))
+	}
+	reason := fmt.Sprintf("%d of %d claims cite no retrieved source: %s", len(uncited), total, strings.Join(reasons, "; "))
+	if len(ids) == 0 && len(texts) == 0 {
+		reason = fmt.Sprintf("no retrieval results to cite; %d claims uncited", total)
+	}
+	return Result{
+		Pass:   false,
+		Score:  float64(total-len(uncited)) / float64(total),
+		Reason: reason,
+	}, nil
+}
+
+// sources returns the document IDs found in the retrieval responses and
+// the responses' text.
+func (j *CitationJudge) sources(input Input) (map[string]bool, []string) {
+	field := j.Rules.IDField
+	if field == "" {
+		field = defaultCitationIDField
+	}
+	ids := make(map[string]bool)
+	var texts []string
+	for _, tc := range input.ToolCalls {
+		if tc.Error != "" || tc.Response == "" {
+			continue
+		}
+		if len(j.Rules.Tools) > 0 && !slices.Contains(j.Rules.Tools, tc.ToolName) {
+			continue
+		}
+		texts = append(texts, normalizeSpan(tc.Response))
+		var v interface{}
+		if json.Unmarshal([]byte(tc.Response), &v) == nil {
+			collectIDs(v, field, ids)
+		}
+	}
+	return ids, texts
+}
+
+// collectIDs records the value of every field named field in the JSON
+// value v, at any depth.
+func collectIDs(v interface{}, field string, ids map[string]bool) {
+	switch x := v.(type) {
+	case map[string]interface{}:
+		for k, val := range x {
+			if k == field {
+				switch val.(type) {
+				case string, float64:
+					ids[fmt.Sprint(val)] = true
+				}
+			}
+			collectIDs(val, field, ids)
+		}
+	case []interface{}:
+		for _, val := range x {
+			collectIDs(val, field, ids)
+		}
+	}
+}
+
+// citationClaims splits output into sentences, attaching sentences that
+// hold nothing but citation markers (as in "... capital. [doc-1]") to the
+// sentence before them.
+func citationClaims(output string, marker *regexp.Regexp) []string {
+	var claims []string
+	for _, s := range splitSentences(output) {
+		if len(claims) > 0 && strings.TrimSpace(marker.ReplaceAllString(s, "")) == "" {
+			claims[len(claims)-1] += " " + s
+			continue
+		}
+		claims = append(claims, s)
+	}
+	return claims
+}
+
+// citesID reports whether claim has a citation marker naming a retrieved
+// document.
+func citesID(claim string, marker *regexp.Regexp, ids map[string]bool) bool {
+	for _, m := range marker.FindAllStringSubmatch(claim, -1) {
+		for _, id := range strings.Split(m[1], ",") {
+			if ids[strings.TrimSpace(id)] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// quotesSource reports whether claim quotes a span found in one of the
+// retrieval responses, ignoring case and whitespace differences.
+func quotesSource(claim string, texts []string) bool {
+	for _, m := range quotedSpan.FindAllStringSubmatch(claim, -1) {
+		span := normalizeSpan(m[1] + m[2])
+		if span == "" {
+			continue
+		}
+		for _, t := range texts {
+			if strings.Contains(t, span) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func normalizeSpan(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
+
+// clipClaim shortens a claim for use in a failure reason.
+func clipClaim(s string) string {
+	const width = 60
+	if r := []rune(s); len(r) > width {
+		return string(r[:width]) + "..."
+	}
+	return s
+}
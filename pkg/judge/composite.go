@@ -3,6 +3,8 @@ package judge
 import (
 	"fmt"
 	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 )
 
 // Status represents the overall evaluation status.
@@ -23,6 +25,12 @@ type JudgeScore struct {
 	Weight    float64 `json:"weight"`
 	Reason    string  `json:"reason"`
 	Status    Status  `json:"status"`
+
+	// Token usage and estimated USD cost of this judge's model calls for
+	// the case, for judges that call a model.
+	InputTokens  int     `json:"input_tokens,omitempty"`
+	OutputTokens int     `json:"output_tokens,omitempty"`
+	Cost         float64 `json:"cost,omitempty"`
 }
 
 // CompositeResult holds the aggregated scoring result from all judges.
@@ -38,6 +46,18 @@ type CompositeResult struct {
 type JudgeConfig struct {
 	Judge  Judge   `json:"-"`
 	Weight float64 `json:"weight"`
+
+	// MinScore, when above zero, replaces the judge's own pass verdict
+	// with its score reaching MinScore, and fails the composite whenever
+	// the judge does not pass.
+	MinScore float64 `json:"min_score,omitempty"`
+}
+
+// UsageReporter is implemented by judges that call a model. GetUsage is
+// cumulative across evaluations; ModelName is used to estimate cost.
+type UsageReporter interface {
+	GetUsage() provider.Usage
+	ModelName() string
 }
 
 // CompositeScorer combines multiple judge results into a single score.
@@ -61,7 +81,7 @@ func (cs *CompositeScorer) Score(input Input, configs []JudgeConfig) CompositeRe
 	var scores []JudgeScore
 	var totalWeight float64
 	var weightedSum float64
-	var hasReview, hasError bool
+	var hasReview, hasError, belowMin bool
 	var reasons []string
 
 	for _, cfg := range configs {
@@ -70,12 +90,30 @@ func (cs *CompositeScorer) Score(input Input, configs []JudgeConfig) CompositeRe
 			w = 1.0
 		}
 
+		var before provider.Usage
+		ur, reportsUsage := cfg.Judge.(UsageReporter)
+		if reportsUsage {
+			before = ur.GetUsage()
+		}
+
 		result, err := cfg.Judge.Evaluate(input)
 
 		js := JudgeScore{
 			JudgeName: cfg.Judge.Name(),
 			Weight:    w,
 		}
+		if reportsUsage {
+			after := ur.GetUsage()
+			used := provider.Usage{
+				InputTokens:      after.InputTokens - before.InputTokens,
+				OutputTokens:     after.OutputTokens - before.OutputTokens,
+				CacheReadTokens:  after.CacheReadTokens - before.CacheReadTokens,
+				CacheWriteTokens: after.CacheWriteTokens - before.CacheWriteTokens,
+			}
+			js.InputTokens = used.InputTokens
+			js.OutputTokens = used.OutputTokens
+			js.Cost = provider.EstimateCost(ur.ModelName(), used)
+		}
 
 		if err != nil {
 			js.Status = StatusError
@@ -86,11 +124,15 @@ func (cs *CompositeScorer) Score(input Input, configs []JudgeConfig) CompositeRe
 			js.Pass = result.Pass
 			js.Score = result.Score
 			js.Reason = result.Reason
+			if cfg.MinScore > 0 {
+				js.Pass = result.Score >= cfg.MinScore
+				belowMin = belowMin || !js.Pass
+			}
 
 			if result.Reason == "review" {
 				js.Status = StatusReview
 				hasReview = true
-			} else if result.Pass {
+			} else if js.Pass {
 				js.Status = StatusPass
 			} else {
 				js.Status = StatusFail
@@ -98,7 +140,11 @@ func (cs *CompositeScorer) Score(input Input, configs []JudgeConfig) CompositeRe
 
 			weightedSum += result.Score * w
 			totalWeight += w
-			reasons = append(reasons, fmt.Sprintf("%s: %s (score=%.2f)", cfg.Judge.Name(), result.Reason, result.Score))
+			if cfg.MinScore > 0 && !js.Pass {
+				reasons = append(reasons, fmt.Sprintf("%s: %s (score=%.2f, below min_score %.2f)", cfg.Judge.Name(), result.Reason, result.Score, cfg.MinScore))
+			} else {
+				reasons = append(reasons, fmt.Sprintf("%s: %s (score=%.2f)", cfg.Judge.Name(), result.Reason, result.Score))
+			}
 		}
 
 		scores = append(scores, js)
@@ -110,7 +156,7 @@ func (cs *CompositeScorer) Score(input Input, configs []JudgeConfig) CompositeRe
 	}
 
 	status := StatusFail
-	pass := composite >= cs.Threshold
+	pass := composite >= cs.Threshold && !belowMin
 
 	if hasError {
 		status = StatusError
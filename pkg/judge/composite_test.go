@@ -3,7 +3,10 @@ package judge
 import (
 	"fmt"
 	"math"
+	"strings"
 	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 )
 
 // stubJudge is a test helper that returns a fixed result.
@@ -105,6 +108,25 @@ func TestCompositeScorer_DefaultWeight(t *testing.T) {
 	}
 }
 
+func TestCompositeScorer_MinScore(t *testing.T) {
+	cs := NewCompositeScorer(0.5)
+	result := cs.Score(Input{}, []JudgeConfig{
+		{Judge: &stubJudge{name: "safety", result: Result{Pass: true, Score: 0.8, Reason: "mostly safe"}}, Weight: 1.0, MinScore: 0.9},
+		{Judge: &stubJudge{name: "style", result: Result{Pass: false, Score: 0.4, Reason: "wordy"}}, Weight: 3.0, MinScore: 0.3},
+	})
+
+	// Composite = (0.8 + 1.2) / 4 = 0.5 reaches the threshold, but safety is below its min_score.
+	if result.Pass || result.Status != StatusFail {
+		t.Errorf("pass = %v, status = %q, want a fail from the safety judge", result.Pass, result.Status)
+	}
+	if result.Scores[0].Pass || !result.Scores[1].Pass {
+		t.Errorf("judge passes = %v, %v; want min_score to decide each", result.Scores[0].Pass, result.Scores[1].Pass)
+	}
+	if want := "safety: mostly safe (score=0.80, below min_score 0.90)"; !strings.Contains(result.Reason, want) {
+		t.Errorf("reason = %q, want it to contain %q", result.Reason, want)
+	}
+}
+
 func TestCompositeScorer_ReviewOverride(t *testing.T) {
 	cs := NewCompositeScorer(0.5)
 	result := cs.Score(Input{}, []JudgeConfig{
@@ -184,3 +206,34 @@ func TestCompositeScorer_DefaultThreshold(t *testing.T) {
 		t.Errorf("threshold = %v, want 0.5 as default", cs.Threshold)
 	}
 }
+
+func TestCompositeScorer_AttributesJudgeUsage(t *testing.T) {
+	llm := &LLMJudge{
+		Provider: &mockProvider{response: &provider.Response{
+			Content: `{"score": 4, "pass": true, "reasoning": "good"}`,
+			Usage:   provider.Usage{InputTokens: 1000, OutputTokens: 100},
+		}},
+		Model:  "gpt-4o",
+		Rubric: "Be helpful.",
+	}
+	// Usage from an earlier case must not be attributed to this one.
+	llm.Usage = provider.Usage{InputTokens: 5000, OutputTokens: 500}
+
+	cs := NewCompositeScorer(0.5)
+	result := cs.Score(Input{Output: "hi"}, []JudgeConfig{
+		{Judge: &ExactJudge{}},
+		{Judge: &PreprocessJudge{Judge: llm, Steps: []string{StepTrim}}},
+	})
+
+	if s := result.Scores[0]; s.InputTokens != 0 || s.Cost != 0 {
+		t.Errorf("exact judge usage = %d tokens, $%v; want none", s.InputTokens, s.Cost)
+	}
+	s := result.Scores[1]
+	if s.InputTokens != 1000 || s.OutputTokens != 100 {
+		t.Errorf("llm judge tokens = %d/%d, want 1000/100", s.InputTokens, s.OutputTokens)
+	}
+	want := provider.EstimateCost("gpt-4o", provider.Usage{InputTokens: 1000, OutputTokens: 100})
+	if math.Abs(s.Cost-want) > 1e-12 {
+		t.Errorf("llm judge cost = %v, want %v", s.Cost, want)
+	}
+}
@@ -0,0 +1,43 @@
+package judge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ContainsJudge checks that the agent output contains a substring.
+type ContainsJudge struct {
+	Value string `json:"value" yaml:"value"`
+
+	// Normalize lists normalizations (see Normalize) applied to both the
+	// output and Value before searching.
+	Normalize []string `json:"normalize,omitempty" yaml:"normalize,omitempty"`
+}
+
+// Name returns the judge type identifier.
+func (j *ContainsJudge) Name() string { return "contains" }
+
+// Evaluate checks if the output contains the configured value.
+func (j *ContainsJudge) Evaluate(input Input) (Result, error) {
+	output, err := Normalize(input.Output, j.Normalize)
+	if err != nil {
+		return Result{}, err
+	}
+	value, err := Normalize(j.Value, j.Normalize)
+	if err != nil {
+		return Result{}, err
+	}
+	if strings.Contains(output, value) {
+		return Result{
+			Pass:   true,
+			Score:  1.0,
+			Reason: fmt.Sprintf("output contains %q", j.Value),
+		}, nil
+	}
+
+	return Result{
+		Pass:   false,
+		Score:  0.0,
+		Reason: fmt.Sprintf("output does not contain %q", j.Value),
+	}, nil
+}
@@ -1,3 +1,4 @@
 // Package judge provides scoring implementations for eval results including
-// deterministic judges (exact match, regex, schema) and LLM-as-judge.
+// deterministic judges (exact match, regex, schema, style), LLM-as-judge, and
+// external judges run as subprocesses.
 package judge
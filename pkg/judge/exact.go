@@ -1,13 +1,14 @@
 package judge
 
-import (
-	"fmt"
-	"strings"
-)
+import "fmt"
 
 // ExactJudge compares agent output against an expected string.
 type ExactJudge struct {
 	NormalizeWhitespace bool `json:"normalize_whitespace" yaml:"normalize_whitespace"`
+
+	// Normalize lists normalizations (see Normalize) applied to both the
+	// output and the expected output before comparing them.
+	Normalize []string `json:"normalize,omitempty" yaml:"normalize,omitempty"`
 }
 
 // Name returns the judge type identifier.
@@ -17,12 +18,17 @@ func (j *ExactJudge) Name() string { return "exact" }
 // When NormalizeWhitespace is true, leading/trailing whitespace is trimmed
 // and runs of internal whitespace are collapsed to single spaces.
 func (j *ExactJudge) Evaluate(input Input) (Result, error) {
-	got := input.Output
-	want := input.ExpectedOutput
-
+	norms := j.Normalize
 	if j.NormalizeWhitespace {
-		got = normalizeWhitespace(got)
-		want = normalizeWhitespace(want)
+		norms = append(norms[:len(norms):len(norms)], NormWhitespace)
+	}
+	got, err := Normalize(input.Output, norms)
+	if err != nil {
+		return Result{}, err
+	}
+	want, err := Normalize(input.ExpectedOutput, norms)
+	if err != nil {
+		return Result{}, err
 	}
 
 	if got == want {
@@ -40,12 +46,6 @@ func (j *ExactJudge) Evaluate(input Input) (Result, error) {
 	}, nil
 }
 
-func normalizeWhitespace(s string) string {
-	s = strings.TrimSpace(s)
-	fields := strings.Fields(s)
-	return strings.Join(fields, " ")
-}
-
 func truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
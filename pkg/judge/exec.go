@@ -0,0 +1,83 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// DefaultExecTimeout bounds a single external judge invocation when
+// ExecJudge.Timeout is unset.
+const DefaultExecTimeout = 30 * time.Second
+
+// ExecJudge delegates scoring to an external program. The judge Input is
+// written to the program's stdin as JSON, and the program must print a
+// Result as JSON ({"pass": bool, "score": float, "reason": string}) on
+// stdout and exit 0. Anything written to stderr is included in the error
+// when the program fails.
+//
+// This lets judges be written in any language without changing the Go
+// code.
+type ExecJudge struct {
+	// Command is the program and its arguments. It is executed directly,
+	// not through a shell.
+	Command []string
+	Timeout time.Duration
+	Ctx     context.Context
+}
+
+// Name returns "exec".
+func (j *ExecJudge) Name() string { return "exec" }
+
+// Evaluate runs the external program and parses its verdict.
+func (j *ExecJudge) Evaluate(input Input) (Result, error) {
+	if len(j.Command) == 0 {
+		return Result{}, fmt.Errorf("exec judge requires a command")
+	}
+
+	ctx := j.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := j.Timeout
+	if timeout <= 0 {
+		timeout = DefaultExecTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	payload, err := json.Marshal(input)
+	if err != nil {
+		return Result{}, fmt.Errorf("encoding judge input: %w", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, j.Command[0], j.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(payload)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() == context.DeadlineExceeded {
+			return Result{}, fmt.Errorf("exec judge %q timed out after %s", j.Command[0], timeout)
+		}
+		msg := strings.TrimSpace(stderr.String())
+		if msg != "" {
+			return Result{}, fmt.Errorf("exec judge %q failed: %w: %s", j.Command[0], err, truncate(msg, 500))
+		}
+		return Result{}, fmt.Errorf("exec judge %q failed: %w", j.Command[0], err)
+	}
+
+	var result Result
+	if err := json.Unmarshal(bytes.TrimSpace(stdout.Bytes()), &result); err != nil {
+		return Result{}, fmt.Errorf("parsing exec judge output %q: %w", truncate(stdout.String(), 200), err)
+	}
+	if result.Score < 0 || result.Score > 1 {
+		return Result{}, fmt.Errorf("exec judge score %v out of range [0, 1]", result.Score)
+	}
+	return result, nil
+}
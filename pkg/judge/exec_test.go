@@ -0,0 +1,52 @@
+package judge
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestExecJudge(t *testing.T) {
+	// The script echoes a verdict based on whether stdin mentions "hello".
+	script := `if grep -q hello; then echo '{"pass": true, "score": 1, "reason": "greeted"}'; ` +
+		`else echo '{"pass": false, "score": 0.25, "reason": "no greeting"}'; fi`
+	j := &ExecJudge{Command: []string{"sh", "-c", script}}
+
+	r, err := j.Evaluate(Input{Output: "hello there"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass || r.Score != 1 || r.Reason != "greeted" {
+		t.Errorf("got %+v", r)
+	}
+
+	r, err = j.Evaluate(Input{Output: "goodbye"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if r.Pass || r.Score != 0.25 {
+		t.Errorf("got %+v", r)
+	}
+}
+
+func TestExecJudge_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		judge   ExecJudge
+		wantErr string
+	}{
+		{"no command", ExecJudge{}, "requires a command"},
+		{"non-zero exit", ExecJudge{Command: []string{"sh", "-c", "echo boom >&2; exit 3"}}, "boom"},
+		{"bad output", ExecJudge{Command: []string{"sh", "-c", "echo not-json"}}, "parsing exec judge output"},
+		{"score out of range", ExecJudge{Command: []string{"sh", "-c", `echo '{"score": 5}'`}}, "out of range"},
+		{"timeout", ExecJudge{Command: []string{"sleep", "5"}, Timeout: 50 * time.Millisecond}, "timed out"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := tt.judge.Evaluate(Input{Output: "x"})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error = %v, want containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}
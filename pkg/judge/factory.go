@@ -0,0 +1,168 @@
+package judge
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// Options supplies the dependencies needed by judges that call out to a
+// model. They are ignored by deterministic judges.
+type Options struct {
+	Provider provider.Provider
+	Model    string
+	Ctx      context.Context
+}
+
+// FromConfig builds a weighted judge from a suite judge definition. The
+// meaning of Value depends on the judge type: a substring for "contains",
+// the expected number for "numeric", a pattern for "regex", a JSON Schema
+// for "schema", a JSON array of ExpectedToolCall for "toolcall", a rubric
+// for "llm" and "agent", a command line (split on whitespace, no shell)
+// for "exec", a test file for "go_test", and a URL for "http". Style and
+// citation judges are configured by Style and Citations instead of Value.
+// Any judge may list preprocess steps, which are applied to the output
+// first; exact and contains judges may also list normalizations, which
+// are applied to both sides of the comparison.
+func FromConfig(cfg suite.JudgeConfig, opts Options) (JudgeConfig, error) {
+	var j Judge
+	switch cfg.Type {
+	case "exact":
+		j = &ExactJudge{Normalize: cfg.Normalize}
+	case "numeric":
+		j = &NumericJudge{Value: cfg.Value}
+	case "contains":
+		j = &ContainsJudge{Value: cfg.Value, Normalize: cfg.Normalize}
+	case "regex":
+		j = &RegexJudge{
+			Pattern:  cfg.Value,
+			Patterns: cfg.Patterns,
+			Match:    cfg.Match,
+			Captures: cfg.Captures,
+		}
+	case "schema":
+		j = &SchemaJudge{Schema: cfg.Value}
+	case "toolcall":
+		var expected []ExpectedToolCall
+		if cfg.Value != "" {
+			if err := json.Unmarshal([]byte(cfg.Value), &expected); err != nil {
+				return JudgeConfig{}, fmt.Errorf("parsing toolcall expectations: %w", err)
+			}
+		}
+		if err := validateConstraints(cfg.Constraints); err != nil {
+			return JudgeConfig{}, fmt.Errorf("invalid toolcall constraints: %w", err)
+		}
+		j = &ToolCallJudge{Expected: expected, Constraints: cfg.Constraints}
+	case "llm":
+		if opts.Provider == nil {
+			return JudgeConfig{}, fmt.Errorf("llm judge requires a provider")
+		}
+		j = &LLMJudge{
+			Provider: opts.Provider,
+			Model:    opts.Model,
+			Rubric:   cfg.Value,
+			Ctx:      opts.Ctx,
+		}
+	case "agent":
+		if opts.Provider == nil {
+			return JudgeConfig{}, fmt.Errorf("agent judge requires a provider")
+		}
+		tools := make([]provider.Tool, len(cfg.Tools))
+		for i, t := range cfg.Tools {
+			tools[i] = provider.Tool{Name: t.Name, Description: t.Description, Parameters: t.Parameters}
+		}
+		j = &AgentJudge{
+			Provider: opts.Provider,
+			Model:    opts.Model,
+			Rubric:   cfg.Value,
+			Tools:    tools,
+			Mocks:    cfg.Mocks,
+			Ctx:      opts.Ctx,
+		}
+	case "exec":
+		command := strings.Fields(cfg.Value)
+		if len(command) == 0 {
+			return JudgeConfig{}, fmt.Errorf("exec judge requires a command")
+		}
+		j = &ExecJudge{Command: command, Timeout: cfg.Timeout, Ctx: opts.Ctx}
+	case "go_test":
+		if cfg.Value == "" {
+			return JudgeConfig{}, fmt.Errorf("go_test judge requires a test file")
+		}
+		j = &GoTestJudge{Test: cfg.Value, Timeout: cfg.Timeout, Ctx: opts.Ctx}
+	case "http":
+		if cfg.Value == "" {
+			return JudgeConfig{}, fmt.Errorf("http judge requires a URL")
+		}
+		headers := make(map[string]string, len(cfg.Headers))
+		for k, v := range cfg.Headers {
+			headers[k] = os.ExpandEnv(v)
+		}
+		j = &HTTPJudge{
+			URL:        cfg.Value,
+			Headers:    headers,
+			MaxRetries: cfg.MaxRetries,
+			Timeout:    cfg.Timeout,
+			Ctx:        opts.Ctx,
+		}
+	case "style":
+		if err := validateStyle(cfg.Style); err != nil {
+			return JudgeConfig{}, fmt.Errorf("invalid style judge: %w", err)
+		}
+		j = &StyleJudge{Rules: *cfg.Style}
+	case "citation":
+		var rules suite.CitationRules
+		if cfg.Citations != nil {
+			rules = *cfg.Citations
+		}
+		if rules.Pattern != "" {
+			if _, err := regexp.Compile(rules.Pattern); err != nil {
+				return JudgeConfig{}, fmt.Errorf("invalid citation pattern: %w", err)
+			}
+		}
+		j = &CitationJudge{Rules: rules}
+	case "known_tools":
+		j = &KnownToolsJudge{}
+	case "human_review":
+		// The composite scorer keys review status off the default reason,
+		// so the configured value is documentation for the reviewer only.
+		j = &HumanReviewJudge{}
+	default:
+		return JudgeConfig{}, fmt.Errorf("unknown judge type %q", cfg.Type)
+	}
+	if len(cfg.Normalize) > 0 {
+		if cfg.Type != "exact" && cfg.Type != "contains" {
+			return JudgeConfig{}, fmt.Errorf("normalize is only supported by exact and contains judges")
+		}
+		if _, err := Normalize("", cfg.Normalize); err != nil {
+			return JudgeConfig{}, err
+		}
+	}
+	if len(cfg.Preprocess) > 0 {
+		if _, err := Preprocess("", cfg.Preprocess); err != nil {
+			return JudgeConfig{}, err
+		}
+		j = &PreprocessJudge{Judge: j, Steps: cfg.Preprocess}
+	}
+	return JudgeConfig{Judge: j, Weight: cfg.Weight, MinScore: cfg.MinScore}, nil
+}
+
+// FromConfigs builds judges for every definition in cfgs, stopping at the
+// first invalid one.
+func FromConfigs(cfgs []suite.JudgeConfig, opts Options) ([]JudgeConfig, error) {
+	out := make([]JudgeConfig, 0, len(cfgs))
+	for i, c := range cfgs {
+		jc, err := FromConfig(c, opts)
+		if err != nil {
+			return nil, fmt.Errorf("judge %d (%s): %w", i, c.Type, err)
+		}
+		out = append(out, jc)
+	}
+	return out, nil
+}
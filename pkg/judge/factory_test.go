@@ -0,0 +1,162 @@
+package judge
+
+import (
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+func TestFromConfig_Types(t *testing.T) {
+	tests := []struct {
+		cfg  suite.JudgeConfig
+		name string
+	}{
+		{suite.JudgeConfig{Type: "exact"}, "exact"},
+		{suite.JudgeConfig{Type: "contains", Value: "hi"}, "contains"},
+		{suite.JudgeConfig{Type: "regex", Value: `\d+`}, "regex"},
+		{suite.JudgeConfig{Type: "schema", Value: `{"type":"object"}`}, "schema"},
+		{suite.JudgeConfig{Type: "toolcall", Value: `[{"tool_name":"read_file"}]`}, "toolcall"},
+		{suite.JudgeConfig{Type: "llm", Value: "Be correct."}, "llm"},
+		{suite.JudgeConfig{Type: "agent", Value: "Verify the math."}, "agent"},
+		{suite.JudgeConfig{Type: "human_review", Value: "Check tone."}, "human_review"},
+		{suite.JudgeConfig{Type: "exec", Value: "python3 judges/tone.py"}, "exec"},
+		{suite.JudgeConfig{Type: "style", Style: &suite.StyleRules{MaxWords: 50}}, "style"},
+		{suite.JudgeConfig{Type: "citation"}, "citation"},
+		{suite.JudgeConfig{Type: "known_tools"}, "known_tools"},
+	}
+
+	opts := Options{Provider: &mockProvider{}, Model: "judge-model"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jc, err := FromConfig(tt.cfg, opts)
+			if err != nil {
+				t.Fatalf("FromConfig() error: %v", err)
+			}
+			if jc.Judge.Name() != tt.name {
+				t.Errorf("Name() = %q, want %q", jc.Judge.Name(), tt.name)
+			}
+		})
+	}
+}
+
+func TestFromConfig_ToolCallExpectations(t *testing.T) {
+	jc, err := FromConfig(suite.JudgeConfig{
+		Type:   "toolcall",
+		Value:  `[{"tool_name": "read_file"}, {"tool_name": "delete_file", "negate": true}]`,
+		Weight: 2.0,
+	}, Options{})
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	if jc.Weight != 2.0 {
+		t.Errorf("Weight = %f, want 2.0", jc.Weight)
+	}
+
+	r, err := jc.Judge.Evaluate(Input{ToolCalls: []trace.ToolCallTrace{{ToolName: "read_file"}}})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+}
+
+func TestFromConfig_RegexCaptures(t *testing.T) {
+	jc, err := FromConfig(suite.JudgeConfig{
+		Type:     "regex",
+		Value:    `id=(?P<id>\w+)`,
+		Patterns: []string{`status=ok`},
+		Captures: map[string]string{"id": "{{.want}}"},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+
+	r, err := jc.Judge.Evaluate(Input{
+		Output: "id=abc status=ok",
+		Vars:   map[string]interface{}{"want": "abc"},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+}
+
+func TestFromConfig_Preprocess(t *testing.T) {
+	jc, err := FromConfig(suite.JudgeConfig{
+		Type:       "schema",
+		Value:      `{"type": "object", "required": ["ok"]}`,
+		Preprocess: []string{"strip_fences", "extract_json"},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	if jc.Judge.Name() != "schema" {
+		t.Errorf("Name() = %q, want schema", jc.Judge.Name())
+	}
+
+	r, err := jc.Judge.Evaluate(Input{Output: "Here is the JSON:\n```json\n{\"ok\": true}\n```"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+}
+
+func TestFromConfig_Normalize(t *testing.T) {
+	jc, err := FromConfig(suite.JudgeConfig{
+		Type:      "contains",
+		Value:     "Total: 1,000",
+		Normalize: []string{"case", "numbers", "punctuation"},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	r, err := jc.Judge.Evaluate(Input{Output: "the total 1000 is final"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+}
+
+func TestFromConfig_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  suite.JudgeConfig
+	}{
+		{"unknown type", suite.JudgeConfig{Type: "telepathy"}},
+		{"bad toolcall json", suite.JudgeConfig{Type: "toolcall", Value: "not json"}},
+		{"unknown toolcall constraint", suite.JudgeConfig{Type: "toolcall", Constraints: []suite.ToolConstraint{{Type: "after"}}}},
+		{"before with one tool", suite.JudgeConfig{Type: "toolcall", Constraints: []suite.ToolConstraint{{Type: "before", Tools: []string{"a"}}}}},
+		{"llm without provider", suite.JudgeConfig{Type: "llm", Value: "rubric"}},
+		{"agent without provider", suite.JudgeConfig{Type: "agent", Value: "rubric"}},
+		{"unknown preprocess step", suite.JudgeConfig{Type: "exact", Preprocess: []string{"summarize"}}},
+		{"unknown normalization", suite.JudgeConfig{Type: "exact", Normalize: []string{"stemming"}}},
+		{"normalize on regex", suite.JudgeConfig{Type: "regex", Value: "x", Normalize: []string{"case"}}},
+		{"exec without command", suite.JudgeConfig{Type: "exec"}},
+		{"http without url", suite.JudgeConfig{Type: "http"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := FromConfig(tt.cfg, Options{}); err == nil {
+				t.Error("expected error")
+			}
+		})
+	}
+}
+
+func TestFromConfigs_ReportsIndex(t *testing.T) {
+	_, err := FromConfigs([]suite.JudgeConfig{{Type: "exact"}, {Type: "bogus"}}, Options{})
+	if err == nil {
+		t.Fatal("expected error")
+	}
+	if got := err.Error(); got != `judge 1 (bogus): unknown judge type "bogus"` {
+		t.Errorf("error = %q", got)
+	}
+}
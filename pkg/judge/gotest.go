@@ -0,0 +1,109 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// DefaultGoTestTimeout bounds a GoTestJudge run, module download and
+// build included, when GoTestJudge.Timeout is unset.
+const DefaultGoTestTimeout = 2 * time.Minute
+
+// packageClause matches the package clause of a Go file.
+var packageClause = regexp.MustCompile(`(?m)^package\s+\w+`)
+
+// GoTestJudge passes when the Go code in the agent output passes a test
+// file, for code generation benchmarks such as HumanEval. The code is the
+// first fenced block of the output, or the whole output, and gets the test
+// file's package clause if it has none. Both files are placed in a
+// temporary module whose dependencies are resolved with 'go mod tidy', so
+// tests may use third-party assertion packages, and 'go test' is run
+// there. Code that does not build, fails its tests, or runs past the
+// timeout fails the judge; a missing go command is an error.
+type GoTestJudge struct {
+	// Test is the source of the _test.go file, package clause included.
+	Test    string
+	Timeout time.Duration
+	Ctx     context.Context
+}
+
+// Name returns "go_test".
+func (j *GoTestJudge) Name() string { return "go_test" }
+
+// Evaluate runs the test file against the code in the output.
+func (j *GoTestJudge) Evaluate(input Input) (Result, error) {
+	pkg := packageClause.FindString(j.Test)
+	if pkg == "" {
+		return Result{}, fmt.Errorf("go_test judge: test file has no package clause")
+	}
+	code := stripFences(input.Output)
+	if !packageClause.MatchString(code) {
+		code = pkg + "\n\n" + code
+	}
+
+	dir, err := os.MkdirTemp("", "eval-gotest-")
+	if err != nil {
+		return Result{}, fmt.Errorf("go_test judge: %w", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"go.mod":           "module evalcode\n",
+		"solution.go":      code,
+		"solution_test.go": j.Test,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0o644); err != nil {
+			return Result{}, fmt.Errorf("go_test judge: %w", err)
+		}
+	}
+
+	ctx := j.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	timeout := j.Timeout
+	if timeout <= 0 {
+		timeout = DefaultGoTestTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	for _, args := range [][]string{{"mod", "tidy"}, {"test", "."}} {
+		var out bytes.Buffer
+		cmd := exec.CommandContext(ctx, "go", args...)
+		cmd.Dir = dir
+		cmd.Stdout = &out
+		cmd.Stderr = &out
+		err := cmd.Run()
+		switch {
+		case err == nil:
+			continue
+		case errors.Is(err, exec.ErrNotFound):
+			return Result{}, fmt.Errorf("go_test judge: %w", err)
+		case ctx.Err() == context.DeadlineExceeded:
+			return Result{
+				Pass:   false,
+				Score:  0.0,
+				Reason: fmt.Sprintf("go %s timed out after %s", args[0], timeout),
+			}, nil
+		}
+		return Result{
+			Pass:   false,
+			Score:  0.0,
+			Reason: fmt.Sprintf("go %s failed: %s", args[0], truncate(strings.TrimSpace(out.String()), 500)),
+		}, nil
+	}
+	return Result{
+		Pass:   true,
+		Score:  1.0,
+		Reason: "tests pass",
+	}, nil
+}
@@ -0,0 +1,45 @@
+package judge
+
+import (
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestGoTestJudge(t *testing.T) {
+	if _, err := exec.LookPath("go"); err != nil {
+		t.Skip("go command not available")
+	}
+	j := &GoTestJudge{Test: `package main
+
+import "testing"
+
+func TestAdd(t *testing.T) {
+	if got := Add(2, 3); got != 5 {
+		t.Errorf("Add(2, 3) = %d, want 5", got)
+	}
+}
+`}
+
+	tests := []struct {
+		name   string
+		output string
+		pass   bool
+		reason string
+	}{
+		{"fenced, no package clause", "Here you go:\n```go\nfunc Add(a, b int) int { return a + b }\n```", true, "tests pass"},
+		{"wrong answer", "package main\n\nfunc Add(a, b int) int { return a - b }\n", false, "Add(2, 3) = -1"},
+		{"does not build", "func Add(a, b int) int { return a + }", false, "go test failed"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := j.Evaluate(Input{Output: tt.output})
+			if err != nil {
+				t.Fatalf("Evaluate() error: %v", err)
+			}
+			if r.Pass != tt.pass || !strings.Contains(r.Reason, tt.reason) {
+				t.Errorf("got pass=%v reason=%q, want pass=%v reason containing %q", r.Pass, r.Reason, tt.pass, tt.reason)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package judge
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KnownToolsJudge fails when the agent called a tool that was not in the
+// tool list it was given. The runner flags such calls as hallucinated in
+// the trace; this judge turns them into a scored failure.
+type KnownToolsJudge struct{}
+
+// Name returns the judge type identifier.
+func (j *KnownToolsJudge) Name() string { return "known_tools" }
+
+// Evaluate checks the recorded tool calls for hallucinated tool names. The
+// score is the fraction of calls made to known tools.
+func (j *KnownToolsJudge) Evaluate(input Input) (Result, error) {
+	if len(input.ToolCalls) == 0 {
+		return Result{Pass: true, Score: 1.0, Reason: "no tool calls"}, nil
+	}
+
+	var names []string
+	seen := make(map[string]bool)
+	hallucinated := 0
+	for _, tc := range input.ToolCalls {
+		if !tc.Hallucinated {
+			continue
+		}
+		hallucinated++
+		if !seen[tc.ToolName] {
+			seen[tc.ToolName] = true
+			names = append(names, tc.ToolName)
+		}
+	}
+
+	total := len(input.ToolCalls)
+	if hallucinated == 0 {
+		return Result{
+			Pass:   true,
+			Score:  1.0,
+			Reason: fmt.Sprintf("all %d tool calls were to known tools", total),
+		}, nil
+	}
+	return Result{
+		Pass:   false,
+		Score:  float64(total-hallucinated) / float64(total),
+		Reason: fmt.Sprintf("hallucinated tool: %d of %d calls were to unknown tools (%s)", hallucinated, total, strings.Join(names, ", ")),
+	}, nil
+}
@@ -0,0 +1,123 @@
+package judge
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"time"
+)
+
+// DefaultHTTPTimeout bounds a single request to a remote judge when
+// HTTPJudge.Timeout is unset.
+const DefaultHTTPTimeout = 30 * time.Second
+
+// httpJudgeBackoff is the delay before the first retry; it doubles on each
+// subsequent attempt.
+var httpJudgeBackoff = 500 * time.Millisecond
+
+// HTTPJudge delegates scoring to a remote grading service. The judge Input
+// is POSTed to URL as JSON and the response body must be a Result as JSON.
+// Network errors, 429, and 5xx responses are retried with exponential
+// backoff up to MaxRetries times.
+type HTTPJudge struct {
+	URL        string
+	Headers    map[string]string
+	MaxRetries int
+	Timeout    time.Duration
+	Client     *http.Client
+	Ctx        context.Context
+}
+
+// Name returns "http".
+func (j *HTTPJudge) Name() string { return "http" }
+
+// Evaluate posts the input to the grading service and parses its verdict.
+func (j *HTTPJudge) Evaluate(input Input) (Result, error) {
+	if j.URL == "" {
+		return Result{}, fmt.Errorf("http judge requires a URL")
+	}
+
+	ctx := j.Ctx
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	body, err := json.Marshal(input)
+	if err != nil {
+		return Result{}, fmt.Errorf("encoding judge input: %w", err)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= j.MaxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := httpJudgeBackoff * time.Duration(math.Pow(2, float64(attempt-1)))
+			select {
+			case <-ctx.Done():
+				return Result{}, ctx.Err()
+			case <-time.After(backoff):
+			}
+		}
+
+		result, retry, err := j.post(ctx, body)
+		if err == nil {
+			return result, nil
+		}
+		if !retry {
+			return Result{}, err
+		}
+		lastErr = err
+	}
+	return Result{}, fmt.Errorf("http judge request failed after %d attempts: %w", j.MaxRetries+1, lastErr)
+}
+
+// post makes one request. retry reports whether a failure is transient.
+func (j *HTTPJudge) post(ctx context.Context, body []byte) (result Result, retry bool, err error) {
+	timeout := j.Timeout
+	if timeout <= 0 {
+		timeout = DefaultHTTPTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, j.URL, bytes.NewReader(body))
+	if err != nil {
+		return Result{}, false, fmt.Errorf("creating HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range j.Headers {
+		req.Header.Set(k, v)
+	}
+
+	client := j.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{}, true, fmt.Errorf("sending HTTP request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return Result{}, true, fmt.Errorf("reading response body: %w", err)
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+		return Result{}, true, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+	if resp.StatusCode != http.StatusOK {
+		return Result{}, false, fmt.Errorf("HTTP %d: %s", resp.StatusCode, truncate(string(respBody), 200))
+	}
+
+	if err := json.Unmarshal(respBody, &result); err != nil {
+		return Result{}, false, fmt.Errorf("parsing http judge response %q: %w", truncate(string(respBody), 200), err)
+	}
+	if result.Score < 0 || result.Score > 1 {
+		return Result{}, false, fmt.Errorf("http judge score %v out of range [0, 1]", result.Score)
+	}
+	return result, false, nil
+}
@@ -0,0 +1,108 @@
+package judge
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+func TestHTTPJudge(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var in Input
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		res := Result{Reason: "graded"}
+		if strings.Contains(in.Output, "42") {
+			res.Pass, res.Score = true, 1
+		}
+		json.NewEncoder(w).Encode(res)
+	}))
+	defer srv.Close()
+
+	j := &HTTPJudge{URL: srv.URL, Headers: map[string]string{"Authorization": "Bearer secret"}}
+	r, err := j.Evaluate(Input{Output: "the answer is 42"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass || r.Reason != "graded" {
+		t.Errorf("got %+v", r)
+	}
+
+	j.Headers = nil
+	if _, err := j.Evaluate(Input{Output: "42"}); err == nil || !strings.Contains(err.Error(), "HTTP 401") {
+		t.Errorf("error = %v, want HTTP 401", err)
+	}
+}
+
+func TestHTTPJudge_Retries(t *testing.T) {
+	old := httpJudgeBackoff
+	httpJudgeBackoff = time.Millisecond
+	defer func() { httpJudgeBackoff = old }()
+
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"pass": true, "score": 1}`))
+	}))
+	defer srv.Close()
+
+	j := &HTTPJudge{URL: srv.URL, MaxRetries: 2}
+	if _, err := j.Evaluate(Input{}); err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("calls = %d, want 3", calls.Load())
+	}
+
+	calls.Store(0)
+	j.MaxRetries = 1
+	if _, err := j.Evaluate(Input{}); err == nil || !strings.Contains(err.Error(), "after 2 attempts") {
+		t.Errorf("error = %v, want failure after 2 attempts", err)
+	}
+}
+
+func TestHTTPJudge_Timeout(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+		case <-time.After(200 * time.Millisecond):
+		}
+	}))
+	defer srv.Close()
+
+	j := &HTTPJudge{URL: srv.URL, Timeout: 20 * time.Millisecond}
+	if _, err := j.Evaluate(Input{}); err == nil {
+		t.Error("expected timeout error")
+	}
+}
+
+func TestFromConfig_HTTPHeadersExpandEnv(t *testing.T) {
+	t.Setenv("GRADER_TOKEN", "tok")
+	jc, err := FromConfig(suite.JudgeConfig{
+		Type:    "http",
+		Value:   "http://grader.internal/score",
+		Headers: map[string]string{"Authorization": "Bearer ${GRADER_TOKEN}"},
+	}, Options{})
+	if err != nil {
+		t.Fatalf("FromConfig() error: %v", err)
+	}
+	hj := jc.Judge.(*HTTPJudge)
+	if got := hj.Headers["Authorization"]; got != "Bearer tok" {
+		t.Errorf("Authorization = %q, want %q", got, "Bearer tok")
+	}
+}
@@ -16,6 +16,14 @@ type Input struct {
 	Output         string                   `json:"output"`
 	ExpectedOutput string                   `json:"expected_output,omitempty"`
 	ToolCalls      []trace.ToolCallTrace    `json:"tool_calls,omitempty"`
+
+	// Messages is the recorded conversation, for judges that care where
+	// tool calls fall relative to the agent's replies.
+	Messages []trace.Message `json:"messages,omitempty"`
+
+	// Vars holds the case input variables, for judges whose expectations
+	// are templated on them.
+	Vars map[string]interface{} `json:"vars,omitempty"`
 }
 
 // Judge defines the interface for evaluating agent outputs.
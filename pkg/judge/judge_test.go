@@ -1,8 +1,11 @@
 package judge
 
 import (
+	"encoding/json"
+	"strings"
 	"testing"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
 	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
@@ -65,6 +68,58 @@ func TestExactJudge_Name(t *testing.T) {
 	}
 }
 
+// --- Contains Judge ---
+
+func TestContainsJudge(t *testing.T) {
+	j := &ContainsJudge{Value: "hello"}
+	r, err := j.Evaluate(Input{Output: "well hello there"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass || r.Score != 1.0 {
+		t.Errorf("expected pass with score 1.0, got pass=%v score=%v", r.Pass, r.Score)
+	}
+
+	r, _ = j.Evaluate(Input{Output: "goodbye"})
+	if r.Pass {
+		t.Error("expected fail when substring is absent")
+	}
+	if j.Name() != "contains" {
+		t.Errorf("name = %q, want %q", j.Name(), "contains")
+	}
+}
+
+func TestNumericJudge(t *testing.T) {
+	j := &NumericJudge{Value: "1000"}
+	tests := []struct {
+		output string
+		pass   bool
+	}{
+		{"She has 3 boxes of 250 plus 250, so the answer is 1000.", true},
+		{"The total comes to $1,000.00", true},
+		{"#### 999", false},
+		{"I am not sure.", false},
+	}
+	for _, tt := range tests {
+		r, err := j.Evaluate(Input{Output: tt.output})
+		if err != nil {
+			t.Fatalf("Evaluate(%q) error: %v", tt.output, err)
+		}
+		if r.Pass != tt.pass {
+			t.Errorf("Evaluate(%q) pass = %v, want %v (%s)", tt.output, r.Pass, tt.pass, r.Reason)
+		}
+	}
+
+	// Without a value the case's expected output is the answer.
+	r, err := (&NumericJudge{}).Evaluate(Input{Output: "x = -2.5", ExpectedOutput: "-2.50"})
+	if err != nil || !r.Pass {
+		t.Errorf("Evaluate with expected output = %+v, %v; want pass", r, err)
+	}
+	if _, err := (&NumericJudge{Value: "many"}).Evaluate(Input{Output: "3"}); err == nil {
+		t.Error("expected an error for a non-numeric expected value")
+	}
+}
+
 // --- Regex Judge ---
 
 func TestRegexJudge_Pass(t *testing.T) {
@@ -97,6 +152,77 @@ func TestRegexJudge_InvalidPattern(t *testing.T) {
 	}
 }
 
+func TestRegexJudge_MultiplePatterns(t *testing.T) {
+	output := "order ORD-1234 shipped to Berlin"
+	tests := []struct {
+		name      string
+		judge     RegexJudge
+		wantPass  bool
+		wantScore float64
+	}{
+		{"all match", RegexJudge{Patterns: []string{`ORD-\d+`, `Berlin`}}, true, 1.0},
+		{"all partial", RegexJudge{Patterns: []string{`ORD-\d+`, `Paris`}}, false, 0.5},
+		{"any one", RegexJudge{Patterns: []string{`Paris`, `Berlin`}, Match: "any"}, true, 1.0},
+		{"any none", RegexJudge{Patterns: []string{`Paris`, `Rome`}, Match: "any"}, false, 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, err := tt.judge.Evaluate(Input{Output: output})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.Pass != tt.wantPass || r.Score != tt.wantScore {
+				t.Errorf("got pass=%v score=%.2f, want pass=%v score=%.2f (%s)",
+					r.Pass, r.Score, tt.wantPass, tt.wantScore, r.Reason)
+			}
+		})
+	}
+
+	if _, err := (&RegexJudge{Patterns: []string{"x"}, Match: "most"}).Evaluate(Input{}); err == nil {
+		t.Error("expected error for unknown match mode")
+	}
+}
+
+func TestRegexJudge_Captures(t *testing.T) {
+	j := &RegexJudge{
+		Pattern:  `order (?P<order_id>ORD-\d+)`,
+		Captures: map[string]string{"order_id": "{{.expected_id}}"},
+	}
+
+	r, err := j.Evaluate(Input{
+		Output: "Your order ORD-1234 has shipped.",
+		Vars:   map[string]interface{}{"expected_id": "ORD-1234"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+
+	r, err = j.Evaluate(Input{
+		Output: "Your order ORD-9999 has shipped.",
+		Vars:   map[string]interface{}{"expected_id": "ORD-1234"},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pass || r.Score != 0.5 {
+		t.Errorf("got pass=%v score=%.2f, want fail at 0.50", r.Pass, r.Score)
+	}
+	if !strings.Contains(r.Reason, `"ORD-9999"`) {
+		t.Errorf("reason should show the captured value: %s", r.Reason)
+	}
+
+	if _, err := j.Evaluate(Input{Output: "order ORD-1"}); err == nil {
+		t.Error("expected error for undefined template variable")
+	}
+	undefined := &RegexJudge{Pattern: `ORD`, Captures: map[string]string{"missing": "x"}}
+	if _, err := undefined.Evaluate(Input{Output: "ORD"}); err == nil {
+		t.Error("expected error for capture group not defined by any pattern")
+	}
+}
+
 func TestRegexJudge_Name(t *testing.T) {
 	j := &RegexJudge{}
 	if j.Name() != "regex" {
@@ -104,6 +230,80 @@ func TestRegexJudge_Name(t *testing.T) {
 	}
 }
 
+// --- Style Judge ---
+
+func TestStyleJudge_Pass(t *testing.T) {
+	zero := 0
+	j := &StyleJudge{Rules: suite.StyleRules{
+		MinWords:         5,
+		MaxWords:         20,
+		MaxSentences:     2,
+		MaxBullets:       &zero,
+		ForbiddenPhrases: []string{"as an AI"},
+	}}
+	r, err := j.Evaluate(Input{Output: "The build failed because pi is 3.14 here. Fix the import."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass || r.Score != 1.0 {
+		t.Errorf("expected pass with score 1.0, got pass=%v score=%f: %s", r.Pass, r.Score, r.Reason)
+	}
+}
+
+func TestStyleJudge_Fail(t *testing.T) {
+	j := &StyleJudge{Rules: suite.StyleRules{
+		MaxWords:         10,
+		MinBullets:       2,
+		ForbiddenPhrases: []string{"As an AI"},
+	}}
+	r, err := j.Evaluate(Input{Output: "As an ai language model, I think:\n- one option\n"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pass {
+		t.Fatal("expected fail")
+	}
+	if r.Score < 0.33 || r.Score > 0.34 {
+		t.Errorf("score = %f, want 1/3", r.Score)
+	}
+	for _, want := range []string{"1 bullet items, want at least 2", `forbidden phrase "As an AI"`} {
+		if !strings.Contains(r.Reason, want) {
+			t.Errorf("reason %q missing %q", r.Reason, want)
+		}
+	}
+}
+
+func TestStyleJudge_Counts(t *testing.T) {
+	out := "Summary first. Then details!\n\n1. Install it\n2) Run it\n* check v1.2 output\n"
+	if n := countSentences(out); n != 5 {
+		t.Errorf("countSentences = %d, want 5", n)
+	}
+	if n := countBullets(out); n != 3 {
+		t.Errorf("countBullets = %d, want 3", n)
+	}
+}
+
+func TestValidateStyle(t *testing.T) {
+	one := 1
+	tests := []struct {
+		rules   *suite.StyleRules
+		wantErr bool
+	}{
+		{nil, true},
+		{&suite.StyleRules{}, true},
+		{&suite.StyleRules{MinWords: 10, MaxWords: 5}, true},
+		{&suite.StyleRules{MinBullets: 2, MaxBullets: &one}, true},
+		{&suite.StyleRules{MaxSentences: -1}, true},
+		{&suite.StyleRules{MaxBullets: &one}, false},
+		{&suite.StyleRules{ForbiddenPhrases: []string{"As an AI"}}, false},
+	}
+	for i, tt := range tests {
+		if err := validateStyle(tt.rules); (err != nil) != tt.wantErr {
+			t.Errorf("case %d: validateStyle() error = %v, wantErr %v", i, err, tt.wantErr)
+		}
+	}
+}
+
 // --- Schema Judge ---
 
 func TestSchemaJudge_Pass(t *testing.T) {
@@ -185,6 +385,23 @@ func TestSchemaJudge_InvalidSchema(t *testing.T) {
 	}
 }
 
+func TestValidateOutput(t *testing.T) {
+	c := suite.EvalCase{Judges: []suite.JudgeConfig{
+		{Type: "exact", Value: "ignored"},
+		{Type: "schema", Value: `{"type":"object","required":["answer"]}`},
+	}}
+	if err := ValidateOutput(c, `{"answer": 42}`); err != nil {
+		t.Errorf("valid output: %v", err)
+	}
+	err := ValidateOutput(c, `{"other": 1}`)
+	if err == nil || !strings.Contains(err.Error(), "does not match schema") {
+		t.Errorf("invalid output: error = %v, want schema violation", err)
+	}
+	if err := ValidateOutput(suite.EvalCase{}, "anything"); err != nil {
+		t.Errorf("case without schema judges: %v", err)
+	}
+}
+
 func TestSchemaJudge_Name(t *testing.T) {
 	j := &SchemaJudge{}
 	if j.Name() != "schema" {
@@ -401,6 +618,88 @@ func TestToolCallJudge_EmptyExpectations(t *testing.T) {
 	}
 }
 
+func TestToolCallJudge_NestedParams(t *testing.T) {
+	var expected []ExpectedToolCall
+	err := json.Unmarshal([]byte(`[{"tool_name": "search", "parameters": {"filter.date.start": "regex:^2024-", "query": "*"}}]`), &expected)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	j := &ToolCallJudge{Expected: expected}
+
+	r, err := j.Evaluate(Input{
+		ToolCalls: []trace.ToolCallTrace{
+			{
+				ToolName: "search",
+				Parameters: map[string]interface{}{
+					"query":  "invoices",
+					"filter": map[string]interface{}{"date": map[string]interface{}{"start": "2024-01-01"}},
+				},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass with nested param match, got fail: %s", r.Reason)
+	}
+}
+
+func TestToolCallJudge_Constraints(t *testing.T) {
+	calls := func(names ...string) []trace.ToolCallTrace {
+		out := make([]trace.ToolCallTrace, len(names))
+		for i, n := range names {
+			out[i] = trace.ToolCallTrace{ToolName: n}
+		}
+		return out
+	}
+
+	tests := []struct {
+		name       string
+		constraint suite.ToolConstraint
+		input      Input
+		wantPass   bool
+	}{
+		{"before pass", suite.ToolConstraint{Type: "before", Tools: []string{"read", "write"}}, Input{ToolCalls: calls("read", "write", "read")}, true},
+		{"before fail", suite.ToolConstraint{Type: "before", Tools: []string{"read", "write"}}, Input{ToolCalls: calls("write", "read")}, false},
+		{"before missing first", suite.ToolConstraint{Type: "before", Tools: []string{"read", "write"}}, Input{ToolCalls: calls("write")}, false},
+		{"before neither called", suite.ToolConstraint{Type: "before", Tools: []string{"read", "write"}}, Input{}, true},
+		{"unordered pass", suite.ToolConstraint{Type: "unordered", Tools: []string{"a", "b"}}, Input{ToolCalls: calls("b", "a")}, true},
+		{"unordered fail", suite.ToolConstraint{Type: "unordered", Tools: []string{"a", "b"}}, Input{ToolCalls: calls("a")}, false},
+		{"count pass", suite.ToolConstraint{Type: "count", Tools: []string{"search"}, Count: 2}, Input{ToolCalls: calls("search", "read", "search")}, true},
+		{"count fail", suite.ToolConstraint{Type: "count", Tools: []string{"search"}, Count: 1}, Input{ToolCalls: calls("search", "search")}, false},
+		{
+			"no calls after answer pass",
+			suite.ToolConstraint{Type: "no_calls_after_answer"},
+			Input{Output: "42", Messages: []trace.Message{
+				{Role: "user", Content: "q"}, {Role: "assistant", Content: ""}, {Role: "tool", Content: "r"}, {Role: "assistant", Content: "42"},
+			}},
+			true,
+		},
+		{
+			"no calls after answer fail",
+			suite.ToolConstraint{Type: "no_calls_after_answer"},
+			Input{Output: "42", Messages: []trace.Message{
+				{Role: "user", Content: "q"}, {Role: "assistant", Content: "42"}, {Role: "tool", Content: "r"}, {Role: "assistant", Content: "42"},
+			}},
+			false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			j := &ToolCallJudge{Constraints: []suite.ToolConstraint{tt.constraint}}
+			r, err := j.Evaluate(tt.input)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if r.Pass != tt.wantPass {
+				t.Errorf("Pass = %v, want %v (reason: %s)", r.Pass, tt.wantPass, r.Reason)
+			}
+		})
+	}
+}
+
 // --- Human Review Judge ---
 
 func TestHumanReviewJudge_DefaultReason(t *testing.T) {
@@ -454,3 +753,107 @@ func TestHumanReviewJudge_CompositeIntegration(t *testing.T) {
 		t.Error("review status should not pass")
 	}
 }
+
+// --- Citation Judge ---
+
+func retrievalCalls() []trace.ToolCallTrace {
+	return []trace.ToolCallTrace{
+		{ToolName: "search_docs", Response: `{"results": [{"id": "doc-1", "text": "Paris is the capital of France."}, {"id": "doc-2", "text": "The Seine flows through Paris."}]}`},
+		{ToolName: "get_weather", Response: `{"id": "wx-9", "forecast": "rain"}`},
+	}
+}
+
+func TestCitationJudge_AllCited(t *testing.T) {
+	j := &CitationJudge{Rules: suite.CitationRules{Tools: []string{"search_docs"}}}
+	out := "Here is what I found:\n" +
+		"- Paris is the capital of France [doc-1].\n" +
+		"- The river through the city is the Seine. [doc-1, doc-2]\n" +
+		`- As the source says, "the Seine flows through  paris".`
+	r, err := j.Evaluate(Input{Output: out, ToolCalls: retrievalCalls()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass || r.Score != 1.0 {
+		t.Errorf("expected pass with score 1.0, got pass=%v score=%f: %s", r.Pass, r.Score, r.Reason)
+	}
+}
+
+func TestCitationJudge_Uncited(t *testing.T) {
+	j := &CitationJudge{Rules: suite.CitationRules{Tools: []string{"search_docs"}}}
+	out := "Paris is the capital of France [doc-1]. " +
+		"It will rain in Paris tomorrow [wx-9]. " +
+		"Paris has about two million residents [doc-7]. " +
+		"The Eiffel Tower opened in 1889."
+	r, err := j.Evaluate(Input{Output: out, ToolCalls: retrievalCalls()})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pass {
+		t.Fatal("expected fail")
+	}
+	if r.Score != 0.25 {
+		t.Errorf("score = %f, want 0.25", r.Score)
+	}
+	for _, want := range []string{"3 of 4 claims", "rain in Paris", "two million", "Eiffel Tower"} {
+		if !strings.Contains(r.Reason, want) {
+			t.Errorf("reason %q missing %q", r.Reason, want)
+		}
+	}
+}
+
+func TestCitationJudge_NoRetrieval(t *testing.T) {
+	j := &CitationJudge{}
+	r, err := j.Evaluate(Input{Output: "Paris is the capital of France [doc-1]."})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pass || !strings.Contains(r.Reason, "no retrieval results") {
+		t.Errorf("expected fail for missing retrieval, got pass=%v: %s", r.Pass, r.Reason)
+	}
+}
+
+func TestCitationJudge_CustomPattern(t *testing.T) {
+	j := &CitationJudge{Rules: suite.CitationRules{Pattern: `\(source: (\w+)\)`, IDField: "doc_id"}}
+	calls := []trace.ToolCallTrace{{ToolName: "search", Response: `[{"doc_id": 42, "text": "x"}]`}}
+	r, err := j.Evaluate(Input{Output: "The answer to everything is 42 (source: 42).", ToolCalls: calls})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+
+	j.Rules.Pattern = `\(source: \w+\)`
+	if _, err := j.Evaluate(Input{Output: "x", ToolCalls: calls}); err == nil {
+		t.Error("expected error for pattern without a group")
+	}
+}
+
+// --- Known Tools Judge ---
+
+func TestKnownToolsJudge(t *testing.T) {
+	j := &KnownToolsJudge{}
+	r, err := j.Evaluate(Input{ToolCalls: []trace.ToolCallTrace{{ToolName: "read_file"}}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+
+	r, err = j.Evaluate(Input{ToolCalls: []trace.ToolCallTrace{
+		{ToolName: "read_file"},
+		{ToolName: "web_search", Hallucinated: true},
+		{ToolName: "web_search", Hallucinated: true},
+		{ToolName: "read_file"},
+	}})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if r.Pass || r.Score != 0.5 {
+		t.Errorf("expected fail with score 0.5, got pass=%v score=%f", r.Pass, r.Score)
+	}
+	if !strings.Contains(r.Reason, "hallucinated tool") || !strings.Contains(r.Reason, "(web_search)") {
+		t.Errorf("reason = %q", r.Reason)
+	}
+}
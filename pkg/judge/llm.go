@@ -67,6 +67,8 @@ func (j *LLMJudge) Evaluate(input Input) (Result, error) {
 	// Track judge usage separately.
 	j.Usage.InputTokens += resp.Usage.InputTokens
 	j.Usage.OutputTokens += resp.Usage.OutputTokens
+	j.Usage.CacheReadTokens += resp.Usage.CacheReadTokens
+	j.Usage.CacheWriteTokens += resp.Usage.CacheWriteTokens
 
 	result, err := parseJudgeResponse(resp.Content)
 	if err != nil {
@@ -81,6 +83,9 @@ func (j *LLMJudge) GetUsage() provider.Usage {
 	return j.Usage
 }
 
+// ModelName returns the judge model.
+func (j *LLMJudge) ModelName() string { return j.Model }
+
 func buildJudgePrompt(rubric string, input Input) string {
 	var b strings.Builder
 
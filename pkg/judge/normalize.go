@@ -0,0 +1,119 @@
+package judge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Normalizations accepted by Normalize and the "normalize" option of exact
+// and contains judges. They make trivially different outputs compare
+// equal: "The answer is 1,000." matches "the answer is 1000" with case,
+// punctuation, and numbers.
+const (
+	NormWhitespace  = "whitespace"  // trim and collapse runs of whitespace
+	NormCase        = "case"        // fold to lower case
+	NormUnicode     = "unicode"     // NFKC, with typographic quotes and dashes made ASCII
+	NormPunctuation = "punctuation" // drop punctuation, keeping decimal points and digit grouping
+	NormNumbers     = "numbers"     // drop thousands separators and trailing decimal zeros
+)
+
+// normOrder is the order normalizations are applied in, whatever order
+// they are listed in: unicode first so the rest see canonical runes,
+// numbers before punctuation so decimal points survive, and whitespace
+// last to tidy up after punctuation removal.
+var normOrder = []string{NormUnicode, NormCase, NormNumbers, NormPunctuation, NormWhitespace}
+
+var normFuncs = map[string]func(string) string{
+	NormWhitespace:  normalizeWhitespace,
+	NormCase:        strings.ToLower,
+	NormUnicode:     normalizeUnicode,
+	NormPunctuation: stripPunctuation,
+	NormNumbers:     normalizeNumbers,
+}
+
+// Normalize applies the named normalizations to s. Unlike preprocess
+// steps, they are applied in a fixed order, so listing order does not
+// matter; judges apply the same normalizations to both sides of a
+// comparison.
+func Normalize(s string, names []string) (string, error) {
+	want := make(map[string]bool, len(names))
+	for _, name := range names {
+		if _, ok := normFuncs[name]; !ok {
+			return "", fmt.Errorf("unknown normalization %q", name)
+		}
+		want[name] = true
+	}
+	for _, name := range normOrder {
+		if want[name] {
+			s = normFuncs[name](s)
+		}
+	}
+	return s, nil
+}
+
+func normalizeWhitespace(s string) string {
+	s = strings.TrimSpace(s)
+	fields := strings.Fields(s)
+	return strings.Join(fields, " ")
+}
+
+// typographic maps the quotes and dashes that NFKC leaves alone, and that
+// models emit freely, to their ASCII forms.
+var typographic = strings.NewReplacer(
+	"‘", "'", "’", "'", "‚", "'", "‛", "'",
+	"“", `"`, "”", `"`, "„", `"`, "‟", `"`,
+	"‐", "-", "‑", "-", "‒", "-", "–", "-", "—", "-", "−", "-",
+)
+
+func normalizeUnicode(s string) string {
+	return typographic.Replace(norm.NFKC.String(s))
+}
+
+// stripPunctuation removes punctuation runes, except a '.' or ',' between
+// two digits, which is part of a number.
+func stripPunctuation(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	b.Grow(len(s))
+	for i, r := range runes {
+		if unicode.IsPunct(r) {
+			inNumber := (r == '.' || r == ',') && i > 0 && i < len(runes)-1 &&
+				unicode.IsDigit(runes[i-1]) && unicode.IsDigit(runes[i+1])
+			if !inNumber {
+				continue
+			}
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+var (
+	numberRe  = regexp.MustCompile(`\d[\d,]*(?:\.\d+)?`)
+	groupedRe = regexp.MustCompile(`^\d{1,3}(?:,\d{3})+(?:\.\d+)?$`)
+)
+
+// normalizeNumbers rewrites numbers to a canonical form: "1,000.50"
+// becomes "1000.5" and "3.0" becomes "3". Commas that are not thousands
+// separators, as in "1,2,3", are left alone.
+func normalizeNumbers(s string) string {
+	return numberRe.ReplaceAllStringFunc(s, func(n string) string {
+		// The match may end in a comma that punctuates the sentence.
+		trailing := ""
+		for strings.HasSuffix(n, ",") {
+			n, trailing = n[:len(n)-1], trailing+","
+		}
+		if groupedRe.MatchString(n) {
+			n = strings.ReplaceAll(n, ",", "")
+		}
+		if !strings.Contains(n, ",") && strings.Contains(n, ".") {
+			n = strings.TrimRight(n, "0")
+			n = strings.TrimSuffix(n, ".")
+		}
+		return n + trailing
+	})
+}
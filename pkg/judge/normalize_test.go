@@ -0,0 +1,69 @@
+package judge
+
+import "testing"
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name  string
+		norms []string
+		in    string
+		want  string
+	}{
+		{"none", nil, " Hello, World ", " Hello, World "},
+		{"whitespace", []string{NormWhitespace}, "  a \n\t b ", "a b"},
+		{"case", []string{NormCase}, "HeLLo", "hello"},
+		{"unicode nfkc", []string{NormUnicode}, "ﬁle ①", "file 1"},
+		{"unicode composes", []string{NormUnicode}, "cafe\u0301", "caf\u00e9"},
+		{"unicode quotes and dashes", []string{NormUnicode}, "“it’s” — ok", `"it's" - ok`},
+		{"punctuation", []string{NormPunctuation}, "Yes! It's done.", "Yes Its done"},
+		{"punctuation keeps decimals", []string{NormPunctuation}, "pi is 3.14, e is 2,718.", "pi is 3.14 e is 2,718"},
+		{"numbers grouping", []string{NormNumbers}, "1,000,000 and 12,345.50", "1000000 and 12345.5"},
+		{"numbers trailing zeros", []string{NormNumbers}, "3.0 or 2.50 or 100", "3 or 2.5 or 100"},
+		{"numbers list untouched", []string{NormNumbers}, "1,2,3", "1,2,3"},
+		{"numbers sentence comma", []string{NormNumbers}, "1.50, then 2", "1.5, then 2"},
+		{"order independent", []string{NormWhitespace, NormPunctuation, NormCase}, "The Answer:  42.", "the answer 42"},
+		{"all", []string{NormUnicode, NormCase, NormPunctuation, NormNumbers, NormWhitespace}, " The total is “1,000.00”! ", "the total is 1000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.in, tt.norms)
+			if err != nil {
+				t.Fatalf("Normalize() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Normalize("x", []string{"bogus"}); err == nil {
+		t.Error("expected error for unknown normalization")
+	}
+}
+
+func TestExactJudge_Normalize(t *testing.T) {
+	j := &ExactJudge{Normalize: []string{NormCase, NormPunctuation, NormNumbers, NormWhitespace}}
+	r, err := j.Evaluate(Input{Output: "The answer is 1,000.", ExpectedOutput: "the answer is 1000"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+
+	r, _ = j.Evaluate(Input{Output: "The answer is 1,001.", ExpectedOutput: "the answer is 1000"})
+	if r.Pass {
+		t.Error("expected fail for a different number")
+	}
+}
+
+func TestContainsJudge_Normalize(t *testing.T) {
+	j := &ContainsJudge{Value: "Paris", Normalize: []string{NormCase}}
+	r, err := j.Evaluate(Input{Output: "the capital is PARIS"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+}
@@ -0,0 +1,69 @@
+package judge
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// numberPattern matches a number as models write them in answers, with
+// optional sign, currency symbol, thousands separators, and decimals.
+var numberPattern = regexp.MustCompile(`-?\$?\d[\d,]*(?:\.\d+)?`)
+
+// NumericJudge checks the final number in the agent output against an
+// expected number, for math word problems whose answers end with the
+// result after a worked solution. Value is the expected number, or the
+// case's expected output when empty. Formatting such as "$1,000.00" is
+// ignored, and numbers are compared with a small relative tolerance.
+type NumericJudge struct {
+	Value string `json:"value" yaml:"value"`
+}
+
+// Name returns the judge type identifier.
+func (j *NumericJudge) Name() string { return "numeric" }
+
+// Evaluate checks if the last number in the output equals the expected one.
+func (j *NumericJudge) Evaluate(input Input) (Result, error) {
+	expected := j.Value
+	if expected == "" {
+		expected = input.ExpectedOutput
+	}
+	want, err := parseNumber(strings.TrimSpace(expected))
+	if err != nil {
+		return Result{}, fmt.Errorf("numeric judge: expected value %q is not a number", expected)
+	}
+
+	matches := numberPattern.FindAllString(input.Output, -1)
+	if len(matches) == 0 {
+		return Result{
+			Pass:   false,
+			Score:  0.0,
+			Reason: "output contains no number",
+		}, nil
+	}
+	last := matches[len(matches)-1]
+	got, err := parseNumber(last)
+	if err != nil {
+		return Result{}, fmt.Errorf("numeric judge: parsing %q: %w", last, err)
+	}
+
+	if math.Abs(got-want) <= 1e-6*math.Max(1, math.Abs(want)) {
+		return Result{
+			Pass:   true,
+			Score:  1.0,
+			Reason: fmt.Sprintf("final number %s equals %s", last, expected),
+		}, nil
+	}
+	return Result{
+		Pass:   false,
+		Score:  0.0,
+		Reason: fmt.Sprintf("final number %s, want %s", last, expected),
+	}, nil
+}
+
+func parseNumber(s string) (float64, error) {
+	s = strings.NewReplacer("$", "", ",", "").Replace(s)
+	return strconv.ParseFloat(s, 64)
+}
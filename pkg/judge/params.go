@@ -0,0 +1,223 @@
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ParamOptions controls how expected tool-call parameters are compared with
+// the arguments a model actually sent.
+type ParamOptions struct {
+	// Exact requires maps to have identical keys at every level. Otherwise
+	// an expected map matches any actual map containing its keys.
+	Exact bool
+	// Tolerance is the largest absolute difference at which two numbers
+	// are still equal.
+	Tolerance float64
+	// IgnoreCase compares strings case-insensitively.
+	IgnoreCase bool
+}
+
+// Value matchers usable as expected parameter values.
+const (
+	// AnyValue matches any value, so only the key must be present.
+	AnyValue = "*"
+	// RegexPrefix marks an expected string as a regular expression matched
+	// against the actual value's string form, e.g. "regex:^2024-".
+	RegexPrefix = "regex:"
+)
+
+// ParamsMatch reports whether actual satisfies expected. Values are
+// compared by type rather than by their printed form: numbers match
+// regardless of how JSON decoding typed them (1, 1.0, and "1" are equal),
+// booleans match "true" and "false", and nested maps and slices are
+// compared element by element.
+//
+// Expected keys may be dotted paths ("filter.date.start", "items.0.id")
+// that reach into nested objects and arrays, and expected values may be
+// AnyValue or a RegexPrefix pattern instead of a literal.
+func ParamsMatch(expected, actual map[string]interface{}, opts ParamOptions) bool {
+	return valuesMatch(expected, actual, opts)
+}
+
+func valuesMatch(expected, actual interface{}, opts ParamOptions) bool {
+	expected, actual = normalizeValue(expected), normalizeValue(actual)
+
+	if s, ok := expected.(string); ok {
+		if s == AnyValue {
+			return true
+		}
+		if pattern, ok := strings.CutPrefix(s, RegexPrefix); ok {
+			if opts.IgnoreCase {
+				pattern = "(?i)" + pattern
+			}
+			re, err := regexp.Compile(pattern)
+			return err == nil && re.MatchString(fmt.Sprint(actual))
+		}
+	}
+
+	switch e := expected.(type) {
+	case nil:
+		return actual == nil
+	case map[string]interface{}:
+		a, ok := actual.(map[string]interface{})
+		if !ok || (opts.Exact && len(a) != countTopLevelKeys(e)) {
+			return false
+		}
+		for k, ev := range e {
+			av, ok := lookupPath(a, k)
+			if !ok || !valuesMatch(ev, av, opts) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		a, ok := actual.([]interface{})
+		if !ok || len(a) != len(e) {
+			return false
+		}
+		for i := range e {
+			if !valuesMatch(e[i], a[i], opts) {
+				return false
+			}
+		}
+		return true
+	}
+
+	es, eIsString := expected.(string)
+	as, aIsString := actual.(string)
+	if eIsString && aIsString {
+		if opts.IgnoreCase {
+			return strings.EqualFold(es, as)
+		}
+		return es == as
+	}
+
+	if en, ok := toNumber(expected); ok {
+		if an, ok := toNumber(actual); ok {
+			return math.Abs(en-an) <= opts.Tolerance
+		}
+		return false
+	}
+	if eb, ok := toBool(expected); ok {
+		ab, ok := toBool(actual)
+		return ok && eb == ab
+	}
+
+	return fmt.Sprint(expected) == fmt.Sprint(actual)
+}
+
+// lookupPath finds key in m, treating it as a dotted path into nested
+// objects and arrays when m has no key by that literal name.
+func lookupPath(m map[string]interface{}, key string) (interface{}, bool) {
+	if v, ok := m[key]; ok {
+		return v, true
+	}
+	if !strings.Contains(key, ".") {
+		return nil, false
+	}
+
+	var cur interface{} = m
+	for _, seg := range strings.Split(key, ".") {
+		switch c := normalizeValue(cur).(type) {
+		case map[string]interface{}:
+			v, ok := c[seg]
+			if !ok {
+				return nil, false
+			}
+			cur = v
+		case []interface{}:
+			i, err := strconv.Atoi(seg)
+			if err != nil || i < 0 || i >= len(c) {
+				return nil, false
+			}
+			cur = c[i]
+		default:
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// countTopLevelKeys returns how many distinct keys of the actual map the
+// expected keys refer to, counting a dotted path by its first segment.
+func countTopLevelKeys(m map[string]interface{}) int {
+	seen := make(map[string]bool, len(m))
+	for k := range m {
+		first, _, _ := strings.Cut(k, ".")
+		seen[first] = true
+	}
+	return len(seen)
+}
+
+// normalizeValue converts maps with string keys and slices of any element
+// type to their generic JSON forms so they can be compared uniformly.
+func normalizeValue(v interface{}) interface{} {
+	switch v.(type) {
+	case nil, map[string]interface{}, []interface{}:
+		return v
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			return v
+		}
+		m := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			m[iter.Key().String()] = iter.Value().Interface()
+		}
+		return m
+	case reflect.Slice, reflect.Array:
+		s := make([]interface{}, rv.Len())
+		for i := range s {
+			s[i] = rv.Index(i).Interface()
+		}
+		return s
+	}
+	return v
+}
+
+// toNumber returns v as a float64 if it is a number or a string holding one.
+func toNumber(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(n), 64)
+		return f, err == nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	}
+	return 0, false
+}
+
+// toBool returns v as a bool if it is one or the string "true" or "false".
+func toBool(v interface{}) (bool, bool) {
+	switch b := v.(type) {
+	case bool:
+		return b, true
+	case string:
+		switch strings.ToLower(strings.TrimSpace(b)) {
+		case "true":
+			return true, true
+		case "false":
+			return false, true
+		}
+	}
+	return false, false
+}
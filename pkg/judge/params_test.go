@@ -0,0 +1,81 @@
+package judge
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestParamsMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected map[string]interface{}
+		actual   map[string]interface{}
+		opts     ParamOptions
+		want     bool
+	}{
+		{"int vs float", map[string]interface{}{"n": 1}, map[string]interface{}{"n": 1.0}, ParamOptions{}, true},
+		{"number vs numeric string", map[string]interface{}{"n": 1}, map[string]interface{}{"n": "1"}, ParamOptions{}, true},
+		{"json.Number", map[string]interface{}{"n": 2.5}, map[string]interface{}{"n": json.Number("2.5")}, ParamOptions{}, true},
+		{"different numbers", map[string]interface{}{"n": 1}, map[string]interface{}{"n": 1.01}, ParamOptions{}, false},
+		{"within tolerance", map[string]interface{}{"n": 1}, map[string]interface{}{"n": 1.01}, ParamOptions{Tolerance: 0.05}, true},
+		{"strings are not parsed", map[string]interface{}{"zip": "01234"}, map[string]interface{}{"zip": "1234"}, ParamOptions{}, false},
+		{"case sensitive", map[string]interface{}{"q": "Go"}, map[string]interface{}{"q": "go"}, ParamOptions{}, false},
+		{"ignore case", map[string]interface{}{"q": "Go"}, map[string]interface{}{"q": "go"}, ParamOptions{IgnoreCase: true}, true},
+		{"bool vs string", map[string]interface{}{"b": true}, map[string]interface{}{"b": "true"}, ParamOptions{}, true},
+		{"missing key", map[string]interface{}{"a": 1}, map[string]interface{}{"b": 1}, ParamOptions{}, false},
+		{
+			"nested subset",
+			map[string]interface{}{"filter": map[string]interface{}{"limit": 10}},
+			map[string]interface{}{"filter": map[string]interface{}{"limit": 10.0, "sort": "asc"}},
+			ParamOptions{},
+			true,
+		},
+		{
+			"nested exact",
+			map[string]interface{}{"filter": map[string]interface{}{"limit": 10}},
+			map[string]interface{}{"filter": map[string]interface{}{"limit": 10.0, "sort": "asc"}},
+			ParamOptions{Exact: true},
+			false,
+		},
+		{"typed slice", map[string]interface{}{"ids": []int{1, 2}}, map[string]interface{}{"ids": []interface{}{1.0, 2.0}}, ParamOptions{}, true},
+		{"slice length", map[string]interface{}{"ids": []int{1}}, map[string]interface{}{"ids": []interface{}{1.0, 2.0}}, ParamOptions{}, false},
+		{"nil", map[string]interface{}{"x": nil}, map[string]interface{}{"x": nil}, ParamOptions{}, true},
+		{
+			"dotted path",
+			map[string]interface{}{"filter.date.start": "2024-01-01"},
+			map[string]interface{}{"filter": map[string]interface{}{"date": map[string]interface{}{"start": "2024-01-01", "end": "2024-02-01"}}},
+			ParamOptions{},
+			true,
+		},
+		{
+			"dotted path into array",
+			map[string]interface{}{"items.1.id": 7},
+			map[string]interface{}{"items": []interface{}{map[string]interface{}{"id": 3.0}, map[string]interface{}{"id": 7.0}}},
+			ParamOptions{},
+			true,
+		},
+		{"dotted path missing", map[string]interface{}{"filter.date": "x"}, map[string]interface{}{"filter": "x"}, ParamOptions{}, false},
+		{
+			"dotted path exact",
+			map[string]interface{}{"filter.limit": 10, "q": "go"},
+			map[string]interface{}{"filter": map[string]interface{}{"limit": 10.0}, "q": "go"},
+			ParamOptions{Exact: true},
+			true,
+		},
+		{"wildcard", map[string]interface{}{"id": "*"}, map[string]interface{}{"id": 42.0}, ParamOptions{}, true},
+		{"wildcard requires key", map[string]interface{}{"id": "*"}, map[string]interface{}{}, ParamOptions{}, false},
+		{"regex", map[string]interface{}{"date": "regex:^2024-\\d{2}"}, map[string]interface{}{"date": "2024-03-15"}, ParamOptions{}, true},
+		{"regex mismatch", map[string]interface{}{"date": "regex:^2024-"}, map[string]interface{}{"date": "2023-03-15"}, ParamOptions{}, false},
+		{"regex on number", map[string]interface{}{"n": "regex:^4\\d$"}, map[string]interface{}{"n": 42.0}, ParamOptions{}, true},
+		{"regex ignore case", map[string]interface{}{"q": "regex:^golang"}, map[string]interface{}{"q": "GoLang tips"}, ParamOptions{IgnoreCase: true}, true},
+		{"invalid regex", map[string]interface{}{"q": "regex:("}, map[string]interface{}{"q": "("}, ParamOptions{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParamsMatch(tt.expected, tt.actual, tt.opts); got != tt.want {
+				t.Errorf("ParamsMatch() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
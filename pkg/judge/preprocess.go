@@ -0,0 +1,130 @@
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// Preprocessing steps accepted by Preprocess and the "preprocess" judge
+// option. Steps run in the order given.
+const (
+	StepTrim            = "trim"             // trim surrounding whitespace
+	StepStripFences     = "strip_fences"     // keep only the first ``` fenced block
+	StepExtractJSON     = "extract_json"     // keep only the first JSON object or array
+	StepTrimBoilerplate = "trim_boilerplate" // drop chatty lead-in and sign-off lines
+)
+
+var preprocessSteps = map[string]func(string) string{
+	StepTrim:            strings.TrimSpace,
+	StepStripFences:     stripFences,
+	StepExtractJSON:     extractJSON,
+	StepTrimBoilerplate: trimBoilerplate,
+}
+
+// Preprocess applies the named normalization steps to output. Models wrap
+// structured output in prose and markdown constantly; preprocessing lets
+// deterministic judges see just the payload.
+func Preprocess(output string, steps []string) (string, error) {
+	for _, name := range steps {
+		fn, ok := preprocessSteps[name]
+		if !ok {
+			return "", fmt.Errorf("unknown preprocess step %q", name)
+		}
+		output = fn(output)
+	}
+	return output, nil
+}
+
+// PreprocessJudge normalizes the output with Steps before handing it to
+// the wrapped judge. It reports the wrapped judge's name so composite
+// scoring is unaffected.
+type PreprocessJudge struct {
+	Judge Judge
+	Steps []string
+}
+
+// Name returns the wrapped judge's type identifier.
+func (j *PreprocessJudge) Name() string { return j.Judge.Name() }
+
+// GetUsage reports the wrapped judge's token usage, if it calls a model.
+func (j *PreprocessJudge) GetUsage() provider.Usage {
+	if ur, ok := j.Judge.(UsageReporter); ok {
+		return ur.GetUsage()
+	}
+	return provider.Usage{}
+}
+
+// ModelName reports the wrapped judge's model, if it calls one.
+func (j *PreprocessJudge) ModelName() string {
+	if ur, ok := j.Judge.(UsageReporter); ok {
+		return ur.ModelName()
+	}
+	return ""
+}
+
+// Evaluate preprocesses the output and evaluates the wrapped judge.
+func (j *PreprocessJudge) Evaluate(input Input) (Result, error) {
+	out, err := Preprocess(input.Output, j.Steps)
+	if err != nil {
+		return Result{}, err
+	}
+	input.Output = out
+	return j.Judge.Evaluate(input)
+}
+
+var fenceRe = regexp.MustCompile("(?s)```[^\\n`]*\\n(.*?)\\n?```")
+
+// stripFences returns the contents of the first fenced code block, or the
+// output unchanged when there is none.
+func stripFences(s string) string {
+	m := fenceRe.FindStringSubmatch(s)
+	if m == nil {
+		return s
+	}
+	return m[1]
+}
+
+// extractJSON returns the first complete JSON object or array embedded in
+// s, or s unchanged when none parses.
+func extractJSON(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] != '{' && s[i] != '[' {
+			continue
+		}
+		var raw json.RawMessage
+		if err := json.NewDecoder(strings.NewReader(s[i:])).Decode(&raw); err == nil {
+			return string(raw)
+		}
+	}
+	return s
+}
+
+var (
+	leadInRe  = regexp.MustCompile(`(?i)^(sure|certainly|of course|okay|ok|absolutely|here('s| is| are)|below is|the following)\b.*[:.!]$`)
+	signOffRe = regexp.MustCompile(`(?i)^(let me know|i hope|hope this|feel free|if you (have|need)|is there anything)\b`)
+)
+
+// trimBoilerplate drops conversational lead-in lines such as
+// "Here is the JSON:" and sign-offs such as "Let me know if ...".
+func trimBoilerplate(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	for len(lines) > 0 {
+		line := strings.TrimSpace(lines[0])
+		if line != "" && !leadInRe.MatchString(line) {
+			break
+		}
+		lines = lines[1:]
+	}
+	for len(lines) > 0 {
+		line := strings.TrimSpace(lines[len(lines)-1])
+		if line != "" && !signOffRe.MatchString(line) {
+			break
+		}
+		lines = lines[:len(lines)-1]
+	}
+	return strings.Join(lines, "\n")
+}
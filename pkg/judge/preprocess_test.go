@@ -0,0 +1,80 @@
+package judge
+
+import "testing"
+
+func TestPreprocess(t *testing.T) {
+	tests := []struct {
+		name   string
+		steps  []string
+		output string
+		want   string
+	}{
+		{
+			name:   "strip fences",
+			steps:  []string{StepStripFences},
+			output: "Sure:\n```json\n{\"a\": 1}\n```\nDone.",
+			want:   `{"a": 1}`,
+		},
+		{
+			name:   "no fence is unchanged",
+			steps:  []string{StepStripFences},
+			output: "plain text",
+			want:   "plain text",
+		},
+		{
+			name:   "extract first object",
+			steps:  []string{StepExtractJSON},
+			output: `The result is {"a": {"b": [1, 2]}} and {"c": 3}.`,
+			want:   `{"a": {"b": [1, 2]}}`,
+		},
+		{
+			name:   "extract skips unbalanced brace",
+			steps:  []string{StepExtractJSON},
+			output: `use {curly} then [1, 2]`,
+			want:   `[1, 2]`,
+		},
+		{
+			name:   "trim boilerplate",
+			steps:  []string{StepTrimBoilerplate},
+			output: "Here is the JSON:\n\n{\"a\": 1}\n\nLet me know if you need anything else!",
+			want:   `{"a": 1}`,
+		},
+		{
+			name:   "steps compose",
+			steps:  []string{StepTrimBoilerplate, StepTrim},
+			output: "Certainly! Here you go.\n  42  ",
+			want:   "42",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Preprocess(tt.output, tt.steps)
+			if err != nil {
+				t.Fatalf("Preprocess() error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("Preprocess() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := Preprocess("x", []string{"bogus"}); err == nil {
+		t.Error("expected error for unknown step")
+	}
+}
+
+func TestPreprocessJudge(t *testing.T) {
+	j := &PreprocessJudge{Judge: &ExactJudge{}, Steps: []string{StepStripFences}}
+	if j.Name() != "exact" {
+		t.Errorf("Name() = %q, want exact", j.Name())
+	}
+
+	r, err := j.Evaluate(Input{Output: "```\nhello\n```", ExpectedOutput: "hello"})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+}
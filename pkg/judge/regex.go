@@ -1,36 +1,173 @@
 package judge
 
 import (
+	"bytes"
 	"fmt"
 	"regexp"
+	"sort"
+	"strings"
+	"text/template"
 )
 
-// RegexJudge matches agent output against a regular expression pattern.
+// RegexJudge matches agent output against one or more regular expression
+// patterns. Captures compares named capture groups to expected values;
+// each expected value is a Go template rendered against Input.Vars, so
+// `{{.expected_id}}` refers to the case input "expected_id".
 type RegexJudge struct {
 	Pattern string `json:"pattern" yaml:"pattern"`
+
+	// Patterns lists further patterns checked alongside Pattern.
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns"`
+
+	// Match is "all" (default) to require every pattern, or "any" to
+	// require at least one.
+	Match string `json:"match,omitempty" yaml:"match"`
+
+	// Captures maps a named capture group to its expected value.
+	Captures map[string]string `json:"captures,omitempty" yaml:"captures"`
 }
 
 // Name returns the judge type identifier.
 func (j *RegexJudge) Name() string { return "regex" }
 
-// Evaluate checks if the output matches the configured regex pattern.
+// Evaluate checks the output against the configured patterns and captures.
+// The score is the fraction of checks that passed: one per pattern in
+// "all" mode (a single check in "any" mode) plus one per capture.
 func (j *RegexJudge) Evaluate(input Input) (Result, error) {
-	re, err := regexp.Compile(j.Pattern)
-	if err != nil {
-		return Result{}, fmt.Errorf("invalid regex pattern %q: %w", j.Pattern, err)
+	patterns := j.Patterns
+	if j.Pattern != "" || len(j.Patterns) == 0 {
+		patterns = append([]string{j.Pattern}, j.Patterns...)
+	}
+
+	mode := j.Match
+	if mode == "" {
+		mode = "all"
+	}
+	if mode != "all" && mode != "any" {
+		return Result{}, fmt.Errorf("invalid regex match mode %q (want all or any)", j.Match)
+	}
+
+	res := make([]*regexp.Regexp, len(patterns))
+	for i, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return Result{}, fmt.Errorf("invalid regex pattern %q: %w", p, err)
+		}
+		res[i] = re
+	}
+
+	// Simple single-pattern form keeps its original reasons.
+	if len(res) == 1 && len(j.Captures) == 0 {
+		if res[0].MatchString(input.Output) {
+			return Result{
+				Pass:   true,
+				Score:  1.0,
+				Reason: fmt.Sprintf("output matches pattern %q", patterns[0]),
+			}, nil
+		}
+		return Result{
+			Pass:   false,
+			Score:  0.0,
+			Reason: fmt.Sprintf("output does not match pattern %q", patterns[0]),
+		}, nil
+	}
+
+	var failures []string
+	passed, total := 0, 0
+
+	matches := make([][]string, len(res))
+	var unmatched []string
+	for i, re := range res {
+		matches[i] = re.FindStringSubmatch(input.Output)
+		if matches[i] == nil {
+			unmatched = append(unmatched, patterns[i])
+		}
+	}
+	switch mode {
+	case "all":
+		total += len(res)
+		passed += len(res) - len(unmatched)
+		for _, p := range unmatched {
+			failures = append(failures, fmt.Sprintf("no match for %q", p))
+		}
+	case "any":
+		total++
+		if len(unmatched) < len(res) {
+			passed++
+		} else {
+			failures = append(failures, "no pattern matched")
+		}
+	}
+
+	names := make([]string, 0, len(j.Captures))
+	for name := range j.Captures {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		total++
+		want, err := renderExpected(name, j.Captures[name], input.Vars)
+		if err != nil {
+			return Result{}, err
+		}
+		got, defined, captured := findCapture(res, matches, name)
+		if !defined {
+			return Result{}, fmt.Errorf("capture group %q is not defined by any pattern", name)
+		}
+		switch {
+		case !captured:
+			failures = append(failures, fmt.Sprintf("group %q not captured", name))
+		case got != want:
+			failures = append(failures, fmt.Sprintf("group %q = %q, want %q", name, got, want))
+		default:
+			passed++
+		}
 	}
 
-	if re.MatchString(input.Output) {
+	if len(failures) == 0 {
 		return Result{
 			Pass:   true,
 			Score:  1.0,
-			Reason: fmt.Sprintf("output matches pattern %q", j.Pattern),
+			Reason: fmt.Sprintf("output satisfies %d regex checks", total),
 		}, nil
 	}
-
 	return Result{
 		Pass:   false,
-		Score:  0.0,
-		Reason: fmt.Sprintf("output does not match pattern %q", j.Pattern),
+		Score:  float64(passed) / float64(total),
+		Reason: strings.Join(failures, "; "),
 	}, nil
 }
+
+// findCapture returns the value of the named group from the first matching
+// pattern that defines it. defined reports whether any pattern declares the
+// group at all.
+func findCapture(res []*regexp.Regexp, matches [][]string, name string) (value string, defined, captured bool) {
+	for i, re := range res {
+		idx := re.SubexpIndex(name)
+		if idx < 0 {
+			continue
+		}
+		defined = true
+		if matches[i] != nil {
+			return matches[i][idx], true, true
+		}
+	}
+	return "", defined, false
+}
+
+// renderExpected renders an expected capture value against the case
+// variables. Undefined variables are an error rather than an empty string.
+func renderExpected(name, text string, vars map[string]interface{}) (string, error) {
+	if !strings.Contains(text, "{{") {
+		return text, nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("parsing expected value for group %q: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, vars); err != nil {
+		return "", fmt.Errorf("rendering expected value for group %q: %w", name, err)
+	}
+	return buf.String(), nil
+}
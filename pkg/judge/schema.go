@@ -2,8 +2,10 @@ package judge
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
 	"github.com/santhosh-tekuri/jsonschema/v6"
 )
 
@@ -54,3 +56,20 @@ func (j *SchemaJudge) Evaluate(input Input) (Result, error) {
 		Reason: "output matches JSON schema",
 	}, nil
 }
+
+// ValidateOutput checks output against each schema judge configured for c
+// and returns the first violation. It is meant for
+// runner.Config.ValidateOutput; schemas that fail to compile are left for
+// the judge to report.
+func ValidateOutput(c suite.EvalCase, output string) error {
+	for _, jc := range c.Judges {
+		if jc.Type != "schema" {
+			continue
+		}
+		res, err := (&SchemaJudge{Schema: jc.Value}).Evaluate(Input{Output: output})
+		if err == nil && !res.Pass {
+			return errors.New(res.Reason)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package judge
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+var (
+	timeType       = reflect.TypeOf(time.Time{})
+	rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+	marshalerType  = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+)
+
+// SchemaFromStruct derives a JSON Schema from the Go type of v, which is
+// typically a struct value or pointer. Property names follow the type's
+// json tags the same way encoding/json does: fields tagged "-" and
+// unexported fields are skipped, embedded structs are flattened, and every
+// field without "omitempty" is required. Required pointer, slice, and map
+// fields also allow null, which is how encoding/json writes them when nil.
+// Recursive types are cut off with an unconstrained object schema.
+//
+// The result is suitable for SchemaJudge.Schema.
+func SchemaFromStruct(v any) (string, error) {
+	if v == nil {
+		return "", fmt.Errorf("cannot derive schema from nil")
+	}
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return "", fmt.Errorf("cannot derive schema from %s: not a struct", t)
+	}
+
+	schema, err := typeSchema(t, map[reflect.Type]bool{})
+	if err != nil {
+		return "", err
+	}
+	schema["$schema"] = "https://json-schema.org/draft/2020-12/schema"
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return "", fmt.Errorf("encoding schema: %w", err)
+	}
+	return string(data), nil
+}
+
+func typeSchema(t reflect.Type, visiting map[reflect.Type]bool) (map[string]any, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t {
+	case timeType:
+		return map[string]any{"type": "string", "format": "date-time"}, nil
+	case rawMessageType:
+		return map[string]any{}, nil
+	}
+	if t.Implements(marshalerType) || reflect.PointerTo(t).Implements(marshalerType) {
+		// Custom encodings can produce anything.
+		return map[string]any{}, nil
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}, nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]any{"type": "integer"}, nil
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}, nil
+	case reflect.String:
+		return map[string]any{"type": "string"}, nil
+	case reflect.Interface:
+		return map[string]any{}, nil
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes byte slices as base64 strings.
+			return map[string]any{"type": "string"}, nil
+		}
+		items, err := typeSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "array", "items": items}, nil
+	case reflect.Map:
+		if t.Key().Kind() != reflect.String {
+			return nil, fmt.Errorf("unsupported map key type %s", t.Key())
+		}
+		values, err := typeSchema(t.Elem(), visiting)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"type": "object", "additionalProperties": values}, nil
+	case reflect.Struct:
+		if visiting[t] {
+			return map[string]any{"type": "object"}, nil
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		props := map[string]any{}
+		required := []string{}
+		if err := structFields(t, props, &required, visiting); err != nil {
+			return nil, err
+		}
+		schema := map[string]any{"type": "object", "properties": props}
+		if len(required) > 0 {
+			schema["required"] = required
+		}
+		return schema, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %s", t)
+	}
+}
+
+// structFields adds the JSON properties of struct type t to props,
+// flattening embedded structs without a json name.
+func structFields(t reflect.Type, props map[string]any, required *[]string, visiting map[reflect.Type]bool) error {
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		tag := f.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		if f.Anonymous && name == "" {
+			ft := f.Type
+			if ft.Kind() == reflect.Pointer {
+				ft = ft.Elem()
+			}
+			if ft.Kind() == reflect.Struct {
+				if err := structFields(ft, props, required, visiting); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+		if !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		fs, err := typeSchema(f.Type, visiting)
+		if err != nil {
+			return fmt.Errorf("field %s: %w", f.Name, err)
+		}
+		if hasOpt(opts, "string") {
+			// The ",string" option quotes scalar values.
+			switch fs["type"] {
+			case "integer", "number", "boolean":
+				fs = map[string]any{"type": "string"}
+			}
+		}
+		if !hasOpt(opts, "omitempty") && !hasOpt(opts, "omitzero") {
+			*required = append(*required, name)
+			if typ, ok := fs["type"].(string); ok && nillable(f.Type) {
+				fs["type"] = []any{typ, "null"}
+			}
+		}
+		props[name] = fs
+	}
+	return nil
+}
+
+// nillable reports whether encoding/json writes the zero value of t as
+// null.
+func nillable(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true
+	}
+	return false
+}
+
+// hasOpt reports whether a comma-separated json tag option list contains opt.
+func hasOpt(opts, opt string) bool {
+	for _, o := range strings.Split(opts, ",") {
+		if o == opt {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package judge
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type schemaAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip,omitempty"`
+}
+
+type schemaBase struct {
+	ID int `json:"id"`
+}
+
+type schemaOrder struct {
+	schemaBase
+	Customer  string          `json:"customer"`
+	Total     float64         `json:"total"`
+	Paid      bool            `json:"paid"`
+	Items     []string        `json:"items"`
+	Address   *schemaAddress  `json:"address,omitempty"`
+	Meta      map[string]int  `json:"meta,omitempty"`
+	Quantity  int64           `json:"quantity,string"`
+	CreatedAt time.Time       `json:"created_at"`
+	Raw       json.RawMessage `json:"raw,omitempty"`
+	Internal  string          `json:"-"`
+	secret    string          //nolint:unused
+	Untagged  string
+	Extra     map[string]string `json:"extra,omitzero"`
+	Note      *string           `json:"note"`
+}
+
+type schemaNode struct {
+	Value    string        `json:"value"`
+	Children []*schemaNode `json:"children,omitempty"`
+}
+
+func TestSchemaFromStruct(t *testing.T) {
+	doc, err := SchemaFromStruct(&schemaOrder{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct() error: %v", err)
+	}
+
+	var schema struct {
+		Type       string                    `json:"type"`
+		Properties map[string]map[string]any `json:"properties"`
+		Required   []string                  `json:"required"`
+	}
+	if err := json.Unmarshal([]byte(doc), &schema); err != nil {
+		t.Fatalf("schema is not valid JSON: %v", err)
+	}
+	if schema.Type != "object" {
+		t.Errorf("type = %q, want object", schema.Type)
+	}
+
+	wantTypes := map[string]any{
+		"id":         "integer",
+		"customer":   "string",
+		"total":      "number",
+		"paid":       "boolean",
+		"items":      []any{"array", "null"},
+		"address":    "object",
+		"meta":       "object",
+		"quantity":   "string",
+		"created_at": "string",
+		"Untagged":   "string",
+		"extra":      "object",
+		"note":       []any{"string", "null"},
+	}
+	for name, want := range wantTypes {
+		if got := schema.Properties[name]["type"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("properties[%q].type = %v, want %v", name, got, want)
+		}
+	}
+	for _, skipped := range []string{"Internal", "-", "secret", "schemaBase"} {
+		if _, ok := schema.Properties[skipped]; ok {
+			t.Errorf("property %q should be skipped", skipped)
+		}
+	}
+
+	wantRequired := []string{"id", "customer", "total", "paid", "items", "quantity", "created_at", "Untagged", "note"}
+	if !reflect.DeepEqual(schema.Required, wantRequired) {
+		t.Errorf("required = %v, want %v", schema.Required, wantRequired)
+	}
+}
+
+func TestSchemaFromStruct_Recursive(t *testing.T) {
+	if _, err := SchemaFromStruct(schemaNode{}); err != nil {
+		t.Fatalf("SchemaFromStruct() error: %v", err)
+	}
+}
+
+func TestSchemaFromStruct_Errors(t *testing.T) {
+	for _, v := range []any{nil, "not a struct", struct{ M map[int]string }{}} {
+		if _, err := SchemaFromStruct(v); err == nil {
+			t.Errorf("SchemaFromStruct(%T) expected error", v)
+		}
+	}
+}
+
+func TestSchemaFromStruct_WithSchemaJudge(t *testing.T) {
+	doc, err := SchemaFromStruct(schemaAddress{})
+	if err != nil {
+		t.Fatalf("SchemaFromStruct() error: %v", err)
+	}
+	j := &SchemaJudge{Schema: doc}
+
+	r, err := j.Evaluate(Input{Output: `{"city": "Berlin"}`})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if !r.Pass {
+		t.Errorf("expected pass, got fail: %s", r.Reason)
+	}
+
+	r, err = j.Evaluate(Input{Output: `{"zip": "10115"}`})
+	if err != nil {
+		t.Fatalf("Evaluate() error: %v", err)
+	}
+	if r.Pass {
+		t.Error("expected fail when required city is missing")
+	}
+}
@@ -0,0 +1,141 @@
+package judge
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+var (
+	// sentenceEnd splits text after terminal punctuation followed by
+	// whitespace, so decimals and dotted names stay within one sentence.
+	sentenceEnd = regexp.MustCompile(`[.!?]+(?:\s+|$)`)
+	// bulletMarker matches the marker of a "-", "*", "+", "•", or numbered
+	// list item.
+	bulletMarker = regexp.MustCompile(`^\s*(?:[-*+•]|\d+[.)])\s+`)
+)
+
+// StyleJudge checks output against readability and length constraints:
+// word, sentence, and bullet counts, and phrases the output must not
+// contain. Each configured limit is one check; the score is the fraction
+// that passed.
+type StyleJudge struct {
+	Rules suite.StyleRules
+}
+
+// Name returns the judge type identifier.
+func (j *StyleJudge) Name() string { return "style" }
+
+// Evaluate counts words, sentences, and bullet items in the output and
+// compares them with the configured limits.
+func (j *StyleJudge) Evaluate(input Input) (Result, error) {
+	r := j.Rules
+	words := len(strings.Fields(input.Output))
+	sentences := countSentences(input.Output)
+	bullets := countBullets(input.Output)
+
+	var failures []string
+	total := 0
+	check := func(ok bool, format string, args ...interface{}) {
+		total++
+		if !ok {
+			failures = append(failures, fmt.Sprintf(format, args...))
+		}
+	}
+
+	if r.MinWords > 0 {
+		check(words >= r.MinWords, "%d words, want at least %d", words, r.MinWords)
+	}
+	if r.MaxWords > 0 {
+		check(words <= r.MaxWords, "%d words, want at most %d", words, r.MaxWords)
+	}
+	if r.MinSentences > 0 {
+		check(sentences >= r.MinSentences, "%d sentences, want at least %d", sentences, r.MinSentences)
+	}
+	if r.MaxSentences > 0 {
+		check(sentences <= r.MaxSentences, "%d sentences, want at most %d", sentences, r.MaxSentences)
+	}
+	if r.MinBullets > 0 {
+		check(bullets >= r.MinBullets, "%d bullet items, want at least %d", bullets, r.MinBullets)
+	}
+	if r.MaxBullets != nil {
+		check(bullets <= *r.MaxBullets, "%d bullet items, want at most %d", bullets, *r.MaxBullets)
+	}
+	lower := strings.ToLower(input.Output)
+	for _, phrase := range r.ForbiddenPhrases {
+		check(!strings.Contains(lower, strings.ToLower(phrase)), "contains forbidden phrase %q", phrase)
+	}
+
+	if len(failures) == 0 {
+		return Result{
+			Pass:   true,
+			Score:  1.0,
+			Reason: fmt.Sprintf("output satisfies %d style checks", total),
+		}, nil
+	}
+	return Result{
+		Pass:   false,
+		Score:  float64(total-len(failures)) / float64(total),
+		Reason: strings.Join(failures, "; "),
+	}, nil
+}
+
+// countSentences counts the non-blank runs of text between terminal
+// punctuation. Bullet items and other lines are split as well, so an
+// unpunctuated list item counts as one sentence.
+func countSentences(s string) int {
+	return len(splitSentences(s))
+}
+
+// splitSentences splits s into sentences as countSentences counts them,
+// with list markers removed.
+func splitSentences(s string) []string {
+	var out []string
+	for _, line := range strings.Split(s, "\n") {
+		line = bulletMarker.ReplaceAllString(line, "")
+		for _, part := range sentenceEnd.Split(line, -1) {
+			if part = strings.TrimSpace(part); part != "" {
+				out = append(out, part)
+			}
+		}
+	}
+	return out
+}
+
+// countBullets counts the lines that are list items.
+func countBullets(s string) int {
+	n := 0
+	for _, line := range strings.Split(s, "\n") {
+		if loc := bulletMarker.FindStringIndex(line); loc != nil && strings.TrimSpace(line[loc[1]:]) != "" {
+			n++
+		}
+	}
+	return n
+}
+
+// validateStyle rejects style rules that check nothing or can never pass.
+func validateStyle(r *suite.StyleRules) error {
+	if r == nil {
+		return fmt.Errorf("no style rules")
+	}
+	if r.MinWords < 0 || r.MaxWords < 0 || r.MinSentences < 0 || r.MaxSentences < 0 ||
+		r.MinBullets < 0 || (r.MaxBullets != nil && *r.MaxBullets < 0) {
+		return fmt.Errorf("limits must not be negative")
+	}
+	if r.MaxWords > 0 && r.MinWords > r.MaxWords {
+		return fmt.Errorf("min_words %d exceeds max_words %d", r.MinWords, r.MaxWords)
+	}
+	if r.MaxSentences > 0 && r.MinSentences > r.MaxSentences {
+		return fmt.Errorf("min_sentences %d exceeds max_sentences %d", r.MinSentences, r.MaxSentences)
+	}
+	if r.MaxBullets != nil && r.MinBullets > *r.MaxBullets {
+		return fmt.Errorf("min_bullets %d exceeds max_bullets %d", r.MinBullets, *r.MaxBullets)
+	}
+	if r.MinWords == 0 && r.MaxWords == 0 && r.MinSentences == 0 && r.MaxSentences == 0 &&
+		r.MinBullets == 0 && r.MaxBullets == nil && len(r.ForbiddenPhrases) == 0 {
+		return fmt.Errorf("no style rules")
+	}
+	return nil
+}
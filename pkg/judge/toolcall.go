@@ -3,20 +3,28 @@ package judge
 import (
 	"fmt"
 	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
 )
 
 // ExpectedToolCall describes a tool call assertion for the ToolCallJudge.
+// Parameters are matched with ParamsMatch, so keys may be dotted paths
+// into nested arguments and values may be "*" or "regex:" patterns.
 type ExpectedToolCall struct {
 	ToolName   string                 `json:"tool_name" yaml:"tool_name"`
 	Parameters map[string]interface{} `json:"parameters,omitempty" yaml:"parameters,omitempty"`
 	Negate     bool                   `json:"negate,omitempty" yaml:"negate,omitempty"`
-	MatchMode  string                 `json:"match_mode,omitempty" yaml:"match_mode,omitempty"` // "exact" or "subset" (default: "subset")
+	MatchMode  string                 `json:"match_mode,omitempty" yaml:"match_mode,omitempty"`   // "exact" or "subset" (default: "subset")
+	Tolerance  float64                `json:"tolerance,omitempty" yaml:"tolerance,omitempty"`     // allowed absolute difference between numbers
+	IgnoreCase bool                   `json:"ignore_case,omitempty" yaml:"ignore_case,omitempty"` // compare strings case-insensitively
 }
 
 // ToolCallJudge asserts that expected tool calls were made (or not made)
-// in order, with parameter matching.
+// in order, with parameter matching, and that the calls satisfy any
+// Constraints.
 type ToolCallJudge struct {
-	Expected []ExpectedToolCall `json:"expected" yaml:"expected"`
+	Expected    []ExpectedToolCall     `json:"expected" yaml:"expected"`
+	Constraints []suite.ToolConstraint `json:"constraints,omitempty" yaml:"constraints,omitempty"`
 }
 
 // Name returns the judge type identifier.
@@ -56,7 +64,7 @@ func (j *ToolCallJudge) Evaluate(input Input) (Result, error) {
 		for callIdx < len(input.ToolCalls) {
 			call := input.ToolCalls[callIdx]
 			callIdx++
-			if call.ToolName == exp.ToolName && paramsMatch(exp.Parameters, call.Parameters, exp.MatchMode) {
+			if call.ToolName == exp.ToolName && paramsMatch(exp, call.Parameters) {
 				found = true
 				break
 			}
@@ -66,6 +74,10 @@ func (j *ToolCallJudge) Evaluate(input Input) (Result, error) {
 		}
 	}
 
+	for _, c := range j.Constraints {
+		failures = append(failures, checkConstraint(c, input)...)
+	}
+
 	if len(failures) == 0 {
 		return Result{
 			Pass:   true,
@@ -81,42 +93,114 @@ func (j *ToolCallJudge) Evaluate(input Input) (Result, error) {
 	}, nil
 }
 
-// paramsMatch checks whether actual parameters satisfy expected parameters.
-// In "exact" mode, the maps must have identical keys and values.
-// In "subset" mode (default), every key in expected must be present in actual
-// with the same value, but actual may have additional keys.
-func paramsMatch(expected, actual map[string]interface{}, mode string) bool {
-	if len(expected) == 0 {
+// paramsMatch checks whether actual parameters satisfy the expectation's
+// parameters. In "exact" mode, maps must have identical keys at every
+// level. In "subset" mode (default), every expected key must be present in
+// actual with a matching value, but actual may have additional keys.
+func paramsMatch(exp ExpectedToolCall, actual map[string]interface{}) bool {
+	if len(exp.Parameters) == 0 {
 		return true
 	}
+	return ParamsMatch(exp.Parameters, actual, ParamOptions{
+		Exact:      exp.MatchMode == "exact",
+		Tolerance:  exp.Tolerance,
+		IgnoreCase: exp.IgnoreCase,
+	})
+}
 
-	if mode == "exact" {
-		return mapsEqual(expected, actual)
+// validateConstraints checks that each constraint has a known type and the
+// tools it needs.
+func validateConstraints(constraints []suite.ToolConstraint) error {
+	for i, c := range constraints {
+		switch c.Type {
+		case "before":
+			if len(c.Tools) < 2 {
+				return fmt.Errorf("constraint %d: before requires at least 2 tools", i)
+			}
+		case "unordered":
+			if len(c.Tools) == 0 {
+				return fmt.Errorf("constraint %d: unordered requires tools", i)
+			}
+		case "count":
+			if len(c.Tools) == 0 {
+				return fmt.Errorf("constraint %d: count requires tools", i)
+			}
+			if c.Count < 0 {
+				return fmt.Errorf("constraint %d: count must be >= 0, got %d", i, c.Count)
+			}
+		case "no_calls_after_answer":
+		default:
+			return fmt.Errorf("constraint %d: unknown type %q", i, c.Type)
+		}
 	}
-
-	// Default: subset match.
-	return isSubset(expected, actual)
+	return nil
 }
 
-func mapsEqual(a, b map[string]interface{}) bool {
-	if len(a) != len(b) {
-		return false
+// checkConstraint returns a failure message for each way the tool calls
+// in input violate c.
+func checkConstraint(c suite.ToolConstraint, input Input) []string {
+	var failures []string
+	switch c.Type {
+	case "before":
+		for i := 0; i+1 < len(c.Tools); i++ {
+			a, b := c.Tools[i], c.Tools[i+1]
+			firstA, firstB := firstCall(input, a), firstCall(input, b)
+			if firstB >= 0 && (firstA < 0 || firstA > firstB) {
+				failures = append(failures, fmt.Sprintf("tool %q must be called before %q", a, b))
+			}
+		}
+	case "unordered":
+		for _, name := range c.Tools {
+			if firstCall(input, name) < 0 {
+				failures = append(failures, fmt.Sprintf("tool %q was not called", name))
+			}
+		}
+	case "count":
+		for _, name := range c.Tools {
+			n := 0
+			for _, call := range input.ToolCalls {
+				if call.ToolName == name {
+					n++
+				}
+			}
+			if n != c.Count {
+				failures = append(failures, fmt.Sprintf("tool %q called %d times, want exactly %d", name, n, c.Count))
+			}
+		}
+	case "no_calls_after_answer":
+		if n := callsAfterAnswer(input); n > 0 {
+			failures = append(failures, fmt.Sprintf("%d tool calls made after the final answer was given", n))
+		}
 	}
-	for k, v := range a {
-		bv, ok := b[k]
-		if !ok || fmt.Sprintf("%v", v) != fmt.Sprintf("%v", bv) {
-			return false
+	return failures
+}
+
+// firstCall returns the index of the first call to name, or -1.
+func firstCall(input Input, name string) int {
+	for i, call := range input.ToolCalls {
+		if call.ToolName == name {
+			return i
 		}
 	}
-	return true
+	return -1
 }
 
-func isSubset(subset, superset map[string]interface{}) bool {
-	for k, v := range subset {
-		sv, ok := superset[k]
-		if !ok || fmt.Sprintf("%v", v) != fmt.Sprintf("%v", sv) {
-			return false
+// callsAfterAnswer counts tool results recorded after the first assistant
+// message that already contained the final output.
+func callsAfterAnswer(input Input) int {
+	answer := strings.TrimSpace(input.Output)
+	if answer == "" {
+		return 0
+	}
+	answered := false
+	n := 0
+	for _, msg := range input.Messages {
+		switch {
+		case !answered && msg.Role == "assistant" && strings.TrimSpace(msg.Content) == answer:
+			answered = true
+		case answered && msg.Role == "tool":
+			n++
 		}
 	}
-	return true
+	return n
 }
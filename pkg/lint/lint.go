@@ -0,0 +1,159 @@
+// Package lint flags suite configurations that load and run but cannot
+// tell good agent output from bad: judges that pass trivially or never
+// matter, rubrics too short to grade against, and cases nothing judges.
+package lint
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+// Checks reported in Finding.Check.
+const (
+	CheckNoJudges        = "no-judges"
+	CheckTrivialContains = "trivial-contains"
+	CheckInertJudge      = "inert-judge"
+	CheckShortRubric     = "short-rubric"
+)
+
+// MinRubricWords is the fewest words an llm or agent judge's rubric may
+// have before it is flagged as too vague to grade against.
+const MinRubricWords = 10
+
+// maxWeightedJudges bounds the judges per case the inert-judge check
+// examines, since it enumerates every pass/fail combination of the rest.
+const maxWeightedJudges = 16
+
+// Finding is one suspicious configuration in a case.
+type Finding struct {
+	Suite   string
+	Case    string
+	Source  string // file:line of the case, when known
+	Check   string
+	Message string
+}
+
+func (f Finding) String() string {
+	s := fmt.Sprintf("%s/%s: %s (%s)", f.Suite, f.Case, f.Message, f.Check)
+	if f.Source != "" {
+		s = f.Source + ": " + s
+	}
+	return s
+}
+
+// Suite lints the cases of s, whose judges are those in effect after
+// loading. pv is the prompt the cases run with; each case renders it with
+// its input and opts to find contains judges the prompt already satisfies.
+// With a nil pv, or for a case whose prompt does not render, that check is
+// skipped.
+func Suite(s *suite.EvalSuite, pv *prompt.PromptVariant, opts ...prompt.InterpolateOption) []Finding {
+	var out []Finding
+	for _, c := range s.Cases {
+		add := func(check, format string, args ...interface{}) {
+			out = append(out, Finding{
+				Suite:   s.Name,
+				Case:    c.Name,
+				Source:  c.Source,
+				Check:   check,
+				Message: fmt.Sprintf(format, args...),
+			})
+		}
+
+		if len(c.Judges) == 0 {
+			add(CheckNoJudges, "case has no judges, so its result says nothing about the output")
+			continue
+		}
+
+		promptText, rendered := renderCase(c, pv, opts)
+		for i, jc := range c.Judges {
+			switch jc.Type {
+			case "contains":
+				if jc.Value == "" {
+					add(CheckTrivialContains, "judge %d: contains judge has an empty value and passes any output", i+1)
+				} else if rendered && strings.Contains(promptText, jc.Value) {
+					add(CheckTrivialContains, "judge %d: contains value %q appears verbatim in the prompt, so echoing the input passes", i+1, jc.Value)
+				}
+			case "llm", "agent":
+				if n := len(strings.Fields(jc.Value)); n < MinRubricWords {
+					add(CheckShortRubric, "judge %d: %s rubric has %d words; under %d leaves the grader guessing", i+1, jc.Type, n, MinRubricWords)
+				}
+			}
+		}
+
+		for _, i := range inertJudges(c.Judges, judge.NewCompositeScorer(c.Threshold).Threshold) {
+			add(CheckInertJudge, "judge %d: %s judge's weight %g can never change pass/fail; the other judges decide every outcome",
+				i+1, c.Judges[i].Type, weight(c.Judges[i]))
+		}
+	}
+	return out
+}
+
+// renderCase returns the system and user prompt c's agent is sent, and
+// whether it could be rendered.
+func renderCase(c suite.EvalCase, pv *prompt.PromptVariant, opts []prompt.InterpolateOption) (string, bool) {
+	if pv == nil {
+		return "", false
+	}
+	vars, err := prompt.ExpandEnv(c.Input, opts...)
+	if err != nil {
+		return "", false
+	}
+	r, err := pv.Interpolate(vars, opts...)
+	if err != nil {
+		return "", false
+	}
+	return r.System + "\n" + r.User, true
+}
+
+// weight returns the weight composite scoring gives the judge.
+func weight(jc suite.JudgeConfig) float64 {
+	if jc.Weight == 0 {
+		return 1
+	}
+	return jc.Weight
+}
+
+// inertJudges returns the indexes of judges whose result never changes
+// whether the weighted average of scores reaches threshold, treating each
+// judge as scoring 0 or 1. That holds when no combination of the other
+// judges' results sits close enough below the threshold for this judge's
+// weight to carry it over. A judge with a min_score is never inert, since
+// failing it fails the case.
+func inertJudges(judges []suite.JudgeConfig, threshold float64) []int {
+	if len(judges) < 2 || len(judges) > maxWeightedJudges {
+		return nil
+	}
+	var total float64
+	for _, jc := range judges {
+		total += weight(jc)
+	}
+	passes := func(sum float64) bool { return sum/total >= threshold }
+
+	var inert []int
+	for i, jc := range judges {
+		sums := []float64{0}
+		for k, other := range judges {
+			if k == i {
+				continue
+			}
+			for _, s := range sums {
+				sums = append(sums, s+weight(other))
+			}
+		}
+		matters := false
+		for _, s := range sums {
+			if passes(s) != passes(s+weight(jc)) {
+				matters = true
+				break
+			}
+		}
+		if !matters && jc.MinScore == 0 {
+			inert = append(inert, i)
+		}
+	}
+	return inert
+}
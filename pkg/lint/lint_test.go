@@ -0,0 +1,99 @@
+package lint
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
+	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+)
+
+func checks(findings []Finding) map[string][]string {
+	out := make(map[string][]string)
+	for _, f := range findings {
+		out[f.Case] = append(out[f.Case], f.Check)
+	}
+	return out
+}
+
+func TestSuite(t *testing.T) {
+	s := &suite.EvalSuite{
+		Name: "support",
+		Cases: []suite.EvalCase{
+			{Name: "clean", Input: map[string]interface{}{"question": "Where is my order?"}, Judges: []suite.JudgeConfig{
+				{Type: "contains", Value: "tracking number"},
+				{Type: "llm", Value: "Pass if the reply tells the customer how to find their tracking number."},
+			}},
+			{Name: "unjudged", Source: "suites/support.yaml:12"},
+			{Name: "echo", Input: map[string]interface{}{"question": "Cancel order 1234"}, Judges: []suite.JudgeConfig{
+				{Type: "contains", Value: "1234"},
+				{Type: "contains"},
+			}},
+			{Name: "vague", Judges: []suite.JudgeConfig{{Type: "llm", Value: "Is it good?"}}},
+			{Name: "outweighed", Judges: []suite.JudgeConfig{
+				{Type: "schema", Value: "{}", Weight: 10},
+				{Type: "contains", Value: "refund", Weight: 1},
+			}},
+		},
+	}
+	pv := &prompt.PromptVariant{Name: "p", System: "You are a support agent.", User: "{{.question}}"}
+
+	got := checks(Suite(s, pv))
+	want := map[string][]string{
+		"unjudged":   {CheckNoJudges},
+		"echo":       {CheckTrivialContains, CheckTrivialContains},
+		"vague":      {CheckShortRubric},
+		"outweighed": {CheckInertJudge},
+	}
+	for name, w := range want {
+		if !slices.Equal(got[name], w) {
+			t.Errorf("case %s: checks = %v, want %v", name, got[name], w)
+		}
+	}
+	if len(got["clean"]) != 0 {
+		t.Errorf("case clean: checks = %v, want none", got["clean"])
+	}
+
+	// Without the prompt, contains values cannot be checked against it.
+	if c := checks(Suite(s, nil))["echo"]; !slices.Equal(c, []string{CheckTrivialContains}) {
+		t.Errorf("without prompt: echo checks = %v, want only the empty value", c)
+	}
+}
+
+func TestInertJudges(t *testing.T) {
+	tests := []struct {
+		name    string
+		weights []float64
+		want    []int
+	}{
+		{name: "equal weights", weights: []float64{1, 1, 1}},
+		{name: "one dominant", weights: []float64{10, 1}, want: []int{1}},
+		{name: "two small outvoted", weights: []float64{5, 1, 1}, want: []int{1, 2}},
+		{name: "small judges together matter", weights: []float64{2, 1, 1, 1}},
+		{name: "single judge", weights: []float64{0.1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			judges := make([]suite.JudgeConfig, len(tt.weights))
+			for i, w := range tt.weights {
+				judges[i] = suite.JudgeConfig{Type: "contains", Weight: w}
+			}
+			if got := inertJudges(judges, 0.5); !slices.Equal(got, tt.want) {
+				t.Errorf("inertJudges(%v) = %v, want %v", tt.weights, got, tt.want)
+			}
+		})
+	}
+
+	gated := []suite.JudgeConfig{{Type: "llm", Weight: 10}, {Type: "contains", Weight: 1, MinScore: 1}}
+	if got := inertJudges(gated, 0.5); len(got) != 0 {
+		t.Errorf("inertJudges with a min_score judge = %v, want none", got)
+	}
+}
+
+func TestFindingString(t *testing.T) {
+	f := Finding{Suite: "s", Case: "c", Source: "suites/s.yaml:4", Check: CheckNoJudges, Message: "case has no judges"}
+	if got := f.String(); !strings.HasPrefix(got, "suites/s.yaml:4: s/c: ") || !strings.HasSuffix(got, "(no-judges)") {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,96 @@
+package mock
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// z95 is the standard normal quantile at the 95th percentile.
+const z95 = 1.6448536269514722
+
+// Latency describes a tool's response time as a distribution rather than a
+// fixed sleep. Each call samples from a log-normal distribution fitted so
+// its median is P50 and its 95th percentile is P95, which matches the long
+// right tail of real tool latency.
+type Latency struct {
+	P50 time.Duration `yaml:"p50" json:"p50"`
+	P95 time.Duration `yaml:"p95" json:"p95"`
+}
+
+// Validate checks that the percentiles are positive and ordered.
+func (l Latency) Validate() error {
+	if l.P50 <= 0 {
+		return fmt.Errorf("latency p50 must be > 0, got %s", l.P50)
+	}
+	if l.P95 < l.P50 {
+		return fmt.Errorf("latency p95 (%s) must be >= p50 (%s)", l.P95, l.P50)
+	}
+	return nil
+}
+
+// Sample draws one delay from the distribution.
+func (l Latency) Sample() time.Duration {
+	if l.P50 <= 0 {
+		return 0
+	}
+	if l.P95 <= l.P50 {
+		return l.P50
+	}
+	mu := math.Log(float64(l.P50))
+	sigma := (math.Log(float64(l.P95)) - mu) / z95
+	return time.Duration(math.Exp(mu + sigma*rand.NormFloat64()))
+}
+
+// UnmarshalYAML accepts delay either as a fixed duration ("delay: 200ms")
+// or as a distribution ("delay: {p50: 100ms, p95: 800ms}").
+func (r *MockResponse) UnmarshalYAML(node *yaml.Node) error {
+	type plain MockResponse
+	var raw struct {
+		plain `yaml:",inline"`
+		Delay yaml.Node `yaml:"delay"`
+	}
+	if err := node.Decode(&raw); err != nil {
+		return err
+	}
+	*r = MockResponse(raw.plain)
+
+	switch raw.Delay.Kind {
+	case 0:
+		// No delay given.
+	case yaml.MappingNode:
+		var l Latency
+		if err := raw.Delay.Decode(&l); err != nil {
+			return fmt.Errorf("decoding delay distribution: %w", err)
+		}
+		if err := l.Validate(); err != nil {
+			return fmt.Errorf("line %d: %w", raw.Delay.Line, err)
+		}
+		r.Latency = &l
+	default:
+		if err := raw.Delay.Decode(&r.Delay); err != nil {
+			return fmt.Errorf("decoding delay: %w", err)
+		}
+	}
+	return nil
+}
+
+// MarshalYAML writes delay in the form UnmarshalYAML reads, so mock
+// responses built in Go survive a round trip through a suite file.
+func (r MockResponse) MarshalYAML() (interface{}, error) {
+	type plain MockResponse
+	out := struct {
+		plain `yaml:",inline"`
+		Delay interface{} `yaml:"delay,omitempty"`
+	}{plain: plain(r)}
+	switch {
+	case r.Latency != nil:
+		out.Delay = r.Latency
+	case r.Delay != 0:
+		out.Delay = r.Delay
+	}
+	return out, nil
+}
@@ -0,0 +1,77 @@
+package mock
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestMockResponse_UnmarshalDelay(t *testing.T) {
+	var cfg MockConfig
+	err := yaml.Unmarshal([]byte(`
+tool_name: search
+responses:
+  - content: fixed
+    delay: 200ms
+  - content: sampled
+    delay: {p50: 100ms, p95: 800ms}
+  - content: none
+`), &cfg)
+	if err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	if len(cfg.Responses) != 3 {
+		t.Fatalf("got %d responses, want 3", len(cfg.Responses))
+	}
+
+	fixed := cfg.Responses[0]
+	if fixed.Content != "fixed" || fixed.Delay != 200*time.Millisecond || fixed.Latency != nil {
+		t.Errorf("fixed response = %+v", fixed)
+	}
+	sampled := cfg.Responses[1]
+	if sampled.Latency == nil || *sampled.Latency != (Latency{P50: 100 * time.Millisecond, P95: 800 * time.Millisecond}) {
+		t.Errorf("sampled response latency = %+v", sampled.Latency)
+	}
+	if none := cfg.Responses[2]; none.Delay != 0 || none.Latency != nil {
+		t.Errorf("response without delay = %+v", none)
+	}
+}
+
+func TestMockResponse_UnmarshalInvalidLatency(t *testing.T) {
+	var resp MockResponse
+	err := yaml.Unmarshal([]byte(`delay: {p50: 800ms, p95: 100ms}`), &resp)
+	if err == nil || !strings.Contains(err.Error(), "must be >= p50") {
+		t.Errorf("expected ordering error, got %v", err)
+	}
+}
+
+func TestLatency_Sample(t *testing.T) {
+	l := Latency{P50: 100 * time.Millisecond, P95: 800 * time.Millisecond}
+
+	const n = 4000
+	below50, below95 := 0, 0
+	for i := 0; i < n; i++ {
+		d := l.Sample()
+		if d <= 0 {
+			t.Fatalf("sampled non-positive delay %s", d)
+		}
+		if d <= l.P50 {
+			below50++
+		}
+		if d <= l.P95 {
+			below95++
+		}
+	}
+	if frac := float64(below50) / n; frac < 0.45 || frac > 0.55 {
+		t.Errorf("%.2f of samples <= p50, want ~0.50", frac)
+	}
+	if frac := float64(below95) / n; frac < 0.92 || frac > 0.98 {
+		t.Errorf("%.2f of samples <= p95, want ~0.95", frac)
+	}
+
+	if got := (Latency{P50: 50 * time.Millisecond, P95: 50 * time.Millisecond}).Sample(); got != 50*time.Millisecond {
+		t.Errorf("degenerate distribution sampled %s, want 50ms", got)
+	}
+}
@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"errors"
 	"fmt"
 	"sync"
 	"time"
@@ -8,16 +9,46 @@ import (
 
 // MockConfig defines the mock behavior for a single tool.
 type MockConfig struct {
-	ToolName        string         `yaml:"tool_name" json:"tool_name"`
-	Responses       []MockResponse `yaml:"responses" json:"responses"`
-	DefaultResponse *MockResponse  `yaml:"default_response" json:"default_response"`
+	ToolName        string         `yaml:"tool_name,omitempty" json:"tool_name"`
+	Responses       []MockResponse `yaml:"responses,omitempty" json:"responses"`
+	DefaultResponse *MockResponse  `yaml:"default_response,omitempty" json:"default_response"`
+
+	// MaxCalls, when positive, is the number of calls the tool answers.
+	// Later calls fail with an error wrapping ErrCallBudgetExceeded, so
+	// suites can check that an agent respects a usage limit stated in its
+	// prompt.
+	MaxCalls int `yaml:"max_calls,omitempty" json:"max_calls,omitempty"`
 }
 
+// ErrCallBudgetExceeded is wrapped by the error of a call to a mocked
+// tool that has used up its MaxCalls.
+var ErrCallBudgetExceeded = errors.New("call budget exceeded")
+
 // MockResponse defines a single mock response including optional error and delay.
+// When Latency is set, the delay is sampled from it on every call and Delay
+// is ignored. ContentFile names a suite fixture file whose contents become
+// Content when the suite is loaded.
 type MockResponse struct {
-	Content string        `yaml:"content" json:"content"`
-	Error   string        `yaml:"error" json:"error"`
-	Delay   time.Duration `yaml:"delay" json:"delay"`
+	Content     string        `yaml:"content,omitempty" json:"content"`
+	ContentFile string        `yaml:"content_file,omitempty" json:"content_file,omitempty"`
+	Error       string        `yaml:"error,omitempty" json:"error"`
+	Delay       time.Duration `yaml:"-" json:"delay"`
+	Latency     *Latency      `yaml:"-" json:"latency,omitempty"`
+}
+
+// Validate checks that the mock names a tool and can answer at least one
+// call.
+func (c MockConfig) Validate() error {
+	if c.ToolName == "" {
+		return fmt.Errorf("mock has no tool_name")
+	}
+	if len(c.Responses) == 0 && c.DefaultResponse == nil {
+		return fmt.Errorf("mock for tool %q has no responses or default_response", c.ToolName)
+	}
+	if c.MaxCalls < 0 {
+		return fmt.Errorf("mock for tool %q: max_calls must be >= 0, got %d", c.ToolName, c.MaxCalls)
+	}
+	return nil
 }
 
 // ToolCallRecord captures a single tool invocation for later inspection.
@@ -30,6 +61,18 @@ type ToolCallRecord struct {
 	Timestamp  time.Time              `json:"timestamp"`
 }
 
+// Policies for calls to tools that have no mock configured, set with
+// MockRegistry.SetUnmockedPolicy.
+const (
+	UnmockedError       = "error"       // fail the call (the default)
+	UnmockedEmpty       = "empty"       // succeed with an empty result
+	UnmockedPassthrough = "passthrough" // hand the call to a real executor
+)
+
+// ToolFunc executes a tool call for real. It is used to pass unmocked
+// calls through under the UnmockedPassthrough policy.
+type ToolFunc func(toolName string, params map[string]interface{}) (string, error)
+
 // MockRegistry manages mock configurations and records tool calls.
 // All methods are safe for concurrent use.
 type MockRegistry struct {
@@ -37,6 +80,10 @@ type MockRegistry struct {
 	calls   []ToolCallRecord
 	mu      sync.Mutex
 	callIdx map[string]int // tracks next response index per tool
+	called  map[string]int // counts calls per tool, for MaxCalls
+
+	unmocked    string
+	passthrough ToolFunc
 }
 
 // NewRegistry creates a MockRegistry pre-loaded with the given configs.
@@ -44,6 +91,7 @@ func NewRegistry(configs []MockConfig) *MockRegistry {
 	r := &MockRegistry{
 		mocks:   make(map[string]*MockConfig),
 		callIdx: make(map[string]int),
+		called:  make(map[string]int),
 	}
 	for i := range configs {
 		c := configs[i]
@@ -59,20 +107,48 @@ func (r *MockRegistry) Register(config MockConfig) {
 	r.mocks[config.ToolName] = &config
 }
 
+// SetUnmockedPolicy controls what Resolve does for tools with no mock:
+// UnmockedError (or "") returns an error, UnmockedEmpty returns an empty
+// result, and UnmockedPassthrough calls passthrough. Calls handled by the
+// empty and passthrough policies are recorded like mocked calls.
+func (r *MockRegistry) SetUnmockedPolicy(policy string, passthrough ToolFunc) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.unmocked = policy
+	r.passthrough = passthrough
+}
+
 // Resolve simulates a tool call. It returns the next sequential response for
 // the tool, falling back to the default response when the sequence is
-// exhausted. If no mock is configured for the tool, an error is returned to
-// prevent accidental real API calls. Errors defined in the MockResponse are
-// returned as Go errors. If a delay is configured, Resolve sleeps for that
-// duration before returning.
+// exhausted. If no mock is configured for the tool, the unmocked policy
+// applies; by default an error is returned to prevent accidental real API
+// calls. Errors defined in the MockResponse are
+// returned as Go errors, as are calls beyond the mock's MaxCalls, which
+// are recorded but not answered. If a delay or latency distribution is configured,
+// Resolve sleeps for that duration before returning.
 func (r *MockRegistry) Resolve(toolName string, params map[string]interface{}) (string, error) {
 	start := time.Now()
 
 	r.mu.Lock()
 	cfg, ok := r.mocks[toolName]
 	if !ok {
+		policy, passthrough := r.unmocked, r.passthrough
 		r.mu.Unlock()
-		return "", fmt.Errorf("no mock configured for tool %q", toolName)
+		return r.resolveUnmocked(policy, passthrough, toolName, params, start)
+	}
+
+	r.called[toolName]++
+	if cfg.MaxCalls > 0 && r.called[toolName] > cfg.MaxCalls {
+		err := fmt.Errorf("tool %q: %w: at most %d calls are allowed", toolName, ErrCallBudgetExceeded, cfg.MaxCalls)
+		r.calls = append(r.calls, ToolCallRecord{
+			ToolName:   toolName,
+			Parameters: params,
+			Error:      err.Error(),
+			Duration:   time.Since(start),
+			Timestamp:  start,
+		})
+		r.mu.Unlock()
+		return "", err
 	}
 
 	idx := r.callIdx[toolName]
@@ -92,6 +168,9 @@ func (r *MockRegistry) Resolve(toolName string, params map[string]interface{}) (
 	content := resp.Content
 	errMsg := resp.Error
 	delay := resp.Delay
+	if resp.Latency != nil {
+		delay = resp.Latency.Sample()
+	}
 	r.mu.Unlock()
 
 	if delay > 0 {
@@ -120,6 +199,37 @@ func (r *MockRegistry) Resolve(toolName string, params map[string]interface{}) (
 	return content, nil
 }
 
+// resolveUnmocked applies the unmocked policy to a call with no mock.
+func (r *MockRegistry) resolveUnmocked(policy string, passthrough ToolFunc, toolName string, params map[string]interface{}, start time.Time) (string, error) {
+	var content string
+	var err error
+	switch policy {
+	case UnmockedEmpty:
+	case UnmockedPassthrough:
+		if passthrough == nil {
+			return "", fmt.Errorf("no mock configured for tool %q and no passthrough executor available", toolName)
+		}
+		content, err = passthrough(toolName, params)
+	default:
+		return "", fmt.Errorf("no mock configured for tool %q", toolName)
+	}
+
+	record := ToolCallRecord{
+		ToolName:   toolName,
+		Parameters: params,
+		Response:   content,
+		Duration:   time.Since(start),
+		Timestamp:  start,
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	r.mu.Lock()
+	r.calls = append(r.calls, record)
+	r.mu.Unlock()
+	return content, err
+}
+
 // GetCalls returns a copy of all recorded tool call records.
 func (r *MockRegistry) GetCalls() []ToolCallRecord {
 	r.mu.Lock()
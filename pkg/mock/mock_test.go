@@ -1,6 +1,7 @@
 package mock
 
 import (
+	"errors"
 	"strings"
 	"sync"
 	"testing"
@@ -98,6 +99,39 @@ func TestSequentialExhaustedNoDefault(t *testing.T) {
 	}
 }
 
+func TestMaxCalls(t *testing.T) {
+	reg := NewRegistry([]MockConfig{
+		{
+			ToolName:        "search",
+			Responses:       []MockResponse{{Content: "first"}},
+			DefaultResponse: &MockResponse{Content: "more"},
+			MaxCalls:        2,
+		},
+	})
+
+	for _, want := range []string{"first", "more"} {
+		if got, err := reg.Resolve("search", nil); err != nil || got != want {
+			t.Fatalf("Resolve() = %q, %v; want %q", got, err, want)
+		}
+	}
+	_, err := reg.Resolve("search", nil)
+	if !errors.Is(err, ErrCallBudgetExceeded) {
+		t.Fatalf("Resolve() beyond max_calls error = %v, want ErrCallBudgetExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "at most 2 calls") {
+		t.Errorf("error %q should state the budget", err)
+	}
+
+	calls := reg.GetCallsForTool("search")
+	if len(calls) != 3 || calls[2].Error == "" {
+		t.Errorf("calls = %+v, want the over-budget call recorded with its error", calls)
+	}
+
+	if err := (MockConfig{ToolName: "x", DefaultResponse: &MockResponse{}, MaxCalls: -1}).Validate(); err == nil {
+		t.Error("Validate() should reject negative max_calls")
+	}
+}
+
 func TestErrorSimulation(t *testing.T) {
 	reg := NewRegistry([]MockConfig{
 		{
@@ -242,3 +276,49 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("expected %d recorded calls, got %d", expected, len(calls))
 	}
 }
+
+func TestUnmockedPolicy(t *testing.T) {
+	t.Run("error by default", func(t *testing.T) {
+		reg := NewRegistry(nil)
+		if _, err := reg.Resolve("explore", nil); err == nil {
+			t.Fatal("expected error for unmocked tool")
+		}
+		if n := len(reg.GetCalls()); n != 0 {
+			t.Errorf("recorded %d calls, want 0", n)
+		}
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		reg := NewRegistry(nil)
+		reg.SetUnmockedPolicy(UnmockedEmpty, nil)
+		got, err := reg.Resolve("explore", map[string]interface{}{"q": "x"})
+		if err != nil || got != "" {
+			t.Fatalf("Resolve() = %q, %v; want empty result", got, err)
+		}
+		if calls := reg.GetCallsForTool("explore"); len(calls) != 1 {
+			t.Errorf("recorded %d calls, want 1", len(calls))
+		}
+	})
+
+	t.Run("passthrough", func(t *testing.T) {
+		reg := NewRegistry([]MockConfig{{ToolName: "mocked", DefaultResponse: &MockResponse{Content: "mock"}}})
+		reg.SetUnmockedPolicy(UnmockedPassthrough, func(name string, _ map[string]interface{}) (string, error) {
+			return "real " + name, nil
+		})
+		if got, _ := reg.Resolve("mocked", nil); got != "mock" {
+			t.Errorf("mocked tool = %q, want %q", got, "mock")
+		}
+		if got, err := reg.Resolve("search", nil); err != nil || got != "real search" {
+			t.Errorf("passthrough = %q, %v; want %q", got, err, "real search")
+		}
+	})
+
+	t.Run("passthrough without executor", func(t *testing.T) {
+		reg := NewRegistry(nil)
+		reg.SetUnmockedPolicy(UnmockedPassthrough, nil)
+		_, err := reg.Resolve("search", nil)
+		if err == nil || !strings.Contains(err.Error(), "no passthrough executor") {
+			t.Errorf("expected missing executor error, got %v", err)
+		}
+	})
+}
@@ -0,0 +1,74 @@
+package prompt
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// InterpolateOption configures Interpolate.
+type InterpolateOption func(*interpolateOptions)
+
+type interpolateOptions struct {
+	allowedEnv map[string]bool
+}
+
+// WithEnv lets templates read the named environment variables with
+// {{env "NAME"}}. Reading any variable not listed is an error, so a suite
+// cannot reach secrets such as API keys that were not explicitly allowed.
+func WithEnv(allowed []string) InterpolateOption {
+	return func(o *interpolateOptions) {
+		if o.allowedEnv == nil {
+			o.allowedEnv = make(map[string]bool, len(allowed))
+		}
+		for _, name := range allowed {
+			o.allowedEnv[name] = true
+		}
+	}
+}
+
+// env is the template function behind {{env "NAME"}}.
+func (o *interpolateOptions) env(name string) (string, error) {
+	if !o.allowedEnv[name] {
+		return "", fmt.Errorf("environment variable %q is not allow-listed for templates", name)
+	}
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("environment variable %q is not set", name)
+	}
+	return v, nil
+}
+
+var envRefPattern = regexp.MustCompile(`\{\{\s*env\s+"([^"]+)"\s*\}\}`)
+
+// ExpandEnv returns a copy of inputs in which every {{env "NAME"}}
+// reference inside a top-level string value is replaced by the variable's
+// value, subject to the same allow-list as Interpolate. Other template
+// syntax is left untouched, since case inputs often contain code.
+func ExpandEnv(inputs map[string]interface{}, opts ...InterpolateOption) (map[string]interface{}, error) {
+	var o interpolateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+
+	out := make(map[string]interface{}, len(inputs))
+	for k, v := range inputs {
+		s, ok := v.(string)
+		if !ok || !envRefPattern.MatchString(s) {
+			out[k] = v
+			continue
+		}
+		var firstErr error
+		out[k] = envRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+			val, err := o.env(envRefPattern.FindStringSubmatch(ref)[1])
+			if err != nil && firstErr == nil {
+				firstErr = err
+			}
+			return val
+		})
+		if firstErr != nil {
+			return nil, fmt.Errorf("input %q: %w", k, firstErr)
+		}
+	}
+	return out, nil
+}
@@ -8,6 +8,7 @@ import (
 	"strings"
 	"text/template"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 	"gopkg.in/yaml.v3"
 )
 
@@ -15,11 +16,16 @@ import (
 // YAML and rendered with variable interpolation.
 type PromptVariant struct {
 	Name        string            `yaml:"name"`
-	Description string            `yaml:"description"`
-	System      string            `yaml:"system"`
+	Description string            `yaml:"description,omitempty"`
+	System      string            `yaml:"system,omitempty"`
 	User        string            `yaml:"user"`
-	Tools       []ToolDefinition  `yaml:"tools"`
-	Metadata    map[string]string `yaml:"metadata"`
+	Tools       []ToolDefinition  `yaml:"tools,omitempty"`
+	Metadata    map[string]string `yaml:"metadata,omitempty"`
+
+	// ToolChoice forces the agent's first action: "auto", "any" (some
+	// tool), "none", or the name of a tool it must call. Later turns of
+	// the tool loop are left to the model.
+	ToolChoice string `yaml:"tool_choice,omitempty"`
 }
 
 // ToolDefinition describes a tool that the LLM can invoke during evaluation.
@@ -79,30 +85,55 @@ func (p *PromptVariant) Validate() error {
 	if p.System == "" && p.User == "" {
 		return fmt.Errorf("prompt %q must have at least a system or user prompt", p.Name)
 	}
+	if tc := provider.ParseToolChoice(p.ToolChoice); tc != nil && tc.Type != provider.ToolChoiceAuto && tc.Type != provider.ToolChoiceNone {
+		if len(p.Tools) == 0 {
+			return fmt.Errorf("prompt %q sets tool_choice %q but defines no tools", p.Name, p.ToolChoice)
+		}
+		if tc.Type == provider.ToolChoiceTool && !p.hasTool(tc.Name) {
+			return fmt.Errorf("prompt %q: tool_choice names unknown tool %q", p.Name, tc.Name)
+		}
+	}
 	return nil
 }
 
+func (p *PromptVariant) hasTool(name string) bool {
+	for _, t := range p.Tools {
+		if t.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
 // Interpolate applies Go text/template rendering to the System and User fields
 // using the provided variables. It returns a new PromptVariant with the
 // rendered strings; the original is not modified.
 //
 // Template variables use {{.VarName}} syntax. An error is returned if a
-// template references a variable not present in vars.
-func (p *PromptVariant) Interpolate(vars map[string]interface{}) (*PromptVariant, error) {
+// template references a variable not present in vars. Templates may read
+// environment variables allowed by WithEnv as {{env "NAME"}}.
+func (p *PromptVariant) Interpolate(vars map[string]interface{}, opts ...InterpolateOption) (*PromptVariant, error) {
+	var o interpolateOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	funcs := template.FuncMap{"env": o.env}
+
 	rendered := &PromptVariant{
 		Name:        p.Name,
 		Description: p.Description,
 		Tools:       p.Tools,
 		Metadata:    p.Metadata,
+		ToolChoice:  p.ToolChoice,
 	}
 
 	var err error
-	rendered.System, err = renderTemplate(p.Name+".system", p.System, vars)
+	rendered.System, err = renderTemplate(p.Name+".system", p.System, vars, funcs)
 	if err != nil {
 		return nil, fmt.Errorf("interpolating system prompt for %q: %w", p.Name, err)
 	}
 
-	rendered.User, err = renderTemplate(p.Name+".user", p.User, vars)
+	rendered.User, err = renderTemplate(p.Name+".user", p.User, vars, funcs)
 	if err != nil {
 		return nil, fmt.Errorf("interpolating user prompt for %q: %w", p.Name, err)
 	}
@@ -112,12 +143,12 @@ func (p *PromptVariant) Interpolate(vars map[string]interface{}) (*PromptVariant
 
 // renderTemplate parses and executes a Go text/template with "missingkey=error"
 // so that undefined variables produce an error instead of empty strings.
-func renderTemplate(name, text string, vars map[string]interface{}) (string, error) {
+func renderTemplate(name, text string, vars map[string]interface{}, funcs template.FuncMap) (string, error) {
 	if text == "" {
 		return "", nil
 	}
 
-	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	tmpl, err := template.New(name).Option("missingkey=error").Funcs(funcs).Parse(text)
 	if err != nil {
 		return "", fmt.Errorf("parsing template: %w", err)
 	}
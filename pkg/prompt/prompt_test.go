@@ -3,6 +3,7 @@ package prompt
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -156,6 +157,21 @@ func TestValidate(t *testing.T) {
 			prompt:  PromptVariant{Name: "test"},
 			wantErr: true,
 		},
+		{
+			name:    "tool_choice names a defined tool",
+			prompt:  PromptVariant{Name: "test", User: "u", Tools: []ToolDefinition{{Name: "search"}}, ToolChoice: "search"},
+			wantErr: false,
+		},
+		{
+			name:    "tool_choice names an unknown tool",
+			prompt:  PromptVariant{Name: "test", User: "u", Tools: []ToolDefinition{{Name: "search"}}, ToolChoice: "lookup"},
+			wantErr: true,
+		},
+		{
+			name:    "tool_choice any without tools",
+			prompt:  PromptVariant{Name: "test", User: "u", ToolChoice: "any"},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -210,6 +226,48 @@ func TestInterpolate(t *testing.T) {
 	}
 }
 
+func TestInterpolate_Env(t *testing.T) {
+	t.Setenv("TEST_EVAL_ACCOUNT_ID", "acct-123")
+	t.Setenv("TEST_EVAL_SECRET", "hunter2")
+	p := &PromptVariant{Name: "env", User: `Account {{env "TEST_EVAL_ACCOUNT_ID"}}: {{.q}}`}
+
+	got, err := p.Interpolate(map[string]interface{}{"q": "hi"}, WithEnv([]string{"TEST_EVAL_ACCOUNT_ID"}))
+	if err != nil {
+		t.Fatalf("Interpolate() error: %v", err)
+	}
+	if got.User != "Account acct-123: hi" {
+		t.Errorf("User = %q", got.User)
+	}
+
+	p.User = `{{env "TEST_EVAL_SECRET"}}`
+	if _, err := p.Interpolate(nil, WithEnv([]string{"TEST_EVAL_ACCOUNT_ID"})); err == nil || !strings.Contains(err.Error(), "not allow-listed") {
+		t.Errorf("error = %v, want allow-list error", err)
+	}
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("TEST_EVAL_ACCOUNT_ID", "acct-123")
+	inputs := map[string]interface{}{
+		"account": `id={{ env "TEST_EVAL_ACCOUNT_ID" }}`,
+		"code":    "func f() { return {{.x}} }",
+		"n":       3,
+	}
+	got, err := ExpandEnv(inputs, WithEnv([]string{"TEST_EVAL_ACCOUNT_ID"}))
+	if err != nil {
+		t.Fatalf("ExpandEnv() error: %v", err)
+	}
+	if got["account"] != "id=acct-123" || got["code"] != inputs["code"] || got["n"] != 3 {
+		t.Errorf("ExpandEnv() = %v", got)
+	}
+	if inputs["account"] != `id={{ env "TEST_EVAL_ACCOUNT_ID" }}` {
+		t.Error("ExpandEnv modified its input")
+	}
+
+	if _, err := ExpandEnv(inputs); err == nil || !strings.Contains(err.Error(), `input "account"`) {
+		t.Errorf("error = %v, want error naming the input", err)
+	}
+}
+
 func TestInterpolate_UndefinedVariable(t *testing.T) {
 	p := &PromptVariant{
 		Name:   "undef-test",
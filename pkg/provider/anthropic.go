@@ -3,6 +3,7 @@ package provider
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,10 +13,18 @@ import (
 )
 
 const (
-	defaultAnthropicURL    = "https://api.anthropic.com/v1/messages"
+	defaultAnthropicURL     = "https://api.anthropic.com/v1/messages"
 	defaultAnthropicVersion = "2023-06-01"
-	defaultMaxRetries      = 3
-	baseBackoff            = 500 * time.Millisecond
+	defaultMaxRetries       = 3
+	baseBackoff             = 500 * time.Millisecond
+
+	// overloadedBackoff replaces baseBackoff after an overloaded response,
+	// giving a provider that is shedding load longer to recover.
+	overloadedBackoff = 2 * time.Second
+
+	// statusOverloaded is the non-standard status Anthropic returns when
+	// the API is temporarily overloaded.
+	statusOverloaded = 529
 )
 
 // AnthropicOption configures an AnthropicProvider.
@@ -36,12 +45,49 @@ func WithMaxRetries(n int) AnthropicOption {
 	return func(p *AnthropicProvider) { p.maxRetries = n }
 }
 
+// WithHeaders sets extra HTTP headers sent with every request, such as
+// routing or organization headers required by an API gateway. They are
+// applied after the standard headers and may override them.
+func WithHeaders(h map[string]string) AnthropicOption {
+	return func(p *AnthropicProvider) { p.headers = h }
+}
+
+// WithRetryBudget shares a run-level retry budget with other providers. Once it
+// is exhausted, failed calls return immediately instead of retrying.
+func WithRetryBudget(b *RetryBudget) AnthropicOption {
+	return func(p *AnthropicProvider) { p.budget = b }
+}
+
+// WithQuota caps this provider's requests and tokens over the run. Share
+// one Quota between every instance of the provider; once it is used up,
+// calls fail with an error wrapping ErrQuotaExceeded.
+func WithQuota(q *Quota) AnthropicOption {
+	return func(p *AnthropicProvider) { p.quota = q }
+}
+
+// WithRateLimiter paces this provider's requests below its rate limits.
+// Share one RateLimiter between every instance of the provider.
+func WithRateLimiter(l *RateLimiter) AnthropicOption {
+	return func(p *AnthropicProvider) { p.limiter = l }
+}
+
+// WithKeyPool sends each request with a key from pool instead of the
+// provider's own API key.
+func WithKeyPool(pool *KeyPool) AnthropicOption {
+	return func(p *AnthropicProvider) { p.keys = pool }
+}
+
 // AnthropicProvider implements Provider for the Anthropic Messages API.
 type AnthropicProvider struct {
 	apiKey     string
+	keys       *KeyPool
 	baseURL    string
 	client     *http.Client
 	maxRetries int
+	budget     *RetryBudget
+	quota      *Quota
+	limiter    *RateLimiter
+	headers    map[string]string
 }
 
 // NewAnthropicProvider creates a new Anthropic provider with the given API key.
@@ -49,7 +95,7 @@ func NewAnthropicProvider(apiKey string, opts ...AnthropicOption) *AnthropicProv
 	p := &AnthropicProvider{
 		apiKey:     apiKey,
 		baseURL:    defaultAnthropicURL,
-		client:     &http.Client{Timeout: 60 * time.Second},
+		client:     defaultClient,
 		maxRetries: defaultMaxRetries,
 	}
 	for _, opt := range opts {
@@ -68,7 +114,15 @@ type anthropicRequest struct {
 	System      string             `json:"system,omitempty"`
 	Messages    []anthropicMessage `json:"messages"`
 	Tools       []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice  *ToolChoice        `json:"tool_choice,omitempty"`
 	Temperature *float64           `json:"temperature,omitempty"`
+	Metadata    *anthropicMetadata `json:"metadata,omitempty"`
+}
+
+// anthropicMetadata describes the request; the API accepts only an
+// opaque user identifier.
+type anthropicMetadata struct {
+	UserID string `json:"user_id"`
 }
 
 type anthropicMessage struct {
@@ -84,14 +138,17 @@ type anthropicTool struct {
 
 // anthropicResponse is the Anthropic Messages API response body.
 type anthropicResponse struct {
-	ID         string                 `json:"id"`
-	Type       string                 `json:"type"`
-	Role       string                 `json:"role"`
+	ID         string                  `json:"id"`
+	Type       string                  `json:"type"`
+	Role       string                  `json:"role"`
+	Model      string                  `json:"model"`
 	Content    []anthropicContentBlock `json:"content"`
-	StopReason string                 `json:"stop_reason"`
+	StopReason string                  `json:"stop_reason"`
 	Usage      struct {
-		InputTokens  int `json:"input_tokens"`
-		OutputTokens int `json:"output_tokens"`
+		InputTokens              int `json:"input_tokens"`
+		OutputTokens             int `json:"output_tokens"`
+		CacheReadInputTokens     int `json:"cache_read_input_tokens"`
+		CacheCreationInputTokens int `json:"cache_creation_input_tokens"`
 	} `json:"usage"`
 }
 
@@ -118,47 +175,77 @@ func (p *AnthropicProvider) Complete(ctx context.Context, req *Request) (*Respon
 		return nil, fmt.Errorf("building request body: %w", err)
 	}
 
+	estimated := EstimateTokens(req)
 	var lastErr error
+	var retry RetryStats
 	for attempt := 0; attempt <= p.maxRetries; attempt++ {
 		if attempt > 0 {
-			backoff := baseBackoff * time.Duration(math.Pow(2, float64(attempt-1)))
+			backoff := retryBackoff(attempt, lastErr)
+			if !p.budget.Take(backoff) {
+				return nil, &RetryError{Provider: "anthropic", Retry: retry, Err: fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, lastErr)}
+			}
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			case <-time.After(backoff):
 			}
+			retry.Retries = attempt
+			retry.Backoff += backoff
 		}
 
+		if err := p.limiter.Wait(ctx, estimated); err != nil {
+			return nil, err
+		}
+		if err := p.quota.Take(); err != nil {
+			return nil, err
+		}
 		resp, err := p.doRequest(ctx, body)
 		if err != nil {
 			if !isRetryable(err) {
 				return nil, err
 			}
+			if isOverloaded(err) {
+				retry.Overloaded++
+			}
 			lastErr = err
 			continue
 		}
+		p.quota.Add(resp.Usage)
+		p.limiter.Settle(estimated, resp.Usage)
+		resp.Retry = retry
 		return resp, nil
 	}
 
-	return nil, fmt.Errorf("anthropic API request failed after %d attempts: %w", p.maxRetries+1, lastErr)
+	return nil, &RetryError{Provider: "anthropic", Retry: retry, Err: lastErr}
 }
 
 func (p *AnthropicProvider) buildRequestBody(req *Request) ([]byte, error) {
+	ar, err := p.buildRequest(req)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(ar)
+}
+
+func (p *AnthropicProvider) buildRequest(req *Request) (*anthropicRequest, error) {
+	if err := validateParts(req.Messages); err != nil {
+		return nil, err
+	}
 	maxTokens := req.MaxTokens
 	if maxTokens == 0 {
 		maxTokens = 4096
 	}
 
-	ar := anthropicRequest{
+	ar := &anthropicRequest{
 		Model:     req.Model,
 		MaxTokens: maxTokens,
 		System:    req.System,
 		Messages:  convertMessages(req.Messages),
 	}
 
-	if req.Temperature != 0 {
-		t := req.Temperature
-		ar.Temperature = &t
+	ar.Temperature = req.temperature(false)
+	if user := MetadataUser(req.Metadata); user != "" {
+		ar.Metadata = &anthropicMetadata{UserID: user}
 	}
 
 	for _, tool := range req.Tools {
@@ -168,8 +255,53 @@ func (p *AnthropicProvider) buildRequestBody(req *Request) ([]byte, error) {
 			InputSchema: tool.Parameters,
 		})
 	}
+	// ToolChoice uses the Anthropic field names, and tool_choice is only
+	// accepted alongside tools.
+	if len(ar.Tools) > 0 {
+		ar.ToolChoice = req.ToolChoice
+	}
 
-	return json.Marshal(ar)
+	return ar, nil
+}
+
+// anthropicCountRequest is the token counting API request body: a
+// Messages API request without the generation parameters.
+type anthropicCountRequest struct {
+	Model      string             `json:"model"`
+	System     string             `json:"system,omitempty"`
+	Messages   []anthropicMessage `json:"messages"`
+	Tools      []anthropicTool    `json:"tools,omitempty"`
+	ToolChoice *ToolChoice        `json:"tool_choice,omitempty"`
+}
+
+// CountTokens returns the input tokens of req as counted by the Messages
+// API's count_tokens endpoint, which runs no model and is not billed.
+func (p *AnthropicProvider) CountTokens(ctx context.Context, req *Request) (int, error) {
+	ar, err := p.buildRequest(req)
+	if err != nil {
+		return 0, fmt.Errorf("building request body: %w", err)
+	}
+	body, err := json.Marshal(anthropicCountRequest{
+		Model:      ar.Model,
+		System:     ar.System,
+		Messages:   ar.Messages,
+		Tools:      ar.Tools,
+		ToolChoice: ar.ToolChoice,
+	})
+	if err != nil {
+		return 0, fmt.Errorf("building request body: %w", err)
+	}
+	respBody, err := p.send(ctx, http.MethodPost, p.baseURL+"/count_tokens", body)
+	if err != nil {
+		return 0, err
+	}
+	var count struct {
+		InputTokens int `json:"input_tokens"`
+	}
+	if err := json.Unmarshal(respBody, &count); err != nil {
+		return 0, fmt.Errorf("decoding response: %w", err)
+	}
+	return count.InputTokens, nil
 }
 
 func convertMessages(msgs []Message) []anthropicMessage {
@@ -180,17 +312,23 @@ func convertMessages(msgs []Message) []anthropicMessage {
 		if m.Role == "tool" {
 			// Tool result messages use structured content for Anthropic.
 			am.Role = "user"
+			var content interface{} = m.Content
+			if len(m.Parts) > 0 {
+				content = anthropicParts(m.Parts)
+			}
 			am.Content = []map[string]interface{}{
 				{
 					"type":        "tool_result",
 					"tool_use_id": m.ToolCallID,
-					"content":     m.Content,
+					"content":     content,
 				},
 			}
 		} else if len(m.ToolCalls) > 0 {
 			// Assistant messages with tool calls use content blocks.
-			blocks := make([]map[string]interface{}, 0, len(m.ToolCalls)+1)
-			if m.Content != "" {
+			blocks := make([]map[string]interface{}, 0, len(m.ToolCalls)+len(m.Parts)+1)
+			if len(m.Parts) > 0 {
+				blocks = append(blocks, anthropicParts(m.Parts)...)
+			} else if m.Content != "" {
 				blocks = append(blocks, map[string]interface{}{
 					"type": "text",
 					"text": m.Content,
@@ -205,6 +343,8 @@ func convertMessages(msgs []Message) []anthropicMessage {
 				})
 			}
 			am.Content = blocks
+		} else if len(m.Parts) > 0 {
+			am.Content = anthropicParts(m.Parts)
 		} else {
 			am.Content = m.Content
 		}
@@ -214,15 +354,64 @@ func convertMessages(msgs []Message) []anthropicMessage {
 	return out
 }
 
+// anthropicParts converts content parts to Messages API content blocks,
+// whose image and document block types match the part types.
+func anthropicParts(parts []ContentPart) []map[string]interface{} {
+	blocks := make([]map[string]interface{}, 0, len(parts))
+	for _, cp := range parts {
+		if cp.Type == PartText {
+			blocks = append(blocks, map[string]interface{}{"type": "text", "text": cp.Text})
+			continue
+		}
+		source := map[string]interface{}{"type": "url", "url": cp.URL}
+		if cp.Data != nil {
+			source = map[string]interface{}{
+				"type":       "base64",
+				"media_type": cp.MediaType,
+				"data":       base64.StdEncoding.EncodeToString(cp.Data),
+			}
+		}
+		block := map[string]interface{}{"type": cp.Type, "source": source}
+		if cp.Type == PartDocument && cp.Name != "" {
+			block["title"] = cp.Name
+		}
+		blocks = append(blocks, block)
+	}
+	return blocks
+}
+
 func (p *AnthropicProvider) doRequest(ctx context.Context, body []byte) (*Response, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL, bytes.NewReader(body))
+	respBody, err := p.send(ctx, http.MethodPost, p.baseURL, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var ar anthropicResponse
+	if err := json.Unmarshal(respBody, &ar); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return parseAnthropicResponse(&ar), nil
+}
+
+// send makes an authenticated request to the Anthropic API and returns the
+// body of a successful response. Failures that are worth retrying are
+// returned as *retryableError.
+func (p *AnthropicProvider) send(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("creating HTTP request: %w", err)
 	}
 
 	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("X-Api-Key", p.apiKey)
+	key := p.keys.keyOr(p.apiKey)
+	httpReq.Header.Set("X-Api-Key", key)
 	httpReq.Header.Set("Anthropic-Version", defaultAnthropicVersion)
+	setHeaders(httpReq, p.headers)
 
 	httpResp, err := p.client.Do(httpReq)
 	if err != nil {
@@ -235,36 +424,39 @@ func (p *AnthropicProvider) doRequest(ctx context.Context, body []byte) (*Respon
 		return nil, &retryableError{err: fmt.Errorf("reading response body: %w", err)}
 	}
 
-	if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= 500 {
-		var apiErr anthropicErrorResponse
-		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
-			return nil, &retryableError{err: fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, apiErr.Error.Message)}
-		}
-		return nil, &retryableError{err: fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, string(respBody))}
-	}
-
 	if httpResp.StatusCode != http.StatusOK {
+		msg := string(respBody)
 		var apiErr anthropicErrorResponse
 		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
-			return nil, fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, apiErr.Error.Message)
+			msg = apiErr.Error.Message
 		}
-		return nil, fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, string(respBody))
-	}
-
-	var ar anthropicResponse
-	if err := json.Unmarshal(respBody, &ar); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+		switch {
+		case httpResp.StatusCode == statusOverloaded || apiErr.Error.Type == "overloaded_error":
+			return nil, &retryableError{err: fmt.Errorf("HTTP %d: %w: %s", httpResp.StatusCode, ErrOverloaded, msg), overloaded: true}
+		case httpResp.StatusCode == http.StatusTooManyRequests:
+			p.keys.RateLimited(key)
+			return nil, &retryableError{err: fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, msg)}
+		case httpResp.StatusCode >= 500:
+			return nil, &retryableError{err: fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, msg)}
+		case httpResp.StatusCode == http.StatusNotFound || apiErr.Error.Type == "not_found_error":
+			return nil, fmt.Errorf("HTTP %d: %w: %s", httpResp.StatusCode, ErrModelUnavailable, msg)
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, msg)
 	}
 
-	return parseAnthropicResponse(&ar), nil
+	return respBody, nil
 }
 
 func parseAnthropicResponse(ar *anthropicResponse) *Response {
 	resp := &Response{
 		StopReason: ar.StopReason,
+		Model:      ar.Model,
+		// Anthropic's input_tokens leaves out the cached tokens.
 		Usage: Usage{
-			InputTokens:  ar.Usage.InputTokens,
-			OutputTokens: ar.Usage.OutputTokens,
+			InputTokens:      ar.Usage.InputTokens + ar.Usage.CacheReadInputTokens + ar.Usage.CacheCreationInputTokens,
+			OutputTokens:     ar.Usage.OutputTokens,
+			CacheReadTokens:  ar.Usage.CacheReadInputTokens,
+			CacheWriteTokens: ar.Usage.CacheCreationInputTokens,
 		},
 	}
 
@@ -289,14 +481,40 @@ func parseAnthropicResponse(ar *anthropicResponse) *Response {
 	return resp
 }
 
-// retryableError wraps errors that should trigger a retry.
+// setHeaders applies custom headers to an outgoing request.
+func setHeaders(req *http.Request, headers map[string]string) {
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+}
+
+// retryableError wraps errors that should trigger a retry. overloaded marks
+// a provider that is shedding load, which is retried with a longer backoff.
 type retryableError struct {
-	err error
+	err        error
+	overloaded bool
 }
 
 func (e *retryableError) Error() string { return e.err.Error() }
 func (e *retryableError) Unwrap() error { return e.err }
 
+// isOverloaded reports whether err is a retryable overloaded response.
+func isOverloaded(err error) bool {
+	re, ok := err.(*retryableError)
+	return ok && re.overloaded
+}
+
+// retryBackoff returns the delay before the given retry attempt, doubling
+// from baseBackoff, or from overloadedBackoff when lastErr was an
+// overloaded response.
+func retryBackoff(attempt int, lastErr error) time.Duration {
+	base := baseBackoff
+	if isOverloaded(lastErr) {
+		base = overloadedBackoff
+	}
+	return base * time.Duration(math.Pow(2, float64(attempt-1)))
+}
+
 // isRetryable returns true if the error should trigger a retry.
 func isRetryable(err error) bool {
 	_, ok := err.(*retryableError)
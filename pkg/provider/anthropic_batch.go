@@ -0,0 +1,121 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// anthropicBatches is the Anthropic Message Batches API.
+type anthropicBatches struct {
+	p *AnthropicProvider
+}
+
+// messageBatch is the Message Batches API's batch object.
+type messageBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"`
+	ResultsURL       string `json:"results_url"`
+}
+
+// messageBatchResult is one line of a batch's JSONL results.
+type messageBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string            `json:"type"` // succeeded, errored, canceled, or expired
+		Message anthropicResponse `json:"message"`
+		Error   struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+func (a anthropicBatches) CountTokens(ctx context.Context, req *Request) (int, error) {
+	return a.p.CountTokens(ctx, req)
+}
+
+func (a anthropicBatches) params(req *Request) ([]byte, error) {
+	return a.p.buildRequestBody(req)
+}
+
+func (a anthropicBatches) submit(ctx context.Context, reqs []batchRequest) (string, error) {
+	body, err := json.Marshal(struct {
+		Requests []batchRequest `json:"requests"`
+	}{reqs})
+	if err != nil {
+		return "", err
+	}
+	var batch messageBatch
+	if err := a.call(ctx, http.MethodPost, a.p.baseURL+"/batches", body, &batch); err != nil {
+		return "", err
+	}
+	return batch.ID, nil
+}
+
+func (a anthropicBatches) ended(ctx context.Context, id string) (bool, error) {
+	var batch messageBatch
+	if err := a.call(ctx, http.MethodGet, a.p.baseURL+"/batches/"+id, nil, &batch); err != nil {
+		return false, err
+	}
+	return batch.ProcessingStatus == "ended", nil
+}
+
+func (a anthropicBatches) results(ctx context.Context, id string) (map[string]batchResult, error) {
+	var batch messageBatch
+	if err := a.call(ctx, http.MethodGet, a.p.baseURL+"/batches/"+id, nil, &batch); err != nil {
+		return nil, fmt.Errorf("fetching message batch %s: %w", id, err)
+	}
+	data, err := a.send(ctx, http.MethodGet, batch.ResultsURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("fetching results of message batch %s: %w", id, err)
+	}
+
+	results := make(map[string]batchResult)
+	sc := bufio.NewScanner(bytes.NewReader(data))
+	sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+	for sc.Scan() {
+		if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+			continue
+		}
+		var line messageBatchResult
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			return nil, fmt.Errorf("decoding results of message batch %s: %w", id, err)
+		}
+		switch r := line.Result; r.Type {
+		case "succeeded":
+			results[line.CustomID] = batchResult{resp: parseAnthropicResponse(&r.Message)}
+		case "errored":
+			results[line.CustomID] = batchResult{err: fmt.Errorf("batch request failed: %s: %s", r.Error.Error.Type, r.Error.Error.Message)}
+		default:
+			results[line.CustomID] = batchResult{err: fmt.Errorf("batch request %s in message batch %s", r.Type, id)}
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return nil, fmt.Errorf("reading results of message batch %s: %w", id, err)
+	}
+	return results, nil
+}
+
+// call sends a batch API request and decodes its JSON response into out.
+func (a anthropicBatches) call(ctx context.Context, method, url string, body []byte, out interface{}) error {
+	data, err := a.send(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (a anthropicBatches) send(ctx context.Context, method, url string, body []byte) ([]byte, error) {
+	return retryBatchCall(a.p.Name(), a.p.maxRetries, a.p.budget, func() ([]byte, error) {
+		return a.p.send(ctx, method, url, body)
+	})
+}
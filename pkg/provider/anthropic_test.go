@@ -3,11 +3,15 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestAnthropicComplete_TextResponse(t *testing.T) {
@@ -39,9 +43,10 @@ func TestAnthropicComplete_TextResponse(t *testing.T) {
 		}
 
 		resp := anthropicResponse{
-			ID:   "msg_01",
-			Type: "message",
-			Role: "assistant",
+			ID:    "msg_01",
+			Type:  "message",
+			Role:  "assistant",
+			Model: "claude-3-haiku-20240307",
 			Content: []anthropicContentBlock{
 				{Type: "text", Text: "Hello! How can I help?"},
 			},
@@ -74,6 +79,9 @@ func TestAnthropicComplete_TextResponse(t *testing.T) {
 	if got.StopReason != "end_turn" {
 		t.Errorf("StopReason = %q, want %q", got.StopReason, "end_turn")
 	}
+	if got.Model != "claude-3-haiku-20240307" {
+		t.Errorf("Model = %q, want %q", got.Model, "claude-3-haiku-20240307")
+	}
 	if got.Usage.InputTokens != 15 {
 		t.Errorf("InputTokens = %d, want %d", got.Usage.InputTokens, 15)
 	}
@@ -228,6 +236,10 @@ func TestAnthropicComplete_RetryOn429(t *testing.T) {
 	if n := attempts.Load(); n != 3 {
 		t.Errorf("attempts = %d, want 3", n)
 	}
+	// Backoff is 500ms then 1s.
+	if got.Retry.Retries != 2 || got.Retry.Backoff != 1500*time.Millisecond {
+		t.Errorf("Retry = %+v, want 2 retries with 1.5s backoff", got.Retry)
+	}
 }
 
 func TestAnthropicComplete_RetryOn500(t *testing.T) {
@@ -306,6 +318,208 @@ func TestAnthropicComplete_ExhaustedRetries(t *testing.T) {
 	if n := attempts.Load(); n != 3 {
 		t.Errorf("attempts = %d, want 3", n)
 	}
+
+	var re *RetryError
+	if !errors.As(err, &re) || re.Retry.Retries != 2 {
+		t.Errorf("error = %v, want *RetryError with 2 retries", err)
+	}
+	if !strings.Contains(err.Error(), "anthropic API request failed after 3 attempts") {
+		t.Errorf("error = %q", err)
+	}
+}
+
+func TestAnthropicComplete_Overloaded(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(statusOverloaded)
+			w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("test-key", WithBaseURL(server.URL), WithMaxRetries(1))
+	resp, err := p.Complete(context.Background(), &Request{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if resp.Retry.Overloaded != 1 || resp.Retry.Backoff != overloadedBackoff {
+		t.Errorf("Retry = %+v, want 1 overloaded response and %s backoff", resp.Retry, overloadedBackoff)
+	}
+}
+
+func TestAnthropicComplete_OverloadedError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(`{"type":"error","error":{"type":"overloaded_error","message":"Overloaded"}}`))
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("test-key", WithBaseURL(server.URL), WithMaxRetries(0))
+	_, err := p.Complete(context.Background(), &Request{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	if !errors.Is(err, ErrOverloaded) {
+		t.Fatalf("error = %v, want ErrOverloaded", err)
+	}
+	var re *RetryError
+	if !errors.As(err, &re) || re.Retry.Overloaded != 1 {
+		t.Errorf("error = %v, want *RetryError with 1 overloaded response", err)
+	}
+}
+
+func TestAnthropicComplete_RetryBudget(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+		w.Write([]byte(`{"type":"error","error":{"type":"api_error","message":"unavailable"}}`))
+	}))
+	defer server.Close()
+
+	budget := &RetryBudget{MaxRetries: 1}
+	p := NewAnthropicProvider("test-key",
+		WithBaseURL(server.URL),
+		WithMaxRetries(3),
+		WithRetryBudget(budget),
+	)
+	req := &Request{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}
+
+	// The first call spends the only retry, the second gets none.
+	for i, want := range []int32{2, 3} {
+		_, err := p.Complete(context.Background(), req)
+		if !errors.Is(err, ErrRetryBudgetExhausted) {
+			t.Fatalf("call %d: error = %v, want ErrRetryBudgetExhausted", i, err)
+		}
+		if n := attempts.Load(); n != want {
+			t.Errorf("call %d: attempts = %d, want %d", i, n, want)
+		}
+	}
+	if !budget.Exhausted() {
+		t.Error("budget not marked exhausted")
+	}
+	if used := budget.Used(); used.Retries != 1 || used.Backoff != baseBackoff {
+		t.Errorf("Used() = %+v, want 1 retry and %s", used, baseBackoff)
+	}
+}
+
+func TestAnthropicComplete_Quota(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		resp := anthropicResponse{
+			ID:         "msg_01",
+			Type:       "message",
+			Role:       "assistant",
+			Content:    []anthropicContentBlock{{Type: "text", Text: "ok"}},
+			StopReason: "end_turn",
+		}
+		resp.Usage.InputTokens = 10
+		resp.Usage.OutputTokens = 5
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	quota := &Quota{Name: "anthropic", MaxRequests: 2}
+	p := NewAnthropicProvider("test-key",
+		WithBaseURL(server.URL),
+		WithQuota(quota),
+	)
+	req := &Request{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := p.Complete(context.Background(), req); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	_, err := p.Complete(context.Background(), req)
+	if !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("error = %v, want ErrQuotaExceeded", err)
+	}
+	if !strings.Contains(err.Error(), "quotas.anthropic.max_requests is 2") {
+		t.Errorf("error = %q, want it to name the exceeded setting", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+	if requests, tokens := quota.Used(); requests != 2 || tokens != 30 {
+		t.Errorf("Used() = %d requests, %d tokens, want 2 and 30", requests, tokens)
+	}
+}
+
+func TestAnthropicComplete_RateLimit(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(anthropicResponse{
+			Type:    "message",
+			Role:    "assistant",
+			Content: []anthropicContentBlock{{Type: "text", Text: "ok"}},
+		})
+	}))
+	defer server.Close()
+
+	// 120 requests per minute allows a burst of two, then one every half
+	// second, however many callers there are.
+	limiter := &RateLimiter{RequestsPerMinute: 120}
+	p := NewAnthropicProvider("test-key", WithBaseURL(server.URL), WithRateLimiter(limiter))
+	req := &Request{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := p.Complete(context.Background(), req); err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("4 requests took %s, want about 1s at 2 per second", elapsed)
+	}
+}
+
+func TestRateLimiter_TokenDebt(t *testing.T) {
+	l := &RateLimiter{TokensPerMinute: 600}
+	if err := l.Wait(context.Background(), 5); err != nil {
+		t.Fatal(err)
+	}
+	// The response used far more than estimated, so the bucket is in debt
+	// for about two seconds.
+	l.Settle(5, Usage{InputTokens: 20, OutputTokens: 10})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := l.Wait(ctx, 5); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Wait in debt = %v, want context.DeadlineExceeded", err)
+	}
+
+	var unlimited *RateLimiter
+	if err := unlimited.Wait(ctx, 5); err != nil {
+		t.Errorf("nil limiter Wait = %v, want nil", err)
+	}
 }
 
 func TestAnthropicComplete_NonRetryableError(t *testing.T) {
@@ -337,6 +551,33 @@ func TestAnthropicComplete_NonRetryableError(t *testing.T) {
 	}
 }
 
+func TestAnthropicComplete_CustomHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Org-Id"); got != "org-42" {
+			t.Errorf("X-Org-Id = %q, want %q", got, "org-42")
+		}
+		if got := r.Header.Get("X-Api-Key"); got != "test-key" {
+			t.Errorf("X-Api-Key = %q, want %q", got, "test-key")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"type":"message","content":[{"type":"text","text":"ok"}],"stop_reason":"end_turn"}`))
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("test-key",
+		WithBaseURL(server.URL),
+		WithMaxRetries(0),
+		WithHeaders(map[string]string{"X-Org-Id": "org-42"}),
+	)
+
+	if _, err := p.Complete(context.Background(), &Request{
+		Model:    "claude-3-haiku-20240307",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+}
+
 func TestEstimateCost(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -374,6 +615,24 @@ func TestEstimateCost(t *testing.T) {
 			usage: Usage{InputTokens: 100_000, OutputTokens: 50_000},
 			want:  1.05, // (0.1 * 3) + (0.05 * 15)
 		},
+		{
+			name:  "vendor-prefixed slug",
+			model: "anthropic/claude-3-haiku-20240307",
+			usage: Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000},
+			want:  1.5,
+		},
+		{
+			name:  "prompt cache reads and writes",
+			model: "claude-sonnet-4-5-20250929",
+			usage: Usage{InputTokens: 1_000_000, CacheReadTokens: 500_000, CacheWriteTokens: 100_000},
+			want:  1.725, // (0.4 * 3) + (0.5 * 0.30) + (0.1 * 3.75)
+		},
+		{
+			name:  "cache read without a cache rate",
+			model: "gpt-4-turbo",
+			usage: Usage{InputTokens: 1_000_000, CacheReadTokens: 1_000_000},
+			want:  10.0,
+		},
 		{
 			name:  "unknown model",
 			model: "unknown-model-xyz",
@@ -398,6 +657,55 @@ func TestEstimateCost(t *testing.T) {
 	}
 }
 
+func TestAnthropicBuildRequestBody_ToolChoice(t *testing.T) {
+	p := NewAnthropicProvider("k")
+	tools := []Tool{{Name: "search", Parameters: map[string]interface{}{"type": "object"}}}
+
+	body, err := p.buildRequestBody(&Request{Model: "m", Tools: tools, ToolChoice: ParseToolChoice("search")})
+	if err != nil {
+		t.Fatalf("buildRequestBody() error: %v", err)
+	}
+	if !strings.Contains(string(body), `"tool_choice":{"type":"tool","name":"search"}`) {
+		t.Errorf("body = %s, want a tool_choice for search", body)
+	}
+
+	// tool_choice is dropped when there are no tools to choose from.
+	body, _ = p.buildRequestBody(&Request{Model: "m", ToolChoice: ParseToolChoice("any")})
+	if strings.Contains(string(body), "tool_choice") {
+		t.Errorf("body = %s, want no tool_choice without tools", body)
+	}
+}
+
+func TestAnthropicBuildRequestBody_Metadata(t *testing.T) {
+	p := NewAnthropicProvider("k")
+	md := map[string]string{"suite": "smoke", "run_id": "20250630-120000-smoke"}
+
+	body, err := p.buildRequestBody(&Request{Model: "m", Metadata: md})
+	if err != nil {
+		t.Fatalf("buildRequestBody() error: %v", err)
+	}
+	if !strings.Contains(string(body), `"metadata":{"user_id":"run_id=20250630-120000-smoke;suite=smoke"}`) {
+		t.Errorf("body = %s, want metadata.user_id", body)
+	}
+
+	body, _ = p.buildRequestBody(&Request{Model: "m"})
+	if strings.Contains(string(body), "metadata") {
+		t.Errorf("body = %s, want no metadata", body)
+	}
+}
+
+func TestAnthropicBuildRequestBody_Seed(t *testing.T) {
+	p := NewAnthropicProvider("k")
+	seed := int64(7)
+	body, err := p.buildRequestBody(&Request{Model: "m", Seed: &seed})
+	if err != nil {
+		t.Fatalf("buildRequestBody() error: %v", err)
+	}
+	if strings.Contains(string(body), "temperature") || strings.Contains(string(body), "seed") {
+		t.Errorf("body = %s, want neither temperature nor seed", body)
+	}
+}
+
 func TestAnthropicProviderName(t *testing.T) {
 	p := NewAnthropicProvider("key")
 	if got := p.Name(); got != "anthropic" {
@@ -465,3 +773,48 @@ func TestConvertMessages_ToolResult(t *testing.T) {
 		t.Errorf("msg[2] tool_use_id = %v, want tc_01", resultBlocks[0]["tool_use_id"])
 	}
 }
+
+func TestConvertMessages_ContentParts(t *testing.T) {
+	msgs := []Message{
+		{Role: "user", Parts: []ContentPart{
+			TextPart("What is in these?"),
+			ImageURLPart("https://example.com/cat.png"),
+			ImagePart("image/png", []byte("png")),
+			DocumentPart("report.pdf", "application/pdf", []byte("pdf")),
+		}},
+		{Role: "tool", ToolCallID: "tc_01", Parts: []ContentPart{ImagePart("image/jpeg", []byte("jpg"))}},
+	}
+	data, err := json.Marshal(convertMessages(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"role":"user","content":[` +
+		`{"text":"What is in these?","type":"text"},` +
+		`{"source":{"type":"url","url":"https://example.com/cat.png"},"type":"image"},` +
+		`{"source":{"data":"cG5n","media_type":"image/png","type":"base64"},"type":"image"},` +
+		`{"source":{"data":"cGRm","media_type":"application/pdf","type":"base64"},"title":"report.pdf","type":"document"}]},` +
+		`{"role":"user","content":[{"content":[{"source":{"data":"anBn","media_type":"image/jpeg","type":"base64"},"type":"image"}],"tool_use_id":"tc_01","type":"tool_result"}]}]`
+	if string(data) != want {
+		t.Errorf("messages =\n%s\nwant\n%s", data, want)
+	}
+
+	p := NewAnthropicProvider("test-key")
+	_, err = p.buildRequestBody(&Request{Messages: []Message{{Role: "user", Parts: []ContentPart{{Type: PartImage}}}}})
+	if err == nil || !strings.Contains(err.Error(), "neither a URL nor data") {
+		t.Errorf("buildRequestBody() error = %v, want the empty image rejected", err)
+	}
+}
+
+func TestParseAnthropicResponse_CacheUsage(t *testing.T) {
+	var ar anthropicResponse
+	body := `{"model": "claude-sonnet-4-5-20250929", "content": [{"type": "text", "text": "hi"}],
+		"usage": {"input_tokens": 10, "output_tokens": 5, "cache_read_input_tokens": 900, "cache_creation_input_tokens": 100}}`
+	if err := json.Unmarshal([]byte(body), &ar); err != nil {
+		t.Fatal(err)
+	}
+	got := parseAnthropicResponse(&ar).Usage
+	want := Usage{InputTokens: 1010, OutputTokens: 5, CacheReadTokens: 900, CacheWriteTokens: 100}
+	if got != want {
+		t.Errorf("Usage = %+v, want %+v (cached tokens counted as input)", got, want)
+	}
+}
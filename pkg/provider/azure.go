@@ -0,0 +1,37 @@
+package provider
+
+import (
+	"net/url"
+	"strings"
+)
+
+// DefaultAzureAPIVersion is the Azure OpenAI API version used when none is
+// configured.
+const DefaultAzureAPIVersion = "2024-10-21"
+
+// AzureOpenAIURL returns the Chat Completions URL of an Azure OpenAI
+// deployment: endpoint is the resource endpoint, such as
+// https://my-resource.openai.azure.com, and apiVersion defaults to
+// DefaultAzureAPIVersion.
+func AzureOpenAIURL(endpoint, deployment, apiVersion string) string {
+	if apiVersion == "" {
+		apiVersion = DefaultAzureAPIVersion
+	}
+	endpoint = strings.TrimSuffix(endpoint, "/")
+	endpoint = strings.TrimSuffix(endpoint, "/openai")
+	return endpoint + "/openai/deployments/" + url.PathEscape(deployment) +
+		"/chat/completions?api-version=" + url.QueryEscape(apiVersion)
+}
+
+// NewAzureOpenAIProvider creates a provider for an Azure OpenAI deployment.
+// Azure serves the Chat Completions API, so this is an OpenAIProvider that
+// posts to the deployment's URL (see AzureOpenAIURL) and sends the key in
+// the api-key header instead of as a Bearer token. The deployment, not the
+// request's model, selects the model that answers.
+func NewAzureOpenAIProvider(apiKey, endpoint, deployment, apiVersion string, opts ...OpenAIOption) *OpenAIProvider {
+	opts = append([]OpenAIOption{WithOpenAIBaseURL(AzureOpenAIURL(endpoint, deployment, apiVersion))}, opts...)
+	p := NewOpenAIProvider(apiKey, opts...)
+	p.name = "azure-openai"
+	p.apiKeyHeader = "api-key"
+	return p
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestAzureOpenAIURL(t *testing.T) {
+	tests := []struct {
+		endpoint, deployment, version, want string
+	}{
+		{
+			endpoint: "https://res.openai.azure.com", deployment: "gpt-4o-prod", version: "2024-06-01",
+			want: "https://res.openai.azure.com/openai/deployments/gpt-4o-prod/chat/completions?api-version=2024-06-01",
+		},
+		{
+			endpoint: "https://res.openai.azure.com/openai/", deployment: "gpt-4o",
+			want: "https://res.openai.azure.com/openai/deployments/gpt-4o/chat/completions?api-version=" + DefaultAzureAPIVersion,
+		},
+	}
+	for _, tt := range tests {
+		if got := AzureOpenAIURL(tt.endpoint, tt.deployment, tt.version); got != tt.want {
+			t.Errorf("AzureOpenAIURL(%q, %q, %q) = %q, want %q", tt.endpoint, tt.deployment, tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestAzureOpenAIComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/openai/deployments/prod-4o/chat/completions" {
+			t.Errorf("path = %q, want the deployment's chat completions path", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("api-version"); got != "2024-06-01" {
+			t.Errorf("api-version = %q, want %q", got, "2024-06-01")
+		}
+		if got := r.Header.Get("api-key"); got != "azure-key" {
+			t.Errorf("api-key = %q, want %q", got, "azure-key")
+		}
+		if got := r.Header.Get("Authorization"); got != "" {
+			t.Errorf("Authorization = %q, want none", got)
+		}
+		if got := r.Header.Get("X-Team"); got != "evals" {
+			t.Errorf("X-Team = %q, want %q", got, "evals")
+		}
+		resp := openaiResponse{
+			Model:   "gpt-4o-2024-08-06",
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: strPtr("hi")}, FinishReason: "stop"}},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p := NewAzureOpenAIProvider("azure-key", server.URL, "prod-4o", "2024-06-01",
+		WithOpenAIMaxRetries(0),
+		WithOpenAIHeaders(map[string]string{"X-Team": "evals"}),
+	)
+	if p.Name() != "azure-openai" {
+		t.Errorf("Name() = %q, want %q", p.Name(), "azure-openai")
+	}
+
+	got, err := p.Complete(context.Background(), &Request{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got.Content != "hi" || got.Model != "gpt-4o-2024-08-06" {
+		t.Errorf("Complete() = %+v", got)
+	}
+}
@@ -0,0 +1,349 @@
+package provider
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultBatchWindow       = 2 * time.Second
+	defaultBatchPollInterval = 30 * time.Second
+
+	// maxBatchRequests is the most requests a batch may hold: the OpenAI
+	// Batch API's limit, which is below the Message Batches API's.
+	maxBatchRequests = 50_000
+)
+
+// ErrBatchPending is wrapped by the error a detached BatchProvider returns
+// for a request whose batch has not ended yet.
+var ErrBatchPending = errors.New("batch request pending")
+
+// BatchOption configures a BatchProvider.
+type BatchOption func(*BatchProvider)
+
+// WithBatchWindow sets how long a batch gathers requests after the first
+// one arrives before it is submitted.
+func WithBatchWindow(d time.Duration) BatchOption {
+	return func(b *BatchProvider) { b.window = d }
+}
+
+// WithBatchPollInterval sets how often a submitted batch is checked for
+// completion.
+func WithBatchPollInterval(d time.Duration) BatchOption {
+	return func(b *BatchProvider) { b.poll = d }
+}
+
+// WithBatchStore detaches the provider from its batches: instead of waiting
+// for a batch to end, Complete records the submitted request in s and
+// returns an error wrapping ErrBatchPending. Repeating the same requests
+// in a later process, as re-running the same suite does, returns the
+// results stored once their batch has ended, and submits only requests
+// not seen before, such as the next turn of an agent loop.
+func WithBatchStore(s *BatchStore) BatchOption {
+	return func(b *BatchProvider) { b.store = s }
+}
+
+// batchBackend is a vendor's batch API.
+type batchBackend interface {
+	// params builds the body of one batched request.
+	params(req *Request) ([]byte, error)
+	// submit creates a batch of the requests and returns its ID.
+	submit(ctx context.Context, reqs []batchRequest) (string, error)
+	// ended reports whether the batch has finished processing.
+	ended(ctx context.Context, id string) (bool, error)
+	// results returns the results of an ended batch by custom ID.
+	results(ctx context.Context, id string) (map[string]batchResult, error)
+}
+
+type batchRequest struct {
+	CustomID string          `json:"custom_id"`
+	Params   json.RawMessage `json:"params"`
+}
+
+// BatchProvider implements Provider on a vendor's batch API, which bills
+// requests at half the standard price in exchange for latency of minutes
+// to hours. Complete calls made within the batch window of the first
+// pending call are submitted together as one batch; each call blocks until
+// the batch ends and returns its own result, matched by custom ID. Running
+// a suite's cases concurrently therefore sends each round of their agent
+// loops as a single batch. WithBatchStore detaches the calls from the
+// batches instead.
+//
+// A batch keeps running when the callers waiting on it give up; their
+// results are discarded.
+type BatchProvider struct {
+	name    string
+	backend batchBackend
+	quota   *Quota
+	window  time.Duration
+	poll    time.Duration
+	store   *BatchStore
+
+	mu      sync.Mutex
+	pending []*batchCall
+	timer   *time.Timer
+	nextID  int
+
+	// checks holds the stored batches whose status this process has
+	// checked.
+	checks map[string]*batchCheck
+}
+
+type batchCall struct {
+	id     string
+	params json.RawMessage
+	done   chan batchResult
+}
+
+type batchResult struct {
+	resp *Response
+	err  error
+}
+
+type batchCheck struct {
+	once sync.Once
+	err  error
+}
+
+// NewAnthropicBatchProvider creates a provider that sends p's requests, with
+// its endpoint, key, headers, client, and quota, through the Message
+// Batches API. p's retry settings apply to the batch API calls themselves.
+func NewAnthropicBatchProvider(p *AnthropicProvider, opts ...BatchOption) *BatchProvider {
+	return newBatchProvider(p.Name(), anthropicBatches{p}, p.quota, opts)
+}
+
+func newBatchProvider(name string, backend batchBackend, quota *Quota, opts []BatchOption) *BatchProvider {
+	b := &BatchProvider{
+		name:    name,
+		backend: backend,
+		quota:   quota,
+		window:  defaultBatchWindow,
+		poll:    defaultBatchPollInterval,
+		checks:  make(map[string]*batchCheck),
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Name returns the name of the wrapped provider.
+func (b *BatchProvider) Name() string { return b.name }
+
+// CountTokens counts the input tokens of req with the batch API's
+// provider, which implements TokenCounter when its API can count them,
+// and estimates them otherwise.
+func (b *BatchProvider) CountTokens(ctx context.Context, req *Request) (int, error) {
+	if c, ok := b.backend.(TokenCounter); ok {
+		return c.CountTokens(ctx, req)
+	}
+	return EstimateTokens(req), nil
+}
+
+// Complete adds the request to the next batch and waits for its result,
+// or, when detached, for the batch to be submitted.
+func (b *BatchProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	params, err := b.backend.params(req)
+	if err != nil {
+		return nil, fmt.Errorf("building request body: %w", err)
+	}
+
+	var id string
+	if b.store != nil {
+		// Metadata such as the run ID differs between the run that
+		// submits a request and the one that collects it, so the key
+		// leaves it out.
+		keyParams := params
+		if len(req.Metadata) > 0 {
+			untagged := *req
+			untagged.Metadata = nil
+			if keyParams, err = b.backend.params(&untagged); err != nil {
+				return nil, fmt.Errorf("building request body: %w", err)
+			}
+		}
+		id = b.requestKey(keyParams)
+		if e, ok := b.store.lookup(id); ok {
+			return b.stored(ctx, id, e)
+		}
+	}
+	if err := b.quota.Take(); err != nil {
+		return nil, err
+	}
+
+	call := &batchCall{id: id, params: params, done: make(chan batchResult, 1)}
+	b.mu.Lock()
+	if call.id == "" {
+		b.nextID++
+		call.id = fmt.Sprintf("req-%d", b.nextID)
+	}
+	b.pending = append(b.pending, call)
+	switch {
+	case len(b.pending) >= maxBatchRequests:
+		b.submitLocked()
+	case b.timer == nil:
+		b.timer = time.AfterFunc(b.window, func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			b.submitLocked()
+		})
+	}
+	b.mu.Unlock()
+
+	select {
+	case r := <-call.done:
+		if r.err == nil {
+			b.quota.Add(r.resp.Usage)
+		}
+		return r.resp, r.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// requestKey returns the custom ID a detached provider stores a request
+// under: a digest of the provider and request body, numbered by the store
+// to tell identical requests apart.
+func (b *BatchProvider) requestKey(params []byte) string {
+	h := sha256.New()
+	h.Write([]byte(b.name))
+	h.Write([]byte{0})
+	h.Write(params)
+	return b.store.key(hex.EncodeToString(h.Sum(nil))[:32])
+}
+
+// stored returns the result of a request a detached provider submitted
+// earlier, collecting its batch's results first if the batch has ended.
+func (b *BatchProvider) stored(ctx context.Context, id string, e batchEntry) (*Response, error) {
+	if !e.Done {
+		b.mu.Lock()
+		c, ok := b.checks[e.Batch]
+		if !ok {
+			c = &batchCheck{}
+			b.checks[e.Batch] = c
+		}
+		b.mu.Unlock()
+		c.once.Do(func() { c.err = b.collect(ctx, e.Batch) })
+		if c.err != nil {
+			return nil, c.err
+		}
+		e, _ = b.store.lookup(id)
+		if !e.Done {
+			return nil, fmt.Errorf("%w: batch %s has not ended", ErrBatchPending, e.Batch)
+		}
+	}
+	if e.Error != "" {
+		return nil, errors.New(e.Error)
+	}
+	return e.Response, nil
+}
+
+// collect stores the results of batch id if it has ended.
+func (b *BatchProvider) collect(ctx context.Context, id string) error {
+	ended, err := b.backend.ended(ctx, id)
+	if err != nil {
+		return fmt.Errorf("checking batch %s: %w", id, err)
+	}
+	if !ended {
+		return nil
+	}
+	results, err := b.backend.results(ctx, id)
+	if err != nil {
+		return err
+	}
+	return b.store.complete(id, results)
+}
+
+// submitLocked starts a batch of the pending calls. b.mu must be held.
+func (b *BatchProvider) submitLocked() {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	if len(b.pending) == 0 {
+		return
+	}
+	calls := b.pending
+	b.pending = nil
+	go b.run(calls)
+}
+
+// run submits calls as one batch and delivers each call's result: once the
+// batch ends, or, when detached, as soon as it is recorded in the store.
+func (b *BatchProvider) run(calls []*batchCall) {
+	ctx := context.Background()
+	reqs := make([]batchRequest, len(calls))
+	ids := make([]string, len(calls))
+	for i, c := range calls {
+		reqs[i] = batchRequest{CustomID: c.id, Params: c.params}
+		ids[i] = c.id
+	}
+
+	var results map[string]batchResult
+	id, err := b.backend.submit(ctx, reqs)
+	switch {
+	case err != nil:
+		err = fmt.Errorf("creating batch: %w", err)
+	case b.store != nil:
+		err = b.store.addPending(b.name, id, ids)
+		if err == nil {
+			err = fmt.Errorf("%w: submitted in batch %s", ErrBatchPending, id)
+		}
+	default:
+		results, err = b.wait(ctx, id)
+	}
+
+	for _, c := range calls {
+		r, ok := results[c.id]
+		switch {
+		case err != nil:
+			r = batchResult{err: err}
+		case !ok:
+			r = batchResult{err: fmt.Errorf("batch returned no result for request %s", c.id)}
+		}
+		c.done <- r
+	}
+}
+
+// wait polls batch id until it ends and returns its results.
+func (b *BatchProvider) wait(ctx context.Context, id string) (map[string]batchResult, error) {
+	for {
+		ended, err := b.backend.ended(ctx, id)
+		if err != nil {
+			return nil, fmt.Errorf("polling batch %s: %w", id, err)
+		}
+		if ended {
+			return b.backend.results(ctx, id)
+		}
+		time.Sleep(b.poll)
+	}
+}
+
+// retryBatchCall makes a batch API request with send, retrying as the
+// provider's own requests would.
+func retryBatchCall(name string, maxRetries int, budget *RetryBudget, send func() ([]byte, error)) ([]byte, error) {
+	var lastErr error
+	for attempt := 0; attempt <= maxRetries; attempt++ {
+		if attempt > 0 {
+			backoff := retryBackoff(attempt, lastErr)
+			if !budget.Take(backoff) {
+				return nil, fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, lastErr)
+			}
+			time.Sleep(backoff)
+		}
+		data, err := send()
+		if err == nil {
+			return data, nil
+		}
+		if !isRetryable(err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+	return nil, &RetryError{Provider: name, Err: lastErr}
+}
@@ -0,0 +1,181 @@
+package provider
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// BatchStore records the requests detached BatchProviders have submitted
+// and, once their batches end, their results, in a JSON file, so that a
+// later process can pick up where an earlier one left off. It is safe for
+// concurrent use, and one store may be shared by several providers.
+type BatchStore struct {
+	path string
+
+	mu    sync.Mutex
+	state batchState
+	// seen counts the requests with each digest this process has looked
+	// up, so identical requests get distinct results.
+	seen map[string]int
+}
+
+type batchState struct {
+	Args     []string              `json:"args,omitempty"`
+	Requests map[string]batchEntry `json:"requests"`
+}
+
+// batchEntry is a submitted request, keyed by its custom ID.
+type batchEntry struct {
+	Provider string    `json:"provider"`
+	Batch    string    `json:"batch"`
+	Done     bool      `json:"done,omitempty"`
+	Response *Response `json:"response,omitempty"`
+	Error    string    `json:"error,omitempty"`
+}
+
+// OpenBatchStore loads the store saved at path, or starts an empty one if
+// there is no file there yet.
+func OpenBatchStore(path string) (*BatchStore, error) {
+	s := &BatchStore{
+		path:  path,
+		state: batchState{Requests: make(map[string]batchEntry)},
+		seen:  make(map[string]int),
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading batch store: %w", err)
+	}
+	if err := json.Unmarshal(data, &s.state); err != nil {
+		return nil, fmt.Errorf("parsing batch store %s: %w", path, err)
+	}
+	if s.state.Requests == nil {
+		s.state.Requests = make(map[string]batchEntry)
+	}
+	return s, nil
+}
+
+// Path returns the file the store is saved to.
+func (s *BatchStore) Path() string { return s.path }
+
+// Args returns the arguments recorded with SetArgs.
+func (s *BatchStore) Args() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.state.Args
+}
+
+// SetArgs records the command-line arguments of the run that submits the
+// batches, so that a later process can repeat it, and saves the store.
+func (s *BatchStore) SetArgs(args []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.state.Args = args
+	return s.saveLocked()
+}
+
+// Pending returns the number of submitted requests whose results have not
+// been collected, and the IDs of the batches they belong to.
+func (s *BatchStore) Pending() (requests int, batches []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seen := make(map[string]bool)
+	for _, e := range s.state.Requests {
+		if e.Done {
+			continue
+		}
+		requests++
+		if !seen[e.Batch] {
+			seen[e.Batch] = true
+			batches = append(batches, e.Batch)
+		}
+	}
+	sort.Strings(batches)
+	return requests, batches
+}
+
+// Remove deletes the store's file.
+func (s *BatchStore) Remove() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// key returns the ID of the next request with the given digest: the
+// digest numbered by how many identical requests came before it in this
+// process. A later process that repeats the same requests gets the same
+// IDs.
+func (s *BatchStore) key(digest string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.seen[digest]++
+	return fmt.Sprintf("%s-%d", digest, s.seen[digest])
+}
+
+func (s *BatchStore) lookup(id string) (batchEntry, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e, ok := s.state.Requests[id]
+	return e, ok
+}
+
+// addPending records the requests submitted in a batch.
+func (s *BatchStore) addPending(provider, batch string, ids []string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, id := range ids {
+		s.state.Requests[id] = batchEntry{Provider: provider, Batch: batch}
+	}
+	return s.saveLocked()
+}
+
+// complete records the results of an ended batch. Requests of the batch
+// missing from results fail.
+func (s *BatchStore) complete(batch string, results map[string]batchResult) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for id, e := range s.state.Requests {
+		if e.Batch != batch || e.Done {
+			continue
+		}
+		e.Done = true
+		r, ok := results[id]
+		switch {
+		case !ok:
+			e.Error = fmt.Sprintf("batch returned no result for request %s", id)
+		case r.err != nil:
+			e.Error = r.err.Error()
+		default:
+			e.Response = r.resp
+		}
+		s.state.Requests[id] = e
+	}
+	return s.saveLocked()
+}
+
+// saveLocked writes the store to its file. s.mu must be held.
+func (s *BatchStore) saveLocked() error {
+	data, err := json.MarshalIndent(s.state, "", "  ")
+	if err != nil {
+		return fmt.Errorf("encoding batch store: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return fmt.Errorf("creating batch store directory: %w", err)
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("writing batch store: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("writing batch store: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAnthropicBatchComplete(t *testing.T) {
+	var batches, polls atomic.Int32
+	var mu sync.Mutex
+	var requests []string // custom IDs with the user message they carried
+
+	mux := http.NewServeMux()
+	var serverURL string
+	mux.HandleFunc("POST /v1/messages/batches", func(w http.ResponseWriter, r *http.Request) {
+		batches.Add(1)
+		if got := r.Header.Get("X-Api-Key"); got != "test-key" {
+			t.Errorf("X-Api-Key = %q, want %q", got, "test-key")
+		}
+		var body struct {
+			Requests []struct {
+				CustomID string           `json:"custom_id"`
+				Params   anthropicRequest `json:"params"`
+			} `json:"requests"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding batch: %v", err)
+		}
+		mu.Lock()
+		for _, req := range body.Requests {
+			requests = append(requests, req.CustomID+"="+req.Params.Messages[0].Content.(string))
+		}
+		mu.Unlock()
+		fmt.Fprint(w, `{"id": "msgbatch_1", "processing_status": "in_progress"}`)
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1", func(w http.ResponseWriter, r *http.Request) {
+		if polls.Add(1) < 2 {
+			fmt.Fprint(w, `{"id": "msgbatch_1", "processing_status": "in_progress"}`)
+			return
+		}
+		fmt.Fprintf(w, `{"id": "msgbatch_1", "processing_status": "ended", "results_url": %q}`, serverURL+"/v1/messages/batches/msgbatch_1/results")
+	})
+	mux.HandleFunc("GET /v1/messages/batches/msgbatch_1/results", func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, req := range requests {
+			id, msg, _ := strings.Cut(req, "=")
+			if msg == "fail" {
+				fmt.Fprintf(w, `{"custom_id": %q, "result": {"type": "errored", "error": {"type": "error", "error": {"type": "invalid_request_error", "message": "bad request"}}}}`+"\n", id)
+				continue
+			}
+			fmt.Fprintf(w, `{"custom_id": %q, "result": {"type": "succeeded", "message": {"model": "claude-3-haiku-20240307", "content": [{"type": "text", "text": "echo: %s"}], "stop_reason": "end_turn", "usage": {"input_tokens": 5, "output_tokens": 3}}}}`+"\n", id, msg)
+		}
+	})
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	serverURL = server.URL
+
+	quota := &Quota{Name: "anthropic"}
+	p := NewAnthropicBatchProvider(
+		NewAnthropicProvider("test-key", WithBaseURL(server.URL+"/v1/messages"), WithQuota(quota)),
+		WithBatchWindow(20*time.Millisecond),
+		WithBatchPollInterval(time.Millisecond),
+	)
+
+	inputs := []string{"one", "two", "fail"}
+	got := make([]string, len(inputs))
+	errs := make([]error, len(inputs))
+	var wg sync.WaitGroup
+	for i, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := p.Complete(context.Background(), &Request{
+				Model:    "claude-3-haiku-20240307",
+				Messages: []Message{{Role: "user", Content: in}},
+			})
+			errs[i] = err
+			if err == nil {
+				got[i] = resp.Content
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := batches.Load(); n != 1 {
+		t.Errorf("batches = %d, want all requests in 1 batch", n)
+	}
+	for i, want := range []string{"echo: one", "echo: two"} {
+		if errs[i] != nil || got[i] != want {
+			t.Errorf("call %d = %q, %v, want %q", i, got[i], errs[i], want)
+		}
+	}
+	if errs[2] == nil || !strings.Contains(errs[2].Error(), "bad request") {
+		t.Errorf("errored request: error = %v, want the batch error", errs[2])
+	}
+	if requests, tokens := quota.Used(); requests != 3 || tokens != 16 {
+		t.Errorf("quota used %d requests, %d tokens, want 3 and 16", requests, tokens)
+	}
+}
+
+func TestAnthropicBatchComplete_CallerGivesUp(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id": "msgbatch_1", "processing_status": "in_progress"}`)
+	}))
+	defer server.Close()
+
+	p := NewAnthropicBatchProvider(
+		NewAnthropicProvider("test-key", WithBaseURL(server.URL)),
+		WithBatchWindow(time.Millisecond),
+		WithBatchPollInterval(time.Millisecond),
+	)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	_, err := p.Complete(ctx, &Request{Model: "m", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if err != context.DeadlineExceeded {
+		t.Errorf("error = %v, want context.DeadlineExceeded", err)
+	}
+}
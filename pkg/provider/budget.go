@@ -0,0 +1,68 @@
+package provider
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrRetryBudgetExhausted is wrapped by the error a provider returns when it
+// would have retried but the run's RetryBudget has nothing left.
+var ErrRetryBudgetExhausted = errors.New("run retry budget exhausted")
+
+// RetryBudget caps the retrying done across every provider call of a run,
+// so an outage fails the run quickly instead of each case independently
+// waiting out its full exponential backoff. Share one budget between all
+// providers of a run. It is safe for concurrent use.
+type RetryBudget struct {
+	// MaxRetries is the total number of retries allowed; zero means no
+	// limit.
+	MaxRetries int
+	// MaxBackoff is the total backoff time allowed; zero means no limit.
+	MaxBackoff time.Duration
+
+	mu        sync.Mutex
+	retries   int
+	backoff   time.Duration
+	exhausted bool
+}
+
+// Take reserves one retry that will wait backoff. It returns false, and
+// marks the budget exhausted, when the retry would exceed either limit. A
+// nil budget always allows the retry.
+func (b *RetryBudget) Take(backoff time.Duration) bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.exhausted ||
+		(b.MaxRetries > 0 && b.retries+1 > b.MaxRetries) ||
+		(b.MaxBackoff > 0 && b.backoff+backoff > b.MaxBackoff) {
+		b.exhausted = true
+		return false
+	}
+	b.retries++
+	b.backoff += backoff
+	return true
+}
+
+// Exhausted reports whether a retry has been refused.
+func (b *RetryBudget) Exhausted() bool {
+	if b == nil {
+		return false
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.exhausted
+}
+
+// Used returns the retries and backoff spent so far.
+func (b *RetryBudget) Used() RetryStats {
+	if b == nil {
+		return RetryStats{}
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return RetryStats{Retries: b.retries, Backoff: b.backoff}
+}
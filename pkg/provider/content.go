@@ -0,0 +1,86 @@
+package provider
+
+import (
+	"encoding/base64"
+	"fmt"
+)
+
+// Content part types for ContentPart.Type.
+const (
+	PartText     = "text"
+	PartImage    = "image"
+	PartDocument = "document"
+)
+
+// ContentPart is one block of a multimodal message: text, an image, or a
+// document such as a PDF. An image or document is given either by URL or
+// inline as Data with its MediaType, such as "image/png" or
+// "application/pdf".
+type ContentPart struct {
+	Type      string `json:"type"`
+	Text      string `json:"text,omitempty"`
+	URL       string `json:"url,omitempty"`
+	MediaType string `json:"media_type,omitempty"`
+	Data      []byte `json:"data,omitempty"` // base64 in JSON
+
+	// Name is a document's file name or title.
+	Name string `json:"name,omitempty"`
+}
+
+// TextPart returns a text content part.
+func TextPart(text string) ContentPart {
+	return ContentPart{Type: PartText, Text: text}
+}
+
+// ImageURLPart returns an image content part the API fetches from url.
+func ImageURLPart(url string) ContentPart {
+	return ContentPart{Type: PartImage, URL: url}
+}
+
+// ImagePart returns an inline image content part.
+func ImagePart(mediaType string, data []byte) ContentPart {
+	return ContentPart{Type: PartImage, MediaType: mediaType, Data: data}
+}
+
+// DocumentPart returns an inline document content part.
+func DocumentPart(name, mediaType string, data []byte) ContentPart {
+	return ContentPart{Type: PartDocument, Name: name, MediaType: mediaType, Data: data}
+}
+
+// dataURL returns the part's inline data as a data: URL, or its URL if it
+// has no inline data.
+func (cp ContentPart) dataURL() string {
+	if cp.Data == nil {
+		return cp.URL
+	}
+	return "data:" + cp.MediaType + ";base64," + base64.StdEncoding.EncodeToString(cp.Data)
+}
+
+// validate reports a part that cannot be sent to any API.
+func (cp ContentPart) validate() error {
+	switch cp.Type {
+	case PartText:
+		return nil
+	case PartImage, PartDocument:
+		if cp.URL == "" && cp.Data == nil {
+			return fmt.Errorf("%s part has neither a URL nor data", cp.Type)
+		}
+		if cp.Data != nil && cp.MediaType == "" {
+			return fmt.Errorf("inline %s part has no media type", cp.Type)
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown content part type %q", cp.Type)
+}
+
+// validateParts checks the content parts of msgs.
+func validateParts(msgs []Message) error {
+	for i, m := range msgs {
+		for _, cp := range m.Parts {
+			if err := cp.validate(); err != nil {
+				return fmt.Errorf("message %d: %w", i, err)
+			}
+		}
+	}
+	return nil
+}
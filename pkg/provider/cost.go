@@ -1,48 +1,209 @@
 package provider
 
-// modelPricing holds per-million-token pricing for known models.
-type modelPricing struct {
-	InputPerMillion  float64
-	OutputPerMillion float64
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Pricing holds a model's token costs in USD per million tokens.
+type Pricing struct {
+	InputPerMillion  float64 `yaml:"input"`
+	OutputPerMillion float64 `yaml:"output"`
+
+	// CacheReadPerMillion and CacheWritePerMillion price input tokens
+	// read from and written to the prompt cache. Zero prices them as
+	// ordinary input.
+	CacheReadPerMillion  float64 `yaml:"cache_read,omitempty"`
+	CacheWritePerMillion float64 `yaml:"cache_write,omitempty"`
 }
 
-// pricing maps model identifiers to their token costs in USD.
-var pricing = map[string]modelPricing{
-	// Claude 3 family
-	"claude-3-opus-20240229":   {InputPerMillion: 15.0, OutputPerMillion: 75.0},
-	"claude-3-sonnet-20240229": {InputPerMillion: 3.0, OutputPerMillion: 15.0},
-	"claude-3-haiku-20240307":  {InputPerMillion: 0.25, OutputPerMillion: 1.25},
+var (
+	pricingMu sync.RWMutex
+
+	// pricing maps model identifiers to their token costs.
+	pricing = map[string]Pricing{
+		// Claude 3 family
+		"claude-3-opus-20240229":   {InputPerMillion: 15.0, OutputPerMillion: 75.0, CacheReadPerMillion: 1.50, CacheWritePerMillion: 18.75},
+		"claude-3-sonnet-20240229": {InputPerMillion: 3.0, OutputPerMillion: 15.0},
+		"claude-3-haiku-20240307":  {InputPerMillion: 0.25, OutputPerMillion: 1.25, CacheReadPerMillion: 0.03, CacheWritePerMillion: 0.30},
+
+		// Claude 3.5 family
+		"claude-3-5-sonnet-20241022": {InputPerMillion: 3.0, OutputPerMillion: 15.0, CacheReadPerMillion: 0.30, CacheWritePerMillion: 3.75},
+		"claude-3-5-haiku-20241022":  {InputPerMillion: 0.80, OutputPerMillion: 4.0, CacheReadPerMillion: 0.08, CacheWritePerMillion: 1.0},
 
-	// Claude 3.5 family
-	"claude-3-5-sonnet-20241022": {InputPerMillion: 3.0, OutputPerMillion: 15.0},
-	"claude-3-5-haiku-20241022":  {InputPerMillion: 0.80, OutputPerMillion: 4.0},
+		// Claude 4 family
+		"claude-sonnet-4-5-20250929": {InputPerMillion: 3.0, OutputPerMillion: 15.0, CacheReadPerMillion: 0.30, CacheWritePerMillion: 3.75},
+		"claude-opus-4-6":            {InputPerMillion: 15.0, OutputPerMillion: 75.0, CacheReadPerMillion: 1.50, CacheWritePerMillion: 18.75},
 
-	// Claude 4 family
-	"claude-sonnet-4-5-20250929": {InputPerMillion: 3.0, OutputPerMillion: 15.0},
-	"claude-opus-4-6":            {InputPerMillion: 15.0, OutputPerMillion: 75.0},
+		// OpenAI GPT-4o family
+		"gpt-4o":      {InputPerMillion: 2.50, OutputPerMillion: 10.0, CacheReadPerMillion: 1.25},
+		"gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.60, CacheReadPerMillion: 0.075},
 
-	// OpenAI GPT-4o family
-	"gpt-4o":      {InputPerMillion: 2.50, OutputPerMillion: 10.0},
-	"gpt-4o-mini": {InputPerMillion: 0.15, OutputPerMillion: 0.60},
+		// OpenAI GPT-4 family
+		"gpt-4-turbo": {InputPerMillion: 10.0, OutputPerMillion: 30.0},
+		"gpt-4":       {InputPerMillion: 30.0, OutputPerMillion: 60.0},
 
-	// OpenAI GPT-4 family
-	"gpt-4-turbo": {InputPerMillion: 10.0, OutputPerMillion: 30.0},
-	"gpt-4":       {InputPerMillion: 30.0, OutputPerMillion: 60.0},
+		// OpenAI o-series
+		"o1":      {InputPerMillion: 15.0, OutputPerMillion: 60.0, CacheReadPerMillion: 7.50},
+		"o1-mini": {InputPerMillion: 3.0, OutputPerMillion: 12.0, CacheReadPerMillion: 1.50},
+		"o3-mini": {InputPerMillion: 1.10, OutputPerMillion: 4.40, CacheReadPerMillion: 0.55},
+	}
+)
+
+// fallbackPricing, when set, prices models with no pricing of their own.
+var fallbackPricing *Pricing
+
+// SetFallbackPricing sets the pricing EstimateCost uses for models the
+// pricing table does not cover, so their cost is estimated rather than
+// reported as $0. Nil removes the fallback.
+func SetFallbackPricing(p *Pricing) {
+	pricingMu.Lock()
+	defer pricingMu.Unlock()
+	fallbackPricing = p
+}
 
-	// OpenAI o-series
-	"o1":      {InputPerMillion: 15.0, OutputPerMillion: 60.0},
-	"o1-mini": {InputPerMillion: 3.0, OutputPerMillion: 12.0},
-	"o3-mini": {InputPerMillion: 1.10, OutputPerMillion: 4.40},
+// RegisterPricing sets the pricing of model, replacing any built-in
+// pricing, so that costs are estimated for fine-tunes, new models, and
+// negotiated rates.
+func RegisterPricing(model string, p Pricing) {
+	pricingMu.Lock()
+	defer pricingMu.Unlock()
+	pricing[model] = p
+}
+
+// LoadPricing registers the pricing in a prices file, which lists models
+// by provider with their rates in USD per million tokens:
+//
+//	anthropic:
+//	  claude-opus-4-6: {input: 15, output: 75, cache_read: 1.5, cache_write: 18.75}
+//	openai:
+//	  "ft:gpt-4o-mini-2024-07-18:acme::abc123": {input: 0.30, output: 1.20}
+//
+// Models are priced by name, so a model may appear under only one
+// provider. Models the file does not list keep their built-in pricing.
+func LoadPricing(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading prices: %w", err)
+	}
+	var file map[string]map[string]Pricing
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("parsing prices %s: %w", path, err)
+	}
+
+	providers := make([]string, 0, len(file))
+	for name := range file {
+		providers = append(providers, name)
+	}
+	sort.Strings(providers)
+	models := make(map[string]Pricing)
+	seenUnder := make(map[string]string)
+	var errs []error
+	for _, prov := range providers {
+		for model, p := range file[prov] {
+			if other, ok := seenUnder[model]; ok {
+				errs = append(errs, fmt.Errorf("%s: model %q is listed under both %s and %s", path, model, other, prov))
+				continue
+			}
+			seenUnder[model] = prov
+			if p.InputPerMillion < 0 || p.OutputPerMillion < 0 || p.CacheReadPerMillion < 0 || p.CacheWritePerMillion < 0 {
+				errs = append(errs, fmt.Errorf("%s: %s.%s: prices must be >= 0", path, prov, model))
+				continue
+			}
+			models[model] = p
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return err
+	}
+	for model, p := range models {
+		RegisterPricing(model, p)
+	}
+	return nil
+}
+
+// lookupPricing returns the pricing of model. A vendor-prefixed slug, as
+// used by OpenRouter, is priced as the model it names, and a model not in
+// the table as the longest model in it that it extends, so dated snapshots
+// such as "gpt-4o-2024-08-06" are priced as "gpt-4o". pricingMu must be
+// held.
+func lookupPricing(model string) (Pricing, bool) {
+	if p, ok := pricing[model]; ok {
+		return p, true
+	}
+	if i := strings.LastIndexByte(model, '/'); i >= 0 {
+		model = model[i+1:]
+		if p, ok := pricing[model]; ok {
+			return p, true
+		}
+	}
+	var best string
+	for name := range pricing {
+		if len(name) > len(best) && extendsModel(model, name) {
+			best = name
+		}
+	}
+	if best == "" {
+		return Pricing{}, false
+	}
+	return pricing[best], true
+}
+
+// extendsModel reports whether model is base with a suffix, such as a date
+// or version, set off by a separator.
+func extendsModel(model, base string) bool {
+	if len(model) <= len(base) || !strings.HasPrefix(model, base) {
+		return false
+	}
+	switch model[len(base)] {
+	case '-', '@', ':':
+		return true
+	}
+	return false
+}
+
+// HasPricing reports whether model has pricing of its own, found as
+// EstimateCost finds it, rather than only the fallback.
+func HasPricing(model string) bool {
+	pricingMu.RLock()
+	defer pricingMu.RUnlock()
+	_, ok := lookupPricing(model)
+	return ok
 }
 
 // EstimateCost returns the estimated USD cost for the given model and usage.
-// Returns 0 if the model is not in the pricing table.
+// A vendor-prefixed slug, as used by OpenRouter, is priced as the model it
+// names, so "openai/gpt-4o" costs the same as "gpt-4o", and a dated or
+// versioned model as the longest priced model it extends. Models with no
+// pricing are priced at the fallback set with SetFallbackPricing, or cost
+// 0 without one; HasPricing tells them apart.
 func EstimateCost(model string, usage Usage) float64 {
-	p, ok := pricing[model]
+	pricingMu.RLock()
+	p, ok := lookupPricing(model)
+	if !ok && fallbackPricing != nil {
+		p, ok = *fallbackPricing, true
+	}
+	pricingMu.RUnlock()
 	if !ok {
 		return 0
 	}
-	inputCost := float64(usage.InputTokens) / 1_000_000 * p.InputPerMillion
+	cacheRead, cacheWrite := p.CacheReadPerMillion, p.CacheWritePerMillion
+	if cacheRead == 0 {
+		cacheRead = p.InputPerMillion
+	}
+	if cacheWrite == 0 {
+		cacheWrite = p.InputPerMillion
+	}
+	uncached := usage.InputTokens - usage.CacheReadTokens - usage.CacheWriteTokens
+	inputCost := (float64(uncached)*p.InputPerMillion +
+		float64(usage.CacheReadTokens)*cacheRead +
+		float64(usage.CacheWriteTokens)*cacheWrite) / 1_000_000
 	outputCost := float64(usage.OutputTokens) / 1_000_000 * p.OutputPerMillion
 	return inputCost + outputCost
 }
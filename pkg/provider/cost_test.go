@@ -0,0 +1,99 @@
+package provider
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoadPricing(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "prices.yaml")
+	prices := `anthropic:
+  claude-test-tuned: {input: 2, output: 10, cache_read: 0.2}
+openai:
+  "ft:gpt-test:acme::abc": {input: 0.3, output: 1.2}
+  o3-mini: {input: 1, output: 4}
+`
+	if err := os.WriteFile(path, []byte(prices), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	builtin, _ := lookupPricing("o3-mini")
+	defer RegisterPricing("o3-mini", builtin)
+
+	if err := LoadPricing(path); err != nil {
+		t.Fatalf("LoadPricing() error: %v", err)
+	}
+	tests := []struct {
+		model string
+		usage Usage
+		want  float64
+	}{
+		{"ft:gpt-test:acme::abc", Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000}, 1.5},
+		{"openrouter/ft:gpt-test:acme::abc", Usage{InputTokens: 1_000_000}, 0.3},
+		{"claude-test-tuned", Usage{InputTokens: 1_000_000, CacheReadTokens: 1_000_000}, 0.2},
+		{"o3-mini", Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000}, 5}, // overridden
+		{"gpt-4o", Usage{InputTokens: 1_000_000}, 2.5},                         // built-in kept
+	}
+	for _, tt := range tests {
+		if got := EstimateCost(tt.model, tt.usage); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EstimateCost(%q) = %f, want %f", tt.model, got, tt.want)
+		}
+	}
+
+	bad := `anthropic:
+  shared-model: {input: 1, output: 2}
+openai:
+  shared-model: {input: 1, output: 2}
+  negative-model: {input: -1, output: 2}
+`
+	if err := os.WriteFile(path, []byte(bad), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	err := LoadPricing(path)
+	for _, want := range []string{`"shared-model" is listed under both anthropic and openai`, "openai.negative-model: prices must be >= 0"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("LoadPricing() error = %v, want %q", err, want)
+		}
+	}
+	if _, ok := lookupPricing("negative-model"); ok {
+		t.Error("an invalid prices file was partly registered")
+	}
+}
+
+func TestEstimateCost_PrefixAndFallback(t *testing.T) {
+	million := Usage{InputTokens: 1_000_000}
+	tests := []struct {
+		model string
+		want  float64
+		known bool
+	}{
+		{"gpt-4o-2024-08-06", 2.5, true},
+		{"gpt-4o-mini-2024-07-18", 0.15, true}, // longest match, not gpt-4o
+		{"openai/gpt-4o-2024-08-06", 2.5, true},
+		{"claude-opus-4-6@20260101", 15, true},
+		{"gpt-4.5-preview", 0, false}, // not a gpt-4 snapshot
+		{"mystery-model", 0, false},
+	}
+	for _, tt := range tests {
+		if got := EstimateCost(tt.model, million); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("EstimateCost(%q) = %f, want %f", tt.model, got, tt.want)
+		}
+		if got := HasPricing(tt.model); got != tt.known {
+			t.Errorf("HasPricing(%q) = %v, want %v", tt.model, got, tt.known)
+		}
+	}
+
+	SetFallbackPricing(&Pricing{InputPerMillion: 1, OutputPerMillion: 2})
+	defer SetFallbackPricing(nil)
+	if got := EstimateCost("mystery-model", Usage{InputTokens: 1_000_000, OutputTokens: 1_000_000}); math.Abs(got-3) > 1e-9 {
+		t.Errorf("EstimateCost() at fallback = %f, want 3", got)
+	}
+	if HasPricing("mystery-model") {
+		t.Error("HasPricing() should be false for a model priced only by the fallback")
+	}
+	if got := EstimateCost("gpt-4o", million); math.Abs(got-2.5) > 1e-9 {
+		t.Errorf("EstimateCost(gpt-4o) with a fallback = %f, want its own 2.5", got)
+	}
+}
@@ -1,3 +1,13 @@
 // Package provider defines the LLM provider interface and implementations
 // for communicating with language model APIs (Anthropic, OpenAI, etc).
+// EmbeddingProvider does the same for embedding APIs (OpenAI, Voyage).
+// CompositeProvider chains providers, falling back to the next when one
+// is unavailable.
+//
+// NewFromConfig constructs a provider from its eval.yaml entry by type.
+// Other packages add types with Register, usually from an init function:
+//
+//	func init() {
+//		provider.Register("bedrock", newBedrockProvider)
+//	}
 package provider
@@ -0,0 +1,136 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// EmbeddingProvider turns texts into vectors whose closeness reflects how
+// similar the texts are in meaning, for semantic-similarity judges and
+// clustering. OpenAIProvider and VoyageProvider implement it; Voyage is
+// the embedding service Anthropic recommends, as Anthropic has no
+// embedding API of its own.
+type EmbeddingProvider interface {
+	// Embed returns one embedding per input text, in input order.
+	Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error)
+
+	// Name returns the provider identifier (e.g. "voyage").
+	Name() string
+}
+
+// Embedding input types, for APIs that embed queries and the documents
+// they search differently.
+const (
+	EmbeddingQuery    = "query"
+	EmbeddingDocument = "document"
+)
+
+// EmbeddingRequest asks for the embeddings of Input.
+type EmbeddingRequest struct {
+	Model string
+	Input []string
+
+	// InputType is EmbeddingQuery, EmbeddingDocument, or empty for
+	// neither. APIs without the distinction, such as OpenAI's, ignore it.
+	InputType string
+}
+
+// EmbeddingResponse holds the embeddings of a request's inputs, in input
+// order. Usage counts the input tokens embedded.
+type EmbeddingResponse struct {
+	Embeddings [][]float64
+	Model      string
+	Usage      Usage
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, from
+// -1 for opposite to 1 for the same direction. It returns 0 when either is
+// a zero vector or their lengths differ.
+func CosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) {
+		return 0
+	}
+	var dot, na, nb float64
+	for i := range a {
+		dot += a[i] * b[i]
+		na += a[i] * a[i]
+		nb += b[i] * b[i]
+	}
+	if na == 0 || nb == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(na) * math.Sqrt(nb))
+}
+
+// embeddingData is an embedding in OpenAI's and Voyage's response bodies,
+// which share their shape.
+type embeddingData struct {
+	Embedding []float64 `json:"embedding"`
+	Index     int       `json:"index"`
+}
+
+// embeddingsResponse is the embeddings response body of the OpenAI and
+// Voyage APIs.
+type embeddingsResponse struct {
+	Data  []embeddingData `json:"data"`
+	Model string          `json:"model"`
+	Usage struct {
+		PromptTokens int `json:"prompt_tokens"`
+		TotalTokens  int `json:"total_tokens"`
+	} `json:"usage"`
+}
+
+// parseEmbeddings decodes an embeddings response body for n inputs.
+func parseEmbeddings(data []byte, n int) (*EmbeddingResponse, error) {
+	var er embeddingsResponse
+	if err := json.Unmarshal(data, &er); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+	if len(er.Data) != n {
+		return nil, fmt.Errorf("got %d embeddings for %d inputs", len(er.Data), n)
+	}
+	sort.Slice(er.Data, func(i, j int) bool { return er.Data[i].Index < er.Data[j].Index })
+	resp := &EmbeddingResponse{
+		Embeddings: make([][]float64, n),
+		Model:      er.Model,
+		Usage:      Usage{InputTokens: er.Usage.PromptTokens},
+	}
+	if resp.Usage.InputTokens == 0 {
+		resp.Usage.InputTokens = er.Usage.TotalTokens
+	}
+	for i, d := range er.Data {
+		resp.Embeddings[i] = d.Embedding
+	}
+	return resp, nil
+}
+
+// Embed returns the embeddings of req's inputs from the OpenAI embeddings
+// endpoint, which sits beside the chat completions or responses endpoint
+// the provider is configured with.
+func (p *OpenAIProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if len(req.Input) == 0 {
+		return nil, errors.New("embedding request has no input")
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"model":           req.Model,
+		"input":           req.Input,
+		"encoding_format": "float",
+	})
+	if err != nil {
+		return nil, fmt.Errorf("building request body: %w", err)
+	}
+	root := strings.TrimSuffix(strings.TrimSuffix(p.baseURL, "/chat/completions"), "/responses")
+	data, err := retryBatchCall(p.Name(), p.maxRetries, p.budget, func() ([]byte, error) {
+		return p.send(ctx, http.MethodPost, root+"/embeddings", "application/json", body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseEmbeddings(data, len(req.Input))
+}
@@ -0,0 +1,103 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestCosineSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b []float64
+		want float64
+	}{
+		{[]float64{1, 0}, []float64{2, 0}, 1},
+		{[]float64{1, 0}, []float64{0, 3}, 0},
+		{[]float64{1, 1}, []float64{-1, -1}, -1},
+		{[]float64{0, 0}, []float64{1, 1}, 0},
+		{[]float64{1}, []float64{1, 1}, 0},
+	}
+	for _, tt := range tests {
+		if got := CosineSimilarity(tt.a, tt.b); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("CosineSimilarity(%v, %v) = %f, want %f", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// embeddingServer answers embeddings requests out of index order, as the
+// APIs may, and records the last request body.
+func embeddingServer(t *testing.T, path string, got *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != path {
+			t.Errorf("path = %q, want %q", r.URL.Path, path)
+		}
+		if err := json.NewDecoder(r.Body).Decode(got); err != nil {
+			t.Errorf("decoding request body: %v", err)
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data": [{"embedding": [0, 1], "index": 1}, {"embedding": [1, 0], "index": 0}],
+			"model": "embed-model", "usage": {"prompt_tokens": 7, "total_tokens": 7}}`))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestOpenAIEmbed(t *testing.T) {
+	var body map[string]interface{}
+	server := embeddingServer(t, "/v1/embeddings", &body)
+	p := NewOpenAIProvider("k", WithOpenAIBaseURL(server.URL+"/v1/chat/completions"))
+
+	var _ EmbeddingProvider = p
+	resp, err := p.Embed(context.Background(), &EmbeddingRequest{Model: "text-embedding-3-small", Input: []string{"a", "b"}})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if len(resp.Embeddings) != 2 || resp.Embeddings[0][0] != 1 || resp.Embeddings[1][1] != 1 {
+		t.Errorf("Embeddings = %v, want them in input order", resp.Embeddings)
+	}
+	if resp.Usage.InputTokens != 7 || resp.Model != "embed-model" {
+		t.Errorf("Usage = %+v, Model = %q", resp.Usage, resp.Model)
+	}
+	if body["model"] != "text-embedding-3-small" {
+		t.Errorf("request model = %v", body["model"])
+	}
+}
+
+func TestVoyageEmbed(t *testing.T) {
+	var body map[string]interface{}
+	server := embeddingServer(t, "/v1/embeddings", &body)
+	p := NewVoyageProvider("k", WithVoyageBaseURL(server.URL+"/v1/embeddings"))
+
+	var _ EmbeddingProvider = p
+	resp, err := p.Embed(context.Background(), &EmbeddingRequest{Model: "voyage-3", Input: []string{"a", "b"}, InputType: EmbeddingQuery})
+	if err != nil {
+		t.Fatalf("Embed() error: %v", err)
+	}
+	if len(resp.Embeddings) != 2 || resp.Embeddings[0][0] != 1 {
+		t.Errorf("Embeddings = %v, want them in input order", resp.Embeddings)
+	}
+	if body["input_type"] != "query" || body["model"] != "voyage-3" {
+		t.Errorf("request body = %v", body)
+	}
+
+	if _, err := p.Embed(context.Background(), &EmbeddingRequest{Model: "voyage-3"}); err == nil {
+		t.Error("Embed() with no input should fail")
+	}
+}
+
+func TestVoyageEmbed_ClientError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"detail": "model not found"}`))
+	}))
+	defer server.Close()
+	p := NewVoyageProvider("k", WithVoyageBaseURL(server.URL))
+	_, err := p.Embed(context.Background(), &EmbeddingRequest{Model: "nope", Input: []string{"a"}})
+	if err == nil || err.Error() != "HTTP 400: model not found" {
+		t.Errorf("Embed() error = %v, want the API's message", err)
+	}
+}
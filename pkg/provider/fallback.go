@@ -0,0 +1,94 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Fallback is a provider a CompositeProvider falls back to. Model, when
+// set, replaces the request's model, since a secondary vendor rarely
+// serves the primary's model under the same name.
+type Fallback struct {
+	Provider Provider
+	Model    string
+}
+
+// CompositeProvider sends each request to a primary provider and, when it
+// fails with an error that another provider might not, such as exhausted
+// retries, an overload, or an unavailable model, to each fallback in turn.
+// The response names the provider that served it. Errors that the request
+// itself caused, like a malformed request, are returned without falling
+// back.
+type CompositeProvider struct {
+	primary   Provider
+	fallbacks []Fallback
+}
+
+// NewCompositeProvider returns a provider that tries primary and then
+// fallbacks, in order.
+func NewCompositeProvider(primary Provider, fallbacks ...Fallback) *CompositeProvider {
+	return &CompositeProvider{primary: primary, fallbacks: fallbacks}
+}
+
+// Name returns the primary provider's name.
+func (p *CompositeProvider) Name() string { return p.primary.Name() }
+
+// Complete sends req to the first provider in the chain that serves it.
+// The response's Retry totals the retries of every provider tried,
+// counting each fall back as one more retry. When every provider fails,
+// the error lists each one's, as a RetryError when any of them retried.
+func (p *CompositeProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
+	var retry RetryStats
+	var errs []error
+	retried := false
+	for i := 0; i <= len(p.fallbacks); i++ {
+		prov, r := p.primary, req
+		if i > 0 {
+			retry.Retries++
+			fb := p.fallbacks[i-1]
+			prov = fb.Provider
+			if fb.Model != "" {
+				cp := *req
+				cp.Model = fb.Model
+				r = &cp
+			}
+		}
+		resp, err := prov.Complete(ctx, r)
+		if err == nil {
+			resp.Retry.Retries += retry.Retries
+			resp.Retry.Backoff += retry.Backoff
+			resp.Retry.Overloaded += retry.Overloaded
+			resp.Provider, resp.Fallbacks = prov.Name(), i
+			return resp, nil
+		}
+		var re *RetryError
+		if errors.As(err, &re) {
+			retried = true
+			retry.Retries += re.Retry.Retries
+			retry.Backoff += re.Retry.Backoff
+			retry.Overloaded += re.Retry.Overloaded
+		}
+		if i == 0 && (ctx.Err() != nil || !shouldFallBack(err)) {
+			return nil, err
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", prov.Name(), err))
+		if ctx.Err() != nil || !shouldFallBack(err) {
+			break
+		}
+	}
+	err := fmt.Errorf("all %d providers failed: %w", len(errs), errors.Join(errs...))
+	if retried {
+		return nil, &RetryError{Provider: p.Name(), Retry: retry, Err: err}
+	}
+	return nil, err
+}
+
+// shouldFallBack reports whether err is a failure another provider might
+// not share: retries exhausted on transient errors, an overloaded or
+// retryable response, or a model the provider does not serve.
+func shouldFallBack(err error) bool {
+	var re *RetryError
+	return errors.As(err, &re) || isRetryable(err) ||
+		errors.Is(err, ErrOverloaded) || errors.Is(err, ErrModelUnavailable)
+}
@@ -0,0 +1,102 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scriptedProvider fails with err, when set, and records the model of
+// each request it gets.
+type scriptedProvider struct {
+	name   string
+	err    error
+	models []string
+}
+
+func (s *scriptedProvider) Name() string { return s.name }
+func (s *scriptedProvider) Complete(_ context.Context, req *Request) (*Response, error) {
+	s.models = append(s.models, req.Model)
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Response{Content: "from " + s.name}, nil
+}
+
+func TestCompositeProvider(t *testing.T) {
+	exhausted := &RetryError{Provider: "primary", Retry: RetryStats{Retries: 3, Backoff: time.Second}, Err: errors.New("HTTP 503")}
+	primary := &scriptedProvider{name: "primary", err: exhausted}
+	unavailable := &scriptedProvider{name: "second", err: ErrModelUnavailable}
+	third := &scriptedProvider{name: "third"}
+	p := NewCompositeProvider(primary, Fallback{Provider: unavailable}, Fallback{Provider: third, Model: "third-model"})
+
+	resp, err := p.Complete(context.Background(), &Request{Model: "primary-model"})
+	if err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if resp.Content != "from third" || resp.Provider != "third" || resp.Fallbacks != 2 {
+		t.Errorf("resp = %+v, want third to serve after 2 fallbacks", resp)
+	}
+	if resp.Retry.Retries != 5 || resp.Retry.Backoff != time.Second {
+		t.Errorf("Retry = %+v, want the primary's 3 retries plus 2 fallbacks", resp.Retry)
+	}
+	if unavailable.models[0] != "primary-model" || third.models[0] != "third-model" {
+		t.Errorf("models = %v, %v, want fallback models to replace the request's only when set", unavailable.models, third.models)
+	}
+	if p.Name() != "primary" {
+		t.Errorf("Name() = %q, want the primary's", p.Name())
+	}
+}
+
+func TestCompositeProvider_NoFallback(t *testing.T) {
+	bad := errors.New("HTTP 400: invalid request")
+	second := &scriptedProvider{name: "second"}
+	p := NewCompositeProvider(&scriptedProvider{name: "primary", err: bad}, Fallback{Provider: second})
+	if _, err := p.Complete(context.Background(), &Request{}); err != bad {
+		t.Errorf("Complete() error = %v, want the request error unchanged", err)
+	}
+	if len(second.models) != 0 {
+		t.Error("a request error should not fall back")
+	}
+
+	// A served primary reports itself with no fallbacks.
+	p = NewCompositeProvider(second, Fallback{Provider: &scriptedProvider{name: "third"}})
+	if resp, err := p.Complete(context.Background(), &Request{}); err != nil || resp.Provider != "second" || resp.Fallbacks != 0 {
+		t.Errorf("Complete() = %+v, %v", resp, err)
+	}
+}
+
+func TestCompositeProvider_AllFail(t *testing.T) {
+	exhausted := &RetryError{Provider: "primary", Retry: RetryStats{Retries: 2}, Err: ErrOverloaded}
+	p := NewCompositeProvider(
+		&scriptedProvider{name: "primary", err: exhausted},
+		Fallback{Provider: &scriptedProvider{name: "second", err: errors.New("HTTP 401: bad key")}},
+	)
+	_, err := p.Complete(context.Background(), &Request{})
+	var re *RetryError
+	if !errors.As(err, &re) || re.Retry.Retries != 3 {
+		t.Fatalf("Complete() error = %v, want a RetryError totalling the chain's retries", err)
+	}
+	for _, want := range []string{"all 2 providers failed", "second: HTTP 401"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q missing %q", err, want)
+		}
+	}
+}
+
+func TestModelUnavailable(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		w.Write([]byte(`{"type": "error", "error": {"type": "not_found_error", "message": "model: claude-old"}}`))
+	}))
+	defer server.Close()
+	p := NewAnthropicProvider("k", WithBaseURL(server.URL), WithMaxRetries(0))
+	_, err := p.Complete(context.Background(), &Request{Model: "claude-old", Messages: []Message{{Role: "user", Content: "hi"}}})
+	if !errors.Is(err, ErrModelUnavailable) {
+		t.Errorf("Complete() error = %v, want ErrModelUnavailable", err)
+	}
+}
@@ -0,0 +1,57 @@
+package provider
+
+import "sync"
+
+// KeyPool spreads a provider's requests over several API keys, raising
+// the throughput a large run can get under per-key rate limits. Requests
+// either take the keys in turn or keep using one key until a response
+// reports it rate limited; either way, a rate-limited request is retried
+// with the next key. Share one KeyPool
+// between every instance of the same provider in a run. It is safe for
+// concurrent use.
+type KeyPool struct {
+	keys   []string
+	sticky bool
+
+	mu   sync.Mutex
+	next int
+}
+
+// NewKeyPool returns a pool of keys, which must not be empty. With
+// stickUntilLimited, requests use one key until it is rate limited instead
+// of rotating on every request.
+func NewKeyPool(keys []string, stickUntilLimited bool) *KeyPool {
+	return &KeyPool{keys: append([]string(nil), keys...), sticky: stickUntilLimited}
+}
+
+// Key returns the key to send the next request with.
+func (k *KeyPool) Key() string {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	key := k.keys[k.next]
+	if !k.sticky {
+		k.next = (k.next + 1) % len(k.keys)
+	}
+	return key
+}
+
+// keyOr returns the pool's next key, or fallback for a nil pool.
+func (k *KeyPool) keyOr(fallback string) string {
+	if k == nil {
+		return fallback
+	}
+	return k.Key()
+}
+
+// RateLimited records that a request sent with key was rate limited, so
+// that the retry goes out with another key. A nil pool ignores it.
+func (k *KeyPool) RateLimited(key string) {
+	if k == nil {
+		return
+	}
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.keys[k.next] == key {
+		k.next = (k.next + 1) % len(k.keys)
+	}
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestKeyPool_RoundRobin(t *testing.T) {
+	pool := NewKeyPool([]string{"a", "b", "c"}, false)
+	var got []string
+	for i := 0; i < 4; i++ {
+		got = append(got, pool.Key())
+	}
+	if want := []string{"a", "b", "c", "a"}; !slices.Equal(got, want) {
+		t.Errorf("keys = %v, want %v", got, want)
+	}
+
+	// The next key is skipped once it is known to be rate limited.
+	pool.RateLimited("b")
+	if k := pool.Key(); k != "c" {
+		t.Errorf("Key() after b was rate limited = %q, want c", k)
+	}
+}
+
+func TestKeyPool_OnRateLimit(t *testing.T) {
+	pool := NewKeyPool([]string{"a", "b"}, true)
+	if pool.Key() != "a" || pool.Key() != "a" {
+		t.Fatal("sticky pool should keep its first key")
+	}
+	pool.RateLimited("a")
+	if k := pool.Key(); k != "b" {
+		t.Errorf("Key() after a was rate limited = %q, want b", k)
+	}
+	// A stale report for a key no longer in use changes nothing.
+	pool.RateLimited("a")
+	if k := pool.Key(); k != "b" {
+		t.Errorf("Key() after a stale report = %q, want b", k)
+	}
+
+	var nilPool *KeyPool
+	nilPool.RateLimited("a")
+	if k := nilPool.keyOr("own"); k != "own" {
+		t.Errorf("nil pool keyOr() = %q, want own", k)
+	}
+}
+
+func TestOpenAIProvider_KeyPool(t *testing.T) {
+	var mu sync.Mutex
+	var auths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		auths = append(auths, r.Header.Get("Authorization"))
+		first := len(auths) == 1
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusTooManyRequests)
+			w.Write([]byte(`{"error": {"message": "rate limited"}}`))
+			return
+		}
+		w.Write([]byte(`{"choices": [{"message": {"role": "assistant", "content": "ok"}, "finish_reason": "stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("unused",
+		WithOpenAIBaseURL(server.URL),
+		WithOpenAIKeyPool(NewKeyPool([]string{"k1", "k2"}, true)),
+	)
+	if _, err := p.Complete(context.Background(), &Request{Model: "gpt-4o", Messages: []Message{{Role: "user", Content: "hi"}}}); err != nil {
+		t.Fatalf("Complete() error: %v", err)
+	}
+	if want := []string{"Bearer k1", "Bearer k2"}; !slices.Equal(auths, want) {
+		t.Errorf("Authorization headers = %v, want %v", auths, want)
+	}
+}
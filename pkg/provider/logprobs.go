@@ -0,0 +1,35 @@
+package provider
+
+import "math"
+
+// TokenLogprob is the natural-log probability the model assigned to one
+// output token. TopLogprobs lists the likeliest tokens at its position,
+// most likely first, when the request asked for them.
+type TokenLogprob struct {
+	Token       string         `json:"token"`
+	Logprob     float64        `json:"logprob"`
+	TopLogprobs []TokenLogprob `json:"top_logprobs,omitempty"`
+}
+
+// MeanLogprob returns the average log probability of the tokens, or 0 for
+// none. Values near 0 mean the model was confident in its output.
+func MeanLogprob(tokens []TokenLogprob) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, t := range tokens {
+		sum += t.Logprob
+	}
+	return sum / float64(len(tokens))
+}
+
+// Perplexity returns the perplexity of the tokens: e raised to their
+// negative mean log probability. It is 1 when the model was certain of
+// every token and grows as it was less so; it is 0 for no tokens.
+func Perplexity(tokens []TokenLogprob) float64 {
+	if len(tokens) == 0 {
+		return 0
+	}
+	return math.Exp(-MeanLogprob(tokens))
+}
@@ -6,8 +6,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"math"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -33,12 +33,61 @@ func WithOpenAIMaxRetries(n int) OpenAIOption {
 	return func(p *OpenAIProvider) { p.maxRetries = n }
 }
 
+// WithOpenAIHeaders sets extra HTTP headers sent with every request. They
+// are applied after the standard headers and may override them, which also
+// makes this option suitable for OpenAI-compatible gateways.
+func WithOpenAIHeaders(h map[string]string) OpenAIOption {
+	return func(p *OpenAIProvider) { p.headers = h }
+}
+
+// WithOpenAIRetryBudget shares a run-level retry budget with other providers. Once it
+// is exhausted, failed calls return immediately instead of retrying.
+func WithOpenAIRetryBudget(b *RetryBudget) OpenAIOption {
+	return func(p *OpenAIProvider) { p.budget = b }
+}
+
+// WithOpenAIQuota caps this provider's requests and tokens over the run. Share
+// one Quota between every instance of the provider; once it is used up,
+// calls fail with an error wrapping ErrQuotaExceeded.
+func WithOpenAIQuota(q *Quota) OpenAIOption {
+	return func(p *OpenAIProvider) { p.quota = q }
+}
+
+// WithOpenAIRateLimiter paces this provider's requests below its rate
+// limits. Share one RateLimiter between every instance of the provider.
+func WithOpenAIRateLimiter(l *RateLimiter) OpenAIOption {
+	return func(p *OpenAIProvider) { p.limiter = l }
+}
+
+// WithOpenAIKeyPool sends each request with a key from pool instead of the
+// provider's own API key.
+func WithOpenAIKeyPool(pool *KeyPool) OpenAIOption {
+	return func(p *OpenAIProvider) { p.keys = pool }
+}
+
 // OpenAIProvider implements Provider for the OpenAI Chat Completions API.
 type OpenAIProvider struct {
 	apiKey     string
+	keys       *KeyPool
 	baseURL    string
 	client     *http.Client
 	maxRetries int
+	budget     *RetryBudget
+	quota      *Quota
+	limiter    *RateLimiter
+	headers    map[string]string
+
+	// name and apiKeyHeader are set for OpenAI-compatible services that
+	// identify themselves differently, such as Azure OpenAI. An empty
+	// apiKeyHeader sends the key as a Bearer token.
+	name         string
+	apiKeyHeader string
+
+	// routing is sent as the provider object of OpenRouter requests.
+	routing *OpenRouterRouting
+
+	// responsesAPI targets the Responses API instead of Chat Completions.
+	responsesAPI bool
 }
 
 // NewOpenAIProvider creates a new OpenAI provider with the given API key.
@@ -46,7 +95,7 @@ func NewOpenAIProvider(apiKey string, opts ...OpenAIOption) *OpenAIProvider {
 	p := &OpenAIProvider{
 		apiKey:     apiKey,
 		baseURL:    defaultOpenAIURL,
-		client:     &http.Client{Timeout: 60 * time.Second},
+		client:     defaultClient,
 		maxRetries: defaultMaxRetries,
 	}
 	for _, opt := range opts {
@@ -55,16 +104,30 @@ func NewOpenAIProvider(apiKey string, opts ...OpenAIOption) *OpenAIProvider {
 	return p
 }
 
-// Name returns "openai".
-func (p *OpenAIProvider) Name() string { return "openai" }
+// Name returns "openai", or the name of the OpenAI-compatible service,
+// such as "azure-openai" or "openrouter".
+func (p *OpenAIProvider) Name() string {
+	if p.name != "" {
+		return p.name
+	}
+	return "openai"
+}
 
 // openaiRequest is the OpenAI Chat Completions API request body.
 type openaiRequest struct {
 	Model       string          `json:"model"`
 	Messages    []openaiMessage `json:"messages"`
 	Tools       []openaiTool    `json:"tools,omitempty"`
+	ToolChoice  interface{}     `json:"tool_choice,omitempty"`
 	Temperature *float64        `json:"temperature,omitempty"`
 	MaxTokens   *int            `json:"max_tokens,omitempty"`
+	Logprobs    bool            `json:"logprobs,omitempty"`
+	TopLogprobs int             `json:"top_logprobs,omitempty"`
+	User        string          `json:"user,omitempty"`
+	Seed        *int64          `json:"seed,omitempty"`
+
+	// Provider holds OpenRouter's routing preferences.
+	Provider *OpenRouterRouting `json:"provider,omitempty"`
 }
 
 type openaiMessage struct {
@@ -72,6 +135,34 @@ type openaiMessage struct {
 	Content    *string          `json:"content"`
 	ToolCalls  []openaiToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string           `json:"tool_call_id,omitempty"`
+
+	// Parts, when set, is sent as the content instead of Content.
+	Parts []openaiContentPart `json:"-"`
+}
+
+// MarshalJSON sends Parts, when set, as the message's content array.
+func (m openaiMessage) MarshalJSON() ([]byte, error) {
+	type plain openaiMessage
+	if len(m.Parts) == 0 {
+		return json.Marshal(plain(m))
+	}
+	return json.Marshal(struct {
+		plain
+		Content []openaiContentPart `json:"content"`
+	}{plain(m), m.Parts})
+}
+
+// openaiContentPart is a content part of a Chat Completions user message.
+type openaiContentPart struct {
+	Type     string `json:"type"`
+	Text     string `json:"text,omitempty"`
+	ImageURL *struct {
+		URL string `json:"url"`
+	} `json:"image_url,omitempty"`
+	File *struct {
+		Filename string `json:"filename"`
+		FileData string `json:"file_data"`
+	} `json:"file,omitempty"`
 }
 
 type openaiTool struct {
@@ -100,17 +191,34 @@ type openaiCallFunction struct {
 type openaiResponse struct {
 	ID      string         `json:"id"`
 	Object  string         `json:"object"`
+	Model   string         `json:"model"`
 	Choices []openaiChoice `json:"choices"`
 	Usage   struct {
-		PromptTokens     int `json:"prompt_tokens"`
-		CompletionTokens int `json:"completion_tokens"`
+		PromptTokens        int `json:"prompt_tokens"`
+		CompletionTokens    int `json:"completion_tokens"`
+		PromptTokensDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"prompt_tokens_details"`
 	} `json:"usage"`
+
+	// SystemFingerprint identifies the backend configuration that served
+	// the request.
+	SystemFingerprint string `json:"system_fingerprint"`
+
+	// Provider is the upstream provider that served an OpenRouter request.
+	Provider string `json:"provider"`
 }
 
 type openaiChoice struct {
 	Index        int           `json:"index"`
 	Message      openaiMessage `json:"message"`
 	FinishReason string        `json:"finish_reason"`
+
+	// Logprobs holds the log probabilities of the message's tokens, whose
+	// fields match TokenLogprob's.
+	Logprobs *struct {
+		Content []TokenLogprob `json:"content"`
+	} `json:"logprobs"`
 }
 
 type openaiErrorResponse struct {
@@ -121,24 +229,42 @@ type openaiErrorResponse struct {
 	} `json:"error"`
 }
 
-// Complete sends a request to the OpenAI Chat Completions API.
+// Complete sends a request to the OpenAI Chat Completions API, or to the
+// Responses API when the provider was created WithOpenAIResponsesAPI.
 func (p *OpenAIProvider) Complete(ctx context.Context, req *Request) (*Response, error) {
-	body, err := p.buildRequestBody(req)
+	build := p.buildRequestBody
+	if p.responsesAPI {
+		build = buildResponsesRequestBody
+	}
+	body, err := build(req)
 	if err != nil {
 		return nil, fmt.Errorf("building request body: %w", err)
 	}
 
+	estimated := EstimateTokens(req)
 	var lastErr error
+	var retry RetryStats
 	for attempt := 0; attempt <= p.maxRetries; attempt++ {
 		if attempt > 0 {
-			backoff := baseBackoff * time.Duration(math.Pow(2, float64(attempt-1)))
+			backoff := retryBackoff(attempt, lastErr)
+			if !p.budget.Take(backoff) {
+				return nil, &RetryError{Provider: p.Name(), Retry: retry, Err: fmt.Errorf("%w: %v", ErrRetryBudgetExhausted, lastErr)}
+			}
 			select {
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			case <-time.After(backoff):
 			}
+			retry.Retries = attempt
+			retry.Backoff += backoff
 		}
 
+		if err := p.limiter.Wait(ctx, estimated); err != nil {
+			return nil, err
+		}
+		if err := p.quota.Take(); err != nil {
+			return nil, err
+		}
 		resp, err := p.doRequest(ctx, body)
 		if err != nil {
 			if !isRetryable(err) {
@@ -147,28 +273,37 @@ func (p *OpenAIProvider) Complete(ctx context.Context, req *Request) (*Response,
 			lastErr = err
 			continue
 		}
+		p.quota.Add(resp.Usage)
+		p.limiter.Settle(estimated, resp.Usage)
+		resp.Retry = retry
 		return resp, nil
 	}
 
-	return nil, fmt.Errorf("openai API request failed after %d attempts: %w", p.maxRetries+1, lastErr)
+	return nil, &RetryError{Provider: p.Name(), Retry: retry, Err: lastErr}
 }
 
 func (p *OpenAIProvider) buildRequestBody(req *Request) ([]byte, error) {
+	if err := checkOpenAIParts(req.Messages, false); err != nil {
+		return nil, err
+	}
 	or := openaiRequest{
 		Model:    req.Model,
 		Messages: convertToOpenAIMessages(req.System, req.Messages),
+		User:     MetadataUser(req.Metadata),
+		Provider: p.routing,
+		Seed:     req.Seed,
 	}
-
-	if req.Temperature != 0 {
-		t := req.Temperature
-		or.Temperature = &t
-	}
+	or.Temperature = req.temperature(true)
 
 	if req.MaxTokens != 0 {
 		m := req.MaxTokens
 		or.MaxTokens = &m
 	}
 
+	// The API only accepts top_logprobs along with logprobs.
+	or.Logprobs = req.Logprobs || req.TopLogprobs > 0
+	or.TopLogprobs = req.TopLogprobs
+
 	for _, tool := range req.Tools {
 		or.Tools = append(or.Tools, openaiTool{
 			Type: "function",
@@ -179,10 +314,28 @@ func (p *OpenAIProvider) buildRequestBody(req *Request) ([]byte, error) {
 			},
 		})
 	}
+	if len(or.Tools) > 0 && req.ToolChoice != nil {
+		or.ToolChoice = openaiToolChoice(req.ToolChoice)
+	}
 
 	return json.Marshal(or)
 }
 
+// openaiToolChoice maps a ToolChoice to the tool_choice forms the Chat
+// Completions API accepts: a mode string or a named function.
+func openaiToolChoice(tc *ToolChoice) interface{} {
+	switch tc.Type {
+	case ToolChoiceAny:
+		return "required"
+	case ToolChoiceTool:
+		return map[string]interface{}{
+			"type":     "function",
+			"function": map[string]string{"name": tc.Name},
+		}
+	}
+	return tc.Type
+}
+
 func convertToOpenAIMessages(system string, msgs []Message) []openaiMessage {
 	out := make([]openaiMessage, 0, len(msgs)+1)
 
@@ -199,6 +352,14 @@ func convertToOpenAIMessages(system string, msgs []Message) []openaiMessage {
 			c := m.Content
 			om.Content = &c
 		}
+		if len(m.Parts) > 0 {
+			if m.Role == "user" {
+				om.Content, om.Parts = nil, openaiParts(m.Parts)
+			} else {
+				c := partsText(m.Parts)
+				om.Content = &c
+			}
+		}
 
 		if m.Role == "tool" {
 			om.ToolCallID = m.ToolCallID
@@ -223,14 +384,116 @@ func convertToOpenAIMessages(system string, msgs []Message) []openaiMessage {
 	return out
 }
 
+// openaiParts converts content parts to Chat Completions content parts.
+// Inline images and documents are sent as data URLs.
+func openaiParts(parts []ContentPart) []openaiContentPart {
+	out := make([]openaiContentPart, 0, len(parts))
+	for _, cp := range parts {
+		var op openaiContentPart
+		switch cp.Type {
+		case PartText:
+			op = openaiContentPart{Type: "text", Text: cp.Text}
+		case PartImage:
+			op.Type = "image_url"
+			op.ImageURL = &struct {
+				URL string `json:"url"`
+			}{cp.dataURL()}
+		case PartDocument:
+			op.Type = "file"
+			op.File = &struct {
+				Filename string `json:"filename"`
+				FileData string `json:"file_data"`
+			}{documentName(cp), cp.dataURL()}
+		}
+		out = append(out, op)
+	}
+	return out
+}
+
+// checkOpenAIParts reports content parts the OpenAI APIs cannot take: only
+// user messages may carry images and documents, and only the Responses
+// API, when responsesAPI is set, accepts documents by URL.
+func checkOpenAIParts(msgs []Message, responsesAPI bool) error {
+	if err := validateParts(msgs); err != nil {
+		return err
+	}
+	for i, m := range msgs {
+		for _, cp := range m.Parts {
+			switch {
+			case cp.Type == PartText:
+			case m.Role != "user":
+				return fmt.Errorf("message %d: %s messages cannot carry %s parts", i, m.Role, cp.Type)
+			case cp.Type == PartDocument && cp.Data == nil && !responsesAPI:
+				return fmt.Errorf("message %d: documents must be sent inline, not by URL", i)
+			}
+		}
+	}
+	return nil
+}
+
+// partsText joins the text of content parts.
+func partsText(parts []ContentPart) string {
+	var b strings.Builder
+	for _, cp := range parts {
+		b.WriteString(cp.Text)
+	}
+	return b.String()
+}
+
+// documentName returns a document part's file name, which the OpenAI APIs
+// require.
+func documentName(cp ContentPart) string {
+	if cp.Name != "" {
+		return cp.Name
+	}
+	return "document"
+}
+
 func (p *OpenAIProvider) doRequest(ctx context.Context, body []byte) (*Response, error) {
-	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL, bytes.NewReader(body))
+	respBody, err := p.send(ctx, http.MethodPost, p.baseURL, "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.responsesAPI {
+		var rr responsesResponse
+		if err := json.Unmarshal(respBody, &rr); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+		return parseResponsesResponse(&rr), nil
+	}
+
+	var or openaiResponse
+	if err := json.Unmarshal(respBody, &or); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return parseOpenAIResponse(&or), nil
+}
+
+// send makes an authenticated request to the API and returns the body of
+// a successful response. Failures that are worth retrying are returned as
+// *retryableError.
+func (p *OpenAIProvider) send(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
 	if err != nil {
 		return nil, fmt.Errorf("creating HTTP request: %w", err)
 	}
 
-	httpReq.Header.Set("Content-Type", "application/json")
-	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+	if contentType != "" {
+		httpReq.Header.Set("Content-Type", contentType)
+	}
+	key := p.keys.keyOr(p.apiKey)
+	if p.apiKeyHeader != "" {
+		httpReq.Header.Set(p.apiKeyHeader, key)
+	} else {
+		httpReq.Header.Set("Authorization", "Bearer "+key)
+	}
+	setHeaders(httpReq, p.headers)
 
 	httpResp, err := p.client.Do(httpReq)
 	if err != nil {
@@ -244,6 +507,9 @@ func (p *OpenAIProvider) doRequest(ctx context.Context, body []byte) (*Response,
 	}
 
 	if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= 500 {
+		if httpResp.StatusCode == http.StatusTooManyRequests {
+			p.keys.RateLimited(key)
+		}
 		var apiErr openaiErrorResponse
 		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
 			return nil, &retryableError{err: fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, apiErr.Error.Message)}
@@ -252,29 +518,35 @@ func (p *OpenAIProvider) doRequest(ctx context.Context, body []byte) (*Response,
 	}
 
 	if httpResp.StatusCode != http.StatusOK {
+		msg := string(respBody)
 		var apiErr openaiErrorResponse
 		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Error.Message != "" {
-			return nil, fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, apiErr.Error.Message)
+			msg = apiErr.Error.Message
 		}
-		return nil, fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, string(respBody))
-	}
-
-	var or openaiResponse
-	if err := json.Unmarshal(respBody, &or); err != nil {
-		return nil, fmt.Errorf("decoding response: %w", err)
+		if httpResp.StatusCode == http.StatusNotFound || apiErr.Error.Code == "model_not_found" {
+			return nil, fmt.Errorf("HTTP %d: %w: %s", httpResp.StatusCode, ErrModelUnavailable, msg)
+		}
+		return nil, fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, msg)
 	}
 
-	return parseOpenAIResponse(&or), nil
+	return respBody, nil
 }
 
 func parseOpenAIResponse(or *openaiResponse) *Response {
 	resp := &Response{
+		Model:             or.Model,
+		SystemFingerprint: or.SystemFingerprint,
 		Usage: Usage{
-			InputTokens:  or.Usage.PromptTokens,
-			OutputTokens: or.Usage.CompletionTokens,
+			InputTokens:     or.Usage.PromptTokens,
+			OutputTokens:    or.Usage.CompletionTokens,
+			CacheReadTokens: or.Usage.PromptTokensDetails.CachedTokens,
 		},
 	}
 
+	if or.Provider != "" {
+		resp.Model += " via " + or.Provider
+	}
+
 	if len(or.Choices) == 0 {
 		return resp
 	}
@@ -285,6 +557,9 @@ func parseOpenAIResponse(or *openaiResponse) *Response {
 	if choice.Message.Content != nil {
 		resp.Content = *choice.Message.Content
 	}
+	if choice.Logprobs != nil {
+		resp.Logprobs = choice.Logprobs.Content
+	}
 
 	for _, tc := range choice.Message.ToolCalls {
 		var params map[string]interface{}
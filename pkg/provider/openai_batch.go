@@ -0,0 +1,237 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// NewOpenAIBatchProvider creates a provider that sends p's requests, with
+// its key, headers, client, and quota, through the OpenAI Batch API, to
+// Chat Completions or, for a provider created WithOpenAIResponsesAPI, the
+// Responses API. The batch endpoints are found next to p's endpoint. p's
+// retry settings apply to the batch API calls themselves.
+func NewOpenAIBatchProvider(p *OpenAIProvider, opts ...BatchOption) *BatchProvider {
+	return newBatchProvider(p.Name(), openaiBatches{p}, p.quota, opts)
+}
+
+// openaiBatches is the OpenAI Batch API. A batch's requests are uploaded
+// as a JSONL file, and its results are downloaded as one.
+type openaiBatches struct {
+	p *OpenAIProvider
+}
+
+// openaiBatch is the Batch API's batch object.
+type openaiBatch struct {
+	ID           string `json:"id"`
+	Status       string `json:"status"`
+	OutputFileID string `json:"output_file_id"`
+	ErrorFileID  string `json:"error_file_id"`
+	Errors       *struct {
+		Data []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"data"`
+	} `json:"errors"`
+}
+
+// openaiBatchResult is one line of a batch's output or error file.
+type openaiBatchResult struct {
+	CustomID string `json:"custom_id"`
+	Response *struct {
+		StatusCode int             `json:"status_code"`
+		Body       json.RawMessage `json:"body"`
+	} `json:"response"`
+	Error *struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// endpoint returns the API path batched requests are sent to, and the API
+// root the file and batch endpoints are under.
+func (o openaiBatches) endpoint() (path, root string) {
+	path = "/v1/chat/completions"
+	if o.p.responsesAPI {
+		path = "/v1/responses"
+	}
+	return path, strings.TrimSuffix(o.p.baseURL, strings.TrimPrefix(path, "/v1"))
+}
+
+func (o openaiBatches) params(req *Request) ([]byte, error) {
+	if o.p.responsesAPI {
+		return buildResponsesRequestBody(req)
+	}
+	return o.p.buildRequestBody(req)
+}
+
+func (o openaiBatches) submit(ctx context.Context, reqs []batchRequest) (string, error) {
+	path, root := o.endpoint()
+
+	var input bytes.Buffer
+	enc := json.NewEncoder(&input)
+	for _, r := range reqs {
+		line := struct {
+			CustomID string          `json:"custom_id"`
+			Method   string          `json:"method"`
+			URL      string          `json:"url"`
+			Body     json.RawMessage `json:"body"`
+		}{r.CustomID, http.MethodPost, path, r.Params}
+		if err := enc.Encode(line); err != nil {
+			return "", err
+		}
+	}
+
+	var form bytes.Buffer
+	mw := multipart.NewWriter(&form)
+	if err := mw.WriteField("purpose", "batch"); err != nil {
+		return "", err
+	}
+	fw, err := mw.CreateFormFile("file", "batch.jsonl")
+	if err != nil {
+		return "", err
+	}
+	if _, err := fw.Write(input.Bytes()); err != nil {
+		return "", err
+	}
+	if err := mw.Close(); err != nil {
+		return "", err
+	}
+	var file struct {
+		ID string `json:"id"`
+	}
+	if err := o.call(ctx, http.MethodPost, root+"/files", mw.FormDataContentType(), form.Bytes(), &file); err != nil {
+		return "", fmt.Errorf("uploading batch input: %w", err)
+	}
+
+	body, err := json.Marshal(map[string]string{
+		"input_file_id":     file.ID,
+		"endpoint":          path,
+		"completion_window": "24h",
+	})
+	if err != nil {
+		return "", err
+	}
+	var batch openaiBatch
+	if err := o.call(ctx, http.MethodPost, root+"/batches", "application/json", body, &batch); err != nil {
+		return "", err
+	}
+	return batch.ID, nil
+}
+
+func (o openaiBatches) ended(ctx context.Context, id string) (bool, error) {
+	batch, err := o.batch(ctx, id)
+	if err != nil {
+		return false, err
+	}
+	switch batch.Status {
+	case "completed", "expired", "cancelled":
+		return true, nil
+	case "failed":
+		msg := "no reason given"
+		if batch.Errors != nil && len(batch.Errors.Data) > 0 {
+			msg = batch.Errors.Data[0].Message
+		}
+		return false, fmt.Errorf("batch %s failed: %s", id, msg)
+	}
+	return false, nil
+}
+
+// results reads an ended batch's output file, which holds the requests
+// that got a response, and its error file, which holds those that did not,
+// such as requests still queued when the batch expired.
+func (o openaiBatches) results(ctx context.Context, id string) (map[string]batchResult, error) {
+	batch, err := o.batch(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("fetching batch %s: %w", id, err)
+	}
+	_, root := o.endpoint()
+
+	results := make(map[string]batchResult)
+	for _, fileID := range []string{batch.OutputFileID, batch.ErrorFileID} {
+		if fileID == "" {
+			continue
+		}
+		data, err := o.send(ctx, http.MethodGet, root+"/files/"+fileID+"/content", "", nil)
+		if err != nil {
+			return nil, fmt.Errorf("fetching results of batch %s: %w", id, err)
+		}
+		sc := bufio.NewScanner(bytes.NewReader(data))
+		sc.Buffer(make([]byte, 0, 64*1024), 64*1024*1024)
+		for sc.Scan() {
+			if len(bytes.TrimSpace(sc.Bytes())) == 0 {
+				continue
+			}
+			var line openaiBatchResult
+			if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+				return nil, fmt.Errorf("decoding results of batch %s: %w", id, err)
+			}
+			results[line.CustomID] = o.result(line)
+		}
+		if err := sc.Err(); err != nil {
+			return nil, fmt.Errorf("reading results of batch %s: %w", id, err)
+		}
+	}
+	return results, nil
+}
+
+// result converts one line of a batch's results.
+func (o openaiBatches) result(line openaiBatchResult) batchResult {
+	switch {
+	case line.Error != nil:
+		return batchResult{err: fmt.Errorf("batch request failed: %s: %s", line.Error.Code, line.Error.Message)}
+	case line.Response == nil:
+		return batchResult{err: fmt.Errorf("batch request %s has no response", line.CustomID)}
+	case line.Response.StatusCode != http.StatusOK:
+		var apiErr openaiErrorResponse
+		if json.Unmarshal(line.Response.Body, &apiErr) == nil && apiErr.Error.Message != "" {
+			return batchResult{err: fmt.Errorf("batch request failed: HTTP %d: %s", line.Response.StatusCode, apiErr.Error.Message)}
+		}
+		return batchResult{err: fmt.Errorf("batch request failed: HTTP %d: %s", line.Response.StatusCode, line.Response.Body)}
+	}
+
+	if o.p.responsesAPI {
+		var rr responsesResponse
+		if err := json.Unmarshal(line.Response.Body, &rr); err != nil {
+			return batchResult{err: fmt.Errorf("decoding response: %w", err)}
+		}
+		return batchResult{resp: parseResponsesResponse(&rr)}
+	}
+	var or openaiResponse
+	if err := json.Unmarshal(line.Response.Body, &or); err != nil {
+		return batchResult{err: fmt.Errorf("decoding response: %w", err)}
+	}
+	return batchResult{resp: parseOpenAIResponse(&or)}
+}
+
+func (o openaiBatches) batch(ctx context.Context, id string) (*openaiBatch, error) {
+	_, root := o.endpoint()
+	var batch openaiBatch
+	if err := o.call(ctx, http.MethodGet, root+"/batches/"+id, "", nil, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// call sends a batch API request and decodes its JSON response into out.
+func (o openaiBatches) call(ctx context.Context, method, url, contentType string, body []byte, out interface{}) error {
+	data, err := o.send(ctx, method, url, contentType, body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("decoding response: %w", err)
+	}
+	return nil
+}
+
+func (o openaiBatches) send(ctx context.Context, method, url, contentType string, body []byte) ([]byte, error) {
+	return retryBatchCall(o.p.Name(), o.p.maxRetries, o.p.budget, func() ([]byte, error) {
+		return o.p.send(ctx, method, url, contentType, body)
+	})
+}
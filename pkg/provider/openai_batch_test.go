@@ -0,0 +1,218 @@
+package provider
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeOpenAIBatches serves the OpenAI file and batch endpoints. Each batch
+// ends once its status has been checked `checks` times, and answers every
+// request with the request's user message echoed back, failing those that
+// say "fail".
+type fakeOpenAIBatches struct {
+	t      *testing.T
+	checks int32
+
+	mu      sync.Mutex
+	files   map[string][]openaiBatchLine
+	batches map[string]string // batch ID to input file ID
+	polls   map[string]*atomic.Int32
+}
+
+type openaiBatchLine struct {
+	CustomID string        `json:"custom_id"`
+	Method   string        `json:"method"`
+	URL      string        `json:"url"`
+	Body     openaiRequest `json:"body"`
+}
+
+func newFakeOpenAIBatches(t *testing.T, checks int32) *httptest.Server {
+	f := &fakeOpenAIBatches{
+		t:       t,
+		checks:  checks,
+		files:   make(map[string][]openaiBatchLine),
+		batches: make(map[string]string),
+		polls:   make(map[string]*atomic.Int32),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/files", f.upload)
+	mux.HandleFunc("POST /v1/batches", f.create)
+	mux.HandleFunc("GET /v1/batches/{id}", f.get)
+	mux.HandleFunc("GET /v1/files/{id}/content", f.content)
+	s := httptest.NewServer(mux)
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (f *fakeOpenAIBatches) upload(w http.ResponseWriter, r *http.Request) {
+	if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+		f.t.Errorf("Authorization = %q, want %q", got, "Bearer test-key")
+	}
+	if got := r.FormValue("purpose"); got != "batch" {
+		f.t.Errorf("purpose = %q, want batch", got)
+	}
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		f.t.Fatalf("reading upload: %v", err)
+	}
+	var lines []openaiBatchLine
+	sc := bufio.NewScanner(file)
+	for sc.Scan() {
+		var line openaiBatchLine
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			f.t.Fatalf("decoding input line: %v", err)
+		}
+		if line.Method != "POST" || line.URL != "/v1/chat/completions" {
+			f.t.Errorf("input line %s %s, want POST /v1/chat/completions", line.Method, line.URL)
+		}
+		lines = append(lines, line)
+	}
+	f.mu.Lock()
+	id := fmt.Sprintf("file-%d", len(f.files)+1)
+	f.files[id] = lines
+	f.mu.Unlock()
+	fmt.Fprintf(w, `{"id": %q}`, id)
+}
+
+func (f *fakeOpenAIBatches) create(w http.ResponseWriter, r *http.Request) {
+	var body map[string]string
+	json.NewDecoder(r.Body).Decode(&body)
+	if body["endpoint"] != "/v1/chat/completions" || body["completion_window"] != "24h" {
+		f.t.Errorf("batch = %v, want chat completions endpoint and 24h window", body)
+	}
+	f.mu.Lock()
+	id := fmt.Sprintf("batch_%d", len(f.batches)+1)
+	f.batches[id] = body["input_file_id"]
+	f.polls[id] = &atomic.Int32{}
+	f.mu.Unlock()
+	fmt.Fprintf(w, `{"id": %q, "status": "validating"}`, id)
+}
+
+func (f *fakeOpenAIBatches) get(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	f.mu.Lock()
+	input, polls := f.batches[id], f.polls[id]
+	f.mu.Unlock()
+	if polls.Add(1) <= f.checks {
+		fmt.Fprintf(w, `{"id": %q, "status": "in_progress"}`, id)
+		return
+	}
+	fmt.Fprintf(w, `{"id": %q, "status": "completed", "output_file_id": %q, "error_file_id": %q}`, id, "out-"+input, "err-"+input)
+}
+
+func (f *fakeOpenAIBatches) content(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	kind, input := id[:3], id[4:]
+	f.mu.Lock()
+	lines := f.files[input]
+	f.mu.Unlock()
+	for _, line := range lines {
+		msg := *line.Body.Messages[len(line.Body.Messages)-1].Content
+		switch {
+		case msg == "fail" && kind == "err":
+			fmt.Fprintf(w, `{"custom_id": %q, "response": {"status_code": 400, "body": {"error": {"message": "bad request"}}}}`+"\n", line.CustomID)
+		case msg != "fail" && kind == "out":
+			fmt.Fprintf(w, `{"custom_id": %q, "response": {"status_code": 200, "body": {"model": "gpt-4o-2024-08-06", "choices": [{"message": {"role": "assistant", "content": "echo: %s"}, "finish_reason": "stop"}], "usage": {"prompt_tokens": 5, "completion_tokens": 3}}}}`+"\n", line.CustomID, msg)
+		}
+	}
+}
+
+func TestOpenAIBatchComplete(t *testing.T) {
+	server := newFakeOpenAIBatches(t, 1)
+	p := NewOpenAIBatchProvider(
+		NewOpenAIProvider("test-key", WithOpenAIBaseURL(server.URL+"/v1/chat/completions")),
+		WithBatchWindow(20*time.Millisecond),
+		WithBatchPollInterval(time.Millisecond),
+	)
+
+	inputs := []string{"one", "two", "fail"}
+	got := make([]string, len(inputs))
+	errs := make([]error, len(inputs))
+	var wg sync.WaitGroup
+	for i, in := range inputs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := p.Complete(context.Background(), &Request{
+				Model:    "gpt-4o",
+				Messages: []Message{{Role: "user", Content: in}},
+			})
+			errs[i] = err
+			if err == nil {
+				got[i] = resp.Content
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i, want := range []string{"echo: one", "echo: two"} {
+		if errs[i] != nil || got[i] != want {
+			t.Errorf("call %d = %q, %v, want %q", i, got[i], errs[i], want)
+		}
+	}
+	if errs[2] == nil || errs[2].Error() != "batch request failed: HTTP 400: bad request" {
+		t.Errorf("failed request: error = %v, want the request's error", errs[2])
+	}
+}
+
+func TestOpenAIBatchComplete_Detached(t *testing.T) {
+	server := newFakeOpenAIBatches(t, 1)
+	path := filepath.Join(t.TempDir(), "batches.json")
+
+	// Each process opens the store and repeats the same conversation, as
+	// re-running a suite does.
+	run := func(msgs ...string) []error {
+		t.Helper()
+		store, err := OpenBatchStore(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := NewOpenAIBatchProvider(
+			NewOpenAIProvider("test-key", WithOpenAIBaseURL(server.URL+"/v1/chat/completions")),
+			WithBatchWindow(time.Millisecond),
+			WithBatchStore(store),
+		)
+		var errs []error
+		for _, msg := range msgs {
+			_, err := p.Complete(context.Background(), &Request{
+				Model:    "gpt-4o",
+				Messages: []Message{{Role: "user", Content: msg}},
+			})
+			errs = append(errs, err)
+		}
+		return errs
+	}
+
+	// Submitting returns at once.
+	if errs := run("one", "one"); !errors.Is(errs[0], ErrBatchPending) || !errors.Is(errs[1], ErrBatchPending) {
+		t.Fatalf("first run: errors = %v, want ErrBatchPending", errs)
+	}
+	// The batch has not ended at the first check.
+	if errs := run("one", "one"); !errors.Is(errs[0], ErrBatchPending) {
+		t.Fatalf("second run: errors = %v, want ErrBatchPending", errs)
+	}
+	// Once it has, both identical requests have results, and a new
+	// request goes into a new batch.
+	errs := run("one", "one", "two")
+	if errs[0] != nil || errs[1] != nil || !errors.Is(errs[2], ErrBatchPending) {
+		t.Fatalf("third run: errors = %v, want results and a new pending request", errs)
+	}
+
+	store, err := OpenBatchStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, batches := store.Pending(); n != 1 || len(batches) != 1 {
+		t.Errorf("Pending() = %d, %v, want 1 request in 1 batch", n, batches)
+	}
+}
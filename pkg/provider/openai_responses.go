@@ -0,0 +1,307 @@
+package provider
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+const defaultOpenAIResponsesURL = "https://api.openai.com/v1/responses"
+
+// WithOpenAIResponsesAPI makes the provider use the OpenAI Responses API
+// (/v1/responses) instead of Chat Completions, as newer models expect.
+// Unless a base URL is also set, requests go to OpenAI's responses
+// endpoint.
+//
+// Requests are stateless: nothing is stored on OpenAI's side, and each
+// response's reasoning items are returned in encrypted form in
+// Response.Reasoning so they can be sent back with the tool calls they
+// led to.
+func WithOpenAIResponsesAPI() OpenAIOption {
+	return func(p *OpenAIProvider) {
+		p.responsesAPI = true
+		if p.baseURL == defaultOpenAIURL {
+			p.baseURL = defaultOpenAIResponsesURL
+		}
+	}
+}
+
+// responsesRequest is the OpenAI Responses API request body.
+type responsesRequest struct {
+	Model           string          `json:"model"`
+	Instructions    string          `json:"instructions,omitempty"`
+	Input           []interface{}   `json:"input"`
+	Tools           []responsesTool `json:"tools,omitempty"`
+	ToolChoice      interface{}     `json:"tool_choice,omitempty"`
+	Temperature     *float64        `json:"temperature,omitempty"`
+	MaxOutputTokens *int            `json:"max_output_tokens,omitempty"`
+	Store           bool            `json:"store"`
+	Include         []string        `json:"include,omitempty"`
+	TopLogprobs     int             `json:"top_logprobs,omitempty"`
+
+	// User and Metadata tag the request; the Responses API takes the
+	// metadata pairs as they are, as well as the user identifier.
+	User     string            `json:"user,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// responsesTool is a function tool. Unlike Chat Completions, the function's
+// fields are not nested under a function object.
+type responsesTool struct {
+	Type        string                 `json:"type"`
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	Parameters  map[string]interface{} `json:"parameters"`
+}
+
+// Input items. The Responses API represents a conversation as a flat list
+// of items rather than messages with nested tool calls.
+type (
+	// responsesMessage's Content is a string, or for a multimodal user
+	// message a list of input parts.
+	responsesMessage struct {
+		Type    string      `json:"type"`
+		Role    string      `json:"role"`
+		Content interface{} `json:"content"`
+	}
+
+	responsesFunctionCall struct {
+		Type      string `json:"type"`
+		CallID    string `json:"call_id"`
+		Name      string `json:"name"`
+		Arguments string `json:"arguments"`
+	}
+
+	responsesFunctionCallOutput struct {
+		Type   string `json:"type"`
+		CallID string `json:"call_id"`
+		Output string `json:"output"`
+	}
+
+	responsesReasoning struct {
+		Type             string             `json:"type"`
+		ID               string             `json:"id"`
+		Summary          []responsesSummary `json:"summary"`
+		EncryptedContent string             `json:"encrypted_content,omitempty"`
+	}
+)
+
+type responsesSummary struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// responsesResponse is the OpenAI Responses API response body.
+type responsesResponse struct {
+	ID     string                `json:"id"`
+	Model  string                `json:"model"`
+	Status string                `json:"status"`
+	Output []responsesOutputItem `json:"output"`
+	Usage  struct {
+		InputTokens        int `json:"input_tokens"`
+		OutputTokens       int `json:"output_tokens"`
+		InputTokensDetails struct {
+			CachedTokens int `json:"cached_tokens"`
+		} `json:"input_tokens_details"`
+	} `json:"usage"`
+
+	// IncompleteDetails says why a response with status "incomplete"
+	// stopped early.
+	IncompleteDetails *struct {
+		Reason string `json:"reason"`
+	} `json:"incomplete_details"`
+}
+
+// responsesOutputItem is any item of a response's output: a message, a
+// function call, or a reasoning item.
+type responsesOutputItem struct {
+	Type    string `json:"type"`
+	ID      string `json:"id"`
+	Content []struct {
+		Type     string         `json:"type"`
+		Text     string         `json:"text"`
+		Logprobs []TokenLogprob `json:"logprobs"`
+	} `json:"content"`
+	CallID           string             `json:"call_id"`
+	Name             string             `json:"name"`
+	Arguments        string             `json:"arguments"`
+	Summary          []responsesSummary `json:"summary"`
+	EncryptedContent string             `json:"encrypted_content"`
+}
+
+func buildResponsesRequestBody(req *Request) ([]byte, error) {
+	if err := checkOpenAIParts(req.Messages, true); err != nil {
+		return nil, err
+	}
+	rr := responsesRequest{
+		Model:        req.Model,
+		Instructions: req.System,
+		Input:        convertToResponsesInput(req.Messages),
+		Include:      []string{"reasoning.encrypted_content"},
+		User:         MetadataUser(req.Metadata),
+		Metadata:     req.Metadata,
+	}
+
+	rr.Temperature = req.temperature(false)
+
+	if req.MaxTokens != 0 {
+		m := req.MaxTokens
+		rr.MaxOutputTokens = &m
+	}
+
+	if req.Logprobs || req.TopLogprobs > 0 {
+		rr.Include = append(rr.Include, "message.output_text.logprobs")
+		rr.TopLogprobs = req.TopLogprobs
+	}
+
+	for _, tool := range req.Tools {
+		rr.Tools = append(rr.Tools, responsesTool{
+			Type:        "function",
+			Name:        tool.Name,
+			Description: tool.Description,
+			Parameters:  tool.Parameters,
+		})
+	}
+	if len(rr.Tools) > 0 && req.ToolChoice != nil {
+		rr.ToolChoice = responsesToolChoice(req.ToolChoice)
+	}
+
+	return json.Marshal(rr)
+}
+
+// responsesToolChoice maps a ToolChoice to the tool_choice forms the
+// Responses API accepts.
+func responsesToolChoice(tc *ToolChoice) interface{} {
+	switch tc.Type {
+	case ToolChoiceAny:
+		return "required"
+	case ToolChoiceTool:
+		return map[string]string{"type": "function", "name": tc.Name}
+	}
+	return tc.Type
+}
+
+// convertToResponsesInput flattens messages into input items: an assistant
+// turn becomes its reasoning items, its text, and one item per tool call,
+// and a tool result becomes a function call output.
+func convertToResponsesInput(msgs []Message) []interface{} {
+	out := make([]interface{}, 0, len(msgs))
+	for _, m := range msgs {
+		content := m.Content
+		if len(m.Parts) > 0 {
+			content = partsText(m.Parts)
+		}
+		if m.Role == "tool" {
+			out = append(out, responsesFunctionCallOutput{
+				Type:   "function_call_output",
+				CallID: m.ToolCallID,
+				Output: content,
+			})
+			continue
+		}
+
+		for _, r := range m.Reasoning {
+			item := responsesReasoning{
+				Type:             "reasoning",
+				ID:               r.ID,
+				Summary:          []responsesSummary{},
+				EncryptedContent: r.EncryptedContent,
+			}
+			for _, text := range r.Summary {
+				item.Summary = append(item.Summary, responsesSummary{Type: "summary_text", Text: text})
+			}
+			out = append(out, item)
+		}
+
+		switch {
+		case len(m.Parts) > 0 && m.Role == "user":
+			out = append(out, responsesMessage{Type: "message", Role: m.Role, Content: responsesParts(m.Parts)})
+		case content != "" || len(m.ToolCalls) == 0:
+			out = append(out, responsesMessage{Type: "message", Role: m.Role, Content: content})
+		}
+
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Parameters)
+			out = append(out, responsesFunctionCall{
+				Type:      "function_call",
+				CallID:    tc.ID,
+				Name:      tc.Name,
+				Arguments: string(args),
+			})
+		}
+	}
+	return out
+}
+
+// responsesParts converts content parts to Responses API input parts.
+// Inline images and documents are sent as data URLs.
+func responsesParts(parts []ContentPart) []map[string]string {
+	out := make([]map[string]string, 0, len(parts))
+	for _, cp := range parts {
+		switch cp.Type {
+		case PartText:
+			out = append(out, map[string]string{"type": "input_text", "text": cp.Text})
+		case PartImage:
+			out = append(out, map[string]string{"type": "input_image", "image_url": cp.dataURL()})
+		case PartDocument:
+			if cp.Data == nil {
+				out = append(out, map[string]string{"type": "input_file", "file_url": cp.URL})
+			} else {
+				out = append(out, map[string]string{"type": "input_file", "filename": documentName(cp), "file_data": cp.dataURL()})
+			}
+		}
+	}
+	return out
+}
+
+// parseResponsesResponse converts a Responses API response. Stop reasons
+// use the Chat Completions values: "stop", "tool_calls", or "length" when
+// the output token limit cut the response short.
+func parseResponsesResponse(rr *responsesResponse) *Response {
+	resp := &Response{
+		Model: rr.Model,
+		Usage: Usage{
+			InputTokens:     rr.Usage.InputTokens,
+			OutputTokens:    rr.Usage.OutputTokens,
+			CacheReadTokens: rr.Usage.InputTokensDetails.CachedTokens,
+		},
+	}
+
+	var text strings.Builder
+	for _, item := range rr.Output {
+		switch item.Type {
+		case "message":
+			for _, c := range item.Content {
+				if c.Type == "output_text" {
+					text.WriteString(c.Text)
+					resp.Logprobs = append(resp.Logprobs, c.Logprobs...)
+				}
+			}
+		case "function_call":
+			var params map[string]interface{}
+			json.Unmarshal([]byte(item.Arguments), &params)
+			resp.ToolCalls = append(resp.ToolCalls, ToolCall{
+				ID:         item.CallID,
+				Name:       item.Name,
+				Parameters: params,
+			})
+		case "reasoning":
+			r := Reasoning{ID: item.ID, EncryptedContent: item.EncryptedContent}
+			for _, s := range item.Summary {
+				r.Summary = append(r.Summary, s.Text)
+			}
+			resp.Reasoning = append(resp.Reasoning, r)
+		}
+	}
+	resp.Content = text.String()
+
+	switch {
+	case rr.Status == "incomplete" && rr.IncompleteDetails != nil && rr.IncompleteDetails.Reason == "max_output_tokens":
+		resp.StopReason = "length"
+	case len(resp.ToolCalls) > 0:
+		resp.StopReason = "tool_calls"
+	default:
+		resp.StopReason = "stop"
+	}
+
+	return resp
+}
@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenAIResponsesComplete_ToolCallWithReasoning(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Model        string                   `json:"model"`
+			Instructions string                   `json:"instructions"`
+			Input        []map[string]interface{} `json:"input"`
+			Tools        []map[string]interface{} `json:"tools"`
+			ToolChoice   interface{}              `json:"tool_choice"`
+			Store        bool                     `json:"store"`
+			Include      []string                 `json:"include"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		if body.Instructions != "You are helpful." || body.Store || len(body.Include) != 1 {
+			t.Errorf("request = %+v, want instructions, store false, and encrypted reasoning included", body)
+		}
+		if len(body.Tools) != 1 || body.Tools[0]["name"] != "get_weather" || body.Tools[0]["type"] != "function" {
+			t.Errorf("tools = %v, want the flat function tool get_weather", body.Tools)
+		}
+		if body.ToolChoice != "required" {
+			t.Errorf("tool_choice = %v, want required", body.ToolChoice)
+		}
+		if len(body.Input) != 1 || body.Input[0]["role"] != "user" || body.Input[0]["content"] != "Weather in Paris?" {
+			t.Errorf("input = %v, want the user message", body.Input)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{
+			"id": "resp_1",
+			"model": "o4-mini-2025-04-16",
+			"status": "completed",
+			"output": [
+				{"type": "reasoning", "id": "rs_1", "summary": [{"type": "summary_text", "text": "Need the weather tool."}], "encrypted_content": "opaque"},
+				{"type": "function_call", "id": "fc_1", "call_id": "call_1", "name": "get_weather", "arguments": "{\"city\":\"Paris\"}"}
+			],
+			"usage": {"input_tokens": 20, "output_tokens": 12}
+		}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key", WithOpenAIResponsesAPI(), WithOpenAIBaseURL(server.URL))
+	got, err := p.Complete(context.Background(), &Request{
+		Model:      "o4-mini",
+		System:     "You are helpful.",
+		Messages:   []Message{{Role: "user", Content: "Weather in Paris?"}},
+		Tools:      []Tool{{Name: "get_weather", Parameters: map[string]interface{}{"type": "object"}}},
+		ToolChoice: &ToolChoice{Type: ToolChoiceAny},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if got.StopReason != "tool_calls" || got.Model != "o4-mini-2025-04-16" || got.Usage.OutputTokens != 12 {
+		t.Errorf("Complete() = %+v", got)
+	}
+	if len(got.ToolCalls) != 1 || got.ToolCalls[0].ID != "call_1" || got.ToolCalls[0].Parameters["city"] != "Paris" {
+		t.Errorf("ToolCalls = %+v, want get_weather(city=Paris) with call ID call_1", got.ToolCalls)
+	}
+	if len(got.Reasoning) != 1 || got.Reasoning[0].EncryptedContent != "opaque" || got.Reasoning[0].Summary[0] != "Need the weather tool." {
+		t.Errorf("Reasoning = %+v", got.Reasoning)
+	}
+}
+
+func TestConvertToResponsesInput(t *testing.T) {
+	msgs := []Message{
+		{Role: "user", Content: "Weather in Paris?"},
+		{
+			Role:      "assistant",
+			ToolCalls: []ToolCall{{ID: "call_1", Name: "get_weather", Parameters: map[string]interface{}{"city": "Paris"}}},
+			Reasoning: []Reasoning{{ID: "rs_1", EncryptedContent: "opaque"}},
+		},
+		{Role: "tool", ToolCallID: "call_1", Content: "18C, sunny"},
+	}
+	data, err := json.Marshal(convertToResponsesInput(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var items []map[string]interface{}
+	json.Unmarshal(data, &items)
+
+	wantTypes := []string{"message", "reasoning", "function_call", "function_call_output"}
+	if len(items) != len(wantTypes) {
+		t.Fatalf("items = %s, want %d items", data, len(wantTypes))
+	}
+	for i, want := range wantTypes {
+		if items[i]["type"] != want {
+			t.Errorf("items[%d].type = %v, want %s", i, items[i]["type"], want)
+		}
+	}
+	// Reasoning items must carry a summary, even an empty one.
+	if s, ok := items[1]["summary"].([]interface{}); !ok || len(s) != 0 {
+		t.Errorf("reasoning summary = %v, want []", items[1]["summary"])
+	}
+	if items[2]["call_id"] != "call_1" || items[2]["arguments"] != `{"city":"Paris"}` {
+		t.Errorf("function_call = %v", items[2])
+	}
+	if items[3]["call_id"] != "call_1" || items[3]["output"] != "18C, sunny" {
+		t.Errorf("function_call_output = %v", items[3])
+	}
+}
+
+func TestParseResponsesResponse_Incomplete(t *testing.T) {
+	var rr responsesResponse
+	json.Unmarshal([]byte(`{
+		"status": "incomplete",
+		"incomplete_details": {"reason": "max_output_tokens"},
+		"output": [{"type": "message", "role": "assistant", "content": [{"type": "output_text", "text": "Partial"}]}]
+	}`), &rr)
+
+	got := parseResponsesResponse(&rr)
+	if got.Content != "Partial" || got.StopReason != "length" {
+		t.Errorf("parseResponsesResponse() = %+v, want partial content and stop reason length", got)
+	}
+}
+
+func TestWithOpenAIResponsesAPI_DefaultURL(t *testing.T) {
+	if p := NewOpenAIProvider("key", WithOpenAIResponsesAPI()); p.baseURL != defaultOpenAIResponsesURL {
+		t.Errorf("baseURL = %q, want %q", p.baseURL, defaultOpenAIResponsesURL)
+	}
+	if p := NewOpenAIProvider("key", WithOpenAIBaseURL("https://gw/v1/responses"), WithOpenAIResponsesAPI()); p.baseURL != "https://gw/v1/responses" {
+		t.Errorf("baseURL = %q, want the configured one kept", p.baseURL)
+	}
+}
+
+func TestOpenAIResponsesComplete_Logprobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Include     []string `json:"include"`
+			TopLogprobs int      `json:"top_logprobs"`
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		if len(body.Include) != 2 || body.Include[1] != "message.output_text.logprobs" || body.TopLogprobs != 0 {
+			t.Errorf("include/top_logprobs = %v/%d, want output text logprobs included and no alternatives", body.Include, body.TopLogprobs)
+		}
+		w.Write([]byte(`{
+			"model": "gpt-4.1",
+			"status": "completed",
+			"output": [{"type": "message", "content": [
+				{"type": "output_text", "text": "Hi", "logprobs": [{"token": "Hi", "logprob": -0.05, "top_logprobs": []}]}
+			]}]
+		}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key", WithOpenAIResponsesAPI(), WithOpenAIBaseURL(server.URL))
+	got, err := p.Complete(context.Background(), &Request{
+		Model:    "gpt-4.1",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+		Logprobs: true,
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if len(got.Logprobs) != 1 || got.Logprobs[0].Token != "Hi" || got.Logprobs[0].Logprob != -0.05 {
+		t.Errorf("Logprobs = %+v, want Hi at -0.05", got.Logprobs)
+	}
+}
+
+func TestConvertToResponsesInput_ContentParts(t *testing.T) {
+	msgs := []Message{{Role: "user", Parts: []ContentPart{
+		TextPart("Compare."),
+		ImageURLPart("https://example.com/a.png"),
+		{Type: PartDocument, URL: "https://example.com/b.pdf"},
+		DocumentPart("c.pdf", "application/pdf", []byte("pdf")),
+	}}}
+	data, err := json.Marshal(convertToResponsesInput(msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"type":"message","role":"user","content":[` +
+		`{"text":"Compare.","type":"input_text"},` +
+		`{"image_url":"https://example.com/a.png","type":"input_image"},` +
+		`{"file_url":"https://example.com/b.pdf","type":"input_file"},` +
+		`{"file_data":"data:application/pdf;base64,cGRm","filename":"c.pdf","type":"input_file"}]}]`
+	if string(data) != want {
+		t.Errorf("input =\n%s\nwant\n%s", data, want)
+	}
+}
+
+func TestBuildResponsesRequestBody_Seed(t *testing.T) {
+	// The Responses API takes no seed, and its reasoning models reject a
+	// non-default temperature, so a seed must not force temperature 0.
+	seed := int64(42)
+	body, err := buildResponsesRequestBody(&Request{Model: "o3", Seed: &seed})
+	if err != nil {
+		t.Fatalf("buildResponsesRequestBody() error: %v", err)
+	}
+	if strings.Contains(string(body), "temperature") || strings.Contains(string(body), "seed") {
+		t.Errorf("body = %s, want neither temperature nor seed", body)
+	}
+
+	body, _ = buildResponsesRequestBody(&Request{Model: "gpt-4.1", Seed: &seed, Temperature: 0.5})
+	if !strings.Contains(string(body), `"temperature":0.5`) {
+		t.Errorf("body = %s, want the request's own temperature", body)
+	}
+}
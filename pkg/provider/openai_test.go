@@ -3,11 +3,14 @@ package provider
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"math"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"sync/atomic"
 	"testing"
+	"unicode/utf8"
 )
 
 func TestOpenAIComplete_TextResponse(t *testing.T) {
@@ -42,6 +45,7 @@ func TestOpenAIComplete_TextResponse(t *testing.T) {
 		resp := openaiResponse{
 			ID:     "chatcmpl-01",
 			Object: "chat.completion",
+			Model:  "gpt-4o-2024-08-06",
 			Choices: []openaiChoice{
 				{
 					Index: 0,
@@ -80,6 +84,9 @@ func TestOpenAIComplete_TextResponse(t *testing.T) {
 	if got.StopReason != "stop" {
 		t.Errorf("StopReason = %q, want %q", got.StopReason, "stop")
 	}
+	if got.Model != "gpt-4o-2024-08-06" {
+		t.Errorf("Model = %q, want the served snapshot %q", got.Model, "gpt-4o-2024-08-06")
+	}
 	if got.Usage.InputTokens != 15 {
 		t.Errorf("InputTokens = %d, want %d", got.Usage.InputTokens, 15)
 	}
@@ -361,6 +368,72 @@ func TestOpenAIComplete_ExhaustedRetries(t *testing.T) {
 	}
 }
 
+func TestOpenAIComplete_CustomHeaders(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("X-Route"); got != "eu-west" {
+			t.Errorf("X-Route = %q, want %q", got, "eu-west")
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer test-key" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer test-key")
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"ok"},"finish_reason":"stop"}]}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key",
+		WithOpenAIBaseURL(server.URL),
+		WithOpenAIMaxRetries(0),
+		WithOpenAIHeaders(map[string]string{"X-Route": "eu-west"}),
+	)
+
+	if _, err := p.Complete(context.Background(), &Request{
+		Model:    "gpt-4o",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	}); err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+}
+
+func TestOpenAIComplete_TokenQuota(t *testing.T) {
+	var attempts atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts.Add(1)
+		resp := openaiResponse{
+			ID: "chatcmpl-01",
+			Choices: []openaiChoice{
+				{Message: openaiMessage{Role: "assistant", Content: strPtr("ok")}, FinishReason: "stop"},
+			},
+		}
+		resp.Usage.PromptTokens = 60
+		resp.Usage.CompletionTokens = 40
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key",
+		WithOpenAIBaseURL(server.URL),
+		WithOpenAIQuota(&Quota{Name: "openai", MaxTokens: 150}),
+	)
+	req := &Request{Model: "gpt-4o", Messages: []Message{{Role: "user", Content: "Hi"}}}
+
+	// Usage is only known after a response, so the call that crosses the
+	// limit completes and the next one is refused.
+	for i := 0; i < 2; i++ {
+		if _, err := p.Complete(context.Background(), req); err != nil {
+			t.Fatalf("call %d: unexpected error: %v", i, err)
+		}
+	}
+	if _, err := p.Complete(context.Background(), req); !errors.Is(err, ErrQuotaExceeded) {
+		t.Fatalf("error = %v, want ErrQuotaExceeded", err)
+	}
+	if n := attempts.Load(); n != 2 {
+		t.Errorf("attempts = %d, want 2", n)
+	}
+}
+
 func TestOpenAICostEstimation(t *testing.T) {
 	tests := []struct {
 		name  string
@@ -416,6 +489,90 @@ func TestOpenAICostEstimation(t *testing.T) {
 	}
 }
 
+func TestOpenAIBuildRequestBody_ToolChoice(t *testing.T) {
+	tools := []Tool{{Name: "search", Parameters: map[string]interface{}{"type": "object"}}}
+	tests := []struct {
+		choice string
+		want   string
+	}{
+		{"auto", `"auto"`},
+		{"any", `"required"`},
+		{"none", `"none"`},
+		{"search", `{"function":{"name":"search"},"type":"function"}`},
+	}
+	for _, tt := range tests {
+		p := NewOpenAIProvider("k")
+		body, err := p.buildRequestBody(&Request{Model: "gpt-4o", Tools: tools, ToolChoice: ParseToolChoice(tt.choice)})
+		if err != nil {
+			t.Fatalf("buildRequestBody() error: %v", err)
+		}
+		var got struct {
+			ToolChoice json.RawMessage `json:"tool_choice"`
+		}
+		json.Unmarshal(body, &got)
+		if string(got.ToolChoice) != tt.want {
+			t.Errorf("tool_choice %q = %s, want %s", tt.choice, got.ToolChoice, tt.want)
+		}
+	}
+}
+
+func TestOpenAIBuildRequestBody_Metadata(t *testing.T) {
+	p := NewOpenAIProvider("k")
+	body, err := p.buildRequestBody(&Request{Model: "gpt-4o", Metadata: map[string]string{"suite": "smoke"}})
+	if err != nil {
+		t.Fatalf("buildRequestBody() error: %v", err)
+	}
+	var got struct {
+		User string `json:"user"`
+	}
+	json.Unmarshal(body, &got)
+	if got.User != "suite=smoke" {
+		t.Errorf("user = %q, want %q", got.User, "suite=smoke")
+	}
+}
+
+func TestOpenAIBuildRequestBody_Seed(t *testing.T) {
+	p := NewOpenAIProvider("k")
+	seed := int64(42)
+	body, err := p.buildRequestBody(&Request{Model: "gpt-4o", Seed: &seed})
+	if err != nil {
+		t.Fatalf("buildRequestBody() error: %v", err)
+	}
+	if !strings.Contains(string(body), `"temperature":0`) || !strings.Contains(string(body), `"seed":42`) {
+		t.Errorf("body = %s, want seed 42 at temperature 0", body)
+	}
+
+	body, _ = p.buildRequestBody(&Request{Model: "gpt-4o", Seed: &seed, Temperature: 0.7})
+	if !strings.Contains(string(body), `"temperature":0.7`) {
+		t.Errorf("body = %s, want the request's own temperature", body)
+	}
+	body, _ = p.buildRequestBody(&Request{Model: "gpt-4o"})
+	if strings.Contains(string(body), "temperature") || strings.Contains(string(body), "seed") {
+		t.Errorf("body = %s, want the API defaults without a seed", body)
+	}
+
+	resp := parseOpenAIResponse(&openaiResponse{Model: "gpt-4o", SystemFingerprint: "fp_44709d6fcb"})
+	if resp.SystemFingerprint != "fp_44709d6fcb" {
+		t.Errorf("SystemFingerprint = %q", resp.SystemFingerprint)
+	}
+}
+
+func TestMetadataUser(t *testing.T) {
+	if got := MetadataUser(nil); got != "" {
+		t.Errorf("MetadataUser(nil) = %q, want empty", got)
+	}
+	if got := MetadataUser(map[string]string{"b": "2", "a": "1"}); got != "a=1;b=2" {
+		t.Errorf("MetadataUser() = %q, want %q", got, "a=1;b=2")
+	}
+	if got := MetadataUser(map[string]string{"k": strings.Repeat("x", 300)}); len(got) != 256 {
+		t.Errorf("MetadataUser() of long metadata has length %d, want 256", len(got))
+	}
+	// 256 bytes would end inside a two-byte character, so the cut backs up.
+	if got := MetadataUser(map[string]string{"k": "x" + strings.Repeat("é", 150)}); len(got) != 255 || !utf8.ValidString(got) {
+		t.Errorf("MetadataUser() of long multibyte metadata = %d bytes, valid UTF-8 %v; want 255, true", len(got), utf8.ValidString(got))
+	}
+}
+
 func TestOpenAIProviderName(t *testing.T) {
 	p := NewOpenAIProvider("key")
 	if got := p.Name(); got != "openai" {
@@ -480,3 +637,95 @@ func TestConvertToOpenAIMessages(t *testing.T) {
 }
 
 func strPtr(s string) *string { return &s }
+
+func TestOpenAIComplete_Logprobs(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]interface{}
+		json.NewDecoder(r.Body).Decode(&body)
+		if body["logprobs"] != true || body["top_logprobs"] != float64(2) {
+			t.Errorf("logprobs/top_logprobs = %v/%v, want true/2", body["logprobs"], body["top_logprobs"])
+		}
+		w.Write([]byte(`{
+			"model": "gpt-4o",
+			"choices": [{
+				"message": {"role": "assistant", "content": "Yes."},
+				"finish_reason": "stop",
+				"logprobs": {"content": [
+					{"token": "Yes", "logprob": -0.1, "bytes": [89, 101, 115], "top_logprobs": [
+						{"token": "Yes", "logprob": -0.1},
+						{"token": "No", "logprob": -2.4}
+					]},
+					{"token": ".", "logprob": -0.3, "top_logprobs": []}
+				]}
+			}]
+		}`))
+	}))
+	defer server.Close()
+
+	p := NewOpenAIProvider("test-key", WithOpenAIBaseURL(server.URL), WithOpenAIMaxRetries(0))
+	got, err := p.Complete(context.Background(), &Request{
+		Model:       "gpt-4o",
+		Messages:    []Message{{Role: "user", Content: "Is it?"}},
+		TopLogprobs: 2,
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if len(got.Logprobs) != 2 || got.Logprobs[0].Token != "Yes" || got.Logprobs[1].Logprob != -0.3 {
+		t.Fatalf("Logprobs = %+v, want Yes and . with their log probabilities", got.Logprobs)
+	}
+	if top := got.Logprobs[0].TopLogprobs; len(top) != 2 || top[1].Token != "No" || top[1].Logprob != -2.4 {
+		t.Errorf("TopLogprobs = %+v, want Yes and No", top)
+	}
+	if mean := MeanLogprob(got.Logprobs); math.Abs(mean+0.2) > 1e-9 {
+		t.Errorf("MeanLogprob() = %v, want -0.2", mean)
+	}
+	if ppl := Perplexity(got.Logprobs); math.Abs(ppl-math.Exp(0.2)) > 1e-9 {
+		t.Errorf("Perplexity() = %v, want e^0.2", ppl)
+	}
+	if Perplexity(nil) != 0 {
+		t.Errorf("Perplexity(nil) = %v, want 0", Perplexity(nil))
+	}
+
+	// Without the request, neither field is sent.
+	body, err := p.buildRequestBody(&Request{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(body), "logprobs") {
+		t.Errorf("request body = %s, want no logprobs fields", body)
+	}
+}
+
+func TestConvertToOpenAIMessages_ContentParts(t *testing.T) {
+	msgs := []Message{
+		{Role: "user", Parts: []ContentPart{
+			TextPart("Summarize."),
+			ImagePart("image/png", []byte("png")),
+			DocumentPart("", "application/pdf", []byte("pdf")),
+		}},
+		{Role: "tool", ToolCallID: "call_01", Parts: []ContentPart{TextPart("a"), TextPart("b")}},
+	}
+	data, err := json.Marshal(convertToOpenAIMessages("", msgs))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"role":"user","content":[` +
+		`{"type":"text","text":"Summarize."},` +
+		`{"type":"image_url","image_url":{"url":"data:image/png;base64,cG5n"}},` +
+		`{"type":"file","file":{"filename":"document","file_data":"data:application/pdf;base64,cGRm"}}]},` +
+		`{"role":"tool","content":"ab","tool_call_id":"call_01"}]`
+	if string(data) != want {
+		t.Errorf("messages =\n%s\nwant\n%s", data, want)
+	}
+
+	p := NewOpenAIProvider("test-key")
+	for _, m := range []Message{
+		{Role: "user", Parts: []ContentPart{{Type: PartDocument, URL: "https://example.com/a.pdf"}}},
+		{Role: "tool", Parts: []ContentPart{ImageURLPart("https://example.com/a.png")}},
+	} {
+		if _, err := p.buildRequestBody(&Request{Messages: []Message{m}}); err == nil {
+			t.Errorf("buildRequestBody(%+v) succeeded, want an error", m)
+		}
+	}
+}
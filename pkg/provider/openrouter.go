@@ -0,0 +1,37 @@
+package provider
+
+const defaultOpenRouterURL = "https://openrouter.ai/api/v1/chat/completions"
+
+// OpenRouterRouting holds OpenRouter's provider routing preferences, sent
+// as the request's provider object. They choose which upstream providers
+// may serve a model slug and in what order; nil leaves routing to
+// OpenRouter.
+type OpenRouterRouting struct {
+	Order             []string `json:"order,omitempty"`
+	Only              []string `json:"only,omitempty"`
+	Ignore            []string `json:"ignore,omitempty"`
+	AllowFallbacks    *bool    `json:"allow_fallbacks,omitempty"`
+	RequireParameters bool     `json:"require_parameters,omitempty"`
+	DataCollection    string   `json:"data_collection,omitempty"` // "allow" or "deny"
+	Sort              string   `json:"sort,omitempty"`            // "price", "throughput", or "latency"
+}
+
+// WithOpenRouterRouting sets the provider routing preferences sent with
+// every request. It only has an effect on OpenRouter.
+func WithOpenRouterRouting(r *OpenRouterRouting) OpenAIOption {
+	return func(p *OpenAIProvider) { p.routing = r }
+}
+
+// NewOpenRouterProvider creates a provider for OpenRouter, which serves
+// many vendors' models through the Chat Completions API under slugs such
+// as "anthropic/claude-sonnet-4" or "meta-llama/llama-3.3-70b-instruct".
+// Any slug is passed through as the request's model. Each response's
+// model is reported as the model that actually answered followed by the
+// upstream provider that served it, e.g. "openai/gpt-4o via Azure", so
+// the trace records where routing sent the call.
+func NewOpenRouterProvider(apiKey string, opts ...OpenAIOption) *OpenAIProvider {
+	opts = append([]OpenAIOption{WithOpenAIBaseURL(defaultOpenRouterURL)}, opts...)
+	p := NewOpenAIProvider(apiKey, opts...)
+	p.name = "openrouter"
+	return p
+}
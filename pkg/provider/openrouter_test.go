@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestOpenRouterComplete(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer or-key" {
+			t.Errorf("Authorization = %q, want %q", got, "Bearer or-key")
+		}
+		var reqBody openaiRequest
+		if err := json.NewDecoder(r.Body).Decode(&reqBody); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		if reqBody.Model != "meta-llama/llama-3.3-70b-instruct" {
+			t.Errorf("model = %q, want the slug passed through", reqBody.Model)
+		}
+		rt := reqBody.Provider
+		if rt == nil || !slices.Equal(rt.Order, []string{"Together", "Fireworks"}) ||
+			rt.AllowFallbacks == nil || *rt.AllowFallbacks || rt.DataCollection != "deny" {
+			t.Errorf("provider = %+v, want the configured routing", rt)
+		}
+		resp := openaiResponse{
+			Model:    "meta-llama/llama-3.3-70b-instruct",
+			Provider: "Fireworks",
+			Choices:  []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: strPtr("hi")}, FinishReason: "stop"}},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	noFallbacks := false
+	p := NewOpenRouterProvider("or-key",
+		WithOpenAIBaseURL(server.URL),
+		WithOpenAIMaxRetries(0),
+		WithOpenRouterRouting(&OpenRouterRouting{
+			Order:          []string{"Together", "Fireworks"},
+			AllowFallbacks: &noFallbacks,
+			DataCollection: "deny",
+		}),
+	)
+	if p.Name() != "openrouter" {
+		t.Errorf("Name() = %q, want %q", p.Name(), "openrouter")
+	}
+
+	got, err := p.Complete(context.Background(), &Request{
+		Model:    "meta-llama/llama-3.3-70b-instruct",
+		Messages: []Message{{Role: "user", Content: "Hi"}},
+	})
+	if err != nil {
+		t.Fatalf("Complete() error = %v", err)
+	}
+	if want := "meta-llama/llama-3.3-70b-instruct via Fireworks"; got.Model != want {
+		t.Errorf("Model = %q, want %q", got.Model, want)
+	}
+}
+
+func TestOpenAIBuildRequestBody_NoRouting(t *testing.T) {
+	body, err := NewOpenAIProvider("key").buildRequestBody(&Request{Model: "gpt-4o"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var raw map[string]interface{}
+	json.Unmarshal(body, &raw)
+	if _, ok := raw["provider"]; ok {
+		t.Errorf("request body %s has a provider object, want none without routing", body)
+	}
+}
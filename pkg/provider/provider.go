@@ -1,6 +1,14 @@
 package provider
 
-import "context"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
 
 // Provider defines the interface for LLM API backends.
 type Provider interface {
@@ -19,6 +27,101 @@ type Request struct {
 	Tools       []Tool    `json:"tools,omitempty"`
 	Temperature float64   `json:"temperature,omitempty"`
 	MaxTokens   int       `json:"max_tokens,omitempty"`
+
+	// ToolChoice constrains whether and which tool the model calls. Nil
+	// leaves the choice to the model.
+	ToolChoice *ToolChoice `json:"tool_choice,omitempty"`
+
+	// Logprobs asks for the log probability of each output token, and
+	// TopLogprobs for that many of the likeliest alternatives at each
+	// position, in Response.Logprobs. APIs that do not report log
+	// probabilities, such as Anthropic's, ignore both.
+	Logprobs    bool `json:"logprobs,omitempty"`
+	TopLogprobs int  `json:"top_logprobs,omitempty"`
+
+	// Metadata tags the request so its traffic can be identified and
+	// segmented in the provider's dashboards, such as by suite and run.
+	// It is sent as Anthropic's metadata.user_id and OpenAI's user, both
+	// as MetadataUser formats it.
+	Metadata map[string]string `json:"metadata,omitempty"`
+
+	// Seed asks the API to sample reproducibly, so repeated runs give the
+	// same responses where the API allows. OpenAI's chat completions take
+	// it as seed, on a best-effort basis, and report the backend they ran
+	// on in Response.SystemFingerprint, and a seeded request with no
+	// temperature is sent to them with temperature 0 rather than the
+	// API's default. APIs without a seed parameter, such as Anthropic's
+	// and OpenAI's Responses API, ignore it and keep their default
+	// temperature, which reasoning models require.
+	Seed *int64 `json:"seed,omitempty"`
+}
+
+// temperature returns the temperature to send for r: its own when set,
+// 0 for a seeded request to an API that takes the seed, as sendsSeed
+// reports, and nil to leave the API's default.
+func (r *Request) temperature(sendsSeed bool) *float64 {
+	if r.Temperature == 0 && (r.Seed == nil || !sendsSeed) {
+		return nil
+	}
+	t := r.Temperature
+	return &t
+}
+
+// maxMetadataUser is the longest user identifier the APIs accept.
+const maxMetadataUser = 256
+
+// MetadataUser formats md as the single user identifier APIs accept:
+// key=value pairs in key order, separated by semicolons, cut to at most
+// 256 bytes without splitting a UTF-8 character. It returns "" for empty
+// metadata.
+func MetadataUser(md map[string]string) string {
+	if len(md) == 0 {
+		return ""
+	}
+	keys := make([]string, 0, len(md))
+	for k := range md {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		pairs[i] = k + "=" + md[k]
+	}
+	user := strings.Join(pairs, ";")
+	if len(user) > maxMetadataUser {
+		n := maxMetadataUser
+		for n > 0 && !utf8.RuneStart(user[n]) {
+			n--
+		}
+		user = user[:n]
+	}
+	return user
+}
+
+// Tool choice modes.
+const (
+	ToolChoiceAuto = "auto" // the model decides
+	ToolChoiceAny  = "any"  // the model must call some tool
+	ToolChoiceNone = "none" // the model must not call tools
+	ToolChoiceTool = "tool" // the model must call the tool named Name
+)
+
+// ToolChoice constrains the model's tool use for a request.
+type ToolChoice struct {
+	Type string `json:"type"`
+	Name string `json:"name,omitempty"`
+}
+
+// ParseToolChoice parses the short form used in prompt files: "auto",
+// "any", "none", or the name of the tool the model must call.
+func ParseToolChoice(s string) *ToolChoice {
+	switch s {
+	case "":
+		return nil
+	case ToolChoiceAuto, ToolChoiceAny, ToolChoiceNone:
+		return &ToolChoice{Type: s}
+	}
+	return &ToolChoice{Type: ToolChoiceTool, Name: s}
 }
 
 // Message represents a single message in a conversation.
@@ -27,6 +130,27 @@ type Message struct {
 	Content    string     `json:"content,omitempty"`
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	ToolCallID string     `json:"tool_call_id,omitempty"`
+
+	// Parts, when set, is the message's content as multimodal blocks,
+	// such as text with images, and Content is ignored. Tool results may
+	// carry parts only for APIs that accept them there, such as
+	// Anthropic's.
+	Parts []ContentPart `json:"parts,omitempty"`
+
+	// Reasoning carries the reasoning items an assistant turn produced,
+	// which some APIs require to be sent back with its tool calls.
+	Reasoning []Reasoning `json:"reasoning,omitempty"`
+}
+
+// Reasoning is a reasoning item a model produced before answering, as
+// returned by the OpenAI Responses API. Models using that API expect the
+// items that led to a tool call to be sent back with it, so they are kept
+// on the assistant message. EncryptedContent is the opaque reasoning state
+// itself; Summary is the readable summary, when the model provides one.
+type Reasoning struct {
+	ID               string   `json:"id"`
+	Summary          []string `json:"summary,omitempty"`
+	EncryptedContent string   `json:"encrypted_content,omitempty"`
 }
 
 // Tool describes a tool the model can invoke.
@@ -49,10 +173,75 @@ type Response struct {
 	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
 	Usage      Usage      `json:"usage"`
 	StopReason string     `json:"stop_reason"`
+
+	// Reasoning holds the reasoning items the model produced, for APIs
+	// that return them. Send them back on the assistant message.
+	Reasoning []Reasoning `json:"reasoning,omitempty"`
+
+	// Model is the concrete model version that served the request as
+	// reported by the API, such as a dated snapshot of a model alias.
+	// Empty when the API does not report one.
+	Model string `json:"model,omitempty"`
+
+	// Logprobs holds the log probability of each token of Content, when
+	// the request asked for them and the API reports them.
+	Logprobs []TokenLogprob `json:"logprobs,omitempty"`
+
+	// SystemFingerprint identifies the backend configuration that served
+	// the request, as OpenAI reports it. A change between runs with the
+	// same seed explains changed responses. Empty when not reported.
+	SystemFingerprint string `json:"system_fingerprint,omitempty"`
+
+	// Retry reports the retries the provider needed to obtain this
+	// response.
+	Retry RetryStats `json:"retry"`
+
+	// Provider names the provider that served a request sent through a
+	// CompositeProvider, and Fallbacks counts the providers in its chain
+	// that failed before it. Both are empty for other providers.
+	Provider  string `json:"provider,omitempty"`
+	Fallbacks int    `json:"fallbacks,omitempty"`
+}
+
+// RetryStats describes the retrying done for a single provider call.
+// Overloaded counts the attempts the provider rejected as overloaded.
+type RetryStats struct {
+	Retries    int           `json:"retries"`
+	Backoff    time.Duration `json:"backoff"`
+	Overloaded int           `json:"overloaded,omitempty"`
 }
 
+// ErrOverloaded is wrapped by errors for responses in which the provider
+// reported it was overloaded, such as Anthropic's 529 overloaded_error.
+var ErrOverloaded = errors.New("provider overloaded")
+
+// ErrModelUnavailable is wrapped by errors for responses in which the API
+// reported that the requested model does not exist or is not available,
+// such as a 404 or OpenAI's model_not_found.
+var ErrModelUnavailable = errors.New("model unavailable")
+
+// RetryError is returned when a provider gives up after exhausting its
+// retries. It carries the retry telemetry for the failed call.
+type RetryError struct {
+	Provider string
+	Retry    RetryStats
+	Err      error
+}
+
+func (e *RetryError) Error() string {
+	return fmt.Sprintf("%s API request failed after %d attempts: %v", e.Provider, e.Retry.Retries+1, e.Err)
+}
+
+func (e *RetryError) Unwrap() error { return e.Err }
+
 // Usage tracks token consumption for a single request.
 type Usage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
+
+	// CacheReadTokens and CacheWriteTokens are the input tokens read from
+	// and written to the vendor's prompt cache, which are priced
+	// differently. Both are included in InputTokens.
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
 }
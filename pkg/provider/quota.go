@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ErrQuotaExceeded is wrapped by the error a provider returns when a call
+// would go past its run's Quota.
+var ErrQuotaExceeded = errors.New("provider usage quota exceeded")
+
+// Quota caps the requests and tokens one provider may use over a run, so an
+// accidentally unbounded suite cannot drain a shared organization quota.
+// Share one Quota between every instance of the same provider in a run. It
+// is safe for concurrent use.
+type Quota struct {
+	// Name identifies the quota in errors: the provider's name in the
+	// config's quotas section.
+	Name string
+	// MaxRequests is the total number of HTTP requests allowed, retries
+	// included; zero means no limit.
+	MaxRequests int
+	// MaxTokens is the total of input and output tokens allowed; zero
+	// means no limit. Token usage is only known once a response arrives,
+	// so the call that crosses the limit completes and later calls are
+	// refused.
+	MaxTokens int
+
+	mu       sync.Mutex
+	requests int
+	tokens   int
+}
+
+// Take reserves one request. It returns an error wrapping
+// ErrQuotaExceeded when either limit has been reached. A nil quota always
+// allows the request.
+func (q *Quota) Take() error {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.MaxRequests > 0 && q.requests >= q.MaxRequests {
+		return fmt.Errorf("%w: %d requests made this run (quotas.%s.max_requests is %d)",
+			ErrQuotaExceeded, q.requests, q.Name, q.MaxRequests)
+	}
+	if q.MaxTokens > 0 && q.tokens >= q.MaxTokens {
+		return fmt.Errorf("%w: %d tokens used this run (quotas.%s.max_tokens is %d)",
+			ErrQuotaExceeded, q.tokens, q.Name, q.MaxTokens)
+	}
+	q.requests++
+	return nil
+}
+
+// Add records the tokens a response used.
+func (q *Quota) Add(u Usage) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.tokens += u.InputTokens + u.OutputTokens
+}
+
+// Used returns the requests and tokens spent so far.
+func (q *Quota) Used() (requests, tokens int) {
+	if q == nil {
+		return 0, 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.requests, q.tokens
+}
@@ -0,0 +1,105 @@
+package provider
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimiter paces one provider's requests below its vendor rate limits,
+// so that a suite run with high concurrency waits its turn instead of
+// drawing 429 responses. Requests and tokens are each metered by a token
+// bucket refilled continuously at the per-minute rate and holding at most
+// one second's worth, since vendors enforce their limits over short
+// windows. Share one RateLimiter between every instance of the same
+// provider in a run. It is safe for concurrent use.
+type RateLimiter struct {
+	// RequestsPerMinute and TokensPerMinute are the limits; zero means no
+	// limit. Token usage is only known once a response arrives, so a
+	// request is charged an estimate of its input tokens up front and the
+	// difference from its actual usage afterwards.
+	RequestsPerMinute int
+	TokensPerMinute   int
+
+	mu       sync.Mutex
+	started  bool
+	last     time.Time
+	requests float64
+	tokens   float64
+}
+
+// Wait blocks until a request estimated to use tokens input tokens may be
+// sent, then charges it. It returns early with ctx's error if ctx ends
+// first. A nil limiter never waits.
+func (l *RateLimiter) Wait(ctx context.Context, tokens int) error {
+	if l == nil {
+		return nil
+	}
+	for {
+		d := l.reserve(tokens)
+		if d == 0 {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+		}
+	}
+}
+
+// Settle charges the difference between a request's actual usage and the
+// estimate it was charged by Wait.
+func (l *RateLimiter) Settle(estimated int, u Usage) {
+	if l == nil || l.TokensPerMinute <= 0 {
+		return
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.tokens -= float64(u.InputTokens + u.OutputTokens - estimated)
+}
+
+// reserve charges a request if both buckets allow it and returns zero, or
+// returns how long to wait before trying again.
+func (l *RateLimiter) reserve(tokens int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill(time.Now())
+
+	var wait float64 // seconds
+	if l.RequestsPerMinute > 0 && l.requests < 1 {
+		wait = (1 - l.requests) / perSecond(l.RequestsPerMinute)
+	}
+	// The token bucket may go into debt: a request waits only for it to
+	// be positive, so a request larger than a second's worth still runs.
+	if l.TokensPerMinute > 0 && l.tokens <= 0 {
+		wait = math.Max(wait, (1-l.tokens)/perSecond(l.TokensPerMinute))
+	}
+	if wait > 0 {
+		return time.Duration(math.Ceil(wait * float64(time.Second)))
+	}
+	l.requests--
+	l.tokens -= float64(tokens)
+	return 0
+}
+
+// refill adds what the buckets have earned since the last call, starting
+// them full.
+func (l *RateLimiter) refill(now time.Time) {
+	if !l.started {
+		l.started = true
+		l.requests = burst(l.RequestsPerMinute)
+		l.tokens = burst(l.TokensPerMinute)
+	} else {
+		elapsed := now.Sub(l.last).Seconds()
+		l.requests = math.Min(burst(l.RequestsPerMinute), l.requests+elapsed*perSecond(l.RequestsPerMinute))
+		l.tokens = math.Min(burst(l.TokensPerMinute), l.tokens+elapsed*perSecond(l.TokensPerMinute))
+	}
+	l.last = now
+}
+
+func perSecond(perMinute int) float64 { return float64(perMinute) / 60 }
+
+// burst is a bucket's capacity: one second's worth, and at least one.
+func burst(perMinute int) float64 { return math.Max(1, perSecond(perMinute)) }
@@ -0,0 +1,196 @@
+package provider
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+)
+
+// Factory constructs a provider from its entry in the config's providers
+// map. name is the entry's key, apiKey the key read from its api_key_env,
+// and deps the objects the provider should share with the rest of the run.
+type Factory func(name string, pc config.ProviderConfig, apiKey string, deps Deps) (Provider, error)
+
+// Deps are the process-wide objects NewFromConfig wires into a provider so
+// that every provider of a run shares them. Nil fields are left unset.
+type Deps struct {
+	// MaxRetries is the number of times a failed request is retried.
+	MaxRetries int
+
+	RetryBudget *RetryBudget
+	Quota       *Quota
+	RateLimiter *RateLimiter
+
+	// HTTPClient replaces the default client, e.g. to share one
+	// connection pool between providers.
+	HTTPClient *http.Client
+
+	// BatchStore records the batches of a batch provider; see
+	// WithBatchStore.
+	BatchStore *BatchStore
+
+	// KeyPool, when set, supplies the keys requests are sent with in
+	// place of the factory's apiKey. NewFromConfig sets it for providers
+	// configured with api_key_envs.
+	KeyPool *KeyPool
+
+	// SecretPatterns, when set, wraps the provider in a SecretGuard that
+	// refuses requests matching any of them.
+	SecretPatterns []SecretPattern
+}
+
+var (
+	factoriesMu sync.RWMutex
+	factories   = make(map[string]Factory)
+)
+
+// Register makes a provider type available to NewFromConfig and accepted
+// by config validation. Third-party providers call it from an init
+// function, so a binary that imports them can name their type in
+// eval.yaml. Register panics if typ is already registered, like
+// database/sql.Register.
+func Register(typ string, f Factory) {
+	factoriesMu.Lock()
+	defer factoriesMu.Unlock()
+	if f == nil {
+		panic("provider: Register factory is nil")
+	}
+	if _, dup := factories[typ]; dup {
+		panic("provider: Register called twice for type " + typ)
+	}
+	factories[typ] = f
+	config.RegisterProviderType(typ)
+}
+
+// Types returns the registered provider types, sorted.
+func Types() []string {
+	factoriesMu.RLock()
+	defer factoriesMu.RUnlock()
+	types := make([]string, 0, len(factories))
+	for t := range factories {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+	return types
+}
+
+// NewFromConfig constructs the provider configured as name, with the
+// factory registered for its type. Its API keys are read from the
+// environment; a provider with several is given a KeyPool of them unless
+// deps has one.
+func NewFromConfig(name string, pc config.ProviderConfig, deps Deps) (Provider, error) {
+	typ := pc.ResolvedType(name)
+	factoriesMu.RLock()
+	f, ok := factories[typ]
+	factoriesMu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported provider %q (types: %s)", name, strings.Join(Types(), ", "))
+	}
+	keys, err := pc.APIKeys(name)
+	if err != nil {
+		return nil, err
+	}
+	if len(keys) > 1 && deps.KeyPool == nil {
+		deps.KeyPool = NewKeyPool(keys, pc.KeyRotation == config.KeyRotationOnRateLimit)
+	}
+	p, err := f(name, pc, keys[0], deps)
+	if err != nil || len(deps.SecretPatterns) == 0 {
+		return p, err
+	}
+	return NewSecretGuard(p, deps.SecretPatterns), nil
+}
+
+func init() {
+	Register(config.ProviderAnthropic, newAnthropicFromConfig)
+	Register(config.ProviderOpenAI, newOpenAIFromConfig)
+	Register(config.ProviderAzureOpenAI, newOpenAIFromConfig)
+	Register(config.ProviderOpenRouter, newOpenAIFromConfig)
+}
+
+func newAnthropicFromConfig(name string, pc config.ProviderConfig, apiKey string, deps Deps) (Provider, error) {
+	opts := []AnthropicOption{
+		WithMaxRetries(deps.MaxRetries),
+		WithRetryBudget(deps.RetryBudget),
+		WithQuota(deps.Quota),
+		WithRateLimiter(deps.RateLimiter),
+		WithHeaders(pc.Headers),
+		WithKeyPool(deps.KeyPool),
+	}
+	if deps.HTTPClient != nil {
+		opts = append(opts, WithHTTPClient(deps.HTTPClient))
+	}
+	if pc.BaseURL != "" {
+		opts = append(opts, WithBaseURL(endpointURL(pc.BaseURL, "/messages")))
+	}
+	if pc.Batch {
+		return NewAnthropicBatchProvider(NewAnthropicProvider(apiKey, opts...), WithBatchStore(deps.BatchStore)), nil
+	}
+	return NewAnthropicProvider(apiKey, opts...), nil
+}
+
+// newOpenAIFromConfig constructs the provider types that speak the OpenAI
+// API: openai, azure-openai, and openrouter.
+func newOpenAIFromConfig(name string, pc config.ProviderConfig, apiKey string, deps Deps) (Provider, error) {
+	opts := []OpenAIOption{
+		WithOpenAIMaxRetries(deps.MaxRetries),
+		WithOpenAIRetryBudget(deps.RetryBudget),
+		WithOpenAIQuota(deps.Quota),
+		WithOpenAIRateLimiter(deps.RateLimiter),
+		WithOpenAIHeaders(pc.Headers),
+		WithOpenAIKeyPool(deps.KeyPool),
+	}
+	if deps.HTTPClient != nil {
+		opts = append(opts, WithOpenAIHTTPClient(deps.HTTPClient))
+	}
+	typ := pc.ResolvedType(name)
+	if typ == config.ProviderAzureOpenAI {
+		return NewAzureOpenAIProvider(apiKey, pc.BaseURL, pc.ResolvedDeployment(), pc.APIVersion, opts...), nil
+	}
+	endpoint := "/chat/completions"
+	if pc.API == config.APIResponses {
+		opts = append(opts, WithOpenAIResponsesAPI())
+		endpoint = "/responses"
+	}
+	if pc.BaseURL != "" {
+		opts = append(opts, WithOpenAIBaseURL(endpointURL(pc.BaseURL, endpoint)))
+	}
+	if typ == config.ProviderOpenRouter {
+		opts = append(opts, WithOpenRouterRouting(openRouterRouting(pc.Routing)))
+		return NewOpenRouterProvider(apiKey, opts...), nil
+	}
+	if pc.Batch {
+		return NewOpenAIBatchProvider(NewOpenAIProvider(apiKey, opts...), WithBatchStore(deps.BatchStore)), nil
+	}
+	return NewOpenAIProvider(apiKey, opts...), nil
+}
+
+// openRouterRouting converts configured routing preferences to the form
+// sent to OpenRouter.
+func openRouterRouting(rc *config.RoutingConfig) *OpenRouterRouting {
+	if rc == nil {
+		return nil
+	}
+	return &OpenRouterRouting{
+		Order:             rc.Order,
+		Only:              rc.Only,
+		Ignore:            rc.Ignore,
+		AllowFallbacks:    rc.AllowFallbacks,
+		RequireParameters: rc.RequireParameters,
+		DataCollection:    rc.DataCollection,
+		Sort:              rc.Sort,
+	}
+}
+
+// endpointURL joins a configured base URL with an API endpoint path. The
+// base may be given with or without the trailing /v1 version segment.
+func endpointURL(base, path string) string {
+	base = strings.TrimSuffix(base, "/")
+	if !strings.HasSuffix(base, "/v1") {
+		base += "/v1"
+	}
+	return base + path
+}
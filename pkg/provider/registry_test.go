@@ -0,0 +1,143 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/config"
+)
+
+type echoProvider struct{ key, model string }
+
+func (p *echoProvider) Complete(_ context.Context, req *Request) (*Response, error) {
+	return &Response{Content: req.Messages[0].Content, Model: p.model}, nil
+}
+
+func (p *echoProvider) Name() string { return "echo" }
+
+func TestRegister(t *testing.T) {
+	var gotDeps Deps
+	Register("echo-test", func(name string, pc config.ProviderConfig, apiKey string, deps Deps) (Provider, error) {
+		gotDeps = deps
+		return &echoProvider{key: apiKey, model: pc.Model}, nil
+	})
+	if !slices.Contains(Types(), "echo-test") {
+		t.Errorf("Types() = %v, want echo-test included", Types())
+	}
+
+	t.Setenv("ECHO_KEY", "secret")
+	cfg := config.Default()
+	cfg.Providers = map[string]config.ProviderConfig{
+		"local": {Type: "echo-test", Model: "m", APIKeyEnv: "ECHO_KEY"},
+	}
+	if err := cfg.Validate(); err != nil {
+		t.Fatalf("Validate() rejected a registered type: %v", err)
+	}
+
+	quota := &Quota{Name: "local"}
+	p, err := NewFromConfig("local", cfg.Providers["local"], Deps{MaxRetries: 2, Quota: quota})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error: %v", err)
+	}
+	if e, ok := p.(*echoProvider); !ok || e.key != "secret" || e.model != "m" {
+		t.Errorf("provider = %#v, want an echo provider with the resolved key", p)
+	}
+	if gotDeps.MaxRetries != 2 || gotDeps.Quota != quota {
+		t.Errorf("factory got deps %+v", gotDeps)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("registering a type twice did not panic")
+		}
+	}()
+	Register("echo-test", func(string, config.ProviderConfig, string, Deps) (Provider, error) { return nil, nil })
+}
+
+func TestNewFromConfig_KeyPool(t *testing.T) {
+	var gotKey string
+	var gotPool *KeyPool
+	Register("pool-test", func(name string, pc config.ProviderConfig, apiKey string, deps Deps) (Provider, error) {
+		gotKey, gotPool = apiKey, deps.KeyPool
+		return &echoProvider{}, nil
+	})
+	t.Setenv("POOL_KEY_1", "one")
+	t.Setenv("POOL_KEY_2", "two")
+	pc := config.ProviderConfig{Type: "pool-test", Model: "m", APIKeyEnvs: []string{"POOL_KEY_1", "POOL_KEY_2"}}
+	if _, err := NewFromConfig("pooled", pc, Deps{}); err != nil {
+		t.Fatalf("NewFromConfig() error: %v", err)
+	}
+	if gotKey != "one" || gotPool == nil {
+		t.Fatalf("factory got key %q, pool %v; want the first key and a pool", gotKey, gotPool)
+	}
+	if a, b := gotPool.Key(), gotPool.Key(); a != "one" || b != "two" {
+		t.Errorf("pool keys = %q, %q; want one, two", a, b)
+	}
+}
+
+func TestNewFromConfig_SecretGuard(t *testing.T) {
+	t.Setenv("OPENAI_KEY", "k")
+	pc := config.ProviderConfig{Model: "gpt-4o", APIKeyEnv: "OPENAI_KEY"}
+	p, err := NewFromConfig("openai", pc, Deps{SecretPatterns: DefaultSecretPatterns})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error: %v", err)
+	}
+	if _, ok := p.(*SecretGuard); !ok {
+		t.Fatalf("provider = %T, want *SecretGuard", p)
+	}
+	if p.Name() != "openai" {
+		t.Errorf("Name() = %q, want openai", p.Name())
+	}
+
+	p, err = NewFromConfig("openai", pc, Deps{})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error: %v", err)
+	}
+	if _, ok := p.(*SecretGuard); ok {
+		t.Error("provider is guarded without secret patterns")
+	}
+}
+
+func TestNewFromConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/chat/completions" {
+			t.Errorf("path = %q, want the base URL joined with the endpoint", r.URL.Path)
+		}
+		if got := r.Header.Get("X-Team"); got != "evals" {
+			t.Errorf("X-Team = %q, want the configured header", got)
+		}
+		json.NewEncoder(w).Encode(openaiResponse{
+			Choices: []openaiChoice{{Message: openaiMessage{Role: "assistant", Content: strPtr("hi")}, FinishReason: "stop"}},
+		})
+	}))
+	defer server.Close()
+
+	t.Setenv("OPENAI_TEST_KEY", "k")
+	pc := config.ProviderConfig{Model: "gpt", BaseURL: server.URL, APIKeyEnv: "OPENAI_TEST_KEY", Headers: map[string]string{"X-Team": "evals"}}
+	p, err := NewFromConfig("openai", pc, Deps{HTTPClient: server.Client()})
+	if err != nil {
+		t.Fatalf("NewFromConfig() error: %v", err)
+	}
+	resp, err := p.Complete(context.Background(), &Request{Model: "gpt", Messages: []Message{{Role: "user", Content: "Hi"}}})
+	if err != nil || resp.Content != "hi" {
+		t.Fatalf("Complete() = %v, %v", resp, err)
+	}
+
+	pc.Batch = true
+	p, _ = NewFromConfig("openai", pc, Deps{})
+	if _, ok := p.(*BatchProvider); !ok {
+		t.Errorf("provider with batch: true is %T, want a batch provider", p)
+	}
+
+	if _, err := NewFromConfig("bedrock", config.ProviderConfig{APIKeyEnv: "OPENAI_TEST_KEY"}, Deps{}); err == nil || !strings.Contains(err.Error(), "unsupported provider") {
+		t.Errorf("unknown type error = %v, want unsupported provider", err)
+	}
+	if _, err := NewFromConfig("openai", config.ProviderConfig{APIKeyEnv: "UNSET_TEST_KEY"}, Deps{}); err == nil || !strings.Contains(err.Error(), "UNSET_TEST_KEY") {
+		t.Errorf("missing key error = %v, want the variable named", err)
+	}
+}
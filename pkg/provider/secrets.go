@@ -0,0 +1,133 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+)
+
+// SecretPattern is a named regular expression matching a kind of
+// credential.
+type SecretPattern struct {
+	Name    string
+	Pattern *regexp.Regexp
+}
+
+// DefaultSecretPatterns match the credentials most often pasted into
+// fixtures by mistake.
+var DefaultSecretPatterns = []SecretPattern{
+	{"aws_access_key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github_token", regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`)},
+	{"anthropic_api_key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}`)},
+	{"openai_api_key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9]{20,}`)},
+	{"slack_token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{"google_api_key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"private_key", regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )*PRIVATE KEY-----`)},
+}
+
+// SecretPatterns returns DefaultSecretPatterns followed by the patterns
+// in extra, keyed by name and added in name order.
+func SecretPatterns(extra map[string]string) ([]SecretPattern, error) {
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	patterns := append([]SecretPattern(nil), DefaultSecretPatterns...)
+	for _, name := range names {
+		re, err := regexp.Compile(extra[name])
+		if err != nil {
+			return nil, fmt.Errorf("secret pattern %q: %w", name, err)
+		}
+		patterns = append(patterns, SecretPattern{Name: name, Pattern: re})
+	}
+	return patterns, nil
+}
+
+// ErrSecretDetected is wrapped by the errors of requests SecretGuard
+// refuses to send.
+var ErrSecretDetected = errors.New("secret detected in outbound request")
+
+// ScanRequest returns an error wrapping ErrSecretDetected, naming the
+// pattern and where in req it matched, if any pattern matches the
+// request's system prompt, messages, tool calls, or tool definitions.
+// The matched text itself is never included.
+func ScanRequest(req *Request, patterns []SecretPattern) error {
+	check := func(where, text string) error {
+		if text == "" {
+			return nil
+		}
+		for _, sp := range patterns {
+			if sp.Pattern.MatchString(text) {
+				return fmt.Errorf("%w: %s matches %s", ErrSecretDetected, where, sp.Name)
+			}
+		}
+		return nil
+	}
+
+	if err := check("system prompt", req.System); err != nil {
+		return err
+	}
+	for i, m := range req.Messages {
+		where := fmt.Sprintf("message %d (%s)", i+1, m.Role)
+		if m.Role == "tool" {
+			where = fmt.Sprintf("message %d (tool result)", i+1)
+		}
+		if err := check(where, m.Content); err != nil {
+			return err
+		}
+		for _, part := range m.Parts {
+			if err := check(where, part.Text); err != nil {
+				return err
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			if err := check(fmt.Sprintf("%s call to %s", where, tc.Name), fmt.Sprint(tc.Parameters)); err != nil {
+				return err
+			}
+		}
+	}
+	for _, t := range req.Tools {
+		if err := check("tool "+t.Name, t.Description); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// SecretGuard is a Provider that scans every request with ScanRequest
+// before passing it on, and fails requests that carry a secret instead of
+// sending them.
+type SecretGuard struct {
+	Provider
+	Patterns []SecretPattern
+}
+
+// NewSecretGuard wraps p so that requests matching any of patterns are
+// refused.
+func NewSecretGuard(p Provider, patterns []SecretPattern) *SecretGuard {
+	return &SecretGuard{Provider: p, Patterns: patterns}
+}
+
+// Complete sends req with the wrapped provider unless it carries a
+// secret.
+func (g *SecretGuard) Complete(ctx context.Context, req *Request) (*Response, error) {
+	if err := ScanRequest(req, g.Patterns); err != nil {
+		return nil, err
+	}
+	return g.Provider.Complete(ctx, req)
+}
+
+// CountTokens counts req's tokens with the wrapped provider unless it
+// carries a secret, since counting sends the request too.
+func (g *SecretGuard) CountTokens(ctx context.Context, req *Request) (int, error) {
+	if err := ScanRequest(req, g.Patterns); err != nil {
+		return 0, err
+	}
+	if c, ok := g.Provider.(TokenCounter); ok {
+		return c.CountTokens(ctx, req)
+	}
+	return EstimateTokens(req), nil
+}
@@ -0,0 +1,165 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// TokenCounter is implemented by providers whose API counts the input
+// tokens of a request without running it, such as Anthropic's
+// count_tokens endpoint.
+type TokenCounter interface {
+	CountTokens(ctx context.Context, req *Request) (int, error)
+}
+
+// CountTokens returns the number of input tokens req would use with p. It
+// asks the API when p implements TokenCounter and falls back to
+// EstimateTokens otherwise, or when p is nil.
+func CountTokens(ctx context.Context, p Provider, req *Request) (int, error) {
+	if c, ok := p.(TokenCounter); ok {
+		n, err := c.CountTokens(ctx, req)
+		if err != nil {
+			return 0, fmt.Errorf("counting tokens with %s: %w", p.Name(), err)
+		}
+		return n, nil
+	}
+	return EstimateTokens(req), nil
+}
+
+const (
+	// messageTokenOverhead is the tokens each message costs beyond its
+	// content for role and separators, and replyTokenOverhead those that
+	// prime the reply, as in OpenAI's chat format.
+	messageTokenOverhead = 3
+	replyTokenOverhead   = 3
+
+	// imageTokenEstimate and documentTokenEstimate stand in for the
+	// tokens of an image or document, which depend on its dimensions or
+	// pages.
+	imageTokenEstimate    = 1000
+	documentTokenEstimate = 1500
+)
+
+// pretokenRe splits text the way tiktoken's cl100k_base pre-tokenizer
+// does, less its lookahead: contractions, words with an optional leading
+// space or symbol, runs of up to three digits, punctuation, and
+// whitespace.
+var pretokenRe = regexp.MustCompile(`(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+`)
+
+// EstimateTokens estimates the input tokens of req without a tokenizer
+// vocabulary, in the manner of tiktoken: text is split into pre-tokens,
+// each of which is usually one token, with long and non-ASCII pre-tokens
+// counted as several. Estimates are typically within 10-15% of the real
+// count for English text and code; use CountTokens where the API can
+// count exactly.
+func EstimateTokens(req *Request) int {
+	n := replyTokenOverhead
+	if req.System != "" {
+		n += messageTokenOverhead + estimateTextTokens(req.System)
+	}
+	for _, m := range req.Messages {
+		n += messageTokenOverhead + estimateTextTokens(m.Content)
+		for _, part := range m.Parts {
+			switch part.Type {
+			case PartImage:
+				n += imageTokenEstimate
+			case PartDocument:
+				n += documentTokenEstimate
+			default:
+				n += estimateTextTokens(part.Text)
+			}
+		}
+		for _, tc := range m.ToolCalls {
+			args, _ := json.Marshal(tc.Parameters)
+			n += messageTokenOverhead + estimateTextTokens(tc.Name) + estimateTextTokens(string(args))
+		}
+	}
+	for _, t := range req.Tools {
+		schema, _ := json.Marshal(t.Parameters)
+		n += messageTokenOverhead + estimateTextTokens(t.Name) +
+			estimateTextTokens(t.Description) + estimateTextTokens(string(schema))
+	}
+	return n
+}
+
+// estimateTextTokens estimates the tokens of text. Common words are a
+// single token; longer pre-tokens take one more token per six characters,
+// and non-ASCII text about one token per character.
+func estimateTextTokens(text string) int {
+	n := 0
+	for _, piece := range pretokenRe.FindAllString(text, -1) {
+		runes := utf8.RuneCountInString(strings.TrimLeft(piece, " "))
+		switch {
+		case runes == 0:
+			n++
+		case len(piece) != utf8.RuneCountInString(piece):
+			n += runes
+		default:
+			n += 1 + (runes-1)/6
+		}
+	}
+	return n
+}
+
+// ErrContextWindow is wrapped by the error CheckContextWindow returns for
+// a request too large for its model.
+var ErrContextWindow = errors.New("request exceeds the model's context window")
+
+// contextWindows maps model identifiers to their context window in
+// tokens, shared between input and output.
+var contextWindows = map[string]int{
+	"claude-3-opus-20240229":     200_000,
+	"claude-3-sonnet-20240229":   200_000,
+	"claude-3-haiku-20240307":    200_000,
+	"claude-3-5-sonnet-20241022": 200_000,
+	"claude-3-5-haiku-20241022":  200_000,
+	"claude-sonnet-4-5-20250929": 200_000,
+	"claude-opus-4-6":            200_000,
+	"gpt-4o":                     128_000,
+	"gpt-4o-mini":                128_000,
+	"gpt-4-turbo":                128_000,
+	"gpt-4":                      8_192,
+	"o1":                         200_000,
+	"o1-mini":                    128_000,
+	"o3-mini":                    200_000,
+}
+
+// ContextWindow returns the context window of model in tokens. A
+// vendor-prefixed slug, as used by OpenRouter, has the window of the
+// model it names.
+func ContextWindow(model string) (int, bool) {
+	n, ok := contextWindows[model]
+	if !ok {
+		if i := strings.LastIndexByte(model, '/'); i >= 0 {
+			n, ok = contextWindows[model[i+1:]]
+		}
+	}
+	return n, ok
+}
+
+// CheckContextWindow counts the input tokens of req with CountTokens and
+// returns them, with an error wrapping ErrContextWindow if they and
+// req.MaxTokens of output do not fit in the model's context window.
+// Requests for models with no known window are only counted.
+func CheckContextWindow(ctx context.Context, p Provider, req *Request) (int, error) {
+	n, err := CountTokens(ctx, p, req)
+	if err != nil {
+		return 0, err
+	}
+	window, ok := ContextWindow(req.Model)
+	if !ok {
+		return n, nil
+	}
+	if n+req.MaxTokens > window {
+		if req.MaxTokens > 0 {
+			return n, fmt.Errorf("%w: %d input tokens plus max_tokens %d exceeds %d for %s", ErrContextWindow, n, req.MaxTokens, window, req.Model)
+		}
+		return n, fmt.Errorf("%w: %d input tokens exceeds %d for %s", ErrContextWindow, n, window, req.Model)
+	}
+	return n, nil
+}
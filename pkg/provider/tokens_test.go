@@ -0,0 +1,131 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestEstimateTokens(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		min, max int
+	}{
+		{"empty", "", 0, 0},
+		{"short words", "The quick brown fox jumps over the lazy dog.", 9, 12},
+		{"numbers", "1234567", 3, 3},
+		{"long word", "internationalization", 3, 5},
+		{"non-ascii", "日本語", 3, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateTextTokens(tt.text)
+			if got < tt.min || got > tt.max {
+				t.Errorf("estimateTextTokens(%q) = %d, want %d-%d", tt.text, got, tt.min, tt.max)
+			}
+		})
+	}
+
+	req := &Request{
+		System:   "You are terse.",
+		Messages: []Message{{Role: "user", Content: "Hi", Parts: []ContentPart{ImageURLPart("https://example.com/cat.png")}}},
+		Tools:    []Tool{{Name: "lookup", Description: "Look something up.", Parameters: map[string]interface{}{"type": "object"}}},
+	}
+	got := EstimateTokens(req)
+	base := EstimateTokens(&Request{Messages: []Message{{Role: "user", Content: "Hi"}}})
+	if got <= base+imageTokenEstimate {
+		t.Errorf("EstimateTokens() = %d, want more than %d for system, image, and tools", got, base+imageTokenEstimate)
+	}
+}
+
+type countingProvider struct {
+	stubProvider
+	n int
+}
+
+func (c countingProvider) CountTokens(context.Context, *Request) (int, error) { return c.n, nil }
+
+type stubProvider struct{}
+
+func (stubProvider) Name() string { return "stub" }
+func (stubProvider) Complete(context.Context, *Request) (*Response, error) {
+	return &Response{}, nil
+}
+
+func TestCountTokens(t *testing.T) {
+	req := &Request{Messages: []Message{{Role: "user", Content: "hello there"}}}
+	if n, err := CountTokens(context.Background(), countingProvider{n: 42}, req); err != nil || n != 42 {
+		t.Errorf("CountTokens(counter) = %d, %v, want 42", n, err)
+	}
+	want := EstimateTokens(req)
+	if n, err := CountTokens(context.Background(), stubProvider{}, req); err != nil || n != want {
+		t.Errorf("CountTokens(no counter) = %d, %v, want estimate %d", n, err, want)
+	}
+	if n, err := CountTokens(context.Background(), nil, req); err != nil || n != want {
+		t.Errorf("CountTokens(nil) = %d, %v, want estimate %d", n, err, want)
+	}
+}
+
+func TestContextWindow(t *testing.T) {
+	if n, ok := ContextWindow("openai/gpt-4o"); !ok || n != 128_000 {
+		t.Errorf("ContextWindow(openai/gpt-4o) = %d, %v, want 128000", n, ok)
+	}
+	if _, ok := ContextWindow("my-fine-tune"); ok {
+		t.Error("ContextWindow(unknown) reported a window")
+	}
+}
+
+func TestCheckContextWindow(t *testing.T) {
+	ctx := context.Background()
+	req := &Request{Model: "gpt-4"}
+	if _, err := CheckContextWindow(ctx, countingProvider{n: 8000}, req); err != nil {
+		t.Errorf("8000 tokens: unexpected error %v", err)
+	}
+	req.MaxTokens = 500
+	_, err := CheckContextWindow(ctx, countingProvider{n: 8000}, req)
+	if !errors.Is(err, ErrContextWindow) {
+		t.Errorf("8000 tokens + 500 max_tokens: err = %v, want ErrContextWindow", err)
+	}
+	req.Model = "my-fine-tune"
+	if n, err := CheckContextWindow(ctx, countingProvider{n: 1_000_000}, req); err != nil || n != 1_000_000 {
+		t.Errorf("unknown model: got %d, %v, want count and no error", n, err)
+	}
+}
+
+func TestAnthropicProvider_CountTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasSuffix(r.URL.Path, "/messages/count_tokens") {
+			t.Errorf("path = %q, want .../messages/count_tokens", r.URL.Path)
+		}
+		var body map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("decoding request body: %v", err)
+		}
+		if _, ok := body["max_tokens"]; ok {
+			t.Error("count_tokens request carries max_tokens")
+		}
+		if body["system"] != "Be brief." {
+			t.Errorf("system = %v, want %q", body["system"], "Be brief.")
+		}
+		w.Write([]byte(`{"input_tokens": 17}`))
+	}))
+	defer server.Close()
+
+	p := NewAnthropicProvider("test-key", WithBaseURL(server.URL+"/v1/messages"))
+	n, err := CountTokens(context.Background(), p, &Request{
+		Model:    "claude-opus-4-6",
+		System:   "Be brief.",
+		Messages: []Message{{Role: "user", Content: "Hello"}},
+	})
+	if err != nil {
+		t.Fatalf("CountTokens() error: %v", err)
+	}
+	if n != 17 {
+		t.Errorf("CountTokens() = %d, want 17", n)
+	}
+}
@@ -0,0 +1,81 @@
+package provider
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"time"
+)
+
+// TransportConfig tunes the HTTP connection pool providers send requests
+// through. Zero values fall back to DefaultTransportConfig.
+type TransportConfig struct {
+	// MaxIdleConnsPerHost is how many idle keep-alive connections are kept
+	// per API host. It should be at least the run concurrency, or
+	// connections are closed and redialed between requests.
+	MaxIdleConnsPerHost int
+	// MaxConnsPerHost caps all connections per host; 0 means no limit.
+	MaxConnsPerHost int
+	// IdleConnTimeout is how long an idle connection is kept open.
+	IdleConnTimeout time.Duration
+	// KeepAlive is the TCP keep-alive probe interval.
+	KeepAlive time.Duration
+	// DisableHTTP2 forces HTTP/1.1, for proxies that mishandle HTTP/2.
+	DisableHTTP2 bool
+}
+
+// DefaultTransportConfig returns the transport settings used when none are
+// configured, sized for runs of up to 100 concurrent cases.
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConnsPerHost: 100,
+		IdleConnTimeout:     90 * time.Second,
+		KeepAlive:           30 * time.Second,
+	}
+}
+
+// NewTransport builds an HTTP transport from cfg. One transport should be
+// shared by every provider in a process so connections are reused across
+// providers and goroutines.
+func NewTransport(cfg TransportConfig) *http.Transport {
+	def := DefaultTransportConfig()
+	if cfg.MaxIdleConnsPerHost <= 0 {
+		cfg.MaxIdleConnsPerHost = def.MaxIdleConnsPerHost
+	}
+	if cfg.IdleConnTimeout <= 0 {
+		cfg.IdleConnTimeout = def.IdleConnTimeout
+	}
+	if cfg.KeepAlive <= 0 {
+		cfg.KeepAlive = def.KeepAlive
+	}
+
+	t := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: cfg.KeepAlive,
+		}).DialContext,
+		ForceAttemptHTTP2:     !cfg.DisableHTTP2,
+		MaxIdleConns:          0, // bounded per host instead
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       cfg.IdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if cfg.DisableHTTP2 {
+		// A non-nil, empty map turns off the transport's HTTP/2 upgrade.
+		t.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+	return t
+}
+
+// NewHTTPClient returns a client for providers that sends requests through
+// transport.
+func NewHTTPClient(transport http.RoundTripper) *http.Client {
+	return &http.Client{Transport: transport, Timeout: 60 * time.Second}
+}
+
+// defaultClient is used by providers created without WithHTTPClient, so
+// that separately constructed providers still share one connection pool.
+var defaultClient = NewHTTPClient(NewTransport(DefaultTransportConfig()))
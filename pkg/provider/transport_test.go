@@ -0,0 +1,85 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestNewTransport_Defaults(t *testing.T) {
+	tr := NewTransport(TransportConfig{})
+	def := DefaultTransportConfig()
+	if tr.MaxIdleConnsPerHost != def.MaxIdleConnsPerHost {
+		t.Errorf("MaxIdleConnsPerHost = %d, want %d", tr.MaxIdleConnsPerHost, def.MaxIdleConnsPerHost)
+	}
+	if tr.IdleConnTimeout != def.IdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %s, want %s", tr.IdleConnTimeout, def.IdleConnTimeout)
+	}
+	if !tr.ForceAttemptHTTP2 || tr.TLSNextProto != nil {
+		t.Error("HTTP/2 should be enabled by default")
+	}
+
+	tr = NewTransport(TransportConfig{MaxIdleConnsPerHost: 7, DisableHTTP2: true})
+	if tr.MaxIdleConnsPerHost != 7 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 7", tr.MaxIdleConnsPerHost)
+	}
+	if tr.ForceAttemptHTTP2 || tr.TLSNextProto == nil {
+		t.Error("DisableHTTP2 should turn off the HTTP/2 upgrade")
+	}
+}
+
+// TestSharedProvider_ReusesConnections runs waves of concurrent requests
+// through one provider instance (run with -race to check it is safe to
+// share) and checks that later waves reuse the first wave's connections.
+func TestSharedProvider_ReusesConnections(t *testing.T) {
+	var newConns atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resp := anthropicResponse{Content: []anthropicContentBlock{{Type: "text", Text: "ok"}}}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(resp)
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			newConns.Add(1)
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	const concurrency = 20
+	p := NewAnthropicProvider("test-key",
+		WithBaseURL(server.URL),
+		WithHTTPClient(NewHTTPClient(NewTransport(TransportConfig{MaxIdleConnsPerHost: concurrency}))),
+	)
+
+	for wave := 0; wave < 3; wave++ {
+		var wg sync.WaitGroup
+		for i := 0; i < concurrency; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				resp, err := p.Complete(context.Background(), &Request{
+					Model:    "m",
+					Messages: []Message{{Role: "user", Content: "hi"}},
+				})
+				if err != nil {
+					t.Errorf("Complete() error: %v", err)
+					return
+				}
+				if resp.Content != "ok" {
+					t.Errorf("Content = %q, want ok", resp.Content)
+				}
+			}()
+		}
+		wg.Wait()
+	}
+
+	if got := newConns.Load(); got > concurrency {
+		t.Errorf("opened %d connections for 3 waves of %d requests, want at most %d", got, concurrency, concurrency)
+	}
+}
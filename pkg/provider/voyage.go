@@ -0,0 +1,127 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const defaultVoyageURL = "https://api.voyageai.com/v1/embeddings"
+
+// VoyageOption configures a VoyageProvider.
+type VoyageOption func(*VoyageProvider)
+
+// WithVoyageHTTPClient sets a custom HTTP client (useful for testing).
+func WithVoyageHTTPClient(c *http.Client) VoyageOption {
+	return func(p *VoyageProvider) { p.client = c }
+}
+
+// WithVoyageBaseURL overrides the Voyage embeddings endpoint.
+func WithVoyageBaseURL(url string) VoyageOption {
+	return func(p *VoyageProvider) { p.baseURL = url }
+}
+
+// WithVoyageMaxRetries sets the maximum number of retry attempts.
+func WithVoyageMaxRetries(n int) VoyageOption {
+	return func(p *VoyageProvider) { p.maxRetries = n }
+}
+
+// WithVoyageRetryBudget shares a run-level retry budget with other
+// providers.
+func WithVoyageRetryBudget(b *RetryBudget) VoyageOption {
+	return func(p *VoyageProvider) { p.budget = b }
+}
+
+// VoyageProvider implements EmbeddingProvider for the Voyage AI embeddings
+// API, the embeddings Anthropic recommends alongside Claude.
+type VoyageProvider struct {
+	apiKey     string
+	baseURL    string
+	client     *http.Client
+	maxRetries int
+	budget     *RetryBudget
+}
+
+// NewVoyageProvider creates a Voyage embeddings provider with the given
+// API key.
+func NewVoyageProvider(apiKey string, opts ...VoyageOption) *VoyageProvider {
+	p := &VoyageProvider{
+		apiKey:     apiKey,
+		baseURL:    defaultVoyageURL,
+		client:     defaultClient,
+		maxRetries: defaultMaxRetries,
+	}
+	for _, opt := range opts {
+		opt(p)
+	}
+	return p
+}
+
+// Name returns "voyage".
+func (p *VoyageProvider) Name() string { return "voyage" }
+
+// voyageRequest is the Voyage embeddings request body.
+type voyageRequest struct {
+	Input     []string `json:"input"`
+	Model     string   `json:"model"`
+	InputType string   `json:"input_type,omitempty"`
+}
+
+// Embed returns the embeddings of req's inputs. InputType is passed
+// through, so queries and documents are embedded for retrieval.
+func (p *VoyageProvider) Embed(ctx context.Context, req *EmbeddingRequest) (*EmbeddingResponse, error) {
+	if len(req.Input) == 0 {
+		return nil, errors.New("embedding request has no input")
+	}
+	body, err := json.Marshal(voyageRequest{Input: req.Input, Model: req.Model, InputType: req.InputType})
+	if err != nil {
+		return nil, fmt.Errorf("building request body: %w", err)
+	}
+	data, err := retryBatchCall(p.Name(), p.maxRetries, p.budget, func() ([]byte, error) {
+		return p.send(ctx, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return parseEmbeddings(data, len(req.Input))
+}
+
+func (p *VoyageProvider) send(ctx context.Context, body []byte) ([]byte, error) {
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("creating HTTP request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set("Authorization", "Bearer "+p.apiKey)
+
+	httpResp, err := p.client.Do(httpReq)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("sending HTTP request: %w", err)}
+	}
+	defer httpResp.Body.Close()
+
+	respBody, err := io.ReadAll(httpResp.Body)
+	if err != nil {
+		return nil, &retryableError{err: fmt.Errorf("reading response body: %w", err)}
+	}
+
+	if httpResp.StatusCode != http.StatusOK {
+		var apiErr struct {
+			Detail string `json:"detail"`
+		}
+		msg := string(respBody)
+		if json.Unmarshal(respBody, &apiErr) == nil && apiErr.Detail != "" {
+			msg = apiErr.Detail
+		}
+		err := fmt.Errorf("HTTP %d: %s", httpResp.StatusCode, msg)
+		if httpResp.StatusCode == http.StatusTooManyRequests || httpResp.StatusCode >= 500 {
+			return nil, &retryableError{err: err}
+		}
+		return nil, err
+	}
+	return respBody, nil
+}
@@ -0,0 +1,110 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+)
+
+// Badge colors, matching the shields.io palette so eval badges sit well
+// beside other README badges.
+const (
+	badgeBrightGreen = "#4c1"
+	badgeGreen       = "#97ca00"
+	badgeYellow      = "#dfb317"
+	badgeOrange      = "#fe7d37"
+	badgeRed         = "#e05d44"
+	badgeGrey        = "#9f9f9f"
+)
+
+// BadgeValue returns the text a pass-rate badge shows for s: the pass
+// rate rounded down to a whole percent, so a run with any failure never
+// reads 100%, or "no cases" for an empty run.
+func BadgeValue(s result.Stats) string {
+	if s.TotalCases == 0 {
+		return "no cases"
+	}
+	return fmt.Sprintf("%d%%", int(math.Floor(s.PassRate*100)))
+}
+
+// BadgeColor returns the color of a pass-rate badge for s, from bright
+// green at 95% and above down to red below 40%.
+func BadgeColor(s result.Stats) string {
+	switch {
+	case s.TotalCases == 0:
+		return badgeGrey
+	case s.PassRate >= 0.95:
+		return badgeBrightGreen
+	case s.PassRate >= 0.80:
+		return badgeGreen
+	case s.PassRate >= 0.60:
+		return badgeYellow
+	case s.PassRate >= 0.40:
+		return badgeOrange
+	default:
+		return badgeRed
+	}
+}
+
+// Badge renders a shields-style SVG badge with label on the left and the
+// pass rate of s on the right, for embedding in READMEs and dashboards.
+func Badge(label string, s result.Stats) string {
+	value := BadgeValue(s)
+	// Text widths are estimated for 11px Verdana, the shields.io font, as
+	// the badge is rendered without access to font metrics.
+	lw, vw := badgeTextWidth(label)+10, badgeTextWidth(value)+10
+	total := lw + vw
+	label, value = html.EscapeString(label), html.EscapeString(value)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="20" role="img" aria-label="%s: %s">`, total, label, value)
+	fmt.Fprintf(&b, `<title>%s: %s</title>`, label, value)
+	b.WriteString(`<linearGradient id="s" x2="0" y2="100%"><stop offset="0" stop-color="#bbb" stop-opacity=".1"/><stop offset="1" stop-opacity=".1"/></linearGradient>`)
+	fmt.Fprintf(&b, `<clipPath id="r"><rect width="%d" height="20" rx="3" fill="#fff"/></clipPath>`, total)
+	b.WriteString(`<g clip-path="url(#r)">`)
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="#555"/>`, lw)
+	fmt.Fprintf(&b, `<rect x="%d" width="%d" height="20" fill="%s"/>`, lw, vw, BadgeColor(s))
+	fmt.Fprintf(&b, `<rect width="%d" height="20" fill="url(#s)"/>`, total)
+	b.WriteString(`</g>`)
+	b.WriteString(`<g fill="#fff" text-anchor="middle" font-family="Verdana,Geneva,DejaVu Sans,sans-serif" font-size="11">`)
+	for _, t := range []struct {
+		x    int
+		text string
+	}{{lw / 2, label}, {lw + vw/2, value}} {
+		fmt.Fprintf(&b, `<text x="%d" y="15" fill="#010101" fill-opacity=".3">%s</text>`, t.x, t.text)
+		fmt.Fprintf(&b, `<text x="%d" y="14">%s</text>`, t.x, t.text)
+	}
+	b.WriteString("</g></svg>\n")
+	return b.String()
+}
+
+// badgeTextWidth estimates the width in pixels of s in 11px Verdana.
+func badgeTextWidth(s string) int {
+	w := 0.0
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune("il.:,;|!'", r):
+			w += 3.5
+		case strings.ContainsRune("mwMW%", r):
+			w += 10
+		case r == ' ':
+			w += 4
+		default:
+			w += 7
+		}
+	}
+	return int(math.Ceil(w))
+}
+
+// BadgeMarkdown returns a markdown image snippet showing the badge at
+// svgPath, linked to link when it is non-empty.
+func BadgeMarkdown(label, svgPath, link string) string {
+	img := fmt.Sprintf("![%s](%s)", label, svgPath)
+	if link == "" {
+		return img
+	}
+	return fmt.Sprintf("[%s](%s)", img, link)
+}
@@ -0,0 +1,55 @@
+package report
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+)
+
+func TestBadgeValueAndColor(t *testing.T) {
+	tests := []struct {
+		stats     result.Stats
+		wantValue string
+		wantColor string
+	}{
+		{result.Stats{}, "no cases", badgeGrey},
+		{result.Stats{TotalCases: 400, PassRate: 399.0 / 400}, "99%", badgeBrightGreen},
+		{result.Stats{TotalCases: 10, PassRate: 1}, "100%", badgeBrightGreen},
+		{result.Stats{TotalCases: 10, PassRate: 0.8}, "80%", badgeGreen},
+		{result.Stats{TotalCases: 10, PassRate: 0.6}, "60%", badgeYellow},
+		{result.Stats{TotalCases: 10, PassRate: 0.5}, "50%", badgeOrange},
+		{result.Stats{TotalCases: 10, PassRate: 0.1}, "10%", badgeRed},
+	}
+	for _, tt := range tests {
+		if got := BadgeValue(tt.stats); got != tt.wantValue {
+			t.Errorf("BadgeValue(%+v) = %q, want %q", tt.stats, got, tt.wantValue)
+		}
+		if got := BadgeColor(tt.stats); got != tt.wantColor {
+			t.Errorf("BadgeColor(%+v) = %q, want %q", tt.stats, got, tt.wantColor)
+		}
+	}
+}
+
+func TestBadge(t *testing.T) {
+	svg := Badge("evals <codegen>", sampleSummary().Stats)
+	if err := xml.Unmarshal([]byte(svg), new(struct{})); err != nil {
+		t.Fatalf("Badge() is not valid XML: %v\n%s", err, svg)
+	}
+	for _, want := range []string{"evals &lt;codegen&gt;: 50%", badgeOrange} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("Badge() missing %q:\n%s", want, svg)
+		}
+	}
+}
+
+func TestBadgeMarkdown(t *testing.T) {
+	if got := BadgeMarkdown("evals", "badge.svg", ""); got != "![evals](badge.svg)" {
+		t.Errorf("BadgeMarkdown() = %q", got)
+	}
+	want := "[![evals](badge.svg)](https://ci.example.com/run/1)"
+	if got := BadgeMarkdown("evals", "badge.svg", "https://ci.example.com/run/1"); got != want {
+		t.Errorf("BadgeMarkdown() = %q, want %q", got, want)
+	}
+}
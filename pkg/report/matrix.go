@@ -0,0 +1,289 @@
+package report
+
+import (
+	"fmt"
+	"html"
+	"io"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+)
+
+// AllSuites is the column of a Matrix that covers every suite, shown when
+// it has more than one.
+const AllSuites = "all suites"
+
+// Matrix is a model-by-suite grid of run statistics, built from the case
+// results of runs that put several models through the same suites, for
+// choosing between models from a single table.
+type Matrix struct {
+	// Models and Suites are the rows and columns, in the order they first
+	// appear in the results. Suites ends with AllSuites when there is more
+	// than one suite.
+	Models []string
+	Suites []string
+
+	cells map[matrixKey]result.Stats
+}
+
+type matrixKey struct{ model, suite string }
+
+// BuildMatrix groups the case results of summaries by model and suite. A
+// case's suite is its own for a combined summary and the summary's
+// otherwise; its model is the one it ran on, falling back to the run's
+// default model from the manifest.
+func BuildMatrix(summaries []*result.RunSummary) *Matrix {
+	m := &Matrix{cells: make(map[matrixKey]result.Stats)}
+	groups := make(map[matrixKey][]result.CaseResult)
+	seenModel, seenSuite := make(map[string]bool), make(map[string]bool)
+	for _, s := range summaries {
+		for _, cr := range s.Results {
+			model, suiteName := cr.Model, cr.Suite
+			if model == "" && s.Manifest != nil {
+				model = s.Manifest.Model
+			}
+			if model == "" {
+				model = "unknown"
+			}
+			if suiteName == "" {
+				suiteName = s.SuiteName
+			}
+			if !seenModel[model] {
+				seenModel[model] = true
+				m.Models = append(m.Models, model)
+			}
+			if !seenSuite[suiteName] {
+				seenSuite[suiteName] = true
+				m.Suites = append(m.Suites, suiteName)
+			}
+			k := matrixKey{model, suiteName}
+			groups[k] = append(groups[k], cr)
+			all := matrixKey{model, AllSuites}
+			groups[all] = append(groups[all], cr)
+		}
+	}
+	if len(m.Suites) > 1 {
+		m.Suites = append(m.Suites, AllSuites)
+	}
+	for k, crs := range groups {
+		if k.suite == AllSuites && len(m.Suites) == 1 {
+			continue
+		}
+		m.cells[k] = result.ComputeStats(crs)
+	}
+	return m
+}
+
+// Cell returns the stats of model's cases in suite, and whether it ran any.
+func (m *Matrix) Cell(model, suite string) (result.Stats, bool) {
+	s, ok := m.cells[matrixKey{model, suite}]
+	return s, ok
+}
+
+// costPerCase returns the average estimated cost of the cases in s.
+func costPerCase(s result.Stats) float64 {
+	if s.TotalCases == 0 {
+		return 0
+	}
+	return s.Cost / float64(s.TotalCases)
+}
+
+// matrixBest records which of a cell's metrics are the best in its column.
+type matrixBest struct{ pass, score, cost bool }
+
+// best reports, for each model with a cell in suite, which of its metrics
+// are the best in the column: the highest pass rate and average score and
+// the lowest cost per case. Ties are all best; nothing is best in a column
+// with a single model, and cost is only compared between priced cells.
+func (m *Matrix) best(suite string) map[string]matrixBest {
+	var models []string
+	for _, model := range m.Models {
+		if _, ok := m.Cell(model, suite); ok {
+			models = append(models, model)
+		}
+	}
+	out := make(map[string]matrixBest)
+	if len(models) < 2 {
+		return out
+	}
+	var maxPass, maxScore, minCost float64
+	minCost = -1
+	for _, model := range models {
+		s, _ := m.Cell(model, suite)
+		maxPass = max(maxPass, s.PassRate)
+		maxScore = max(maxScore, s.AvgScore)
+		if c := costPerCase(s); c > 0 && (minCost < 0 || c < minCost) {
+			minCost = c
+		}
+	}
+	for _, model := range models {
+		s, _ := m.Cell(model, suite)
+		out[model] = matrixBest{
+			pass:  s.PassRate == maxPass,
+			score: s.AvgScore == maxScore,
+			cost:  minCost > 0 && costPerCase(s) == minCost,
+		}
+	}
+	return out
+}
+
+// bestByColumn returns best for every column, keyed by suite.
+func (m *Matrix) bestByColumn() map[string]map[string]matrixBest {
+	out := make(map[string]map[string]matrixBest, len(m.Suites))
+	for _, suite := range m.Suites {
+		out[suite] = m.best(suite)
+	}
+	return out
+}
+
+// matrixCell holds the formatted metrics of a cell.
+type matrixCell struct{ pass, score, cost string }
+
+func formatMatrixCell(s result.Stats) matrixCell {
+	c := matrixCell{
+		pass:  fmt.Sprintf("%.1f%%", s.PassRate*100),
+		score: fmt.Sprintf("%.2f", s.AvgScore),
+		cost:  "-",
+	}
+	if s.Cost > 0 {
+		c.cost = fmt.Sprintf("$%.4f", costPerCase(s))
+	}
+	return c
+}
+
+// PrintMatrix writes the matrix as a terminal table whose cells show the
+// pass rate, average score, and cost per case. The best of each metric in
+// a column is highlighted in green when color is set, and marked with an
+// asterisk otherwise.
+func PrintMatrix(w io.Writer, m *Matrix, color bool) {
+	const modelWidth, cellWidth = 28, 24
+	sep := strings.Repeat("-", 2+modelWidth+len(m.Suites)*(2+cellWidth))
+	fmt.Fprintf(w, "%s\n", sep)
+	fmt.Fprintf(w, "  %-*s", modelWidth, "MODEL")
+	for _, suite := range m.Suites {
+		fmt.Fprintf(w, "  %-*s", cellWidth, truncate(suite, cellWidth))
+	}
+	fmt.Fprintf(w, "\n%s\n", sep)
+
+	best := m.bestByColumn()
+	mark := func(text string, width int, isBest bool) string {
+		text = fmt.Sprintf("%*s", width, text)
+		switch {
+		case isBest && color:
+			return colorBold + colorGreen + text + colorReset + " "
+		case isBest:
+			return text + "*"
+		default:
+			return text + " "
+		}
+	}
+	for _, model := range m.Models {
+		fmt.Fprintf(w, "  %-*s", modelWidth, truncate(model, modelWidth))
+		for _, suite := range m.Suites {
+			s, ok := m.Cell(model, suite)
+			if !ok {
+				fmt.Fprintf(w, "  %-*s", cellWidth, "")
+				continue
+			}
+			c, b := formatMatrixCell(s), best[suite][model]
+			fmt.Fprintf(w, "  %s%s%s", mark(c.pass, 6, b.pass), mark(c.score, 5, b.score), mark(c.cost, 9, b.cost))
+		}
+		fmt.Fprintln(w)
+	}
+	fmt.Fprintf(w, "%s\n", sep)
+	legend := "  cells: pass rate, avg score, cost per case"
+	if color {
+		fmt.Fprintf(w, "%s; best in each column in green\n", legend)
+	} else {
+		fmt.Fprintf(w, "%s; * best in each column\n", legend)
+	}
+}
+
+// PrintMatrixMarkdown writes the matrix as a markdown table, with the best
+// of each metric in a column in bold.
+func PrintMatrixMarkdown(w io.Writer, m *Matrix) {
+	fmt.Fprintf(w, "# Model comparison\n\n")
+	fmt.Fprintf(w, "Each cell: pass rate · avg score · cost per case. **Bold** marks the best in each column.\n\n")
+	fmt.Fprintf(w, "| Model |")
+	for _, suite := range m.Suites {
+		fmt.Fprintf(w, " %s |", mdEscape(suite))
+	}
+	fmt.Fprintf(w, "\n|---|%s\n", strings.Repeat("---:|", len(m.Suites)))
+
+	best := m.bestByColumn()
+	bold := func(text string, isBest bool) string {
+		if isBest {
+			return "**" + text + "**"
+		}
+		return text
+	}
+	for _, model := range m.Models {
+		fmt.Fprintf(w, "| %s |", mdEscape(model))
+		for _, suite := range m.Suites {
+			s, ok := m.Cell(model, suite)
+			if !ok {
+				fmt.Fprintf(w, " |")
+				continue
+			}
+			c, b := formatMatrixCell(s), best[suite][model]
+			fmt.Fprintf(w, " %s · %s · %s |", bold(c.pass, b.pass), bold(c.score, b.score), bold(c.cost, b.cost))
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// PrintMatrixHTML writes the matrix as a self-contained HTML page, with
+// the best of each metric in a column highlighted.
+func PrintMatrixHTML(w io.Writer, m *Matrix) {
+	fmt.Fprint(w, `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Model comparison</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; }
+table { border-collapse: collapse; }
+th, td { border: 1px solid #ccc; padding: 6px 10px; text-align: right; }
+th:first-child, td:first-child { text-align: left; }
+td span { display: inline-block; min-width: 4.5em; }
+.best { font-weight: bold; color: #1a7f37; }
+.legend { color: #555; }
+</style>
+</head>
+<body>
+<h1>Model comparison</h1>
+<p class="legend">Each cell: pass rate, avg score, cost per case. <span class="best">Highlighted</span> values are the best in their column.</p>
+<table>
+<tr><th>Model</th>`)
+	for _, suite := range m.Suites {
+		fmt.Fprintf(w, "<th>%s</th>", html.EscapeString(suite))
+	}
+	fmt.Fprint(w, "</tr>\n")
+
+	best := m.bestByColumn()
+	span := func(text string, isBest bool) string {
+		if isBest {
+			return `<span class="best">` + html.EscapeString(text) + "</span>"
+		}
+		return "<span>" + html.EscapeString(text) + "</span>"
+	}
+	for _, model := range m.Models {
+		fmt.Fprintf(w, "<tr><td>%s</td>", html.EscapeString(model))
+		for _, suite := range m.Suites {
+			s, ok := m.Cell(model, suite)
+			if !ok {
+				fmt.Fprint(w, "<td></td>")
+				continue
+			}
+			c, b := formatMatrixCell(s), best[suite][model]
+			fmt.Fprintf(w, "<td>%s%s%s</td>", span(c.pass, b.pass), span(c.score, b.score), span(c.cost, b.cost))
+		}
+		fmt.Fprint(w, "</tr>\n")
+	}
+	fmt.Fprint(w, "</table>\n</body>\n</html>\n")
+}
+
+// mdEscape escapes the pipes that would break a markdown table cell.
+func mdEscape(s string) string {
+	return strings.ReplaceAll(s, "|", `\|`)
+}
@@ -0,0 +1,104 @@
+package report
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+)
+
+// matrixRuns returns two models' runs over the same two suites: model-a
+// passes more, model-b is cheaper.
+func matrixRuns() []*result.RunSummary {
+	run := func(model string, cost float64, passes ...bool) *result.RunSummary {
+		var crs []result.CaseResult
+		for i, p := range passes {
+			suiteName := "qa"
+			if i%2 == 1 {
+				suiteName = "code|gen"
+			}
+			score := 0.0
+			if p {
+				score = 1
+			}
+			crs = append(crs, result.CaseResult{Suite: suiteName, Model: model, Pass: p, Score: score, Cost: cost})
+		}
+		return &result.RunSummary{SuiteName: result.CombinedSuiteName, Suites: []string{"qa", "code|gen"}, Results: crs}
+	}
+	return []*result.RunSummary{
+		run("model-a", 0.02, true, true, true, false),
+		run("model-b", 0.01, true, false, false, false),
+	}
+}
+
+func TestBuildMatrix(t *testing.T) {
+	m := BuildMatrix(matrixRuns())
+	if got := strings.Join(m.Models, ","); got != "model-a,model-b" {
+		t.Errorf("Models = %q", got)
+	}
+	if got := strings.Join(m.Suites, ","); got != "qa,code|gen,"+AllSuites {
+		t.Errorf("Suites = %q", got)
+	}
+	s, ok := m.Cell("model-a", "code|gen")
+	if !ok || s.TotalCases != 2 || s.PassRate != 0.5 {
+		t.Errorf("Cell(model-a, code|gen) = %+v, %v", s, ok)
+	}
+	if s, _ := m.Cell("model-b", AllSuites); s.TotalCases != 4 || s.PassRate != 0.25 {
+		t.Errorf("Cell(model-b, all) = %+v", s)
+	}
+	if _, ok := m.Cell("model-c", "qa"); ok {
+		t.Error("Cell() of a model that did not run should not exist")
+	}
+
+	best := m.best("qa")
+	if a, b := best["model-a"], best["model-b"]; !a.pass || !a.score || a.cost || b.pass || !b.cost {
+		t.Errorf("best(qa) = %+v", best)
+	}
+}
+
+func TestBuildMatrix_SingleSuite(t *testing.T) {
+	runs := []*result.RunSummary{{
+		SuiteName: "qa",
+		Manifest:  &result.Manifest{Model: "default-model"},
+		Results:   []result.CaseResult{{Pass: true}},
+	}}
+	m := BuildMatrix(runs)
+	if len(m.Suites) != 1 || m.Models[0] != "default-model" {
+		t.Errorf("Suites = %v, Models = %v", m.Suites, m.Models)
+	}
+	if len(m.best("qa")) != 0 {
+		t.Error("a column with one model should have no best")
+	}
+}
+
+func TestPrintMatrix(t *testing.T) {
+	m := BuildMatrix(matrixRuns())
+
+	var buf bytes.Buffer
+	PrintMatrix(&buf, m, false)
+	out := buf.String()
+	for _, want := range []string{"MODEL", "model-a", "100.0%*", "$0.0100*", "* best in each column"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PrintMatrix() missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	PrintMatrixMarkdown(&buf, m)
+	out = buf.String()
+	for _, want := range []string{`| Model | qa | code\|gen | all suites |`, "| model-a | **100.0%** · **1.00** · $0.0200 |"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PrintMatrixMarkdown() missing %q:\n%s", want, out)
+		}
+	}
+
+	buf.Reset()
+	PrintMatrixHTML(&buf, m)
+	out = buf.String()
+	for _, want := range []string{"<th>code|gen</th>", `<span class="best">100.0%</span>`, "</html>"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("PrintMatrixHTML() missing %q:\n%s", want, out)
+		}
+	}
+}
@@ -3,6 +3,7 @@ package report
 import (
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 	"time"
 
@@ -21,6 +22,18 @@ const (
 
 // StatusLabel returns a colored status string for terminal display.
 func StatusLabel(cr result.CaseResult) string {
+	if cr.Status == "timeout" {
+		return colorYellow + "TIMEOUT" + colorReset
+	}
+	if cr.Status == "unjudged" {
+		return colorDim + "PENDING" + colorReset
+	}
+	if cr.Status == "skipped" {
+		return colorDim + "SKIP" + colorReset
+	}
+	if cr.Status == "blocked" {
+		return colorYellow + "BLOCKED" + colorReset
+	}
 	if cr.Error != "" {
 		return colorRed + "ERROR" + colorReset
 	}
@@ -32,6 +45,18 @@ func StatusLabel(cr result.CaseResult) string {
 
 // StatusLabelPlain returns an uncolored status string.
 func StatusLabelPlain(cr result.CaseResult) string {
+	if cr.Status == "timeout" {
+		return "TIMEOUT"
+	}
+	if cr.Status == "unjudged" {
+		return "PENDING"
+	}
+	if cr.Status == "skipped" {
+		return "SKIP"
+	}
+	if cr.Status == "blocked" {
+		return "BLOCKED"
+	}
 	if cr.Error != "" {
 		return "ERROR"
 	}
@@ -60,21 +85,27 @@ func PrintSummaryTable(w io.Writer, summary *result.RunSummary, color bool) {
 	fmt.Fprintf(w, "  %-30s  %-7s  %8s  %8s\n", "CASE", "STATUS", "SCORE", "LATENCY")
 	fmt.Fprintf(w, "%s\n", sep)
 
-	// Case rows.
-	for _, cr := range summary.Results {
-		name := truncate(cr.CaseName, 30)
-		var status string
-		if color {
-			status = StatusLabel(cr)
-		} else {
-			status = StatusLabelPlain(cr)
+	// Case rows, in a section per suite for a combined summary.
+	if summary.IsCombined() {
+		for i, part := range summary.Split() {
+			if i > 0 {
+				fmt.Fprintln(w)
+			}
+			fmt.Fprintf(w, "  [%s]\n", part.SuiteName)
+			printCaseRows(w, part.Results, color)
+			ps := part.Stats
+			fmt.Fprintf(w, "  %d passed  %d failed  %d errored  | avg %.2f\n",
+				ps.PassedCases, ps.FailedCases, ps.ErroredCases, ps.AvgScore)
 		}
-		fmt.Fprintf(w, "  %-30s  %-7s  %8.2f  %8s\n",
-			name, status, cr.Score, FormatDuration(cr.Duration))
+	} else {
+		printCaseRows(w, summary.Results, color)
 	}
 
 	// Footer.
 	fmt.Fprintf(w, "%s\n", sep)
+	if summary.IsCombined() {
+		fmt.Fprintf(w, "  TOTAL (%d suites)\n", len(summary.Suites))
+	}
 	s := summary.Stats
 	if color {
 		fmt.Fprintf(w, "  %s%d passed%s  %s%d failed%s  %s%d errored%s  | avg %.2f | %s total\n",
@@ -87,10 +118,151 @@ func PrintSummaryTable(w io.Writer, summary *result.RunSummary, color bool) {
 			s.PassedCases, s.FailedCases, s.ErroredCases,
 			s.AvgScore, FormatDuration(summary.Duration))
 	}
-	fmt.Fprintf(w, "  p50 %s | p95 %s | tokens: %d in / %d out\n",
+	if n := s.JudgedCases(); n > 0 {
+		low, high := s.PassRateInterval()
+		fmt.Fprintf(w, "  pass rate %.1f%% (95%% CI %.1f%%-%.1f%%, n=%d)\n", s.PassRate*100, low*100, high*100, n)
+		if n < result.MinCasesForComparison {
+			fmt.Fprintf(w, "  warning: only %d judged cases; pass rate differences under %.0f points may be noise\n",
+				n, (high-low)/2*100)
+		}
+	}
+	if s.TimedOutCases > 0 {
+		fmt.Fprintf(w, "  %d timed out\n", s.TimedOutCases)
+	}
+	if len(s.ErrorsByCategory) > 0 {
+		cats := make([]string, 0, len(s.ErrorsByCategory))
+		for c := range s.ErrorsByCategory {
+			cats = append(cats, c)
+		}
+		sort.Strings(cats)
+		parts := make([]string, len(cats))
+		for i, c := range cats {
+			parts[i] = fmt.Sprintf("%d %s", s.ErrorsByCategory[c], c)
+		}
+		fmt.Fprintf(w, "  errors: %s\n", strings.Join(parts, ", "))
+	}
+	if s.BlockedCases > 0 {
+		fmt.Fprintf(w, "  %d blocked by a failed dependency\n", s.BlockedCases)
+	}
+	if s.SkippedCases > 0 {
+		fmt.Fprintf(w, "  %d skipped after the run stopped early\n", s.SkippedCases)
+	}
+	if s.ReaskedCases > 0 {
+		fmt.Fprintf(w, "  %d re-asked after invalid output\n", s.ReaskedCases)
+	}
+	if s.HallucinatedToolCases > 0 {
+		fmt.Fprintf(w, "  %d called hallucinated tools\n", s.HallucinatedToolCases)
+	}
+	if s.UnjudgedCases > 0 {
+		fmt.Fprintf(w, "  %d awaiting judgement (run 'eval rejudge')\n", s.UnjudgedCases)
+	}
+	if s.SpotChecks > 0 {
+		fmt.Fprintf(w, "  %d judge passes spot-checked, %d reviewed", s.SpotChecks, s.SpotChecksReviewed)
+		if p, ok := s.JudgePrecision(); ok {
+			fmt.Fprintf(w, " (judge precision %.0f%%)", p*100)
+		}
+		if pending := s.SpotChecks - s.SpotChecksReviewed; pending > 0 {
+			fmt.Fprintf(w, "; %d awaiting 'eval review'", pending)
+		}
+		fmt.Fprintln(w)
+	}
+	if s.TotalRetries > 0 {
+		fmt.Fprintf(w, "  %d API retries (%.0f%% of cases) | %s backing off\n",
+			s.TotalRetries, s.RetryRate*100, FormatDuration(s.BackoffTime))
+	}
+	if s.OverloadedResponses > 0 {
+		fmt.Fprintf(w, "  warning: provider overloaded (%d responses across %d cases); consider lowering concurrency\n",
+			s.OverloadedResponses, s.OverloadedCases)
+	}
+	fmt.Fprintf(w, "  p50 %s | p95 %s | tokens: %d in / %d out",
 		FormatDuration(s.LatencyP50), FormatDuration(s.LatencyP95),
 		s.TotalInputTokens, s.TotalOutputTokens)
+	if s.Cost > 0 {
+		fmt.Fprintf(w, " | est. $%.4f", s.Cost)
+	}
+	fmt.Fprintln(w)
+	if s.UnpricedCases > 0 {
+		fmt.Fprintf(w, "  warning: no pricing for the model of %d cases; their cost is at the fallback rate or omitted (see prices and price_fallback)\n",
+			s.UnpricedCases)
+	}
+	if s.TotalJudgeInputTokens > 0 || s.TotalJudgeOutputTokens > 0 {
+		fmt.Fprintf(w, "  judge tokens: %d in / %d out | est. $%.4f\n",
+			s.TotalJudgeInputTokens, s.TotalJudgeOutputTokens, s.JudgeCost)
+	}
+	fmt.Fprintf(w, "%s\n", sep)
+}
+
+func printCaseRows(w io.Writer, results []result.CaseResult, color bool) {
+	for _, cr := range results {
+		name := truncate(cr.CaseName, 30)
+		var status string
+		if color {
+			status = StatusLabel(cr)
+		} else {
+			status = StatusLabelPlain(cr)
+		}
+		fmt.Fprintf(w, "  %-30s  %-7s  %8.2f  %8s\n",
+			name, status, cr.Score, FormatDuration(cr.Duration))
+	}
+}
+
+// PrintOverview writes one row per suite run followed by a combined total,
+// used when several suites are executed in a single invocation.
+func PrintOverview(w io.Writer, summaries []*result.RunSummary) {
+	sep := strings.Repeat("-", 78)
+	fmt.Fprintf(w, "%s\n", sep)
+	fmt.Fprintf(w, "  %-26s  %6s  %6s  %6s  %6s  %8s  %6s\n",
+		"SUITE", "CASES", "PASS", "FAIL", "ERROR", "PASS %", "AVG")
+	fmt.Fprintf(w, "%s\n", sep)
+
+	var all []result.CaseResult
+	for _, s := range summaries {
+		printOverviewRow(w, truncate(s.SuiteName, 26), s.Stats)
+		all = append(all, s.Results...)
+	}
+
 	fmt.Fprintf(w, "%s\n", sep)
+	printOverviewRow(w, "TOTAL", result.ComputeStats(all))
+	fmt.Fprintf(w, "%s\n", sep)
+}
+
+func printOverviewRow(w io.Writer, name string, s result.Stats) {
+	fmt.Fprintf(w, "  %-26s  %6d  %6d  %6d  %6d  %7.1f%%  %6.2f\n",
+		name, s.TotalCases, s.PassedCases, s.FailedCases,
+		s.ErroredCases+s.TimedOutCases, s.PassRate*100, s.AvgScore)
+}
+
+// PrintHistory writes one row per stored run with its pass rate and tags.
+// Labels and the run note, when present, follow on indented lines.
+func PrintHistory(w io.Writer, summaries []*result.RunSummary) {
+	sep := strings.Repeat("-", 78)
+	fmt.Fprintf(w, "%s\n", sep)
+	fmt.Fprintf(w, "  %-40s  %6s  %8s  %s\n", "RUN ID", "CASES", "PASS %", "TAGS")
+	fmt.Fprintf(w, "%s\n", sep)
+
+	for _, s := range summaries {
+		fmt.Fprintf(w, "  %-40s  %6d  %7.1f%%  %s\n",
+			truncate(s.RunID, 40), s.Stats.TotalCases, s.Stats.PassRate*100,
+			strings.Join(s.Tags, ","))
+		if len(s.Labels) > 0 {
+			keys := make([]string, 0, len(s.Labels))
+			for k := range s.Labels {
+				keys = append(keys, k)
+			}
+			sort.Strings(keys)
+			pairs := make([]string, len(keys))
+			for i, k := range keys {
+				pairs[i] = k + "=" + s.Labels[k]
+			}
+			fmt.Fprintf(w, "    labels: %s\n", strings.Join(pairs, " "))
+		}
+		if s.Note != "" {
+			fmt.Fprintf(w, "    note:   %s\n", s.Note)
+		}
+	}
+
+	fmt.Fprintf(w, "%s\n", sep)
+	fmt.Fprintf(w, "  %d runs\n", len(summaries))
 }
 
 // PrintVerbose writes detailed per-case output including full responses.
@@ -107,16 +279,60 @@ func PrintVerbose(w io.Writer, summary *result.RunSummary, color bool) {
 			status = StatusLabelPlain(cr)
 		}
 
-		fmt.Fprintf(w, "Case: %s [%s]\n", cr.CaseName, status)
+		name := cr.CaseName
+		if cr.Suite != "" {
+			name = cr.Suite + "/" + name
+		}
+		fmt.Fprintf(w, "Case: %s [%s]\n", name, status)
 		fmt.Fprintf(w, "  ID:       %s\n", cr.CaseID)
+		if cr.Source != "" {
+			fmt.Fprintf(w, "  Source:   %s\n", cr.Source)
+		}
 		fmt.Fprintf(w, "  Prompt:   %s\n", cr.Prompt)
-		fmt.Fprintf(w, "  Model:    %s\n", cr.Model)
+		if len(cr.ModelVersions) > 0 && !(len(cr.ModelVersions) == 1 && cr.ModelVersions[0] == cr.Model) {
+			fmt.Fprintf(w, "  Model:    %s (served by %s)\n", cr.Model, strings.Join(cr.ModelVersions, ", "))
+		} else {
+			fmt.Fprintf(w, "  Model:    %s\n", cr.Model)
+		}
+		if tr := cr.Trace; tr != nil && tr.Fallbacks > 0 {
+			fmt.Fprintf(w, "  Fallback: %d calls served by a fallback (providers: %s)\n", tr.Fallbacks, strings.Join(tr.Providers, ", "))
+		}
 		fmt.Fprintf(w, "  Score:    %.2f\n", cr.Score)
 		fmt.Fprintf(w, "  Latency:  %s\n", FormatDuration(cr.Duration))
-		fmt.Fprintf(w, "  Tokens:   %d in / %d out\n", cr.InputTokens, cr.OutputTokens)
+		if cr.PricingUnknown && cr.Cost > 0 {
+			fmt.Fprintf(w, "  Tokens:   %d in / %d out (est. $%.4f at the fallback rate)\n", cr.InputTokens, cr.OutputTokens, cr.Cost)
+		} else if cr.PricingUnknown {
+			fmt.Fprintf(w, "  Tokens:   %d in / %d out (no pricing for model %q)\n", cr.InputTokens, cr.OutputTokens, cr.Model)
+		} else if cr.Cost > 0 {
+			fmt.Fprintf(w, "  Tokens:   %d in / %d out (est. $%.4f)\n", cr.InputTokens, cr.OutputTokens, cr.Cost)
+		} else {
+			fmt.Fprintf(w, "  Tokens:   %d in / %d out\n", cr.InputTokens, cr.OutputTokens)
+		}
 
 		if cr.Error != "" {
-			fmt.Fprintf(w, "  Error:    %s\n", cr.Error)
+			if cr.ErrorCategory != "" {
+				fmt.Fprintf(w, "  Error:    [%s] %s\n", cr.ErrorCategory, cr.Error)
+			} else {
+				fmt.Fprintf(w, "  Error:    %s\n", cr.Error)
+			}
+		}
+		if cr.FailureCategory == result.FailureDisallowedTool {
+			fmt.Fprintf(w, "  Failure:  [%s] called tools outside allowed_tools: %s\n", cr.FailureCategory, strings.Join(cr.DisallowedTools, ", "))
+		} else if cr.FailureCategory == result.FailureToolViolation {
+			fmt.Fprintf(w, "  Failure:  [%s] %s\n", cr.FailureCategory, strings.Join(cr.ToolViolations, "; "))
+		} else if cr.FailureCategory != "" {
+			fmt.Fprintf(w, "  Failure:  [%s] called unknown tools: %s\n", cr.FailureCategory, strings.Join(cr.HallucinatedTools, ", "))
+		} else if len(cr.HallucinatedTools) > 0 {
+			fmt.Fprintf(w, "  Tools:    called unknown tools: %s\n", strings.Join(cr.HallucinatedTools, ", "))
+		}
+		if cr.TimeoutIteration > 0 {
+			fmt.Fprintf(w, "  Timeout:  tool loop iteration %d\n", cr.TimeoutIteration)
+		}
+		if cr.Retries > 0 {
+			fmt.Fprintf(w, "  Retries:  %d (%s backing off)\n", cr.Retries, FormatDuration(cr.BackoffTime))
+		}
+		if cr.JudgeInputTokens > 0 || cr.JudgeOutputTokens > 0 {
+			fmt.Fprintf(w, "  Judging:  %d in / %d out (est. $%.4f)\n", cr.JudgeInputTokens, cr.JudgeOutputTokens, cr.JudgeCost)
 		}
 
 		if cr.FinalResponse != "" {
@@ -129,6 +345,57 @@ func PrintVerbose(w io.Writer, summary *result.RunSummary, color bool) {
 	}
 }
 
+// PrintPins writes, for each pinned case of the summary's suite, the
+// pinned exemplar's final response next to the response in this run, for
+// comparing outputs that scores alone do not capture.
+func PrintPins(w io.Writer, summary *result.RunSummary, pins []result.Pin, color bool) {
+	pins = result.PinsFor(pins, summary.SuiteName)
+	if len(pins) == 0 {
+		return
+	}
+	label := StatusLabelPlain
+	if color {
+		label = StatusLabel
+	}
+
+	fmt.Fprintf(w, "\n--- Pinned Cases ---\n\n")
+	for _, p := range pins {
+		fmt.Fprintf(w, "Case: %s\n", p.CaseName)
+		fmt.Fprintf(w, "  Pinned:   run %s on %s\n", p.RunID, p.PinnedAt.Format("2006-01-02"))
+		if p.Note != "" {
+			fmt.Fprintf(w, "  Note:     %s\n", p.Note)
+		}
+		printPinnedResponse(w, "Exemplar", p.Case, label)
+		latest, ok := findCase(summary, p.CaseName)
+		if !ok {
+			fmt.Fprintf(w, "  Latest:   not in this run\n\n")
+			continue
+		}
+		printPinnedResponse(w, "Latest", latest, label)
+		fmt.Fprintln(w)
+	}
+}
+
+func printPinnedResponse(w io.Writer, name string, cr result.CaseResult, label func(result.CaseResult) string) {
+	fmt.Fprintf(w, "  %-9s [%s] score %.2f\n", name+":", label(cr), cr.Score)
+	response := cr.FinalResponse
+	if response == "" && cr.Error != "" {
+		response = "error: " + cr.Error
+	}
+	for _, line := range strings.Split(response, "\n") {
+		fmt.Fprintf(w, "    %s\n", line)
+	}
+}
+
+func findCase(summary *result.RunSummary, name string) (result.CaseResult, bool) {
+	for _, cr := range summary.Results {
+		if cr.CaseName == name {
+			return cr, true
+		}
+	}
+	return result.CaseResult{}, false
+}
+
 func truncate(s string, max int) string {
 	if len(s) <= max {
 		return s
@@ -43,6 +43,10 @@ func TestStatusLabel(t *testing.T) {
 		{"pass", result.CaseResult{Pass: true}, "PASS"},
 		{"fail", result.CaseResult{Pass: false}, "FAIL"},
 		{"error", result.CaseResult{Error: "err"}, "ERROR"},
+		{"timeout", result.CaseResult{Error: "timeout", Status: "timeout"}, "TIMEOUT"},
+		{"unjudged", result.CaseResult{Status: "unjudged"}, "PENDING"},
+		{"skipped", result.CaseResult{Error: "skipped", Status: "skipped"}, "SKIP"},
+		{"blocked", result.CaseResult{Error: "skipped", Status: "blocked"}, "BLOCKED"},
 	}
 
 	for _, tt := range tests {
@@ -102,6 +106,77 @@ func TestPrintSummaryTable_Plain(t *testing.T) {
 	}
 }
 
+func TestPrintSummaryTable_PassRateInterval(t *testing.T) {
+	var buf bytes.Buffer
+	PrintSummaryTable(&buf, sampleSummary(), false)
+	for _, want := range []string{
+		"pass rate 50.0% (95% CI 9.5%-90.5%, n=2)",
+		"warning: only 2 judged cases; pass rate differences under 41 points may be noise",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("output missing %q:\n%s", want, buf.String())
+		}
+	}
+
+	large := sampleSummary()
+	large.Stats.PassedCases, large.Stats.FailedCases = 40, 40
+	buf.Reset()
+	PrintSummaryTable(&buf, large, false)
+	if strings.Contains(buf.String(), "warning: only") {
+		t.Errorf("80 judged cases should not warn:\n%s", buf.String())
+	}
+}
+
+func TestPrintSummaryTable_Combined(t *testing.T) {
+	other := sampleSummary()
+	other.SuiteName = "other-suite"
+	other.Results = other.Results[:1]
+	combined := result.Combine([]*result.RunSummary{sampleSummary(), other})
+
+	var buf bytes.Buffer
+	PrintSummaryTable(&buf, combined, false)
+	out := buf.String()
+
+	for _, want := range []string{
+		"  [test-suite]\n",
+		"  1 passed  1 failed  1 errored  | avg 0.43\n",
+		"  [other-suite]\n",
+		"  1 passed  0 failed  0 errored  | avg 1.00\n",
+		"TOTAL (2 suites)",
+		"  2 passed  1 failed  1 errored  | avg 0.57",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Index(out, "[other-suite]") > strings.Index(out, "TOTAL") {
+		t.Errorf("suite sections should come before the roll-up:\n%s", out)
+	}
+}
+
+func TestPrintSummaryTable_ErrorCategories(t *testing.T) {
+	s := sampleSummary()
+	s.Stats.ErrorsByCategory = map[string]int{"timeout": 1, "provider_error": 2}
+	var buf bytes.Buffer
+	PrintSummaryTable(&buf, s, false)
+
+	if want := "errors: 2 provider_error, 1 timeout"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
+func TestPrintSummaryTable_Overloaded(t *testing.T) {
+	s := sampleSummary()
+	s.Stats.OverloadedResponses = 5
+	s.Stats.OverloadedCases = 2
+	var buf bytes.Buffer
+	PrintSummaryTable(&buf, s, false)
+
+	if want := "provider overloaded (5 responses across 2 cases)"; !strings.Contains(buf.String(), want) {
+		t.Errorf("output missing %q:\n%s", want, buf.String())
+	}
+}
+
 func TestPrintSummaryTable_Colored(t *testing.T) {
 	var buf bytes.Buffer
 	PrintSummaryTable(&buf, sampleSummary(), true)
@@ -120,6 +195,7 @@ func TestPrintVerbose(t *testing.T) {
 	summary.Results[0].FinalResponse = "The answer is 42."
 	summary.Results[0].Prompt = "default"
 	summary.Results[0].Model = "test-model"
+	summary.Results[0].Source = "suites/basic.yaml:12"
 
 	var buf bytes.Buffer
 	PrintVerbose(&buf, summary, false)
@@ -128,6 +204,7 @@ func TestPrintVerbose(t *testing.T) {
 	for _, want := range []string{
 		"Detailed Results",
 		"Case: pass-case",
+		"Source:   suites/basic.yaml:12",
 		"Response:",
 		"The answer is 42.",
 		"Case: error-case",
@@ -159,3 +236,84 @@ func TestTruncate(t *testing.T) {
 		})
 	}
 }
+
+func TestPrintOverview(t *testing.T) {
+	second := sampleSummary()
+	second.SuiteName = "other-suite"
+	second.Results = second.Results[:1]
+	second.Stats = result.ComputeStats(second.Results)
+
+	var buf bytes.Buffer
+	PrintOverview(&buf, []*result.RunSummary{sampleSummary(), second})
+	out := buf.String()
+
+	for _, want := range []string{"SUITE", "test-suite", "other-suite", "TOTAL"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("overview missing %q:\n%s", want, out)
+		}
+	}
+	// The total row aggregates all four cases across both suites.
+	for _, line := range strings.Split(out, "\n") {
+		if strings.Contains(line, "TOTAL") && !strings.Contains(line, " 4 ") {
+			t.Errorf("TOTAL row should count 4 cases: %q", line)
+		}
+	}
+}
+
+func TestPrintHistory(t *testing.T) {
+	tagged := sampleSummary()
+	tagged.Tags = []string{"exp", "v2"}
+	tagged.Labels = map[string]string{"prompt": "short", "model": "m2"}
+	tagged.Note = "trimmed system prompt"
+
+	var buf bytes.Buffer
+	PrintHistory(&buf, []*result.RunSummary{tagged, sampleSummary()})
+	out := buf.String()
+
+	for _, want := range []string{
+		"RUN ID", "exp,v2",
+		"labels: model=m2 prompt=short",
+		"note:   trimmed system prompt",
+		"2 runs",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("history missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestPrintPins(t *testing.T) {
+	summary := sampleSummary()
+	summary.Results[1].FinalResponse = "Sure.\nDone."
+	pins := []result.Pin{
+		{SuiteName: "test-suite", CaseName: "fail-case", RunID: "run-0", Note: "ideal tone",
+			Case: result.CaseResult{CaseName: "fail-case", Pass: true, Score: 0.9, FinalResponse: "Happy to help!"}},
+		{SuiteName: "test-suite", CaseName: "gone-case", RunID: "run-0"},
+		{SuiteName: "other-suite", CaseName: "pass-case", RunID: "run-0"},
+	}
+
+	var buf bytes.Buffer
+	PrintPins(&buf, summary, pins, false)
+	out := buf.String()
+
+	for _, want := range []string{
+		"--- Pinned Cases ---",
+		"Case: fail-case", "Note:     ideal tone",
+		"Exemplar: [PASS] score 0.90", "    Happy to help!",
+		"Latest:   [FAIL] score 0.30", "    Sure.\n    Done.",
+		"Case: gone-case", "not in this run",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("pins output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "pass-case") {
+		t.Errorf("pins of other suites should not be shown:\n%s", out)
+	}
+
+	buf.Reset()
+	PrintPins(&buf, summary, nil, false)
+	if buf.Len() != 0 {
+		t.Errorf("no pins should print nothing, got:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,103 @@
+package result
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// CombinedSuiteName is the SuiteName of a summary built by Combine.
+const CombinedSuiteName = "combined"
+
+// Combine merges the summaries of suites run in one invocation into a
+// single summary whose stats cover every case. Suite boundaries are kept:
+// Suites lists the suites in order and each case result names its suite,
+// so Split and SuiteRun can recover the per-suite summaries. Tags, note,
+// and labels are taken from the first run, since a single invocation
+// applies the same ones to every suite; the runs' manifests are merged.
+func Combine(runs []*RunSummary) *RunSummary {
+	out := &RunSummary{SuiteName: CombinedSuiteName}
+	for i, r := range runs {
+		if i == 0 {
+			out.StartTime = r.StartTime
+			out.Tags, out.Note, out.Labels = r.Tags, r.Note, r.Labels
+			out.Deterministic = r.Deterministic
+		}
+		if r.StartTime.Before(out.StartTime) {
+			out.StartTime = r.StartTime
+		}
+		if r.EndTime.After(out.EndTime) {
+			out.EndTime = r.EndTime
+		}
+		out.Suites = append(out.Suites, r.SuiteName)
+		if r.Manifest != nil {
+			out.Manifest = out.Manifest.Merge(r.Manifest)
+		}
+		for _, cr := range r.Results {
+			cr.Suite = r.SuiteName
+			out.Results = append(out.Results, cr)
+		}
+	}
+	if !out.EndTime.IsZero() {
+		out.Duration = out.EndTime.Sub(out.StartTime)
+	}
+	out.RunID = fmt.Sprintf("%s-%s", out.StartTime.Format("20060102-150405"), CombinedSuiteName)
+	if out.Deterministic {
+		out.RunID = CombinedSuiteName
+	}
+	out.Stats = ComputeStats(out.Results)
+	return out
+}
+
+// IsCombined reports whether s combines the runs of several suites.
+func (s *RunSummary) IsCombined() bool {
+	return len(s.Suites) > 0
+}
+
+// groups reports whether run is one of the suite runs the combined
+// summary s was built from: a run of one of its suites that started
+// within it.
+func (s *RunSummary) groups(run *RunSummary) bool {
+	return slices.Contains(s.Suites, run.SuiteName) &&
+		!run.StartTime.Before(s.StartTime) && !run.StartTime.After(s.EndTime)
+}
+
+// Split returns one summary per suite of a combined summary, in order, with
+// stats computed over that suite's cases, as if each suite's run had been
+// loaded on its own. A summary of a single suite is returned as is.
+func (s *RunSummary) Split() []*RunSummary {
+	if !s.IsCombined() {
+		return []*RunSummary{s}
+	}
+	out := make([]*RunSummary, 0, len(s.Suites))
+	for _, name := range s.Suites {
+		part := *s
+		part.RunID = s.RunID + "/" + name
+		part.SuiteName = name
+		part.Suites = nil
+		part.Results = nil
+		for _, cr := range s.Results {
+			if cr.Suite == name {
+				cr.Suite = ""
+				part.Results = append(part.Results, cr)
+			}
+		}
+		part.Stats = ComputeStats(part.Results)
+		out = append(out, &part)
+	}
+	return out
+}
+
+// SuiteRun returns the part of s covering the named suite: the matching
+// summary from Split, or s itself when it is a run of that suite.
+func (s *RunSummary) SuiteRun(name string) (*RunSummary, error) {
+	for _, part := range s.Split() {
+		if part.SuiteName == name {
+			return part, nil
+		}
+	}
+	if s.IsCombined() {
+		return nil, fmt.Errorf("run %s has no suite %q (suites: %s)", s.RunID, name, strings.Join(s.Suites, ", "))
+	}
+	return nil, fmt.Errorf("run %s is of suite %q, not %q", s.RunID, s.SuiteName, name)
+}
@@ -0,0 +1,63 @@
+package result
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCombineAndSplit(t *testing.T) {
+	start := time.Date(2025, 6, 15, 10, 0, 0, 0, time.UTC)
+	a := &RunSummary{
+		RunID: "run-a", SuiteName: "alpha", StartTime: start, EndTime: start.Add(3 * time.Second),
+		Tags:    []string{"exp"},
+		Results: []CaseResult{{CaseName: "shared", Pass: true, Score: 1}, {CaseName: "only-a", Score: 0.2}},
+	}
+	b := &RunSummary{
+		RunID: "run-b", SuiteName: "beta", StartTime: start.Add(time.Second), EndTime: start.Add(5 * time.Second),
+		Results: []CaseResult{{CaseName: "shared", Pass: true, Score: 0.9}},
+	}
+
+	combined := Combine([]*RunSummary{a, b})
+	if combined.SuiteName != CombinedSuiteName || !combined.IsCombined() || combined.Duration != 5*time.Second {
+		t.Errorf("Combine() = %s/%v over %s", combined.SuiteName, combined.Suites, combined.Duration)
+	}
+	if combined.Stats.TotalCases != 3 || combined.Stats.PassedCases != 2 {
+		t.Errorf("combined stats = %+v, want 3 cases with 2 passed", combined.Stats)
+	}
+	if combined.Results[2].Suite != "beta" || a.Results[0].Suite != "" {
+		t.Error("Combine() should tag the combined results with their suite without changing the inputs")
+	}
+	if len(combined.Tags) != 1 {
+		t.Errorf("Tags = %v, want the first run's", combined.Tags)
+	}
+
+	// Suite boundaries survive a round trip through the saved file.
+	path := filepath.Join(t.TempDir(), "combined.json")
+	if err := combined.Save(path); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	loaded, err := LoadSummary(path)
+	if err != nil {
+		t.Fatalf("LoadSummary() error: %v", err)
+	}
+
+	parts := loaded.Split()
+	if len(parts) != 2 || parts[0].SuiteName != "alpha" || len(parts[0].Results) != 2 || parts[1].Stats.TotalCases != 1 {
+		t.Fatalf("Split() = %d parts", len(parts))
+	}
+	if parts[0].Results[0].Suite != "" {
+		t.Error("split results should look like a single suite's run")
+	}
+
+	beta, err := loaded.SuiteRun("beta")
+	if err != nil || beta.Results[0].Score != 0.9 {
+		t.Errorf("SuiteRun(beta) = %v, %v", beta, err)
+	}
+	if _, err := loaded.SuiteRun("gamma"); err == nil {
+		t.Error("SuiteRun() of a missing suite should fail")
+	}
+	if got, err := a.SuiteRun("alpha"); err != nil || got != a {
+		t.Errorf("SuiteRun() of a single-suite run = %v, %v; want the run itself", got, err)
+	}
+}
@@ -0,0 +1,108 @@
+package result
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+// Conversation is one line of a conversations export: a case transcript in
+// OpenAI chat format, usable directly as fine-tuning data.
+type Conversation struct {
+	Messages []trace.ChatMessage `json:"messages"`
+
+	// Metadata identifies the run and case the transcript came from. It is
+	// only written when requested, since fine-tuning APIs reject unknown
+	// fields.
+	Metadata *ConversationMetadata `json:"metadata,omitempty"`
+}
+
+// ConversationMetadata identifies the source of an exported transcript.
+type ConversationMetadata struct {
+	RunID    string  `json:"run_id"`
+	CaseID   string  `json:"case_id,omitempty"`
+	CaseName string  `json:"case_name"`
+	Model    string  `json:"model,omitempty"`
+	Status   string  `json:"status,omitempty"`
+	Score    float64 `json:"score"`
+}
+
+// WriteConversations writes one JSON line per case transcript. Only cases
+// whose status is in statuses are written, or all cases when statuses is
+// empty; cases without a trace are skipped. It returns the number of
+// conversations written.
+func (s *RunSummary) WriteConversations(w io.Writer, statuses []string, withMetadata bool) (int, error) {
+	want := make(map[string]bool, len(statuses))
+	for _, st := range statuses {
+		want[st] = true
+	}
+
+	enc := json.NewEncoder(w)
+	var n int
+	for _, cr := range s.Results {
+		if cr.Trace == nil || (len(want) > 0 && !want[cr.Status]) {
+			continue
+		}
+		conv := Conversation{Messages: cr.Trace.Conversation()}
+		if withMetadata {
+			conv.Metadata = &ConversationMetadata{
+				RunID:    s.RunID,
+				CaseID:   cr.CaseID,
+				CaseName: cr.CaseName,
+				Model:    cr.Model,
+				Status:   cr.Status,
+				Score:    cr.Score,
+			}
+		}
+		if err := enc.Encode(conv); err != nil {
+			return n, fmt.Errorf("writing conversation for case %q: %w", cr.CaseName, err)
+		}
+		n++
+	}
+	return n, nil
+}
+
+// WriteFinetune writes the transcripts of cases whose status is in
+// statuses, as WriteConversations does, prepared for use as fine-tuning
+// data: personal information is replaced by placeholders (see RedactPII),
+// and transcripts identical to one already written are dropped. It returns
+// the number of conversations written and of duplicates dropped.
+func (s *RunSummary) WriteFinetune(w io.Writer, statuses []string) (written, duplicates int, err error) {
+	want := make(map[string]bool, len(statuses))
+	for _, st := range statuses {
+		want[st] = true
+	}
+
+	seen := make(map[[sha256.Size]byte]bool)
+	for _, cr := range s.Results {
+		if cr.Trace == nil || (len(want) > 0 && !want[cr.Status]) {
+			continue
+		}
+		messages := cr.Trace.Conversation()
+		for i := range messages {
+			m := &messages[i]
+			m.Content = RedactPII(m.Content)
+			for j := range m.ToolCalls {
+				m.ToolCalls[j].Function.Arguments = RedactPII(m.ToolCalls[j].Function.Arguments)
+			}
+		}
+		line, err := json.Marshal(Conversation{Messages: messages})
+		if err != nil {
+			return written, duplicates, fmt.Errorf("encoding conversation for case %q: %w", cr.CaseName, err)
+		}
+		key := sha256.Sum256(line)
+		if seen[key] {
+			duplicates++
+			continue
+		}
+		seen[key] = true
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return written, duplicates, fmt.Errorf("writing conversation for case %q: %w", cr.CaseName, err)
+		}
+		written++
+	}
+	return written, duplicates, nil
+}
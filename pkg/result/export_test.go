@@ -0,0 +1,104 @@
+package result
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
+)
+
+func TestWriteConversations(t *testing.T) {
+	transcript := func(answer string) *trace.AgentTrace {
+		tr := trace.New()
+		tr.AddMessage("user", "question")
+		tr.AddMessage("assistant", answer)
+		return tr
+	}
+	s := &RunSummary{
+		RunID: "run-1",
+		Results: []CaseResult{
+			{CaseName: "good", Status: "pass", Score: 1, Trace: transcript("right")},
+			{CaseName: "bad", Status: "fail", Score: 0.2, Trace: transcript("wrong")},
+			{CaseName: "broken", Status: "error"},
+		},
+	}
+
+	var buf bytes.Buffer
+	n, err := s.WriteConversations(&buf, []string{"fail", "error"}, false)
+	if err != nil {
+		t.Fatalf("WriteConversations() error: %v", err)
+	}
+	if n != 1 {
+		t.Fatalf("wrote %d conversations, want 1 (errored case has no trace)", n)
+	}
+	if got, want := strings.TrimSpace(buf.String()), `{"messages":[{"role":"user","content":"question"},{"role":"assistant","content":"wrong"}]}`; got != want {
+		t.Errorf("line = %s\nwant   %s", got, want)
+	}
+
+	buf.Reset()
+	n, err = s.WriteConversations(&buf, nil, true)
+	if err != nil || n != 2 {
+		t.Fatalf("WriteConversations(all) = %d, %v; want 2, nil", n, err)
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	var conv Conversation
+	if err := json.Unmarshal([]byte(lines[0]), &conv); err != nil {
+		t.Fatal(err)
+	}
+	if conv.Metadata == nil || conv.Metadata.RunID != "run-1" || conv.Metadata.CaseName != "good" || conv.Metadata.Status != "pass" {
+		t.Errorf("Metadata = %+v", conv.Metadata)
+	}
+}
+
+func TestWriteFinetune(t *testing.T) {
+	transcript := func(user, answer string) *trace.AgentTrace {
+		tr := trace.New()
+		tr.AddMessage("user", user)
+		tr.AddMessage("assistant", answer)
+		return tr
+	}
+	s := &RunSummary{
+		Results: []CaseResult{
+			{CaseName: "a", Status: "pass", Trace: transcript("Email jane@example.com", "Done")},
+			{CaseName: "b", Status: "pass", Trace: transcript("Email bob@example.org", "Done")},
+			{CaseName: "c", Status: "review", Trace: transcript("Unreviewed", "Maybe")},
+			{CaseName: "d", Status: "pass", Trace: transcript("Call 555-123-4567", "Calling")},
+		},
+	}
+
+	var buf bytes.Buffer
+	n, dups, err := s.WriteFinetune(&buf, []string{"pass"})
+	if err != nil {
+		t.Fatalf("WriteFinetune() error: %v", err)
+	}
+	// Cases a and b are the same transcript once their addresses are
+	// redacted.
+	if n != 2 || dups != 1 {
+		t.Fatalf("WriteFinetune() = %d written, %d duplicates; want 2 and 1", n, dups)
+	}
+	want := `{"messages":[{"role":"user","content":"Email [EMAIL]"},{"role":"assistant","content":"Done"}]}` + "\n" +
+		`{"messages":[{"role":"user","content":"Call [PHONE]"},{"role":"assistant","content":"Calling"}]}` + "\n"
+	if buf.String() != want {
+		t.Errorf("output =\n%s\nwant\n%s", buf.String(), want)
+	}
+}
+
+func TestRedactPII(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"reach me at a.b+c@mail.example.co.uk", "reach me at [EMAIL]"},
+		{"SSN 123-45-6789 on file", "SSN [SSN] on file"},
+		{"card 4111 1111 1111 1111 expires", "card [CARD] expires"},
+		{"call (555) 123-4567 or +1 555.123.4567", "call [PHONE] or [PHONE]"},
+		{"from 192.168.0.1", "from [IP]"},
+		{"order 12345 shipped in 3 days", "order 12345 shipped in 3 days"},
+	}
+	for _, tt := range tests {
+		if got := RedactPII(tt.in); got != tt.want {
+			t.Errorf("RedactPII(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package result
+
+import (
+	"fmt"
+	"maps"
+	"sort"
+)
+
+// Manifest records what a run was produced from, so that it can be
+// re-executed with 'eval rerun' and the rerun can report what changed in
+// between. Hashes are fingerprints of the loaded definitions, so edits
+// that do not change meaning, such as reformatting, do not count.
+type Manifest struct {
+	// Args are the 'eval run' arguments of the invocation.
+	Args []string `json:"args"`
+
+	// FrameworkVersion identifies the build of the eval tool.
+	FrameworkVersion string `json:"framework_version,omitempty"`
+
+	// ConfigHash fingerprints the redacted config, SuiteHashes each suite
+	// by name, and PromptHashes each prompt by name.
+	ConfigHash   string            `json:"config_hash,omitempty"`
+	SuiteHashes  map[string]string `json:"suite_hashes,omitempty"`
+	PromptHashes map[string]string `json:"prompt_hashes,omitempty"`
+
+	// Provider and Model are the provider and default model the run used.
+	// Per-case model snapshots are in the case results' ModelVersions.
+	Provider string `json:"provider,omitempty"`
+	Model    string `json:"model,omitempty"`
+
+	// Seeds holds the random seeds the run used, such as "sample" for
+	// --sample and "spot_check" for spot-check sampling.
+	Seeds map[string]int64 `json:"seeds,omitempty"`
+
+	// MockFingerprints fingerprints the tool mocks each case ran with,
+	// keyed by "suite/case ID".
+	MockFingerprints map[string]string `json:"mock_fingerprints,omitempty"`
+}
+
+// Merge returns m with the suites, prompts, mocks, and seeds of other, a
+// manifest of another suite run in the same invocation, added. A nil m
+// yields a copy of other.
+func (m *Manifest) Merge(other *Manifest) *Manifest {
+	if m == nil {
+		m = &Manifest{
+			Args:             other.Args,
+			FrameworkVersion: other.FrameworkVersion,
+			ConfigHash:       other.ConfigHash,
+			Provider:         other.Provider,
+			Model:            other.Model,
+			SuiteHashes:      make(map[string]string),
+			PromptHashes:     make(map[string]string),
+			Seeds:            make(map[string]int64),
+			MockFingerprints: make(map[string]string),
+		}
+	}
+	maps.Copy(m.SuiteHashes, other.SuiteHashes)
+	maps.Copy(m.PromptHashes, other.PromptHashes)
+	maps.Copy(m.Seeds, other.Seeds)
+	maps.Copy(m.MockFingerprints, other.MockFingerprints)
+	return m
+}
+
+// Changes describes how the definitions recorded in m differ from those in
+// other: one line per config, suite, prompt, mock set, framework version,
+// or model that changed, was added, or was removed.
+func (m *Manifest) Changes(other *Manifest) []string {
+	var out []string
+	if m.FrameworkVersion != other.FrameworkVersion {
+		out = append(out, fmt.Sprintf("framework version: %s -> %s", m.FrameworkVersion, other.FrameworkVersion))
+	}
+	if m.ConfigHash != other.ConfigHash {
+		out = append(out, "config changed")
+	}
+	if m.Provider != other.Provider || m.Model != other.Model {
+		out = append(out, fmt.Sprintf("model: %s/%s -> %s/%s", m.Provider, m.Model, other.Provider, other.Model))
+	}
+	out = append(out, hashChanges("suite", m.SuiteHashes, other.SuiteHashes)...)
+	out = append(out, hashChanges("prompt", m.PromptHashes, other.PromptHashes)...)
+	out = append(out, hashChanges("mocks of", m.MockFingerprints, other.MockFingerprints)...)
+	return out
+}
+
+// hashChanges describes the differences between two sets of fingerprints.
+func hashChanges(kind string, before, after map[string]string) []string {
+	names := make(map[string]bool)
+	for name := range before {
+		names[name] = true
+	}
+	for name := range after {
+		names[name] = true
+	}
+	sorted := make([]string, 0, len(names))
+	for name := range names {
+		sorted = append(sorted, name)
+	}
+	sort.Strings(sorted)
+
+	var out []string
+	for _, name := range sorted {
+		b, inBefore := before[name]
+		a, inAfter := after[name]
+		switch {
+		case !inAfter:
+			out = append(out, fmt.Sprintf("%s %s removed", kind, name))
+		case !inBefore:
+			out = append(out, fmt.Sprintf("%s %s added", kind, name))
+		case a != b:
+			out = append(out, fmt.Sprintf("%s %s changed", kind, name))
+		}
+	}
+	return out
+}
@@ -0,0 +1,58 @@
+package result
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManifest_MergeAndChanges(t *testing.T) {
+	a := &Manifest{
+		Args:         []string{"--suite", "suites"},
+		ConfigHash:   "c1",
+		Provider:     "openai",
+		Model:        "gpt-4o",
+		SuiteHashes:  map[string]string{"smoke": "s1"},
+		PromptHashes: map[string]string{"p": "p1"},
+		Seeds:        map[string]int64{"sample": 3},
+	}
+	b := &Manifest{
+		ConfigHash:       "c1",
+		SuiteHashes:      map[string]string{"deep": "d1"},
+		PromptHashes:     map[string]string{"q": "q1"},
+		MockFingerprints: map[string]string{"deep/x": "m1"},
+	}
+
+	var merged *Manifest
+	merged = merged.Merge(a).Merge(b)
+	if merged.Provider != "openai" || merged.ConfigHash != "c1" {
+		t.Errorf("merged = %+v, want the first manifest's run fields", merged)
+	}
+	if len(merged.SuiteHashes) != 2 || len(merged.PromptHashes) != 2 || merged.MockFingerprints["deep/x"] != "m1" {
+		t.Errorf("merged = %+v, want both manifests' definitions", merged)
+	}
+	if _, ok := a.SuiteHashes["deep"]; ok {
+		t.Error("Merge into a nil manifest modified its argument")
+	}
+
+	if got := merged.Changes(merged); len(got) != 0 {
+		t.Errorf("Changes(self) = %v, want none", got)
+	}
+
+	now := &Manifest{
+		ConfigHash:       "c2",
+		Provider:         "openai",
+		Model:            "gpt-4o",
+		SuiteHashes:      map[string]string{"smoke": "s2", "deep": "d1"},
+		PromptHashes:     map[string]string{"p": "p1", "r": "r1"},
+		MockFingerprints: map[string]string{"deep/x": "m1"},
+	}
+	want := []string{
+		"config changed",
+		"suite smoke changed",
+		"prompt q removed",
+		"prompt r added",
+	}
+	if got := merged.Changes(now); !reflect.DeepEqual(got, want) {
+		t.Errorf("Changes = %v, want %v", got, want)
+	}
+}
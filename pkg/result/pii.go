@@ -0,0 +1,28 @@
+package result
+
+import "regexp"
+
+// piiPatterns match common kinds of personal information, each with the
+// placeholder that replaces it. They are tried in order, so the more
+// specific number formats come before phone numbers.
+var piiPatterns = []struct {
+	re          *regexp.Regexp
+	placeholder string
+}{
+	{regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`), "[EMAIL]"},
+	{regexp.MustCompile(`\b\d{3}-\d{2}-\d{4}\b`), "[SSN]"},
+	{regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), "[CARD]"},
+	{regexp.MustCompile(`(?:\+\d{1,3}[ .-]?)?(?:\(\d{3}\)|\b\d{3})[ .-]?\d{3}[ .-]?\d{4}\b`), "[PHONE]"},
+	{regexp.MustCompile(`\b(?:\d{1,3}\.){3}\d{1,3}\b`), "[IP]"},
+}
+
+// RedactPII replaces email addresses, US social security numbers, payment
+// card numbers, phone numbers, and IPv4 addresses in s with placeholders
+// such as "[EMAIL]". It is a pattern match, not a guarantee: names and
+// street addresses, for instance, are left alone.
+func RedactPII(s string) string {
+	for _, p := range piiPatterns {
+		s = p.re.ReplaceAllString(s, p.placeholder)
+	}
+	return s
+}
@@ -0,0 +1,115 @@
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// PinsFile is the name of the file, in the results directory, that holds
+// pinned cases.
+const PinsFile = "pins.json"
+
+// Pin is an exemplar transcript of one case, kept so later runs of the case
+// can be read side by side with it. The case result, including its trace,
+// is copied into the pin, so pins outlive pruned result files.
+type Pin struct {
+	SuiteName string     `json:"suite_name"`
+	CaseName  string     `json:"case_name"`
+	RunID     string     `json:"run_id"`
+	PinnedAt  time.Time  `json:"pinned_at"`
+	Note      string     `json:"note,omitempty"`
+	Case      CaseResult `json:"case"`
+}
+
+// PinsPath returns the path of the pins file in the results directory dir.
+func PinsPath(dir string) string {
+	return filepath.Join(dir, PinsFile)
+}
+
+// LoadPins reads the pins stored at path. A missing file holds no pins.
+func LoadPins(path string) ([]Pin, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading pins file %s: %w", path, err)
+	}
+	var pins []Pin
+	if err := json.Unmarshal(data, &pins); err != nil {
+		return nil, fmt.Errorf("parsing pins file %s: %w", path, err)
+	}
+	return pins, nil
+}
+
+// SavePins writes pins to path, creating its directory if needed.
+func SavePins(path string, pins []Pin) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating directory for %s: %w", path, err)
+	}
+	data, err := json.MarshalIndent(pins, "", "  ")
+	if err != nil {
+		return fmt.Errorf("marshaling pins: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		return fmt.Errorf("writing pins to %s: %w", path, err)
+	}
+	return nil
+}
+
+// PinCase returns a pin of the case named name (or with that ID) in s.
+func (s *RunSummary) PinCase(name, note string, now time.Time) (Pin, error) {
+	for _, cr := range s.Results {
+		if cr.CaseName == name || (cr.CaseID != "" && cr.CaseID == name) {
+			return Pin{
+				SuiteName: s.SuiteName,
+				CaseName:  cr.CaseName,
+				RunID:     s.RunID,
+				PinnedAt:  now,
+				Note:      note,
+				Case:      cr,
+			}, nil
+		}
+	}
+	return Pin{}, fmt.Errorf("case %q not found in run %s", name, s.RunID)
+}
+
+// AddPin adds p to pins, replacing an existing pin of the same case from
+// the same run.
+func AddPin(pins []Pin, p Pin) []Pin {
+	for i, existing := range pins {
+		if existing.SuiteName == p.SuiteName && existing.CaseName == p.CaseName && existing.RunID == p.RunID {
+			pins[i] = p
+			return pins
+		}
+	}
+	return append(pins, p)
+}
+
+// RemovePins drops the pins of the named case in the suite and reports
+// how many were removed.
+func RemovePins(pins []Pin, suiteName, caseName string) ([]Pin, int) {
+	kept := pins[:0]
+	for _, p := range pins {
+		if p.SuiteName != suiteName || p.CaseName != caseName {
+			kept = append(kept, p)
+		}
+	}
+	return kept, len(pins) - len(kept)
+}
+
+// PinsFor returns the pins of the suite's cases, in the order they were
+// pinned.
+func PinsFor(pins []Pin, suiteName string) []Pin {
+	var out []Pin
+	for _, p := range pins {
+		if p.SuiteName == suiteName {
+			out = append(out, p)
+		}
+	}
+	return out
+}
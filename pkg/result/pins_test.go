@@ -0,0 +1,68 @@
+package result
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPins_RoundTripAndSurvivePrune(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	run := &RunSummary{RunID: "run-1", SuiteName: "s", Results: []CaseResult{
+		{CaseID: "c1", CaseName: "greet", FinalResponse: "Hello there!"},
+	}}
+
+	if pins, err := LoadPins(PinsPath(dir)); err != nil || pins != nil {
+		t.Fatalf("LoadPins() of missing file = %v, %v; want no pins", pins, err)
+	}
+
+	pin, err := run.PinCase("c1", "warm tone", now)
+	if err != nil {
+		t.Fatalf("PinCase() error: %v", err)
+	}
+	if pin.CaseName != "greet" || pin.RunID != "run-1" || pin.Case.FinalResponse != "Hello there!" {
+		t.Errorf("PinCase() = %+v", pin)
+	}
+	if _, err := run.PinCase("missing", "", now); err == nil {
+		t.Error("PinCase() of an unknown case should fail")
+	}
+
+	// Re-pinning the same run replaces the pin; another run adds one.
+	pins := AddPin(nil, pin)
+	pins = AddPin(pins, pin)
+	other := pin
+	other.RunID = "run-2"
+	pins = AddPin(pins, other)
+	if len(pins) != 2 {
+		t.Fatalf("AddPin() gave %d pins, want 2", len(pins))
+	}
+	if err := SavePins(PinsPath(dir), pins); err != nil {
+		t.Fatalf("SavePins() error: %v", err)
+	}
+
+	writeRun(t, dir, "old.json", now.AddDate(0, 0, -10), false)
+	if _, err := Prune(dir, RetentionPolicy{KeepLast: 1}, now, false); err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	runs, err := LoadDir(dir)
+	if err != nil || len(runs) != 1 {
+		t.Fatalf("LoadDir() = %d runs, %v; want the pins file skipped", len(runs), err)
+	}
+
+	loaded, err := LoadPins(filepath.Join(dir, PinsFile))
+	if err != nil {
+		t.Fatalf("LoadPins() error: %v", err)
+	}
+	if len(loaded) != 2 || loaded[0].Note != "warm tone" || !loaded[0].PinnedAt.Equal(now) {
+		t.Errorf("LoadPins() = %+v", loaded)
+	}
+	if got := PinsFor(loaded, "other-suite"); len(got) != 0 {
+		t.Errorf("PinsFor(other-suite) = %v, want none", got)
+	}
+
+	loaded, n := RemovePins(loaded, "s", "greet")
+	if n != 2 || len(loaded) != 0 {
+		t.Errorf("RemovePins() removed %d, left %d; want 2 and 0", n, len(loaded))
+	}
+}
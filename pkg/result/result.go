@@ -7,57 +7,218 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
 // RunSummary is the top-level structure persisted to JSON for each eval run.
 type RunSummary struct {
-	RunID     string    `json:"run_id"`
-	SuiteName string    `json:"suite_name"`
-	StartTime time.Time `json:"start_time"`
-	EndTime   time.Time `json:"end_time"`
+	RunID     string        `json:"run_id"`
+	SuiteName string        `json:"suite_name"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
 	Duration  time.Duration `json:"duration"`
 	Stats     Stats         `json:"stats"`
 	Results   []CaseResult  `json:"results"`
+
+	// Concurrency and RateLimit record the limits the suite ran under,
+	// after suite-level caps were applied.
+	Concurrency int     `json:"concurrency,omitempty"`
+	RateLimit   float64 `json:"rate_limit,omitempty"`
+
+	// Tags, Note, and Labels identify the experiment a run belongs to.
+	// They are supplied by the user at run time and never interpreted.
+	Tags   []string          `json:"tags,omitempty"`
+	Note   string            `json:"note,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// Deterministic is set when the summary was normalized for storage as
+	// a golden file; see Normalize.
+	Deterministic bool `json:"deterministic,omitempty"`
+
+	// Suites lists, in order, the suites of a summary that combines the
+	// runs of several suites; see Combine. Each case result then names its
+	// suite in CaseResult.Suite.
+	Suites []string `json:"suites,omitempty"`
+
+	// Manifest records what the run was produced from; see Manifest.
+	Manifest *Manifest `json:"manifest,omitempty"`
 }
 
 // Stats holds aggregate statistics for the run.
 type Stats struct {
-	TotalCases   int     `json:"total_cases"`
-	PassedCases  int     `json:"passed_cases"`
-	FailedCases  int     `json:"failed_cases"`
-	ErroredCases int     `json:"errored_cases"`
-	PassRate     float64 `json:"pass_rate"`
-	AvgScore     float64 `json:"avg_score"`
-	LatencyP50   time.Duration `json:"latency_p50"`
-	LatencyP95   time.Duration `json:"latency_p95"`
-	TotalInputTokens  int `json:"total_input_tokens"`
-	TotalOutputTokens int `json:"total_output_tokens"`
+	TotalCases        int           `json:"total_cases"`
+	PassedCases       int           `json:"passed_cases"`
+	FailedCases       int           `json:"failed_cases"`
+	ErroredCases      int           `json:"errored_cases"`
+	TimedOutCases     int           `json:"timed_out_cases"`
+	UnjudgedCases     int           `json:"unjudged_cases,omitempty"`
+	SkippedCases      int           `json:"skipped_cases,omitempty"`
+	BlockedCases      int           `json:"blocked_cases,omitempty"`
+	PassRate          float64       `json:"pass_rate"`
+	AvgScore          float64       `json:"avg_score"`
+	LatencyP50        time.Duration `json:"latency_p50"`
+	LatencyP95        time.Duration `json:"latency_p95"`
+	TotalInputTokens  int           `json:"total_input_tokens"`
+	TotalOutputTokens int           `json:"total_output_tokens"`
+
+	// PassRateLow and PassRateHigh bound the 95% Wilson confidence
+	// interval on PassRate; see PassRateInterval.
+	PassRateLow  float64 `json:"pass_rate_low,omitempty"`
+	PassRateHigh float64 `json:"pass_rate_high,omitempty"`
+
+	// Cost is the estimated USD cost of the agent's model calls, summed
+	// over cases at each case's own model's pricing.
+	Cost float64 `json:"cost,omitempty"`
+
+	// UnpricedCases counts cases whose model has no pricing, whose cost
+	// is at the fallback rate or missing from Cost.
+	UnpricedCases int `json:"unpriced_cases,omitempty"`
+
+	// Retry telemetry: total provider retries, the fraction of cases that
+	// needed at least one, and the total time spent backing off. High
+	// values point at a flaky API rather than a change in model quality.
+	TotalRetries int           `json:"total_retries,omitempty"`
+	RetryRate    float64       `json:"retry_rate,omitempty"`
+	BackoffTime  time.Duration `json:"backoff_time,omitempty"`
+
+	// OverloadedResponses counts the responses in which the provider
+	// reported it was overloaded, and OverloadedCases the cases that saw
+	// at least one.
+	OverloadedResponses int `json:"overloaded_responses,omitempty"`
+	OverloadedCases     int `json:"overloaded_cases,omitempty"`
+
+	// ReaskedCases counts cases whose agent was re-asked after an invalid
+	// final output.
+	ReaskedCases int `json:"reasked_cases,omitempty"`
+
+	// HallucinatedToolCases counts cases whose agent called a tool that
+	// was not in its tool list.
+	HallucinatedToolCases int `json:"hallucinated_tool_cases,omitempty"`
+
+	// ErrorsByCategory breaks errored and timed-out cases down by
+	// CaseResult.ErrorCategory.
+	ErrorsByCategory map[string]int `json:"errors_by_category,omitempty"`
+
+	// Judge model usage, kept apart from the agent's own token counts.
+	TotalJudgeInputTokens  int     `json:"total_judge_input_tokens,omitempty"`
+	TotalJudgeOutputTokens int     `json:"total_judge_output_tokens,omitempty"`
+	JudgeCost              float64 `json:"judge_cost,omitempty"`
+
+	// SpotChecks counts the judge-passed cases sampled for human audit,
+	// SpotChecksReviewed those a human has graded, and
+	// SpotChecksConfirmed those the human passed too; see JudgePrecision.
+	SpotChecks          int `json:"spot_checks,omitempty"`
+	SpotChecksReviewed  int `json:"spot_checks_reviewed,omitempty"`
+	SpotChecksConfirmed int `json:"spot_checks_confirmed,omitempty"`
+}
+
+// JudgePrecision returns the fraction of reviewed spot checks the human
+// agreed the case passed, an estimate of how often a judge pass is right.
+// ok is false when no spot check has been reviewed.
+func (s Stats) JudgePrecision() (precision float64, ok bool) {
+	if s.SpotChecksReviewed == 0 {
+		return 0, false
+	}
+	return float64(s.SpotChecksConfirmed) / float64(s.SpotChecksReviewed), true
 }
 
 // CaseResult is the per-case result stored in the JSON output.
 type CaseResult struct {
-	CaseID        string        `json:"case_id"`
-	CaseName      string        `json:"case_name"`
-	Prompt        string        `json:"prompt"`
-	Model         string        `json:"model"`
-	FinalResponse string        `json:"final_response"`
-	Status        string        `json:"status"` // "pass", "fail", "review", "error"
-	Score         float64       `json:"score"`
-	Pass          bool          `json:"pass"`
-	Error         string        `json:"error,omitempty"`
-	Duration      time.Duration `json:"duration"`
-	InputTokens   int           `json:"input_tokens"`
-	OutputTokens  int           `json:"output_tokens"`
-}
-
-// FromRunResult converts a runner.RunResult into a RunSummary, generating
-// a run ID and computing summary statistics. Scores and pass/fail are left
-// at zero values since judging is performed separately.
+	Suite            string        `json:"suite,omitempty"` // only in combined summaries
+	CaseID           string        `json:"case_id"`
+	CaseName         string        `json:"case_name"`
+	Source           string        `json:"source,omitempty"` // file:line of the case definition
+	Prompt           string        `json:"prompt"`
+	Model            string        `json:"model"`
+	ModelVersions    []string      `json:"model_versions,omitempty"` // as reported by the API
+	FinalResponse    string        `json:"final_response"`
+	Status           string        `json:"status"` // "pass", "fail", "review", "error", "timeout", "unjudged", "skipped", "blocked"
+	Score            float64       `json:"score"`
+	Pass             bool          `json:"pass"`
+	Error            string        `json:"error,omitempty"`
+	ErrorCategory    string        `json:"error_category,omitempty"`
+	Duration         time.Duration `json:"duration"`
+	InputTokens      int           `json:"input_tokens"`
+	OutputTokens     int           `json:"output_tokens"`
+	Cost             float64       `json:"cost,omitempty"` // estimated USD, from Model's pricing
+	TimeoutIteration int           `json:"timeout_iteration,omitempty"`
+	Retries          int           `json:"retries,omitempty"`
+	BackoffTime      time.Duration `json:"backoff_time,omitempty"`
+	Overloaded       int           `json:"overloaded,omitempty"`
+	Reasks           int           `json:"reasks,omitempty"` // corrective turns after invalid output
+
+	// BlockedBy names the failed dependency of a "blocked" case.
+	BlockedBy string `json:"blocked_by,omitempty"`
+
+	// PricingUnknown is set when Model has no pricing, so Cost is at the
+	// fallback rate or 0 rather than the model's own price.
+	PricingUnknown bool `json:"pricing_unknown,omitempty"`
+
+	// SystemFingerprints lists the backend configurations the API
+	// reported serving the case; see provider.Response.SystemFingerprint.
+	SystemFingerprints []string `json:"system_fingerprints,omitempty"`
+
+	// HallucinatedTools lists the tools the agent called that were not in
+	// its tool list. A failed case that called any has FailureCategory
+	// FailureHallucinatedTool.
+	HallucinatedTools []string `json:"hallucinated_tools,omitempty"`
+	FailureCategory   string   `json:"failure_category,omitempty"`
+
+	// DisallowedTools lists the tools the agent called outside the case's
+	// allowed_tools. A case with any fails with FailureCategory
+	// FailureDisallowedTool, whatever its judges score.
+	DisallowedTools []string `json:"disallowed_tools,omitempty"`
+
+	// ToolViolations lists the tool calls the runner's OnToolCall hook
+	// rejected, as "tool: reason". A case with any fails with
+	// FailureCategory FailureToolViolation, whatever its judges score.
+	ToolViolations []string `json:"tool_violations,omitempty"`
+
+	// SpotCheck marks a case the judges passed that was sampled for human
+	// audit. Its status is "review" until a human grades it, and Pass
+	// keeps the judges' verdict meanwhile.
+	SpotCheck bool `json:"spot_check,omitempty"`
+
+	// JudgeInputTokens, JudgeOutputTokens, and JudgeCost total the model
+	// usage of this case's judges; per-judge figures are in Judges.
+	JudgeInputTokens  int     `json:"judge_input_tokens,omitempty"`
+	JudgeOutputTokens int     `json:"judge_output_tokens,omitempty"`
+	JudgeCost         float64 `json:"judge_cost,omitempty"`
+
+	Judges []judge.JudgeScore `json:"judges,omitempty"`
+	Trace  *trace.AgentTrace  `json:"trace,omitempty"`
+}
+
+// Failure categories for CaseResult.FailureCategory.
+const (
+	// FailureHallucinatedTool marks a failed case whose agent called a
+	// tool that does not exist.
+	FailureHallucinatedTool = "hallucinated_tool"
+
+	// FailureDisallowedTool marks a case whose agent called a tool outside
+	// the case's allowed_tools.
+	FailureDisallowedTool = "disallowed_tool"
+
+	// FailureToolViolation marks a case with a tool call that the
+	// runner's OnToolCall hook rejected.
+	FailureToolViolation = "tool_violation"
+)
+
+// FromRunResult converts a runner.RunResult into a RunSummary, keeping its
+// run ID, or generating one if it has none, and computing summary
+// statistics. Scores and pass/fail are left at zero values since judging
+// is performed separately.
 func FromRunResult(rr *runner.RunResult) *RunSummary {
-	runID := fmt.Sprintf("%s-%s", rr.StartTime.Format("20060102-150405"), rr.SuiteName)
+	runID := rr.RunID
+	if runID == "" {
+		runID = runner.RunID(rr.StartTime, rr.SuiteName)
+	}
 
 	summary := &RunSummary{
 		RunID:     runID,
@@ -65,22 +226,56 @@ func FromRunResult(rr *runner.RunResult) *RunSummary {
 		StartTime: rr.StartTime,
 		EndTime:   rr.EndTime,
 		Duration:  rr.Duration,
+
+		Concurrency: rr.Concurrency,
+		RateLimit:   rr.RateLimit,
 	}
 
 	for _, cr := range rr.Cases {
 		caseResult := CaseResult{
 			CaseID:        cr.CaseID,
 			CaseName:      cr.CaseName,
+			Source:        cr.Source,
 			Prompt:        cr.Prompt,
 			Model:         cr.Model,
 			FinalResponse: cr.FinalResponse,
 			Error:         cr.Error,
+			ErrorCategory: string(cr.ErrorCategory),
 			Duration:      cr.Duration,
+			Trace:         cr.Trace,
+
+			DisallowedTools: cr.DisallowedTools,
+		}
+		if cr.Skipped {
+			caseResult.Status = "skipped"
+			if cr.BlockedBy != "" {
+				caseResult.Status = "blocked"
+				caseResult.BlockedBy = cr.BlockedBy
+			}
+		} else if cr.TimedOut {
+			caseResult.Status = "timeout"
+			caseResult.TimeoutIteration = cr.TimeoutIteration
+		} else if cr.Error != "" {
+			caseResult.Status = "error"
 		}
 		if cr.Trace != nil {
 			usage := cr.Trace.GetUsage()
 			caseResult.InputTokens = usage.InputTokens
 			caseResult.OutputTokens = usage.OutputTokens
+			caseResult.Cost = provider.EstimateCost(cr.Model, provider.Usage{
+				InputTokens:      usage.InputTokens,
+				OutputTokens:     usage.OutputTokens,
+				CacheReadTokens:  usage.CacheReadTokens,
+				CacheWriteTokens: usage.CacheWriteTokens,
+			})
+			caseResult.PricingUnknown = usage.TotalTokens > 0 && !provider.HasPricing(cr.Model)
+			caseResult.Retries, caseResult.BackoffTime = cr.Trace.GetRetries()
+			caseResult.Overloaded = cr.Trace.GetOverloaded()
+			caseResult.Reasks = len(cr.Trace.GetReasks())
+			caseResult.HallucinatedTools = cr.Trace.HallucinatedTools()
+			caseResult.ToolViolations = cr.Trace.ToolViolations()
+			caseResult.ModelVersions = cr.Trace.GetModelVersions()
+			caseResult.SystemFingerprints = cr.Trace.GetSystemFingerprints()
 		}
 		summary.Results = append(summary.Results, caseResult)
 	}
@@ -89,6 +284,90 @@ func FromRunResult(rr *runner.RunResult) *RunSummary {
 	return summary
 }
 
+// ApplyJudgement records a composite judge result on the case, overwriting
+// any previous score, pass flag, and status. A judge that errored marks the
+// case with the judge_error category, and a failed case that called a
+// hallucinated tool gets the hallucinated_tool failure category.
+func (cr *CaseResult) ApplyJudgement(res judge.CompositeResult) {
+	cr.Score = res.CompositeScore
+	cr.Pass = res.Pass
+	cr.Status = string(res.Status)
+	cr.Judges = res.Scores
+	if res.Status == judge.StatusError {
+		cr.ErrorCategory = string(runner.CategoryJudge)
+	} else if cr.ErrorCategory == string(runner.CategoryJudge) {
+		cr.ErrorCategory = ""
+	}
+	cr.FailureCategory = ""
+	cr.SpotCheck = false
+	switch {
+	case len(cr.DisallowedTools) > 0 && res.Status != judge.StatusError:
+		cr.Pass = false
+		cr.Status = string(judge.StatusFail)
+		cr.FailureCategory = FailureDisallowedTool
+	case len(cr.ToolViolations) > 0 && res.Status != judge.StatusError:
+		cr.Pass = false
+		cr.Status = string(judge.StatusFail)
+		cr.FailureCategory = FailureToolViolation
+	case res.Status == judge.StatusFail && len(cr.HallucinatedTools) > 0:
+		cr.FailureCategory = FailureHallucinatedTool
+	}
+
+	cr.JudgeInputTokens, cr.JudgeOutputTokens, cr.JudgeCost = 0, 0, 0
+	for _, js := range res.Scores {
+		cr.JudgeInputTokens += js.InputTokens
+		cr.JudgeOutputTokens += js.OutputTokens
+		cr.JudgeCost += js.Cost
+	}
+}
+
+// MarkUnjudged sets every case that completed without error to the
+// "unjudged" status, for runs that skip scoring so that a later rejudge can
+// score the saved outputs.
+func (s *RunSummary) MarkUnjudged() {
+	for i := range s.Results {
+		if s.Results[i].Error == "" {
+			s.Results[i].Status = "unjudged"
+		}
+	}
+	s.Stats = ComputeStats(s.Results)
+}
+
+// Normalize strips run-specific values from the summary so that two runs
+// producing the same outputs serialize identically. The run ID becomes the
+// suite name, and all timestamps, durations, latency statistics, and retry
+// telemetry are zeroed, including those inside traces, as is the
+// manifest's framework version. Provider fallbacks and system fingerprints
+// vary from run to run with the state of the APIs, so they are cleared
+// too; model versions are kept, so a golden diff shows a model update.
+func (s *RunSummary) Normalize() {
+	s.RunID = s.SuiteName
+	s.StartTime = time.Time{}
+	s.EndTime = time.Time{}
+	s.Duration = 0
+	s.Stats.LatencyP50 = 0
+	s.Stats.LatencyP95 = 0
+	s.Stats.TotalRetries = 0
+	s.Stats.RetryRate = 0
+	s.Stats.BackoffTime = 0
+	s.Stats.OverloadedResponses = 0
+	s.Stats.OverloadedCases = 0
+	if s.Manifest != nil {
+		s.Manifest.FrameworkVersion = ""
+	}
+	for i := range s.Results {
+		s.Results[i].Duration = 0
+		s.Results[i].Retries = 0
+		s.Results[i].BackoffTime = 0
+		s.Results[i].Overloaded = 0
+		s.Results[i].SystemFingerprints = nil
+		if s.Results[i].Trace != nil {
+			s.Results[i].Trace.Normalize()
+		}
+	}
+	s.Deterministic = true
+}
+
 // ComputeStats calculates aggregate statistics from a slice of CaseResults.
 func ComputeStats(results []CaseResult) Stats {
 	s := Stats{TotalCases: len(results)}
@@ -100,7 +379,20 @@ func ComputeStats(results []CaseResult) Stats {
 	var durations []time.Duration
 
 	for _, r := range results {
-		if r.Error != "" {
+		// Cases that never ran count toward neither scores nor latency.
+		if r.Status == "skipped" {
+			s.SkippedCases++
+			continue
+		}
+		if r.Status == "blocked" {
+			s.BlockedCases++
+			continue
+		}
+		if r.Status == "timeout" {
+			s.TimedOutCases++
+		} else if r.Status == "unjudged" {
+			s.UnjudgedCases++
+		} else if r.Error != "" || r.ErrorCategory == string(runner.CategoryJudge) {
 			s.ErroredCases++
 		} else if r.Pass {
 			s.PassedCases++
@@ -111,13 +403,56 @@ func ComputeStats(results []CaseResult) Stats {
 		durations = append(durations, r.Duration)
 		s.TotalInputTokens += r.InputTokens
 		s.TotalOutputTokens += r.OutputTokens
+		s.Cost += r.Cost
+		if r.PricingUnknown {
+			s.UnpricedCases++
+		}
+		if r.ErrorCategory != "" {
+			if s.ErrorsByCategory == nil {
+				s.ErrorsByCategory = make(map[string]int)
+			}
+			s.ErrorsByCategory[r.ErrorCategory]++
+		}
+		s.TotalRetries += r.Retries
+		s.BackoffTime += r.BackoffTime
+		s.TotalJudgeInputTokens += r.JudgeInputTokens
+		s.TotalJudgeOutputTokens += r.JudgeOutputTokens
+		s.JudgeCost += r.JudgeCost
+		if r.Retries > 0 {
+			s.RetryRate++
+		}
+		if r.Reasks > 0 {
+			s.ReaskedCases++
+		}
+		if len(r.HallucinatedTools) > 0 {
+			s.HallucinatedToolCases++
+		}
+		if r.Overloaded > 0 {
+			s.OverloadedResponses += r.Overloaded
+			s.OverloadedCases++
+		}
+		if r.SpotCheck {
+			s.SpotChecks++
+			if r.Status != "review" {
+				s.SpotChecksReviewed++
+				if r.Pass {
+					s.SpotChecksConfirmed++
+				}
+			}
+		}
+	}
+	ran := s.TotalCases - s.SkippedCases - s.BlockedCases
+	if ran == 0 {
+		return s
 	}
+	s.RetryRate /= float64(ran)
 
-	nonErrored := s.TotalCases - s.ErroredCases
+	nonErrored := ran - s.ErroredCases - s.TimedOutCases - s.UnjudgedCases
 	if nonErrored > 0 {
 		s.PassRate = float64(s.PassedCases) / float64(nonErrored)
+		s.PassRateLow, s.PassRateHigh = WilsonInterval(s.PassedCases, nonErrored)
 	}
-	s.AvgScore = totalScore / float64(s.TotalCases)
+	s.AvgScore = totalScore / float64(ran)
 
 	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
 	s.LatencyP50 = percentile(durations, 0.5)
@@ -126,6 +461,44 @@ func ComputeStats(results []CaseResult) Stats {
 	return s
 }
 
+// MinCasesForComparison is the number of judged cases below which a pass
+// rate's 95% confidence interval is wider than about ±17 points, too wide
+// for the rate to be compared with another run's. Reports warn about
+// smaller runs.
+const MinCasesForComparison = 30
+
+// wilsonZ is the normal quantile for a 95% confidence interval.
+const wilsonZ = 1.96
+
+// WilsonInterval returns the 95% Wilson score interval on the pass rate of
+// passed successes out of n. Unlike the normal approximation it stays
+// within [0, 1] and is usable for the small n and extreme rates common in
+// eval suites. It returns 0, 0 when n is 0.
+func WilsonInterval(passed, n int) (low, high float64) {
+	if n <= 0 {
+		return 0, 0
+	}
+	p := float64(passed) / float64(n)
+	nf := float64(n)
+	z2 := wilsonZ * wilsonZ
+	center := (p + z2/(2*nf)) / (1 + z2/nf)
+	half := wilsonZ / (1 + z2/nf) * math.Sqrt(p*(1-p)/nf+z2/(4*nf*nf))
+	return max(0, center-half), min(1, center+half)
+}
+
+// JudgedCases returns the number of cases the pass rate is computed over:
+// those that ran to a pass or fail verdict.
+func (s Stats) JudgedCases() int {
+	return s.PassedCases + s.FailedCases
+}
+
+// PassRateInterval returns the 95% Wilson confidence interval on the pass
+// rate, computed from the case counts so it is also available for results
+// saved before the interval was stored.
+func (s Stats) PassRateInterval() (low, high float64) {
+	return WilsonInterval(s.PassedCases, s.JudgedCases())
+}
+
 // percentile returns the value at the given percentile (0.0-1.0) from a
 // sorted slice of durations.
 func percentile(sorted []time.Duration, p float64) time.Duration {
@@ -168,6 +541,108 @@ func (s *RunSummary) Save(path string) error {
 	return nil
 }
 
+// ModelVersions maps each model the run's cases were nominally run on to
+// the sorted, distinct versions the API reported serving them. Models
+// whose API reported no version are omitted.
+func (s *RunSummary) ModelVersions() map[string][]string {
+	return s.distinctByModel(func(cr CaseResult) []string { return cr.ModelVersions })
+}
+
+// SystemFingerprints maps each model the run's cases were nominally run
+// on to the sorted, distinct system fingerprints the API reported for
+// them. Models whose API reported none are omitted.
+func (s *RunSummary) SystemFingerprints() map[string][]string {
+	return s.distinctByModel(func(cr CaseResult) []string { return cr.SystemFingerprints })
+}
+
+// distinctByModel maps each model of the run's cases to the sorted,
+// distinct values of field over its cases, omitting models with none.
+func (s *RunSummary) distinctByModel(field func(CaseResult) []string) map[string][]string {
+	seen := make(map[string]map[string]bool)
+	for _, cr := range s.Results {
+		for _, v := range field(cr) {
+			if seen[cr.Model] == nil {
+				seen[cr.Model] = make(map[string]bool)
+			}
+			seen[cr.Model][v] = true
+		}
+	}
+	versions := make(map[string][]string, len(seen))
+	for model, vs := range seen {
+		for v := range vs {
+			versions[model] = append(versions[model], v)
+		}
+		sort.Strings(versions[model])
+	}
+	return versions
+}
+
+// HasTags reports whether the run carries every one of the given tags.
+func (s *RunSummary) HasTags(tags []string) bool {
+	for _, want := range tags {
+		found := false
+		for _, t := range s.Tags {
+			if t == want {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+// HasLabels reports whether the run carries every given label with the
+// same value.
+func (s *RunSummary) HasLabels(labels map[string]string) bool {
+	for k, v := range labels {
+		if got, ok := s.Labels[k]; !ok || got != v {
+			return false
+		}
+	}
+	return true
+}
+
+// RunFile pairs a stored RunSummary with the path it was loaded from.
+type RunFile struct {
+	Path    string
+	Summary *RunSummary
+}
+
+// LoadDir loads every run summary stored as a top-level .json file in dir,
+// newest first. Files that are not run summaries are skipped.
+func LoadDir(dir string) ([]RunFile, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading results directory %s: %w", dir, err)
+	}
+
+	var runs []RunFile
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.EqualFold(filepath.Ext(entry.Name()), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, entry.Name())
+		s, err := LoadSummary(path)
+		if err != nil || s.RunID == "" {
+			continue
+		}
+		runs = append(runs, RunFile{Path: path, Summary: s})
+	}
+
+	// Newest first; ties broken by path for a stable order.
+	sort.Slice(runs, func(i, j int) bool {
+		ti, tj := runs[i].Summary.StartTime, runs[j].Summary.StartTime
+		if !ti.Equal(tj) {
+			return ti.After(tj)
+		}
+		return runs[i].Path < runs[j].Path
+	})
+	return runs, nil
+}
+
 // LoadSummary reads a RunSummary from a JSON file.
 func LoadSummary(path string) (*RunSummary, error) {
 	data, err := os.ReadFile(path)
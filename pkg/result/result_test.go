@@ -1,11 +1,13 @@
 package result
 
 import (
+	"math"
 	"os"
 	"path/filepath"
 	"testing"
 	"time"
 
+	"github.com/jdgilhuly/go_eval_agent/pkg/judge"
 	"github.com/jdgilhuly/go_eval_agent/pkg/runner"
 	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
@@ -13,6 +15,8 @@ import (
 func TestFromRunResult(t *testing.T) {
 	tr := trace.New()
 	tr.AddUsage(100, 50)
+	tr.AddModelVersion("test-model-2025-06-01")
+	tr.AddModelVersion("test-model-2025-06-01")
 	tr.Finish()
 
 	rr := &runner.RunResult{
@@ -55,6 +59,12 @@ func TestFromRunResult(t *testing.T) {
 	if cr.OutputTokens != 50 {
 		t.Errorf("OutputTokens = %d, want 50", cr.OutputTokens)
 	}
+	if len(cr.ModelVersions) != 1 || cr.ModelVersions[0] != "test-model-2025-06-01" {
+		t.Errorf("ModelVersions = %v, want [test-model-2025-06-01]", cr.ModelVersions)
+	}
+	if got := summary.ModelVersions()["test-model"]; len(got) != 1 {
+		t.Errorf("summary ModelVersions = %v, want one version for test-model", summary.ModelVersions())
+	}
 }
 
 func TestComputeStats(t *testing.T) {
@@ -103,6 +113,281 @@ func TestComputeStats(t *testing.T) {
 	if s.LatencyP50 != 250*time.Millisecond {
 		t.Errorf("LatencyP50 = %v, want 250ms", s.LatencyP50)
 	}
+
+	// Wilson 95% interval on 2/3 is [0.208, 0.939].
+	if math.Abs(s.PassRateLow-0.2077) > 0.001 || math.Abs(s.PassRateHigh-0.9385) > 0.001 {
+		t.Errorf("pass rate interval = [%f, %f], want [0.2077, 0.9385]", s.PassRateLow, s.PassRateHigh)
+	}
+}
+
+func TestWilsonInterval(t *testing.T) {
+	tests := []struct {
+		passed, n int
+		low, high float64
+	}{
+		{passed: 0, n: 0, low: 0, high: 0},
+		{passed: 10, n: 10, low: 0.7225, high: 1},
+		{passed: 0, n: 10, low: 0, high: 0.2775},
+		{passed: 50, n: 100, low: 0.4038, high: 0.5962},
+	}
+	for _, tt := range tests {
+		low, high := WilsonInterval(tt.passed, tt.n)
+		if math.Abs(low-tt.low) > 0.001 || math.Abs(high-tt.high) > 0.001 {
+			t.Errorf("WilsonInterval(%d, %d) = [%f, %f], want [%f, %f]", tt.passed, tt.n, low, high, tt.low, tt.high)
+		}
+	}
+
+	// Older results without a stored interval get one from their counts.
+	s := Stats{PassedCases: 50, FailedCases: 50, PassRate: 0.5}
+	if low, high := s.PassRateInterval(); math.Abs(low-0.4038) > 0.001 || math.Abs(high-0.5962) > 0.001 {
+		t.Errorf("PassRateInterval() = [%f, %f], want [0.4038, 0.5962]", low, high)
+	}
+}
+
+func TestComputeStats_TimeoutsCountedSeparately(t *testing.T) {
+	results := []CaseResult{
+		{CaseName: "c1", Pass: true, Score: 1.0},
+		{CaseName: "c2", Error: "connection refused", Status: "error"},
+		{CaseName: "c3", Error: "timeout after 1s during tool loop iteration 2", Status: "timeout", TimeoutIteration: 2},
+	}
+
+	s := ComputeStats(results)
+	if s.ErroredCases != 1 {
+		t.Errorf("ErroredCases = %d, want 1", s.ErroredCases)
+	}
+	if s.TimedOutCases != 1 {
+		t.Errorf("TimedOutCases = %d, want 1", s.TimedOutCases)
+	}
+	if s.PassRate != 1.0 {
+		t.Errorf("PassRate = %f, want 1.0", s.PassRate)
+	}
+}
+
+func TestComputeStats_RetryTelemetry(t *testing.T) {
+	results := []CaseResult{
+		{CaseName: "c1", Pass: true, Retries: 2, BackoffTime: 1500 * time.Millisecond},
+		{CaseName: "c2", Pass: true},
+		{CaseName: "c3", Pass: false, Retries: 1, BackoffTime: 500 * time.Millisecond},
+		{CaseName: "c4", Pass: true},
+	}
+
+	s := ComputeStats(results)
+	if s.TotalRetries != 3 {
+		t.Errorf("TotalRetries = %d, want 3", s.TotalRetries)
+	}
+	if s.RetryRate != 0.5 {
+		t.Errorf("RetryRate = %f, want 0.5", s.RetryRate)
+	}
+	if s.BackoffTime != 2*time.Second {
+		t.Errorf("BackoffTime = %s, want 2s", s.BackoffTime)
+	}
+}
+
+func TestApplyJudgement_JudgeUsage(t *testing.T) {
+	cr := CaseResult{CaseName: "c1", InputTokens: 50}
+	cr.ApplyJudgement(judge.CompositeResult{
+		Status: judge.StatusPass,
+		Pass:   true,
+		Scores: []judge.JudgeScore{
+			{JudgeName: "llm", InputTokens: 300, OutputTokens: 40, Cost: 0.002},
+			{JudgeName: "agent", InputTokens: 700, OutputTokens: 60, Cost: 0.005},
+			{JudgeName: "exact"},
+		},
+	})
+	if cr.JudgeInputTokens != 1000 || cr.JudgeOutputTokens != 100 {
+		t.Errorf("judge tokens = %d/%d, want 1000/100", cr.JudgeInputTokens, cr.JudgeOutputTokens)
+	}
+	if cr.InputTokens != 50 {
+		t.Errorf("agent InputTokens = %d, want unchanged 50", cr.InputTokens)
+	}
+
+	s := ComputeStats([]CaseResult{cr, {CaseName: "c2", JudgeInputTokens: 10, JudgeCost: 0.001}})
+	if s.TotalJudgeInputTokens != 1010 || s.TotalJudgeOutputTokens != 100 {
+		t.Errorf("stats judge tokens = %d/%d, want 1010/100", s.TotalJudgeInputTokens, s.TotalJudgeOutputTokens)
+	}
+	if s.JudgeCost < 0.00799 || s.JudgeCost > 0.00801 {
+		t.Errorf("JudgeCost = %v, want 0.008", s.JudgeCost)
+	}
+	if s.TotalInputTokens != 50 {
+		t.Errorf("TotalInputTokens = %d, want agent usage only (50)", s.TotalInputTokens)
+	}
+}
+
+func TestApplyJudgement_HallucinatedTool(t *testing.T) {
+	cr := CaseResult{CaseName: "c1", HallucinatedTools: []string{"web_search"}}
+	cr.ApplyJudgement(judge.CompositeResult{Status: judge.StatusFail})
+	if cr.FailureCategory != FailureHallucinatedTool {
+		t.Errorf("FailureCategory = %q, want %q", cr.FailureCategory, FailureHallucinatedTool)
+	}
+
+	cr.ApplyJudgement(judge.CompositeResult{Status: judge.StatusPass, Pass: true})
+	if cr.FailureCategory != "" {
+		t.Errorf("FailureCategory = %q after passing, want empty", cr.FailureCategory)
+	}
+
+	s := ComputeStats([]CaseResult{cr, {CaseName: "c2"}})
+	if s.HallucinatedToolCases != 1 {
+		t.Errorf("HallucinatedToolCases = %d, want 1", s.HallucinatedToolCases)
+	}
+}
+
+func TestApplyJudgement_DisallowedTool(t *testing.T) {
+	cr := CaseResult{CaseName: "c1", DisallowedTools: []string{"web_search"}, HallucinatedTools: []string{"web_search"}}
+	cr.ApplyJudgement(judge.CompositeResult{Status: judge.StatusPass, Pass: true, CompositeScore: 1})
+	if cr.Pass || cr.Status != "fail" || cr.FailureCategory != FailureDisallowedTool {
+		t.Errorf("Pass/Status/FailureCategory = %v/%s/%s, want false/fail/%s", cr.Pass, cr.Status, cr.FailureCategory, FailureDisallowedTool)
+	}
+	if cr.Score != 1 {
+		t.Errorf("Score = %v, want the judges' score 1", cr.Score)
+	}
+}
+
+func TestApplyJudgement_ToolViolation(t *testing.T) {
+	cr := CaseResult{CaseName: "c1", ToolViolations: []string{"write_file: does not compile"}}
+	cr.ApplyJudgement(judge.CompositeResult{Status: judge.StatusPass, Pass: true, CompositeScore: 1})
+	if cr.Pass || cr.Status != "fail" || cr.FailureCategory != FailureToolViolation {
+		t.Errorf("Pass/Status/FailureCategory = %v/%s/%s, want false/fail/%s", cr.Pass, cr.Status, cr.FailureCategory, FailureToolViolation)
+	}
+
+	// A judge error still takes precedence.
+	cr.ApplyJudgement(judge.CompositeResult{Status: judge.StatusError})
+	if cr.Status != "error" || cr.FailureCategory != "" {
+		t.Errorf("Status/FailureCategory = %s/%s after judge error, want error/empty", cr.Status, cr.FailureCategory)
+	}
+}
+
+func TestComputeStats_ErrorsByCategory(t *testing.T) {
+	results := []CaseResult{
+		{CaseName: "ok", Pass: true, Status: "pass"},
+		{CaseName: "p1", Error: "provider error: 500", ErrorCategory: "provider_error", Status: "error"},
+		{CaseName: "p2", Error: "provider error: 429", ErrorCategory: "provider_error", Status: "error"},
+		{CaseName: "t1", Error: "timeout", ErrorCategory: "timeout", Status: "timeout"},
+		{CaseName: "legacy", Error: "something", Status: "error"},
+	}
+	judged := CaseResult{CaseName: "j1", FinalResponse: "out"}
+	judged.ApplyJudgement(judge.CompositeResult{Status: judge.StatusError})
+	results = append(results, judged)
+
+	s := ComputeStats(results)
+	if s.ErroredCases != 4 || s.TimedOutCases != 1 {
+		t.Errorf("errored/timed out = %d/%d, want 4/1", s.ErroredCases, s.TimedOutCases)
+	}
+	want := map[string]int{"provider_error": 2, "timeout": 1, "judge_error": 1}
+	if len(s.ErrorsByCategory) != len(want) {
+		t.Errorf("ErrorsByCategory = %v, want %v", s.ErrorsByCategory, want)
+	}
+	for k, v := range want {
+		if s.ErrorsByCategory[k] != v {
+			t.Errorf("ErrorsByCategory[%s] = %d, want %d", k, s.ErrorsByCategory[k], v)
+		}
+	}
+
+	// A successful rejudge clears the judge error.
+	judged.ApplyJudgement(judge.CompositeResult{Status: judge.StatusPass, Pass: true})
+	if judged.ErrorCategory != "" {
+		t.Errorf("ErrorCategory after rejudge = %q, want empty", judged.ErrorCategory)
+	}
+}
+
+func TestMarkUnjudged(t *testing.T) {
+	summary := &RunSummary{
+		Results: []CaseResult{
+			{CaseName: "ok", FinalResponse: "done"},
+			{CaseName: "broken", Error: "provider error", Status: "error"},
+		},
+	}
+	summary.MarkUnjudged()
+
+	if summary.Results[0].Status != "unjudged" {
+		t.Errorf("Status = %q, want %q", summary.Results[0].Status, "unjudged")
+	}
+	if summary.Results[1].Status != "error" {
+		t.Errorf("errored case Status = %q, want %q", summary.Results[1].Status, "error")
+	}
+	if summary.Stats.UnjudgedCases != 1 || summary.Stats.FailedCases != 0 {
+		t.Errorf("Stats = %+v, want 1 unjudged and 0 failed", summary.Stats)
+	}
+	if summary.Stats.PassRate != 0 {
+		t.Errorf("PassRate = %f, want 0", summary.Stats.PassRate)
+	}
+}
+
+func TestFromRunResult_Timeout(t *testing.T) {
+	rr := &runner.RunResult{
+		SuiteName: "timeout-suite",
+		Cases: []runner.CaseResult{
+			{CaseName: "slow", Error: "timeout after 1s during tool loop iteration 3", TimedOut: true, TimeoutIteration: 3},
+		},
+	}
+
+	summary := FromRunResult(rr)
+	cr := summary.Results[0]
+	if cr.Status != "timeout" {
+		t.Errorf("Status = %q, want %q", cr.Status, "timeout")
+	}
+	if cr.TimeoutIteration != 3 {
+		t.Errorf("TimeoutIteration = %d, want 3", cr.TimeoutIteration)
+	}
+	if summary.Stats.TimedOutCases != 1 {
+		t.Errorf("Stats.TimedOutCases = %d, want 1", summary.Stats.TimedOutCases)
+	}
+}
+
+func TestFromRunResult_SkippedAndBlocked(t *testing.T) {
+	rr := &runner.RunResult{
+		SuiteName: "deps-suite",
+		Cases: []runner.CaseResult{
+			{CaseName: "setup", FinalResponse: "ok", Duration: time.Second},
+			{CaseName: "e2e", Error: `skipped: dependency "setup" did not pass`, Skipped: true, BlockedBy: "setup"},
+			{CaseName: "later", Error: "skipped: an earlier case failed (fail-fast)", Skipped: true},
+		},
+	}
+
+	summary := FromRunResult(rr)
+	if got := summary.Results[1]; got.Status != "blocked" || got.BlockedBy != "setup" {
+		t.Errorf("e2e: Status = %q, BlockedBy = %q, want blocked by setup", got.Status, got.BlockedBy)
+	}
+	if got := summary.Results[2].Status; got != "skipped" {
+		t.Errorf("later: Status = %q, want skipped", got)
+	}
+
+	summary.Results[0].ApplyJudgement(judge.CompositeResult{CompositeScore: 1, Pass: true, Status: judge.StatusPass})
+	st := ComputeStats(summary.Results)
+	if st.BlockedCases != 1 || st.SkippedCases != 1 || st.ErroredCases != 0 {
+		t.Errorf("Blocked/Skipped/Errored = %d/%d/%d, want 1/1/0", st.BlockedCases, st.SkippedCases, st.ErroredCases)
+	}
+	if st.PassRate != 1 || st.AvgScore != 1 {
+		t.Errorf("PassRate = %v, AvgScore = %v; cases that never ran should not count", st.PassRate, st.AvgScore)
+	}
+}
+
+func TestFromRunResult_CostUsesCaseModel(t *testing.T) {
+	usage := func() *trace.AgentTrace {
+		tr := trace.New()
+		tr.AddUsage(1_000_000, 0)
+		return tr
+	}
+	rr := &runner.RunResult{
+		SuiteName: "mixed",
+		Cases: []runner.CaseResult{
+			{CaseName: "big", Model: "gpt-4o", Trace: usage()},
+			{CaseName: "small", Model: "gpt-4o-mini", Trace: usage()},
+			{CaseName: "unpriced", Model: "in-house-model", Trace: usage()},
+		},
+	}
+
+	summary := FromRunResult(rr)
+	big, small := summary.Results[0].Cost, summary.Results[1].Cost
+	if big <= small || small <= 0 {
+		t.Errorf("Cost: gpt-4o = %v, gpt-4o-mini = %v; want each priced at its own model", big, small)
+	}
+	if got := summary.Stats.Cost; got != big+small {
+		t.Errorf("Stats.Cost = %v, want %v", got, big+small)
+	}
+	if summary.Results[0].PricingUnknown || !summary.Results[2].PricingUnknown || summary.Stats.UnpricedCases != 1 {
+		t.Errorf("PricingUnknown = %v, %v; UnpricedCases = %d; want only the in-house model flagged",
+			summary.Results[0].PricingUnknown, summary.Results[2].PricingUnknown, summary.Stats.UnpricedCases)
+	}
 }
 
 func TestComputeStats_Empty(t *testing.T) {
@@ -174,6 +459,55 @@ func TestSaveAndLoad(t *testing.T) {
 	}
 }
 
+func TestLoadDir(t *testing.T) {
+	dir := t.TempDir()
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, name := range []string{"old.json", "new.json"} {
+		s := &RunSummary{
+			RunID:     name,
+			StartTime: base.Add(time.Duration(i) * time.Hour),
+			Tags:      []string{"nightly"},
+		}
+		if err := s.Save(filepath.Join(dir, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.json"), []byte(`{"x":1}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	runs, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir() error: %v", err)
+	}
+	if len(runs) != 2 {
+		t.Fatalf("len(runs) = %d, want 2", len(runs))
+	}
+	if runs[0].Summary.RunID != "new.json" {
+		t.Errorf("runs[0] = %q, want newest first", runs[0].Summary.RunID)
+	}
+}
+
+func TestHasTagsAndLabels(t *testing.T) {
+	s := &RunSummary{
+		Tags:   []string{"exp", "v2"},
+		Labels: map[string]string{"model": "m2"},
+	}
+
+	if !s.HasTags(nil) || !s.HasTags([]string{"v2"}) || !s.HasTags([]string{"exp", "v2"}) {
+		t.Error("HasTags should match a subset of tags")
+	}
+	if s.HasTags([]string{"exp", "v3"}) {
+		t.Error("HasTags matched a missing tag")
+	}
+	if !s.HasLabels(map[string]string{"model": "m2"}) {
+		t.Error("HasLabels should match an equal label")
+	}
+	if s.HasLabels(map[string]string{"model": "m1"}) || s.HasLabels(map[string]string{"owner": "x"}) {
+		t.Error("HasLabels matched a differing or missing label")
+	}
+}
+
 func TestLoadSummary_NotFound(t *testing.T) {
 	_, err := LoadSummary("/nonexistent/result.json")
 	if err == nil {
@@ -193,3 +527,70 @@ func TestLoadSummary_InvalidJSON(t *testing.T) {
 		t.Fatal("LoadSummary() expected error for invalid JSON, got nil")
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	tr := trace.New()
+	tr.AddMessage("user", "hi")
+	tr.AddSystemFingerprint("fp_1")
+	tr.AddProvider("backup", 1)
+	tr.Finish()
+
+	rr := &runner.RunResult{
+		SuiteName: "golden",
+		StartTime: time.Now(),
+		EndTime:   time.Now().Add(time.Second),
+		Duration:  time.Second,
+		Cases: []runner.CaseResult{
+			{CaseName: "c1", FinalResponse: "ok", Trace: tr, Duration: 300 * time.Millisecond},
+		},
+	}
+
+	summary := FromRunResult(rr)
+	summary.Normalize()
+
+	if summary.RunID != "golden" {
+		t.Errorf("RunID = %q, want %q", summary.RunID, "golden")
+	}
+	if !summary.StartTime.IsZero() || !summary.EndTime.IsZero() || summary.Duration != 0 {
+		t.Error("run timestamps not zeroed")
+	}
+	if summary.Stats.LatencyP50 != 0 || summary.Stats.LatencyP95 != 0 {
+		t.Error("latency stats not zeroed")
+	}
+	if summary.Results[0].Duration != 0 {
+		t.Errorf("case Duration = %v, want 0", summary.Results[0].Duration)
+	}
+	if !summary.Results[0].Trace.StartTime.IsZero() {
+		t.Error("trace StartTime not zeroed")
+	}
+	if len(summary.Results[0].SystemFingerprints) != 0 {
+		t.Errorf("SystemFingerprints = %v, want them cleared", summary.Results[0].SystemFingerprints)
+	}
+	if !summary.Deterministic {
+		t.Error("Deterministic = false, want true")
+	}
+
+	// Two normalized summaries of the same outputs serialize identically.
+	other := FromRunResult(&runner.RunResult{
+		SuiteName: "golden",
+		StartTime: time.Now().Add(time.Hour),
+		Cases:     []runner.CaseResult{{CaseName: "c1", FinalResponse: "ok", Trace: trace.New(), Duration: time.Second}},
+	})
+	other.Results[0].Trace.AddMessage("user", "hi")
+	other.Normalize()
+
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a.json")
+	pathB := filepath.Join(dir, "b.json")
+	if err := summary.Save(pathA); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.Save(pathB); err != nil {
+		t.Fatal(err)
+	}
+	a, _ := os.ReadFile(pathA)
+	b, _ := os.ReadFile(pathB)
+	if string(a) != string(b) {
+		t.Errorf("normalized summaries differ:\n%s\n---\n%s", a, b)
+	}
+}
@@ -0,0 +1,113 @@
+package result
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// RetentionPolicy selects which result files Prune keeps. A file is kept if
+// it satisfies any rule. At least one of KeepLast or KeepDays must be set.
+type RetentionPolicy struct {
+	// KeepLast keeps the N most recent runs by start time.
+	KeepLast int
+	// KeepDays keeps runs that started within the last N days.
+	KeepDays int
+	// Pinned lists file names (relative to the results directory) that are
+	// always kept, such as baselines.
+	Pinned []string
+}
+
+// PruneReport lists the result files Prune kept and removed.
+type PruneReport struct {
+	Kept    []string `json:"kept"`
+	Removed []string `json:"removed"`
+
+	// Signatures lists the signatures of removed files, removed with them.
+	Signatures []string `json:"signatures,omitempty"`
+}
+
+// Prune removes result files in dir that fall outside the retention policy.
+// Only top-level .json files that parse as a RunSummary are considered;
+// pinned files and deterministic golden files are never removed. A
+// removed file's signature, if any, is removed with it. A combined
+// multi-suite summary does not count toward KeepLast; it is kept as long
+// as any of the suite runs it groups is. When
+// dryRun is true nothing is deleted, but the report describes what would
+// have been.
+func Prune(dir string, policy RetentionPolicy, now time.Time, dryRun bool) (*PruneReport, error) {
+	if policy.KeepLast <= 0 && policy.KeepDays <= 0 {
+		return nil, fmt.Errorf("retention policy must set keep_last or keep_days")
+	}
+
+	runs, err := LoadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	pinned := make(map[string]bool, len(policy.Pinned))
+	for _, p := range policy.Pinned {
+		pinned[filepath.Base(p)] = true
+	}
+
+	cutoff := now.AddDate(0, 0, -policy.KeepDays)
+	report := &PruneReport{}
+	prune := func(rf RunFile, keep bool) error {
+		if keep {
+			report.Kept = append(report.Kept, rf.Path)
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(rf.Path); err != nil {
+				return fmt.Errorf("removing %s: %w", rf.Path, err)
+			}
+		}
+		report.Removed = append(report.Removed, rf.Path)
+		sig := SignaturePath(rf.Path)
+		if _, err := os.Stat(sig); err != nil {
+			return nil
+		}
+		if !dryRun {
+			if err := os.Remove(sig); err != nil {
+				return fmt.Errorf("removing %s: %w", sig, err)
+			}
+		}
+		report.Signatures = append(report.Signatures, sig)
+		return nil
+	}
+
+	// Combined summaries are not runs of their own: only suite runs are
+	// ranked, and a combined summary goes with the runs it groups.
+	var combined []RunFile
+	var kept []*RunSummary
+	rank := 0
+	for _, rf := range runs {
+		if rf.Summary.IsCombined() {
+			combined = append(combined, rf)
+			continue
+		}
+		keep := rf.Summary.Deterministic ||
+			pinned[filepath.Base(rf.Path)] ||
+			(policy.KeepLast > 0 && rank < policy.KeepLast) ||
+			(policy.KeepDays > 0 && rf.Summary.StartTime.After(cutoff))
+		rank++
+		if keep {
+			kept = append(kept, rf.Summary)
+		}
+		if err := prune(rf, keep); err != nil {
+			return report, err
+		}
+	}
+	for _, rf := range combined {
+		keep := rf.Summary.Deterministic ||
+			pinned[filepath.Base(rf.Path)] ||
+			slices.ContainsFunc(kept, rf.Summary.groups)
+		if err := prune(rf, keep); err != nil {
+			return report, err
+		}
+	}
+
+	return report, nil
+}
@@ -0,0 +1,169 @@
+package result
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func writeRun(t *testing.T, dir, name string, start time.Time, deterministic bool) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	s := &RunSummary{RunID: name, SuiteName: "s", StartTime: start, Deterministic: deterministic}
+	if err := s.Save(path); err != nil {
+		t.Fatalf("Save() error: %v", err)
+	}
+	return path
+}
+
+func TestPrune_KeepLast(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	oldest := writeRun(t, dir, "1.json", now.AddDate(0, 0, -3), false)
+	middle := writeRun(t, dir, "2.json", now.AddDate(0, 0, -2), false)
+	newest := writeRun(t, dir, "3.json", now.AddDate(0, 0, -1), false)
+
+	report, err := Prune(dir, RetentionPolicy{KeepLast: 2}, now, false)
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0] != oldest {
+		t.Errorf("Removed = %v, want [%s]", report.Removed, oldest)
+	}
+	if _, err := os.Stat(oldest); !os.IsNotExist(err) {
+		t.Error("oldest run should have been deleted")
+	}
+	for _, p := range []string{middle, newest} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should have been kept: %v", p, err)
+		}
+	}
+}
+
+func TestPrune_KeepDaysPinnedAndGolden(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	recent := writeRun(t, dir, "recent.json", now.AddDate(0, 0, -5), false)
+	stale := writeRun(t, dir, "stale.json", now.AddDate(0, 0, -60), false)
+	baseline := writeRun(t, dir, "baseline.json", now.AddDate(0, 0, -90), false)
+	golden := writeRun(t, dir, "golden.json", time.Time{}, true)
+	// Non-result JSON files are ignored entirely.
+	other := filepath.Join(dir, "notes.json")
+	os.WriteFile(other, []byte(`{"hello": "world"}`), 0o644)
+
+	report, err := Prune(dir, RetentionPolicy{KeepDays: 30, Pinned: []string{"baseline.json"}}, now, false)
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if len(report.Removed) != 1 || report.Removed[0] != stale {
+		t.Errorf("Removed = %v, want [%s]", report.Removed, stale)
+	}
+	for _, p := range []string{recent, baseline, golden, other} {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should have been kept: %v", p, err)
+		}
+	}
+}
+
+func TestPrune_Signatures(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	old := writeRun(t, dir, "old.json", now.AddDate(0, 0, -10), false)
+	kept := writeRun(t, dir, "new.json", now.AddDate(0, 0, -1), false)
+	for _, p := range []string{old, kept} {
+		if _, err := SignFile(p, []byte("key"), "", now); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	report, err := Prune(dir, RetentionPolicy{KeepDays: 5}, now, true)
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if len(report.Signatures) != 1 || report.Signatures[0] != SignaturePath(old) {
+		t.Errorf("dry run Signatures = %v, want [%s]", report.Signatures, SignaturePath(old))
+	}
+	if _, err := os.Stat(SignaturePath(old)); err != nil {
+		t.Error("dry run should not delete signatures")
+	}
+
+	if _, err := Prune(dir, RetentionPolicy{KeepDays: 5}, now, false); err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if _, err := os.Stat(SignaturePath(old)); !os.IsNotExist(err) {
+		t.Error("a pruned run's signature should have been deleted")
+	}
+	if _, err := os.Stat(SignaturePath(kept)); err != nil {
+		t.Errorf("a kept run's signature should remain: %v", err)
+	}
+}
+
+func TestPrune_Combined(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC)
+	// invocation saves suite runs of qa and code and their combined
+	// summary, as 'eval run' does for several suites.
+	invocation := func(name string, start time.Time) []string {
+		var runs []*RunSummary
+		var paths []string
+		for i, suiteName := range []string{"qa", "code"} {
+			r := &RunSummary{RunID: name + "-" + suiteName, SuiteName: suiteName, StartTime: start, EndTime: start.Add(time.Duration(i+1) * time.Minute)}
+			path := filepath.Join(dir, name+"-"+suiteName+".json")
+			if err := r.Save(path); err != nil {
+				t.Fatal(err)
+			}
+			runs, paths = append(runs, r), append(paths, path)
+		}
+		path := filepath.Join(dir, name+"-combined.json")
+		if err := Combine(runs).Save(path); err != nil {
+			t.Fatal(err)
+		}
+		return append(paths, path)
+	}
+	old := invocation("old", now.AddDate(0, 0, -2))
+	recent := invocation("new", now.AddDate(0, 0, -1))
+
+	// The newest combined summary is not counted as one of the two runs
+	// kept, and the older one goes with its suite runs.
+	report, err := Prune(dir, RetentionPolicy{KeepLast: 2}, now, false)
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if len(report.Removed) != 3 {
+		t.Errorf("Removed = %v, want the old invocation's %v", report.Removed, old)
+	}
+	for _, p := range old {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s should have been deleted", p)
+		}
+	}
+	for _, p := range recent {
+		if _, err := os.Stat(p); err != nil {
+			t.Errorf("%s should have been kept: %v", p, err)
+		}
+	}
+}
+
+func TestPrune_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	old := writeRun(t, dir, "old.json", now.AddDate(0, 0, -100), false)
+
+	report, err := Prune(dir, RetentionPolicy{KeepDays: 1}, now, true)
+	if err != nil {
+		t.Fatalf("Prune() error: %v", err)
+	}
+	if len(report.Removed) != 1 {
+		t.Fatalf("Removed = %v, want 1 entry", report.Removed)
+	}
+	if _, err := os.Stat(old); err != nil {
+		t.Error("dry run should not delete files")
+	}
+}
+
+func TestPrune_RequiresPolicy(t *testing.T) {
+	if _, err := Prune(t.TempDir(), RetentionPolicy{}, time.Now(), false); err == nil {
+		t.Error("expected error for empty policy")
+	}
+}
@@ -0,0 +1,116 @@
+package result
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// SignatureAlgorithm is the algorithm of the signatures SignFile writes.
+const SignatureAlgorithm = "hmac-sha256"
+
+// Errors returned by VerifyFile.
+var (
+	ErrUnsigned          = errors.New("result file is not signed")
+	ErrSignatureMismatch = errors.New("result file does not match its signature")
+)
+
+// Signature is a detached signature of a result file, stored next to it
+// at SignaturePath. It proves that the file is byte-for-byte the one
+// signed by a holder of the key, so a baseline used for sign-off can be
+// shown to be unmodified.
+type Signature struct {
+	Algorithm string    `json:"algorithm"`
+	KeyID     string    `json:"key_id,omitempty"`
+	SignedAt  time.Time `json:"signed_at"`
+
+	// Digest is the SHA-256 of the file, and Signature the HMAC-SHA256
+	// under the key of the algorithm, KeyID, SignedAt, and the file, both
+	// hex-encoded. Editing any of them invalidates the signature.
+	Digest    string `json:"digest"`
+	Signature string `json:"signature"`
+}
+
+// SignaturePath returns the path of the signature of the result file at
+// path.
+func SignaturePath(path string) string { return path + ".sig" }
+
+// SignFile signs the result file at path with key and writes the
+// signature to SignaturePath(path), replacing any earlier one. keyID
+// names the key so verifiers know which one to use; it is not secret.
+func SignFile(path string, key []byte, keyID string, now time.Time) (*Signature, error) {
+	if len(key) == 0 {
+		return nil, errors.New("signing key is empty")
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading result file %s: %w", path, err)
+	}
+	digest := sha256.Sum256(data)
+	sig := &Signature{
+		Algorithm: SignatureAlgorithm,
+		KeyID:     keyID,
+		SignedAt:  now.UTC(),
+		Digest:    hex.EncodeToString(digest[:]),
+	}
+	sig.Signature = hex.EncodeToString(mac(key, sig, data))
+	out, err := json.MarshalIndent(sig, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("marshaling signature: %w", err)
+	}
+	if err := os.WriteFile(SignaturePath(path), out, 0o644); err != nil {
+		return nil, fmt.Errorf("writing signature: %w", err)
+	}
+	return sig, nil
+}
+
+// VerifyFile checks the result file at path against its signature under
+// key, returning the signature when they match. It returns an error
+// wrapping ErrUnsigned when there is no signature, and one wrapping
+// ErrSignatureMismatch when the file, its key ID, or its signing time was
+// altered, or it was signed with another key.
+func VerifyFile(path string, key []byte) (*Signature, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading result file %s: %w", path, err)
+	}
+	raw, err := os.ReadFile(SignaturePath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s not found", ErrUnsigned, SignaturePath(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading signature: %w", err)
+	}
+	var sig Signature
+	if err := json.Unmarshal(raw, &sig); err != nil {
+		return nil, fmt.Errorf("parsing signature %s: %w", SignaturePath(path), err)
+	}
+	if sig.Algorithm != SignatureAlgorithm {
+		return nil, fmt.Errorf("unsupported signature algorithm %q", sig.Algorithm)
+	}
+
+	digest := sha256.Sum256(data)
+	if hex.EncodeToString(digest[:]) != sig.Digest {
+		return &sig, fmt.Errorf("%w: the file was modified after it was signed", ErrSignatureMismatch)
+	}
+	want, err := hex.DecodeString(sig.Signature)
+	if err != nil || !hmac.Equal(mac(key, &sig, data), want) {
+		return &sig, fmt.Errorf("%w: the signature is invalid for this key", ErrSignatureMismatch)
+	}
+	return &sig, nil
+}
+
+// mac returns the HMAC-SHA256 under key of sig's algorithm, key ID, and
+// signing time, followed by data. The key ID is quoted so that no choice
+// of it can be confused with the fields after it.
+func mac(key []byte, sig *Signature, data []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	fmt.Fprintf(h, "%s\n%q\n%s\n", sig.Algorithm, sig.KeyID, sig.SignedAt.UTC().Format(time.RFC3339Nano))
+	h.Write(data)
+	return h.Sum(nil)
+}
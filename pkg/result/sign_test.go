@@ -0,0 +1,77 @@
+package result
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSignFile_Verify(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	run := &RunSummary{RunID: "run-1", SuiteName: "s", Note: "baseline"}
+	if err := run.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	key := []byte("secret-key")
+	now := time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC)
+
+	if _, err := VerifyFile(path, key); !errors.Is(err, ErrUnsigned) {
+		t.Fatalf("VerifyFile() of unsigned file error = %v, want ErrUnsigned", err)
+	}
+
+	signed, err := SignFile(path, key, "ci", now)
+	if err != nil {
+		t.Fatalf("SignFile() error: %v", err)
+	}
+	sig, err := VerifyFile(path, key)
+	if err != nil {
+		t.Fatalf("VerifyFile() error: %v", err)
+	}
+	if sig.KeyID != "ci" || !sig.SignedAt.Equal(now) || sig.Digest != signed.Digest {
+		t.Errorf("VerifyFile() = %+v, want %+v", sig, signed)
+	}
+
+	if _, err := VerifyFile(path, []byte("other-key")); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifyFile() with another key error = %v, want ErrSignatureMismatch", err)
+	}
+
+	// The key ID and signing time are covered by the signature too.
+	for _, edit := range []func(*Signature){
+		func(s *Signature) { s.KeyID = "prod" },
+		func(s *Signature) { s.SignedAt = s.SignedAt.Add(time.Hour) },
+	} {
+		forged := *signed
+		edit(&forged)
+		raw, _ := json.Marshal(forged)
+		if err := os.WriteFile(SignaturePath(path), raw, 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := VerifyFile(path, key); !errors.Is(err, ErrSignatureMismatch) {
+			t.Errorf("VerifyFile() of signature %+v error = %v, want ErrSignatureMismatch", forged, err)
+		}
+	}
+
+	run.Note = "edited"
+	if err := run.Save(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := VerifyFile(path, key); !errors.Is(err, ErrSignatureMismatch) {
+		t.Errorf("VerifyFile() of modified file error = %v, want ErrSignatureMismatch", err)
+	}
+}
+
+func TestSignFile_EmptyKey(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "run.json")
+	if err := os.WriteFile(path, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := SignFile(path, nil, "", time.Now()); err == nil {
+		t.Error("SignFile() with an empty key should fail")
+	}
+	if _, err := os.Stat(SignaturePath(path)); !os.IsNotExist(err) {
+		t.Errorf("SignFile() with an empty key wrote a signature: %v", err)
+	}
+}
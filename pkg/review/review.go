@@ -98,6 +98,9 @@ func filterCases(results []result.CaseResult, filter Filter) []int {
 func printCase(w io.Writer, cr *result.CaseResult) {
 	fmt.Fprintf(w, "Name:     %s\n", cr.CaseName)
 	fmt.Fprintf(w, "Status:   %s\n", cr.Status)
+	if cr.SpotCheck {
+		fmt.Fprintf(w, "Judges:   passed (score %.2f); spot check\n", cr.Score)
+	}
 	if cr.Prompt != "" {
 		fmt.Fprintf(w, "Prompt:   %s\n", truncateStr(cr.Prompt, 200))
 	}
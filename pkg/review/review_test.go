@@ -2,6 +2,7 @@ package review
 
 import (
 	"bytes"
+	"math/rand"
 	"strings"
 	"testing"
 
@@ -227,3 +228,41 @@ func TestHumanReviewJudge(t *testing.T) {
 		t.Errorf("review filter indices = %v, want [1, 3]", indices)
 	}
 }
+
+func TestSpotCheck_Sample(t *testing.T) {
+	summary := &result.RunSummary{Results: []result.CaseResult{
+		{CaseName: "confident", Status: "pass", Pass: true, Score: 0.95},
+		{CaseName: "borderline", Status: "pass", Pass: true, Score: 0.6},
+		{CaseName: "failed", Status: "fail", Score: 0.2},
+	}}
+	sc := SpotCheck{Rate: 0, Bands: []ScoreBand{{Min: 0.5, Max: 0.7, Rate: 1}}}
+	if got := sc.RateFor(0.7); got != 1 {
+		t.Errorf("RateFor(0.7) = %v, want the band's rate 1", got)
+	}
+
+	if n := sc.Sample(summary, rand.New(rand.NewSource(1))); n != 1 {
+		t.Fatalf("Sample() = %d, want 1", n)
+	}
+	cr := summary.Results[1]
+	if !cr.SpotCheck || cr.Status != "review" || !cr.Pass {
+		t.Errorf("borderline = %+v, want a passing spot check in review", cr)
+	}
+	if summary.Results[0].SpotCheck || summary.Results[2].SpotCheck {
+		t.Error("cases outside the band or not passed were sampled")
+	}
+	if s := summary.Stats; s.SpotChecks != 1 || s.PassedCases != 2 {
+		t.Errorf("Stats = %+v, want 1 spot check and both passes still counted", s)
+	}
+	if _, ok := summary.Stats.JudgePrecision(); ok {
+		t.Error("JudgePrecision() ok before any review")
+	}
+
+	// Grading the spot check measures the judges' precision.
+	r := &Reviewer{In: strings.NewReader("fail\n"), Out: &bytes.Buffer{}}
+	if _, err := r.Review(summary, FilterReview); err != nil {
+		t.Fatal(err)
+	}
+	if p, ok := summary.Stats.JudgePrecision(); !ok || p != 0 {
+		t.Errorf("JudgePrecision() = %v, %v, want 0 after the human failed the case", p, ok)
+	}
+}
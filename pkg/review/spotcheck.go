@@ -0,0 +1,57 @@
+package review
+
+import (
+	"math/rand"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/result"
+)
+
+// SpotCheck routes a random sample of the cases the judges passed to
+// human review, so that LLM-judge precision is measured continuously
+// without reviewing every case. Sampled cases get status "review" and are
+// marked CaseResult.SpotCheck; they keep the judges' pass until a human
+// grades them.
+type SpotCheck struct {
+	// Rate is the fraction of passed cases sampled, in [0, 1].
+	Rate float64
+
+	// Bands override Rate for passed cases whose score falls in them, so
+	// that borderline scores can be audited more often than confident
+	// ones. The first band containing a score applies.
+	Bands []ScoreBand
+}
+
+// ScoreBand is a sampling rate for the scores from Min to Max inclusive.
+type ScoreBand struct {
+	Min, Max float64
+	Rate     float64
+}
+
+// RateFor returns the sampling rate for a passed case with the given score.
+func (sc SpotCheck) RateFor(score float64) float64 {
+	for _, b := range sc.Bands {
+		if score >= b.Min && score <= b.Max {
+			return b.Rate
+		}
+	}
+	return sc.Rate
+}
+
+// Sample marks a random sample of summary's passed cases for review,
+// recomputes its stats, and returns the number of cases sampled.
+func (sc SpotCheck) Sample(summary *result.RunSummary, rng *rand.Rand) int {
+	sampled := 0
+	for i := range summary.Results {
+		cr := &summary.Results[i]
+		if cr.Status != "pass" {
+			continue
+		}
+		if rng.Float64() < sc.RateFor(cr.Score) {
+			cr.Status = "review"
+			cr.SpotCheck = true
+			sampled++
+		}
+	}
+	summary.Stats = result.ComputeStats(summary.Results)
+	return sampled
+}
@@ -0,0 +1,43 @@
+package runner
+
+import "errors"
+
+// ErrSkipped is wrapped by the error reported to the progress callback for
+// a case that was not run.
+var ErrSkipped = errors.New("skipped")
+
+// ErrorCategory classifies why a case errored, so run statistics can tell
+// problems in the suite apart from problems with the provider.
+type ErrorCategory string
+
+const (
+	CategoryProvider      ErrorCategory = "provider_error"
+	CategoryOverloaded    ErrorCategory = "provider_overloaded"
+	CategoryTimeout       ErrorCategory = "timeout"
+	CategoryInterpolation ErrorCategory = "interpolation_error"
+	CategoryMock          ErrorCategory = "mock_error"
+	CategoryConfig        ErrorCategory = "config_error"
+	CategoryTraceLimit    ErrorCategory = "trace_limit"
+
+	// CategoryJudge is assigned when scoring a case fails. The runner
+	// itself never sets it.
+	CategoryJudge ErrorCategory = "judge_error"
+)
+
+// CaseError is an error that ended a case before it produced a final
+// response, tagged with its category.
+type CaseError struct {
+	Category ErrorCategory
+	Err      error
+}
+
+func (e *CaseError) Error() string { return e.Err.Error() }
+
+func (e *CaseError) Unwrap() error { return e.Err }
+
+// fail records ce as the reason the case errored.
+func (cr *CaseResult) fail(ce *CaseError) {
+	cr.Error = ce.Error()
+	cr.err = ce
+	cr.ErrorCategory = ce.Category
+}
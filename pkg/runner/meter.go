@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// Meter is a run-wide tally of agent token usage and estimated cost,
+// updated after every provider call so that a runaway tool loop shows up
+// while the run is still in progress. It is safe for concurrent use.
+type Meter struct {
+	// WarnCost, when positive, is the estimated cost in USD at which
+	// OnWarn is called, once per meter.
+	WarnCost float64
+	OnWarn   func(cost float64)
+
+	inputTokens  atomic.Int64
+	outputTokens atomic.Int64
+	nanoUSD      atomic.Int64
+	calls        atomic.Int64
+	warned       atomic.Bool
+}
+
+// Add records one provider call's usage, priced at model.
+func (m *Meter) Add(model string, u provider.Usage) {
+	m.inputTokens.Add(int64(u.InputTokens))
+	m.outputTokens.Add(int64(u.OutputTokens))
+	m.calls.Add(1)
+	total := m.nanoUSD.Add(int64(provider.EstimateCost(model, u) * 1e9))
+
+	cost := float64(total) / 1e9
+	if m.WarnCost > 0 && cost >= m.WarnCost && m.OnWarn != nil && m.warned.CompareAndSwap(false, true) {
+		m.OnWarn(cost)
+	}
+}
+
+// Tokens returns the input and output tokens recorded so far.
+func (m *Meter) Tokens() (input, output int) {
+	return int(m.inputTokens.Load()), int(m.outputTokens.Load())
+}
+
+// Cost returns the estimated cost in USD recorded so far.
+func (m *Meter) Cost() float64 {
+	return float64(m.nanoUSD.Load()) / 1e9
+}
+
+// Calls returns the number of provider calls recorded so far.
+func (m *Meter) Calls() int {
+	return int(m.calls.Load())
+}
+
+// String formats the tally for a progress line.
+func (m *Meter) String() string {
+	in, out := m.Tokens()
+	return fmt.Sprintf("%d in / %d out, est. $%.4f", in, out, m.Cost())
+}
+
+// meteredProvider records the usage of every successful call in a Meter.
+type meteredProvider struct {
+	provider.Provider
+	meter *Meter
+}
+
+func (p meteredProvider) Complete(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	resp, err := p.Provider.Complete(ctx, req)
+	if err == nil {
+		p.meter.Add(req.Model, resp.Usage)
+	}
+	return resp, err
+}
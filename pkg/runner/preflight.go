@@ -0,0 +1,30 @@
+package runner
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
+)
+
+// Preflight sends one trivial request to p so that authentication, model
+// name, and connectivity problems surface once, with a clear error, before
+// a full run fans out into many identical provider errors.
+func Preflight(ctx context.Context, p provider.Provider, model string, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = 60 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	_, err := p.Complete(ctx, &provider.Request{
+		Model:     model,
+		Messages:  []provider.Message{{Role: "user", Content: "Reply with OK."}},
+		MaxTokens: 8,
+	})
+	if err != nil {
+		return fmt.Errorf("preflight request to %s (model %q) failed: %w", p.Name(), model, err)
+	}
+	return nil
+}
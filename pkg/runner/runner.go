@@ -3,10 +3,14 @@ package runner
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"slices"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/jdgilhuly/go_eval_agent/internal/agentloop"
 	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
 	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
 	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
@@ -14,41 +18,143 @@ import (
 	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
-// MaxToolLoopIterations is the maximum number of tool-call round-trips
-// per case before the runner stops to prevent infinite loops.
-const MaxToolLoopIterations = 20
+// MaxToolLoopIterations is the default maximum number of tool-call
+// round-trips per case before the runner stops to prevent infinite loops.
+const MaxToolLoopIterations = agentloop.DefaultMaxIterations
+
+// reaskPrompt is the corrective turn sent for an invalid final output in
+// suites with reask_invalid.
+const reaskPrompt = "Your output was invalid because %v. Please fix it and respond again."
 
 // CaseResult holds the output from running a single eval case.
 type CaseResult struct {
-	CaseName      string              `json:"case_name"`
-	CaseID        string              `json:"case_id"`
-	Prompt        string              `json:"prompt"`
-	Model         string              `json:"model"`
-	FinalResponse string              `json:"final_response"`
-	Trace         *trace.AgentTrace   `json:"trace"`
-	Error         string              `json:"error,omitempty"`
-	Duration      time.Duration       `json:"duration"`
+	CaseName      string            `json:"case_name"`
+	CaseID        string            `json:"case_id"`
+	Source        string            `json:"source,omitempty"` // file:line of the case definition
+	Prompt        string            `json:"prompt"`
+	Model         string            `json:"model"`
+	FinalResponse string            `json:"final_response"`
+	Trace         *trace.AgentTrace `json:"trace"`
+	Error         string            `json:"error,omitempty"`
+	ErrorCategory ErrorCategory     `json:"error_category,omitempty"`
+	Duration      time.Duration     `json:"duration"`
+
+	// err is the error Error describes, with its chain intact, for the
+	// progress callback.
+	err error
+
+	// TimedOut is set when the per-case deadline fired before the agent
+	// produced a final response. TimeoutIteration records the 1-based
+	// tool-loop iteration that was in flight at the time.
+	TimedOut         bool `json:"timed_out,omitempty"`
+	TimeoutIteration int  `json:"timeout_iteration,omitempty"`
+
+	// Skipped is set for cases that never ran: either FailFast or an
+	// exhausted RetryBudget stopped the run, or BlockedBy, a case this one depends on, did not pass.
+	Skipped   bool   `json:"skipped,omitempty"`
+	BlockedBy string `json:"blocked_by,omitempty"`
+
+	// DisallowedTools lists the tools the agent called outside the case's
+	// allowed_tools. A case with any does not pass.
+	DisallowedTools []string `json:"disallowed_tools,omitempty"`
 }
 
 // RunResult holds the output from an entire suite run.
 type RunResult struct {
-	SuiteName string       `json:"suite_name"`
-	StartTime time.Time    `json:"start_time"`
-	EndTime   time.Time    `json:"end_time"`
+	RunID     string        `json:"run_id"`
+	SuiteName string        `json:"suite_name"`
+	StartTime time.Time     `json:"start_time"`
+	EndTime   time.Time     `json:"end_time"`
 	Duration  time.Duration `json:"duration"`
-	Cases     []CaseResult `json:"cases"`
+	Cases     []CaseResult  `json:"cases"`
+
+	// Concurrency and RateLimit are the limits the suite actually ran
+	// under: the runner's concurrency capped by the suite's
+	// max_concurrency, and the suite's rate_limit (0 = unlimited).
+	Concurrency int     `json:"concurrency"`
+	RateLimit   float64 `json:"rate_limit,omitempty"`
 }
 
 // Config controls runner behavior.
 type Config struct {
 	Concurrency int
 	Timeout     time.Duration
+
+	// Model is sent with every provider request. Providers that embed a
+	// fixed model may leave it empty.
+	Model string
+
+	// ToolExecutor runs real tool calls for suites with
+	// on_unmocked_tool: passthrough. Without one, passthrough calls fail.
+	ToolExecutor ToolResolver
+
+	// MaxIterations limits each case's tool-call round-trips; zero means
+	// MaxToolLoopIterations.
+	MaxIterations int
+
+	// FailFast stops starting cases once one fails. Cases not yet started
+	// are recorded as skipped.
+	FailFast bool
+
+	// Passed decides whether a finished case passed, for FailFast and
+	// depends_on. It is only called for cases whose outcome decides
+	// whether others run. When nil, a case passes if it did not error.
+	Passed func(s *suite.EvalSuite, index int, cr CaseResult) bool
+
+	// Providers resolves the provider named by a case's provider field.
+	// Cases that name a provider fail when it is nil.
+	Providers ProviderFactory
+
+	// Meter, when set, is updated after every provider call made for a
+	// case.
+	Meter *Meter
+
+	// RetryBudget, when set, is the retry budget the run's providers
+	// share. Once it is exhausted, cases not yet started are skipped.
+	RetryBudget *provider.RetryBudget
+
+	// ValidateOutput checks a case's final output for suites with
+	// reask_invalid. When it returns an error, the agent gets one
+	// corrective follow-up turn quoting it.
+	ValidateOutput func(c suite.EvalCase, output string) error
+
+	// TemplateEnv lists the environment variables that prompt templates
+	// and case inputs may read with {{env "NAME"}}.
+	TemplateEnv []string
+
+	// OnToolCall is called for every tool call a case's agent makes, once
+	// its result is known, to enforce invariants at call time, such as
+	// that written code compiles. An error is recorded as a violation on
+	// the call's trace entry, and a case with any does not pass.
+	OnToolCall func(s *suite.EvalSuite, c suite.EvalCase, call provider.ToolCall, result trace.ToolCallTrace) error
+
+	// CheckContextWindow counts the input tokens of each case's first
+	// request with provider.CheckContextWindow and fails the case,
+	// without calling the model, when the request would not fit in the
+	// model's context window.
+	CheckContextWindow bool
+
+	// TraceLimits caps each case's trace; a case whose agent exceeds them
+	// fails with CategoryTraceLimit. Zero fields take their value from
+	// trace.DefaultLimits.
+	TraceLimits trace.Limits
+
+	// Seed, when set, is sent with every case's requests; see
+	// provider.Request.Seed.
+	Seed *int64
 }
 
+// ProviderFactory returns the provider configured under name and the
+// model it uses by default.
+type ProviderFactory func(name string) (provider.Provider, string, error)
+
 // Runner orchestrates suite execution against one or more provider/prompt
-// combinations with bounded concurrency.
+// combinations with bounded concurrency. The concurrency bound applies to
+// the Runner as a whole, so suites run concurrently through the same Runner
+// share one budget of in-flight cases.
 type Runner struct {
 	cfg Config
+	sem chan struct{}
 }
 
 // New creates a Runner with the given configuration.
@@ -59,24 +165,73 @@ func New(cfg Config) *Runner {
 	if cfg.Timeout <= 0 {
 		cfg.Timeout = 60 * time.Second
 	}
-	return &Runner{cfg: cfg}
+	if cfg.TraceLimits.MaxMessages <= 0 {
+		cfg.TraceLimits.MaxMessages = trace.DefaultLimits.MaxMessages
+	}
+	if cfg.TraceLimits.MaxBytes <= 0 {
+		cfg.TraceLimits.MaxBytes = trace.DefaultLimits.MaxBytes
+	}
+	return &Runner{cfg: cfg, sem: make(chan struct{}, cfg.Concurrency)}
 }
 
 // ProgressFunc is called after each case completes. Index is 0-based,
-// total is the number of cases.
+// total is the number of cases. err is the error that ended the case, or
+// nil; it wraps ErrSkipped for cases that were not run.
 type ProgressFunc func(index, total int, caseName string, elapsed time.Duration, err error)
 
 // Run executes all cases in the suite using the given prompt variant and
-// provider. It respects bounded concurrency and per-case timeouts.
+// provider. It respects bounded concurrency, the suite's own concurrency
+// and rate limits, and per-case timeouts. A case with depends_on waits
+// for its dependencies and is skipped if any did not pass; with FailFast,
+// cases not yet started when one fails are skipped, as are cases not yet
+// started once the RetryBudget is exhausted.
 // The optional progress callback is invoked after each case completes.
+// Run is safe to call from multiple goroutines.
 func (r *Runner) Run(ctx context.Context, s *suite.EvalSuite, pv *prompt.PromptVariant, p provider.Provider, progress ProgressFunc) (*RunResult, error) {
 	result := &RunResult{
-		SuiteName: s.Name,
-		StartTime: time.Now(),
-		Cases:     make([]CaseResult, len(s.Cases)),
+		SuiteName:   s.Name,
+		StartTime:   time.Now(),
+		Cases:       make([]CaseResult, len(s.Cases)),
+		Concurrency: r.cfg.Concurrency,
+		RateLimit:   s.RateLimit,
+	}
+	result.RunID = RunID(result.StartTime, s.Name)
+	metadata := RequestMetadata(s.Name, result.RunID)
+
+	// A suite may cap itself below the runner's budget. Its own slot is
+	// taken before the shared one so a throttled suite never holds shared
+	// slots other suites could use.
+	var suiteSem chan struct{}
+	if s.MaxConcurrency > 0 && s.MaxConcurrency < r.cfg.Concurrency {
+		result.Concurrency = s.MaxConcurrency
+		suiteSem = make(chan struct{}, s.MaxConcurrency)
+	}
+	var limiter *rateLimiter
+	if s.RateLimit > 0 {
+		limiter = newRateLimiter(s.RateLimit)
+	}
+
+	// Each case closes done[i] once passed[i] is final, so dependents
+	// wait for their dependencies before taking a concurrency slot.
+	byName := make(map[string]int, len(s.Cases))
+	dependents := make([]bool, len(s.Cases))
+	for i, c := range s.Cases {
+		byName[c.Name] = i
+	}
+	for _, c := range s.Cases {
+		for _, dep := range c.DependsOn {
+			if j, ok := byName[dep]; ok {
+				dependents[j] = true
+			}
+		}
+	}
+	done := make([]chan struct{}, len(s.Cases))
+	for i := range done {
+		done[i] = make(chan struct{})
 	}
+	passed := make([]bool, len(s.Cases))
+	var stopped atomic.Bool
 
-	sem := make(chan struct{}, r.cfg.Concurrency)
 	var mu sync.Mutex
 	var completed int
 
@@ -85,23 +240,45 @@ func (r *Runner) Run(ctx context.Context, s *suite.EvalSuite, pv *prompt.PromptV
 		wg.Add(1)
 		go func(idx int, ec suite.EvalCase) {
 			defer wg.Done()
+			defer close(done[idx])
+
+			var cr CaseResult
+			if dep := failedDependency(ec, byName, done, passed); dep != "" {
+				cr = r.skippedCase(ec, pv, fmt.Sprintf("dependency %q did not pass", dep))
+				cr.BlockedBy = dep
+			} else {
+				cr = func() CaseResult {
+					if suiteSem != nil {
+						suiteSem <- struct{}{}
+						defer func() { <-suiteSem }()
+					}
+					if limiter != nil && r.stopReason(&stopped) == "" {
+						limiter.wait(ctx)
+					}
+					r.sem <- struct{}{}
+					defer func() { <-r.sem }()
+
+					if reason := r.stopReason(&stopped); reason != "" {
+						return r.skippedCase(ec, pv, reason)
+					}
+					return r.runCase(ctx, s, ec, pv, p, metadata)
+				}()
+			}
+			if !cr.Skipped && (r.cfg.FailFast || dependents[idx]) {
+				passed[idx] = r.passed(s, idx, cr)
+				if !passed[idx] && r.cfg.FailFast {
+					stopped.Store(true)
+				}
+			}
 
-			sem <- struct{}{}
-			defer func() { <-sem }()
-
-			cr := r.runCase(ctx, ec, pv, p)
+			// Progress is reported under the lock so callbacks never
+			// overlap, even for skipped cases that hold no slot.
 			mu.Lock()
+			defer mu.Unlock()
 			result.Cases[idx] = cr
 			completed++
-			current := completed
-			mu.Unlock()
-
 			if progress != nil {
-				var caseErr error
-				if cr.Error != "" {
-					caseErr = fmt.Errorf("%s", cr.Error)
-				}
-				progress(current-1, len(s.Cases), ec.Name, time.Since(result.StartTime), caseErr)
+				progress(completed-1, len(s.Cases), ec.Name, time.Since(result.StartTime), cr.err)
 			}
 		}(i, c)
 	}
@@ -112,16 +289,236 @@ func (r *Runner) Run(ctx context.Context, s *suite.EvalSuite, pv *prompt.PromptV
 	return result, nil
 }
 
-// runCase executes a single eval case through the full agent loop.
-func (r *Runner) runCase(ctx context.Context, c suite.EvalCase, pv *prompt.PromptVariant, p provider.Provider) CaseResult {
+// failedDependency waits for the dependencies of c and returns the name
+// of the first one that did not pass, or "" if all passed. Dependencies
+// not in the suite are ignored.
+func failedDependency(c suite.EvalCase, byName map[string]int, done []chan struct{}, passed []bool) string {
+	for _, dep := range c.DependsOn {
+		j, ok := byName[dep]
+		if !ok {
+			continue
+		}
+		<-done[j]
+		if !passed[j] {
+			return dep
+		}
+	}
+	return ""
+}
+
+// skippedCase returns the result for a case that was not run.
+func (r *Runner) skippedCase(c suite.EvalCase, pv *prompt.PromptVariant, reason string) CaseResult {
+	model := r.cfg.Model
+	if c.Model != "" {
+		model = c.Model
+	}
+	err := fmt.Errorf("%w: %s", ErrSkipped, reason)
+	return CaseResult{
+		CaseName: c.Name,
+		CaseID:   c.ID,
+		Source:   c.Source,
+		Model:    model,
+		Prompt:   pv.Name,
+		Error:    err.Error(),
+		Skipped:  true,
+		err:      err,
+	}
+}
+
+// stopReason returns why cases should no longer start, or "" if they
+// may. stopped is set once a case fails under FailFast.
+func (r *Runner) stopReason(stopped *atomic.Bool) string {
+	switch {
+	case r.cfg.FailFast && stopped.Load():
+		return "an earlier case failed (fail-fast)"
+	case r.cfg.RetryBudget.Exhausted():
+		return "the run's retry budget is exhausted"
+	}
+	return ""
+}
+
+// passed reports whether cr counts as a pass for FailFast and depends_on.
+func (r *Runner) passed(s *suite.EvalSuite, idx int, cr CaseResult) bool {
+	if cr.Error != "" || len(cr.DisallowedTools) > 0 {
+		return false
+	}
+	if cr.Trace != nil && len(cr.Trace.ToolViolations()) > 0 {
+		return false
+	}
+	if r.cfg.Passed == nil {
+		return true
+	}
+	return r.cfg.Passed(s, idx, cr)
+}
+
+// rateLimiter spaces out case starts so no more than a fixed number begin
+// per second.
+type rateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+func newRateLimiter(perSecond float64) *rateLimiter {
+	return &rateLimiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the caller may start, or until ctx is done.
+func (l *rateLimiter) wait(ctx context.Context) {
+	l.mu.Lock()
+	now := time.Now()
+	start := l.next
+	if start.Before(now) {
+		start = now
+	}
+	l.next = start.Add(l.interval)
+	l.mu.Unlock()
+
+	delay := time.Until(start)
+	if delay <= 0 {
+		return
+	}
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+	case <-ctx.Done():
+	}
+}
+
+// ToolResolver produces the result of a tool call. *mock.MockRegistry
+// implements it.
+type ToolResolver = agentloop.ToolResolver
+
+// RunToolLoop drives the agent tool-use loop: it sends req to p, resolves
+// any tool calls through tools, feeds the results back, and repeats until
+// the model answers without tool calls or MaxToolLoopIterations is
+// reached. req.ToolChoice applies to the first request only, so a forced
+// tool call does not stop the model from answering afterwards. When
+// req.Tools is set, calls to tools not in it are recorded as hallucinated
+// and answered with an error instead of being resolved. Assistant and
+// tool messages, tool calls, usage, and provider retries are recorded in
+// tr; the caller records the initial messages.
+//
+// It returns the final response and the 1-based iteration that was last
+// started. On a provider error the iteration identifies the request that
+// failed.
+func RunToolLoop(ctx context.Context, p provider.Provider, req provider.Request, tools ToolResolver, tr *trace.AgentTrace) (string, int, error) {
+	res, err := agentloop.Run(ctx, p, req, tools, tr, MaxToolLoopIterations, nil)
+	return res.Final, res.Iterations, err
+}
+
+// CaseTools builds the tools offered to the provider for case c from the
+// prompt's tool definitions, keeping only the case's allowed tools when it
+// restricts them. It fails if allowed_tools names a tool the prompt does
+// not define.
+func CaseTools(c suite.EvalCase, pv *prompt.PromptVariant) ([]provider.Tool, error) {
+	tools := make([]provider.Tool, 0, len(pv.Tools))
+	for _, t := range pv.Tools {
+		if len(c.AllowedTools) > 0 && !slices.Contains(c.AllowedTools, t.Name) {
+			continue
+		}
+		tools = append(tools, provider.Tool{
+			Name:        t.Name,
+			Description: t.Description,
+			Parameters:  t.Parameters,
+		})
+	}
+	for _, name := range c.AllowedTools {
+		if !slices.ContainsFunc(tools, func(t provider.Tool) bool { return t.Name == name }) {
+			return nil, fmt.Errorf("allowed_tools names %q, which prompt %q does not define", name, pv.Name)
+		}
+	}
+	return tools, nil
+}
+
+// RequestMetadata returns the metadata every request of a run is tagged
+// with: the suite and run, so eval traffic can be told apart in the
+// provider's dashboards.
+func RequestMetadata(suiteName, runID string) map[string]string {
+	return map[string]string{"suite": suiteName, "run_id": runID}
+}
+
+// CaseMocks validates the tool mocks of case c of suite s and returns a
+// registry resolving tool calls through them. Tools without a mock follow
+// the suite's unmocked-tool policy, passing through to passthrough when it
+// allows. Errors are *CaseError.
+func CaseMocks(s *suite.EvalSuite, c suite.EvalCase, passthrough mock.ToolFunc) (*mock.MockRegistry, error) {
+	for _, m := range c.Mocks {
+		if err := m.Validate(); err != nil {
+			return nil, &CaseError{Category: CategoryMock, Err: fmt.Errorf("invalid mock: %w", err)}
+		}
+	}
+	registry := mock.NewRegistry(c.Mocks)
+	registry.SetUnmockedPolicy(s.OnUnmockedTool, passthrough)
+	return registry, nil
+}
+
+// CaseRequest renders the first request the runner sends to the model for
+// case c with prompt pv: the prompt interpolated with the case's inputs,
+// the case's tools, and the prompt's tool choice. Errors are *CaseError.
+func CaseRequest(c suite.EvalCase, pv *prompt.PromptVariant, model string, templateEnv []string) (provider.Request, error) {
+	env := prompt.WithEnv(templateEnv)
+	vars, err := prompt.ExpandEnv(c.Input, env)
+	if err != nil {
+		return provider.Request{}, &CaseError{Category: CategoryInterpolation, Err: fmt.Errorf("interpolating inputs: %w", err)}
+	}
+	rendered, err := pv.Interpolate(vars, env)
+	if err != nil {
+		return provider.Request{}, &CaseError{Category: CategoryInterpolation, Err: fmt.Errorf("interpolating prompt: %w", err)}
+	}
+	tools, err := CaseTools(c, rendered)
+	if err != nil {
+		return provider.Request{}, &CaseError{Category: CategoryConfig, Err: err}
+	}
+	return provider.Request{
+		Model:    model,
+		System:   rendered.System,
+		Messages: []provider.Message{{Role: "user", Content: rendered.User}},
+		Tools:    tools,
+		// tool_choice forces only the first action; RunToolLoop leaves
+		// later turns to the model.
+		ToolChoice: provider.ParseToolChoice(rendered.ToolChoice),
+	}, nil
+}
+
+// runCase executes a single eval case through the full agent loop,
+// tagging its requests with metadata.
+func (r *Runner) runCase(ctx context.Context, s *suite.EvalSuite, c suite.EvalCase, pv *prompt.PromptVariant, p provider.Provider, metadata map[string]string) CaseResult {
 	start := time.Now()
 	cr := CaseResult{
 		CaseName: c.Name,
 		CaseID:   c.ID,
-		Model:    "",
+		Source:   c.Source,
+		Model:    r.cfg.Model,
 		Prompt:   pv.Name,
 	}
 
+	// Per-case provider and model.
+	if c.Provider != "" {
+		if r.cfg.Providers == nil {
+			cr.fail(&CaseError{Category: CategoryProvider, Err: fmt.Errorf("case requests provider %q but the runner has no provider factory", c.Provider)})
+			cr.Duration = time.Since(start)
+			return cr
+		}
+		var err error
+		p, cr.Model, err = r.cfg.Providers(c.Provider)
+		if err != nil {
+			cr.fail(&CaseError{Category: CategoryProvider, Err: fmt.Errorf("resolving provider %q: %w", c.Provider, err)})
+			cr.Duration = time.Since(start)
+			return cr
+		}
+	}
+	if c.Model != "" {
+		cr.Model = c.Model
+	}
+	// The metered wrapper hides optional interfaces such as
+	// provider.TokenCounter, so keep the provider itself for those.
+	base := p
+	if r.cfg.Meter != nil {
+		p = meteredProvider{Provider: p, meter: r.cfg.Meter}
+	}
+
 	// Per-case timeout.
 	timeout := r.cfg.Timeout
 	if c.Timeout > 0 {
@@ -131,108 +528,99 @@ func (r *Runner) runCase(ctx context.Context, c suite.EvalCase, pv *prompt.Promp
 	defer cancel()
 
 	// Set up mocks.
-	registry := mock.NewRegistry(c.Mocks)
-
-	// Interpolate prompt with case input variables.
-	rendered, err := pv.Interpolate(c.Input)
+	var passthrough mock.ToolFunc
+	if r.cfg.ToolExecutor != nil {
+		passthrough = r.cfg.ToolExecutor.Resolve
+	}
+	registry, err := CaseMocks(s, c, passthrough)
 	if err != nil {
-		cr.Error = fmt.Sprintf("interpolating prompt: %v", err)
+		var ce *CaseError
+		errors.As(err, &ce)
+		cr.fail(ce)
 		cr.Duration = time.Since(start)
 		return cr
 	}
 
-	// Build tools for the provider request.
-	tools := make([]provider.Tool, len(rendered.Tools))
-	for i, t := range rendered.Tools {
-		tools[i] = provider.Tool{
-			Name:        t.Name,
-			Description: t.Description,
-			Parameters:  t.Parameters,
+	req, err := CaseRequest(c, pv, cr.Model, r.cfg.TemplateEnv)
+	if err != nil {
+		var ce *CaseError
+		errors.As(err, &ce)
+		cr.fail(ce)
+		cr.Duration = time.Since(start)
+		return cr
+	}
+	req.Metadata = metadata
+	req.Seed = r.cfg.Seed
+	if r.cfg.CheckContextWindow {
+		if _, err := provider.CheckContextWindow(caseCtx, base, &req); err != nil {
+			category := CategoryProvider
+			if errors.Is(err, provider.ErrContextWindow) || errors.Is(err, provider.ErrSecretDetected) {
+				category = CategoryConfig
+			}
+			cr.fail(&CaseError{Category: category, Err: err})
+			cr.Duration = time.Since(start)
+			return cr
 		}
 	}
 
 	// Start trace.
 	tr := trace.New()
+	tr.SetLimits(r.cfg.TraceLimits)
 	cr.Trace = tr
+	tr.AddMessage("user", req.Messages[0].Content)
 
-	// Build initial messages.
-	messages := []provider.Message{
-		{Role: "user", Content: rendered.User},
-	}
-	tr.AddMessage("user", rendered.User)
-
-	// Agent tool-use loop.
-	for iteration := 0; iteration < MaxToolLoopIterations; iteration++ {
-		req := &provider.Request{
-			System:   rendered.System,
-			Messages: messages,
-			Tools:    tools,
+	var onToolCall agentloop.ToolHook
+	if r.cfg.OnToolCall != nil {
+		onToolCall = func(call provider.ToolCall, result trace.ToolCallTrace) error {
+			return r.cfg.OnToolCall(s, c, call, result)
 		}
-
-		resp, err := p.Complete(caseCtx, req)
-		if err != nil {
-			cr.Error = fmt.Sprintf("provider error: %v", err)
-			break
-		}
-
-		cr.Model = req.Model
-		tr.AddUsage(resp.Usage.InputTokens, resp.Usage.OutputTokens)
-
-		// If no tool calls, we have the final response.
-		if len(resp.ToolCalls) == 0 {
-			tr.AddMessage("assistant", resp.Content)
-			cr.FinalResponse = resp.Content
-			break
-		}
-
-		// Record assistant message with tool calls.
-		tr.AddMessage("assistant", resp.Content)
-
-		// Append the assistant message (with tool calls) to the conversation.
-		messages = append(messages, provider.Message{
-			Role:      "assistant",
-			Content:   resp.Content,
-			ToolCalls: resp.ToolCalls,
-		})
-
-		// Resolve each tool call via mocks.
-		for _, tc := range resp.ToolCalls {
-			tcStart := time.Now()
-			content, mockErr := registry.Resolve(tc.Name, tc.Parameters)
-			tcDuration := time.Since(tcStart)
-
-			tcTrace := trace.ToolCallTrace{
-				ToolName:   tc.Name,
-				Parameters: tc.Parameters,
-				Response:   content,
-				StartTime:  tcStart,
-				EndTime:    time.Now(),
-				Duration:   tcDuration,
-			}
-			if mockErr != nil {
-				tcTrace.Error = mockErr.Error()
-			}
-			tr.AddToolCall(tcTrace)
-
-			// Add the tool result as a message for the next turn.
-			toolContent := content
-			if mockErr != nil {
-				toolContent = fmt.Sprintf("Error: %v", mockErr)
-			}
-			messages = append(messages, provider.Message{
-				Role:       "tool",
-				Content:    toolContent,
-				ToolCallID: tc.ID,
-			})
-			tr.AddMessage("tool", toolContent)
+	}
+	loop, err := agentloop.Run(caseCtx, p, req, registry, tr, r.cfg.MaxIterations, onToolCall)
+	final, iteration := loop.Final, loop.Iterations
+	if err == nil && final != "" && s.ReaskInvalid && r.cfg.ValidateOutput != nil {
+		if verr := r.cfg.ValidateOutput(c, final); verr != nil {
+			reask := fmt.Sprintf(reaskPrompt, verr)
+			tr.AddReask(verr.Error())
+			tr.AddMessage("user", reask)
+			req.Messages = append(loop.Messages, provider.Message{Role: "user", Content: reask})
+			req.ToolChoice = nil
+			loop, err = agentloop.Run(caseCtx, p, req, registry, tr, r.cfg.MaxIterations, onToolCall)
+			final = loop.Final
+			iteration += loop.Iterations
 		}
 	}
+	switch {
+	case err != nil && errors.Is(caseCtx.Err(), context.DeadlineExceeded):
+		cr.TimedOut = true
+		cr.TimeoutIteration = iteration
+		cr.fail(&CaseError{Category: CategoryTimeout, Err: fmt.Errorf("timeout after %s during tool loop iteration %d", timeout, iteration)})
+	case err != nil && errors.Is(err, provider.ErrOverloaded):
+		cr.fail(&CaseError{Category: CategoryOverloaded, Err: fmt.Errorf("provider error: %w", err)})
+	case err != nil && errors.Is(err, provider.ErrSecretDetected):
+		cr.fail(&CaseError{Category: CategoryConfig, Err: err})
+	case err != nil && errors.Is(err, trace.ErrLimitExceeded):
+		cr.fail(&CaseError{Category: CategoryTraceLimit, Err: fmt.Errorf("%w during tool loop iteration %d", err, iteration)})
+	case err != nil:
+		cr.fail(&CaseError{Category: CategoryProvider, Err: fmt.Errorf("provider error: %w", err)})
+	default:
+		cr.FinalResponse = final
+	}
+	// Tools outside allowed_tools were not offered, so the loop recorded
+	// calls to them as hallucinated.
+	if len(c.AllowedTools) > 0 {
+		cr.DisallowedTools = tr.HallucinatedTools()
+	}
 
 	tr.Finish()
 	cr.Duration = time.Since(start)
 	return cr
 }
 
+// RunID returns the ID of the run of the named suite started at start.
+func RunID(start time.Time, suiteName string) string {
+	return fmt.Sprintf("%s-%s", start.Format("20060102-150405"), suiteName)
+}
+
 // JSON serializes the RunResult to indented JSON bytes.
 func (r *RunResult) JSON() ([]byte, error) {
 	return json.MarshalIndent(r, "", "  ")
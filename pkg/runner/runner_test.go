@@ -2,7 +2,12 @@ package runner
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -11,6 +16,7 @@ import (
 	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
 	"github.com/jdgilhuly/go_eval_agent/pkg/provider"
 	"github.com/jdgilhuly/go_eval_agent/pkg/suite"
+	"github.com/jdgilhuly/go_eval_agent/pkg/trace"
 )
 
 // fakeProvider is a test double that implements provider.Provider.
@@ -178,6 +184,143 @@ func TestRun_WithToolCalls(t *testing.T) {
 	}
 }
 
+func TestRun_HallucinatedTool(t *testing.T) {
+	fp := &fakeProvider{
+		responses: []provider.Response{
+			{
+				StopReason: "tool_use",
+				ToolCalls: []provider.ToolCall{
+					{ID: "tc1", Name: "web_search", Parameters: map[string]interface{}{"q": "2+2"}},
+					{ID: "tc2", Name: "calculator", Parameters: map[string]interface{}{"expr": "2+2"}},
+				},
+			},
+			{Content: "The answer is 4.", StopReason: "end_turn"},
+		},
+	}
+
+	s := &suite.EvalSuite{
+		Name: "tool-suite",
+		Cases: []suite.EvalCase{{
+			Name:  "hallucinating-case",
+			Input: map[string]interface{}{"question": "Calculate 2+2"},
+			Mocks: []mock.MockConfig{
+				{ToolName: "web_search", DefaultResponse: &mock.MockResponse{Content: "should not be used"}},
+				{ToolName: "calculator", DefaultResponse: &mock.MockResponse{Content: "4"}},
+			},
+		}},
+	}
+	pv := &prompt.PromptVariant{
+		Name: "tool-prompt",
+		User: "{{.question}}",
+		Tools: []prompt.ToolDefinition{
+			{Name: "calculator", Description: "Do math"},
+		},
+	}
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), s, pv, fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	cr := result.Cases[0]
+	if cr.Error != "" {
+		t.Fatalf("unexpected case error: %s", cr.Error)
+	}
+	calls := cr.Trace.GetToolCalls()
+	if len(calls) != 2 {
+		t.Fatalf("len(ToolCalls) = %d, want 2", len(calls))
+	}
+	if !calls[0].Hallucinated || calls[0].Response != "" || !strings.Contains(calls[0].Error, "does not exist") {
+		t.Errorf("web_search call = %+v, want flagged and unresolved", calls[0])
+	}
+	if calls[1].Hallucinated || calls[1].Response != "4" {
+		t.Errorf("calculator call = %+v, want resolved normally", calls[1])
+	}
+	if got := cr.Trace.HallucinatedTools(); len(got) != 1 || got[0] != "web_search" {
+		t.Errorf("HallucinatedTools() = %v, want [web_search]", got)
+	}
+}
+
+// toolsProvider records the tools offered in each request.
+type toolsProvider struct {
+	fakeProvider
+	offered [][]string
+}
+
+func (p *toolsProvider) Complete(ctx context.Context, req *provider.Request) (*provider.Response, error) {
+	var names []string
+	for _, t := range req.Tools {
+		names = append(names, t.Name)
+	}
+	p.offered = append(p.offered, names)
+	return p.fakeProvider.Complete(ctx, req)
+}
+
+func TestRun_AllowedTools(t *testing.T) {
+	fp := &toolsProvider{fakeProvider: fakeProvider{
+		responses: []provider.Response{
+			{
+				StopReason: "tool_use",
+				ToolCalls:  []provider.ToolCall{{ID: "tc1", Name: "web_search"}},
+			},
+			{Content: "Done.", StopReason: "end_turn"},
+		},
+	}}
+	s := &suite.EvalSuite{
+		Name: "restricted",
+		Cases: []suite.EvalCase{{
+			Name:         "no-search",
+			Input:        map[string]interface{}{"question": "Calculate 2+2"},
+			AllowedTools: []string{"calculator"},
+			Mocks: []mock.MockConfig{
+				{ToolName: "web_search", DefaultResponse: &mock.MockResponse{Content: "results"}},
+			},
+		}},
+	}
+	pv := &prompt.PromptVariant{
+		Name: "tool-prompt",
+		User: "{{.question}}",
+		Tools: []prompt.ToolDefinition{
+			{Name: "calculator", Description: "Do math"},
+			{Name: "web_search", Description: "Search the web"},
+		},
+	}
+
+	var passedCalled bool
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, Passed: func(*suite.EvalSuite, int, CaseResult) bool {
+		passedCalled = true
+		return true
+	}})
+	result, err := r.Run(context.Background(), s, pv, fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if len(fp.offered) == 0 || !slices.Equal(fp.offered[0], []string{"calculator"}) {
+		t.Errorf("offered tools = %v, want only calculator", fp.offered)
+	}
+	cr := result.Cases[0]
+	if cr.Error != "" {
+		t.Fatalf("unexpected case error: %s", cr.Error)
+	}
+	if !slices.Equal(cr.DisallowedTools, []string{"web_search"}) {
+		t.Errorf("DisallowedTools = %v, want [web_search]", cr.DisallowedTools)
+	}
+	if r.passed(s, 0, cr) || passedCalled {
+		t.Error("case calling a disallowed tool counted as passed")
+	}
+
+	// Allowing a tool the prompt does not define is a configuration error.
+	s.Cases[0].AllowedTools = []string{"calculator", "shell"}
+	result, err = r.Run(context.Background(), s, pv, &fakeProvider{}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if cr := result.Cases[0]; cr.ErrorCategory != CategoryConfig || !strings.Contains(cr.Error, `"shell"`) {
+		t.Errorf("Error = %q (%s), want config error naming shell", cr.Error, cr.ErrorCategory)
+	}
+}
+
 func TestRun_ProviderError(t *testing.T) {
 	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
 	result, err := r.Run(context.Background(), simpleSuite(), simplePrompt(), &errorProvider{}, nil)
@@ -189,6 +332,9 @@ func TestRun_ProviderError(t *testing.T) {
 	if cr.Error == "" {
 		t.Fatal("expected case Error to be set for provider error")
 	}
+	if cr.ErrorCategory != CategoryProvider {
+		t.Errorf("ErrorCategory = %q, want %q", cr.ErrorCategory, CategoryProvider)
+	}
 	if cr.FinalResponse != "" {
 		t.Errorf("FinalResponse = %q, want empty", cr.FinalResponse)
 	}
@@ -227,6 +373,29 @@ func TestRun_InterpolationError(t *testing.T) {
 	if cr.Error == "" {
 		t.Fatal("expected interpolation error")
 	}
+	if cr.ErrorCategory != CategoryInterpolation {
+		t.Errorf("ErrorCategory = %q, want %q", cr.ErrorCategory, CategoryInterpolation)
+	}
+}
+
+func TestRun_InvalidMockError(t *testing.T) {
+	s := simpleSuite()
+	s.Cases[0].Mocks = []mock.MockConfig{{ToolName: "search"}}
+	fp := &fakeProvider{responses: []provider.Response{{Content: "should not reach"}}}
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), s, simplePrompt(), fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	cr := result.Cases[0]
+	if cr.ErrorCategory != CategoryMock {
+		t.Errorf("ErrorCategory = %q, want %q (error: %s)", cr.ErrorCategory, CategoryMock, cr.Error)
+	}
+	if fp.callIdx != 0 {
+		t.Errorf("provider called %d times, want 0", fp.callIdx)
+	}
 }
 
 func TestRun_BoundedConcurrency(t *testing.T) {
@@ -335,6 +504,36 @@ func TestRun_ProgressCallback(t *testing.T) {
 	}
 }
 
+// pendingProvider fails every request as a detached batch would.
+type pendingProvider struct{}
+
+func (pendingProvider) Name() string { return "pending" }
+func (pendingProvider) Complete(_ context.Context, _ *provider.Request) (*provider.Response, error) {
+	return nil, fmt.Errorf("%w: submitted in batch b1", provider.ErrBatchPending)
+}
+
+func TestRun_ProgressErrorChain(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name:  "progress-errors",
+		Cases: []suite.EvalCase{{Name: "a", Input: q}, {Name: "b", Input: q, DependsOn: []string{"a"}}},
+	}
+	errs := map[string]error{}
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	_, err := r.Run(context.Background(), s, simplePrompt(), pendingProvider{}, func(_, _ int, name string, _ time.Duration, err error) {
+		errs[name] = err
+	})
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if !errors.Is(errs["a"], provider.ErrBatchPending) {
+		t.Errorf("progress error for a = %v, want one wrapping ErrBatchPending", errs["a"])
+	}
+	if !errors.Is(errs["b"], ErrSkipped) {
+		t.Errorf("progress error for b = %v, want one wrapping ErrSkipped", errs["b"])
+	}
+}
+
 func TestRun_ContextCancellation(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	cancel() // Cancel immediately.
@@ -383,3 +582,753 @@ func TestRunResult_JSON(t *testing.T) {
 		t.Fatal("JSON() returned empty")
 	}
 }
+
+// blockingProvider returns the first response immediately and then blocks
+// until the request context is done.
+type blockingProvider struct {
+	first provider.Response
+	calls atomic.Int32
+}
+
+func (b *blockingProvider) Name() string { return "blocking" }
+func (b *blockingProvider) Complete(ctx context.Context, _ *provider.Request) (*provider.Response, error) {
+	if b.calls.Add(1) == 1 {
+		resp := b.first
+		return &resp, nil
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func TestRun_TimeoutClassified(t *testing.T) {
+	bp := &blockingProvider{
+		first: provider.Response{
+			StopReason: "tool_use",
+			ToolCalls: []provider.ToolCall{
+				{ID: "t1", Name: "lookup", Parameters: map[string]interface{}{}},
+			},
+		},
+	}
+
+	s := simpleSuite()
+	s.Cases[0].Mocks = []mock.MockConfig{
+		{ToolName: "lookup", DefaultResponse: &mock.MockResponse{Content: "ok"}},
+	}
+
+	r := New(Config{Concurrency: 1, Timeout: 50 * time.Millisecond})
+	result, err := r.Run(context.Background(), s, simplePrompt(), bp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	cr := result.Cases[0]
+	if !cr.TimedOut {
+		t.Fatalf("TimedOut = false, want true (error: %q)", cr.Error)
+	}
+	if cr.TimeoutIteration != 2 {
+		t.Errorf("TimeoutIteration = %d, want 2", cr.TimeoutIteration)
+	}
+	if strings.Contains(cr.Error, "provider error") {
+		t.Errorf("Error = %q, should not be reported as a provider error", cr.Error)
+	}
+}
+
+func TestRun_CancellationIsNotTimeout(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(ctx, simpleSuite(), simplePrompt(), &contextAwareProvider{}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Cases[0].TimedOut {
+		t.Error("TimedOut = true for a cancelled context, want false")
+	}
+}
+
+func TestRun_SharedConcurrencyAcrossSuites(t *testing.T) {
+	var maxConcurrent atomic.Int32
+	var current atomic.Int32
+	sp := &slowFakeProvider{
+		delay:         30 * time.Millisecond,
+		response:      provider.Response{Content: "ok", StopReason: "end_turn"},
+		maxConcurrent: &maxConcurrent,
+		current:       &current,
+	}
+
+	newSuite := func(name string) *suite.EvalSuite {
+		return &suite.EvalSuite{
+			Name: name,
+			Cases: []suite.EvalCase{
+				{Name: "c1", Input: map[string]interface{}{"question": "a"}},
+				{Name: "c2", Input: map[string]interface{}{"question": "b"}},
+				{Name: "c3", Input: map[string]interface{}{"question": "c"}},
+			},
+		}
+	}
+
+	r := New(Config{Concurrency: 2, Timeout: 5 * time.Second})
+	var wg sync.WaitGroup
+	for _, name := range []string{"s1", "s2", "s3"} {
+		wg.Add(1)
+		go func(s *suite.EvalSuite) {
+			defer wg.Done()
+			if _, err := r.Run(context.Background(), s, simplePrompt(), sp, nil); err != nil {
+				t.Errorf("Run(%s) error: %v", s.Name, err)
+			}
+		}(newSuite(name))
+	}
+	wg.Wait()
+
+	if maxConcurrent.Load() > 2 {
+		t.Errorf("maxConcurrent = %d across suites, want <= 2", maxConcurrent.Load())
+	}
+}
+
+func TestRun_SuiteMaxConcurrency(t *testing.T) {
+	var maxConcurrent atomic.Int32
+	var current atomic.Int32
+	sp := &slowFakeProvider{
+		delay:         20 * time.Millisecond,
+		response:      provider.Response{Content: "ok", StopReason: "end_turn"},
+		maxConcurrent: &maxConcurrent,
+		current:       &current,
+	}
+
+	s := &suite.EvalSuite{
+		Name:           "fragile",
+		MaxConcurrency: 1,
+		Cases: []suite.EvalCase{
+			{Name: "c1", Input: map[string]interface{}{"question": "a"}},
+			{Name: "c2", Input: map[string]interface{}{"question": "b"}},
+			{Name: "c3", Input: map[string]interface{}{"question": "c"}},
+		},
+	}
+
+	r := New(Config{Concurrency: 4, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), s, simplePrompt(), sp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if maxConcurrent.Load() != 1 {
+		t.Errorf("maxConcurrent = %d, want 1", maxConcurrent.Load())
+	}
+	if result.Concurrency != 1 {
+		t.Errorf("Concurrency = %d, want 1", result.Concurrency)
+	}
+
+	// A suite cap above the runner's budget has no effect.
+	s.MaxConcurrency = 10
+	result, err = r.Run(context.Background(), s, simplePrompt(), sp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.Concurrency != 4 {
+		t.Errorf("Concurrency = %d, want 4", result.Concurrency)
+	}
+}
+
+func TestRun_SuiteRateLimit(t *testing.T) {
+	s := &suite.EvalSuite{
+		Name:      "rate-limited",
+		RateLimit: 20, // one case start every 50ms
+		Cases: []suite.EvalCase{
+			{Name: "c1", Input: map[string]interface{}{"question": "a"}},
+			{Name: "c2", Input: map[string]interface{}{"question": "b"}},
+			{Name: "c3", Input: map[string]interface{}{"question": "c"}},
+		},
+	}
+	var maxConcurrent, current atomic.Int32
+	fp := &slowFakeProvider{
+		response:      provider.Response{Content: "ok", StopReason: "end_turn"},
+		maxConcurrent: &maxConcurrent,
+		current:       &current,
+	}
+
+	r := New(Config{Concurrency: 4, Timeout: 5 * time.Second})
+	start := time.Now()
+	result, err := r.Run(context.Background(), s, simplePrompt(), fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	// Three starts at 50ms spacing take at least 100ms.
+	if elapsed := time.Since(start); elapsed < 100*time.Millisecond {
+		t.Errorf("elapsed = %s, want >= 100ms", elapsed)
+	}
+	if result.RateLimit != 20 {
+		t.Errorf("RateLimit = %v, want 20", result.RateLimit)
+	}
+}
+
+// retryErrorProvider fails every call after reporting exhausted retries.
+type retryErrorProvider struct{}
+
+func (retryErrorProvider) Name() string { return "flaky" }
+func (retryErrorProvider) Complete(_ context.Context, _ *provider.Request) (*provider.Response, error) {
+	return nil, &provider.RetryError{
+		Provider: "flaky",
+		Retry:    provider.RetryStats{Retries: 3, Backoff: 3500 * time.Millisecond},
+		Err:      fmt.Errorf("HTTP 529: overloaded"),
+	}
+}
+
+func TestRun_RecordsRetryTelemetry(t *testing.T) {
+	fp := &fakeProvider{
+		responses: []provider.Response{
+			{
+				ToolCalls:  []provider.ToolCall{{ID: "t1", Name: "lookup"}},
+				StopReason: "tool_use",
+				Retry:      provider.RetryStats{Retries: 1, Backoff: 500 * time.Millisecond},
+			},
+			{
+				Content:    "done",
+				StopReason: "end_turn",
+				Retry:      provider.RetryStats{Retries: 2, Backoff: 1500 * time.Millisecond},
+			},
+		},
+	}
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), simpleSuite(), simplePrompt(), fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	retries, backoff := result.Cases[0].Trace.GetRetries()
+	if retries != 3 || backoff != 2*time.Second {
+		t.Errorf("retries = %d, backoff = %s; want 3, 2s", retries, backoff)
+	}
+
+	// Exhausted retries are still counted on the failed case.
+	result, err = r.Run(context.Background(), simpleSuite(), simplePrompt(), retryErrorProvider{}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	cr := result.Cases[0]
+	if cr.Error == "" {
+		t.Fatal("expected case error")
+	}
+	if retries, _ := cr.Trace.GetRetries(); retries != 3 {
+		t.Errorf("retries = %d, want 3", retries)
+	}
+}
+
+func TestRun_OnUnmockedToolEmpty(t *testing.T) {
+	fp := &fakeProvider{
+		responses: []provider.Response{
+			{ToolCalls: []provider.ToolCall{{ID: "tc1", Name: "explore"}}},
+			{Content: "done", StopReason: "end_turn"},
+		},
+	}
+	s := simpleSuite()
+	s.OnUnmockedTool = mock.UnmockedEmpty
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), s, simplePrompt(), fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	calls := result.Cases[0].Trace.GetToolCalls()
+	if len(calls) != 1 {
+		t.Fatalf("len(ToolCalls) = %d, want 1", len(calls))
+	}
+	if calls[0].Error != "" {
+		t.Errorf("unmocked call error = %q, want none", calls[0].Error)
+	}
+}
+
+func TestRun_DependsOnSkipsWhenDependencyFails(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name: "deps",
+		Cases: []suite.EvalCase{
+			{Name: "e2e", DependsOn: []string{"setup"}, Input: q},
+			{Name: "setup", Input: q},
+			{Name: "independent", Input: q},
+		},
+	}
+	p := &fakeProvider{responses: []provider.Response{{Content: "a"}, {Content: "b"}, {Content: "c"}}}
+	r := New(Config{
+		Concurrency: 1,
+		Timeout:     5 * time.Second,
+		Passed: func(_ *suite.EvalSuite, _ int, cr CaseResult) bool {
+			return cr.CaseName != "setup"
+		},
+	})
+	result, err := r.Run(context.Background(), s, simplePrompt(), p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	e2e := result.Cases[0]
+	if !e2e.Skipped || e2e.BlockedBy != "setup" {
+		t.Errorf("e2e: Skipped = %v, BlockedBy = %q, want skipped by setup", e2e.Skipped, e2e.BlockedBy)
+	}
+	if e2e.Trace != nil {
+		t.Error("e2e should not have run")
+	}
+	for _, cr := range result.Cases[1:] {
+		if cr.Skipped || cr.Error != "" {
+			t.Errorf("%s: Skipped = %v, Error = %q, want run", cr.CaseName, cr.Skipped, cr.Error)
+		}
+	}
+	if p.callIdx != 2 {
+		t.Errorf("provider calls = %d, want 2", p.callIdx)
+	}
+}
+
+func TestRun_DependsOnRunsWhenDependencyPasses(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name: "deps",
+		Cases: []suite.EvalCase{
+			{Name: "e2e", DependsOn: []string{"setup"}, Input: q},
+			{Name: "setup", Input: q},
+		},
+	}
+	var order []string
+	var mu sync.Mutex
+	r := New(Config{
+		Concurrency: 2,
+		Timeout:     5 * time.Second,
+		Passed: func(_ *suite.EvalSuite, _ int, cr CaseResult) bool {
+			mu.Lock()
+			order = append(order, cr.CaseName)
+			mu.Unlock()
+			return true
+		},
+	})
+	var maxConcurrent, current atomic.Int32
+	p := &slowFakeProvider{
+		delay:         10 * time.Millisecond,
+		response:      provider.Response{Content: "ok"},
+		maxConcurrent: &maxConcurrent,
+		current:       &current,
+	}
+	result, err := r.Run(context.Background(), s, simplePrompt(), p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if got := maxConcurrent.Load(); got != 1 {
+		t.Errorf("max concurrent = %d, want 1 (e2e must wait for setup)", got)
+	}
+	for _, cr := range result.Cases {
+		if cr.Skipped || cr.Error != "" {
+			t.Errorf("%s: Skipped = %v, Error = %q, want run", cr.CaseName, cr.Skipped, cr.Error)
+		}
+	}
+	// Only setup has dependents, so only its outcome is checked.
+	if len(order) != 1 || order[0] != "setup" {
+		t.Errorf("Passed called for %v, want [setup]", order)
+	}
+}
+
+func TestRun_FailFast(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name:  "fail-fast",
+		Cases: []suite.EvalCase{{Name: "a", Input: q}, {Name: "b", Input: q}, {Name: "c", Input: q}},
+	}
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, FailFast: true})
+	result, err := r.Run(context.Background(), s, simplePrompt(), &errorProvider{}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	var ran, skipped int
+	for _, cr := range result.Cases {
+		if cr.Skipped {
+			skipped++
+			if cr.BlockedBy != "" {
+				t.Errorf("%s: BlockedBy = %q, want empty for fail-fast", cr.CaseName, cr.BlockedBy)
+			}
+		} else {
+			ran++
+		}
+	}
+	if ran != 1 || skipped != 2 {
+		t.Errorf("ran %d, skipped %d; want 1 and 2", ran, skipped)
+	}
+}
+
+func TestRun_RetryBudgetExhausted(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name:  "budget",
+		Cases: []suite.EvalCase{{Name: "a", Input: q}, {Name: "b", Input: q}},
+	}
+	budget := &provider.RetryBudget{MaxRetries: 1}
+	budget.Take(0)
+	budget.Take(0)
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, RetryBudget: budget})
+	result, err := r.Run(context.Background(), s, simplePrompt(), &fakeProvider{}, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	for _, cr := range result.Cases {
+		if !cr.Skipped || !strings.Contains(cr.Error, "retry budget") {
+			t.Errorf("%s: Skipped = %v, Error = %q; want skipped for the retry budget", cr.CaseName, cr.Skipped, cr.Error)
+		}
+	}
+}
+
+// choiceRecordingProvider calls a tool on its first request, answers on
+// the next, and records the tool choice each request carried.
+type choiceRecordingProvider struct {
+	choices []*provider.ToolChoice
+}
+
+func (p *choiceRecordingProvider) Name() string { return "choice" }
+
+func (p *choiceRecordingProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	p.choices = append(p.choices, req.ToolChoice)
+	if len(p.choices) == 1 {
+		return &provider.Response{ToolCalls: []provider.ToolCall{{ID: "1", Name: "search"}}}, nil
+	}
+	return &provider.Response{Content: "done"}, nil
+}
+
+func TestRun_ToolChoiceFirstTurnOnly(t *testing.T) {
+	pv := simplePrompt()
+	pv.Tools = []prompt.ToolDefinition{{Name: "search"}}
+	pv.ToolChoice = "search"
+	s := &suite.EvalSuite{
+		Name: "choice",
+		Cases: []suite.EvalCase{{
+			Name:  "forced",
+			Input: map[string]interface{}{"question": "q"},
+			Mocks: []mock.MockConfig{{ToolName: "search", DefaultResponse: &mock.MockResponse{Content: "found"}}},
+		}},
+	}
+	p := &choiceRecordingProvider{}
+	result, err := New(Config{Concurrency: 1, Timeout: 5 * time.Second}).Run(context.Background(), s, pv, p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if cr := result.Cases[0]; cr.Error != "" {
+		t.Fatalf("case error: %s", cr.Error)
+	}
+	if len(p.choices) != 2 {
+		t.Fatalf("got %d requests, want 2", len(p.choices))
+	}
+	if p.choices[0] == nil || p.choices[0].Name != "search" {
+		t.Errorf("first request tool_choice = %+v, want search", p.choices[0])
+	}
+	if p.choices[1] != nil {
+		t.Errorf("second request tool_choice = %+v, want nil", p.choices[1])
+	}
+}
+
+func TestRun_ReaskInvalid(t *testing.T) {
+	validate := func(_ suite.EvalCase, output string) error {
+		if !strings.HasPrefix(output, "{") {
+			return fmt.Errorf("output is not a JSON object")
+		}
+		return nil
+	}
+	newSuite := func(reask bool) *suite.EvalSuite {
+		return &suite.EvalSuite{
+			Name:         "reask",
+			ReaskInvalid: reask,
+			Cases:        []suite.EvalCase{{Name: "json", Input: map[string]interface{}{"question": "q"}}},
+		}
+	}
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, ValidateOutput: validate})
+
+	p := &fakeProvider{responses: []provider.Response{{Content: "sure"}, {Content: `{"a":1}`}}}
+	result, err := r.Run(context.Background(), newSuite(true), simplePrompt(), p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	cr := result.Cases[0]
+	if cr.FinalResponse != `{"a":1}` {
+		t.Errorf("FinalResponse = %q, want the corrected output", cr.FinalResponse)
+	}
+	if reasks := cr.Trace.GetReasks(); len(reasks) != 1 || reasks[0] != "output is not a JSON object" {
+		t.Errorf("Reasks = %v, want the validation error", reasks)
+	}
+	msgs := cr.Trace.GetMessages()
+	if len(msgs) != 4 || msgs[2].Role != "user" || !strings.Contains(msgs[2].Content, "invalid because output is not a JSON object") {
+		t.Errorf("messages = %+v, want the corrective turn before the final answer", msgs)
+	}
+
+	// Without reask_invalid the first output stands.
+	p = &fakeProvider{responses: []provider.Response{{Content: "sure"}}}
+	result, _ = r.Run(context.Background(), newSuite(false), simplePrompt(), p, nil)
+	if cr := result.Cases[0]; cr.FinalResponse != "sure" || len(cr.Trace.GetReasks()) != 0 {
+		t.Errorf("FinalResponse = %q, reasks = %v; want no re-ask", cr.FinalResponse, cr.Trace.GetReasks())
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	ok := &fakeProvider{responses: []provider.Response{{Content: "OK"}}}
+	if err := Preflight(context.Background(), ok, "m", time.Second); err != nil {
+		t.Errorf("Preflight() error: %v", err)
+	}
+
+	err := Preflight(context.Background(), &errorProvider{}, "bad-model", time.Second)
+	if err == nil {
+		t.Fatal("expected error from failing provider")
+	}
+	if !strings.Contains(err.Error(), `model "bad-model"`) || !strings.Contains(err.Error(), "connection refused") {
+		t.Errorf("error = %q, want model name and cause", err)
+	}
+}
+
+// modelRecordingProvider answers every request and records the model it
+// was sent with.
+type modelRecordingProvider struct {
+	name   string
+	mu     sync.Mutex
+	models []string
+}
+
+func (m *modelRecordingProvider) Name() string { return m.name }
+func (m *modelRecordingProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.models = append(m.models, req.Model)
+	return &provider.Response{Content: "ok"}, nil
+}
+
+func TestRun_CaseProviderOverride(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name: "mixed",
+		Cases: []suite.EvalCase{
+			{Name: "default", Input: q},
+			{Name: "model-only", Input: q, Model: "small-model"},
+			{Name: "vision", Input: q, Provider: "vision", Model: "vision-large"},
+			{Name: "vision-default", Input: q, Provider: "vision"},
+			{Name: "unknown", Input: q, Provider: "missing"},
+		},
+	}
+	base := &modelRecordingProvider{name: "base"}
+	vision := &modelRecordingProvider{name: "vision"}
+	r := New(Config{
+		Concurrency: 1,
+		Timeout:     5 * time.Second,
+		Model:       "base-model",
+		Providers: func(name string) (provider.Provider, string, error) {
+			if name == "vision" {
+				return vision, "vision-default-model", nil
+			}
+			return nil, "", fmt.Errorf("provider %q not found in config", name)
+		},
+	})
+	result, err := r.Run(context.Background(), s, simplePrompt(), base, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	wantModels := []string{"base-model", "small-model", "vision-large", "vision-default-model"}
+	for i, want := range wantModels {
+		if got := result.Cases[i].Model; got != want {
+			t.Errorf("%s: Model = %q, want %q", result.Cases[i].CaseName, got, want)
+		}
+	}
+	if len(base.models) != 2 || len(vision.models) != 2 {
+		t.Errorf("base got %v, vision got %v; want 2 requests each", base.models, vision.models)
+	}
+	if unknown := result.Cases[4]; unknown.ErrorCategory != CategoryProvider || !strings.Contains(unknown.Error, `"missing"`) {
+		t.Errorf("unknown provider: Error = %q, ErrorCategory = %q", unknown.Error, unknown.ErrorCategory)
+	}
+}
+
+func TestRun_MeterTalliesEveryCall(t *testing.T) {
+	q := map[string]interface{}{"question": "q"}
+	s := &suite.EvalSuite{
+		Name: "metered",
+		Cases: []suite.EvalCase{
+			{Name: "tools", Input: q, Mocks: []mock.MockConfig{{ToolName: "search", DefaultResponse: &mock.MockResponse{Content: "r"}}}},
+		},
+	}
+	p := &fakeProvider{responses: []provider.Response{
+		{ToolCalls: []provider.ToolCall{{ID: "1", Name: "search"}}, Usage: provider.Usage{InputTokens: 600_000, OutputTokens: 10}},
+		{Content: "done", Usage: provider.Usage{InputTokens: 600_000, OutputTokens: 20}},
+	}}
+
+	var warnings []float64
+	meter := &Meter{WarnCost: 2.0, OnWarn: func(cost float64) { warnings = append(warnings, cost) }}
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, Model: "gpt-4o", Meter: meter})
+	if _, err := r.Run(context.Background(), s, simplePrompt(), p, nil); err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+
+	if in, out := meter.Tokens(); in != 1_200_000 || out != 30 {
+		t.Errorf("Tokens() = %d, %d; want 1200000, 30", in, out)
+	}
+	if meter.Calls() != 2 {
+		t.Errorf("Calls() = %d, want 2", meter.Calls())
+	}
+	want := provider.EstimateCost("gpt-4o", provider.Usage{InputTokens: 1_200_000, OutputTokens: 30})
+	if diff := meter.Cost() - want; diff > 1e-6 || diff < -1e-6 {
+		t.Errorf("Cost() = %v, want %v", meter.Cost(), want)
+	}
+	// 600k gpt-4o input tokens cost $1.50, so only the second call crosses $2.
+	if len(warnings) != 1 {
+		t.Errorf("OnWarn called %d times, want 1", len(warnings))
+	}
+}
+
+func TestRun_OnToolCall(t *testing.T) {
+	fp := &fakeProvider{
+		responses: []provider.Response{
+			{
+				StopReason: "tool_use",
+				ToolCalls: []provider.ToolCall{
+					{ID: "tc1", Name: "write_file", Parameters: map[string]interface{}{"code": "func {"}},
+				},
+			},
+			{Content: "Written.", StopReason: "end_turn"},
+		},
+	}
+	s := &suite.EvalSuite{
+		Name: "hook-suite",
+		Cases: []suite.EvalCase{{
+			ID:    "c1",
+			Name:  "write",
+			Input: map[string]interface{}{"question": "Write code"},
+			Mocks: []mock.MockConfig{
+				{ToolName: "write_file", DefaultResponse: &mock.MockResponse{Content: "ok"}},
+			},
+		}},
+	}
+	pv := &prompt.PromptVariant{
+		Name:  "tool-prompt",
+		User:  "{{.question}}",
+		Tools: []prompt.ToolDefinition{{Name: "write_file", Description: "Write a file"}},
+	}
+
+	var calls []string
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, OnToolCall: func(s *suite.EvalSuite, c suite.EvalCase, call provider.ToolCall, result trace.ToolCallTrace) error {
+		calls = append(calls, s.Name+"/"+c.Name+"/"+call.Name+"="+result.Response)
+		return fmt.Errorf("does not compile")
+	}})
+	result, err := r.Run(context.Background(), s, pv, fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if want := []string{"hook-suite/write/write_file=ok"}; !slices.Equal(calls, want) {
+		t.Errorf("hook calls = %v, want %v", calls, want)
+	}
+	cr := result.Cases[0]
+	if got := cr.Trace.ToolViolations(); !slices.Equal(got, []string{"write_file: does not compile"}) {
+		t.Errorf("ToolViolations() = %v, want the hook's error", got)
+	}
+	if r.passed(s, 0, cr) {
+		t.Error("case with a tool violation counted as passed")
+	}
+}
+
+func TestRun_CheckContextWindow(t *testing.T) {
+	s := simpleSuite()
+	s.Cases[0].Input["question"] = strings.Repeat("why ", 9000)
+	fp := &fakeProvider{responses: []provider.Response{{Content: "because"}}}
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, Model: "gpt-4", CheckContextWindow: true})
+	result, err := r.Run(context.Background(), s, simplePrompt(), fp, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	cr := result.Cases[0]
+	if cr.ErrorCategory != CategoryConfig || !strings.Contains(cr.Error, "context window") {
+		t.Errorf("case error = %q (%s), want a context window config error", cr.Error, cr.ErrorCategory)
+	}
+	if fp.callIdx != 0 {
+		t.Errorf("provider called %d times, want 0", fp.callIdx)
+	}
+
+	s.Cases[0].Input["question"] = "What is 2+2?"
+	result, _ = r.Run(context.Background(), s, simplePrompt(), fp, nil)
+	if cr := result.Cases[0]; cr.Error != "" || cr.FinalResponse != "because" {
+		t.Errorf("small case: error %q, response %q", cr.Error, cr.FinalResponse)
+	}
+}
+
+func TestRun_SecretDetectedIsConfigError(t *testing.T) {
+	p := provider.NewSecretGuard(&fakeProvider{}, provider.DefaultSecretPatterns)
+	s := simpleSuite()
+	s.Cases[0].Input["question"] = "use AKIA" + strings.Repeat("X", 16)
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), s, simplePrompt(), p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	cr := result.Cases[0]
+	if cr.ErrorCategory != CategoryConfig || !strings.Contains(cr.Error, "aws_access_key") {
+		t.Errorf("case error = %q (%s), want a secret config error", cr.Error, cr.ErrorCategory)
+	}
+}
+
+// loopingProvider calls the same tool forever.
+type loopingProvider struct{ calls int }
+
+func (l *loopingProvider) Name() string { return "looping" }
+
+func (l *loopingProvider) Complete(_ context.Context, _ *provider.Request) (*provider.Response, error) {
+	l.calls++
+	return &provider.Response{
+		Content:    strings.Repeat("thinking ", 100),
+		StopReason: "tool_use",
+		ToolCalls:  []provider.ToolCall{{ID: fmt.Sprintf("tc%d", l.calls), Name: "search"}},
+	}, nil
+}
+
+func TestRun_TraceLimitExceeded(t *testing.T) {
+	s := simpleSuite()
+	s.Cases[0].Mocks = []mock.MockConfig{
+		{ToolName: "search", DefaultResponse: &mock.MockResponse{Content: strings.Repeat("result ", 1000)}},
+	}
+	p := &loopingProvider{}
+
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second, MaxIterations: 100, TraceLimits: trace.Limits{MaxBytes: 20_000}})
+	result, err := r.Run(context.Background(), s, simplePrompt(), p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	cr := result.Cases[0]
+	if cr.ErrorCategory != CategoryTraceLimit || !strings.Contains(cr.Error, "trace limit exceeded") {
+		t.Errorf("case error = %q (%s), want a trace limit error", cr.Error, cr.ErrorCategory)
+	}
+	if p.calls >= 100 {
+		t.Errorf("provider called %d times; the loop should stop at the limit", p.calls)
+	}
+	if cr.Trace.LimitExceeded == "" {
+		t.Error("trace does not record the exceeded limit")
+	}
+}
+
+// metadataRecordingProvider records the metadata of each request.
+type metadataRecordingProvider struct {
+	mu       sync.Mutex
+	metadata []map[string]string
+}
+
+func (m *metadataRecordingProvider) Name() string { return "metadata" }
+
+func (m *metadataRecordingProvider) Complete(_ context.Context, req *provider.Request) (*provider.Response, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.metadata = append(m.metadata, req.Metadata)
+	return &provider.Response{Content: "ok"}, nil
+}
+
+func TestRun_TagsRequestsWithSuiteAndRun(t *testing.T) {
+	p := &metadataRecordingProvider{}
+	r := New(Config{Concurrency: 1, Timeout: 5 * time.Second})
+	result, err := r.Run(context.Background(), simpleSuite(), simplePrompt(), p, nil)
+	if err != nil {
+		t.Fatalf("Run() error: %v", err)
+	}
+	if result.RunID == "" || !strings.HasSuffix(result.RunID, "-"+result.SuiteName) {
+		t.Errorf("RunID = %q, want one ending in the suite name", result.RunID)
+	}
+	want := map[string]string{"suite": result.SuiteName, "run_id": result.RunID}
+	if len(p.metadata) == 0 || !maps.Equal(p.metadata[0], want) {
+		t.Errorf("request metadata = %v, want %v", p.metadata, want)
+	}
+}
@@ -0,0 +1,226 @@
+package suite
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"gopkg.in/yaml.v3"
+)
+
+// Builder constructs a suite in Go, for suites generated from data such as
+// the rows of a database table:
+//
+//	s, err := suite.New("products").Prompt("support").
+//		Case("widget").Input("product", "Widget").
+//		Judge(suite.JudgeConfig{Type: "contains", Value: "Widget"}).
+//		Build()
+//
+// Build returns a suite ready to run, and YAML the suite file it
+// corresponds to.
+type Builder struct {
+	s EvalSuite
+}
+
+// New starts a suite with the given name.
+func New(name string) *Builder {
+	return &Builder{s: EvalSuite{Name: name}}
+}
+
+// Description sets the suite's description.
+func (b *Builder) Description(d string) *Builder {
+	b.s.Description = d
+	return b
+}
+
+// Prompt sets the name of the prompt the suite's cases run with.
+func (b *Builder) Prompt(name string) *Builder {
+	b.s.Prompt = name
+	return b
+}
+
+// DefaultJudges adds judges for the cases that set none of their own.
+func (b *Builder) DefaultJudges(j ...JudgeConfig) *Builder {
+	b.s.DefaultJudges = append(b.s.DefaultJudges, j...)
+	return b
+}
+
+// DefaultMocks adds mocks merged into every case's mocks.
+func (b *Builder) DefaultMocks(m ...mock.MockConfig) *Builder {
+	b.s.DefaultMocks = append(b.s.DefaultMocks, m...)
+	return b
+}
+
+// MaxConcurrency caps how many of the suite's cases run at once.
+func (b *Builder) MaxConcurrency(n int) *Builder {
+	b.s.MaxConcurrency = n
+	return b
+}
+
+// PassCriteria sets the suite's acceptance criteria.
+func (b *Builder) PassCriteria(pc PassCriteria) *Builder {
+	b.s.PassCriteria = &pc
+	return b
+}
+
+// Case adds a case with the given name and returns a builder for it.
+func (b *Builder) Case(name string) *CaseBuilder {
+	b.s.Cases = append(b.s.Cases, EvalCase{Name: name})
+	return &CaseBuilder{b: b, i: len(b.s.Cases) - 1}
+}
+
+// Build validates the suite and returns it with defaults applied, as Load
+// would return the equivalent suite file. The builder may be reused.
+func (b *Builder) Build() (*EvalSuite, error) {
+	if err := b.s.Validate(); err != nil {
+		return nil, err
+	}
+	s := b.definition()
+	s.applyDefaults()
+	return s, nil
+}
+
+// YAML returns the suite file for the suite, with unset fields left out.
+// Loading it gives the suite Build returns.
+func (b *Builder) YAML() ([]byte, error) {
+	if err := b.s.Validate(); err != nil {
+		return nil, err
+	}
+	data, err := yaml.Marshal(b.definition())
+	if err != nil {
+		return nil, fmt.Errorf("encoding suite %q: %w", b.s.Name, err)
+	}
+	return data, nil
+}
+
+// definition returns a copy of the suite as built so far, whose cases can
+// be changed without affecting the builder.
+func (b *Builder) definition() *EvalSuite {
+	s := b.s
+	s.Cases = make([]EvalCase, len(b.s.Cases))
+	for i, c := range b.s.Cases {
+		c.Input = cloneInput(c.Input)
+		s.Cases[i] = c
+	}
+	return &s
+}
+
+func cloneInput(in map[string]interface{}) map[string]interface{} {
+	if in == nil {
+		return nil
+	}
+	out := make(map[string]interface{}, len(in))
+	for k, v := range in {
+		out[k] = v
+	}
+	return out
+}
+
+// CaseBuilder sets the fields of one case of a Builder's suite. Case,
+// Build, and YAML continue with the suite, so a whole suite can be built
+// in one chain.
+type CaseBuilder struct {
+	b *Builder
+	i int
+}
+
+func (cb *CaseBuilder) c() *EvalCase { return &cb.b.s.Cases[cb.i] }
+
+// ID sets the case's stable identifier.
+func (cb *CaseBuilder) ID(id string) *CaseBuilder {
+	cb.c().ID = id
+	return cb
+}
+
+// Input sets the prompt template variable key to value.
+func (cb *CaseBuilder) Input(key string, value interface{}) *CaseBuilder {
+	c := cb.c()
+	if c.Input == nil {
+		c.Input = make(map[string]interface{})
+	}
+	c.Input[key] = value
+	return cb
+}
+
+// Context sets the extra context given to the case's judges.
+func (cb *CaseBuilder) Context(ctx string) *CaseBuilder {
+	cb.c().Context = ctx
+	return cb
+}
+
+// Judge adds judges to the case. They replace the suite's default judges
+// unless AppendJudges is set.
+func (cb *CaseBuilder) Judge(j ...JudgeConfig) *CaseBuilder {
+	cb.c().Judges = append(cb.c().Judges, j...)
+	return cb
+}
+
+// AppendJudges runs the case's judges in addition to the suite's defaults.
+func (cb *CaseBuilder) AppendJudges() *CaseBuilder {
+	cb.c().JudgesMode = JudgesAppend
+	return cb
+}
+
+// Mock adds tool mocks to the case.
+func (cb *CaseBuilder) Mock(m ...mock.MockConfig) *CaseBuilder {
+	cb.c().Mocks = append(cb.c().Mocks, m...)
+	return cb
+}
+
+// ExpectedOutput sets the reference answer judges compare against.
+func (cb *CaseBuilder) ExpectedOutput(out string) *CaseBuilder {
+	cb.c().ExpectedOutput = out
+	return cb
+}
+
+// ExpectedTools adds tools the case is expected to call, in order.
+func (cb *CaseBuilder) ExpectedTools(names ...string) *CaseBuilder {
+	cb.c().ExpectedTools = append(cb.c().ExpectedTools, names...)
+	return cb
+}
+
+// AllowedTools restricts the tools the case may call.
+func (cb *CaseBuilder) AllowedTools(names ...string) *CaseBuilder {
+	cb.c().AllowedTools = append(cb.c().AllowedTools, names...)
+	return cb
+}
+
+// Tags adds tags to the case.
+func (cb *CaseBuilder) Tags(tags ...string) *CaseBuilder {
+	cb.c().Tags = append(cb.c().Tags, tags...)
+	return cb
+}
+
+// Timeout sets the case's timeout.
+func (cb *CaseBuilder) Timeout(d time.Duration) *CaseBuilder {
+	cb.c().Timeout = d
+	return cb
+}
+
+// Threshold sets the composite score the case needs to pass.
+func (cb *CaseBuilder) Threshold(t float64) *CaseBuilder {
+	cb.c().Threshold = t
+	return cb
+}
+
+// DependsOn adds cases that must pass before this one runs.
+func (cb *CaseBuilder) DependsOn(names ...string) *CaseBuilder {
+	cb.c().DependsOn = append(cb.c().DependsOn, names...)
+	return cb
+}
+
+// Model runs the case on another provider or model. Either may be empty.
+func (cb *CaseBuilder) Model(provider, model string) *CaseBuilder {
+	c := cb.c()
+	c.Provider, c.Model = provider, model
+	return cb
+}
+
+// Case adds another case to the suite.
+func (cb *CaseBuilder) Case(name string) *CaseBuilder { return cb.b.Case(name) }
+
+// Build builds the suite; see Builder.Build.
+func (cb *CaseBuilder) Build() (*EvalSuite, error) { return cb.b.Build() }
+
+// YAML encodes the suite; see Builder.YAML.
+func (cb *CaseBuilder) YAML() ([]byte, error) { return cb.b.YAML() }
@@ -0,0 +1,74 @@
+package suite
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+)
+
+func TestBuilder_BuildAndYAML(t *testing.T) {
+	b := New("products").Prompt("support").
+		DefaultJudges(JudgeConfig{Type: "contains", Value: "thanks"}).
+		DefaultMocks(mock.MockConfig{
+			ToolName:        "lookup",
+			DefaultResponse: &mock.MockResponse{Content: "{}", Delay: 50 * time.Millisecond},
+		})
+	for _, p := range []string{"Widget", "Gadget"} {
+		b.Case("describe-"+strings.ToLower(p)).ID(p).Input("product", p).Tags("catalog")
+	}
+	cb := b.Case("price").Input("product", "Widget").
+		Judge(JudgeConfig{Type: "regex", Value: `\$\d+`}).AppendJudges().
+		Timeout(time.Minute).DependsOn("describe-widget")
+
+	s, err := cb.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Cases) != 3 || s.Cases[1].Input["product"] != "Gadget" {
+		t.Fatalf("cases = %+v", s.Cases)
+	}
+	if got := len(s.Cases[0].Judges); got != 1 {
+		t.Errorf("case 0 judges = %d, want the default", got)
+	}
+	if got := len(s.Cases[2].Judges); got != 2 {
+		t.Errorf("case 2 judges = %d, want default plus its own", got)
+	}
+	if len(s.Cases[2].Mocks) != 1 {
+		t.Errorf("case 2 mocks = %+v, want the default mock", s.Cases[2].Mocks)
+	}
+
+	data, err := b.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "description") {
+		t.Errorf("YAML includes unset fields:\n%s", data)
+	}
+	loaded, err := Load(writeTempFile(t, t.TempDir(), "products.yaml", string(data)))
+	if err != nil {
+		t.Fatalf("loading marshaled suite: %v\n%s", err, data)
+	}
+	for i := range loaded.Cases {
+		loaded.Cases[i].Source = ""
+	}
+	if !reflect.DeepEqual(loaded, s) {
+		t.Errorf("loaded suite differs from built suite:\nloaded %+v\nbuilt  %+v\n%s", loaded, s, data)
+	}
+
+	// Building does not change the builder's cases.
+	if again, _ := b.Build(); !reflect.DeepEqual(again, s) {
+		t.Error("second Build differs from the first")
+	}
+}
+
+func TestBuilder_Invalid(t *testing.T) {
+	if _, err := New("empty").Build(); err == nil {
+		t.Error("Build of a suite without cases succeeded")
+	}
+	if _, err := New("deps").Case("a").DependsOn("missing").YAML(); err == nil {
+		t.Error("YAML of a suite with an unknown dependency succeeded")
+	}
+}
@@ -0,0 +1,102 @@
+package suite
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+)
+
+// defaultFixturesDir is the fixtures directory used when a suite names
+// none.
+const defaultFixturesDir = "fixtures"
+
+// loadFixtures fills in the content of every mock response that names a
+// content_file, in the default mocks, case mocks, and agent judge mocks.
+// Paths are relative to dir, the suite file's directory, and must stay
+// within the suite's fixtures directory. Each file is read once.
+func (s *EvalSuite) loadFixtures(dir string) error {
+	fixtures := s.Fixtures
+	if fixtures == "" {
+		fixtures = defaultFixturesDir
+	}
+	root := fixtures
+	if !filepath.IsAbs(root) {
+		root = filepath.Join(dir, fixtures)
+	}
+	cache := make(map[string]string)
+
+	load := func(where string, mocks []mock.MockConfig) error {
+		for i := range mocks {
+			responses := make([]*mock.MockResponse, 0, len(mocks[i].Responses)+1)
+			for j := range mocks[i].Responses {
+				responses = append(responses, &mocks[i].Responses[j])
+			}
+			if mocks[i].DefaultResponse != nil {
+				responses = append(responses, mocks[i].DefaultResponse)
+			}
+			for _, r := range responses {
+				if r.ContentFile == "" {
+					continue
+				}
+				if r.Content != "" {
+					return fmt.Errorf("%s: mock for tool %q sets both content and content_file", where, mocks[i].ToolName)
+				}
+				content, err := readFixture(dir, root, r.ContentFile, cache)
+				if err != nil {
+					return fmt.Errorf("%s: mock for tool %q: %w", where, mocks[i].ToolName, err)
+				}
+				r.Content = content
+			}
+		}
+		return nil
+	}
+
+	if err := load("default_mocks", s.DefaultMocks); err != nil {
+		return err
+	}
+	for i := range s.DefaultJudges {
+		if err := load("default_judges", s.DefaultJudges[i].Mocks); err != nil {
+			return err
+		}
+	}
+	for i := range s.Cases {
+		c := &s.Cases[i]
+		where := fmt.Sprintf("case %q", c.Name)
+		if err := load(where, c.Mocks); err != nil {
+			return err
+		}
+		for _, judges := range [][]JudgeConfig{c.Judges, c.AdditionalJudges} {
+			for j := range judges {
+				if err := load(where, judges[j].Mocks); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// readFixture reads the fixture at name, relative to dir, rejecting paths
+// that leave root.
+func readFixture(dir, root, name string, cache map[string]string) (string, error) {
+	path := filepath.Join(dir, name)
+	if filepath.IsAbs(name) {
+		path = filepath.Clean(name)
+	}
+	rel, err := filepath.Rel(root, path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("content_file %q is outside the fixtures directory %s", name, root)
+	}
+	if content, ok := cache[path]; ok {
+		return content, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("reading content_file: %w", err)
+	}
+	cache[path] = string(data)
+	return string(data), nil
+}
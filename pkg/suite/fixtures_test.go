@@ -0,0 +1,95 @@
+package suite
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLoad_Fixtures(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "fixtures"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTempFile(t, filepath.Join(dir, "fixtures"), "handler.go", "package handler\n")
+	writeTempFile(t, filepath.Join(dir, "fixtures"), "results.json", `[{"id": 1}]`)
+	path := writeTempFile(t, dir, "suite.yaml", `name: fixtures
+default_mocks:
+  - tool_name: search
+    default_response:
+      content_file: fixtures/results.json
+cases:
+  - name: read
+    input: {q: "x"}
+    mocks:
+      - tool_name: read_file
+        responses:
+          - content_file: fixtures/handler.go
+          - content: "inline"
+`)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+	mocks := s.Cases[0].Mocks
+	if len(mocks) != 2 {
+		t.Fatalf("len(Mocks) = %d, want 2", len(mocks))
+	}
+	if got := mocks[0].Responses[0].Content; got != "package handler\n" {
+		t.Errorf("read_file content = %q, want the fixture", got)
+	}
+	if got := mocks[0].Responses[1].Content; got != "inline" {
+		t.Errorf("inline content = %q, want %q", got, "inline")
+	}
+	if got := mocks[1].DefaultResponse.Content; got != `[{"id": 1}]` {
+		t.Errorf("default search content = %q, want the fixture", got)
+	}
+}
+
+func TestLoad_FixturesErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		mock    string
+		wantErr string
+	}{
+		{
+			name:    "missing file",
+			mock:    "{content_file: fixtures/missing.txt}",
+			wantErr: "reading content_file",
+		},
+		{
+			name:    "outside fixtures",
+			mock:    "{content_file: secrets.txt}",
+			wantErr: "outside the fixtures directory",
+		},
+		{
+			name:    "escaping fixtures",
+			mock:    "{content_file: fixtures/../secrets.txt}",
+			wantErr: "outside the fixtures directory",
+		},
+		{
+			name:    "content and content_file",
+			mock:    "{content: x, content_file: fixtures/a.txt}",
+			wantErr: "both content and content_file",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTempFile(t, dir, "secrets.txt", "secret")
+			path := writeTempFile(t, dir, "suite.yaml", `name: bad
+cases:
+  - name: c1
+    mocks:
+      - tool_name: read_file
+        default_response: `+tt.mock+`
+`)
+			_, err := Load(path)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("Load() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,218 @@
+package suite
+
+import (
+	"fmt"
+	"hash/fnv"
+	"math"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Sampling strategies for SampleSpec.Strategy.
+const (
+	SampleRandom     = "random"
+	SampleStratified = "stratified"
+)
+
+// SampleSpec selects a subset of a suite's cases for a quick run.
+type SampleSpec struct {
+	// Strategy is SampleRandom or SampleStratified.
+	Strategy string
+
+	// Fraction is the share of cases to keep, in (0, 1]. It is ignored
+	// when Count is set.
+	Fraction float64
+
+	// Count is the number of cases to keep.
+	Count int
+}
+
+// ParseSampleSpec parses a --sample value of the form
+// "[strategy:]amount", where amount is a percentage ("20%") or a case count
+// ("15") and strategy is "random" (the default) or "stratified".
+func ParseSampleSpec(spec string) (SampleSpec, error) {
+	var sp SampleSpec
+	amount := spec
+	if strategy, rest, ok := strings.Cut(spec, ":"); ok {
+		sp.Strategy, amount = strategy, rest
+	}
+	switch sp.Strategy {
+	case "":
+		sp.Strategy = SampleRandom
+	case SampleRandom, SampleStratified:
+	default:
+		return SampleSpec{}, fmt.Errorf("unknown sampling strategy %q (want %q or %q)", sp.Strategy, SampleRandom, SampleStratified)
+	}
+
+	if pct, ok := strings.CutSuffix(amount, "%"); ok {
+		f, err := strconv.ParseFloat(pct, 64)
+		if err != nil || f <= 0 || f > 100 {
+			return SampleSpec{}, fmt.Errorf("invalid sample percentage %q: must be in (0, 100]", amount)
+		}
+		sp.Fraction = f / 100
+		return sp, nil
+	}
+	n, err := strconv.Atoi(amount)
+	if err != nil || n <= 0 {
+		return SampleSpec{}, fmt.Errorf("invalid sample size %q: want a percentage like 20%% or a positive case count", amount)
+	}
+	sp.Count = n
+	return sp, nil
+}
+
+// String formats the spec the way ParseSampleSpec accepts it.
+func (sp SampleSpec) String() string {
+	if sp.Count > 0 {
+		return fmt.Sprintf("%s:%d", sp.Strategy, sp.Count)
+	}
+	return fmt.Sprintf("%s:%s%%", sp.Strategy, strconv.FormatFloat(sp.Fraction*100, 'f', -1, 64))
+}
+
+// Sample returns a new suite holding a subset of the cases chosen with
+// rng, in their original order. A stratified sample groups cases by their
+// first tag (untagged cases form one group) and takes from every group in
+// proportion to its size; each group keeps at least one case as long as
+// the sample is large enough to cover every group. Cases that sampled
+// cases depend on are kept too, so the sample remains a valid suite.
+func (s *EvalSuite) Sample(sp SampleSpec, rng *rand.Rand) *EvalSuite {
+	total := len(s.Cases)
+	target := sp.Count
+	if target == 0 {
+		target = max(1, int(math.Round(sp.Fraction*float64(total))))
+	}
+	if target >= total {
+		return s
+	}
+
+	var picked []int
+	if sp.Strategy == SampleStratified {
+		picked = sampleStrata(s.Cases, target, rng)
+	} else {
+		picked = rng.Perm(total)[:target]
+	}
+
+	keep := make(map[string]bool, len(picked))
+	for _, i := range picked {
+		s.keepWithDependencies(s.Cases[i].Name, keep)
+	}
+	return s.filter(func(c EvalCase) bool { return keep[c.Name] })
+}
+
+// keepWithDependencies marks the named case and, transitively, the cases
+// it depends on.
+func (s *EvalSuite) keepWithDependencies(name string, keep map[string]bool) {
+	if keep[name] {
+		return
+	}
+	keep[name] = true
+	for _, c := range s.Cases {
+		if c.Name == name {
+			for _, dep := range c.DependsOn {
+				s.keepWithDependencies(dep, keep)
+			}
+		}
+	}
+}
+
+// sampleStrata picks target case indices, apportioning them across the
+// cases' first-tag groups.
+func sampleStrata(cases []EvalCase, target int, rng *rand.Rand) []int {
+	var keys []string
+	groups := make(map[string][]int)
+	for i, c := range cases {
+		key := ""
+		if len(c.Tags) > 0 {
+			key = c.Tags[0]
+		}
+		if _, ok := groups[key]; !ok {
+			keys = append(keys, key)
+		}
+		groups[key] = append(groups[key], i)
+	}
+
+	quota := make(map[string]int, len(keys))
+	if target < len(keys) {
+		// Too small to cover every group: take one case from each of
+		// the largest groups.
+		bySize := append([]string(nil), keys...)
+		sort.SliceStable(bySize, func(a, b int) bool { return len(groups[bySize[a]]) > len(groups[bySize[b]]) })
+		for _, k := range bySize[:target] {
+			quota[k] = 1
+		}
+	} else {
+		// One case per group, then the rest by largest remainder in
+		// proportion to what each group has left.
+		rest := target - len(keys)
+		remaining := len(cases) - len(keys)
+		type share struct {
+			key  string
+			frac float64
+		}
+		var shares []share
+		assigned := 0
+		for _, k := range keys {
+			exact := float64(rest) * float64(len(groups[k])-1) / float64(max(remaining, 1))
+			n := int(exact)
+			quota[k] = 1 + n
+			assigned += n
+			shares = append(shares, share{k, exact - float64(n)})
+		}
+		sort.SliceStable(shares, func(a, b int) bool { return shares[a].frac > shares[b].frac })
+		for i := 0; assigned < rest; i++ {
+			quota[shares[i].key]++
+			assigned++
+		}
+	}
+
+	var picked []int
+	for _, k := range keys {
+		g := groups[k]
+		for _, j := range rng.Perm(len(g))[:quota[k]] {
+			picked = append(picked, g[j])
+		}
+	}
+	return picked
+}
+
+// Split divides the suite into a dev split of about fraction of its cases
+// and a test split of the rest, so a prompt tuned on the dev cases can be
+// checked on cases it was not tuned on. Cases are ranked by a hash of
+// their ID, or their name when they have none, so a case keeps its split
+// when other cases are added or removed. Each split also keeps the cases
+// its cases depend on. A split with no cases is nil.
+func (s *EvalSuite) Split(fraction float64) (dev, test *EvalSuite) {
+	order := make([]int, len(s.Cases))
+	keys := make([]uint64, len(s.Cases))
+	for i, c := range s.Cases {
+		key := c.ID
+		if key == "" {
+			key = c.Name
+		}
+		h := fnv.New64a()
+		h.Write([]byte(key))
+		order[i], keys[i] = i, h.Sum64()
+	}
+	sort.SliceStable(order, func(a, b int) bool { return keys[order[a]] < keys[order[b]] })
+
+	n := int(math.Round(fraction * float64(len(s.Cases))))
+	if n == 0 && fraction > 0 && len(s.Cases) > 0 {
+		n = 1
+	}
+	inDev, inTest := make(map[string]bool), make(map[string]bool)
+	for rank, i := range order {
+		if rank < n {
+			s.keepWithDependencies(s.Cases[i].Name, inDev)
+		} else {
+			s.keepWithDependencies(s.Cases[i].Name, inTest)
+		}
+	}
+	if len(inDev) > 0 {
+		dev = s.filter(func(c EvalCase) bool { return inDev[c.Name] })
+	}
+	if len(inTest) > 0 {
+		test = s.filter(func(c EvalCase) bool { return inTest[c.Name] })
+	}
+	return dev, test
+}
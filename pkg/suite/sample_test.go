@@ -0,0 +1,140 @@
+package suite
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"testing"
+)
+
+func TestParseSampleSpec(t *testing.T) {
+	tests := []struct {
+		spec    string
+		want    SampleSpec
+		wantErr bool
+	}{
+		{spec: "20%", want: SampleSpec{Strategy: SampleRandom, Fraction: 0.2}},
+		{spec: "stratified:20%", want: SampleSpec{Strategy: SampleStratified, Fraction: 0.2}},
+		{spec: "random:15", want: SampleSpec{Strategy: SampleRandom, Count: 15}},
+		{spec: "stratified:0%", wantErr: true},
+		{spec: "stratified:150%", wantErr: true},
+		{spec: "-3", wantErr: true},
+		{spec: "weighted:20%", wantErr: true},
+		{spec: "some", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseSampleSpec(tt.spec)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseSampleSpec(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ParseSampleSpec(%q) = %+v, want %+v", tt.spec, got, tt.want)
+		}
+	}
+}
+
+// taggedSuite has 10 "math" cases, 6 "text" cases, and 4 "safety" cases.
+func taggedSuite() *EvalSuite {
+	s := &EvalSuite{Name: "mixed"}
+	for tag, n := range map[string]int{"math": 10, "text": 6, "safety": 4} {
+		for i := 0; i < n; i++ {
+			s.Cases = append(s.Cases, EvalCase{Name: fmt.Sprintf("%s-%d", tag, i), Tags: []string{tag, "extra"}})
+		}
+	}
+	return s
+}
+
+func TestSample_Stratified(t *testing.T) {
+	s := taggedSuite()
+	for seed := int64(1); seed <= 20; seed++ {
+		sampled := s.Sample(SampleSpec{Strategy: SampleStratified, Fraction: 0.25}, rand.New(rand.NewSource(seed)))
+		counts := make(map[string]int)
+		for _, c := range sampled.Cases {
+			counts[c.Tags[0]]++
+		}
+		want := map[string]int{"math": 2, "text": 2, "safety": 1}
+		if !maps.Equal(counts, want) {
+			t.Fatalf("seed %d: sampled %v, want %v", seed, counts, want)
+		}
+	}
+}
+
+func TestSample_StratifiedKeepsSmallGroups(t *testing.T) {
+	s := taggedSuite()
+	s.Cases = append(s.Cases, EvalCase{Name: "rare", Tags: []string{"rare"}}, EvalCase{Name: "untagged"})
+
+	sampled := s.Sample(SampleSpec{Strategy: SampleStratified, Count: 5}, rand.New(rand.NewSource(7)))
+	seen := make(map[string]bool)
+	for _, c := range sampled.Cases {
+		seen[c.Name] = true
+	}
+	if len(sampled.Cases) != 5 || !seen["rare"] || !seen["untagged"] {
+		t.Errorf("sampled %d cases %v, want 5 including the rare and untagged cases", len(sampled.Cases), seen)
+	}
+}
+
+func TestSample_KeepsOrderAndDependencies(t *testing.T) {
+	s := &EvalSuite{Name: "deps", Cases: []EvalCase{
+		{Name: "a"}, {Name: "b"}, {Name: "c"}, {Name: "setup"},
+		{Name: "e2e", DependsOn: []string{"setup"}},
+	}}
+	for seed := int64(1); seed <= 20; seed++ {
+		sampled := s.Sample(SampleSpec{Strategy: SampleRandom, Count: 2}, rand.New(rand.NewSource(seed)))
+		if err := sampled.Validate(); err != nil {
+			t.Fatalf("seed %d: sampled suite invalid: %v", seed, err)
+		}
+		for i := 1; i < len(sampled.Cases); i++ {
+			if indexOfCase(s, sampled.Cases[i-1].Name) > indexOfCase(s, sampled.Cases[i].Name) {
+				t.Fatalf("seed %d: cases out of order: %s before %s", seed, sampled.Cases[i-1].Name, sampled.Cases[i].Name)
+			}
+		}
+	}
+
+	if got := s.Sample(SampleSpec{Strategy: SampleRandom, Fraction: 1}, rand.New(rand.NewSource(1))); len(got.Cases) != 5 {
+		t.Errorf("100%% sample has %d cases, want 5", len(got.Cases))
+	}
+}
+
+func indexOfCase(s *EvalSuite, name string) int {
+	for i, c := range s.Cases {
+		if c.Name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func TestSplit(t *testing.T) {
+	s := taggedSuite()
+	dev, test := s.Split(0.3)
+	if len(dev.Cases) != 6 || len(test.Cases) != 14 {
+		t.Fatalf("split %d/%d cases, want 6/14", len(dev.Cases), len(test.Cases))
+	}
+	inDev := make(map[string]bool)
+	for _, c := range dev.Cases {
+		inDev[c.Name] = true
+	}
+	for _, c := range test.Cases {
+		if inDev[c.Name] {
+			t.Errorf("case %s is in both splits", c.Name)
+		}
+	}
+
+	// Adding cases does not move existing ones between splits.
+	s.Cases = append(s.Cases, EvalCase{Name: "new-1"}, EvalCase{Name: "new-2"})
+	grown, _ := s.Split(0.3)
+	kept := 0
+	for _, c := range grown.Cases {
+		if inDev[c.Name] {
+			kept++
+		}
+	}
+	if kept < len(dev.Cases)-1 {
+		t.Errorf("only %d of %d dev cases stayed in dev after adding cases", kept, len(dev.Cases))
+	}
+
+	if dev, test := s.Split(1); len(dev.Cases) != len(s.Cases) || test != nil {
+		t.Errorf("Split(1) = %v/%v, want every case in dev", dev, test)
+	}
+}
@@ -4,47 +4,254 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
 
 	"github.com/jdgilhuly/go_eval_agent/pkg/mock"
+	"github.com/jdgilhuly/go_eval_agent/pkg/prompt"
 	"gopkg.in/yaml.v3"
 )
 
 // EvalSuite defines a collection of test cases to run against an LLM agent.
 type EvalSuite struct {
-	Name          string        `yaml:"name"`
-	Description   string        `yaml:"description"`
-	Prompt        string        `yaml:"prompt"`
-	DefaultJudges []JudgeConfig `yaml:"default_judges"`
-	DefaultMocks  []mock.MockConfig `yaml:"default_mocks"`
-	Cases         []EvalCase    `yaml:"cases"`
+	Name          string            `yaml:"name,omitempty"`
+	Description   string            `yaml:"description,omitempty"`
+	Prompt        string            `yaml:"prompt,omitempty"`
+	DefaultJudges []JudgeConfig     `yaml:"default_judges,omitempty"`
+	DefaultMocks  []mock.MockConfig `yaml:"default_mocks,omitempty"`
+	Cases         []EvalCase        `yaml:"cases,omitempty"`
+
+	// Fixtures is the directory, relative to the suite file, holding files
+	// that mock responses load with content_file. Defaults to "fixtures".
+	Fixtures string `yaml:"fixtures,omitempty"`
+
+	// MaxConcurrency caps how many of this suite's cases run at once, below
+	// the global concurrency. RateLimit caps how many cases start per
+	// second. Zero means no suite-level limit.
+	MaxConcurrency int     `yaml:"max_concurrency,omitempty"`
+	RateLimit      float64 `yaml:"rate_limit,omitempty"`
+
+	// OnUnmockedTool is what happens when the agent calls a tool with no
+	// mock: "error" (the default) surfaces an error to the agent, "empty"
+	// returns an empty result, and "passthrough" runs the tool through the
+	// runner's tool executor.
+	OnUnmockedTool string `yaml:"on_unmocked_tool,omitempty"`
+
+	// TagProviders runs cases with a given tag on another provider or
+	// model, e.g. a multimodal model for cases tagged "vision". A case's
+	// own provider and model take precedence.
+	TagProviders map[string]ProviderOverride `yaml:"tag_providers,omitempty"`
+
+	// ReaskInvalid gives the agent one corrective follow-up turn when its
+	// final output fails a case's schema judge, quoting the validation
+	// error, the way a production agent would retry.
+	ReaskInvalid bool `yaml:"reask_invalid,omitempty"`
+
+	// PassCriteria are the suite's own acceptance criteria. eval run
+	// reports them and exits non-zero when one is violated.
+	PassCriteria *PassCriteria `yaml:"pass_criteria,omitempty"`
+}
+
+// PassCriteria declares when a run of a suite is acceptable.
+type PassCriteria struct {
+	// MinPassRate is the lowest acceptable pass rate, from 0 to 1.
+	MinPassRate float64 `yaml:"min_pass_rate,omitempty"`
+
+	// MaxRegressionsVsBaseline is the most cases whose score may drop
+	// compared with Baseline. Nil means regressions are not checked.
+	MaxRegressionsVsBaseline *int `yaml:"max_regressions_vs_baseline,omitempty"`
+
+	// Baseline is the results file regressions are counted against. When
+	// empty, the newest earlier run of the suite in output_dir is used.
+	Baseline string `yaml:"baseline,omitempty"`
+
+	// Tags sets stricter criteria for the cases carrying a tag, since not
+	// every regression is equally serious: a suite may tolerate a few
+	// style regressions but none in its safety cases.
+	Tags map[string]TagCriteria `yaml:"tags,omitempty"`
+}
+
+// TagCriteria are the pass criteria for the cases carrying one tag.
+type TagCriteria struct {
+	// MinPassRate is the lowest acceptable pass rate of the tag's cases.
+	MinPassRate float64 `yaml:"min_pass_rate,omitempty"`
+
+	// MaxRegressions is the most of the tag's cases whose score may drop
+	// compared with the baseline. Nil means they are not checked.
+	MaxRegressions *int `yaml:"max_regressions,omitempty"`
+}
+
+// Judges modes for EvalCase.JudgesMode.
+const (
+	JudgesReplace = "replace"
+	JudgesAppend  = "append"
+)
+
+// ProviderOverride selects a provider from the config's providers and,
+// optionally, a model other than that provider's configured default.
+type ProviderOverride struct {
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
 }
 
 // JudgeConfig describes a judge to apply to a case result.
 type JudgeConfig struct {
-	Type    string  `yaml:"type"`
-	Value   string  `yaml:"value"`
-	Weight  float64 `yaml:"weight"`
-	Comment string  `yaml:"comment"`
+	Type    string  `yaml:"type,omitempty"`
+	Value   string  `yaml:"value,omitempty"`
+	Weight  float64 `yaml:"weight,omitempty"`
+	Comment string  `yaml:"comment,omitempty"`
+
+	// MinScore, from 0 to 1, makes the judge's own pass criterion its
+	// score reaching MinScore, and fails the case whenever it does not,
+	// whatever the composite score.
+	MinScore float64 `yaml:"min_score,omitempty"`
+
+	// Patterns, Match, and Captures configure regex judges beyond the
+	// single pattern in Value; see judge.RegexJudge.
+	Patterns []string          `yaml:"patterns,omitempty"`
+	Match    string            `yaml:"match,omitempty"`
+	Captures map[string]string `yaml:"captures,omitempty"`
+
+	// Preprocess lists output normalization steps (e.g. strip_fences,
+	// extract_json) applied before the judge runs; see judge.Preprocess.
+	Preprocess []string `yaml:"preprocess,omitempty"`
+
+	// Normalize lists normalizations (whitespace, case, unicode,
+	// punctuation, numbers) that exact and contains judges apply to both
+	// the output and the expected value; see judge.Normalize.
+	Normalize []string `yaml:"normalize,omitempty"`
+
+	// Headers, MaxRetries, and Timeout configure judges that call out to
+	// other programs or services (exec, http). Header values may reference
+	// environment variables as ${NAME}.
+	Headers    map[string]string `yaml:"headers,omitempty"`
+	MaxRetries int               `yaml:"max_retries,omitempty"`
+	Timeout    time.Duration     `yaml:"timeout,omitempty"`
+
+	// Tools and Mocks give an agent judge verification tools; calls are
+	// resolved by the mocks.
+	Tools []prompt.ToolDefinition `yaml:"tools,omitempty"`
+	Mocks []mock.MockConfig       `yaml:"mocks,omitempty"`
+
+	// Constraints add call-order policies to a toolcall judge beyond its
+	// strict expected sequence.
+	Constraints []ToolConstraint `yaml:"constraints,omitempty"`
+
+	// Style sets the word, sentence, bullet, and phrase limits checked by
+	// a style judge.
+	Style *StyleRules `yaml:"style,omitempty"`
+
+	// Citations configures how a citation judge finds the retrieved
+	// sources that claims must cite.
+	Citations *CitationRules `yaml:"citations,omitempty"`
+}
+
+// StyleRules are output constraints checked by style judges. Zero limits
+// are not checked; MaxBullets is a pointer so that zero can forbid bullet
+// lists outright.
+type StyleRules struct {
+	MinWords     int  `yaml:"min_words,omitempty" json:"min_words,omitempty"`
+	MaxWords     int  `yaml:"max_words,omitempty" json:"max_words,omitempty"`
+	MinSentences int  `yaml:"min_sentences,omitempty" json:"min_sentences,omitempty"`
+	MaxSentences int  `yaml:"max_sentences,omitempty" json:"max_sentences,omitempty"`
+	MinBullets   int  `yaml:"min_bullets,omitempty" json:"min_bullets,omitempty"`
+	MaxBullets   *int `yaml:"max_bullets,omitempty" json:"max_bullets,omitempty"`
+
+	// ForbiddenPhrases fail the judge if any appears in the output,
+	// compared case-insensitively.
+	ForbiddenPhrases []string `yaml:"forbidden_phrases,omitempty" json:"forbidden_phrases,omitempty"`
 }
 
-// EvalCase is a single test case within a suite.
+// CitationRules configure citation judges. Every field is optional.
+type CitationRules struct {
+	// Tools lists the retrieval tools whose responses are citable
+	// sources; when empty, every tool response is.
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+
+	// IDField is the JSON field holding a retrieved document's ID
+	// (default "id").
+	IDField string `yaml:"id_field,omitempty" json:"id_field,omitempty"`
+
+	// Pattern matches a citation marker, with its first group holding one
+	// or more comma-separated IDs (default `\[([^\[\]]+)\]`, as in
+	// "[doc-1]").
+	Pattern string `yaml:"pattern,omitempty" json:"pattern,omitempty"`
+
+	// MinClaimWords is the number of words a sentence needs to count as a
+	// claim (default 4), so short lead-ins and headings need no citation.
+	MinClaimWords int `yaml:"min_claim_words,omitempty" json:"min_claim_words,omitempty"`
+}
+
+// ToolConstraint is a call-order policy checked by toolcall judges:
+//
+//   - "before": each tool in Tools is first called before the next one is
+//   - "unordered": every tool in Tools is called, in any order
+//   - "count": each tool in Tools is called exactly Count times
+//   - "no_calls_after_answer": no tool is called once the agent has
+//     written its final answer
+type ToolConstraint struct {
+	Type  string   `yaml:"type,omitempty" json:"type"`
+	Tools []string `yaml:"tools,omitempty" json:"tools,omitempty"`
+	Count int      `yaml:"count,omitempty" json:"count,omitempty"`
+}
+
+// EvalCase is a single test case within a suite. AllowedTools, when set,
+// restricts the prompt's tools to those named for this case; calling any
+// other tool fails the case whatever its judges decide.
 type EvalCase struct {
-	ID             string                 `yaml:"id"`
-	Name           string                 `yaml:"name"`
-	Input          map[string]interface{} `yaml:"input"`
-	Context        string                 `yaml:"context"`
-	Mocks          []mock.MockConfig      `yaml:"mocks"`
-	Judges         []JudgeConfig          `yaml:"judges"`
-	ExpectedOutput string                 `yaml:"expected_output"`
-	ExpectedTools  []string               `yaml:"expected_tools"`
-	Tags           []string               `yaml:"tags"`
-	Timeout        time.Duration          `yaml:"timeout"`
-}
-
-// Load reads a single EvalSuite from a YAML file. Suite-level defaults are
-// merged into cases that don't specify their own judges or mocks.
+	ID             string                 `yaml:"id,omitempty"`
+	Name           string                 `yaml:"name,omitempty"`
+	Input          map[string]interface{} `yaml:"input,omitempty"`
+	Context        string                 `yaml:"context,omitempty"`
+	Mocks          []mock.MockConfig      `yaml:"mocks,omitempty"`
+	Judges         []JudgeConfig          `yaml:"judges,omitempty"`
+	ExpectedOutput string                 `yaml:"expected_output,omitempty"`
+	ExpectedTools  []string               `yaml:"expected_tools,omitempty"`
+	AllowedTools   []string               `yaml:"allowed_tools,omitempty"`
+	Tags           []string               `yaml:"tags,omitempty"`
+	Timeout        time.Duration          `yaml:"timeout,omitempty"`
+
+	// DependsOn names cases that must pass before this one runs. If any
+	// of them fails, this case is skipped and reported as blocked.
+	DependsOn []string `yaml:"depends_on,omitempty"`
+
+	// Provider and Model run this case on a different provider or model
+	// than the rest of the suite.
+	Provider string `yaml:"provider,omitempty"`
+	Model    string `yaml:"model,omitempty"`
+
+	// AdditionalJudges are appended to the case's judges after defaults
+	// are applied, so a case can add checks without restating the suite's
+	// default_judges.
+	AdditionalJudges []JudgeConfig `yaml:"additional_judges,omitempty"`
+
+	// JudgesMode decides how Judges combine with the suite's
+	// default_judges: JudgesReplace (the default) uses Judges instead of
+	// the defaults, and JudgesAppend runs both.
+	JudgesMode string `yaml:"judges_mode,omitempty"`
+
+	// Threshold is the composite score, from 0 to 1, the case needs to
+	// pass. Zero uses the default of 0.5, so hard cases can ask less and
+	// easy ones more.
+	Threshold float64 `yaml:"threshold,omitempty"`
+
+	// InheritDefaults controls whether the suite's default_mocks are merged
+	// into this case's mocks. Unset means true; false runs the case with
+	// only its own mocks.
+	InheritDefaults *bool `yaml:"inherit_defaults,omitempty"`
+
+	// Source is the "file:line" of the case's definition, recorded by
+	// Load so reports can point at it. It is empty for cases built in Go.
+	Source string `yaml:"-"`
+}
+
+// Load reads a single EvalSuite from a YAML file. Suite-level default judges
+// apply to cases that don't specify their own (or that set judges_mode:
+// append), additional_judges are folded into each case's judges, and
+// default mocks are merged into each case's mocks. Mock responses with a
+// content_file are filled in from the suite's fixtures directory, and each
+// case records the file and line it is defined at in Source.
 func Load(path string) (*EvalSuite, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
@@ -55,11 +262,39 @@ func Load(path string) (*EvalSuite, error) {
 	if err := yaml.Unmarshal(data, &s); err != nil {
 		return nil, fmt.Errorf("parsing suite file %s: %w", path, err)
 	}
+	if err := s.loadFixtures(filepath.Dir(path)); err != nil {
+		return nil, fmt.Errorf("suite file %s: %w", path, err)
+	}
+	s.recordSources(path, data)
 
 	s.applyDefaults()
 	return &s, nil
 }
 
+// recordSources sets each case's Source from the line its entry starts on
+// in data, the contents of the suite file at path.
+func (s *EvalSuite) recordSources(path string, data []byte) {
+	var doc yaml.Node
+	if yaml.Unmarshal(data, &doc) != nil || len(doc.Content) == 0 {
+		return
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		if root.Content[i].Value != "cases" {
+			continue
+		}
+		entries := root.Content[i+1].Content
+		for j := range s.Cases {
+			if j < len(entries) {
+				s.Cases[j].Source = fmt.Sprintf("%s:%d", path, entries[j].Line)
+			}
+		}
+	}
+}
+
 // LoadDir loads all .yaml and .yml files from dir as EvalSuites.
 func LoadDir(dir string) ([]*EvalSuite, error) {
 	entries, err := os.ReadDir(dir)
@@ -87,6 +322,40 @@ func LoadDir(dir string) ([]*EvalSuite, error) {
 	return suites, nil
 }
 
+// LoadPaths loads suites from a mix of suite files and directories. Each
+// directory contributes every suite file it contains, as with LoadDir.
+// Two suites with the same name are an error, since their results would
+// be saved to the same place.
+func LoadPaths(paths []string) ([]*EvalSuite, error) {
+	var suites []*EvalSuite
+	loadedFrom := map[string]string{}
+	for _, p := range paths {
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("reading suite path %s: %w", p, err)
+		}
+		var loaded []*EvalSuite
+		if info.IsDir() {
+			loaded, err = LoadDir(p)
+		} else {
+			var s *EvalSuite
+			s, err = Load(p)
+			loaded = []*EvalSuite{s}
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range loaded {
+			if prev, ok := loadedFrom[s.Name]; ok && s.Name != "" {
+				return nil, fmt.Errorf("suite %q in %s is already loaded from %s", s.Name, p, prev)
+			}
+			loadedFrom[s.Name] = p
+		}
+		suites = append(suites, loaded...)
+	}
+	return suites, nil
+}
+
 // Validate checks that the EvalSuite has the minimum required fields.
 func (s *EvalSuite) Validate() error {
 	if s.Name == "" {
@@ -95,10 +364,103 @@ func (s *EvalSuite) Validate() error {
 	if len(s.Cases) == 0 {
 		return fmt.Errorf("suite %q must have at least one case", s.Name)
 	}
+	if s.MaxConcurrency < 0 {
+		return fmt.Errorf("suite %q: max_concurrency must be >= 0", s.Name)
+	}
+	if s.RateLimit < 0 {
+		return fmt.Errorf("suite %q: rate_limit must be >= 0", s.Name)
+	}
+	switch s.OnUnmockedTool {
+	case "", mock.UnmockedError, mock.UnmockedEmpty, mock.UnmockedPassthrough:
+	default:
+		return fmt.Errorf("suite %q: on_unmocked_tool must be %q, %q, or %q, got %q",
+			s.Name, mock.UnmockedError, mock.UnmockedEmpty, mock.UnmockedPassthrough, s.OnUnmockedTool)
+	}
 	for i, c := range s.Cases {
 		if c.Name == "" {
 			return fmt.Errorf("suite %q: case %d has no name", s.Name, i)
 		}
+		switch c.JudgesMode {
+		case "", JudgesReplace, JudgesAppend:
+		default:
+			return fmt.Errorf("suite %q: case %q: judges_mode must be %q or %q, got %q",
+				s.Name, c.Name, JudgesReplace, JudgesAppend, c.JudgesMode)
+		}
+		if c.Threshold < 0 || c.Threshold > 1 {
+			return fmt.Errorf("suite %q: case %q: threshold must be between 0 and 1, got %v", s.Name, c.Name, c.Threshold)
+		}
+		for i, jc := range c.Judges {
+			if jc.MinScore < 0 || jc.MinScore > 1 {
+				return fmt.Errorf("suite %q: case %q: judge %d: min_score must be between 0 and 1, got %v", s.Name, c.Name, i+1, jc.MinScore)
+			}
+		}
+	}
+	if pc := s.PassCriteria; pc != nil {
+		if pc.MinPassRate < 0 || pc.MinPassRate > 1 {
+			return fmt.Errorf("suite %q: pass_criteria.min_pass_rate must be between 0 and 1, got %v", s.Name, pc.MinPassRate)
+		}
+		if pc.MaxRegressionsVsBaseline != nil && *pc.MaxRegressionsVsBaseline < 0 {
+			return fmt.Errorf("suite %q: pass_criteria.max_regressions_vs_baseline must be >= 0", s.Name)
+		}
+		for tag, tc := range pc.Tags {
+			if tc.MinPassRate < 0 || tc.MinPassRate > 1 {
+				return fmt.Errorf("suite %q: pass_criteria.tags.%s.min_pass_rate must be between 0 and 1, got %v", s.Name, tag, tc.MinPassRate)
+			}
+			if tc.MaxRegressions != nil && *tc.MaxRegressions < 0 {
+				return fmt.Errorf("suite %q: pass_criteria.tags.%s.max_regressions must be >= 0", s.Name, tag)
+			}
+		}
+	}
+	return s.validateDependencies()
+}
+
+// validateDependencies checks that every depends_on entry names another
+// case in the suite and that dependencies do not form a cycle.
+func (s *EvalSuite) validateDependencies() error {
+	byName := make(map[string]int, len(s.Cases))
+	for i, c := range s.Cases {
+		byName[c.Name] = i
+	}
+	for _, c := range s.Cases {
+		for _, dep := range c.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return fmt.Errorf("suite %q: case %q depends on unknown case %q", s.Name, c.Name, dep)
+			}
+			if dep == c.Name {
+				return fmt.Errorf("suite %q: case %q depends on itself", s.Name, c.Name)
+			}
+		}
+	}
+
+	// Depth-first search; a case reached again while still on the stack
+	// closes a cycle.
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(s.Cases))
+	var visit func(i int) error
+	visit = func(i int) error {
+		switch state[i] {
+		case visiting:
+			return fmt.Errorf("suite %q: dependency cycle through case %q", s.Name, s.Cases[i].Name)
+		case visited:
+			return nil
+		}
+		state[i] = visiting
+		for _, dep := range s.Cases[i].DependsOn {
+			if err := visit(byName[dep]); err != nil {
+				return err
+			}
+		}
+		state[i] = visited
+		return nil
+	}
+	for i := range s.Cases {
+		if err := visit(i); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -115,35 +477,111 @@ func (s *EvalSuite) FilterByTag(tags []string) *EvalSuite {
 		tagSet[t] = true
 	}
 
+	return s.filter(func(c EvalCase) bool {
+		for _, t := range c.Tags {
+			if tagSet[t] {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// Search returns a new suite containing only cases whose ID, name, tags,
+// or string input values match re.
+func (s *EvalSuite) Search(re *regexp.Regexp) *EvalSuite {
+	return s.filter(func(c EvalCase) bool {
+		if re.MatchString(c.ID) || re.MatchString(c.Name) {
+			return true
+		}
+		for _, t := range c.Tags {
+			if re.MatchString(t) {
+				return true
+			}
+		}
+		for _, v := range c.Input {
+			if str, ok := v.(string); ok && re.MatchString(str) {
+				return true
+			}
+		}
+		return false
+	})
+}
+
+// filter returns a copy of s holding only the cases keep accepts.
+func (s *EvalSuite) filter(keep func(EvalCase) bool) *EvalSuite {
 	filtered := &EvalSuite{
 		Name:          s.Name,
 		Description:   s.Description,
 		Prompt:        s.Prompt,
+		Fixtures:      s.Fixtures,
 		DefaultJudges: s.DefaultJudges,
 		DefaultMocks:  s.DefaultMocks,
+
+		MaxConcurrency: s.MaxConcurrency,
+		RateLimit:      s.RateLimit,
+		OnUnmockedTool: s.OnUnmockedTool,
+		TagProviders:   s.TagProviders,
+		ReaskInvalid:   s.ReaskInvalid,
+		PassCriteria:   s.PassCriteria,
 	}
 
 	for _, c := range s.Cases {
-		for _, t := range c.Tags {
-			if tagSet[t] {
-				filtered.Cases = append(filtered.Cases, c)
-				break
-			}
+		if keep(c) {
+			filtered.Cases = append(filtered.Cases, c)
 		}
 	}
 
 	return filtered
 }
 
-// applyDefaults merges suite-level default judges and mocks into cases that
-// don't specify their own.
+// applyDefaults applies suite-level default judges to cases that don't
+// specify their own or that append to them, then adds each case's
+// additional judges. It also merges default mocks into case mocks (a case
+// mock replaces the default for the same tool), and applies tag provider
+// overrides to cases that name no provider or model. The first matching
+// tag wins.
 func (s *EvalSuite) applyDefaults() {
 	for i := range s.Cases {
-		if len(s.Cases[i].Judges) == 0 && len(s.DefaultJudges) > 0 {
-			s.Cases[i].Judges = s.DefaultJudges
+		c := &s.Cases[i]
+		if c.Provider == "" && c.Model == "" {
+			for _, t := range c.Tags {
+				if o, ok := s.TagProviders[t]; ok {
+					c.Provider, c.Model = o.Provider, o.Model
+					break
+				}
+			}
+		}
+		switch {
+		case len(c.Judges) == 0:
+			c.Judges = s.DefaultJudges
+		case c.JudgesMode == JudgesAppend:
+			c.Judges = append(append([]JudgeConfig(nil), s.DefaultJudges...), c.Judges...)
 		}
-		if len(s.Cases[i].Mocks) == 0 && len(s.DefaultMocks) > 0 {
-			s.Cases[i].Mocks = s.DefaultMocks
+		if len(c.AdditionalJudges) > 0 {
+			c.Judges = append(append([]JudgeConfig(nil), c.Judges...), c.AdditionalJudges...)
+		}
+		if c.InheritDefaults == nil || *c.InheritDefaults {
+			c.Mocks = mergeMocks(s.DefaultMocks, c.Mocks)
+		}
+	}
+}
+
+// mergeMocks returns the case's mocks followed by the defaults for tools
+// the case does not mock itself.
+func mergeMocks(defaults, own []mock.MockConfig) []mock.MockConfig {
+	if len(defaults) == 0 {
+		return own
+	}
+	mocked := make(map[string]bool, len(own))
+	for _, m := range own {
+		mocked[m.ToolName] = true
+	}
+	merged := append([]mock.MockConfig(nil), own...)
+	for _, m := range defaults {
+		if !mocked[m.ToolName] {
+			merged = append(merged, m)
 		}
 	}
+	return merged
 }
@@ -1,8 +1,12 @@
 package suite
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
 	"testing"
 )
 
@@ -79,6 +83,11 @@ func TestLoad(t *testing.T) {
 	if s.Cases[0].ID != "c1" {
 		t.Errorf("Cases[0].ID = %q, want %q", s.Cases[0].ID, "c1")
 	}
+	for i, line := range []int{14, 21} {
+		if want := fmt.Sprintf("%s:%d", path, line); s.Cases[i].Source != want {
+			t.Errorf("Cases[%d].Source = %q, want %q", i, s.Cases[i].Source, want)
+		}
+	}
 }
 
 func TestLoad_FileNotFound(t *testing.T) {
@@ -122,7 +131,8 @@ func TestDefaultMerging(t *testing.T) {
 		t.Errorf("case-one: Mocks[0].ToolName = %q, want %q", c1.Mocks[0].ToolName, "search")
 	}
 
-	// case-two specifies its own judges and mocks, should NOT inherit defaults.
+	// case-two specifies its own judges, which replace the defaults, and
+	// its own mocks, which are merged with them.
 	c2 := s.Cases[1]
 	if len(c2.Judges) != 1 {
 		t.Fatalf("case-two: len(Judges) = %d, want 1", len(c2.Judges))
@@ -130,11 +140,140 @@ func TestDefaultMerging(t *testing.T) {
 	if c2.Judges[0].Type != "exact" {
 		t.Errorf("case-two: Judges[0].Type = %q, want %q", c2.Judges[0].Type, "exact")
 	}
-	if len(c2.Mocks) != 1 {
-		t.Fatalf("case-two: len(Mocks) = %d, want 1", len(c2.Mocks))
+	if len(c2.Mocks) != 2 {
+		t.Fatalf("case-two: len(Mocks) = %d, want 2", len(c2.Mocks))
+	}
+	if c2.Mocks[0].ToolName != "calc" || c2.Mocks[1].ToolName != "search" {
+		t.Errorf("case-two: mocks = %q, %q, want calc, search", c2.Mocks[0].ToolName, c2.Mocks[1].ToolName)
+	}
+}
+
+func TestDefaultJudges_AppendAndAdditional(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "suite.yaml", `name: judges
+default_judges:
+  - type: not_contains
+    value: "password"
+cases:
+  - name: additional
+    input: {q: "a"}
+    additional_judges:
+      - type: contains
+        value: "hello"
+  - name: append
+    input: {q: "b"}
+    judges_mode: append
+    judges:
+      - type: exact
+        value: "42"
+  - name: replace
+    input: {q: "c"}
+    judges:
+      - type: exact
+        value: "42"
+    additional_judges:
+      - type: contains
+        value: "4"
+`)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	want := map[string][]string{
+		"additional": {"not_contains", "contains"},
+		"append":     {"not_contains", "exact"},
+		"replace":    {"exact", "contains"},
+	}
+	for _, c := range s.Cases {
+		var got []string
+		for _, j := range c.Judges {
+			got = append(got, j.Type)
+		}
+		if !slices.Equal(got, want[c.Name]) {
+			t.Errorf("%s: judges = %v, want %v", c.Name, got, want[c.Name])
+		}
+	}
+	if len(s.DefaultJudges) != 1 {
+		t.Errorf("len(DefaultJudges) = %d, want 1 (defaults must not be modified)", len(s.DefaultJudges))
+	}
+}
+
+func TestDefaultMocks_OverrideAndOptOut(t *testing.T) {
+	dir := t.TempDir()
+	path := writeTempFile(t, dir, "suite.yaml", `name: mocks
+default_mocks:
+  - tool_name: search
+    default_response:
+      content: "shared"
+  - tool_name: weather
+    default_response:
+      content: "sunny"
+cases:
+  - name: override
+    input: {q: "x"}
+    mocks:
+      - tool_name: search
+        default_response:
+          content: "own"
+  - name: opt-out
+    input: {q: "y"}
+    inherit_defaults: false
+    mocks:
+      - tool_name: calc
+        default_response:
+          content: "42"
+`)
+
+	s, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
+	}
+
+	override := s.Cases[0].Mocks
+	if len(override) != 2 {
+		t.Fatalf("override: len(Mocks) = %d, want 2", len(override))
+	}
+	if override[0].ToolName != "search" || override[0].DefaultResponse.Content != "own" {
+		t.Errorf("override: Mocks[0] = %s %q, want the case's search mock", override[0].ToolName, override[0].DefaultResponse.Content)
+	}
+	if override[1].ToolName != "weather" {
+		t.Errorf("override: Mocks[1].ToolName = %q, want weather", override[1].ToolName)
+	}
+
+	optOut := s.Cases[1].Mocks
+	if len(optOut) != 1 || optOut[0].ToolName != "calc" {
+		t.Errorf("opt-out: mocks = %+v, want only calc", optOut)
+	}
+}
+
+func TestTagProviderOverrides(t *testing.T) {
+	dir := t.TempDir()
+	writeTempFile(t, dir, "suite.yaml", `
+name: mixed
+tag_providers:
+  vision:
+    provider: openai
+    model: gpt-4o
+cases:
+  - name: text
+  - name: image
+    tags: [vision]
+  - name: pinned
+    tags: [vision]
+    model: gpt-4o-mini
+`)
+
+	s, err := Load(filepath.Join(dir, "suite.yaml"))
+	if err != nil {
+		t.Fatalf("Load() error: %v", err)
 	}
-	if c2.Mocks[0].ToolName != "calc" {
-		t.Errorf("case-two: Mocks[0].ToolName = %q, want %q", c2.Mocks[0].ToolName, "calc")
+	want := []ProviderOverride{{}, {Provider: "openai", Model: "gpt-4o"}, {Model: "gpt-4o-mini"}}
+	for i, c := range s.Cases {
+		if got := (ProviderOverride{Provider: c.Provider, Model: c.Model}); got != want[i] {
+			t.Errorf("case %q: provider/model = %+v, want %+v", c.Name, got, want[i])
+		}
 	}
 }
 
@@ -178,6 +317,40 @@ func TestLoadDir_NotFound(t *testing.T) {
 	}
 }
 
+func TestLoadPaths(t *testing.T) {
+	dir := t.TempDir()
+	sub := filepath.Join(dir, "suites")
+	if err := os.Mkdir(sub, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	writeTempFile(t, sub, "alpha.yaml", "name: alpha\ncases:\n  - name: a1\n")
+	writeTempFile(t, sub, "beta.yaml", "name: beta\ncases:\n  - name: b1\n")
+	single := writeTempFile(t, dir, "gamma.yaml", "name: gamma\ncases:\n  - name: g1\n")
+
+	suites, err := LoadPaths([]string{sub, single})
+	if err != nil {
+		t.Fatalf("LoadPaths() error: %v", err)
+	}
+	if len(suites) != 3 {
+		t.Fatalf("LoadPaths() returned %d suites, want 3", len(suites))
+	}
+	if suites[2].Name != "gamma" {
+		t.Errorf("suites[2].Name = %q, want %q", suites[2].Name, "gamma")
+	}
+
+	if _, err := LoadPaths([]string{filepath.Join(dir, "missing.yaml")}); err == nil {
+		t.Error("LoadPaths() expected error for missing path")
+	}
+
+	dup := writeTempFile(t, dir, "alpha-copy.yaml", "name: alpha\ncases:\n  - name: a2\n")
+	if _, err := LoadPaths([]string{sub, dup}); err == nil || !strings.Contains(err.Error(), `"alpha"`) {
+		t.Errorf("LoadPaths() with a duplicate suite name error = %v, want one naming alpha", err)
+	}
+	if _, err := LoadPaths([]string{single, single}); err == nil {
+		t.Error("LoadPaths() expected error for a suite file given twice")
+	}
+}
+
 func TestValidate(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -210,6 +383,129 @@ func TestValidate(t *testing.T) {
 			},
 			wantErr: true,
 		},
+		{
+			name: "negative max_concurrency",
+			suite: EvalSuite{
+				Name:           "test",
+				Cases:          []EvalCase{{Name: "c1"}},
+				MaxConcurrency: -1,
+			},
+			wantErr: true,
+		},
+		{
+			name: "negative rate_limit",
+			suite: EvalSuite{
+				Name:      "test",
+				Cases:     []EvalCase{{Name: "c1"}},
+				RateLimit: -0.5,
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid on_unmocked_tool",
+			suite: EvalSuite{
+				Name:           "test",
+				Cases:          []EvalCase{{Name: "c1"}},
+				OnUnmockedTool: "empty",
+			},
+			wantErr: false,
+		},
+		{
+			name: "unknown on_unmocked_tool",
+			suite: EvalSuite{
+				Name:           "test",
+				Cases:          []EvalCase{{Name: "c1"}},
+				OnUnmockedTool: "ignore",
+			},
+			wantErr: true,
+		},
+		{
+			name: "unknown judges_mode",
+			suite: EvalSuite{
+				Name:  "test",
+				Cases: []EvalCase{{Name: "c1", JudgesMode: "merge"}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "pass_criteria min_pass_rate above 1",
+			suite: EvalSuite{
+				Name:         "test",
+				Cases:        []EvalCase{{Name: "c1"}},
+				PassCriteria: &PassCriteria{MinPassRate: 90},
+			},
+			wantErr: true,
+		},
+		{
+			name: "case threshold above 1",
+			suite: EvalSuite{
+				Name:  "test",
+				Cases: []EvalCase{{Name: "c1", Threshold: 80}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "judge min_score above 1",
+			suite: EvalSuite{
+				Name:  "test",
+				Cases: []EvalCase{{Name: "c1", Threshold: 0.8, Judges: []JudgeConfig{{Type: "llm", MinScore: 7}}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "pass_criteria tag with zero max_regressions",
+			suite: EvalSuite{
+				Name:         "test",
+				Cases:        []EvalCase{{Name: "c1", Tags: []string{"safety"}}},
+				PassCriteria: &PassCriteria{Tags: map[string]TagCriteria{"safety": {MaxRegressions: new(int)}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "pass_criteria tag min_pass_rate above 1",
+			suite: EvalSuite{
+				Name:         "test",
+				Cases:        []EvalCase{{Name: "c1", Tags: []string{"style"}}},
+				PassCriteria: &PassCriteria{Tags: map[string]TagCriteria{"style": {MinPassRate: 70}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "valid depends_on",
+			suite: EvalSuite{
+				Name:  "test",
+				Cases: []EvalCase{{Name: "setup"}, {Name: "e2e", DependsOn: []string{"setup"}}},
+			},
+			wantErr: false,
+		},
+		{
+			name: "depends_on unknown case",
+			suite: EvalSuite{
+				Name:  "test",
+				Cases: []EvalCase{{Name: "e2e", DependsOn: []string{"setup"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "depends_on itself",
+			suite: EvalSuite{
+				Name:  "test",
+				Cases: []EvalCase{{Name: "c1", DependsOn: []string{"c1"}}},
+			},
+			wantErr: true,
+		},
+		{
+			name: "dependency cycle",
+			suite: EvalSuite{
+				Name: "test",
+				Cases: []EvalCase{
+					{Name: "a", DependsOn: []string{"c"}},
+					{Name: "b", DependsOn: []string{"a"}},
+					{Name: "c", DependsOn: []string{"b"}},
+				},
+			},
+			wantErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -222,6 +518,26 @@ func TestValidate(t *testing.T) {
 	}
 }
 
+func TestSearch(t *testing.T) {
+	s := &EvalSuite{
+		Name: "search-test",
+		Cases: []EvalCase{
+			{ID: "auth-1", Name: "login"},
+			{Name: "refund", Tags: []string{"billing"}},
+			{Name: "lookup", Input: map[string]interface{}{"question": "What is my invoice total?", "n": 2}},
+			{Name: "weather"},
+		},
+	}
+
+	got := s.Search(regexp.MustCompile(`(?i)auth|billing|invoice`))
+	if len(got.Cases) != 3 || got.Cases[0].Name != "login" || got.Cases[1].Name != "refund" || got.Cases[2].Name != "lookup" {
+		t.Errorf("Search() cases = %v, want login, refund, lookup", got.Cases)
+	}
+	if got.Name != s.Name || len(s.Cases) != 4 {
+		t.Error("Search() should copy suite settings and leave the original intact")
+	}
+}
+
 func TestFilterByTag(t *testing.T) {
 	s := &EvalSuite{
 		Name: "filter-test",
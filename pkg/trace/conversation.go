@@ -0,0 +1,75 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// ChatMessage is one message in the OpenAI chat format used for
+// fine-tuning data and by most transcript analysis tools.
+type ChatMessage struct {
+	Role       string         `json:"role"`
+	Content    string         `json:"content"`
+	ToolCalls  []ChatToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string         `json:"tool_call_id,omitempty"`
+}
+
+// ChatToolCall is a function call requested by an assistant message.
+type ChatToolCall struct {
+	ID       string       `json:"id"`
+	Type     string       `json:"type"` // always "function"
+	Function ChatFunction `json:"function"`
+}
+
+// ChatFunction names the called function; Arguments is JSON-encoded.
+type ChatFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// Conversation converts the trace to OpenAI chat messages. Each assistant
+// message carries the tool calls answered by the tool messages that follow
+// it, linked by generated call IDs. The trace does not record the system
+// prompt, so the conversation starts with the first user message.
+func (t *AgentTrace) Conversation() []ChatMessage {
+	messages := t.GetMessages()
+	calls := t.GetToolCalls()
+
+	out := make([]ChatMessage, 0, len(messages))
+	next := 0
+	for i, m := range messages {
+		cm := ChatMessage{Role: m.Role, Content: m.Content}
+		switch m.Role {
+		case "assistant":
+			for j, k := i+1, next; j < len(messages) && messages[j].Role == "tool" && k < len(calls); j, k = j+1, k+1 {
+				cm.ToolCalls = append(cm.ToolCalls, ChatToolCall{
+					ID:       callID(k),
+					Type:     "function",
+					Function: ChatFunction{Name: calls[k].ToolName, Arguments: toolArguments(calls[k].Parameters)},
+				})
+			}
+		case "tool":
+			if next < len(calls) {
+				cm.ToolCallID = callID(next)
+				next++
+			}
+		}
+		out = append(out, cm)
+	}
+	return out
+}
+
+func callID(i int) string {
+	return fmt.Sprintf("call_%d", i+1)
+}
+
+func toolArguments(params map[string]interface{}) string {
+	if params == nil {
+		return "{}"
+	}
+	b, err := json.Marshal(params)
+	if err != nil {
+		return "{}"
+	}
+	return string(b)
+}
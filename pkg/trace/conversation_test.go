@@ -0,0 +1,45 @@
+package trace
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestConversation(t *testing.T) {
+	tr := New()
+	tr.AddMessage("user", "weather in Paris and Rome?")
+	tr.AddMessage("assistant", "checking")
+	tr.AddToolCall(ToolCallTrace{ToolName: "weather", Parameters: map[string]interface{}{"city": "Paris"}})
+	tr.AddMessage("tool", "sunny")
+	tr.AddToolCall(ToolCallTrace{ToolName: "weather", Parameters: map[string]interface{}{"city": "Rome"}})
+	tr.AddMessage("tool", "rain")
+	tr.AddMessage("assistant", "Paris is sunny, Rome is rainy.")
+
+	conv := tr.Conversation()
+	if len(conv) != 5 {
+		t.Fatalf("len = %d, want 5", len(conv))
+	}
+
+	asst := conv[1]
+	if len(asst.ToolCalls) != 2 {
+		t.Fatalf("assistant tool calls = %d, want 2", len(asst.ToolCalls))
+	}
+	if tc := asst.ToolCalls[1]; tc.ID != "call_2" || tc.Type != "function" || tc.Function.Name != "weather" || tc.Function.Arguments != `{"city":"Rome"}` {
+		t.Errorf("second tool call = %+v", tc)
+	}
+	if conv[2].ToolCallID != "call_1" || conv[3].ToolCallID != "call_2" {
+		t.Errorf("tool_call_ids = %q, %q; want call_1, call_2", conv[2].ToolCallID, conv[3].ToolCallID)
+	}
+	if len(conv[4].ToolCalls) != 0 {
+		t.Errorf("final answer has tool calls: %+v", conv[4].ToolCalls)
+	}
+
+	// Fields that do not apply are omitted from the JSON form.
+	b, err := json.Marshal(conv[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := string(b), `{"role":"user","content":"weather in Paris and Rome?"}`; got != want {
+		t.Errorf("JSON = %s, want %s", got, want)
+	}
+}
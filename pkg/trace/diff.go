@@ -0,0 +1,163 @@
+package trace
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Step is one event in a transcript: a message, or the tool call that
+// produced the tool message following it.
+type Step struct {
+	Kind    string `json:"kind"` // "message" or "tool_call"
+	Role    string `json:"role,omitempty"`
+	Content string `json:"content"`
+}
+
+func (s Step) String() string {
+	if s.Kind == "tool_call" {
+		return "tool_call " + s.Content
+	}
+	return s.Role + ": " + s.Content
+}
+
+// Diff operations.
+const (
+	OpEqual  = "="
+	OpDelete = "-" // step only in A
+	OpInsert = "+" // step only in B
+)
+
+// DiffLine is one aligned step. A is set for equal and delete lines, B for
+// equal and insert lines.
+type DiffLine struct {
+	Op string `json:"op"`
+	A  *Step  `json:"a,omitempty"`
+	B  *Step  `json:"b,omitempty"`
+}
+
+// TraceDiff is the alignment of two transcripts.
+type TraceDiff struct {
+	Lines []DiffLine `json:"lines"`
+
+	// Divergence is the index in Lines of the first step that differs, or
+	// -1 when the transcripts are identical.
+	Divergence int `json:"divergence"`
+}
+
+// Steps flattens a trace into the order it happened: each tool message is
+// preceded by the tool call that produced it. Timestamps are not used, so
+// normalized traces align the same way.
+func (t *AgentTrace) Steps() []Step {
+	messages := t.GetMessages()
+	calls := t.GetToolCalls()
+
+	var steps []Step
+	next := 0
+	for _, m := range messages {
+		if m.Role == "tool" && next < len(calls) {
+			steps = append(steps, toolCallStep(calls[next]))
+			next++
+		}
+		steps = append(steps, Step{Kind: "message", Role: m.Role, Content: m.Content})
+	}
+	for ; next < len(calls); next++ {
+		steps = append(steps, toolCallStep(calls[next]))
+	}
+	return steps
+}
+
+func toolCallStep(tc ToolCallTrace) Step {
+	// encoding/json sorts map keys, so equal parameters render equally.
+	params, err := json.Marshal(tc.Parameters)
+	if err != nil || tc.Parameters == nil {
+		params = []byte("{}")
+	}
+	return Step{Kind: "tool_call", Content: fmt.Sprintf("%s(%s)", tc.ToolName, params)}
+}
+
+// Diff aligns the transcripts of a and b by longest common subsequence of
+// steps, so a single divergent tool call shows up as one removed and one
+// added step rather than shifting everything after it.
+func Diff(a, b *AgentTrace) *TraceDiff {
+	var sa, sb []Step
+	if a != nil {
+		sa = a.Steps()
+	}
+	if b != nil {
+		sb = b.Steps()
+	}
+
+	// lcs[i][j] is the common subsequence length of sa[i:] and sb[j:].
+	lcs := make([][]int, len(sa)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(sb)+1)
+	}
+	for i := len(sa) - 1; i >= 0; i-- {
+		for j := len(sb) - 1; j >= 0; j-- {
+			if sa[i] == sb[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	d := &TraceDiff{Divergence: -1}
+	i, j := 0, 0
+	for i < len(sa) || j < len(sb) {
+		switch {
+		case i < len(sa) && j < len(sb) && sa[i] == sb[j]:
+			d.Lines = append(d.Lines, DiffLine{Op: OpEqual, A: &sa[i], B: &sb[j]})
+			i++
+			j++
+		case j == len(sb) || (i < len(sa) && lcs[i+1][j] >= lcs[i][j+1]):
+			d.Lines = append(d.Lines, DiffLine{Op: OpDelete, A: &sa[i]})
+			i++
+		default:
+			d.Lines = append(d.Lines, DiffLine{Op: OpInsert, B: &sb[j]})
+			j++
+		}
+		if d.Divergence < 0 && d.Lines[len(d.Lines)-1].Op != OpEqual {
+			d.Divergence = len(d.Lines) - 1
+		}
+	}
+	return d
+}
+
+// Identical reports whether the two transcripts had the same steps.
+func (d *TraceDiff) Identical() bool {
+	return d.Divergence < 0
+}
+
+// Print writes the aligned transcripts, collapsing the shared prefix and
+// marking where they diverged. Step content is truncated to width
+// characters when width > 0.
+func (d *TraceDiff) Print(w io.Writer, width int) {
+	if d.Identical() {
+		fmt.Fprintf(w, "  traces identical (%d steps)\n", len(d.Lines))
+		return
+	}
+
+	if d.Divergence > 0 {
+		fmt.Fprintf(w, "  ... %d identical steps\n", d.Divergence)
+	}
+	fmt.Fprintf(w, "  >>> diverged at step %d\n", d.Divergence+1)
+	for _, l := range d.Lines[d.Divergence:] {
+		s := l.A
+		if s == nil {
+			s = l.B
+		}
+		fmt.Fprintf(w, "  %s %s\n", l.Op, clip(s.String(), width))
+	}
+}
+
+// clip flattens s to one line and truncates it to width characters.
+func clip(s string, width int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if width > 0 && len(s) > width {
+		return s[:width] + "..."
+	}
+	return s
+}
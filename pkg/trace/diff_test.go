@@ -0,0 +1,85 @@
+package trace
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func buildTrace(query string) *AgentTrace {
+	tr := New()
+	tr.AddMessage("user", "find docs")
+	tr.AddMessage("assistant", "")
+	tr.AddToolCall(ToolCallTrace{ToolName: "search", Parameters: map[string]interface{}{"q": query}})
+	tr.AddMessage("tool", "results for "+query)
+	tr.AddMessage("assistant", "done")
+	return tr
+}
+
+func TestSteps(t *testing.T) {
+	steps := buildTrace("go").Steps()
+	want := []string{
+		"user: find docs",
+		"assistant: ",
+		`tool_call search({"q":"go"})`,
+		"tool: results for go",
+		"assistant: done",
+	}
+	if len(steps) != len(want) {
+		t.Fatalf("got %d steps, want %d", len(steps), len(want))
+	}
+	for i, w := range want {
+		if got := steps[i].String(); got != w {
+			t.Errorf("step %d = %q, want %q", i, got, w)
+		}
+	}
+}
+
+func TestDiff_Identical(t *testing.T) {
+	d := Diff(buildTrace("go"), buildTrace("go"))
+	if !d.Identical() {
+		t.Fatalf("expected identical traces, diverged at %d", d.Divergence)
+	}
+	if len(d.Lines) != 5 {
+		t.Errorf("got %d lines, want 5", len(d.Lines))
+	}
+}
+
+func TestDiff_Divergence(t *testing.T) {
+	d := Diff(buildTrace("go"), buildTrace("rust"))
+	if d.Identical() {
+		t.Fatal("expected traces to differ")
+	}
+	if d.Divergence != 2 {
+		t.Errorf("Divergence = %d, want 2 (the tool call)", d.Divergence)
+	}
+	if l := d.Lines[d.Divergence]; l.Op != OpDelete || !strings.Contains(l.A.Content, `"go"`) {
+		t.Errorf("divergent line = %+v, want removal of the go search", l)
+	}
+
+	// The final answer is shared again after the divergent steps.
+	if last := d.Lines[len(d.Lines)-1]; last.Op != OpEqual || last.A.Content != "done" {
+		t.Errorf("last line = %+v, want shared final answer", last)
+	}
+
+	var buf bytes.Buffer
+	d.Print(&buf, 0)
+	out := buf.String()
+	for _, want := range []string{"... 2 identical steps", ">>> diverged at step 3", `- tool_call search({"q":"go"})`, `+ tool_call search({"q":"rust"})`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestDiff_NilTrace(t *testing.T) {
+	d := Diff(nil, buildTrace("go"))
+	if d.Divergence != 0 || len(d.Lines) != 5 {
+		t.Errorf("diff against nil = %d lines diverging at %d, want 5 at 0", len(d.Lines), d.Divergence)
+	}
+	for _, l := range d.Lines {
+		if l.Op != OpInsert {
+			t.Errorf("line op = %q, want insert", l.Op)
+		}
+	}
+}
@@ -2,6 +2,8 @@ package trace
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"sync"
 	"time"
 )
@@ -16,9 +18,64 @@ type AgentTrace struct {
 	EndTime   time.Time       `json:"end_time"`
 	Duration  time.Duration   `json:"duration"`
 
-	mu sync.Mutex
+	// Retries and BackoffTime total the provider-level retries behind the
+	// trace's API calls and the time spent waiting between them.
+	Retries     int           `json:"retries,omitempty"`
+	BackoffTime time.Duration `json:"backoff_time,omitempty"`
+
+	// Overloaded counts API responses in which the provider reported it
+	// was overloaded.
+	Overloaded int `json:"overloaded,omitempty"`
+
+	// ModelVersions lists the distinct model versions the provider
+	// reported serving the trace's API calls, in the order first seen.
+	ModelVersions []string `json:"model_versions,omitempty"`
+
+	// SystemFingerprints lists the distinct backend configurations the
+	// provider reported serving the trace's API calls, in the order first
+	// seen. A change between seeded runs explains changed responses.
+	SystemFingerprints []string `json:"system_fingerprints,omitempty"`
+
+	// Providers lists the distinct providers that served the trace's API
+	// calls through a provider fallback chain, in the order first seen,
+	// and Fallbacks counts the calls served by a fallback rather than the
+	// primary provider.
+	Providers []string `json:"providers,omitempty"`
+	Fallbacks int      `json:"fallbacks,omitempty"`
+
+	// Reasks records why the agent was sent a corrective follow-up turn
+	// after giving an invalid final output, one entry per re-ask.
+	Reasks []string `json:"reasks,omitempty"`
+
+	// LimitExceeded is set once a message or tool call was dropped for
+	// exceeding the trace's limits; see SetLimits.
+	LimitExceeded string `json:"limit_exceeded,omitempty"`
+
+	mu     sync.Mutex
+	limits Limits
+	bytes  int
 }
 
+// Limits caps how large a trace may grow, so an agent stuck in a verbose
+// loop fails its case instead of exhausting memory. Zero fields are
+// unlimited.
+type Limits struct {
+	// MaxMessages caps the number of messages recorded.
+	MaxMessages int
+
+	// MaxBytes caps the total size of message contents and tool call
+	// responses recorded.
+	MaxBytes int
+}
+
+// DefaultLimits are generous enough for any reasonable agent run while
+// keeping a runaway case to a bounded share of memory.
+var DefaultLimits = Limits{MaxMessages: 1000, MaxBytes: 32 << 20}
+
+// ErrLimitExceeded is wrapped by the error LimitErr returns once a trace
+// has exceeded its limits.
+var ErrLimitExceeded = errors.New("trace limit exceeded")
+
 // Message records a single message in the conversation.
 type Message struct {
 	Role      string    `json:"role"`
@@ -36,6 +93,14 @@ type ToolCallTrace struct {
 	StartTime  time.Time              `json:"start_time"`
 	EndTime    time.Time              `json:"end_time"`
 	Duration   time.Duration          `json:"duration"`
+
+	// Hallucinated is set when the tool is not in the tool list the agent
+	// was given. Such calls are not resolved.
+	Hallucinated bool `json:"hallucinated,omitempty"`
+
+	// Violation is the error a tool call hook returned for the call: an
+	// invariant the call broke.
+	Violation string `json:"violation,omitempty"`
 }
 
 // TokenUsage tracks total token consumption across all API calls in a trace.
@@ -43,6 +108,11 @@ type TokenUsage struct {
 	InputTokens  int `json:"input_tokens"`
 	OutputTokens int `json:"output_tokens"`
 	TotalTokens  int `json:"total_tokens"`
+
+	// CacheReadTokens and CacheWriteTokens are the input tokens read from
+	// and written to the prompt cache, included in InputTokens.
+	CacheReadTokens  int `json:"cache_read_tokens,omitempty"`
+	CacheWriteTokens int `json:"cache_write_tokens,omitempty"`
 }
 
 // New creates a new AgentTrace and marks the start time.
@@ -52,10 +122,52 @@ func New() *AgentTrace {
 	}
 }
 
+// SetLimits caps the growth of the trace from now on. Once a limit is
+// exceeded, further messages and tool calls are dropped and LimitErr
+// reports why.
+func (t *AgentTrace) SetLimits(l Limits) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.limits = l
+}
+
+// LimitErr returns an error wrapping ErrLimitExceeded once the trace has
+// exceeded its limits, and nil before.
+func (t *AgentTrace) LimitErr() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.LimitExceeded == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrLimitExceeded, t.LimitExceeded)
+}
+
+// admit reports whether an entry of size bytes, adding messages messages,
+// fits within the limits, recording why not when it does not. t.mu must
+// be held.
+func (t *AgentTrace) admit(messages, size int) bool {
+	if t.LimitExceeded != "" {
+		return false
+	}
+	if n := t.limits.MaxMessages; n > 0 && len(t.Messages)+messages > n {
+		t.LimitExceeded = fmt.Sprintf("more than %d messages", n)
+		return false
+	}
+	if n := t.limits.MaxBytes; n > 0 && t.bytes+size > n {
+		t.LimitExceeded = fmt.Sprintf("more than %d bytes of messages and tool responses", n)
+		return false
+	}
+	t.bytes += size
+	return true
+}
+
 // AddMessage appends a message to the trace.
 func (t *AgentTrace) AddMessage(role, content string) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if !t.admit(1, len(content)) {
+		return
+	}
 	t.Messages = append(t.Messages, Message{
 		Role:      role,
 		Content:   content,
@@ -67,6 +179,9 @@ func (t *AgentTrace) AddMessage(role, content string) {
 func (t *AgentTrace) AddToolCall(tc ToolCallTrace) {
 	t.mu.Lock()
 	defer t.mu.Unlock()
+	if !t.admit(0, len(tc.Response)+len(tc.Error)) {
+		return
+	}
 	t.ToolCalls = append(t.ToolCalls, tc)
 }
 
@@ -79,6 +194,105 @@ func (t *AgentTrace) AddUsage(input, output int) {
 	t.Usage.TotalTokens += input + output
 }
 
+// AddCacheUsage accumulates the prompt cache reads and writes of a single
+// API call, whose input tokens AddUsage has already counted.
+func (t *AgentTrace) AddCacheUsage(read, write int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Usage.CacheReadTokens += read
+	t.Usage.CacheWriteTokens += write
+}
+
+// AddRetries accumulates retry telemetry from a single API call into the
+// trace totals.
+func (t *AgentTrace) AddRetries(retries int, backoff time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Retries += retries
+	t.BackoffTime += backoff
+}
+
+// AddOverloaded records n overloaded responses from a single API call.
+func (t *AgentTrace) AddOverloaded(n int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Overloaded += n
+}
+
+// AddModelVersion records the model version that served an API call.
+// Empty and already recorded versions are ignored.
+func (t *AgentTrace) AddModelVersion(version string) {
+	if version == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, v := range t.ModelVersions {
+		if v == version {
+			return
+		}
+	}
+	t.ModelVersions = append(t.ModelVersions, version)
+}
+
+// GetModelVersions returns a copy of the recorded model versions.
+func (t *AgentTrace) GetModelVersions() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.ModelVersions...)
+}
+
+// AddSystemFingerprint records the backend configuration that served an
+// API call. Empty and already recorded fingerprints are ignored.
+func (t *AgentTrace) AddSystemFingerprint(fp string) {
+	if fp == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, f := range t.SystemFingerprints {
+		if f == fp {
+			return
+		}
+	}
+	t.SystemFingerprints = append(t.SystemFingerprints, fp)
+}
+
+// GetSystemFingerprints returns a copy of the recorded system
+// fingerprints.
+func (t *AgentTrace) GetSystemFingerprints() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.SystemFingerprints...)
+}
+
+// AddProvider records the provider that served an API call through a
+// fallback chain after fallbacks other providers failed. An empty name,
+// for a call that did not go through a chain, is ignored.
+func (t *AgentTrace) AddProvider(name string, fallbacks int) {
+	if name == "" {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if fallbacks > 0 {
+		t.Fallbacks++
+	}
+	for _, p := range t.Providers {
+		if p == name {
+			return
+		}
+	}
+	t.Providers = append(t.Providers, name)
+}
+
+// AddReask records that the agent was re-asked because of reason.
+func (t *AgentTrace) AddReask(reason string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.Reasks = append(t.Reasks, reason)
+}
+
 // Finish marks the trace as complete and records the end time and duration.
 func (t *AgentTrace) Finish() {
 	t.mu.Lock()
@@ -105,6 +319,57 @@ func (t *AgentTrace) GetToolCalls() []ToolCallTrace {
 	return out
 }
 
+// HallucinatedTools returns the distinct names of the tools the agent
+// called that were not in its tool list, in the order first called.
+func (t *AgentTrace) HallucinatedTools() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var names []string
+	seen := make(map[string]bool)
+	for _, tc := range t.ToolCalls {
+		if tc.Hallucinated && !seen[tc.ToolName] {
+			seen[tc.ToolName] = true
+			names = append(names, tc.ToolName)
+		}
+	}
+	return names
+}
+
+// ToolViolations returns the violations tool call hooks reported, each as
+// the tool's name followed by the violation, in call order.
+func (t *AgentTrace) ToolViolations() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	var out []string
+	for _, tc := range t.ToolCalls {
+		if tc.Violation != "" {
+			out = append(out, tc.ToolName+": "+tc.Violation)
+		}
+	}
+	return out
+}
+
+// GetRetries returns the current retry count and total backoff time.
+func (t *AgentTrace) GetRetries() (int, time.Duration) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Retries, t.BackoffTime
+}
+
+// GetOverloaded returns the number of overloaded responses recorded.
+func (t *AgentTrace) GetOverloaded() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.Overloaded
+}
+
+// GetReasks returns a copy of the recorded re-ask reasons.
+func (t *AgentTrace) GetReasks() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]string(nil), t.Reasks...)
+}
+
 // GetUsage returns the current token usage totals.
 func (t *AgentTrace) GetUsage() TokenUsage {
 	t.mu.Lock()
@@ -112,6 +377,36 @@ func (t *AgentTrace) GetUsage() TokenUsage {
 	return t.Usage
 }
 
+// Normalize zeroes every wall-clock timestamp and duration in the trace so
+// that serialized traces are stable across runs. It also clears what
+// depends on the API's state rather than the outputs: retry telemetry,
+// the providers that served the calls and how many fell back, and system
+// fingerprints, which change whenever the vendor redeploys. Model
+// versions are kept, since a new model snapshot is a change a golden file
+// should show. It is intended for golden result files and should only be
+// called once the trace is finished.
+func (t *AgentTrace) Normalize() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.StartTime = time.Time{}
+	t.EndTime = time.Time{}
+	t.Duration = 0
+	t.Retries = 0
+	t.BackoffTime = 0
+	t.Overloaded = 0
+	t.Providers = nil
+	t.Fallbacks = 0
+	t.SystemFingerprints = nil
+	for i := range t.Messages {
+		t.Messages[i].Timestamp = time.Time{}
+	}
+	for i := range t.ToolCalls {
+		t.ToolCalls[i].StartTime = time.Time{}
+		t.ToolCalls[i].EndTime = time.Time{}
+		t.ToolCalls[i].Duration = 0
+	}
+}
+
 // JSON serializes the trace to indented JSON bytes.
 func (t *AgentTrace) JSON() ([]byte, error) {
 	t.mu.Lock()
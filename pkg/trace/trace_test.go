@@ -2,6 +2,8 @@ package trace
 
 import (
 	"encoding/json"
+	"errors"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -258,3 +260,75 @@ func TestConcurrentAccess(t *testing.T) {
 		t.Errorf("input_tokens = %d, want %d", usage.InputTokens, expectedInput)
 	}
 }
+
+func TestNormalize(t *testing.T) {
+	tr := New()
+	tr.AddMessage("user", "hi")
+	tr.AddToolCall(ToolCallTrace{
+		ToolName:  "lookup",
+		StartTime: time.Now(),
+		EndTime:   time.Now(),
+		Duration:  5 * time.Millisecond,
+	})
+	tr.AddUsage(3, 4)
+	tr.AddModelVersion("model-2025-01-01")
+	tr.AddSystemFingerprint("fp_1")
+	tr.AddProvider("backup", 1)
+	tr.Finish()
+	tr.Normalize()
+
+	if !tr.StartTime.IsZero() || !tr.EndTime.IsZero() || tr.Duration != 0 {
+		t.Errorf("trace times not zeroed: start=%v end=%v duration=%v", tr.StartTime, tr.EndTime, tr.Duration)
+	}
+	if !tr.GetMessages()[0].Timestamp.IsZero() {
+		t.Error("message timestamp not zeroed")
+	}
+	tc := tr.GetToolCalls()[0]
+	if !tc.StartTime.IsZero() || !tc.EndTime.IsZero() || tc.Duration != 0 {
+		t.Errorf("tool call times not zeroed: %+v", tc)
+	}
+	if tr.GetUsage().TotalTokens != 7 {
+		t.Errorf("usage changed by Normalize: %+v", tr.GetUsage())
+	}
+	if len(tr.Providers) != 0 || tr.Fallbacks != 0 || len(tr.GetSystemFingerprints()) != 0 {
+		t.Errorf("providers %v, fallbacks %d, fingerprints %v not cleared", tr.Providers, tr.Fallbacks, tr.GetSystemFingerprints())
+	}
+	if got := tr.GetModelVersions(); len(got) != 1 {
+		t.Errorf("model versions = %v, want them kept", got)
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	tr := New()
+	tr.SetLimits(Limits{MaxMessages: 3, MaxBytes: 100})
+
+	tr.AddMessage("user", "hi")
+	tr.AddToolCall(ToolCallTrace{ToolName: "search", Response: "ok"})
+	tr.AddMessage("assistant", "hello")
+	if err := tr.LimitErr(); err != nil {
+		t.Fatalf("LimitErr() within limits = %v", err)
+	}
+
+	tr.AddToolCall(ToolCallTrace{ToolName: "search", Response: string(make([]byte, 200))})
+	err := tr.LimitErr()
+	if !errors.Is(err, ErrLimitExceeded) {
+		t.Fatalf("LimitErr() = %v, want ErrLimitExceeded", err)
+	}
+	if got := len(tr.GetToolCalls()); got != 1 {
+		t.Errorf("tool calls = %d, want the oversized one dropped", got)
+	}
+
+	// Once exceeded, everything further is dropped.
+	tr.AddMessage("tool", "x")
+	if got := len(tr.GetMessages()); got != 2 {
+		t.Errorf("messages = %d, want 2", got)
+	}
+
+	tr = New()
+	tr.SetLimits(Limits{MaxMessages: 1})
+	tr.AddMessage("user", "a")
+	tr.AddMessage("assistant", "b")
+	if err := tr.LimitErr(); err == nil || !strings.Contains(err.Error(), "more than 1 messages") {
+		t.Errorf("LimitErr() = %v, want a message limit error", err)
+	}
+}